@@ -0,0 +1,92 @@
+package apitest
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+// conditionalGet 携带If-None-Match发送GET请求
+func conditionalGet(t *testing.T, h *Harness, path, etag string) *Response {
+	t.Helper()
+
+	req, err := http.NewRequest("GET", h.Server.URL+path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("If-None-Match", etag)
+	resp, err := h.Server.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	return &Response{Status: resp.StatusCode, Header: resp.Header}
+}
+
+func TestCatalogETag(t *testing.T) {
+	h := New(t)
+	teacher := h.User(t, "teacher")
+	course := h.Course(t, teacher, 99)
+	path := fmt.Sprintf("/api/courses/%d", course.ID)
+
+	first := h.Do(t, "GET", path, "", nil).Expect(t, http.StatusOK)
+	etag := first.Header.Get("ETag")
+	if etag == "" || first.Header.Get("Cache-Control") != "no-cache" {
+		t.Fatalf("expected ETag and Cache-Control headers, got %v", first.Header)
+	}
+
+	if resp := conditionalGet(t, h, path, etag); resp.Status != http.StatusNotModified || resp.Header.Get("ETag") != etag {
+		t.Fatalf("expected 304 with the same ETag, got %d %q", resp.Status, resp.Header.Get("ETag"))
+	}
+	if resp := conditionalGet(t, h, path, `"stale", W/`+etag); resp.Status != http.StatusNotModified {
+		t.Fatalf("expected a weak match in a list to return 304, got %d", resp.Status)
+	}
+
+	// 更新课程使缓存失效，内容和ETag随之变化
+	h.Do(t, "PUT", path, teacher.Token, map[string]interface{}{"title": "Go语言进阶", "status": 1}).Expect(t, http.StatusOK)
+	if resp := conditionalGet(t, h, path, etag); resp.Status != http.StatusOK || resp.Header.Get("ETag") == etag {
+		t.Fatalf("expected a new representation after the update, got %d %q", resp.Status, resp.Header.Get("ETag"))
+	}
+
+	// 错误响应不带ETag
+	if resp := h.Do(t, "GET", "/api/courses/404", "", nil); resp.Header.Get("ETag") != "" {
+		t.Fatalf("expected no ETag on %d", resp.Status)
+	}
+}
+
+func TestCategoryWritesInvalidateCatalog(t *testing.T) {
+	h := New(t)
+	admin := h.User(t, "admin")
+	course := h.Course(t, h.User(t, "teacher"), 0)
+
+	var categories struct {
+		Data []struct {
+			Name string `json:"name"`
+		} `json:"data"`
+	}
+	h.Do(t, "GET", "/api/course-categories", "", nil).Expect(t, http.StatusOK).Decode(t, &categories)
+	if len(categories.Data) != 1 {
+		t.Fatalf("expected 1 category, got %+v", categories.Data)
+	}
+	h.Do(t, "GET", fmt.Sprintf("/api/courses/%d", course.ID), "", nil).Expect(t, http.StatusOK)
+
+	h.Do(t, "PUT", fmt.Sprintf("/api/course-categories/%d", course.CategoryID), admin.Token, map[string]interface{}{"name": "后端开发"}).
+		Expect(t, http.StatusOK)
+
+	h.Do(t, "GET", "/api/course-categories", "", nil).Expect(t, http.StatusOK).Decode(t, &categories)
+	if len(categories.Data) != 1 || categories.Data[0].Name != "后端开发" {
+		t.Fatalf("expected the renamed category, got %+v", categories.Data)
+	}
+
+	var detail struct {
+		Data struct {
+			Category struct {
+				Name string `json:"name"`
+			} `json:"category"`
+		} `json:"data"`
+	}
+	h.Do(t, "GET", fmt.Sprintf("/api/courses/%d", course.ID), "", nil).Expect(t, http.StatusOK).Decode(t, &detail)
+	if detail.Data.Category.Name != "后端开发" {
+		t.Fatalf("expected the course detail to show the renamed category, got %q", detail.Data.Category.Name)
+	}
+}
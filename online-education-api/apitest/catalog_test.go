@@ -0,0 +1,114 @@
+package apitest
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"testing"
+
+	"online-education-api/models"
+)
+
+// catalogPage 课程列表接口返回的数据
+type catalogPage struct {
+	List []struct {
+		ID int64 `json:"id"`
+	} `json:"list"`
+	Total  int                 `json:"total"`
+	Facets models.CourseFacets `json:"facets"`
+}
+
+// listCourses 调用课程列表接口，返回课程ID
+func listCourses(t *testing.T, h *Harness, token string, params url.Values) ([]int64, catalogPage) {
+	t.Helper()
+
+	var body struct {
+		Data catalogPage `json:"data"`
+	}
+	h.Do(t, "GET", "/api/courses?"+params.Encode(), token, nil).Expect(t, http.StatusOK).Decode(t, &body)
+	var ids []int64
+	for _, c := range body.Data.List {
+		ids = append(ids, c.ID)
+	}
+	return ids, body.Data
+}
+
+func TestCourseListFiltersAndSorting(t *testing.T) {
+	h := New(t)
+	ctx := context.Background()
+	alice := h.User(t, "teacher")
+	bob := h.User(t, "teacher")
+
+	// 后端开发 > Go > 并发 三级分类
+	backend := h.Category(t)
+	golang := &models.CourseCategory{Name: "Go", ParentID: &backend.ID}
+	if err := h.Services.CourseCategory.CreateCategory(ctx, golang); err != nil {
+		t.Fatal(err)
+	}
+	concurrency := &models.CourseCategory{Name: "并发", ParentID: &golang.ID}
+	if err := h.Services.CourseCategory.CreateCategory(ctx, concurrency); err != nil {
+		t.Fatal(err)
+	}
+
+	create := func(teacher *Account, category int64, price, rating float64, students, status int) int64 {
+		course := &models.Course{Title: fmt.Sprintf("课程%d", h.next()), CategoryID: category, TeacherID: teacher.ID, Level: 1, Price: price, Rating: rating, StudentCount: students, Status: status}
+		if err := h.Services.Course.CreateCourse(ctx, course); err != nil {
+			t.Fatal(err)
+		}
+		return course.ID
+	}
+	free := create(alice, backend.ID, 0, 4.8, 300, 1)
+	cheap := create(alice, golang.ID, 49, 4.2, 1000, 1)
+	pricey := create(bob, concurrency.ID, 599, 3.5, 20, 1)
+	draft := create(bob, concurrency.ID, 99, 0, 0, 0)
+
+	ids, page := listCourses(t, h, "", url.Values{})
+	if page.Total != 3 || !slices.Equal(ids, []int64{pricey, cheap, free}) {
+		t.Fatalf("expected published courses newest first, got %v", ids)
+	}
+	if len(page.Facets.Prices) != 3 || len(page.Facets.Categories) != 3 || page.Facets.Levels[0] != (models.FacetCount{Value: "1", Count: 3}) {
+		t.Fatalf("unexpected facets %+v", page.Facets)
+	}
+
+	cases := []struct {
+		name   string
+		params url.Values
+		want   []int64
+	}{
+		{"popular", url.Values{"sort": {"popular"}}, []int64{cheap, free, pricey}},
+		{"rating", url.Values{"sort": {"rating"}}, []int64{free, cheap, pricey}},
+		{"price", url.Values{"sort": {"price_asc"}}, []int64{free, cheap, pricey}},
+		{"price range", url.Values{"minPrice": {"10"}, "maxPrice": {"100"}}, []int64{cheap}},
+		{"free", url.Values{"free": {"true"}}, []int64{free}},
+		{"teacher", url.Values{"teacherID": {fmt.Sprint(bob.ID)}}, []int64{pricey}},
+		{"rating floor", url.Values{"minRating": {"4"}, "sort": {"rating"}}, []int64{free, cheap}},
+		{"category", url.Values{"categoryID": {fmt.Sprint(golang.ID)}}, []int64{cheap}},
+		{"subcategories", url.Values{"categoryID": {fmt.Sprint(backend.ID)}, "includeSubcategories": {"true"}}, []int64{pricey, cheap, free}},
+	}
+	for _, c := range cases {
+		if ids, _ := listCourses(t, h, "", c.params); !slices.Equal(ids, c.want) {
+			t.Errorf("%s: got %v, want %v", c.name, ids, c.want)
+		}
+	}
+
+	h.Do(t, "GET", "/api/courses?sort=cheapest", "", nil).Expect(t, http.StatusBadRequest)
+	h.Do(t, "GET", "/api/courses?minRating=6", "", nil).Expect(t, http.StatusBadRequest)
+
+	// 未上架的课程只有课程的教师和管理员可以看到
+	if ids, _ := listCourses(t, h, alice.Token, url.Values{}); slices.Contains(ids, draft) {
+		t.Fatalf("expected another teacher not to see the draft, got %v", ids)
+	}
+	if ids, _ := listCourses(t, h, bob.Token, url.Values{}); !slices.Contains(ids, draft) {
+		t.Fatalf("expected the teacher to see their own draft, got %v", ids)
+	}
+	if _, page := listCourses(t, h, h.User(t, "admin").Token, url.Values{}); page.Total != 4 {
+		t.Fatalf("expected the admin to see all courses, got %d", page.Total)
+	}
+
+	path := fmt.Sprintf("/api/courses/%d", draft)
+	h.Do(t, "GET", path, "", nil).Expect(t, http.StatusNotFound)
+	h.Do(t, "GET", path, alice.Token, nil).Expect(t, http.StatusNotFound)
+	h.Do(t, "GET", path, bob.Token, nil).Expect(t, http.StatusOK)
+}
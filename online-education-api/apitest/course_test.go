@@ -0,0 +1,125 @@
+package apitest
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestCourseDetailAndEnroll(t *testing.T) {
+	h := New(t)
+	teacher := h.User(t, "teacher")
+	student := h.User(t, "student")
+	course := h.Course(t, teacher, 0,
+		Chapter("第二章", "2-1"),
+		Chapter("第一章", "1-1", "1-2"),
+	)
+
+	var detail struct {
+		Data struct {
+			TeacherID    int64 `json:"teacher_id"`
+			TotalLessons int   `json:"total_lessons"`
+			Chapters     []struct {
+				Title   string `json:"title"`
+				Lessons []struct {
+					Title string `json:"title"`
+				} `json:"lessons"`
+			} `json:"chapters"`
+		} `json:"data"`
+	}
+	h.Do(t, "GET", fmt.Sprintf("/api/courses/%d", course.ID), "", nil).Expect(t, http.StatusOK).Decode(t, &detail)
+	if detail.Data.TeacherID != teacher.ID || detail.Data.TotalLessons != 3 || len(detail.Data.Chapters) != 2 {
+		t.Fatalf("unexpected course detail %+v", detail.Data)
+	}
+	if detail.Data.Chapters[0].Title != "第二章" || detail.Data.Chapters[1].Lessons[1].Title != "1-2" {
+		t.Fatalf("unexpected chapter order %+v", detail.Data.Chapters)
+	}
+
+	path := fmt.Sprintf("/api/user-courses/%d", course.ID)
+	h.Do(t, "POST", path, "", nil).Expect(t, http.StatusUnauthorized)
+	h.Do(t, "POST", path, student.Token, nil).Expect(t, http.StatusOK)
+	h.Do(t, "POST", path, student.Token, nil).Expect(t, http.StatusInternalServerError)
+	h.Do(t, "POST", "/api/user-courses/404", student.Token, nil).Expect(t, http.StatusInternalServerError)
+
+	var mine struct {
+		Data struct {
+			Total int64 `json:"total"`
+		} `json:"data"`
+	}
+	h.Do(t, "GET", "/api/user-courses", student.Token, nil).Expect(t, http.StatusOK).Decode(t, &mine)
+	if mine.Data.Total != 1 {
+		t.Fatalf("expected 1 enrollment, got %d", mine.Data.Total)
+	}
+
+	// 其他用户看不到该学生的报名记录
+	h.Do(t, "GET", path, teacher.Token, nil).Expect(t, http.StatusInternalServerError)
+
+	h.Do(t, "DELETE", path, student.Token, nil).Expect(t, http.StatusOK)
+	h.Do(t, "GET", "/api/user-courses", student.Token, nil).Expect(t, http.StatusOK).Decode(t, &mine)
+	if mine.Data.Total != 0 {
+		t.Fatalf("expected no enrollments after unenrolling, got %d", mine.Data.Total)
+	}
+}
+
+func TestOnlyTeacherCanChangeCourse(t *testing.T) {
+	h := New(t)
+	owner := h.User(t, "teacher")
+	other := h.User(t, "teacher")
+	course := h.Course(t, owner, 99)
+	path := fmt.Sprintf("/api/courses/%d", course.ID)
+
+	h.Do(t, "PUT", path, other.Token, map[string]interface{}{"title": "抢来的课程", "status": 1}).
+		Expect(t, http.StatusForbidden)
+	h.Do(t, "DELETE", path, other.Token, nil).Expect(t, http.StatusForbidden)
+
+	h.Do(t, "PUT", path, owner.Token, map[string]interface{}{"title": "Go语言进阶", "status": 1}).
+		Expect(t, http.StatusOK)
+
+	var detail struct {
+		Data struct {
+			Title string `json:"title"`
+		} `json:"data"`
+	}
+	h.Do(t, "GET", path, "", nil).Expect(t, http.StatusOK).Decode(t, &detail)
+	if detail.Data.Title != "Go语言进阶" {
+		t.Fatalf("expected the owner's update, got %q", detail.Data.Title)
+	}
+
+	// 只修改标题时保持上架状态
+	h.Do(t, "PUT", path, owner.Token, map[string]interface{}{"title": "Go语言实战"}).Expect(t, http.StatusOK)
+	h.Do(t, "GET", path, "", nil).Expect(t, http.StatusOK).Decode(t, &detail)
+	if detail.Data.Title != "Go语言实战" {
+		t.Fatalf("expected the title-only update, got %q", detail.Data.Title)
+	}
+	var list struct {
+		Data struct {
+			Total int64 `json:"total"`
+		} `json:"data"`
+	}
+	h.Do(t, "GET", "/api/courses?search="+url.QueryEscape("Go语言实战"), "", nil).Expect(t, http.StatusOK).Decode(t, &list)
+	if list.Data.Total != 1 {
+		t.Fatalf("expected the course to stay published, got %d in the public list", list.Data.Total)
+	}
+
+	// 显式下架后公开访问返回404
+	h.Do(t, "PUT", path, owner.Token, map[string]interface{}{"status": 0}).Expect(t, http.StatusOK)
+	h.Do(t, "GET", path, "", nil).Expect(t, http.StatusNotFound)
+	h.Do(t, "PUT", path, owner.Token, map[string]interface{}{"status": 2}).Expect(t, http.StatusBadRequest)
+}
+
+func TestOnlyAuthorCanChangePost(t *testing.T) {
+	h := New(t)
+	author := h.User(t, "student")
+	other := h.User(t, "student")
+	post := h.Post(t, author)
+	path := fmt.Sprintf("/api/posts/%d", post.ID)
+
+	h.Do(t, "PUT", path, other.Token, map[string]interface{}{"title": "被修改的标题", "status": 1}).
+		Expect(t, http.StatusForbidden)
+	h.Do(t, "DELETE", path, other.Token, nil).Expect(t, http.StatusForbidden)
+
+	h.Do(t, "PUT", path, author.Token, map[string]interface{}{"title": "作者修改的标题", "status": 1}).
+		Expect(t, http.StatusOK)
+	h.Do(t, "DELETE", path, author.Token, nil).Expect(t, http.StatusOK)
+}
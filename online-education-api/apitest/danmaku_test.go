@@ -0,0 +1,87 @@
+package apitest
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"online-education-api/models"
+)
+
+// dialDanmaku 连接视频的弹幕推送，token不为空时携带Bearer认证头
+func dialDanmaku(h *Harness, videoID int, token string) (*websocket.Conn, *http.Response, error) {
+	url := "ws" + strings.TrimPrefix(h.Server.URL, "http") + "/api/videos/" + strconv.Itoa(videoID) + "/danmaku/ws"
+	header := http.Header{}
+	if token != "" {
+		header.Set("Authorization", "Bearer "+token)
+	}
+	return websocket.DefaultDialer.Dial(url, header)
+}
+
+func TestDanmaku(t *testing.T) {
+	h := New(t)
+	author := h.User(t, "teacher")
+	viewer := h.User(t, "student")
+	video := createVideo(t, h, author.Token, map[string]interface{}{"title": "弹幕", "duration": 120})
+	private := createVideo(t, h, author.Token, map[string]interface{}{"title": "私有", "visibility": "private"})
+	path := "/api/videos/" + strconv.Itoa(video.ID) + "/danmaku"
+
+	// 私有视频的弹幕对其他用户不存在
+	if _, resp, err := dialDanmaku(h, private.ID, ""); err == nil || resp == nil || resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("dial private video err = %v, want 404", err)
+	}
+	conn, _, err := dialDanmaku(h, video.ID, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	h.Do(t, "POST", path, "", map[string]interface{}{"content": "匿名"}).Expect(t, http.StatusUnauthorized)
+	h.Do(t, "POST", path, viewer.Token, map[string]interface{}{"content": "太快了", "offset": 130}).Expect(t, http.StatusBadRequest)
+	h.Do(t, "POST", path, viewer.Token, map[string]interface{}{"content": "x", "mode": "middle"}).Expect(t, http.StatusBadRequest)
+
+	var created struct {
+		Data models.Danmaku `json:"data"`
+	}
+	h.Do(t, "POST", path, viewer.Token, map[string]interface{}{"content": "前方高能", "offset": 12.5, "color": "#00ff00", "mode": "top"}).
+		Expect(t, http.StatusCreated).Decode(t, &created)
+	h.Do(t, "POST", path, viewer.Token, map[string]interface{}{"content": "前方高能", "offset": 13}).Expect(t, http.StatusConflict)
+
+	// 正在观看的用户收到新弹幕和删除消息
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	var msg models.DanmakuMessage
+	if err := conn.ReadJSON(&msg); err != nil {
+		t.Fatal(err)
+	}
+	if msg.Type != models.DanmakuMessageCreate || msg.Danmaku == nil || msg.Danmaku.ID != created.Data.ID || msg.Danmaku.Color != "#00FF00" {
+		t.Fatalf("unexpected message %+v", msg)
+	}
+
+	var list struct {
+		Data []models.Danmaku `json:"data"`
+	}
+	h.Do(t, "GET", path+"?from=10&to=20", "", nil).Expect(t, http.StatusOK).Decode(t, &list)
+	if len(list.Data) != 1 || list.Data[0].Offset != 12.5 || list.Data[0].Mode != models.DanmakuModeTop {
+		t.Fatalf("unexpected danmaku %+v", list.Data)
+	}
+	h.Do(t, "GET", path+"?from=20&to=30", "", nil).Expect(t, http.StatusOK).Decode(t, &list)
+	if len(list.Data) != 0 {
+		t.Fatalf("danmaku outside range %+v", list.Data)
+	}
+	h.Do(t, "GET", path+"?from=abc", "", nil).Expect(t, http.StatusBadRequest)
+
+	deletePath := path + "/" + strconv.FormatInt(created.Data.ID, 10)
+	other := h.User(t, "student")
+	h.Do(t, "DELETE", deletePath, other.Token, nil).Expect(t, http.StatusForbidden)
+	h.Do(t, "DELETE", deletePath, author.Token, nil).Expect(t, http.StatusNoContent)
+	if err := conn.ReadJSON(&msg); err != nil {
+		t.Fatal(err)
+	}
+	if msg.Type != models.DanmakuMessageDelete || msg.ID != created.Data.ID {
+		t.Fatalf("unexpected message %+v", msg)
+	}
+}
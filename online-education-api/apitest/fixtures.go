@@ -0,0 +1,122 @@
+package apitest
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"online-education-api/models"
+	"online-education-api/utils"
+)
+
+// DefaultPassword 夹具用户的登录密码
+const DefaultPassword = "secret123"
+
+// Account 测试用户及其登录令牌
+type Account struct {
+	*models.User
+	Password string
+	Token    string
+}
+
+// next 返回下一个夹具序号
+func (h *Harness) next() int {
+	h.seq++
+	return h.seq
+}
+
+// User 创建指定角色的用户并签发令牌，role为admin、teacher或student
+func (h *Harness) User(t testing.TB, role string) *Account {
+	t.Helper()
+
+	n := h.next()
+	user, err := h.Services.User.CreateUser(context.Background(), &models.UserCreateRequest{
+		Username: fmt.Sprintf("%s%d", role, n),
+		Email:    fmt.Sprintf("%s%d@example.com", role, n),
+		Password: DefaultPassword,
+		Role:     role,
+	})
+	if err != nil {
+		t.Fatalf("创建用户失败: %v", err)
+	}
+	return &Account{User: user, Password: DefaultPassword, Token: Token(t, user.ID, user.Username, role)}
+}
+
+// Token 签发JWT令牌，用户不必存在，可用于测试令牌中的角色和身份
+func Token(t testing.TB, userID int64, username, role string) string {
+	t.Helper()
+
+	token, err := utils.GenerateToken(userID, username, role)
+	if err != nil {
+		t.Fatalf("签发令牌失败: %v", err)
+	}
+	return token
+}
+
+// Category 创建一级课程分类
+func (h *Harness) Category(t testing.TB) *models.CourseCategory {
+	t.Helper()
+
+	category := &models.CourseCategory{Name: fmt.Sprintf("分类%d", h.next())}
+	if err := h.Services.CourseCategory.CreateCategory(context.Background(), category); err != nil {
+		t.Fatalf("创建分类失败: %v", err)
+	}
+	return category
+}
+
+// Course 为教师创建一门已上架的课程，同时创建分类并按参数顺序导入给定章节
+func (h *Harness) Course(t testing.TB, teacher *Account, price float64, chapters ...*models.Chapter) *models.Course {
+	t.Helper()
+
+	for i, chapter := range chapters {
+		chapter.SortOrder = i + 1
+	}
+
+	course := &models.Course{
+		Title:      fmt.Sprintf("课程%d", h.next()),
+		Price:      price,
+		CategoryID: h.Category(t).ID,
+		TeacherID:  teacher.ID,
+		Level:      1,
+		Status:     1,
+	}
+	if err := h.Services.Course.ImportCourse(context.Background(), course, chapters); err != nil {
+		t.Fatalf("创建课程失败: %v", err)
+	}
+	return course
+}
+
+// Chapter 构造一个未保存的章节，课时按参数顺序排序，由 Course 导入
+func Chapter(title string, lessons ...string) *models.Chapter {
+	chapter := &models.Chapter{Title: title}
+	for i, lesson := range lessons {
+		chapter.Lessons = append(chapter.Lessons, &models.Lesson{
+			Title:     lesson,
+			VideoURL:  fmt.Sprintf("https://cdn.example.com/%s.mp4", lesson),
+			SortOrder: i + 1,
+		})
+	}
+	return chapter
+}
+
+// Payment 为用户创建待支付的课程订单
+func (h *Harness) Payment(t testing.TB, user *Account, course *models.Course) *models.Payment {
+	t.Helper()
+
+	payment, err := h.Services.Payment.CreatePayment(context.Background(), user.ID, course.ID, "alipay")
+	if err != nil {
+		t.Fatalf("创建支付订单失败: %v", err)
+	}
+	return payment
+}
+
+// Post 为作者发布一篇帖子
+func (h *Harness) Post(t testing.TB, author *Account) *models.Post {
+	t.Helper()
+
+	post := &models.Post{UserID: author.ID, Title: fmt.Sprintf("帖子%d", h.next()), Content: "这是一篇用于测试的帖子内容", Status: 1}
+	if err := h.Services.Post.CreatePost(context.Background(), post); err != nil {
+		t.Fatalf("创建帖子失败: %v", err)
+	}
+	return post
+}
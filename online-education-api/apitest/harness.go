@@ -0,0 +1,190 @@
+// Package apitest 端到端API测试工具
+//
+// New 基于一份独立的内存存储创建全部服务，用 routes.SetupRoutes 组装路由并启动
+// httptest 服务器，请求经过与 main 相同的中间件链。每个测试使用自己的存储，
+// 测试之间互不影响，运行时不需要MySQL或网络。
+package apitest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"online-education-api/cache"
+	"online-education-api/config"
+	"online-education-api/controllers"
+	"online-education-api/middleware"
+	"online-education-api/repository"
+	"online-education-api/repository/memory"
+	"online-education-api/routes"
+	"online-education-api/services"
+	"online-education-api/storage"
+	"online-education-api/transcode"
+	"online-education-api/urlsign"
+)
+
+// MaxUploadSize 测试服务器允许上传的最大文件字节数
+const MaxUploadSize = 1 << 20
+
+// Harness 一次测试使用的服务器、服务和存储
+type Harness struct {
+	Server   *httptest.Server
+	Services *services.Services
+	Repos    *repository.Repositories
+	// Transcoder 测试服务器使用的转码执行器，不启动后台转码任务，需要时调用 Services.Transcode.ProcessPending
+	Transcoder *transcode.Fake
+	// Signer 签名本地存储中视频文件的访问地址，用于测试直接下载受保护的文件
+	Signer *urlsign.Signer
+	// Readiness 就绪检查使用的存储，调用 Readiness.Fail 模拟数据库不可用
+	Readiness *Readiness
+
+	// seq 生成唯一的夹具名称
+	seq int
+}
+
+// New 创建使用独立内存存储的测试服务器，测试结束时自动关闭
+// 与生产环境一样启用课程目录缓存，夹具通过服务写入数据，缓存会随之失效
+func New(t testing.TB) *Harness {
+	t.Helper()
+
+	repos := memory.New()
+	readiness := &Readiness{Pinger: repos.Health}
+	repos.Health = readiness
+	files := storage.NewLocal(t.TempDir(), "/media")
+	svc := services.New(repos, files, &config.UploadConfig{MaxSize: MaxUploadSize, TempDir: t.TempDir()})
+	svc.UseCache(cache.NewLRU(1000), time.Minute)
+	transcoder := &transcode.Fake{}
+	if err := svc.UseTranscoder(transcoder, &config.TranscodeConfig{WorkDir: t.TempDir()}); err != nil {
+		t.Fatal(err)
+	}
+	signer := urlsign.New([]byte("apitest"), "/media", time.Hour, false)
+	svc.UseSigner(signer)
+
+	r := routes.SetupRoutes(
+		controllers.NewVideoController(svc.Video, svc.Transcode, svc.Playback, svc.Analytics, svc.Playlist),
+		controllers.NewUserController(svc.User),
+		controllers.NewCourseCategoryController(svc.CourseCategory),
+		controllers.NewCourseController(svc.Course, svc.Playback),
+		controllers.NewUserCourseController(svc.UserCourse),
+		controllers.NewPostController(svc.Post),
+		controllers.NewPaymentController(svc.Payment),
+		controllers.NewCommentController(svc.Comment),
+		controllers.NewHealthController(svc.Health),
+		controllers.NewSearchController(svc.Search),
+		controllers.NewReviewController(svc.Review),
+		controllers.NewUploadController(svc.Upload),
+		controllers.NewImageController(svc.Image),
+		controllers.NewDanmakuController(svc.Danmaku),
+		controllers.NewSubtitleController(svc.Subtitle),
+		controllers.NewPlaylistController(svc.Playlist),
+		controllers.NewVideoCategoryController(svc.Video),
+	)
+	routes.MountMedia(r, files, signer)
+	handler := middleware.RequestIDMiddleware(middleware.AccessLogMiddleware(middleware.CORSMiddleware(middleware.MetricsHandler(r))))
+
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	return &Harness{Server: srv, Services: svc, Repos: repos, Transcoder: transcoder, Signer: signer, Readiness: readiness}
+}
+
+// Readiness 包装内存存储的就绪检查，可以模拟存储不可用
+type Readiness struct {
+	repository.Pinger
+
+	mu  sync.Mutex
+	err error
+}
+
+// Fail 设置就绪检查返回的错误，为nil时恢复正常
+func (p *Readiness) Fail(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.err = err
+}
+
+// Ping 返回 Fail 设置的错误，未设置时检查内存存储
+func (p *Readiness) Ping(ctx context.Context) error {
+	p.mu.Lock()
+	err := p.err
+	p.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	return p.Pinger.Ping(ctx)
+}
+
+// Response 测试请求的响应，响应体已完整读取
+type Response struct {
+	Status int
+	Header http.Header
+	Body   []byte
+}
+
+// Do 发送请求，body不为nil时编码为JSON，token不为空时携带Bearer认证头
+func (h *Harness) Do(t testing.TB, method, path, token string, body interface{}) *Response {
+	t.Helper()
+
+	var reader io.Reader
+	header := http.Header{}
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			t.Fatalf("编码请求体失败: %v", err)
+		}
+		reader = bytes.NewReader(data)
+		header.Set("Content-Type", "application/json")
+	}
+	return h.Send(t, method, path, token, header, reader)
+}
+
+// Send 发送任意请求体的请求，用于上传文件等非JSON请求
+func (h *Harness) Send(t testing.TB, method, path, token string, header http.Header, body io.Reader) *Response {
+	t.Helper()
+
+	req, err := http.NewRequest(method, h.Server.URL+path, body)
+	if err != nil {
+		t.Fatalf("创建请求失败: %v", err)
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := h.Server.Client().Do(req)
+	if err != nil {
+		t.Fatalf("%s %s 失败: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("读取响应体失败: %v", err)
+	}
+	return &Response{Status: resp.StatusCode, Header: resp.Header, Body: data}
+}
+
+// Expect 检查响应状态码，不一致时输出响应体并终止测试
+func (r *Response) Expect(t testing.TB, status int) *Response {
+	t.Helper()
+	if r.Status != status {
+		t.Fatalf("期望状态码%d，实际为%d: %s", status, r.Status, bytes.TrimSpace(r.Body))
+	}
+	return r
+}
+
+// Decode 将响应体解析为JSON
+func (r *Response) Decode(t testing.TB, v interface{}) {
+	t.Helper()
+	if err := json.Unmarshal(r.Body, v); err != nil {
+		t.Fatalf("解析响应体失败: %v: %s", err, bytes.TrimSpace(r.Body))
+	}
+}
@@ -0,0 +1,85 @@
+package apitest
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	_ "github.com/go-sql-driver/mysql"
+
+	"online-education-api/metrics"
+)
+
+func TestHealthAndReadiness(t *testing.T) {
+	h := New(t)
+
+	var health struct {
+		Status string `json:"status"`
+		Error  string `json:"error"`
+	}
+	h.Do(t, "GET", "/healthz", "", nil).Expect(t, http.StatusOK).Decode(t, &health)
+	if health.Status != "ok" {
+		t.Errorf("healthz status = %q", health.Status)
+	}
+	h.Do(t, "GET", "/readyz", "", nil).Expect(t, http.StatusOK).Decode(t, &health)
+	if health.Status != "ready" {
+		t.Errorf("readyz status = %q", health.Status)
+	}
+
+	// 数据库不可用时只有就绪检查失败，存活检查不受影响
+	h.Readiness.Fail(errors.New("connection refused"))
+	health.Status = ""
+	h.Do(t, "GET", "/readyz", "", nil).Expect(t, http.StatusServiceUnavailable).Decode(t, &health)
+	if health.Status != "unavailable" || !strings.Contains(health.Error, "connection refused") {
+		t.Errorf("unexpected readyz response %+v", health)
+	}
+	h.Do(t, "GET", "/healthz", "", nil).Expect(t, http.StatusOK)
+
+	h.Readiness.Fail(nil)
+	h.Do(t, "GET", "/readyz", "", nil).Expect(t, http.StatusOK)
+}
+
+func TestMetricsEndpoint(t *testing.T) {
+	h := New(t)
+
+	// 连接池统计不需要连接数据库
+	db, err := sql.Open("mysql", "apitest:apitest@tcp(127.0.0.1:1)/apitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	db.SetMaxOpenConns(7)
+	metrics.RegisterDBStats(db)
+
+	h.Do(t, "GET", "/healthz", "", nil).Expect(t, http.StatusOK)
+	// 没有匹配的路由和不允许的方法也计入，路由记为unknown
+	h.Do(t, "GET", "/no-such-route", "", nil).Expect(t, http.StatusNotFound)
+	h.Do(t, "DELETE", "/healthz", "", nil).Expect(t, http.StatusMethodNotAllowed)
+
+	resp := h.Do(t, "GET", "/metrics", "", nil).Expect(t, http.StatusOK)
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/plain") {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	body := string(resp.Body)
+	for _, want := range []string{
+		"# TYPE http_requests_total counter\n",
+		`http_requests_total{method="GET",route="/healthz",status="200"} `,
+		`http_requests_total{method="GET",route="unknown",status="404"} `,
+		`http_requests_total{method="DELETE",route="unknown",status="405"} `,
+		"# TYPE http_request_duration_seconds histogram\n",
+		`http_request_duration_seconds_bucket{method="GET",route="/healthz",le="+Inf"} `,
+		`http_request_duration_seconds_count{method="GET",route="unknown"} `,
+		"# TYPE db_pool_max_open_connections gauge\ndb_pool_max_open_connections 7\n",
+		"db_pool_open_connections 0\n",
+		"db_pool_in_use_connections 0\n",
+		"db_pool_idle_connections 0\n",
+		"db_pool_wait_count 0\n",
+		"db_pool_wait_duration_seconds 0\n",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics missing %q", want)
+		}
+	}
+}
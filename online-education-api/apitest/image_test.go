@@ -0,0 +1,89 @@
+package apitest
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"testing"
+
+	"online-education-api/models"
+)
+
+// imageBody 构造带替代文本和图片文件的表单
+func imageBody(t *testing.T, altText string, width, height int) (http.Header, *bytes.Buffer) {
+	t.Helper()
+
+	var data bytes.Buffer
+	if err := png.Encode(&data, image.NewGray(image.Rect(0, 0, width, height))); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	mw.WriteField("alt_text", altText)
+	fw, err := mw.CreateFormFile("file", "cover.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fw.Write(data.Bytes())
+	mw.Close()
+	return http.Header{"Content-Type": {mw.FormDataContentType()}}, &buf
+}
+
+func TestImageUploadAndAttach(t *testing.T) {
+	h := New(t)
+	teacher := h.User(t, "teacher")
+	student := h.User(t, "student")
+	course := h.Course(t, teacher, 0)
+
+	header, body := imageBody(t, "课程封面", 1000, 500)
+	h.Send(t, "POST", "/api/images", "", header, body).Expect(t, http.StatusUnauthorized)
+
+	header, body = imageBody(t, "课程封面", 1000, 500)
+	var created struct {
+		Data models.Image `json:"data"`
+	}
+	h.Send(t, "POST", "/api/images", teacher.Token, header, body).Expect(t, http.StatusCreated).Decode(t, &created)
+	img := created.Data
+	if img.Width != 1000 || img.Height != 500 || img.AltText != "课程封面" || len(img.Thumbnails) != 3 {
+		t.Fatalf("unexpected image %+v", img)
+	}
+	h.Send(t, "GET", img.Thumbnails["small"], "", nil, nil).Expect(t, http.StatusOK)
+
+	header, body = multipartBody(t, "notes.txt", []byte("plain text"))
+	h.Send(t, "POST", "/api/images", teacher.Token, header, body).Expect(t, http.StatusUnsupportedMediaType)
+
+	path := fmt.Sprintf("/api/images/%d", img.ID)
+	h.Do(t, "PUT", path, student.Token, map[string]string{"alt_text": "x"}).Expect(t, http.StatusForbidden)
+	h.Do(t, "PUT", path, teacher.Token, map[string]string{"alt_text": "新封面"}).Expect(t, http.StatusOK)
+
+	// 先读取课程详情写入缓存，关联封面后缓存失效
+	h.Do(t, "GET", fmt.Sprintf("/api/courses/%d", course.ID), "", nil).Expect(t, http.StatusOK)
+	attach := map[string]interface{}{"entity_type": "course", "entity_id": course.ID, "is_primary": true}
+	h.Do(t, "POST", path+"/attach", student.Token, attach).Expect(t, http.StatusForbidden)
+	h.Do(t, "POST", path+"/attach", teacher.Token, map[string]interface{}{"entity_type": "lesson", "entity_id": 1}).Expect(t, http.StatusBadRequest)
+	h.Do(t, "POST", path+"/attach", teacher.Token, attach).Expect(t, http.StatusOK)
+
+	var detail struct {
+		Data models.CourseDetailResponse `json:"data"`
+	}
+	h.Do(t, "GET", fmt.Sprintf("/api/courses/%d", course.ID), "", nil).Expect(t, http.StatusOK).Decode(t, &detail)
+	if detail.Data.CoverImage != img.URL {
+		t.Errorf("cover_image = %q, want %q", detail.Data.CoverImage, img.URL)
+	}
+
+	var list struct {
+		Data []models.Image `json:"data"`
+	}
+	h.Do(t, "GET", fmt.Sprintf("/api/images?entity_type=course&entity_id=%d", course.ID), "", nil).Expect(t, http.StatusOK).Decode(t, &list)
+	if len(list.Data) != 1 || !list.Data[0].IsPrimary || list.Data[0].AltText != "新封面" {
+		t.Fatalf("unexpected images %+v", list.Data)
+	}
+
+	h.Do(t, "DELETE", path, student.Token, nil).Expect(t, http.StatusForbidden)
+	h.Do(t, "DELETE", path, teacher.Token, nil).Expect(t, http.StatusNoContent)
+	h.Do(t, "GET", path, "", nil).Expect(t, http.StatusNotFound)
+	h.Send(t, "GET", img.URL, "", nil, nil).Expect(t, http.StatusNotFound)
+}
@@ -0,0 +1,78 @@
+package apitest
+
+import (
+	"net/http"
+	"net/url"
+	"slices"
+	"testing"
+)
+
+// postsPage 帖子列表接口返回的数据
+type postsPage struct {
+	List []struct {
+		ID int64 `json:"id"`
+	} `json:"list"`
+	PageSize   int    `json:"pageSize"`
+	Total      *int   `json:"total"`
+	NextCursor string `json:"next_cursor"`
+}
+
+// listPosts 调用帖子列表接口
+func listPosts(t *testing.T, h *Harness, params url.Values) postsPage {
+	t.Helper()
+
+	var body struct {
+		Data postsPage `json:"data"`
+	}
+	h.Do(t, "GET", "/api/posts?"+params.Encode(), "", nil).Expect(t, http.StatusOK).Decode(t, &body)
+	return body.Data
+}
+
+func TestPostListCursorPagination(t *testing.T) {
+	h := New(t)
+	author := h.User(t, "student")
+	var want []int64
+	for range 5 {
+		want = append(want, h.Post(t, author).ID)
+	}
+	slices.Reverse(want)
+
+	// 按游标逐页读取，每篇帖子恰好出现一次且按发布时间倒序
+	var got []int64
+	params := url.Values{"pageSize": {"2"}, "skipTotal": {"true"}}
+	for pages := 0; ; pages++ {
+		if pages > len(want) {
+			t.Fatalf("cursor pagination did not terminate, got %v", got)
+		}
+		page := listPosts(t, h, params)
+		if page.Total != nil {
+			t.Fatalf("expected no total with skipTotal, got %d", *page.Total)
+		}
+		for _, post := range page.List {
+			got = append(got, post.ID)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		params.Set("cursor", page.NextCursor)
+
+		// 游标之后发布的帖子排在前面，不影响后续页
+		if pages == 0 {
+			h.Post(t, author)
+		}
+	}
+	if !slices.Equal(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	// 页码分页仍然可用，并返回总数
+	page := listPosts(t, h, url.Values{"page": {"2"}, "pageSize": {"4"}})
+	if page.Total == nil || *page.Total != 6 || len(page.List) != 2 || page.NextCursor != "" {
+		t.Fatalf("unexpected offset page %+v", page)
+	}
+
+	if page := listPosts(t, h, url.Values{"pageSize": {"1000000"}}); page.PageSize != 100 {
+		t.Fatalf("expected pageSize to be capped at 100, got %d", page.PageSize)
+	}
+	h.Do(t, "GET", "/api/posts?cursor=bogus", "", nil).Expect(t, http.StatusBadRequest)
+}
@@ -0,0 +1,100 @@
+package apitest
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+// paymentStatus 查询订单的支付状态
+func paymentStatus(t *testing.T, h *Harness, orderID string) string {
+	t.Helper()
+
+	var resp struct {
+		Data struct {
+			Status string `json:"status"`
+		} `json:"data"`
+	}
+	h.Do(t, "GET", "/api/payments/status/"+orderID, "", nil).Expect(t, http.StatusOK).Decode(t, &resp)
+	return resp.Data.Status
+}
+
+func TestPayAndNotifyEnrollsStudent(t *testing.T) {
+	h := New(t)
+	teacher := h.User(t, "teacher")
+	student := h.User(t, "student")
+	course := h.Course(t, teacher, 199, Chapter("第一章", "1-1"))
+
+	var created struct {
+		Data struct {
+			OrderID string  `json:"order_id"`
+			Amount  float64 `json:"amount"`
+		} `json:"data"`
+	}
+	h.Do(t, "POST", "/api/payments", student.Token, map[string]interface{}{"course_id": course.ID, "payment_method": "wechat"}).
+		Expect(t, http.StatusOK).Decode(t, &created)
+	if created.Data.OrderID == "" || created.Data.Amount != 199 {
+		t.Fatalf("unexpected payment %+v", created.Data)
+	}
+	if status := paymentStatus(t, h, created.Data.OrderID); status != "pending" {
+		t.Fatalf("expected pending, got %q", status)
+	}
+
+	enrollment := fmt.Sprintf("/api/user-courses/%d", course.ID)
+	h.Do(t, "GET", enrollment, student.Token, nil).Expect(t, http.StatusInternalServerError)
+
+	h.Do(t, "POST", "/api/payments/notify", "", map[string]string{"order_id": created.Data.OrderID, "status": "completed"}).
+		Expect(t, http.StatusBadRequest)
+	h.Do(t, "POST", "/api/payments/notify", "", map[string]interface{}{
+		"order_id":       created.Data.OrderID,
+		"transaction_id": "TX-1001",
+		"status":         "completed",
+		"sign":           "ignored",
+	}).Expect(t, http.StatusOK)
+
+	if status := paymentStatus(t, h, created.Data.OrderID); status != "completed" {
+		t.Fatalf("expected completed, got %q", status)
+	}
+	h.Do(t, "GET", enrollment, student.Token, nil).Expect(t, http.StatusOK)
+
+	var payments struct {
+		Data struct {
+			Total int64 `json:"total"`
+		} `json:"data"`
+	}
+	h.Do(t, "GET", "/api/payments/user", student.Token, nil).Expect(t, http.StatusOK).Decode(t, &payments)
+	if payments.Data.Total != 1 {
+		t.Fatalf("expected 1 payment, got %d", payments.Data.Total)
+	}
+	h.Do(t, "GET", "/api/payments/user", teacher.Token, nil).Expect(t, http.StatusOK).Decode(t, &payments)
+	if payments.Data.Total != 0 {
+		t.Fatalf("expected the teacher to see no payments, got %d", payments.Data.Total)
+	}
+}
+
+func TestFailedPaymentDoesNotEnroll(t *testing.T) {
+	h := New(t)
+	student := h.User(t, "student")
+	course := h.Course(t, h.User(t, "teacher"), 59)
+	payment := h.Payment(t, student, course)
+
+	h.Do(t, "POST", "/api/payments/notify", "", map[string]string{
+		"order_id":       payment.OrderID,
+		"transaction_id": "TX-2001",
+		"status":         "failed",
+	}).Expect(t, http.StatusOK)
+
+	if status := paymentStatus(t, h, payment.OrderID); status != "failed" {
+		t.Fatalf("expected failed, got %q", status)
+	}
+	h.Do(t, "GET", fmt.Sprintf("/api/user-courses/%d", course.ID), student.Token, nil).
+		Expect(t, http.StatusInternalServerError)
+
+	h.Do(t, "POST", "/api/payments/notify", "", map[string]string{
+		"order_id":       "ORD-404",
+		"transaction_id": "TX-2002",
+		"status":         "completed",
+	}).Expect(t, http.StatusInternalServerError)
+	h.Do(t, "POST", "/api/payments", student.Token, map[string]interface{}{"course_id": 404, "payment_method": "alipay"}).
+		Expect(t, http.StatusInternalServerError)
+}
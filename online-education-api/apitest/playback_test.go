@@ -0,0 +1,88 @@
+package apitest
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"online-education-api/models"
+)
+
+func TestSignedPlaybackURLs(t *testing.T) {
+	h := New(t)
+	teacher := h.User(t, "teacher")
+	student := h.User(t, "student")
+
+	// 上传并转码一个视频，作为收费课时的视频
+	header, body := multipartBody(t, "lesson.mp4", mp4Data(3000))
+	var upload struct {
+		Data models.Upload `json:"data"`
+	}
+	h.Send(t, "POST", "/api/uploads/multipart", teacher.Token, header, body).Expect(t, http.StatusCreated).Decode(t, &upload)
+	var created struct {
+		Data models.Video `json:"data"`
+	}
+	h.Do(t, "POST", "/api/videos", teacher.Token, map[string]interface{}{
+		"title":     "第一课",
+		"upload_id": upload.Data.ID,
+	}).Expect(t, http.StatusCreated).Decode(t, &created)
+	if _, err := h.Services.Transcode.ProcessPending(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	video, err := h.Services.Video.GetVideoByID(context.Background(), created.Data.ID, teacher.ID, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	course := h.Course(t, teacher, 0, &models.Chapter{Title: "第一章", Lessons: []*models.Lesson{
+		{Title: "试看", VideoURL: upload.Data.URL, Free: 1, SortOrder: 1},
+		{Title: "正课", VideoURL: video.HLSURL, SortOrder: 2},
+	}})
+	path := fmt.Sprintf("/api/courses/%d", course.ID)
+
+	lessonURLs := func(token string) (free, paid string) {
+		t.Helper()
+		var detail struct {
+			Data models.CourseDetailResponse `json:"data"`
+		}
+		h.Do(t, "GET", path, token, nil).Expect(t, http.StatusOK).Decode(t, &detail)
+		lessons := detail.Data.Chapters[0].Lessons
+		return lessons[0].VideoURL, lessons[1].VideoURL
+	}
+
+	// 未购买时只能拿到免费课时的地址
+	for _, token := range []string{"", student.Token} {
+		free, paid := lessonURLs(token)
+		if paid != "" || !strings.HasPrefix(free, "/media/_s/") {
+			t.Fatalf("unexpected lesson urls before enrolling: %q, %q", free, paid)
+		}
+		resp := h.Do(t, "GET", free, "", nil).Expect(t, http.StatusOK)
+		if resp.Header.Get("Cache-Control") != "private" {
+			t.Errorf("Cache-Control = %q, want private", resp.Header.Get("Cache-Control"))
+		}
+	}
+
+	h.Do(t, "POST", fmt.Sprintf("/api/user-courses/%d", course.ID), student.Token, nil).Expect(t, http.StatusOK)
+	_, paid := lessonURLs(student.Token)
+	if !strings.HasPrefix(paid, "/media/_s/") || !strings.HasSuffix(paid, "/hls/"+fmt.Sprint(video.ID)+"/master.m3u8") {
+		t.Fatalf("unexpected paid lesson url %q", paid)
+	}
+
+	// 播放列表引用的子播放列表和分片使用同一个令牌
+	master := h.Do(t, "GET", paid, "", nil).Expect(t, http.StatusOK).Body
+	if !strings.Contains(string(master), "360p/index.m3u8") {
+		t.Fatalf("unexpected master playlist:\n%s", master)
+	}
+	dir := strings.TrimSuffix(paid, "master.m3u8")
+	h.Do(t, "GET", dir+"360p/index.m3u8", "", nil).Expect(t, http.StatusOK)
+	h.Do(t, "GET", dir+"360p/segment_0000.ts", "", nil).Expect(t, http.StatusOK)
+
+	// 未签名、篡改或越出签名范围的地址都被拒绝，封面不受保护
+	h.Do(t, "GET", video.HLSURL, "", nil).Expect(t, http.StatusForbidden)
+	tampered := strings.Replace(paid, fmt.Sprintf(".%d.", student.ID), fmt.Sprintf(".%d.", student.ID+1), 1)
+	h.Do(t, "GET", tampered, "", nil).Expect(t, http.StatusForbidden)
+	h.Do(t, "GET", dir+"../../videos/"+strings.TrimPrefix(upload.Data.URL, "/media/videos/"), "", nil).Expect(t, http.StatusForbidden)
+	h.Do(t, "GET", video.CoverImageURL, "", nil).Expect(t, http.StatusOK)
+}
@@ -0,0 +1,155 @@
+package apitest
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"online-education-api/models"
+)
+
+// playlistVideoIDs 返回播放列表详情中的视频ID
+func playlistVideoIDs(playlist models.Playlist) []int {
+	ids := make([]int, len(playlist.Items))
+	for i, item := range playlist.Items {
+		ids[i] = item.Video.ID
+	}
+	return ids
+}
+
+func TestPlaylistVisibility(t *testing.T) {
+	h := New(t)
+	owner := h.User(t, "student")
+	other := h.User(t, "student")
+	admin := h.User(t, "admin")
+	author := h.User(t, "teacher")
+	public := createVideo(t, h, author.Token, map[string]interface{}{"title": "公开"})
+	private := createVideo(t, h, author.Token, map[string]interface{}{"title": "私有", "visibility": "private"})
+
+	h.Do(t, "POST", "/api/playlists", "", map[string]interface{}{"title": "匿名"}).Expect(t, http.StatusUnauthorized)
+	h.Do(t, "POST", "/api/playlists", owner.Token, map[string]interface{}{"title": " "}).Expect(t, http.StatusBadRequest)
+	h.Do(t, "POST", "/api/playlists", owner.Token, map[string]interface{}{"title": "无效", "visibility": "friends"}).Expect(t, http.StatusBadRequest)
+
+	var created struct {
+		Data models.Playlist `json:"data"`
+	}
+	h.Do(t, "POST", "/api/playlists", owner.Token, map[string]interface{}{"title": "稍后观看", "visibility": "private"}).Expect(t, http.StatusCreated).Decode(t, &created)
+	path := "/api/playlists/" + strconv.FormatInt(created.Data.ID, 10)
+
+	// 只能添加自己可以观看的视频
+	h.Do(t, "POST", path+"/videos", owner.Token, map[string]interface{}{"video_id": private.ID}).Expect(t, http.StatusNotFound)
+	h.Do(t, "POST", path+"/videos", other.Token, map[string]interface{}{"video_id": public.ID}).Expect(t, http.StatusNotFound)
+	h.Do(t, "POST", path+"/videos", owner.Token, map[string]interface{}{"video_id": public.ID}).Expect(t, http.StatusCreated)
+	h.Do(t, "POST", path+"/videos", owner.Token, map[string]interface{}{"video_id": public.ID}).Expect(t, http.StatusConflict)
+
+	// 私有播放列表只对创建者和管理员可见，也不出现在公开列表中
+	h.Do(t, "GET", path, "", nil).Expect(t, http.StatusNotFound)
+	h.Do(t, "GET", path, other.Token, nil).Expect(t, http.StatusNotFound)
+	h.Do(t, "GET", path, admin.Token, nil).Expect(t, http.StatusOK)
+	var list struct {
+		Data  []models.Playlist `json:"data"`
+		Total int               `json:"total"`
+	}
+	h.Do(t, "GET", "/api/playlists?user_id="+strconv.FormatInt(owner.ID, 10), "", nil).Expect(t, http.StatusOK).Decode(t, &list)
+	if len(list.Data) != 0 {
+		t.Fatalf("public list = %+v, want none", list.Data)
+	}
+	h.Do(t, "GET", "/api/playlists/mine", owner.Token, nil).Expect(t, http.StatusOK).Decode(t, &list)
+	if len(list.Data) != 1 || list.Total != 1 || list.Data[0].VideoCount != 1 {
+		t.Fatalf("my playlists = %+v", list)
+	}
+
+	// 公开后其他用户可以查看，但只有创建者和管理员可以修改
+	h.Do(t, "PUT", path, other.Token, map[string]interface{}{"visibility": "public"}).Expect(t, http.StatusNotFound)
+	h.Do(t, "PUT", path, owner.Token, map[string]interface{}{"title": ""}).Expect(t, http.StatusBadRequest)
+	h.Do(t, "PUT", path, owner.Token, map[string]interface{}{"visibility": "public"}).Expect(t, http.StatusOK)
+	h.Do(t, "GET", path, other.Token, nil).Expect(t, http.StatusOK)
+	h.Do(t, "PUT", path, other.Token, map[string]interface{}{"title": "改名"}).Expect(t, http.StatusForbidden)
+	h.Do(t, "DELETE", path+"/videos/"+strconv.Itoa(public.ID), other.Token, nil).Expect(t, http.StatusForbidden)
+	h.Do(t, "DELETE", path, other.Token, nil).Expect(t, http.StatusForbidden)
+	h.Do(t, "DELETE", path, admin.Token, nil).Expect(t, http.StatusNoContent)
+	h.Do(t, "GET", path, owner.Token, nil).Expect(t, http.StatusNotFound)
+}
+
+func TestPlaylistSeries(t *testing.T) {
+	h := New(t)
+	author := h.User(t, "teacher")
+	viewer := h.User(t, "student")
+	var videos []models.Video
+	for i := range 3 {
+		videos = append(videos, createVideo(t, h, author.Token, map[string]interface{}{"title": "第" + strconv.Itoa(i+1) + "集", "duration": 60}))
+	}
+
+	var playlist struct {
+		Data models.Playlist `json:"data"`
+	}
+	h.Do(t, "POST", "/api/playlists", author.Token, map[string]interface{}{"title": "Go入门"}).Expect(t, http.StatusCreated).Decode(t, &playlist)
+	path := "/api/playlists/" + strconv.FormatInt(playlist.Data.ID, 10)
+	h.Do(t, "POST", path+"/videos", author.Token, map[string]interface{}{"video_id": videos[2].ID}).Expect(t, http.StatusCreated)
+	// 插入到第一个位置
+	h.Do(t, "POST", path+"/videos", author.Token, map[string]interface{}{"video_id": videos[0].ID, "position": 1}).Expect(t, http.StatusCreated).Decode(t, &playlist)
+	if ids := playlistVideoIDs(playlist.Data); len(ids) != 2 || ids[0] != videos[0].ID {
+		t.Fatalf("playlist videos = %v", ids)
+	}
+	h.Do(t, "POST", path+"/videos", author.Token, map[string]interface{}{"video_id": videos[1].ID}).Expect(t, http.StatusCreated)
+
+	// 调整顺序必须包含全部视频
+	h.Do(t, "PUT", path+"/order", author.Token, map[string]interface{}{"video_ids": []int{videos[0].ID, videos[1].ID}}).Expect(t, http.StatusBadRequest)
+	h.Do(t, "PUT", path+"/order", viewer.Token, map[string]interface{}{"video_ids": []int{videos[0].ID, videos[1].ID, videos[2].ID}}).Expect(t, http.StatusForbidden)
+	h.Do(t, "PUT", path+"/order", author.Token, map[string]interface{}{"video_ids": []int{videos[0].ID, videos[1].ID, videos[2].ID}}).Expect(t, http.StatusOK).Decode(t, &playlist)
+	if ids := playlistVideoIDs(playlist.Data); len(ids) != 3 || ids[1] != videos[1].ID || playlist.Data.TotalDuration != 180 {
+		t.Fatalf("reordered playlist = %v, total %d", ids, playlist.Data.TotalDuration)
+	}
+
+	// 视频详情返回作者系列中的下一个视频
+	var video struct {
+		Data models.Video `json:"data"`
+	}
+	h.Do(t, "GET", "/api/videos/"+strconv.Itoa(videos[1].ID), "", nil).Expect(t, http.StatusOK).Decode(t, &video)
+	c := video.Data.Playlist
+	if c == nil || c.ID != playlist.Data.ID || c.Position != 2 || c.VideoCount != 3 ||
+		c.Previous == nil || c.Previous.ID != videos[0].ID || c.Next == nil || c.Next.ID != videos[2].ID {
+		t.Fatalf("unexpected playlist context %+v", c)
+	}
+	video.Data.Playlist = nil
+	h.Do(t, "GET", "/api/videos/"+strconv.Itoa(videos[2].ID)+"?playlist="+strconv.FormatInt(playlist.Data.ID, 10), "", nil).Expect(t, http.StatusOK).Decode(t, &video)
+	if c := video.Data.Playlist; c == nil || c.Position != 3 || c.Next != nil {
+		t.Fatalf("unexpected last video context %+v", c)
+	}
+	h.Do(t, "GET", "/api/videos/"+strconv.Itoa(videos[0].ID)+"?playlist=abc", "", nil).Expect(t, http.StatusBadRequest)
+	// 不存在的播放列表不返回上下文
+	video.Data.Playlist = nil
+	h.Do(t, "GET", "/api/videos/"+strconv.Itoa(videos[0].ID)+"?playlist=999", "", nil).Expect(t, http.StatusOK).Decode(t, &video)
+	if video.Data.Playlist != nil {
+		t.Fatalf("unexpected context for missing playlist %+v", video.Data.Playlist)
+	}
+
+	// 看完第一集、第二集看到30秒后，系列的进度和下一集
+	events := []struct {
+		videoID  int
+		typ      string
+		position float64
+	}{
+		{videos[0].ID, "play", 0},
+		{videos[0].ID, "heartbeat", 59},
+		{videos[1].ID, "play", 0},
+		{videos[1].ID, "heartbeat", 30},
+	}
+	for _, e := range events {
+		h.Do(t, "POST", "/api/videos/"+strconv.Itoa(e.videoID)+"/events", viewer.Token,
+			map[string]interface{}{"type": e.typ, "session_id": "s", "position": e.position}).Expect(t, http.StatusNoContent)
+	}
+	if err := h.Services.Analytics.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	playlist.Data = models.Playlist{}
+	h.Do(t, "GET", path, viewer.Token, nil).Expect(t, http.StatusOK).Decode(t, &playlist)
+	p := playlist.Data.Progress
+	if p == nil || p.Completed != 1 || p.WatchedSeconds != 90 || p.NextVideoID != videos[1].ID {
+		t.Fatalf("unexpected progress %+v", p)
+	}
+	if item := playlist.Data.Items[1]; item.Progress == nil || item.Progress.Position != 30 || item.Progress.Completed {
+		t.Fatalf("unexpected item progress %+v", item.Progress)
+	}
+}
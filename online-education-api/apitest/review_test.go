@@ -0,0 +1,167 @@
+package apitest
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"online-education-api/models"
+)
+
+// reviewsPage 评价列表接口返回的数据
+type reviewsPage struct {
+	List       []models.CourseReviewResponse `json:"list"`
+	Total      int                           `json:"total"`
+	NextCursor string                        `json:"next_cursor"`
+}
+
+// listReviews 调用课程评价列表接口
+func listReviews(t *testing.T, h *Harness, courseID int64, params url.Values) reviewsPage {
+	t.Helper()
+
+	var body struct {
+		Data reviewsPage `json:"data"`
+	}
+	h.Do(t, "GET", fmt.Sprintf("/api/courses/%d/reviews?%s", courseID, params.Encode()), "", nil).Expect(t, http.StatusOK).Decode(t, &body)
+	return body.Data
+}
+
+// courseRating 返回课程详情中的评分和评价数
+func courseRating(t *testing.T, h *Harness, courseID int64) (float64, int) {
+	t.Helper()
+
+	var body struct {
+		Data models.Course `json:"data"`
+	}
+	h.Do(t, "GET", fmt.Sprintf("/api/courses/%d", courseID), "", nil).Expect(t, http.StatusOK).Decode(t, &body)
+	return body.Data.Rating, body.Data.ReviewCount
+}
+
+// review 报名课程并提交评价，返回评价ID
+func review(t *testing.T, h *Harness, student *Account, courseID int64, rating int) int64 {
+	t.Helper()
+
+	h.Do(t, "POST", fmt.Sprintf("/api/user-courses/%d", courseID), student.Token, nil).Expect(t, http.StatusOK)
+	var body struct {
+		Data models.CourseReview `json:"data"`
+	}
+	h.Do(t, "POST", fmt.Sprintf("/api/courses/%d/reviews", courseID), student.Token, map[string]interface{}{
+		"rating":  rating,
+		"content": fmt.Sprintf("%d星评价", rating),
+	}).Expect(t, http.StatusOK).Decode(t, &body)
+	return body.Data.ID
+}
+
+func TestCourseReviews(t *testing.T) {
+	h := New(t)
+	teacher := h.User(t, "teacher")
+	course := h.Course(t, teacher, 0)
+	reviewsPath := fmt.Sprintf("/api/courses/%d/reviews", course.ID)
+
+	// 只有报名的用户可以评价，每人只能评价一次
+	outsider := h.User(t, "student")
+	h.Do(t, "POST", reviewsPath, outsider.Token, map[string]interface{}{"rating": 5}).Expect(t, http.StatusForbidden)
+
+	alice, bob := h.User(t, "student"), h.User(t, "student")
+	aliceReview := review(t, h, alice, course.ID, 5)
+	bobReview := review(t, h, bob, course.ID, 2)
+	h.Do(t, "POST", reviewsPath, alice.Token, map[string]interface{}{"rating": 4}).Expect(t, http.StatusConflict)
+	h.Do(t, "POST", reviewsPath, bob.Token, map[string]interface{}{"rating": 6}).Expect(t, http.StatusBadRequest)
+
+	if rating, count := courseRating(t, h, course.ID); rating != 3.5 || count != 2 {
+		t.Fatalf("expected rating 3.5 from 2 reviews, got %v from %d", rating, count)
+	}
+
+	// 修改评价后评分随之更新，只有评价人可以修改
+	bobPath := fmt.Sprintf("/api/reviews/%d", bobReview)
+	h.Do(t, "PUT", bobPath, alice.Token, map[string]interface{}{"rating": 1}).Expect(t, http.StatusForbidden)
+	h.Do(t, "PUT", bobPath, bob.Token, map[string]interface{}{"rating": 4, "content": "后面的章节很有帮助"}).Expect(t, http.StatusOK)
+	if rating, _ := courseRating(t, h, course.ID); rating != 4.5 {
+		t.Fatalf("expected rating 4.5 after the edit, got %v", rating)
+	}
+
+	// 排序、星级筛选和教师回复
+	page := listReviews(t, h, course.ID, url.Values{"sort": {"lowest"}})
+	if page.Total != 2 || page.List[0].ID != bobReview || page.List[0].UserName != bob.Username || page.NextCursor != "" {
+		t.Fatalf("unexpected reviews sorted by lowest rating %+v", page)
+	}
+	if page := listReviews(t, h, course.ID, url.Values{"rating": {"5"}}); page.Total != 1 || page.List[0].ID != aliceReview {
+		t.Fatalf("expected only the 5-star review, got %+v", page.List)
+	}
+	h.Do(t, "GET", reviewsPath+"?sort=helpful", "", nil).Expect(t, http.StatusBadRequest)
+
+	alicePath := fmt.Sprintf("/api/reviews/%d", aliceReview)
+	h.Do(t, "PUT", alicePath+"/reply", h.User(t, "teacher").Token, map[string]interface{}{"reply": "谢谢"}).Expect(t, http.StatusForbidden)
+	h.Do(t, "PUT", alicePath+"/reply", teacher.Token, map[string]interface{}{"reply": "感谢支持"}).Expect(t, http.StatusOK)
+	if page := listReviews(t, h, course.ID, url.Values{"rating": {"5"}}); page.List[0].Reply != "感谢支持" || page.List[0].RepliedAt == nil {
+		t.Fatalf("expected the teacher's reply, got %+v", page.List[0])
+	}
+
+	// 删除评价后从评分中扣除
+	h.Do(t, "DELETE", alicePath, bob.Token, nil).Expect(t, http.StatusForbidden)
+	h.Do(t, "DELETE", alicePath, alice.Token, nil).Expect(t, http.StatusOK)
+	if rating, count := courseRating(t, h, course.ID); rating != 4 || count != 1 {
+		t.Fatalf("expected rating 4 from 1 review after deleting, got %v from %d", rating, count)
+	}
+	h.Do(t, "DELETE", alicePath, alice.Token, nil).Expect(t, http.StatusNotFound)
+}
+
+func TestReviewModeration(t *testing.T) {
+	h := New(t)
+	teacher := h.User(t, "teacher")
+	course := h.Course(t, teacher, 0)
+	admin := h.User(t, "admin")
+
+	spam := review(t, h, h.User(t, "student"), course.ID, 1)
+	fair := review(t, h, h.User(t, "student"), course.ID, 5)
+	reportPath := func(id int64) string { return fmt.Sprintf("/api/reviews/%d/report", id) }
+
+	reporter := h.User(t, "student")
+	h.Do(t, "POST", reportPath(spam), reporter.Token, map[string]interface{}{"reason": "广告"}).Expect(t, http.StatusOK)
+	h.Do(t, "POST", reportPath(spam), reporter.Token, map[string]interface{}{"reason": "广告"}).Expect(t, http.StatusConflict)
+	h.Do(t, "POST", reportPath(spam), h.User(t, "student").Token, map[string]interface{}{"reason": "与课程无关"}).Expect(t, http.StatusOK)
+	h.Do(t, "POST", reportPath(fair), reporter.Token, map[string]interface{}{"reason": "不同意"}).Expect(t, http.StatusOK)
+
+	// 待处理的举报按举报数倒序，只有管理员可以查看和处理
+	h.Do(t, "GET", "/api/reviews/reported", reporter.Token, nil).Expect(t, http.StatusForbidden)
+	var queue struct {
+		Data reviewsPage `json:"data"`
+	}
+	h.Do(t, "GET", "/api/reviews/reported", admin.Token, nil).Expect(t, http.StatusOK).Decode(t, &queue)
+	if queue.Data.Total != 2 || queue.Data.List[0].ID != spam || queue.Data.List[0].ReportCount != 2 {
+		t.Fatalf("unexpected moderation queue %+v", queue.Data)
+	}
+
+	moderate := func(id int64, action string) *Response {
+		return h.Do(t, "PUT", fmt.Sprintf("/api/reviews/%d/moderation", id), admin.Token, map[string]interface{}{"action": action})
+	}
+	moderate(spam, "hide").Expect(t, http.StatusOK)
+	moderate(fair, "dismiss").Expect(t, http.StatusOK)
+	moderate(fair, "delete").Expect(t, http.StatusBadRequest)
+
+	// 隐藏的评价不再显示也不计入评分，恢复后重新计入
+	if page := listReviews(t, h, course.ID, url.Values{}); page.Total != 1 || page.List[0].ID != fair {
+		t.Fatalf("expected only the visible review, got %+v", page.List)
+	}
+	if rating, count := courseRating(t, h, course.ID); rating != 5 || count != 1 {
+		t.Fatalf("expected rating 5 from 1 review after hiding, got %v from %d", rating, count)
+	}
+	h.Do(t, "GET", "/api/reviews/reported", admin.Token, nil).Expect(t, http.StatusOK).Decode(t, &queue)
+	if queue.Data.Total != 0 {
+		t.Fatalf("expected an empty moderation queue, got %+v", queue.Data.List)
+	}
+
+	moderate(spam, "restore").Expect(t, http.StatusOK)
+	if rating, count := courseRating(t, h, course.ID); rating != 3 || count != 2 {
+		t.Fatalf("expected rating 3 from 2 reviews after restoring, got %v from %d", rating, count)
+	}
+
+	// 处理后的举报被删除，恢复显示的评价可以再次举报，举报数从头计算
+	h.Do(t, "POST", reportPath(spam), reporter.Token, map[string]interface{}{"reason": "仍然是广告"}).Expect(t, http.StatusOK)
+	h.Do(t, "POST", reportPath(spam), reporter.Token, map[string]interface{}{"reason": "仍然是广告"}).Expect(t, http.StatusConflict)
+	h.Do(t, "GET", "/api/reviews/reported", admin.Token, nil).Expect(t, http.StatusOK).Decode(t, &queue)
+	if queue.Data.Total != 1 || queue.Data.List[0].ID != spam || queue.Data.List[0].ReportCount != 1 {
+		t.Fatalf("unexpected moderation queue after reporting again %+v", queue.Data)
+	}
+}
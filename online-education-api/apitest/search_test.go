@@ -0,0 +1,108 @@
+package apitest
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"online-education-api/models"
+	"online-education-api/search"
+)
+
+// searchPage 搜索接口返回的数据
+type searchPage struct {
+	List   []search.Hit  `json:"list"`
+	Total  int           `json:"total"`
+	Facets search.Facets `json:"facets"`
+}
+
+// searchFor 调用搜索接口
+func searchFor(t *testing.T, h *Harness, params url.Values) searchPage {
+	t.Helper()
+
+	var body struct {
+		Data searchPage `json:"data"`
+	}
+	h.Do(t, "GET", "/api/search?"+params.Encode(), "", nil).Expect(t, http.StatusOK).Decode(t, &body)
+	return body.Data
+}
+
+func TestSearchFollowsWrites(t *testing.T) {
+	h := New(t)
+	teacher := h.User(t, "teacher")
+	student := h.User(t, "student")
+
+	course := h.Course(t, teacher, 299)
+	coursePath := fmt.Sprintf("/api/courses/%d", course.ID)
+	h.Do(t, "PUT", coursePath, teacher.Token, map[string]interface{}{"title": "Kubernetes集群实战", "status": 1}).Expect(t, http.StatusOK)
+
+	var created struct {
+		Data struct {
+			ID int64 `json:"id"`
+		} `json:"data"`
+	}
+	h.Do(t, "POST", "/api/posts", student.Token, map[string]interface{}{
+		"title":    "集群部署失败求助",
+		"content":  "按照课程把服务部署到Kubernetes集群时报错",
+		"category": "问答",
+	}).Expect(t, http.StatusOK).Decode(t, &created)
+
+	result := searchFor(t, h, url.Values{"q": {"kubernetes 集群"}})
+	if result.Total != 2 || result.List[0].Type != search.TypeCourse || result.List[0].ID != course.ID {
+		t.Fatalf("expected the course first and the post second, got %+v", result.List)
+	}
+	if result.List[0].Highlight.Title != "<em>Kubernetes集群</em>实战" {
+		t.Fatalf("unexpected highlight %q", result.List[0].Highlight.Title)
+	}
+
+	// 搜索文本过长时拒绝
+	h.Do(t, "GET", "/api/search?q="+strings.Repeat("a+", search.MaxQueryTerms+1), "", nil).Expect(t, http.StatusBadRequest)
+	h.Do(t, "GET", "/api/search?q="+strings.Repeat("x", search.MaxQueryRunes+1), "", nil).Expect(t, http.StatusBadRequest)
+
+	// 拼写容错和分面筛选
+	result = searchFor(t, h, url.Values{"q": {"kubernets"}, "type": {"post"}})
+	if result.Total != 1 || result.List[0].ID != created.Data.ID {
+		t.Fatalf("expected the post despite the typo, got %+v", result.List)
+	}
+	result = searchFor(t, h, url.Values{"q": {"集群"}, "min_price": {"100"}, "max_price": {"500"}})
+	if result.Total != 1 || len(result.Facets.Prices) != 1 || result.Facets.Prices[0].Value != "100-500" {
+		t.Fatalf("expected only the course in the price range, got %+v", result)
+	}
+
+	// 下架课程和删除帖子后不再出现在结果中
+	h.Do(t, "PUT", coursePath, teacher.Token, map[string]interface{}{"status": 0}).Expect(t, http.StatusOK)
+	h.Do(t, "DELETE", fmt.Sprintf("/api/posts/%d", created.Data.ID), student.Token, nil).Expect(t, http.StatusOK)
+	if result := searchFor(t, h, url.Values{"q": {"集群"}}); result.Total != 0 {
+		t.Fatalf("expected no hits after unpublishing, got %+v", result.List)
+	}
+
+	h.Do(t, "GET", "/api/search?type=lesson", "", nil).Expect(t, http.StatusBadRequest)
+}
+
+func TestSearchRebuild(t *testing.T) {
+	h := New(t)
+	teacher := h.User(t, "teacher")
+	h.Course(t, teacher, 0)
+	h.Post(t, teacher)
+	// 只有公开视频进入索引
+	for _, visibility := range []string{models.VideoVisibilityPublic, models.VideoVisibilityUnlisted, models.VideoVisibilityPrivate} {
+		video := &models.Video{Title: fmt.Sprintf("视频%d", h.next()), VideoURL: "/v.mp4", AuthorID: teacher.ID, Visibility: visibility}
+		if err := h.Services.Video.CreateVideo(context.Background(), video); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	n, err := h.Services.Search.Rebuild(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 3 {
+		t.Fatalf("expected 3 documents after rebuilding, got %d", n)
+	}
+	if result := searchFor(t, h, url.Values{"type": {"course"}}); result.Total != 1 || result.List[0].Price == nil {
+		t.Fatalf("unexpected courses after rebuilding %+v", result.List)
+	}
+}
@@ -0,0 +1,167 @@
+package apitest
+
+import (
+	"bytes"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"testing"
+
+	"online-education-api/models"
+)
+
+const (
+	testSRT = "1\n00:00:01,000 --> 00:00:03,500\n<i>Hello</i>, welcome\n\n2\n00:01:02,250 --> 00:01:05,000\nLet's start a goroutine\n"
+	testVTT = "WEBVTT\n\n00:01.000 --> 00:03.500\n欢迎 &amp; 你好\n\n01:02.250 --> 01:05.000 align:start\n启动一个goroutine\n"
+)
+
+// uploadSubtitle 以multipart/form-data上传字幕，fields在文件之前写入
+func uploadSubtitle(t *testing.T, h *Harness, path, token string, fields map[string]string, data string) *Response {
+	t.Helper()
+
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	for name, value := range fields {
+		mw.WriteField(name, value)
+	}
+	fw, err := mw.CreateFormFile("file", "subtitle.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fw.Write([]byte(data))
+	mw.Close()
+	return h.Send(t, "POST", path, token, http.Header{"Content-Type": {mw.FormDataContentType()}}, &buf)
+}
+
+func TestVideoSubtitles(t *testing.T) {
+	h := New(t)
+	author := h.User(t, "teacher")
+	other := h.User(t, "teacher")
+	video := createVideo(t, h, author.Token, map[string]interface{}{"title": "字幕"})
+	path := fmt.Sprintf("/api/videos/%d/subtitles", video.ID)
+
+	uploadSubtitle(t, h, path, "", map[string]string{"language": "en"}, testSRT).Expect(t, http.StatusUnauthorized)
+	uploadSubtitle(t, h, path, other.Token, map[string]string{"language": "en"}, testSRT).Expect(t, http.StatusForbidden)
+	uploadSubtitle(t, h, path, author.Token, nil, testSRT).Expect(t, http.StatusBadRequest)
+	uploadSubtitle(t, h, path, author.Token, map[string]string{"language": "english!"}, testSRT).Expect(t, http.StatusBadRequest)
+	uploadSubtitle(t, h, path, author.Token, map[string]string{"language": "en"}, "00:00:05,000 --> 00:00:01,000\nbackwards\n").Expect(t, http.StatusUnprocessableEntity)
+
+	// 第一条轨道设为默认，语言标签转换为规范的大小写
+	var created struct {
+		Data models.Subtitle `json:"data"`
+	}
+	uploadSubtitle(t, h, path, author.Token, map[string]string{"language": "EN", "label": "English"}, testSRT).Expect(t, http.StatusCreated).Decode(t, &created)
+	en := created.Data
+	if en.Language != "en" || !en.IsDefault || en.CueCount != 2 || en.URL != fmt.Sprintf("/api/subtitles/%d", en.ID) {
+		t.Fatalf("unexpected subtitle %+v", en)
+	}
+	uploadSubtitle(t, h, path, author.Token, map[string]string{"language": "zh-cn", "label": "中文"}, testVTT).Expect(t, http.StatusCreated).Decode(t, &created)
+	zh := created.Data
+	if zh.Language != "zh-CN" || zh.IsDefault {
+		t.Fatalf("unexpected subtitle %+v", zh)
+	}
+
+	// 视频详情包含字幕轨道，默认轨道在前
+	var got struct {
+		Data models.Video `json:"data"`
+	}
+	h.Do(t, "GET", fmt.Sprintf("/api/videos/%d", video.ID), "", nil).Expect(t, http.StatusOK).Decode(t, &got)
+	if len(got.Data.Subtitles) != 2 || got.Data.Subtitles[0].ID != en.ID {
+		t.Fatalf("unexpected video subtitles %+v", got.Data.Subtitles)
+	}
+
+	// 上传的SRT可以以WebVTT下载，反之亦然
+	resp := h.Do(t, "GET", en.URL, "", nil).Expect(t, http.StatusOK)
+	if !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/vtt") ||
+		!strings.Contains(string(resp.Body), "00:00:01.000 --> 00:00:03.500\nHello, welcome\n") {
+		t.Fatalf("unexpected vtt %q", resp.Body)
+	}
+	resp = h.Do(t, "GET", zh.URL+"?format=srt", "", nil).Expect(t, http.StatusOK)
+	if !strings.HasPrefix(resp.Header.Get("Content-Type"), "application/x-subrip") ||
+		!strings.HasPrefix(string(resp.Body), "1\n00:00:01,000 --> 00:00:03,500\n欢迎 & 你好\n") {
+		t.Fatalf("unexpected srt %q", resp.Body)
+	}
+	h.Do(t, "GET", en.URL+"?format=ass", "", nil).Expect(t, http.StatusBadRequest)
+
+	// 搜索返回提到搜索词的时间点
+	var matches struct {
+		Data []models.SubtitleMatch `json:"data"`
+	}
+	h.Do(t, "GET", path+"/search?q=GOROUTINE", "", nil).Expect(t, http.StatusOK).Decode(t, &matches)
+	if len(matches.Data) != 2 || matches.Data[0].Start != 62.25 {
+		t.Fatalf("unexpected matches %+v", matches.Data)
+	}
+	h.Do(t, "GET", path+"/search?q=goroutine&language=zh-CN", "", nil).Expect(t, http.StatusOK).Decode(t, &matches)
+	if len(matches.Data) != 1 || matches.Data[0].SubtitleID != zh.ID {
+		t.Fatalf("unexpected matches %+v", matches.Data)
+	}
+	h.Do(t, "GET", path+"/search?q=+", "", nil).Expect(t, http.StatusBadRequest)
+	// 搜索词按字面匹配，LIKE的通配符不匹配任意字符
+	for _, q := range []string{"%25", "_", "go%25", "welc_me"} {
+		h.Do(t, "GET", path+"/search?q="+q, "", nil).Expect(t, http.StatusOK).Decode(t, &matches)
+		if len(matches.Data) != 0 {
+			t.Fatalf("search %q matched %+v", q, matches.Data)
+		}
+	}
+
+	// 设为默认时取消原默认轨道，重新上传保持默认标记
+	subtitlePath := fmt.Sprintf("/api/subtitles/%d", zh.ID)
+	h.Do(t, "PUT", subtitlePath, other.Token, map[string]interface{}{"is_default": true}).Expect(t, http.StatusForbidden)
+	h.Do(t, "PUT", subtitlePath, author.Token, map[string]interface{}{"is_default": true}).Expect(t, http.StatusOK)
+	uploadSubtitle(t, h, path, author.Token, map[string]string{"language": "en"}, testSRT).Expect(t, http.StatusCreated).Decode(t, &created)
+	if created.Data.ID != en.ID || created.Data.IsDefault || created.Data.Label != "en" {
+		t.Fatalf("unexpected replaced subtitle %+v", created.Data)
+	}
+	var list struct {
+		Data []models.Subtitle `json:"data"`
+	}
+	h.Do(t, "GET", path, "", nil).Expect(t, http.StatusOK).Decode(t, &list)
+	if len(list.Data) != 2 || list.Data[0].ID != zh.ID || !list.Data[0].IsDefault || list.Data[1].IsDefault {
+		t.Fatalf("unexpected subtitles %+v", list.Data)
+	}
+
+	// 私有视频的字幕对其他用户不存在
+	h.Do(t, "PUT", fmt.Sprintf("/api/videos/%d", video.ID), author.Token, map[string]interface{}{"title": "字幕", "visibility": "private"}).Expect(t, http.StatusOK)
+	h.Do(t, "GET", path, other.Token, nil).Expect(t, http.StatusNotFound)
+	h.Do(t, "GET", subtitlePath, "", nil).Expect(t, http.StatusNotFound)
+	h.Do(t, "GET", subtitlePath, author.Token, nil).Expect(t, http.StatusOK)
+
+	h.Do(t, "DELETE", subtitlePath, other.Token, nil).Expect(t, http.StatusNotFound)
+	h.Do(t, "DELETE", subtitlePath, author.Token, nil).Expect(t, http.StatusNoContent)
+	h.Do(t, "GET", subtitlePath, author.Token, nil).Expect(t, http.StatusNotFound)
+}
+
+func TestLessonSubtitles(t *testing.T) {
+	h := New(t)
+	teacher := h.User(t, "teacher")
+	student := h.User(t, "student")
+	chapter := &models.Chapter{Title: "第一章", Lessons: []*models.Lesson{
+		{Title: "试看", VideoURL: "https://cdn.example.com/1.mp4", Free: 1, SortOrder: 1},
+		{Title: "正课", VideoURL: "https://cdn.example.com/2.mp4", SortOrder: 2},
+	}}
+	course := h.Course(t, teacher, 0, chapter)
+	free := fmt.Sprintf("/api/lessons/%d/subtitles", chapter.Lessons[0].ID)
+	paid := fmt.Sprintf("/api/lessons/%d/subtitles", chapter.Lessons[1].ID)
+
+	uploadSubtitle(t, h, paid, student.Token, map[string]string{"language": "zh"}, testVTT).Expect(t, http.StatusForbidden)
+	uploadSubtitle(t, h, "/api/lessons/999/subtitles", teacher.Token, map[string]string{"language": "zh"}, testVTT).Expect(t, http.StatusNotFound)
+	for _, path := range []string{free, paid} {
+		uploadSubtitle(t, h, path, teacher.Token, map[string]string{"language": "zh"}, testVTT).Expect(t, http.StatusCreated)
+	}
+
+	// 免费课时的字幕公开，收费课时购买后才能查看
+	h.Do(t, "GET", free, "", nil).Expect(t, http.StatusOK)
+	h.Do(t, "GET", paid, "", nil).Expect(t, http.StatusForbidden)
+	h.Do(t, "GET", paid+"/search?q=goroutine", student.Token, nil).Expect(t, http.StatusForbidden)
+
+	h.Do(t, "POST", fmt.Sprintf("/api/user-courses/%d", course.ID), student.Token, nil).Expect(t, http.StatusOK)
+	var list struct {
+		Data []models.Subtitle `json:"data"`
+	}
+	h.Do(t, "GET", paid, student.Token, nil).Expect(t, http.StatusOK).Decode(t, &list)
+	if len(list.Data) != 1 || list.Data[0].LessonID != chapter.Lessons[1].ID || list.Data[0].VideoID != 0 {
+		t.Fatalf("unexpected lesson subtitles %+v", list.Data)
+	}
+	h.Do(t, "GET", list.Data[0].URL, student.Token, nil).Expect(t, http.StatusOK)
+}
@@ -0,0 +1,59 @@
+package apitest
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+
+	"online-education-api/models"
+)
+
+func TestTranscodeUploadedVideo(t *testing.T) {
+	h := New(t)
+	teacher := h.User(t, "teacher")
+	other := h.User(t, "teacher")
+	admin := h.User(t, "admin")
+
+	header, body := multipartBody(t, "lesson.mp4", mp4Data(3000))
+	var upload struct {
+		Data models.Upload `json:"data"`
+	}
+	h.Send(t, "POST", "/api/uploads/multipart", teacher.Token, header, body).Expect(t, http.StatusCreated).Decode(t, &upload)
+
+	var created struct {
+		Data models.Video `json:"data"`
+	}
+	h.Do(t, "POST", "/api/videos", teacher.Token, map[string]interface{}{
+		"title":     "第一课",
+		"upload_id": upload.Data.ID,
+	}).Expect(t, http.StatusCreated).Decode(t, &created)
+	if created.Data.Status != models.VideoStatusProcessing || created.Data.HLSURL != "" {
+		t.Fatalf("unexpected video before transcoding %+v", created.Data)
+	}
+	path := "/api/videos/" + strconv.Itoa(created.Data.ID)
+
+	if _, err := h.Services.Transcode.ProcessPending(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	var video struct {
+		Data models.Video `json:"data"`
+	}
+	h.Do(t, "GET", path, "", nil).Expect(t, http.StatusOK).Decode(t, &video)
+	if video.Data.Status != models.VideoStatusReady || video.Data.Duration != 120 || len(video.Data.Renditions) != 2 {
+		t.Fatalf("unexpected video after transcoding %+v", video.Data)
+	}
+	master := h.Do(t, "GET", video.Data.HLSURL, "", nil).Expect(t, http.StatusOK).Body
+	if !strings.HasPrefix(string(master), "#EXTM3U") || !strings.Contains(string(master), "720p/index.m3u8") {
+		t.Fatalf("unexpected master playlist:\n%s", master)
+	}
+	h.Do(t, "GET", video.Data.ThumbnailsURL, "", nil).Expect(t, http.StatusOK)
+
+	// 重新转码
+	h.Do(t, "POST", path+"/transcode", "", nil).Expect(t, http.StatusUnauthorized)
+	h.Do(t, "POST", path+"/transcode", other.Token, nil).Expect(t, http.StatusForbidden)
+	h.Do(t, "POST", "/api/videos/999/transcode", admin.Token, nil).Expect(t, http.StatusNotFound)
+	h.Do(t, "POST", path+"/transcode", teacher.Token, nil).Expect(t, http.StatusAccepted)
+	h.Do(t, "POST", path+"/transcode", admin.Token, nil).Expect(t, http.StatusConflict)
+}
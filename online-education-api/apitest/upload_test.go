@@ -0,0 +1,197 @@
+package apitest
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+
+	"online-education-api/models"
+)
+
+// mp4Data 返回以MP4文件头开头的n字节数据
+func mp4Data(n int) []byte {
+	head := []byte("\x00\x00\x00\x18ftypmp42\x00\x00\x00\x00mp42isom")
+	data := bytes.Repeat([]byte{0x42}, n)
+	copy(data, head)
+	return data
+}
+
+// createUpload 创建分片上传，返回上传地址
+func createUpload(t *testing.T, h *Harness, token string, size int) string {
+	t.Helper()
+
+	resp := h.Do(t, "POST", "/api/uploads", token, map[string]interface{}{
+		"filename": "lesson.mp4",
+		"size":     size,
+	}).Expect(t, http.StatusCreated)
+	if resp.Header.Get("Upload-Offset") != "0" {
+		t.Fatalf("Upload-Offset = %q, want 0", resp.Header.Get("Upload-Offset"))
+	}
+	return resp.Header.Get("Location")
+}
+
+// patchChunk 从offset处提交一个分片，withChecksum为true时带上分片的SHA256
+func patchChunk(t *testing.T, h *Harness, token, location string, offset int, chunk []byte, withChecksum bool) *Response {
+	t.Helper()
+
+	header := http.Header{}
+	header.Set("Content-Type", "application/offset+octet-stream")
+	header.Set("Upload-Offset", strconv.Itoa(offset))
+	if withChecksum {
+		sum := sha256.Sum256(chunk)
+		header.Set("Upload-Checksum", "sha256 "+base64.StdEncoding.EncodeToString(sum[:]))
+	}
+	return h.Send(t, "PATCH", location, token, header, bytes.NewReader(chunk))
+}
+
+// uploadOffset 查询已接收的字节数
+func uploadOffset(t *testing.T, h *Harness, token, location string) string {
+	t.Helper()
+	return h.Send(t, "HEAD", location, token, nil, nil).Expect(t, http.StatusOK).Header.Get("Upload-Offset")
+}
+
+func TestChunkedUpload(t *testing.T) {
+	h := New(t)
+	teacher := h.User(t, "teacher")
+	other := h.User(t, "teacher")
+	student := h.User(t, "student")
+	data := mp4Data(2000)
+
+	h.Do(t, "POST", "/api/uploads", student.Token, map[string]interface{}{"filename": "a.mp4", "size": 10}).Expect(t, http.StatusForbidden)
+	h.Do(t, "POST", "/api/uploads", teacher.Token, map[string]interface{}{"filename": "a.mp4", "size": MaxUploadSize + 1}).Expect(t, http.StatusRequestEntityTooLarge)
+
+	location := createUpload(t, h, teacher.Token, len(data))
+	patchChunk(t, h, teacher.Token, location, 0, data[:700], true).Expect(t, http.StatusNoContent)
+
+	// 偏移量与已接收的字节数不一致
+	patchChunk(t, h, teacher.Token, location, 0, data[:700], false).Expect(t, http.StatusConflict)
+
+	// 校验和不匹配的分片被丢弃，从同一偏移量重新上传
+	header := http.Header{}
+	header.Set("Content-Type", "application/offset+octet-stream")
+	header.Set("Upload-Offset", "700")
+	header.Set("Upload-Checksum", "sha256 "+base64.StdEncoding.EncodeToString(make([]byte, 32)))
+	h.Send(t, "PATCH", location, teacher.Token, header, bytes.NewReader(data[700:1000])).Expect(t, 460)
+	if got := uploadOffset(t, h, teacher.Token, location); got != "700" {
+		t.Fatalf("offset after checksum mismatch = %s, want 700", got)
+	}
+
+	h.Send(t, "HEAD", location, other.Token, nil, nil).Expect(t, http.StatusForbidden)
+	patchChunk(t, h, other.Token, location, 700, data[700:], false).Expect(t, http.StatusForbidden)
+
+	resp := patchChunk(t, h, teacher.Token, location, 700, data[700:], true).Expect(t, http.StatusNoContent)
+	if resp.Header.Get("Upload-Offset") != "2000" {
+		t.Fatalf("final Upload-Offset = %q", resp.Header.Get("Upload-Offset"))
+	}
+	patchChunk(t, h, teacher.Token, location, 2000, nil, false).Expect(t, http.StatusConflict)
+
+	var body struct {
+		Data models.Upload `json:"data"`
+	}
+	h.Do(t, "GET", location, teacher.Token, nil).Expect(t, http.StatusOK).Decode(t, &body)
+	upload := body.Data
+	sum := sha256.Sum256(data)
+	if upload.Status != models.UploadStatusCompleted || upload.MimeType != "video/mp4" || upload.Checksum != hex.EncodeToString(sum[:]) {
+		t.Fatalf("unexpected upload %+v", upload)
+	}
+	if !strings.HasPrefix(upload.URL, "/media/videos/") || !strings.HasSuffix(upload.URL, upload.ID+".mp4") {
+		t.Fatalf("unexpected url %q", upload.URL)
+	}
+	// 上传的视频只能通过签名地址下载
+	h.Do(t, "GET", upload.URL, "", nil).Expect(t, http.StatusForbidden)
+	signed := h.Signer.Sign(upload.URL, false, teacher.ID, "")
+	if got := h.Do(t, "GET", signed, "", nil).Expect(t, http.StatusOK).Body; !bytes.Equal(got, data) {
+		t.Fatalf("downloaded %d bytes, want the uploaded file", len(got))
+	}
+	h.Do(t, "DELETE", location, teacher.Token, nil).Expect(t, http.StatusConflict)
+}
+
+func TestUploadRejectsInvalidContent(t *testing.T) {
+	h := New(t)
+	teacher := h.User(t, "teacher")
+
+	// 识别出不是视频后上传被删除
+	location := createUpload(t, h, teacher.Token, 600)
+	patchChunk(t, h, teacher.Token, location, 0, bytes.Repeat([]byte("hello "), 100), false).Expect(t, http.StatusUnsupportedMediaType)
+	h.Send(t, "HEAD", location, teacher.Token, nil, nil).Expect(t, http.StatusNotFound)
+
+	// 分片超出声明的文件大小
+	location = createUpload(t, h, teacher.Token, 100)
+	patchChunk(t, h, teacher.Token, location, 0, mp4Data(200), false).Expect(t, http.StatusRequestEntityTooLarge)
+	if got := uploadOffset(t, h, teacher.Token, location); got != "0" {
+		t.Fatalf("offset after oversized chunk = %s, want 0", got)
+	}
+
+	header := http.Header{"Upload-Offset": {"0"}}
+	h.Send(t, "PATCH", location, teacher.Token, header, strings.NewReader("x")).Expect(t, http.StatusUnsupportedMediaType)
+
+	h.Do(t, "DELETE", location, teacher.Token, nil).Expect(t, http.StatusNoContent)
+	h.Send(t, "HEAD", location, teacher.Token, nil, nil).Expect(t, http.StatusNotFound)
+}
+
+// multipartBody 构造只有一个文件字段的表单
+func multipartBody(t *testing.T, fileName string, data []byte) (http.Header, *bytes.Buffer) {
+	t.Helper()
+
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	mw.WriteField("title", "ignored")
+	fw, err := mw.CreateFormFile("file", fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fw.Write(data)
+	mw.Close()
+	return http.Header{"Content-Type": {mw.FormDataContentType()}}, &buf
+}
+
+func TestMultipartUploadAndCreateVideo(t *testing.T) {
+	h := New(t)
+	teacher := h.User(t, "teacher")
+	other := h.User(t, "teacher")
+	data := mp4Data(5000)
+
+	header, body := multipartBody(t, "intro.mp4", data)
+	var created struct {
+		Data models.Upload `json:"data"`
+	}
+	h.Send(t, "POST", "/api/uploads/multipart", teacher.Token, header, body).Expect(t, http.StatusCreated).Decode(t, &created)
+	upload := created.Data
+	sum := sha256.Sum256(data)
+	if upload.Size != 5000 || upload.FileName != "intro.mp4" || upload.Checksum != hex.EncodeToString(sum[:]) || upload.URL == "" {
+		t.Fatalf("unexpected upload %+v", upload)
+	}
+
+	header, body = multipartBody(t, "notes.txt", []byte("plain text"))
+	h.Send(t, "POST", "/api/uploads/multipart", teacher.Token, header, body).Expect(t, http.StatusUnsupportedMediaType)
+	header, body = multipartBody(t, "big.mp4", mp4Data(MaxUploadSize+1))
+	h.Send(t, "POST", "/api/uploads/multipart", teacher.Token, header, body).Expect(t, http.StatusRequestEntityTooLarge)
+
+	// 由上传的文件创建视频
+	h.Do(t, "POST", "/api/videos", teacher.Token, map[string]interface{}{"title": "无地址"}).Expect(t, http.StatusBadRequest)
+	h.Do(t, "POST", "/api/videos", "", map[string]interface{}{"title": "未登录", "upload_id": upload.ID}).Expect(t, http.StatusUnauthorized)
+	h.Do(t, "POST", "/api/videos", other.Token, map[string]interface{}{"title": "他人的文件", "upload_id": upload.ID}).Expect(t, http.StatusForbidden)
+
+	var video struct {
+		Data models.Video `json:"data"`
+	}
+	h.Do(t, "POST", "/api/videos", teacher.Token, map[string]interface{}{
+		"title":     "课程介绍",
+		"upload_id": upload.ID,
+	}).Expect(t, http.StatusCreated).Decode(t, &video)
+	if video.Data.VideoURL != upload.URL || video.Data.UploadID != upload.ID || video.Data.AuthorID != teacher.ID {
+		t.Fatalf("unexpected video %+v", video.Data)
+	}
+	h.Do(t, "POST", "/api/videos", teacher.Token, map[string]interface{}{"title": "重复", "upload_id": upload.ID}).Expect(t, http.StatusConflict)
+
+	// 未完成的上传不能创建视频
+	location := createUpload(t, h, teacher.Token, 100)
+	pending := strings.TrimPrefix(location, "/api/uploads/")
+	h.Do(t, "POST", "/api/videos", teacher.Token, map[string]interface{}{"title": "未完成", "upload_id": pending}).Expect(t, http.StatusConflict)
+}
@@ -0,0 +1,94 @@
+package apitest
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestRegisterLoginAndProfile(t *testing.T) {
+	h := New(t)
+
+	var registered struct {
+		ID   int64  `json:"id"`
+		Role string `json:"role"`
+	}
+	h.Do(t, "POST", "/api/users/register", "", map[string]string{
+		"username": "alice",
+		"email":    "alice@example.com",
+		"password": "secret1",
+	}).Expect(t, http.StatusCreated).Decode(t, &registered)
+	if registered.ID == 0 || registered.Role != "student" {
+		t.Fatalf("unexpected registered user %+v", registered)
+	}
+
+	h.Do(t, "POST", "/api/users/register", "", map[string]string{
+		"username": "alice",
+		"email":    "other@example.com",
+		"password": "secret1",
+	}).Expect(t, http.StatusBadRequest)
+
+	h.Do(t, "POST", "/api/users/login", "", map[string]string{"username": "alice", "password": "wrong"}).
+		Expect(t, http.StatusUnauthorized)
+
+	var login struct {
+		Token string `json:"token"`
+	}
+	h.Do(t, "POST", "/api/users/login", "", map[string]string{"username": "alice", "password": "secret1"}).
+		Expect(t, http.StatusOK).Decode(t, &login)
+	if login.Token == "" {
+		t.Fatal("expected a token")
+	}
+
+	var profile struct {
+		ID       int64  `json:"id"`
+		Username string `json:"username"`
+	}
+	h.Do(t, "GET", "/api/users/profile", login.Token, nil).Expect(t, http.StatusOK).Decode(t, &profile)
+	if profile.ID != registered.ID || profile.Username != "alice" {
+		t.Fatalf("unexpected profile %+v", profile)
+	}
+}
+
+func TestProtectedRoutesRequireValidToken(t *testing.T) {
+	h := New(t)
+
+	h.Do(t, "GET", "/api/users/profile", "", nil).Expect(t, http.StatusUnauthorized)
+	h.Do(t, "GET", "/api/users/profile", "not-a-jwt", nil).Expect(t, http.StatusUnauthorized)
+
+	resp := h.Do(t, "GET", "/api/users/profile", Token(t, 404, "ghost", "student"), nil).Expect(t, http.StatusNotFound)
+	if !strings.Contains(string(resp.Body), "用户不存在") {
+		t.Fatalf("unexpected body %s", resp.Body)
+	}
+}
+
+func TestAdminOnlyRoutes(t *testing.T) {
+	h := New(t)
+	admin := h.User(t, "admin")
+	student := h.User(t, "student")
+
+	h.Do(t, "GET", "/api/users", student.Token, nil).Expect(t, http.StatusForbidden)
+	h.Do(t, "POST", "/api/users", student.Token, map[string]string{
+		"username": "mallory",
+		"email":    "mallory@example.com",
+		"password": "secret1",
+		"role":     "admin",
+	}).Expect(t, http.StatusForbidden)
+
+	var list struct {
+		Total int64 `json:"total"`
+	}
+	h.Do(t, "GET", "/api/users", admin.Token, nil).Expect(t, http.StatusOK).Decode(t, &list)
+	if list.Total != 2 {
+		t.Fatalf("expected 2 users, got %d", list.Total)
+	}
+
+	h.Do(t, "POST", "/api/users", admin.Token, map[string]string{
+		"username": "teacher9",
+		"email":    "teacher9@example.com",
+		"password": "secret1",
+		"role":     "teacher",
+	}).Expect(t, http.StatusCreated)
+	h.Do(t, "POST", "/api/users/login", "", map[string]string{"username": "teacher9", "password": "secret1"}).
+		Expect(t, http.StatusOK)
+}
@@ -0,0 +1,124 @@
+package apitest
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"testing"
+
+	"online-education-api/models"
+)
+
+// createVideoCategory 以管理员创建视频分类
+func createVideoCategory(t *testing.T, h *Harness, token string, body map[string]interface{}) models.VideoCategory {
+	t.Helper()
+
+	var created struct {
+		Data models.VideoCategory `json:"data"`
+	}
+	h.Do(t, "POST", "/api/video-categories", token, body).Expect(t, http.StatusCreated).Decode(t, &created)
+	return created.Data
+}
+
+func TestVideoCategoryTree(t *testing.T) {
+	h := New(t)
+	admin := h.User(t, "admin")
+	teacher := h.User(t, "teacher")
+
+	h.Do(t, "POST", "/api/video-categories", "", map[string]interface{}{"name": "编程"}).Expect(t, http.StatusUnauthorized)
+	h.Do(t, "POST", "/api/video-categories", teacher.Token, map[string]interface{}{"name": "编程"}).Expect(t, http.StatusForbidden)
+	h.Do(t, "POST", "/api/video-categories", admin.Token, map[string]interface{}{"name": " "}).Expect(t, http.StatusBadRequest)
+	h.Do(t, "POST", "/api/video-categories", admin.Token, map[string]interface{}{"name": "孤儿", "parent_id": 999}).Expect(t, http.StatusBadRequest)
+
+	design := createVideoCategory(t, h, admin.Token, map[string]interface{}{"name": "设计", "sort_order": 2})
+	code := createVideoCategory(t, h, admin.Token, map[string]interface{}{"name": "编程", "sort_order": 1})
+	golang := createVideoCategory(t, h, admin.Token, map[string]interface{}{"name": "Go", "parent_id": code.ID})
+	web := createVideoCategory(t, h, admin.Token, map[string]interface{}{"name": "Web", "parent_id": golang.ID})
+
+	// 先读取一次分类树，确认视频的创建会使缓存的数量失效
+	h.Do(t, "GET", "/api/video-categories", "", nil).Expect(t, http.StatusOK)
+	createVideo(t, h, teacher.Token, map[string]interface{}{"title": "Go基础", "category_id": golang.ID})
+	createVideo(t, h, teacher.Token, map[string]interface{}{"title": "Go Web", "category_id": web.ID})
+	createVideo(t, h, teacher.Token, map[string]interface{}{"title": "私有", "category_id": web.ID, "visibility": "private"})
+
+	var tree struct {
+		Data []*models.VideoCategory `json:"data"`
+	}
+	h.Do(t, "GET", "/api/video-categories", "", nil).Expect(t, http.StatusOK).Decode(t, &tree)
+	if len(tree.Data) != 2 || tree.Data[0].ID != code.ID || tree.Data[1].ID != design.ID {
+		t.Fatalf("roots = %+v, want [编程 设计]", tree.Data)
+	}
+	root := tree.Data[0]
+	if root.VideoCount != 0 || root.TotalVideoCount != 2 || len(root.Children) != 1 ||
+		root.Children[0].VideoCount != 1 || len(root.Children[0].Children) != 1 || root.Children[0].Children[0].VideoCount != 1 {
+		t.Fatalf("unexpected tree %+v", root)
+	}
+
+	// 列表可以包含下级分类的视频
+	if ids := listVideoIDs(t, h, "/api/videos?categoryID="+strconv.Itoa(code.ID), ""); len(ids) != 0 {
+		t.Fatalf("category videos = %v, want none", ids)
+	}
+	if ids := listVideoIDs(t, h, "/api/videos?categoryID="+strconv.Itoa(code.ID)+"&includeSubcategories=true", ""); len(ids) != 2 {
+		t.Fatalf("category videos with subcategories = %v, want 2", ids)
+	}
+
+	// 上级分类不能是分类本身或其下级分类
+	path := "/api/video-categories/" + strconv.Itoa(code.ID)
+	h.Do(t, "PUT", path, admin.Token, map[string]interface{}{"name": "编程", "parent_id": code.ID}).Expect(t, http.StatusBadRequest)
+	h.Do(t, "PUT", path, admin.Token, map[string]interface{}{"name": "编程", "parent_id": web.ID}).Expect(t, http.StatusBadRequest)
+	h.Do(t, "PUT", "/api/video-categories/999", admin.Token, map[string]interface{}{"name": "无"}).Expect(t, http.StatusNotFound)
+	h.Do(t, "PUT", path, admin.Token, map[string]interface{}{"name": "开发", "sort_order": 3}).Expect(t, http.StatusOK)
+	var got struct {
+		Data models.VideoCategory `json:"data"`
+	}
+	h.Do(t, "GET", path, "", nil).Expect(t, http.StatusOK).Decode(t, &got)
+	if got.Data.Name != "开发" || got.Data.SortOrder != 3 || len(got.Data.Children) != 1 {
+		t.Fatalf("unexpected category after update %+v", got.Data)
+	}
+
+	// 搜索结果中的分类名称随分类更新
+	h.Do(t, "PUT", "/api/video-categories/"+strconv.Itoa(golang.ID), admin.Token, map[string]interface{}{"name": "Golang", "parent_id": code.ID}).Expect(t, http.StatusOK)
+	result := searchFor(t, h, url.Values{"q": {"Go基础"}})
+	if len(result.List) == 0 || result.List[0].Category != "Golang" {
+		t.Fatalf("search hits = %+v, want category Golang", result.List)
+	}
+}
+
+func TestDeleteVideoCategory(t *testing.T) {
+	h := New(t)
+	admin := h.User(t, "admin")
+	teacher := h.User(t, "teacher")
+	parent := createVideoCategory(t, h, admin.Token, map[string]interface{}{"name": "编程"})
+	child := createVideoCategory(t, h, admin.Token, map[string]interface{}{"name": "Go", "parent_id": parent.ID})
+	other := createVideoCategory(t, h, admin.Token, map[string]interface{}{"name": "设计"})
+	video := createVideo(t, h, teacher.Token, map[string]interface{}{"title": "私有", "category_id": child.ID, "visibility": "private"})
+	parentPath := "/api/video-categories/" + strconv.Itoa(parent.ID)
+	childPath := "/api/video-categories/" + strconv.Itoa(child.ID)
+
+	h.Do(t, "DELETE", childPath, teacher.Token, nil).Expect(t, http.StatusForbidden)
+	h.Do(t, "DELETE", parentPath, admin.Token, nil).Expect(t, http.StatusConflict)
+	// 非公开的视频也阻止删除
+	h.Do(t, "DELETE", childPath, admin.Token, nil).Expect(t, http.StatusConflict)
+	h.Do(t, "DELETE", childPath+"?reassign_to="+strconv.Itoa(child.ID), admin.Token, nil).Expect(t, http.StatusBadRequest)
+	h.Do(t, "DELETE", childPath+"?reassign_to=999", admin.Token, nil).Expect(t, http.StatusBadRequest)
+	h.Do(t, "DELETE", childPath+"?reassign_to="+strconv.Itoa(other.ID), admin.Token, nil).Expect(t, http.StatusNoContent)
+	h.Do(t, "GET", childPath, "", nil).Expect(t, http.StatusNotFound)
+
+	var got struct {
+		Data models.Video `json:"data"`
+	}
+	h.Do(t, "GET", "/api/videos/"+strconv.Itoa(video.ID), teacher.Token, nil).Expect(t, http.StatusOK).Decode(t, &got)
+	if got.Data.CategoryID != other.ID {
+		t.Fatalf("category_id = %d, want %d", got.Data.CategoryID, other.ID)
+	}
+
+	// 没有下级分类和视频后可以直接删除
+	h.Do(t, "DELETE", parentPath, admin.Token, nil).Expect(t, http.StatusNoContent)
+	var list struct {
+		Data []models.VideoCategory `json:"data"`
+	}
+	h.Do(t, "GET", "/api/videos/categories", "", nil).Expect(t, http.StatusOK).Decode(t, &list)
+	if len(list.Data) != 1 || list.Data[0].ID != other.ID || list.Data[0].VideoCount != 0 {
+		t.Fatalf("categories = %+v, want only %d without public videos", list.Data, other.ID)
+	}
+}
@@ -0,0 +1,162 @@
+package apitest
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"online-education-api/models"
+)
+
+// createVideo 以token创建指定可见性的视频
+func createVideo(t *testing.T, h *Harness, token string, body map[string]interface{}) models.Video {
+	t.Helper()
+
+	body["video_url"] = "https://cdn.example.com/v.mp4"
+	var created struct {
+		Data models.Video `json:"data"`
+	}
+	h.Do(t, "POST", "/api/videos", token, body).Expect(t, http.StatusCreated).Decode(t, &created)
+	return created.Data
+}
+
+// listVideoIDs 返回视频列表中的ID
+func listVideoIDs(t *testing.T, h *Harness, path, token string) []int {
+	t.Helper()
+
+	var list struct {
+		Data []models.Video `json:"data"`
+	}
+	h.Do(t, "GET", path, token, nil).Expect(t, http.StatusOK).Decode(t, &list)
+	ids := make([]int, len(list.Data))
+	for i, video := range list.Data {
+		ids[i] = video.ID
+	}
+	return ids
+}
+
+func TestVideoVisibility(t *testing.T) {
+	h := New(t)
+	author := h.User(t, "teacher")
+	other := h.User(t, "student")
+	admin := h.User(t, "admin")
+
+	h.Do(t, "POST", "/api/videos", "", map[string]interface{}{"title": "匿名", "video_url": "/v.mp4"}).Expect(t, http.StatusUnauthorized)
+	h.Do(t, "POST", "/api/videos", author.Token, map[string]interface{}{"title": "无效", "video_url": "/v.mp4", "visibility": "friends"}).Expect(t, http.StatusBadRequest)
+
+	public := createVideo(t, h, author.Token, map[string]interface{}{"title": "公开"})
+	unlisted := createVideo(t, h, author.Token, map[string]interface{}{"title": "不公开列出", "visibility": "unlisted"})
+	// 旧客户端的is_public=false视为私有
+	private := createVideo(t, h, author.Token, map[string]interface{}{"title": "私有", "is_public": false})
+	if public.AuthorID != author.ID || public.Visibility != models.VideoVisibilityPublic || private.Visibility != models.VideoVisibilityPrivate {
+		t.Fatalf("unexpected videos %+v %+v", public, private)
+	}
+
+	// 列表默认只包含公开视频，其他可见性只有管理员可以查看
+	if ids := listVideoIDs(t, h, "/api/videos", ""); len(ids) != 1 || ids[0] != public.ID {
+		t.Fatalf("public list = %v, want [%d]", ids, public.ID)
+	}
+	h.Do(t, "GET", "/api/videos?visibility=private", author.Token, nil).Expect(t, http.StatusForbidden)
+	h.Do(t, "GET", "/api/videos?visibility=secret", admin.Token, nil).Expect(t, http.StatusBadRequest)
+	if ids := listVideoIDs(t, h, "/api/videos?visibility=all", admin.Token); len(ids) != 3 {
+		t.Fatalf("admin list = %v, want all 3 videos", ids)
+	}
+	if ids := listVideoIDs(t, h, "/api/videos?visibility=private", admin.Token); len(ids) != 1 || ids[0] != private.ID {
+		t.Fatalf("admin private list = %v, want [%d]", ids, private.ID)
+	}
+
+	// 我的视频包含全部可见性
+	h.Do(t, "GET", "/api/videos/mine", "", nil).Expect(t, http.StatusUnauthorized)
+	if ids := listVideoIDs(t, h, "/api/videos/mine", author.Token); len(ids) != 3 {
+		t.Fatalf("my videos = %v, want 3", ids)
+	}
+	if ids := listVideoIDs(t, h, "/api/videos/mine?visibility=unlisted", author.Token); len(ids) != 1 || ids[0] != unlisted.ID {
+		t.Fatalf("my unlisted videos = %v, want [%d]", ids, unlisted.ID)
+	}
+	if ids := listVideoIDs(t, h, "/api/videos/mine", other.Token); len(ids) != 0 {
+		t.Fatalf("other user's videos = %v, want none", ids)
+	}
+
+	// 不公开列出的视频可以通过地址观看，私有视频对其他用户不存在
+	h.Do(t, "GET", "/api/videos/"+strconv.Itoa(unlisted.ID), "", nil).Expect(t, http.StatusOK)
+	privatePath := "/api/videos/" + strconv.Itoa(private.ID)
+	h.Do(t, "GET", privatePath, "", nil).Expect(t, http.StatusNotFound)
+	h.Do(t, "GET", privatePath, other.Token, nil).Expect(t, http.StatusNotFound)
+	h.Do(t, "GET", privatePath, author.Token, nil).Expect(t, http.StatusOK)
+	h.Do(t, "GET", privatePath, admin.Token, nil).Expect(t, http.StatusOK)
+}
+
+func TestVideoOwnership(t *testing.T) {
+	h := New(t)
+	author := h.User(t, "teacher")
+	other := h.User(t, "teacher")
+	admin := h.User(t, "admin")
+	video := createVideo(t, h, author.Token, map[string]interface{}{"title": "原标题", "visibility": "unlisted"})
+	path := "/api/videos/" + strconv.Itoa(video.ID)
+
+	update := map[string]interface{}{"title": "新标题"}
+	h.Do(t, "PUT", path, "", update).Expect(t, http.StatusUnauthorized)
+	h.Do(t, "PUT", path, other.Token, update).Expect(t, http.StatusForbidden)
+	h.Do(t, "PUT", "/api/videos/999", author.Token, update).Expect(t, http.StatusNotFound)
+	h.Do(t, "PUT", path, author.Token, update).Expect(t, http.StatusOK)
+
+	// 未提供可见性时保持不变，管理员可以修改他人的视频
+	var got struct {
+		Data models.Video `json:"data"`
+	}
+	h.Do(t, "GET", path, author.Token, nil).Expect(t, http.StatusOK).Decode(t, &got)
+	if got.Data.Title != "新标题" || got.Data.Visibility != models.VideoVisibilityUnlisted || got.Data.AuthorID != author.ID {
+		t.Fatalf("unexpected video after update %+v", got.Data)
+	}
+	h.Do(t, "PUT", path, admin.Token, map[string]interface{}{"title": "下架", "visibility": "private"}).Expect(t, http.StatusOK)
+	h.Do(t, "GET", path, author.Token, nil).Expect(t, http.StatusOK).Decode(t, &got)
+	if got.Data.Visibility != models.VideoVisibilityPrivate || got.Data.AuthorID != author.ID {
+		t.Fatalf("unexpected video after admin update %+v", got.Data)
+	}
+
+	h.Do(t, "DELETE", path, "", nil).Expect(t, http.StatusUnauthorized)
+	h.Do(t, "DELETE", path, other.Token, nil).Expect(t, http.StatusForbidden)
+	h.Do(t, "DELETE", path, author.Token, nil).Expect(t, http.StatusOK)
+	h.Do(t, "DELETE", path, admin.Token, nil).Expect(t, http.StatusNotFound)
+}
+
+func TestVideoViewStats(t *testing.T) {
+	h := New(t)
+	author := h.User(t, "teacher")
+	viewer := h.User(t, "student")
+	video := createVideo(t, h, author.Token, map[string]interface{}{"title": "统计", "duration": 60})
+	path := "/api/videos/" + strconv.Itoa(video.ID)
+
+	h.Do(t, "POST", path+"/events", "", map[string]interface{}{"type": "pause", "session_id": "s"}).Expect(t, http.StatusBadRequest)
+	h.Do(t, "POST", "/api/videos/999/events", "", map[string]interface{}{"type": "play", "session_id": "s"}).Expect(t, http.StatusNotFound)
+
+	// 同一用户重复播放只计一次，匿名会话单独计数
+	for _, token := range []string{viewer.Token, viewer.Token, ""} {
+		h.Do(t, "POST", path+"/events", token, map[string]interface{}{"type": "play", "session_id": "s"}).Expect(t, http.StatusNoContent)
+	}
+	h.Do(t, "POST", path+"/events", viewer.Token, map[string]interface{}{"type": "heartbeat", "session_id": "s", "position": 3}).Expect(t, http.StatusNoContent)
+	// 读取详情不再计数
+	h.Do(t, "GET", path, "", nil).Expect(t, http.StatusOK)
+
+	h.Do(t, "GET", path+"/stats", "", nil).Expect(t, http.StatusUnauthorized)
+	h.Do(t, "GET", path+"/stats", viewer.Token, nil).Expect(t, http.StatusForbidden)
+	if err := h.Services.Analytics.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	var stats struct {
+		Data models.VideoStats `json:"data"`
+	}
+	h.Do(t, "GET", path+"/stats", author.Token, nil).Expect(t, http.StatusOK).Decode(t, &stats)
+	if stats.Data.Views != 2 || len(stats.Data.Retention) != models.RetentionBuckets || stats.Data.Retention[0].Viewers != 2 {
+		t.Fatalf("unexpected stats %+v", stats.Data)
+	}
+
+	var got struct {
+		Data models.Video `json:"data"`
+	}
+	h.Do(t, "GET", path, "", nil).Expect(t, http.StatusOK).Decode(t, &got)
+	if got.Data.ViewCount != 2 {
+		t.Errorf("view_count = %d, want 2", got.Data.ViewCount)
+	}
+}
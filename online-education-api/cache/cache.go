@@ -0,0 +1,22 @@
+// Package cache 缓存抽象和进程内LRU实现
+//
+// 缓存的值是序列化后的字节，调用方负责编码和解码，因此可以替换为Redis等
+// 多个实例共享的缓存。缓存只用于加速读取，读写失败时按未命中处理，不向调用方返回错误。
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// Cache 缓存接口
+type Cache interface {
+	// Get 读取缓存，不存在或已过期时返回false
+	Get(ctx context.Context, key string) ([]byte, bool)
+	// Set 写入缓存，ttl为0时不过期
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration)
+	// Delete 删除指定的键
+	Delete(ctx context.Context, keys ...string)
+	// DeletePrefix 删除以prefix开头的全部键，共享缓存可以用键扫描或集合记录键来实现
+	DeletePrefix(ctx context.Context, prefix string)
+}
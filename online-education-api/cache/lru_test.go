@@ -0,0 +1,72 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestLRUEvictsLeastRecentlyUsed(t *testing.T) {
+	ctx := context.Background()
+	c := NewLRU(2)
+
+	c.Set(ctx, "a", []byte("1"), 0)
+	c.Set(ctx, "b", []byte("2"), 0)
+	if _, ok := c.Get(ctx, "a"); !ok {
+		t.Fatal("expected a to be cached")
+	}
+	c.Set(ctx, "c", []byte("3"), 0)
+
+	if _, ok := c.Get(ctx, "b"); ok {
+		t.Fatal("expected b to be evicted")
+	}
+	for _, key := range []string{"a", "c"} {
+		if _, ok := c.Get(ctx, key); !ok {
+			t.Fatalf("expected %s to be cached", key)
+		}
+	}
+
+	c.Set(ctx, "a", []byte("updated"), 0)
+	if v, _ := c.Get(ctx, "a"); string(v) != "updated" || c.Len() != 2 {
+		t.Fatalf("unexpected value %q with %d entries", v, c.Len())
+	}
+}
+
+func TestLRUExpiresEntries(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	c := NewLRU(10)
+	c.now = func() time.Time { return now }
+
+	c.Set(ctx, "short", []byte("1"), time.Minute)
+	c.Set(ctx, "forever", []byte("2"), 0)
+
+	now = now.Add(59 * time.Second)
+	if _, ok := c.Get(ctx, "short"); !ok {
+		t.Fatal("expected entry before its ttl")
+	}
+	now = now.Add(time.Second)
+	if _, ok := c.Get(ctx, "short"); ok {
+		t.Fatal("expected entry to expire")
+	}
+	if _, ok := c.Get(ctx, "forever"); !ok || c.Len() != 1 {
+		t.Fatalf("expected only the entry without ttl to remain, got %d entries", c.Len())
+	}
+}
+
+func TestLRUDeletePrefix(t *testing.T) {
+	ctx := context.Background()
+	c := NewLRU(10)
+	for _, key := range []string{"course:list:1", "course:detail:1", "category:all"} {
+		c.Set(ctx, key, []byte("x"), 0)
+	}
+
+	c.DeletePrefix(ctx, "course:")
+	if c.Len() != 1 {
+		t.Fatalf("expected 1 entry, got %d", c.Len())
+	}
+	c.Delete(ctx, "category:all", "missing")
+	if c.Len() != 0 {
+		t.Fatalf("expected no entries, got %d", c.Len())
+	}
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"online-education-api/models"
+)
+
+var courseCommands = map[string]command{
+	"export": {
+		usage: "<课程ID> [--out FILE]",
+		run:   runCourseExport,
+	},
+	"import": {
+		usage: "<FILE> [--teacher 用户ID或用户名] [--category 分类ID]",
+		run:   runCourseImport,
+	},
+}
+
+// courseFile 课程导入导出的文件格式
+type courseFile struct {
+	Course   courseFileCourse     `json:"course"`
+	Chapters []*courseFileChapter `json:"chapters"`
+}
+
+type courseFileCourse struct {
+	Title         string  `json:"title"`
+	Description   string  `json:"description"`
+	CoverImage    string  `json:"cover_image"`
+	Price         float64 `json:"price"`
+	OriginalPrice float64 `json:"original_price"`
+	CategoryID    int64   `json:"category_id"`
+	TeacherID     int64   `json:"teacher_id"`
+	Level         int     `json:"level"`
+	Duration      int     `json:"duration"`
+	Status        int     `json:"status"`
+}
+
+type courseFileChapter struct {
+	Title     string              `json:"title"`
+	SortOrder int                 `json:"sort_order"`
+	Lessons   []*courseFileLesson `json:"lessons"`
+}
+
+type courseFileLesson struct {
+	Title     string `json:"title"`
+	VideoURL  string `json:"video_url"`
+	Duration  int    `json:"duration"`
+	SortOrder int    `json:"sort_order"`
+	Free      int    `json:"free"`
+}
+
+func runCourseExport(a *app, args []string) error {
+	var out string
+	fs := a.flags("course export")
+	fs.StringVar(&out, "out", "", "输出文件，默认输出到标准输出")
+	rest, err := parse(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := requireArgs(rest, "<课程ID>"); err != nil {
+		return err
+	}
+	id, err := strconv.ParseInt(rest[0], 10, 64)
+	if err != nil {
+		return usageError("课程ID格式错误: %s", rest[0])
+	}
+
+	svc, err := a.Services()
+	if err != nil {
+		return err
+	}
+	detail, err := svc.Course.GetCourseDetail(a.ctx, id)
+	if err != nil {
+		return err
+	}
+
+	file := courseFile{
+		Course: courseFileCourse{
+			Title:         detail.Title,
+			Description:   detail.Description,
+			CoverImage:    detail.CoverImage,
+			Price:         detail.Price,
+			OriginalPrice: detail.OriginalPrice,
+			CategoryID:    detail.CategoryID,
+			TeacherID:     detail.TeacherID,
+			Level:         detail.Level,
+			Duration:      detail.Duration,
+			Status:        detail.Status,
+		},
+	}
+	for _, chapter := range detail.Chapters {
+		c := &courseFileChapter{Title: chapter.Title, SortOrder: chapter.SortOrder}
+		for _, lesson := range chapter.Lessons {
+			c.Lessons = append(c.Lessons, &courseFileLesson{
+				Title:     lesson.Title,
+				VideoURL:  lesson.VideoURL,
+				Duration:  lesson.Duration,
+				SortOrder: lesson.SortOrder,
+				Free:      lesson.Free,
+			})
+		}
+		file.Chapters = append(file.Chapters, c)
+	}
+
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	// 导出内容本身就是JSON，未指定文件时直接输出
+	if out == "" {
+		_, err = a.stdout.Write(data)
+		return err
+	}
+	if err := os.WriteFile(out, data, 0o644); err != nil {
+		return fmt.Errorf("写入文件失败: %w", err)
+	}
+	result := map[string]interface{}{"course_id": id, "file": out, "chapters": len(file.Chapters)}
+	return a.print(result, func(w io.Writer) {
+		fmt.Fprintf(w, "已导出课程 %s (%d个章节) 到 %s\n", detail.Title, len(file.Chapters), out)
+	})
+}
+
+func runCourseImport(a *app, args []string) error {
+	var teacher string
+	var categoryID int64
+	fs := a.flags("course import")
+	fs.StringVar(&teacher, "teacher", "", "覆盖文件中的教师")
+	fs.Int64Var(&categoryID, "category", 0, "覆盖文件中的分类ID")
+	rest, err := parse(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := requireArgs(rest, "<FILE>"); err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(rest[0])
+	if err != nil {
+		return fmt.Errorf("读取文件失败: %w", err)
+	}
+	var file courseFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return fmt.Errorf("解析课程文件失败: %w", err)
+	}
+	if file.Course.Title == "" {
+		return fmt.Errorf("课程文件缺少标题")
+	}
+
+	svc, err := a.Services()
+	if err != nil {
+		return err
+	}
+
+	course := &models.Course{
+		Title:         file.Course.Title,
+		Description:   file.Course.Description,
+		CoverImage:    file.Course.CoverImage,
+		Price:         file.Course.Price,
+		OriginalPrice: file.Course.OriginalPrice,
+		CategoryID:    file.Course.CategoryID,
+		TeacherID:     file.Course.TeacherID,
+		Level:         file.Course.Level,
+		Duration:      file.Course.Duration,
+		Status:        file.Course.Status,
+	}
+	if categoryID != 0 {
+		course.CategoryID = categoryID
+	}
+	if teacher != "" {
+		user, err := a.findUser(svc.User, teacher)
+		if err != nil {
+			return err
+		}
+		course.TeacherID = user.ID
+	}
+
+	var chapters []*models.Chapter
+	lessons := 0
+	for _, c := range file.Chapters {
+		chapter := &models.Chapter{Title: c.Title, SortOrder: c.SortOrder}
+		for _, l := range c.Lessons {
+			chapter.Lessons = append(chapter.Lessons, &models.Lesson{
+				Title:     l.Title,
+				VideoURL:  l.VideoURL,
+				Duration:  l.Duration,
+				SortOrder: l.SortOrder,
+				Free:      l.Free,
+			})
+		}
+		lessons += len(chapter.Lessons)
+		chapters = append(chapters, chapter)
+	}
+
+	if err := svc.Course.ImportCourse(a.ctx, course, chapters); err != nil {
+		return err
+	}
+
+	result := map[string]interface{}{"course_id": course.ID, "title": course.Title, "chapters": len(chapters), "lessons": lessons}
+	return a.print(result, func(w io.Writer) {
+		fmt.Fprintf(w, "已导入课程 %s (ID: %d, %d个章节, %d个课时)\n", course.Title, course.ID, len(chapters), lessons)
+	})
+}
@@ -0,0 +1,304 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+
+	"online-education-api/migrations"
+	"online-education-api/models"
+)
+
+var dbCommands = map[string]command{
+	"migrate": {
+		usage: "<up [N] | down [N] | status | force VERSION | drift>",
+		run:   runDBMigrate,
+	},
+	"seed": {
+		usage: "[--password PASS]",
+		run:   runDBSeed,
+	},
+}
+
+// migrationResult 执行或回滚的迁移
+type migrationResult struct {
+	Version int64  `json:"version"`
+	Name    string `json:"name"`
+}
+
+func toMigrationResults(done []migrations.Migration) []migrationResult {
+	results := make([]migrationResult, 0, len(done))
+	for _, mig := range done {
+		results = append(results, migrationResult{Version: mig.Version, Name: mig.Name})
+	}
+	return results
+}
+
+func runDBMigrate(a *app, args []string) error {
+	rest, err := parse(a.flags("db migrate"), args)
+	if err != nil {
+		return err
+	}
+	if len(rest) == 0 {
+		return usageError("缺少迁移命令")
+	}
+
+	// 连接数据库前先检查参数
+	var steps int
+	var version int64
+	switch rest[0] {
+	case "up":
+		steps, err = optionalCount(rest[1:], 0)
+	case "down":
+		steps, err = optionalCount(rest[1:], 1)
+	case "force":
+		if len(rest) != 2 {
+			return usageError("force需要指定版本号")
+		}
+		if version, err = strconv.ParseInt(rest[1], 10, 64); err != nil {
+			return usageError("版本号格式错误: %s", rest[1])
+		}
+	case "status", "drift":
+	default:
+		return usageError("未知的迁移命令: %s", rest[0])
+	}
+	if err != nil {
+		return err
+	}
+
+	db, err := a.DB()
+	if err != nil {
+		return err
+	}
+
+	switch rest[0] {
+	case "up", "down":
+		m, err := migrations.New(db)
+		if err != nil {
+			return err
+		}
+
+		var done []migrations.Migration
+		if rest[0] == "up" {
+			done, err = m.Up(a.ctx, steps)
+		} else {
+			done, err = m.Down(a.ctx, steps)
+		}
+		// 部分迁移成功后失败时也输出已执行的迁移
+		if printErr := a.print(toMigrationResults(done), func(w io.Writer) {
+			verb := "已应用"
+			if rest[0] == "down" {
+				verb = "已回滚"
+			}
+			for _, mig := range done {
+				fmt.Fprintf(w, "%s %04d_%s\n", verb, mig.Version, mig.Name)
+			}
+			if err == nil && len(done) == 0 {
+				fmt.Fprintln(w, "没有需要执行的迁移")
+			}
+		}); printErr != nil {
+			return printErr
+		}
+		return err
+
+	case "status":
+		m, err := migrations.New(db)
+		if err != nil {
+			return err
+		}
+		statuses, err := m.Status(a.ctx)
+		if err != nil {
+			return err
+		}
+		return a.print(statuses, func(w io.Writer) {
+			for _, s := range statuses {
+				state := "未应用"
+				switch {
+				case s.Dirty:
+					state = "失败(dirty)"
+				case s.Applied:
+					state = "已应用 " + s.AppliedAt.Format("2006-01-02 15:04:05")
+				}
+				fmt.Fprintf(w, "%04d_%-32s %s\n", s.Version, s.Name, state)
+			}
+		})
+
+	case "force":
+		m, err := migrations.New(db)
+		if err != nil {
+			return err
+		}
+		if err := m.Force(a.ctx, version); err != nil {
+			return err
+		}
+		return a.print(map[string]int64{"version": version}, func(w io.Writer) {
+			fmt.Fprintf(w, "已将迁移版本设置为 %d\n", version)
+		})
+
+	case "drift":
+		drifts, err := migrations.CheckDrift(a.ctx, db)
+		if err != nil {
+			return err
+		}
+		if err := a.print(drifts, func(w io.Writer) {
+			if len(drifts) == 0 {
+				fmt.Fprintln(w, "表结构与服务层一致")
+			}
+			for _, d := range drifts {
+				fmt.Fprintln(w, d)
+			}
+		}); err != nil {
+			return err
+		}
+		if len(drifts) > 0 {
+			return fmt.Errorf("发现%d处表结构不一致，请执行 oectl db migrate up", len(drifts))
+		}
+		return nil
+	}
+	return nil
+}
+
+// optionalCount 读取可选的数量参数
+func optionalCount(args []string, def int) (int, error) {
+	if len(args) == 0 {
+		return def, nil
+	}
+	n, err := strconv.Atoi(args[0])
+	if err != nil || n < 0 || len(args) > 1 {
+		return 0, usageError("数量格式错误: %v", args)
+	}
+	return n, nil
+}
+
+// seedResult 写入的演示数据
+type seedResult struct {
+	Users      []userResult `json:"users"`
+	Categories []string     `json:"categories"`
+	Courses    []string     `json:"courses"`
+}
+
+// seedUsers 演示账号
+var seedUsers = []models.UserCreateRequest{
+	{Username: "admin", Email: "admin@example.com", Role: "admin"},
+	{Username: "teacher1", Email: "teacher1@example.com", Role: "teacher"},
+	{Username: "student1", Email: "student1@example.com", Role: "student"},
+}
+
+// seedCategories 演示课程分类
+var seedCategories = []string{"编程开发", "数据分析", "人工智能"}
+
+func runDBSeed(a *app, args []string) error {
+	var password string
+	fs := a.flags("db seed")
+	fs.StringVar(&password, "password", "", "演示账号的密码，为空时自动生成")
+	rest, err := parse(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := requireArgs(rest); err != nil {
+		return err
+	}
+	if password == "" {
+		if password, err = generatePassword(); err != nil {
+			return err
+		}
+	}
+
+	svc, err := a.Services()
+	if err != nil {
+		return err
+	}
+	userService := svc.User
+	categoryService := svc.CourseCategory
+	courseService := svc.Course
+
+	var result seedResult
+
+	// 已存在的账号保持不变
+	var teacherID int64
+	for _, u := range seedUsers {
+		existing, err := userService.GetUserByUsername(a.ctx, u.Username)
+		if err == nil {
+			if u.Role == "teacher" {
+				teacherID = existing.ID
+			}
+			continue
+		}
+		req := u
+		req.Password = password
+		user, err := userService.CreateUser(a.ctx, &req)
+		if err != nil {
+			return fmt.Errorf("创建用户%s失败: %w", u.Username, err)
+		}
+		if u.Role == "teacher" {
+			teacherID = user.ID
+		}
+		result.Users = append(result.Users, userResult{ID: user.ID, Username: user.Username, Email: user.Email, Role: user.Role, Password: password})
+	}
+
+	// 已有分类或课程时不再写入
+	categories, err := categoryService.GetAllCategories(a.ctx)
+	if err != nil {
+		return err
+	}
+	if len(categories) == 0 {
+		for i, name := range seedCategories {
+			category := &models.CourseCategory{Name: name, SortOrder: i}
+			if err := categoryService.CreateCategory(a.ctx, category); err != nil {
+				return fmt.Errorf("创建分类%s失败: %w", name, err)
+			}
+			categories = append(categories, &models.CourseCategoryResponse{ID: category.ID, Name: category.Name})
+			result.Categories = append(result.Categories, name)
+		}
+	}
+
+	existing, err := courseService.GetCourseList(a.ctx, models.CourseListQuery{Page: 1, PageSize: 1, IncludeDrafts: true})
+	if err != nil {
+		return err
+	}
+	if existing.Total == 0 && len(categories) > 0 {
+		course := &models.Course{
+			Title:       "Go语言基础入门",
+			Description: "掌握Go语言的基本语法和编程技巧",
+			Price:       99,
+			CategoryID:  categories[0].ID,
+			TeacherID:   teacherID,
+			Level:       1,
+			Duration:    360,
+			Status:      1,
+		}
+		chapters := []*models.Chapter{
+			{Title: "Go语言简介", SortOrder: 1, Lessons: []*models.Lesson{
+				{Title: "Go语言的起源与特点", Duration: 5, SortOrder: 1, Free: 1},
+				{Title: "环境搭建", Duration: 7, SortOrder: 2, Free: 1},
+			}},
+			{Title: "基本语法", SortOrder: 2, Lessons: []*models.Lesson{
+				{Title: "变量与常量", Duration: 10, SortOrder: 1},
+				{Title: "流程控制", Duration: 12, SortOrder: 2},
+			}},
+		}
+		if err := courseService.ImportCourse(a.ctx, course, chapters); err != nil {
+			return err
+		}
+		result.Courses = append(result.Courses, course.Title)
+	}
+
+	return a.print(result, func(w io.Writer) {
+		if len(result.Users)+len(result.Categories)+len(result.Courses) == 0 {
+			fmt.Fprintln(w, "演示数据已存在，未写入新数据")
+			return
+		}
+		for _, u := range result.Users {
+			fmt.Fprintf(w, "已创建用户 %s (%s)\n", u.Username, u.Role)
+		}
+		if len(result.Users) > 0 {
+			fmt.Fprintf(w, "演示账号密码: %s\n", password)
+		}
+		for _, name := range result.Categories {
+			fmt.Fprintf(w, "已创建分类 %s\n", name)
+		}
+		for _, title := range result.Courses {
+			fmt.Fprintf(w, "已创建课程 %s\n", title)
+		}
+	})
+}
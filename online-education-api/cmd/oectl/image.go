@@ -0,0 +1,37 @@
+package main
+
+import (
+	"fmt"
+	"io"
+)
+
+var imageCommands = map[string]command{
+	"purge": {
+		usage: "",
+		run:   runImagePurge,
+	},
+}
+
+// runImagePurge 删除超过IMAGE_ORPHAN_TTL仍未关联、或关联的对象已删除的图片及其缩略图
+func runImagePurge(a *app, args []string) error {
+	rest, err := parse(a.flags("image purge"), args)
+	if err != nil {
+		return err
+	}
+	if err := requireArgs(rest); err != nil {
+		return err
+	}
+
+	svc, err := a.Services()
+	if err != nil {
+		return err
+	}
+	purged, err := svc.Image.PurgeOrphans(a.ctx)
+	if err != nil {
+		return err
+	}
+
+	return a.print(map[string]int{"purged": purged}, func(w io.Writer) {
+		fmt.Fprintf(w, "已删除%d张无人引用的图片\n", purged)
+	})
+}
@@ -0,0 +1,222 @@
+// oectl 在线教育系统管理命令，取代原先scripts和tools目录下的零散脚本
+//
+// 用法:
+//
+//	oectl [--json] <命令> <子命令> [参数]
+//
+// 所有命令都复用config中的数据库配置和services层，加上--json后输出JSON便于脚本处理。
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+
+	"online-education-api/config"
+	"online-education-api/logger"
+	"online-education-api/repository/mysql"
+	"online-education-api/services"
+	"online-education-api/storage"
+)
+
+// errUsage 参数错误，输出用法后以状态码2退出
+var errUsage = errors.New("参数错误")
+
+// command 一个子命令
+type command struct {
+	usage string
+	run   func(a *app, args []string) error
+}
+
+// commands 按"命令 子命令"组织的全部子命令
+var commands = map[string]map[string]command{
+	"user":    userCommands,
+	"db":      dbCommands,
+	"course":  courseCommands,
+	"payment": paymentCommands,
+	"token":   tokenCommands,
+	"upload":  uploadCommands,
+	"image":   imageCommands,
+}
+
+// app 命令执行环境
+type app struct {
+	ctx    context.Context
+	json   bool
+	stdout io.Writer
+	stderr io.Writer
+
+	db *sql.DB
+}
+
+func main() {
+	a := &app{ctx: context.Background(), stdout: os.Stdout, stderr: os.Stderr}
+
+	// 日志输出到标准错误，避免混入--json的输出
+	logCfg := config.GetLogConfig()
+	logCfg.Output = os.Stderr
+	if os.Getenv("LOG_LEVEL") == "" {
+		logCfg.Level = slog.LevelWarn
+	}
+	logger.Init(logCfg)
+
+	code := a.main(os.Args[1:])
+	a.close()
+	os.Exit(code)
+}
+
+// main 执行命令并返回退出状态码
+func (a *app) main(args []string) int {
+	global := flag.NewFlagSet("oectl", flag.ContinueOnError)
+	global.SetOutput(io.Discard)
+	global.BoolVar(&a.json, "json", false, "以JSON格式输出")
+	if err := global.Parse(args); err != nil {
+		a.printUsage()
+		return 2
+	}
+	args = global.Args()
+
+	if len(args) < 2 {
+		a.printUsage()
+		return 2
+	}
+	group, ok := commands[args[0]]
+	if !ok {
+		a.printUsage()
+		return 2
+	}
+	cmd, ok := group[args[1]]
+	if !ok {
+		a.printUsage()
+		return 2
+	}
+
+	if err := cmd.run(a, args[2:]); err != nil {
+		if errors.Is(err, errUsage) {
+			fmt.Fprintf(a.stderr, "%v\n用法: oectl %s %s %s\n", err, args[0], args[1], cmd.usage)
+			return 2
+		}
+		a.printError(err)
+		return 1
+	}
+	return 0
+}
+
+// printUsage 输出全部命令的用法
+func (a *app) printUsage() {
+	fmt.Fprintln(a.stderr, "用法: oectl [--json] <命令> <子命令> [参数]")
+	fmt.Fprintln(a.stderr)
+
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		subs := make([]string, 0, len(commands[name]))
+		for sub := range commands[name] {
+			subs = append(subs, sub)
+		}
+		sort.Strings(subs)
+		for _, sub := range subs {
+			fmt.Fprintf(a.stderr, "  %s %s %s\n", name, sub, commands[name][sub].usage)
+		}
+	}
+}
+
+// flags 创建子命令的参数集合，每个子命令都支持--json
+func (a *app) flags(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.BoolVar(&a.json, "json", a.json, "以JSON格式输出")
+	return fs
+}
+
+// parse 解析参数，允许参数和位置参数交替出现，返回位置参数
+func parse(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, fmt.Errorf("%w: %v", errUsage, err)
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// DB 按需连接数据库，不需要数据库的命令(如token inspect)不会建立连接
+func (a *app) DB() (*sql.DB, error) {
+	if a.db != nil {
+		return a.db, nil
+	}
+	db, err := config.InitDB(config.GetDBConfig())
+	if err != nil {
+		return nil, err
+	}
+	a.db = db
+	return db, nil
+}
+
+// Services 基于数据库连接创建全部服务
+func (a *app) Services() (*services.Services, error) {
+	db, err := a.DB()
+	if err != nil {
+		return nil, err
+	}
+	files, err := storage.New(config.GetStorageConfig())
+	if err != nil {
+		return nil, err
+	}
+	return services.New(mysql.New(db), files, config.GetUploadConfig()), nil
+}
+
+func (a *app) close() {
+	if a.db != nil {
+		a.db.Close()
+	}
+}
+
+// print 输出结果，--json时将v编码为JSON，否则调用text输出文本
+func (a *app) print(v interface{}, text func(w io.Writer)) error {
+	if a.json {
+		enc := json.NewEncoder(a.stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	}
+	text(a.stdout)
+	return nil
+}
+
+// printError 输出错误，--json时输出{"error": "..."}
+func (a *app) printError(err error) {
+	if a.json {
+		json.NewEncoder(a.stdout).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+	fmt.Fprintln(a.stderr, "错误:", err)
+}
+
+// usageError 构造参数错误
+func usageError(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", errUsage, fmt.Sprintf(format, args...))
+}
+
+// requireArgs 检查位置参数数量
+func requireArgs(args []string, names ...string) error {
+	if len(args) != len(names) {
+		return usageError("需要参数 %s", strings.Join(names, " "))
+	}
+	return nil
+}
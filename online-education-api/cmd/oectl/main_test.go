@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"online-education-api/utils"
+)
+
+func newTestApp() (*app, *bytes.Buffer, *bytes.Buffer) {
+	var stdout, stderr bytes.Buffer
+	return &app{ctx: context.Background(), stdout: &stdout, stderr: &stderr}, &stdout, &stderr
+}
+
+func TestTokenInspectJSON(t *testing.T) {
+	token, err := utils.GenerateToken(42, "alice", "teacher")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// --json可以放在子命令参数之后
+	a, stdout, _ := newTestApp()
+	if code := a.main([]string{"token", "inspect", token, "--json"}); code != 0 {
+		t.Fatalf("exit code %d", code)
+	}
+
+	var result tokenResult
+	if err := json.Unmarshal(stdout.Bytes(), &result); err != nil {
+		t.Fatalf("invalid JSON output %q: %v", stdout.String(), err)
+	}
+	if !result.Valid || result.UserID != 42 || result.Username != "alice" || result.Role != "teacher" || result.Algorithm != "HS256" {
+		t.Fatalf("unexpected result %+v", result)
+	}
+}
+
+func TestTokenInspectInvalidSignature(t *testing.T) {
+	token, err := utils.GenerateToken(1, "bob", "student")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tampered := token[:len(token)-2] + "xx"
+
+	a, stdout, _ := newTestApp()
+	if code := a.main([]string{"--json", "token", "inspect", tampered}); code != 0 {
+		t.Fatalf("exit code %d", code)
+	}
+
+	var result tokenResult
+	if err := json.Unmarshal(stdout.Bytes(), &result); err != nil {
+		t.Fatal(err)
+	}
+	if result.Valid || result.Error == "" || result.Username != "bob" {
+		t.Fatalf("expected an invalid token with decoded claims, got %+v", result)
+	}
+}
+
+func TestUsageErrors(t *testing.T) {
+	tests := [][]string{
+		{},
+		{"user"},
+		{"user", "unknown"},
+		{"token", "inspect"},
+		{"user", "set-role", "alice"},
+		{"db", "migrate", "up", "abc"},
+	}
+	for _, args := range tests {
+		a, _, stderr := newTestApp()
+		if code := a.main(args); code != 2 {
+			t.Errorf("%v: exit code %d, want 2", args, code)
+		}
+		if !strings.Contains(stderr.String(), "用法") {
+			t.Errorf("%v: expected usage, got %q", args, stderr.String())
+		}
+	}
+}
+
+func TestJSONErrorOutput(t *testing.T) {
+	a, stdout, _ := newTestApp()
+	if code := a.main([]string{"--json", "token", "inspect", "not-a-token"}); code != 1 {
+		t.Fatalf("exit code %d, want 1", code)
+	}
+	var out map[string]string
+	if err := json.Unmarshal(stdout.Bytes(), &out); err != nil || out["error"] == "" {
+		t.Fatalf("expected a JSON error, got %q", stdout.String())
+	}
+}
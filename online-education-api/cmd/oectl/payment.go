@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"time"
+)
+
+var paymentCommands = map[string]command{
+	"reconcile": {
+		usage: "[--pending-timeout 24h] [--dry-run]",
+		run:   runPaymentReconcile,
+	},
+}
+
+// reconcileAction 对账时对一个订单执行的操作
+type reconcileAction struct {
+	OrderID  string `json:"order_id"`
+	UserID   int64  `json:"user_id"`
+	CourseID int64  `json:"course_id"`
+	Action   string `json:"action"` // enroll: 补充报名, expire: 超时关闭
+	Error    string `json:"error,omitempty"`
+}
+
+// reconcileResult 对账结果
+type reconcileResult struct {
+	DryRun  bool              `json:"dry_run"`
+	Actions []reconcileAction `json:"actions"`
+}
+
+// runPaymentReconcile 支付对账
+// 已完成但没有报名记录的订单补充报名，超时未支付的订单标记为failed
+func runPaymentReconcile(a *app, args []string) error {
+	var pendingTimeout time.Duration
+	var dryRun bool
+	fs := a.flags("payment reconcile")
+	fs.DurationVar(&pendingTimeout, "pending-timeout", 24*time.Hour, "待支付订单的超时时间")
+	fs.BoolVar(&dryRun, "dry-run", false, "只输出需要执行的操作，不修改数据")
+	rest, err := parse(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := requireArgs(rest); err != nil {
+		return err
+	}
+
+	svc, err := a.Services()
+	if err != nil {
+		return err
+	}
+	paymentService := svc.Payment
+	userCourseService := svc.UserCourse
+
+	result := reconcileResult{DryRun: dryRun, Actions: []reconcileAction{}}
+	failed := 0
+
+	completed, err := paymentService.GetPaymentsByStatus(a.ctx, "completed", time.Now())
+	if err != nil {
+		return err
+	}
+	for _, p := range completed {
+		// 有报名记录(包括已取消)说明回调已处理过
+		if _, err := userCourseService.GetUserCourseByID(a.ctx, p.UserID, p.CourseID); err == nil {
+			continue
+		}
+		action := reconcileAction{OrderID: p.OrderID, UserID: p.UserID, CourseID: p.CourseID, Action: "enroll"}
+		if !dryRun {
+			if err := userCourseService.EnrollCourse(a.ctx, p.UserID, p.CourseID); err != nil {
+				action.Error = err.Error()
+				failed++
+			}
+		}
+		result.Actions = append(result.Actions, action)
+	}
+
+	pending, err := paymentService.GetPaymentsByStatus(a.ctx, "pending", time.Now().Add(-pendingTimeout))
+	if err != nil {
+		return err
+	}
+	for _, p := range pending {
+		action := reconcileAction{OrderID: p.OrderID, UserID: p.UserID, CourseID: p.CourseID, Action: "expire"}
+		if !dryRun {
+			if err := paymentService.UpdatePaymentStatus(a.ctx, p.OrderID, p.TransactionID, "failed"); err != nil {
+				action.Error = err.Error()
+				failed++
+			}
+		}
+		result.Actions = append(result.Actions, action)
+	}
+
+	if err := a.print(result, func(w io.Writer) {
+		if len(result.Actions) == 0 {
+			fmt.Fprintln(w, "没有需要处理的订单")
+			return
+		}
+		for _, action := range result.Actions {
+			desc := "补充报名"
+			if action.Action == "expire" {
+				desc = "超时关闭"
+			}
+			status := "完成"
+			switch {
+			case dryRun:
+				status = "待执行"
+			case action.Error != "":
+				status = "失败: " + action.Error
+			}
+			fmt.Fprintf(w, "%s 用户%d 课程%d %s %s\n", action.OrderID, action.UserID, action.CourseID, desc, status)
+		}
+	}); err != nil {
+		return err
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d个订单处理失败", failed)
+	}
+	return nil
+}
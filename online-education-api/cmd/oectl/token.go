@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"online-education-api/utils"
+)
+
+var tokenCommands = map[string]command{
+	"inspect": {
+		usage: "<TOKEN>",
+		run:   runTokenInspect,
+	},
+}
+
+// tokenResult 令牌内容和校验结果
+type tokenResult struct {
+	Valid     bool       `json:"valid"`
+	Error     string     `json:"error,omitempty"`
+	Algorithm string     `json:"algorithm"`
+	UserID    int64      `json:"user_id"`
+	Username  string     `json:"username"`
+	Role      string     `json:"role"`
+	IssuedAt  *time.Time `json:"issued_at,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// runTokenInspect 解码JWT令牌，令牌无效(如已过期)时也会输出其中的声明
+func runTokenInspect(a *app, args []string) error {
+	rest, err := parse(a.flags("token inspect"), args)
+	if err != nil {
+		return err
+	}
+	if err := requireArgs(rest, "<TOKEN>"); err != nil {
+		return err
+	}
+
+	claims := &utils.Claims{}
+	token, _, err := jwt.NewParser().ParseUnverified(rest[0], claims)
+	if err != nil {
+		return fmt.Errorf("无法解码令牌: %w", err)
+	}
+
+	result := tokenResult{
+		Valid:     true,
+		Algorithm: token.Method.Alg(),
+		UserID:    claims.UserID,
+		Username:  claims.Username,
+		Role:      claims.Role,
+	}
+	if claims.IssuedAt != nil {
+		result.IssuedAt = &claims.IssuedAt.Time
+	}
+	if claims.ExpiresAt != nil {
+		result.ExpiresAt = &claims.ExpiresAt.Time
+	}
+	if _, err := utils.ParseToken(rest[0]); err != nil {
+		result.Valid = false
+		result.Error = err.Error()
+	}
+
+	return a.print(result, func(w io.Writer) {
+		if result.Valid {
+			fmt.Fprintln(w, "状态:   有效")
+		} else {
+			fmt.Fprintf(w, "状态:   无效 (%s)\n", result.Error)
+		}
+		fmt.Fprintf(w, "算法:   %s\n", result.Algorithm)
+		fmt.Fprintf(w, "用户ID: %d\n", result.UserID)
+		fmt.Fprintf(w, "用户名: %s\n", result.Username)
+		fmt.Fprintf(w, "角色:   %s\n", result.Role)
+		if result.IssuedAt != nil {
+			fmt.Fprintf(w, "签发:   %s\n", result.IssuedAt.Local().Format(time.DateTime))
+		}
+		if result.ExpiresAt != nil {
+			fmt.Fprintf(w, "过期:   %s\n", result.ExpiresAt.Local().Format(time.DateTime))
+		}
+	})
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"fmt"
+	"io"
+)
+
+var uploadCommands = map[string]command{
+	"purge": {
+		usage: "",
+		run:   runUploadPurge,
+	},
+}
+
+// runUploadPurge 删除超过UPLOAD_SESSION_TTL仍未完成的分片上传及其暂存文件
+// 暂存文件在UPLOAD_TEMP_DIR中，需要在能访问该目录的机器上执行
+func runUploadPurge(a *app, args []string) error {
+	rest, err := parse(a.flags("upload purge"), args)
+	if err != nil {
+		return err
+	}
+	if err := requireArgs(rest); err != nil {
+		return err
+	}
+
+	svc, err := a.Services()
+	if err != nil {
+		return err
+	}
+	purged, err := svc.Upload.PurgeExpired(a.ctx)
+	if err != nil {
+		return err
+	}
+
+	return a.print(map[string]int{"purged": purged}, func(w io.Writer) {
+		fmt.Fprintf(w, "已删除%d个过期的上传\n", purged)
+	})
+}
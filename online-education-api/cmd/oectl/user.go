@@ -0,0 +1,221 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"strconv"
+
+	"online-education-api/models"
+	"online-education-api/services"
+	"online-education-api/utils"
+)
+
+var userCommands = map[string]command{
+	"create": {
+		usage: "--username NAME --email EMAIL [--password PASS] [--role admin|teacher|student]",
+		run:   runUserCreate,
+	},
+	"reset-password": {
+		usage: "<用户ID或用户名> [--password PASS]",
+		run:   runUserResetPassword,
+	},
+	"set-role": {
+		usage: "<用户ID或用户名> <admin|teacher|student>",
+		run:   runUserSetRole,
+	},
+	"ban": {
+		usage: "<用户ID或用户名> [--unban]",
+		run:   runUserBan,
+	},
+}
+
+// userResult 用户命令的输出
+type userResult struct {
+	ID       int64  `json:"id"`
+	Username string `json:"username"`
+	Email    string `json:"email,omitempty"`
+	Role     string `json:"role,omitempty"`
+	Status   *int   `json:"status,omitempty"`
+	Password string `json:"password,omitempty"` // 仅在自动生成密码时返回
+}
+
+func (a *app) userService() (services.UserService, error) {
+	svc, err := a.Services()
+	if err != nil {
+		return nil, err
+	}
+	return svc.User, nil
+}
+
+// findUser 按用户ID或用户名查找用户
+func (a *app) findUser(svc services.UserService, idOrName string) (*models.User, error) {
+	if id, err := strconv.ParseInt(idOrName, 10, 64); err == nil {
+		return svc.GetUserByID(a.ctx, id)
+	}
+	return svc.GetUserByUsername(a.ctx, idOrName)
+}
+
+// generatePassword 生成随机密码
+func generatePassword() (string, error) {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("生成随机密码失败: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func runUserCreate(a *app, args []string) error {
+	var req models.UserCreateRequest
+	fs := a.flags("user create")
+	fs.StringVar(&req.Username, "username", "", "用户名")
+	fs.StringVar(&req.Email, "email", "", "邮箱")
+	fs.StringVar(&req.Password, "password", "", "密码，为空时自动生成")
+	fs.StringVar(&req.Role, "role", "student", "角色")
+	rest, err := parse(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := requireArgs(rest); err != nil {
+		return err
+	}
+
+	generated := ""
+	if req.Password == "" {
+		if generated, err = generatePassword(); err != nil {
+			return err
+		}
+		req.Password = generated
+	}
+	if err := utils.Validate(&req); err != nil {
+		return usageError("%v", err)
+	}
+
+	svc, err := a.userService()
+	if err != nil {
+		return err
+	}
+	user, err := svc.CreateUser(a.ctx, &req)
+	if err != nil {
+		return err
+	}
+
+	result := userResult{ID: user.ID, Username: user.Username, Email: user.Email, Role: user.Role, Password: generated}
+	return a.print(result, func(w io.Writer) {
+		fmt.Fprintf(w, "已创建用户 %s (ID: %d, 角色: %s)\n", user.Username, user.ID, user.Role)
+		if generated != "" {
+			fmt.Fprintf(w, "初始密码: %s\n", generated)
+		}
+	})
+}
+
+func runUserResetPassword(a *app, args []string) error {
+	var password string
+	fs := a.flags("user reset-password")
+	fs.StringVar(&password, "password", "", "新密码，为空时自动生成")
+	rest, err := parse(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := requireArgs(rest, "<用户ID或用户名>"); err != nil {
+		return err
+	}
+
+	generated := ""
+	if password == "" {
+		if generated, err = generatePassword(); err != nil {
+			return err
+		}
+		password = generated
+	}
+	if len(password) < 6 {
+		return usageError("密码至少6位")
+	}
+
+	svc, err := a.userService()
+	if err != nil {
+		return err
+	}
+	user, err := a.findUser(svc, rest[0])
+	if err != nil {
+		return err
+	}
+	if err := svc.ResetPassword(a.ctx, user.ID, password); err != nil {
+		return err
+	}
+
+	result := userResult{ID: user.ID, Username: user.Username, Password: generated}
+	return a.print(result, func(w io.Writer) {
+		fmt.Fprintf(w, "已重置用户 %s 的密码\n", user.Username)
+		if generated != "" {
+			fmt.Fprintf(w, "新密码: %s\n", generated)
+		}
+	})
+}
+
+func runUserSetRole(a *app, args []string) error {
+	rest, err := parse(a.flags("user set-role"), args)
+	if err != nil {
+		return err
+	}
+	if err := requireArgs(rest, "<用户ID或用户名>", "<角色>"); err != nil {
+		return err
+	}
+
+	svc, err := a.userService()
+	if err != nil {
+		return err
+	}
+	user, err := a.findUser(svc, rest[0])
+	if err != nil {
+		return err
+	}
+	if err := svc.SetRole(a.ctx, user.ID, rest[1]); err != nil {
+		return err
+	}
+
+	result := userResult{ID: user.ID, Username: user.Username, Role: rest[1]}
+	return a.print(result, func(w io.Writer) {
+		fmt.Fprintf(w, "用户 %s 的角色已从 %s 改为 %s\n", user.Username, user.Role, rest[1])
+	})
+}
+
+func runUserBan(a *app, args []string) error {
+	var unban bool
+	fs := a.flags("user ban")
+	fs.BoolVar(&unban, "unban", false, "解除禁用")
+	rest, err := parse(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := requireArgs(rest, "<用户ID或用户名>"); err != nil {
+		return err
+	}
+
+	svc, err := a.userService()
+	if err != nil {
+		return err
+	}
+	user, err := a.findUser(svc, rest[0])
+	if err != nil {
+		return err
+	}
+
+	status := 0
+	if unban {
+		status = 1
+	}
+	if err := svc.SetStatus(a.ctx, user.ID, status); err != nil {
+		return err
+	}
+
+	result := userResult{ID: user.ID, Username: user.Username, Status: &status}
+	return a.print(result, func(w io.Writer) {
+		if unban {
+			fmt.Fprintf(w, "已解除禁用用户 %s\n", user.Username)
+		} else {
+			fmt.Fprintf(w, "已禁用用户 %s，该用户将无法登录\n", user.Username)
+		}
+	})
+}
@@ -0,0 +1,25 @@
+package config
+
+import "time"
+
+// AnalyticsConfig 视频播放统计配置
+type AnalyticsConfig struct {
+	DedupWindow       time.Duration // 同一用户或会话在该时间内重复播放同一视频只计一次观看，播放会话在最后一个事件后保留同样的时间
+	FlushInterval     time.Duration // 缓冲的计数写入数据库的间隔
+	HeartbeatInterval time.Duration // 客户端发送心跳的间隔，两次事件之间计入的观看时长不超过它的两倍
+	MaxSessions       int           // 内存中最多保留的播放会话数，达到上限后拒绝新的匿名会话
+	SessionRateLimit  int           // 每个IP在SessionRateWindow内最多开始的匿名会话数
+	SessionRateWindow time.Duration // 匿名会话限流的时间窗口
+}
+
+// GetAnalyticsConfig 从环境变量获取视频播放统计配置
+func GetAnalyticsConfig() *AnalyticsConfig {
+	return &AnalyticsConfig{
+		DedupWindow:       getEnvDuration("ANALYTICS_DEDUP_WINDOW", 30*time.Minute),
+		FlushInterval:     getEnvDuration("ANALYTICS_FLUSH_INTERVAL", 10*time.Second),
+		HeartbeatInterval: getEnvDuration("ANALYTICS_HEARTBEAT_INTERVAL", 15*time.Second),
+		MaxSessions:       getEnvInt("ANALYTICS_MAX_SESSIONS", 100000),
+		SessionRateLimit:  getEnvInt("ANALYTICS_SESSION_RATE_LIMIT", 30),
+		SessionRateWindow: getEnvDuration("ANALYTICS_SESSION_RATE_WINDOW", time.Minute),
+	}
+}
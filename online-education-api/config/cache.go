@@ -0,0 +1,17 @@
+package config
+
+import "time"
+
+// CacheConfig 课程目录缓存配置
+type CacheConfig struct {
+	Size int           // 最多缓存的条目数，为0时不启用缓存
+	TTL  time.Duration // 条目的有效期
+}
+
+// GetCacheConfig 从环境变量获取缓存配置
+func GetCacheConfig() *CacheConfig {
+	return &CacheConfig{
+		Size: getEnvInt("CACHE_SIZE", 10000),
+		TTL:  getEnvDuration("CACHE_TTL", time.Minute),
+	}
+}
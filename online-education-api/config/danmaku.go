@@ -0,0 +1,19 @@
+package config
+
+import "time"
+
+// DanmakuConfig 弹幕配置
+type DanmakuConfig struct {
+	RateLimit    int           // 每个用户在RateWindow内最多发送的弹幕数
+	RateWindow   time.Duration // 限流的时间窗口，同一用户在窗口内重复发送相同内容的弹幕会被拒绝
+	BlockedWords string        // 逗号分隔的屏蔽词，不区分大小写，包含屏蔽词的弹幕会被拒绝
+}
+
+// GetDanmakuConfig 从环境变量获取弹幕配置
+func GetDanmakuConfig() *DanmakuConfig {
+	return &DanmakuConfig{
+		RateLimit:    getEnvInt("DANMAKU_RATE_LIMIT", 5),
+		RateWindow:   getEnvDuration("DANMAKU_RATE_WINDOW", 10*time.Second),
+		BlockedWords: getEnv("DANMAKU_BLOCKED_WORDS", ""),
+	}
+}
@@ -3,10 +3,10 @@ package config
 import (
 	"database/sql"
 	"fmt"
-	"log"
 	"time"
 
 	_ "github.com/go-sql-driver/mysql"
+	"online-education-api/logger"
 )
 
 // DBConfig 数据库配置
@@ -62,6 +62,6 @@ func InitDB(config *DBConfig) (*sql.DB, error) {
 		return nil, fmt.Errorf("无法连接到数据库: %w", err)
 	}
 
-	log.Println("成功连接到数据库")
+	logger.New("config").Info("成功连接到数据库", "host", config.Host, "db", config.DBName)
 	return db, nil
 }
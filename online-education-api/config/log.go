@@ -0,0 +1,23 @@
+package config
+
+import (
+	"log/slog"
+	"os"
+
+	"online-education-api/logger"
+)
+
+// GetLogConfig 从环境变量获取日志配置
+// LOG_LEVEL 设置默认级别(debug, info, warn, error)，默认为info
+// LOG_PACKAGE_LEVELS 按包设置级别，例如"services=debug,access=warn"
+func GetLogConfig() logger.Config {
+	level := slog.LevelInfo
+	if l, ok := logger.ParseLevel(os.Getenv("LOG_LEVEL")); ok {
+		level = l
+	}
+
+	return logger.Config{
+		Level:         level,
+		PackageLevels: logger.ParsePackageLevels(os.Getenv("LOG_PACKAGE_LEVELS")),
+	}
+}
@@ -0,0 +1,22 @@
+package config
+
+import (
+	"os"
+	"time"
+)
+
+// PlaybackConfig 视频播放地址签名配置
+type PlaybackConfig struct {
+	SigningKey string        // 签名密钥，多实例部署时必须相同；为空时启动时随机生成，重启后已签发的地址失效
+	URLTTL     time.Duration // 签名地址的有效期，需要覆盖一次观看的时长
+	BindIP     bool          // 签名地址只能由签名时的客户端IP使用
+}
+
+// GetPlaybackConfig 从环境变量获取视频播放地址签名配置
+func GetPlaybackConfig() *PlaybackConfig {
+	return &PlaybackConfig{
+		SigningKey: os.Getenv("PLAYBACK_SIGNING_KEY"),
+		URLTTL:     getEnvDuration("PLAYBACK_URL_TTL", 2*time.Hour),
+		BindIP:     getEnvBool("PLAYBACK_BIND_IP", false),
+	}
+}
@@ -0,0 +1,69 @@
+package config
+
+import (
+	"os"
+	"strconv"
+	"time"
+)
+
+// ServerConfig HTTP服务器配置
+type ServerConfig struct {
+	Addr              string
+	ReadTimeout       time.Duration
+	ReadHeaderTimeout time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+	MaxHeaderBytes    int
+	ShutdownTimeout   time.Duration // 收到退出信号后等待请求处理完成的最长时间
+	HookTimeout       time.Duration // 每个清理函数的最长执行时间，与等待请求的时间分别计算
+	TLSCertFile       string        // 为空时使用HTTP
+	TLSKeyFile        string
+}
+
+// GetServerConfig 从环境变量获取服务器配置
+func GetServerConfig() *ServerConfig {
+	return &ServerConfig{
+		Addr:              getEnv("SERVER_ADDR", ":8082"),
+		ReadTimeout:       getEnvDuration("SERVER_READ_TIMEOUT", 15*time.Second),
+		ReadHeaderTimeout: getEnvDuration("SERVER_READ_HEADER_TIMEOUT", 5*time.Second),
+		WriteTimeout:      getEnvDuration("SERVER_WRITE_TIMEOUT", 30*time.Second),
+		IdleTimeout:       getEnvDuration("SERVER_IDLE_TIMEOUT", 120*time.Second),
+		MaxHeaderBytes:    getEnvInt("SERVER_MAX_HEADER_BYTES", 1<<20),
+		ShutdownTimeout:   getEnvDuration("SERVER_SHUTDOWN_TIMEOUT", 30*time.Second),
+		HookTimeout:       getEnvDuration("SERVER_HOOK_TIMEOUT", 10*time.Second),
+		TLSCertFile:       os.Getenv("TLS_CERT_FILE"),
+		TLSKeyFile:        os.Getenv("TLS_KEY_FILE"),
+	}
+}
+
+// getEnv 读取字符串环境变量，未设置时返回默认值
+func getEnv(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
+
+// getEnvInt 读取整数环境变量，未设置或格式错误时返回默认值
+func getEnvInt(key string, def int) int {
+	if v, err := strconv.Atoi(os.Getenv(key)); err == nil {
+		return v
+	}
+	return def
+}
+
+// getEnvDuration 读取时长环境变量(如"30s")，未设置或格式错误时返回默认值
+func getEnvDuration(key string, def time.Duration) time.Duration {
+	if v, err := time.ParseDuration(os.Getenv(key)); err == nil {
+		return v
+	}
+	return def
+}
+
+// getEnvBool 读取布尔环境变量(如"true"、"1")，未设置或格式错误时返回默认值
+func getEnvBool(key string, def bool) bool {
+	if v, err := strconv.ParseBool(os.Getenv(key)); err == nil {
+		return v
+	}
+	return def
+}
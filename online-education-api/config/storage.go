@@ -0,0 +1,56 @@
+package config
+
+import (
+	"os"
+	"time"
+)
+
+// StorageConfig 上传文件的对象存储配置
+type StorageConfig struct {
+	Driver    string // local或s3
+	LocalDir  string // local存储保存文件的目录
+	PublicURL string // 客户端访问文件的地址前缀，以"/"开头时由API服务器提供下载
+	// S3兼容存储(AWS S3、MinIO等)的连接参数
+	S3Endpoint  string
+	S3Region    string
+	S3Bucket    string
+	S3AccessKey string
+	S3SecretKey string
+}
+
+// GetStorageConfig 从环境变量获取对象存储配置
+func GetStorageConfig() *StorageConfig {
+	return &StorageConfig{
+		Driver:      getEnv("STORAGE_DRIVER", "local"),
+		LocalDir:    getEnv("STORAGE_LOCAL_DIR", "data/media"),
+		PublicURL:   getEnv("STORAGE_PUBLIC_URL", "/media"),
+		S3Endpoint:  os.Getenv("S3_ENDPOINT"),
+		S3Region:    getEnv("S3_REGION", "us-east-1"),
+		S3Bucket:    os.Getenv("S3_BUCKET"),
+		S3AccessKey: os.Getenv("S3_ACCESS_KEY"),
+		S3SecretKey: os.Getenv("S3_SECRET_KEY"),
+	}
+}
+
+// UploadConfig 视频和图片上传配置
+type UploadConfig struct {
+	MaxSize    int64         // 单个视频文件的最大字节数
+	TempDir    string        // 分片上传过程中暂存文件的目录，多实例部署时需要共享或按上传ID固定路由
+	SessionTTL time.Duration // 未完成的分片上传保留的时间
+
+	ImageMaxSize   int64         // 单张图片的最大字节数，图片在内存中处理
+	ImageMaxPixels int           // 单张图片的最大像素数，解码后每个像素占4字节
+	ImageOrphanTTL time.Duration // 未关联实体的图片保留的时间
+}
+
+// GetUploadConfig 从环境变量获取视频和图片上传配置
+func GetUploadConfig() *UploadConfig {
+	return &UploadConfig{
+		MaxSize:        int64(getEnvInt("UPLOAD_MAX_SIZE", 2<<30)),
+		TempDir:        getEnv("UPLOAD_TEMP_DIR", "data/uploads"),
+		SessionTTL:     getEnvDuration("UPLOAD_SESSION_TTL", 24*time.Hour),
+		ImageMaxSize:   int64(getEnvInt("IMAGE_MAX_SIZE", 10<<20)),
+		ImageMaxPixels: getEnvInt("IMAGE_MAX_PIXELS", 25_000_000),
+		ImageOrphanTTL: getEnvDuration("IMAGE_ORPHAN_TTL", 24*time.Hour),
+	}
+}
@@ -0,0 +1,30 @@
+package config
+
+import (
+	"os"
+	"time"
+)
+
+// TranscodeConfig 视频转码配置
+type TranscodeConfig struct {
+	Workers      int           // 同时转码的视频数，为0时不转码，上传的视频直接使用原文件播放
+	FFmpegPath   string        // ffmpeg可执行文件，为空时从PATH中查找
+	FFprobePath  string        // ffprobe可执行文件，为空时从PATH中查找
+	WorkDir      string        // 下载源文件和生成HLS分片的临时目录，为空时使用系统临时目录
+	Renditions   string        // 逗号分隔的清晰度，高于源文件分辨率的清晰度会被跳过
+	Timeout      time.Duration // 单个视频的转码超时
+	PollInterval time.Duration // 检查待转码视频的间隔，新上传的视频会立即处理，轮询用于重启后继续未完成的任务
+}
+
+// GetTranscodeConfig 从环境变量获取视频转码配置
+func GetTranscodeConfig() *TranscodeConfig {
+	return &TranscodeConfig{
+		Workers:      getEnvInt("TRANSCODE_WORKERS", 2),
+		FFmpegPath:   os.Getenv("FFMPEG_PATH"),
+		FFprobePath:  os.Getenv("FFPROBE_PATH"),
+		WorkDir:      os.Getenv("TRANSCODE_WORK_DIR"),
+		Renditions:   getEnv("TRANSCODE_RENDITIONS", "360p,720p,1080p"),
+		Timeout:      getEnvDuration("TRANSCODE_TIMEOUT", 2*time.Hour),
+		PollInterval: getEnvDuration("TRANSCODE_POLL_INTERVAL", time.Minute),
+	}
+}
@@ -25,26 +25,15 @@ func NewCommentController(commentService services.CommentService) *CommentContro
 // GetCommentList 获取评论列表
 func (c *CommentController) GetCommentList(w http.ResponseWriter, r *http.Request) {
 	// 获取查询参数
-	pageStr := r.URL.Query().Get("page")
-	pageSizeStr := r.URL.Query().Get("pageSize")
 	videoIDStr := r.URL.Query().Get("videoID")
 
 	// 默认值
-	page := 1
-	pageSize := 10
 	videoID := 0
 
 	// 解析参数
-	if pageStr != "" {
-		if p, err := strconv.Atoi(pageStr); err == nil && p > 0 {
-			page = p
-		}
-	}
-
-	if pageSizeStr != "" {
-		if ps, err := strconv.Atoi(pageSizeStr); err == nil && ps > 0 {
-			pageSize = ps
-		}
+	page, ok := parsePage(w, r)
+	if !ok {
+		return
 	}
 
 	if videoIDStr != "" {
@@ -54,7 +43,7 @@ func (c *CommentController) GetCommentList(w http.ResponseWriter, r *http.Reques
 	}
 
 	// 调用服务方法
-	comments, total, err := c.commentService.GetCommentList(page, pageSize, videoID)
+	comments, err := c.commentService.GetCommentList(r.Context(), page, videoID)
 	if err != nil {
 		http.Error(w, "获取评论列表失败: " + err.Error(), http.StatusInternalServerError)
 		return
@@ -63,12 +52,11 @@ func (c *CommentController) GetCommentList(w http.ResponseWriter, r *http.Reques
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"success": true,
-		"data": map[string]interface{}{
-			"list":  comments,
-			"total": total,
-		},
-		"page":    page,
-		"pageSize": pageSize,
+		"data": pageFields(map[string]interface{}{
+			"list": comments.Items,
+		}, comments),
+		"page":     page.Number,
+		"pageSize": page.Size,
 	})
 }
 
@@ -85,7 +73,7 @@ func (c *CommentController) DeleteComment(w http.ResponseWriter, r *http.Request
 	}
 
 	// 调用服务方法
-	if err := c.commentService.DeleteComment(id); err != nil {
+	if err := c.commentService.DeleteComment(r.Context(), id); err != nil {
 		http.Error(w, "删除评论失败: " + err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -8,6 +8,7 @@ import (
 	"github.com/gorilla/mux"
 	"online-education-api/models"
 	"online-education-api/services"
+	"online-education-api/utils"
 )
 
 // CourseCategoryController 课程分类控制器
@@ -24,7 +25,7 @@ func NewCourseCategoryController(courseCategoryService services.CourseCategorySe
 
 // GetAllCategories 获取所有课程分类
 func (c *CourseCategoryController) GetAllCategories(w http.ResponseWriter, r *http.Request) {
-	categories, err := c.courseCategoryService.GetAllCategories()
+	categories, err := c.courseCategoryService.GetAllCategories(r.Context())
 	if err != nil {
 		http.Error(w, "获取分类失败: "+err.Error(), http.StatusInternalServerError)
 		return
@@ -50,7 +51,7 @@ func (c *CourseCategoryController) GetCategoryByID(w http.ResponseWriter, r *htt
 		return
 	}
 
-	category, err := c.courseCategoryService.GetCategoryByID(categoryID)
+	category, err := c.courseCategoryService.GetCategoryByID(r.Context(), categoryID)
 	if err != nil {
 		http.Error(w, "获取分类失败: "+err.Error(), http.StatusInternalServerError)
 		return
@@ -66,13 +67,18 @@ func (c *CourseCategoryController) GetCategoryByID(w http.ResponseWriter, r *htt
 
 // CreateCategory 创建课程分类
 func (c *CourseCategoryController) CreateCategory(w http.ResponseWriter, r *http.Request) {
-	var category models.CourseCategory
-	if err := json.NewDecoder(r.Body).Decode(&category); err != nil {
-		http.Error(w, "请求参数错误", http.StatusBadRequest)
+	var req models.CourseCategoryRequest
+	if err := utils.DecodeJSON(w, r, &req); err != nil {
+		utils.WriteRequestError(w, err)
 		return
 	}
 
-	if err := c.courseCategoryService.CreateCategory(&category); err != nil {
+	category := models.CourseCategory{
+		Name:      req.Name,
+		ParentID:  req.ParentID,
+		SortOrder: req.SortOrder,
+	}
+	if err := c.courseCategoryService.CreateCategory(r.Context(), &category); err != nil {
 		http.Error(w, "创建分类失败: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -97,14 +103,19 @@ func (c *CourseCategoryController) UpdateCategory(w http.ResponseWriter, r *http
 		return
 	}
 
-	var category models.CourseCategory
-	if err := json.NewDecoder(r.Body).Decode(&category); err != nil {
-		http.Error(w, "请求参数错误", http.StatusBadRequest)
+	var req models.CourseCategoryRequest
+	if err := utils.DecodeJSON(w, r, &req); err != nil {
+		utils.WriteRequestError(w, err)
 		return
 	}
 
-	category.ID = categoryID
-	if err := c.courseCategoryService.UpdateCategory(&category); err != nil {
+	category := models.CourseCategory{
+		ID:        categoryID,
+		Name:      req.Name,
+		ParentID:  req.ParentID,
+		SortOrder: req.SortOrder,
+	}
+	if err := c.courseCategoryService.UpdateCategory(r.Context(), &category); err != nil {
 		http.Error(w, "更新分类失败: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -129,7 +140,7 @@ func (c *CourseCategoryController) DeleteCategory(w http.ResponseWriter, r *http
 		return
 	}
 
-	if err := c.courseCategoryService.DeleteCategory(categoryID); err != nil {
+	if err := c.courseCategoryService.DeleteCategory(r.Context(), categoryID); err != nil {
 		http.Error(w, "删除分类失败: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -3,85 +3,150 @@ package controllers
 import (
 	"encoding/json"
 	"net/http"
+	"slices"
 	"strconv"
 
 	"github.com/gorilla/mux"
 	"online-education-api/models"
+	"online-education-api/pagination"
 	"online-education-api/services"
+	"online-education-api/urlsign"
+	"online-education-api/utils"
 )
 
 // CourseController 课程控制器
 type CourseController struct {
-	courseService services.CourseService
+	courseService   services.CourseService
+	playbackService services.PlaybackService
 }
 
 // NewCourseController 创建课程控制器实例
-func NewCourseController(courseService services.CourseService) *CourseController {
+func NewCourseController(courseService services.CourseService, playbackService services.PlaybackService) *CourseController {
 	return &CourseController{
-		courseService: courseService,
+		courseService:   courseService,
+		playbackService: playbackService,
 	}
 }
 
+// courseSorts 课程列表支持的排序方式
+var courseSorts = []string{"newest", "popular", "rating", "price_asc", "price_desc"}
+
 // GetCourseList 获取课程列表
+// 未登录用户和学生只能看到已上架的课程；教师还能看到自己未上架的课程，管理员能看到全部课程
 func (c *CourseController) GetCourseList(w http.ResponseWriter, r *http.Request) {
 	// 获取查询参数
-	page := 1
-	pageSize := 10
-	categoryID := int64(0)
-	level := int64(0)
-	search := ""
-
-	if r.URL.Query().Get("page") != "" {
-		p, err := strconv.Atoi(r.URL.Query().Get("page"))
+	query := r.URL.Query()
+	q := models.CourseListQuery{Page: 1, PageSize: 10}
+
+	if query.Get("page") != "" {
+		p, err := strconv.Atoi(query.Get("page"))
 		if err == nil && p > 0 {
-			page = p
+			q.Page = p
 		}
 	}
 
-	if r.URL.Query().Get("pageSize") != "" {
-		ps, err := strconv.Atoi(r.URL.Query().Get("pageSize"))
+	if query.Get("pageSize") != "" {
+		ps, err := strconv.Atoi(query.Get("pageSize"))
 		if err == nil && ps > 0 {
-			pageSize = ps
+			q.PageSize = min(ps, pagination.MaxPageSize)
 		}
 	}
 
-	if r.URL.Query().Get("categoryID") != "" {
-		cid, err := strconv.ParseInt(r.URL.Query().Get("categoryID"), 10, 64)
+	if query.Get("categoryID") != "" {
+		cid, err := strconv.ParseInt(query.Get("categoryID"), 10, 64)
 		if err == nil && cid > 0 {
-			categoryID = cid
+			q.CategoryID = cid
 		}
 	}
 
-	if r.URL.Query().Get("level") != "" {
-		l, err := strconv.ParseInt(r.URL.Query().Get("level"), 10, 64)
+	if query.Get("level") != "" {
+		l, err := strconv.ParseInt(query.Get("level"), 10, 64)
 		if err == nil && l > 0 {
-			level = l
+			q.Level = l
+		}
+	}
+
+	q.Search = query.Get("search")
+	q.IncludeSubcategories = query.Get("includeSubcategories") == "true"
+	q.FreeOnly = query.Get("free") == "true"
+
+	for _, p := range []struct {
+		name  string
+		value **float64
+	}{{"minPrice", &q.MinPrice}, {"maxPrice", &q.MaxPrice}} {
+		if v := query.Get(p.name); v != "" {
+			price, err := strconv.ParseFloat(v, 64)
+			if err != nil || price < 0 {
+				http.Error(w, "无效的价格: "+p.name, http.StatusBadRequest)
+				return
+			}
+			*p.value = &price
+		}
+	}
+
+	if query.Get("teacherID") != "" {
+		tid, err := strconv.ParseInt(query.Get("teacherID"), 10, 64)
+		if err != nil || tid <= 0 {
+			http.Error(w, "无效的教师ID", http.StatusBadRequest)
+			return
+		}
+		q.TeacherID = tid
+	}
+
+	if query.Get("minRating") != "" {
+		rating, err := strconv.ParseFloat(query.Get("minRating"), 64)
+		if err != nil || rating < 0 || rating > 5 {
+			http.Error(w, "无效的评分", http.StatusBadRequest)
+			return
 		}
+		q.MinRating = rating
 	}
 
-	if r.URL.Query().Get("search") != "" {
-		search = r.URL.Query().Get("search")
+	if sort := query.Get("sort"); sort != "" {
+		if !slices.Contains(courseSorts, sort) {
+			http.Error(w, "无效的排序方式: "+sort, http.StatusBadRequest)
+			return
+		}
+		q.Sort = sort
 	}
 
-	courses, total, err := c.courseService.GetCourseList(page, pageSize, categoryID, level, search)
+	// 根据当前用户决定能否看到未上架的课程
+	switch role, _ := r.Context().Value("role").(string); role {
+	case "admin":
+		q.IncludeDrafts = true
+	case "teacher":
+		q.DraftsOf, _ = r.Context().Value("userID").(int64)
+	}
+
+	result, err := c.courseService.GetCourseList(r.Context(), q)
 	if err != nil {
 		http.Error(w, "获取课程列表失败: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 
+	// 列表内容随登录用户变化
+	w.Header().Add("Vary", "Authorization")
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"code": 200,
 		"msg":  "success",
 		"data": map[string]interface{}{
-			"list":  courses,
-			"total": total,
-			"page":  page,
-			"pageSize": pageSize,
+			"list":     result.List,
+			"total":    result.Total,
+			"facets":   result.Facets,
+			"page":     q.Page,
+			"pageSize": q.PageSize,
 		},
 	})
 }
 
+// canViewDraft 判断当前用户能否查看未上架的课程：管理员或课程的教师
+func canViewDraft(r *http.Request, teacherID int64) bool {
+	role, _ := r.Context().Value("role").(string)
+	userID, _ := r.Context().Value("userID").(int64)
+	return role == "admin" || role == "teacher" && userID == teacherID
+}
+
 // GetCourseDetail 获取课程详情
 func (c *CourseController) GetCourseDetail(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
@@ -94,12 +159,27 @@ func (c *CourseController) GetCourseDetail(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	course, err := c.courseService.GetCourseDetail(courseID)
+	course, err := c.courseService.GetCourseDetail(r.Context(), courseID)
 	if err != nil {
 		http.Error(w, "获取课程详情失败: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 
+	// 未上架的课程对其他用户不可见
+	w.Header().Add("Vary", "Authorization")
+	if course.Status != 1 && !canViewDraft(r, course.TeacherID) {
+		http.Error(w, "课程不存在", http.StatusNotFound)
+		return
+	}
+
+	// 只有有权观看的用户能拿到收费课时的视频地址
+	userID, _ := r.Context().Value("userID").(int64)
+	role, _ := r.Context().Value("role").(string)
+	if err := c.playbackService.SignCourse(r.Context(), course, userID, role == "admin", urlsign.ClientIP(r)); err != nil {
+		http.Error(w, "获取课程详情失败: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"code": 200,
@@ -118,8 +198,8 @@ func (c *CourseController) CreateCourse(w http.ResponseWriter, r *http.Request)
 	}
 
 	var req models.CreateCourseRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "请求参数错误", http.StatusBadRequest)
+	if err := utils.DecodeJSON(w, r, &req); err != nil {
+		utils.WriteRequestError(w, err)
 		return
 	}
 
@@ -139,7 +219,7 @@ func (c *CourseController) CreateCourse(w http.ResponseWriter, r *http.Request)
 		Status:        req.Status,
 	}
 
-	if err := c.courseService.CreateCourse(course); err != nil {
+	if err := c.courseService.CreateCourse(r.Context(), course); err != nil {
 		http.Error(w, "创建课程失败: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -172,7 +252,7 @@ func (c *CourseController) UpdateCourse(w http.ResponseWriter, r *http.Request)
 	}
 
 	// 检查课程是否存在，并且是否为当前用户创建
-	course, err := c.courseService.GetCourseDetail(courseID)
+	course, err := c.courseService.GetCourseDetail(r.Context(), courseID)
 	if err != nil {
 		http.Error(w, "获取课程失败: "+err.Error(), http.StatusInternalServerError)
 		return
@@ -184,8 +264,8 @@ func (c *CourseController) UpdateCourse(w http.ResponseWriter, r *http.Request)
 	}
 
 	var req models.UpdateCourseRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "请求参数错误", http.StatusBadRequest)
+	if err := utils.DecodeJSON(w, r, &req); err != nil {
+		utils.WriteRequestError(w, err)
 		return
 	}
 
@@ -211,11 +291,11 @@ func (c *CourseController) UpdateCourse(w http.ResponseWriter, r *http.Request)
 	if req.Level > 0 {
 		course.Level = req.Level
 	}
-	if req.Status >= 0 {
-		course.Status = req.Status
+	if req.Status != nil {
+		course.Status = *req.Status
 	}
 
-	if err := c.courseService.UpdateCourse(&course.Course); err != nil {
+	if err := c.courseService.UpdateCourse(r.Context(), &course.Course); err != nil {
 		http.Error(w, "更新课程失败: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -248,7 +328,7 @@ func (c *CourseController) DeleteCourse(w http.ResponseWriter, r *http.Request)
 	}
 
 	// 检查课程是否存在，并且是否为当前用户创建
-	course, err := c.courseService.GetCourseDetail(courseID)
+	course, err := c.courseService.GetCourseDetail(r.Context(), courseID)
 	if err != nil {
 		http.Error(w, "获取课程失败: "+err.Error(), http.StatusInternalServerError)
 		return
@@ -259,7 +339,7 @@ func (c *CourseController) DeleteCourse(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	if err := c.courseService.DeleteCourse(courseID); err != nil {
+	if err := c.courseService.DeleteCourse(r.Context(), courseID); err != nil {
 		http.Error(w, "删除课程失败: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
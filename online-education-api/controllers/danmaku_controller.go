@@ -0,0 +1,247 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+
+	"online-education-api/models"
+	"online-education-api/services"
+	"online-education-api/utils"
+)
+
+// WebSocket连接的心跳参数
+const (
+	danmakuWriteWait  = 10 * time.Second
+	danmakuPongWait   = 60 * time.Second
+	danmakuPingPeriod = danmakuPongWait * 9 / 10
+)
+
+// danmakuUpgrader 与CORS配置一致允许所有来源；连接只推送弹幕，认证使用请求头中的令牌而不是Cookie，不存在跨站请求伪造
+var danmakuUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	CheckOrigin:     func(r *http.Request) bool { return true },
+}
+
+// DanmakuController 视频弹幕控制器
+type DanmakuController struct {
+	danmakuService services.DanmakuService
+}
+
+// NewDanmakuController 创建视频弹幕控制器实例
+func NewDanmakuController(danmakuService services.DanmakuService) *DanmakuController {
+	return &DanmakuController{
+		danmakuService: danmakuService,
+	}
+}
+
+// writeDanmakuError 将弹幕服务的错误转换为对应的状态码
+func writeDanmakuError(w http.ResponseWriter, prefix string, err error) {
+	status := http.StatusInternalServerError
+	var rateErr *services.RateLimitError
+	switch {
+	case errors.Is(err, services.ErrVideoNotFound), errors.Is(err, services.ErrDanmakuNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, services.ErrDanmakuForbidden):
+		status = http.StatusForbidden
+	case errors.Is(err, services.ErrDanmakuContent), errors.Is(err, services.ErrDanmakuColor),
+		errors.Is(err, services.ErrDanmakuOffset), errors.Is(err, services.ErrDanmakuRange):
+		status = http.StatusBadRequest
+	case errors.Is(err, services.ErrDanmakuBlocked):
+		status = http.StatusUnprocessableEntity
+	case errors.Is(err, services.ErrDanmakuDuplicate):
+		status = http.StatusConflict
+	case errors.As(err, &rateErr):
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(rateErr.RetryAfter.Seconds()))))
+		status = http.StatusTooManyRequests
+	case errors.Is(err, services.ErrDanmakuClosed):
+		status = http.StatusServiceUnavailable
+	}
+	http.Error(w, prefix+err.Error(), status)
+}
+
+// parseSeconds 解析查询参数中的秒数，未提供时返回def
+func parseSeconds(r *http.Request, name string, def float64) (float64, bool) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return def, true
+	}
+	seconds, err := strconv.ParseFloat(value, 64)
+	if err != nil || math.IsNaN(seconds) || math.IsInf(seconds, 0) {
+		return 0, false
+	}
+	return seconds, true
+}
+
+// GetDanmaku 获取视频的弹幕，from和to为播放位置(秒)，默认为整个视频
+func (c *DanmakuController) GetDanmaku(w http.ResponseWriter, r *http.Request) {
+	videoID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "无效的视频ID", http.StatusBadRequest)
+		return
+	}
+	from, ok := parseSeconds(r, "from", 0)
+	if !ok {
+		http.Error(w, "无效的from参数", http.StatusBadRequest)
+		return
+	}
+	to, ok := parseSeconds(r, "to", math.MaxInt32)
+	if !ok {
+		http.Error(w, "无效的to参数", http.StatusBadRequest)
+		return
+	}
+
+	userID, _ := r.Context().Value("userID").(int64)
+	role, _ := r.Context().Value("role").(string)
+	list, err := c.danmakuService.ListDanmaku(r.Context(), videoID, userID, role == "admin", from, to)
+	if err != nil {
+		writeDanmakuError(w, "获取弹幕失败: ", err)
+		return
+	}
+	if list == nil {
+		list = []*models.Danmaku{}
+	}
+
+	w.Header().Add("Vary", "Authorization")
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    list,
+	})
+}
+
+// SendDanmaku 发送弹幕
+func (c *DanmakuController) SendDanmaku(w http.ResponseWriter, r *http.Request) {
+	userID, isAdmin, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+	videoID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "无效的视频ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.CreateDanmakuRequest
+	if err := utils.DecodeJSON(w, r, &req); err != nil {
+		utils.WriteRequestError(w, err)
+		return
+	}
+
+	danmaku := models.Danmaku{
+		VideoID: videoID,
+		UserID:  userID,
+		Content: req.Content,
+		Offset:  req.Offset,
+		Color:   req.Color,
+		Mode:    req.Mode,
+	}
+	if err := c.danmakuService.SendDanmaku(r.Context(), &danmaku, isAdmin); err != nil {
+		writeDanmakuError(w, "发送弹幕失败: ", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    danmaku,
+	})
+}
+
+// DeleteDanmaku 删除弹幕，发送者、视频作者和管理员可以删除
+func (c *DanmakuController) DeleteDanmaku(w http.ResponseWriter, r *http.Request) {
+	userID, isAdmin, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+	vars := mux.Vars(r)
+	videoID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "无效的视频ID", http.StatusBadRequest)
+		return
+	}
+	id, err := strconv.ParseInt(vars["danmakuID"], 10, 64)
+	if err != nil {
+		http.Error(w, "无效的弹幕ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := c.danmakuService.DeleteDanmaku(r.Context(), videoID, id, userID, isAdmin); err != nil {
+		writeDanmakuError(w, "删除弹幕失败: ", err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// StreamDanmaku 通过WebSocket推送视频的新弹幕和删除消息，客户端发送的消息被忽略，发送弹幕使用 SendDanmaku
+func (c *DanmakuController) StreamDanmaku(w http.ResponseWriter, r *http.Request) {
+	videoID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "无效的视频ID", http.StatusBadRequest)
+		return
+	}
+
+	userID, _ := r.Context().Value("userID").(int64)
+	role, _ := r.Context().Value("role").(string)
+	messages, unsubscribe, err := c.danmakuService.Subscribe(r.Context(), videoID, userID, role == "admin")
+	if err != nil {
+		writeDanmakuError(w, "订阅弹幕失败: ", err)
+		return
+	}
+	defer unsubscribe()
+
+	// 升级失败时Upgrade已返回错误响应
+	conn, err := danmakuUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	// 读取循环处理pong和关闭帧，客户端断开或超过danmakuPongWait没有响应时结束
+	conn.SetReadLimit(512)
+	conn.SetReadDeadline(time.Now().Add(danmakuPongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(danmakuPongWait))
+	})
+	disconnected := make(chan struct{})
+	go func() {
+		defer close(disconnected)
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	ticker := time.NewTicker(danmakuPingPeriod)
+	defer ticker.Stop()
+	for {
+		select {
+		case msg, ok := <-messages:
+			conn.SetWriteDeadline(time.Now().Add(danmakuWriteWait))
+			if !ok {
+				// 服务关闭
+				conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseGoingAway, ""))
+				return
+			}
+			if err := conn.WriteJSON(msg); err != nil {
+				return
+			}
+		case <-ticker.C:
+			conn.SetWriteDeadline(time.Now().Add(danmakuWriteWait))
+			if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		case <-disconnected:
+			return
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"online-education-api/services"
+)
+
+// HealthController 健康检查控制器
+type HealthController struct {
+	healthService services.HealthService
+}
+
+// NewHealthController 创建健康检查控制器实例
+func NewHealthController(healthService services.HealthService) *HealthController {
+	return &HealthController{
+		healthService: healthService,
+	}
+}
+
+// Healthz 进程存活检查
+func (c *HealthController) Healthz(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status": "ok",
+	})
+}
+
+// Readyz 就绪检查，数据库不可用时返回503
+func (c *HealthController) Readyz(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := c.healthService.CheckReadiness(r.Context()); err != nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"status": "unavailable",
+			"error":  "数据库不可用: " + err.Error(),
+		})
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status": "ready",
+	})
+}
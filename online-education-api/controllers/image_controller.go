@@ -0,0 +1,247 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"online-education-api/models"
+	"online-education-api/services"
+	"online-education-api/utils"
+)
+
+// maxAltTextSize 表单中替代文本字段的最大字节数
+const maxAltTextSize = 255
+
+// ImageController 图片控制器
+type ImageController struct {
+	imageService services.ImageService
+}
+
+// NewImageController 创建图片控制器实例
+func NewImageController(imageService services.ImageService) *ImageController {
+	return &ImageController{
+		imageService: imageService,
+	}
+}
+
+// writeImageError 将图片服务的错误转换为对应的状态码
+func writeImageError(w http.ResponseWriter, prefix string, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, services.ErrImageNotFound), errors.Is(err, services.ErrImageEntity):
+		status = http.StatusNotFound
+	case errors.Is(err, services.ErrImageForbidden):
+		status = http.StatusForbidden
+	case errors.Is(err, services.ErrImageAttached):
+		status = http.StatusConflict
+	case errors.Is(err, services.ErrImageTooLarge):
+		status = http.StatusRequestEntityTooLarge
+	case errors.Is(err, services.ErrImageType):
+		status = http.StatusUnsupportedMediaType
+	}
+	http.Error(w, prefix+err.Error(), status)
+}
+
+// imageID 解析路径中的图片ID
+func imageID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "无效的图片ID", http.StatusBadRequest)
+		return 0, false
+	}
+	return id, true
+}
+
+// currentUser 获取当前登录用户的ID以及是否为管理员
+func currentUser(w http.ResponseWriter, r *http.Request) (int64, bool, bool) {
+	userID, ok := r.Context().Value("userID").(int64)
+	if !ok {
+		http.Error(w, "未登录", http.StatusUnauthorized)
+		return 0, false, false
+	}
+	role, _ := r.Context().Value("role").(string)
+	return userID, role == "admin", true
+}
+
+// writeImage 返回图片详情
+func writeImage(w http.ResponseWriter, status int, image *models.Image) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    image,
+	})
+}
+
+// UploadImage 以multipart/form-data上传图片，文件字段名为file，可选的alt_text字段需在文件之前
+func (c *ImageController) UploadImage(w http.ResponseWriter, r *http.Request) {
+	userID, _, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	reader, err := r.MultipartReader()
+	if err != nil {
+		http.Error(w, "请求必须为multipart/form-data", http.StatusBadRequest)
+		return
+	}
+	var altText string
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			http.Error(w, "缺少文件字段file", http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			http.Error(w, "解析表单失败: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if part.FormName() == "alt_text" {
+			value, err := io.ReadAll(io.LimitReader(part, maxAltTextSize+1))
+			part.Close()
+			if err != nil {
+				http.Error(w, "解析表单失败: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+			if len(value) > maxAltTextSize {
+				http.Error(w, "替代文本不能超过255字节", http.StatusBadRequest)
+				return
+			}
+			altText = string(value)
+			continue
+		}
+		if part.FormName() != "file" || part.FileName() == "" {
+			part.Close()
+			continue
+		}
+
+		image, err := c.imageService.UploadImage(r.Context(), userID, part.FileName(), altText, part)
+		part.Close()
+		if err != nil {
+			writeImageError(w, "上传图片失败: ", err)
+			return
+		}
+
+		w.Header().Set("Location", "/api/images/"+strconv.FormatInt(image.ID, 10))
+		writeImage(w, http.StatusCreated, image)
+		return
+	}
+}
+
+// GetImages 获取对象的图片列表，参数entity_type和entity_id必填
+func (c *ImageController) GetImages(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	entityType := query.Get("entity_type")
+	if entityType != models.ImageEntityUser && entityType != models.ImageEntityCourse && entityType != models.ImageEntityVideo {
+		http.Error(w, "entity_type必须为user、course或video", http.StatusBadRequest)
+		return
+	}
+	entityID, err := strconv.ParseInt(query.Get("entity_id"), 10, 64)
+	if err != nil || entityID <= 0 {
+		http.Error(w, "无效的entity_id", http.StatusBadRequest)
+		return
+	}
+
+	images, err := c.imageService.ListImages(r.Context(), entityType, entityID)
+	if err != nil {
+		writeImageError(w, "获取图片列表失败: ", err)
+		return
+	}
+	if images == nil {
+		images = []*models.Image{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    images,
+	})
+}
+
+// GetImage 获取图片详情
+func (c *ImageController) GetImage(w http.ResponseWriter, r *http.Request) {
+	id, ok := imageID(w, r)
+	if !ok {
+		return
+	}
+
+	image, err := c.imageService.GetImage(r.Context(), id)
+	if err != nil {
+		writeImageError(w, "获取图片失败: ", err)
+		return
+	}
+	writeImage(w, http.StatusOK, image)
+}
+
+// UpdateImage 修改图片的替代文本
+func (c *ImageController) UpdateImage(w http.ResponseWriter, r *http.Request) {
+	userID, isAdmin, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+	id, ok := imageID(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.UpdateImageRequest
+	if err := utils.DecodeJSON(w, r, &req); err != nil {
+		utils.WriteRequestError(w, err)
+		return
+	}
+
+	image, err := c.imageService.UpdateImage(r.Context(), id, userID, isAdmin, req.AltText)
+	if err != nil {
+		writeImageError(w, "更新图片失败: ", err)
+		return
+	}
+	writeImage(w, http.StatusOK, image)
+}
+
+// AttachImage 将图片关联到用户、课程或视频，设为主图时替换对应的头像或封面
+func (c *ImageController) AttachImage(w http.ResponseWriter, r *http.Request) {
+	userID, isAdmin, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+	id, ok := imageID(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.AttachImageRequest
+	if err := utils.DecodeJSON(w, r, &req); err != nil {
+		utils.WriteRequestError(w, err)
+		return
+	}
+
+	image, err := c.imageService.AttachImage(r.Context(), id, userID, isAdmin, req.EntityType, req.EntityID, req.IsPrimary)
+	if err != nil {
+		writeImageError(w, "关联图片失败: ", err)
+		return
+	}
+	writeImage(w, http.StatusOK, image)
+}
+
+// DeleteImage 删除图片及其缩略图
+func (c *ImageController) DeleteImage(w http.ResponseWriter, r *http.Request) {
+	userID, isAdmin, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+	id, ok := imageID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := c.imageService.DeleteImage(r.Context(), id, userID, isAdmin); err != nil {
+		writeImageError(w, "删除图片失败: ", err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,26 @@
+package controllers
+
+import (
+	"net/http"
+
+	"online-education-api/pagination"
+)
+
+// parsePage 解析分页参数，游标无效时返回400，调用方在ok为false时直接返回
+func parsePage(w http.ResponseWriter, r *http.Request) (page pagination.Page, ok bool) {
+	page, err := pagination.FromRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return page, false
+	}
+	return page, true
+}
+
+// pageFields 将总数和下一页的游标写入响应，请求了skipTotal时不返回total，没有下一页时next_cursor为空字符串
+func pageFields[T any](fields map[string]interface{}, list *pagination.List[T]) map[string]interface{} {
+	if list.Total != nil {
+		fields["total"] = *list.Total
+	}
+	fields["next_cursor"] = list.NextCursor
+	return fields
+}
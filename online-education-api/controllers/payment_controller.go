@@ -3,11 +3,11 @@ package controllers
 import (
 	"encoding/json"
 	"net/http"
-	"strconv"
 
 	"github.com/gorilla/mux"
 	"online-education-api/models"
 	"online-education-api/services"
+	"online-education-api/utils"
 )
 
 // PaymentController 支付控制器
@@ -33,19 +33,13 @@ func (c *PaymentController) CreatePayment(w http.ResponseWriter, r *http.Request
 
 	// 解析请求参数
 	var req models.CreatePaymentRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "请求参数错误", http.StatusBadRequest)
-		return
-	}
-
-	// 验证支付方式
-	if req.PaymentMethod != "wechat" && req.PaymentMethod != "alipay" {
-		http.Error(w, "不支持的支付方式", http.StatusBadRequest)
+	if err := utils.DecodeJSON(w, r, &req); err != nil {
+		utils.WriteRequestError(w, err)
 		return
 	}
 
 	// 创建支付订单
-	payment, err := c.paymentService.CreatePayment(userID, req.CourseID, req.PaymentMethod)
+	payment, err := c.paymentService.CreatePayment(r.Context(), userID, req.CourseID, req.PaymentMethod)
 	if err != nil {
 		http.Error(w, "创建支付订单失败: "+err.Error(), http.StatusInternalServerError)
 		return
@@ -74,7 +68,7 @@ func (c *PaymentController) GetPaymentStatus(w http.ResponseWriter, r *http.Requ
 	orderID := vars["orderID"]
 
 	// 查询支付状态
-	payment, err := c.paymentService.GetPaymentByOrderID(orderID)
+	payment, err := c.paymentService.GetPaymentByOrderID(r.Context(), orderID)
 	if err != nil {
 		http.Error(w, "查询支付状态失败: "+err.Error(), http.StatusInternalServerError)
 		return
@@ -104,25 +98,13 @@ func (c *PaymentController) GetUserPayments(w http.ResponseWriter, r *http.Reque
 	}
 
 	// 获取分页参数
-	page := 1
-	pageSize := 10
-
-	if r.URL.Query().Get("page") != "" {
-		p, err := strconv.Atoi(r.URL.Query().Get("page"))
-		if err == nil && p > 0 {
-			page = p
-		}
-	}
-
-	if r.URL.Query().Get("pageSize") != "" {
-		ps, err := strconv.Atoi(r.URL.Query().Get("pageSize"))
-		if err == nil && ps > 0 {
-			pageSize = ps
-		}
+	page, ok := parsePage(w, r)
+	if !ok {
+		return
 	}
 
 	// 获取支付记录
-	payments, total, err := c.paymentService.GetPaymentsByUserID(userID, page, pageSize)
+	payments, err := c.paymentService.GetPaymentsByUserID(r.Context(), userID, page)
 	if err != nil {
 		http.Error(w, "获取支付记录失败: "+err.Error(), http.StatusInternalServerError)
 		return
@@ -132,12 +114,11 @@ func (c *PaymentController) GetUserPayments(w http.ResponseWriter, r *http.Reque
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"code": 200,
 		"msg":  "success",
-		"data": map[string]interface{}{
-			"list":     payments,
-			"total":    total,
-			"page":     page,
-			"pageSize": pageSize,
-		},
+		"data": pageFields(map[string]interface{}{
+			"list":     payments.Items,
+			"page":     page.Number,
+			"pageSize": page.Size,
+		}, payments),
 	})
 }
 
@@ -146,9 +127,10 @@ func (c *PaymentController) NotifyPayment(w http.ResponseWriter, r *http.Request
 	// 解析支付平台发送的通知
 	// 注意：实际应用中需要验证通知的真实性
 
+	// 支付平台可能附带额外字段，这里解析为map而不是严格的结构体
 	var notifyData map[string]interface{}
-	if err := json.NewDecoder(r.Body).Decode(&notifyData); err != nil {
-		http.Error(w, "解析通知失败", http.StatusBadRequest)
+	if err := utils.DecodeJSON(w, r, &notifyData); err != nil {
+		utils.WriteRequestError(w, err)
 		return
 	}
 
@@ -172,7 +154,7 @@ func (c *PaymentController) NotifyPayment(w http.ResponseWriter, r *http.Request
 	}
 
 	// 更新支付状态
-	if err := c.paymentService.UpdatePaymentStatus(orderID, transactionID, status); err != nil {
+	if err := c.paymentService.UpdatePaymentStatus(r.Context(), orderID, transactionID, status); err != nil {
 		http.Error(w, "更新支付状态失败: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
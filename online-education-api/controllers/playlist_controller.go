@@ -0,0 +1,273 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"online-education-api/models"
+	"online-education-api/pagination"
+	"online-education-api/repository"
+	"online-education-api/services"
+	"online-education-api/utils"
+)
+
+// PlaylistController 播放列表控制器
+type PlaylistController struct {
+	playlistService services.PlaylistService
+}
+
+// NewPlaylistController 创建播放列表控制器实例
+func NewPlaylistController(playlistService services.PlaylistService) *PlaylistController {
+	return &PlaylistController{
+		playlistService: playlistService,
+	}
+}
+
+// writePlaylistError 将播放列表服务的错误转换为对应的状态码
+func writePlaylistError(w http.ResponseWriter, prefix string, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, services.ErrPlaylistNotFound), errors.Is(err, services.ErrVideoNotFound),
+		errors.Is(err, services.ErrPlaylistVideoNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, services.ErrPlaylistForbidden):
+		status = http.StatusForbidden
+	case errors.Is(err, services.ErrPlaylistTitle), errors.Is(err, services.ErrPlaylistOrder):
+		status = http.StatusBadRequest
+	case errors.Is(err, services.ErrPlaylistVideoExists), errors.Is(err, services.ErrPlaylistFull):
+		status = http.StatusConflict
+	}
+	http.Error(w, prefix+err.Error(), status)
+}
+
+// writePlaylist 返回播放列表详情
+func writePlaylist(w http.ResponseWriter, status int, playlist *models.Playlist) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    playlist,
+	})
+}
+
+// playlistID 解析路径中的播放列表ID
+func playlistID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "无效的播放列表ID", http.StatusBadRequest)
+		return 0, false
+	}
+	return id, true
+}
+
+// GetPlaylists 获取公开的播放列表，可以用user_id参数筛选某个用户的播放列表
+func (c *PlaylistController) GetPlaylists(w http.ResponseWriter, r *http.Request) {
+	page, ok := parsePage(w, r)
+	if !ok {
+		return
+	}
+	filter := repository.PlaylistFilter{Visibility: models.PlaylistVisibilityPublic}
+	if value := r.URL.Query().Get("user_id"); value != "" {
+		userID, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			http.Error(w, "无效的用户ID", http.StatusBadRequest)
+			return
+		}
+		filter.UserID = userID
+	}
+	c.writePlaylists(w, r, page, filter)
+}
+
+// GetMyPlaylists 获取当前用户的全部播放列表
+func (c *PlaylistController) GetMyPlaylists(w http.ResponseWriter, r *http.Request) {
+	userID, _, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+	page, ok := parsePage(w, r)
+	if !ok {
+		return
+	}
+	c.writePlaylists(w, r, page, repository.PlaylistFilter{UserID: userID})
+}
+
+// writePlaylists 查询并返回播放列表，列表项不包含视频
+func (c *PlaylistController) writePlaylists(w http.ResponseWriter, r *http.Request, page pagination.Page, filter repository.PlaylistFilter) {
+	playlists, err := c.playlistService.ListPlaylists(r.Context(), page, filter)
+	if err != nil {
+		writePlaylistError(w, "获取播放列表失败: ", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(pageFields(map[string]interface{}{
+		"success":  true,
+		"data":     playlists.Items,
+		"page":     page.Number,
+		"pageSize": page.Size,
+	}, playlists))
+}
+
+// CreatePlaylist 创建播放列表，默认公开
+func (c *PlaylistController) CreatePlaylist(w http.ResponseWriter, r *http.Request) {
+	userID, _, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.CreatePlaylistRequest
+	if err := utils.DecodeJSON(w, r, &req); err != nil {
+		utils.WriteRequestError(w, err)
+		return
+	}
+
+	playlist := models.Playlist{
+		UserID:      userID,
+		Title:       req.Title,
+		Description: req.Description,
+		Visibility:  req.Visibility,
+	}
+	if err := c.playlistService.CreatePlaylist(r.Context(), &playlist); err != nil {
+		writePlaylistError(w, "创建播放列表失败: ", err)
+		return
+	}
+	writePlaylist(w, http.StatusCreated, &playlist)
+}
+
+// GetPlaylist 获取播放列表详情，包含视频、总时长和登录用户的观看进度
+func (c *PlaylistController) GetPlaylist(w http.ResponseWriter, r *http.Request) {
+	id, ok := playlistID(w, r)
+	if !ok {
+		return
+	}
+
+	userID, _ := r.Context().Value("userID").(int64)
+	role, _ := r.Context().Value("role").(string)
+	playlist, err := c.playlistService.GetPlaylist(r.Context(), id, userID, role == "admin")
+	if err != nil {
+		writePlaylistError(w, "获取播放列表失败: ", err)
+		return
+	}
+	w.Header().Add("Vary", "Authorization")
+	writePlaylist(w, http.StatusOK, playlist)
+}
+
+// UpdatePlaylist 修改播放列表，只有创建者和管理员可以操作
+func (c *PlaylistController) UpdatePlaylist(w http.ResponseWriter, r *http.Request) {
+	userID, isAdmin, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+	id, ok := playlistID(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.UpdatePlaylistRequest
+	if err := utils.DecodeJSON(w, r, &req); err != nil {
+		utils.WriteRequestError(w, err)
+		return
+	}
+
+	playlist, err := c.playlistService.UpdatePlaylist(r.Context(), id, &req, userID, isAdmin)
+	if err != nil {
+		writePlaylistError(w, "更新播放列表失败: ", err)
+		return
+	}
+	writePlaylist(w, http.StatusOK, playlist)
+}
+
+// DeletePlaylist 删除播放列表，只有创建者和管理员可以操作
+func (c *PlaylistController) DeletePlaylist(w http.ResponseWriter, r *http.Request) {
+	userID, isAdmin, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+	id, ok := playlistID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := c.playlistService.DeletePlaylist(r.Context(), id, userID, isAdmin); err != nil {
+		writePlaylistError(w, "删除播放列表失败: ", err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// AddVideo 向播放列表添加视频，position从1开始，未提供时添加到末尾
+func (c *PlaylistController) AddVideo(w http.ResponseWriter, r *http.Request) {
+	userID, isAdmin, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+	id, ok := playlistID(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.AddPlaylistVideoRequest
+	if err := utils.DecodeJSON(w, r, &req); err != nil {
+		utils.WriteRequestError(w, err)
+		return
+	}
+
+	playlist, err := c.playlistService.AddVideo(r.Context(), id, &req, userID, isAdmin)
+	if err != nil {
+		writePlaylistError(w, "添加视频失败: ", err)
+		return
+	}
+	writePlaylist(w, http.StatusCreated, playlist)
+}
+
+// RemoveVideo 从播放列表移除视频
+func (c *PlaylistController) RemoveVideo(w http.ResponseWriter, r *http.Request) {
+	userID, isAdmin, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+	id, ok := playlistID(w, r)
+	if !ok {
+		return
+	}
+	videoID, err := strconv.Atoi(mux.Vars(r)["videoID"])
+	if err != nil {
+		http.Error(w, "无效的视频ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := c.playlistService.RemoveVideo(r.Context(), id, videoID, userID, isAdmin); err != nil {
+		writePlaylistError(w, "移除视频失败: ", err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ReorderVideos 调整播放列表的顺序，video_ids需包含列表中的全部视频
+func (c *PlaylistController) ReorderVideos(w http.ResponseWriter, r *http.Request) {
+	userID, isAdmin, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+	id, ok := playlistID(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.ReorderPlaylistRequest
+	if err := utils.DecodeJSON(w, r, &req); err != nil {
+		utils.WriteRequestError(w, err)
+		return
+	}
+
+	playlist, err := c.playlistService.ReorderVideos(r.Context(), id, req.VideoIDs, userID, isAdmin)
+	if err != nil {
+		writePlaylistError(w, "调整播放列表顺序失败: ", err)
+		return
+	}
+	writePlaylist(w, http.StatusOK, playlist)
+}
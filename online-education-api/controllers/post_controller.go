@@ -8,6 +8,7 @@ import (
 	"github.com/gorilla/mux"
 	"online-education-api/models"
 	"online-education-api/services"
+	"online-education-api/utils"
 )
 
 // PostController 帖子控制器
@@ -25,29 +26,13 @@ func NewPostController(postService services.PostService) *PostController {
 // GetPostList 获取帖子列表
 func (c *PostController) GetPostList(w http.ResponseWriter, r *http.Request) {
 	// 获取查询参数
-	page := 1
-	pageSize := 10
-	search := ""
-
-	if r.URL.Query().Get("page") != "" {
-		p, err := strconv.Atoi(r.URL.Query().Get("page"))
-		if err == nil && p > 0 {
-			page = p
-		}
-	}
-
-	if r.URL.Query().Get("pageSize") != "" {
-		ps, err := strconv.Atoi(r.URL.Query().Get("pageSize"))
-		if err == nil && ps > 0 {
-			pageSize = ps
-		}
-	}
-
-	if r.URL.Query().Get("search") != "" {
-		search = r.URL.Query().Get("search")
+	page, ok := parsePage(w, r)
+	if !ok {
+		return
 	}
+	search := r.URL.Query().Get("search")
 
-	posts, total, err := c.postService.GetPostList(page, pageSize, search)
+	posts, err := c.postService.GetPostList(r.Context(), page, search)
 	if err != nil {
 		http.Error(w, "获取帖子列表失败: "+err.Error(), http.StatusInternalServerError)
 		return
@@ -57,12 +42,11 @@ func (c *PostController) GetPostList(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"code": 200,
 		"msg":  "success",
-		"data": map[string]interface{}{
-			"list":  posts,
-			"total": total,
-			"page":  page,
-			"pageSize": pageSize,
-		},
+		"data": pageFields(map[string]interface{}{
+			"list":     posts.Items,
+			"page":     page.Number,
+			"pageSize": page.Size,
+		}, posts),
 	})
 }
 
@@ -78,7 +62,7 @@ func (c *PostController) GetPostDetail(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	post, err := c.postService.GetPostDetail(postID)
+	post, err := c.postService.GetPostDetail(r.Context(), postID)
 	if err != nil {
 		http.Error(w, "获取帖子详情失败: "+err.Error(), http.StatusInternalServerError)
 		return
@@ -102,8 +86,8 @@ func (c *PostController) CreatePost(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req models.CreatePostRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "请求参数错误", http.StatusBadRequest)
+	if err := utils.DecodeJSON(w, r, &req); err != nil {
+		utils.WriteRequestError(w, err)
 		return
 	}
 
@@ -118,7 +102,7 @@ func (c *PostController) CreatePost(w http.ResponseWriter, r *http.Request) {
 		Category: req.Category,
 	}
 
-	if err := c.postService.CreatePost(post); err != nil {
+	if err := c.postService.CreatePost(r.Context(), post); err != nil {
 		http.Error(w, "创建帖子失败: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -151,7 +135,7 @@ func (c *PostController) UpdatePost(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// 检查帖子是否存在，并且是否为当前用户创建
-	post, err := c.postService.GetPostDetail(postID)
+	post, err := c.postService.GetPostDetail(r.Context(), postID)
 	if err != nil {
 		http.Error(w, "获取帖子失败: "+err.Error(), http.StatusInternalServerError)
 		return
@@ -163,8 +147,8 @@ func (c *PostController) UpdatePost(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req models.UpdatePostRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "请求参数错误", http.StatusBadRequest)
+	if err := utils.DecodeJSON(w, r, &req); err != nil {
+		utils.WriteRequestError(w, err)
 		return
 	}
 
@@ -188,7 +172,7 @@ func (c *PostController) UpdatePost(w http.ResponseWriter, r *http.Request) {
 		Status:  post.Status,
 	}
 
-	if err := c.postService.UpdatePost(updatePost); err != nil {
+	if err := c.postService.UpdatePost(r.Context(), updatePost); err != nil {
 		http.Error(w, "更新帖子失败: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -221,7 +205,7 @@ func (c *PostController) DeletePost(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// 检查帖子是否存在，并且是否为当前用户创建
-	post, err := c.postService.GetPostDetail(postID)
+	post, err := c.postService.GetPostDetail(r.Context(), postID)
 	if err != nil {
 		http.Error(w, "获取帖子失败: "+err.Error(), http.StatusInternalServerError)
 		return
@@ -232,7 +216,7 @@ func (c *PostController) DeletePost(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := c.postService.DeletePost(postID); err != nil {
+	if err := c.postService.DeletePost(r.Context(), postID); err != nil {
 		http.Error(w, "删除帖子失败: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -254,24 +238,12 @@ func (c *PostController) GetUserPosts(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// 获取查询参数
-	page := 1
-	pageSize := 10
-
-	if r.URL.Query().Get("page") != "" {
-		p, err := strconv.Atoi(r.URL.Query().Get("page"))
-		if err == nil && p > 0 {
-			page = p
-		}
-	}
-
-	if r.URL.Query().Get("pageSize") != "" {
-		ps, err := strconv.Atoi(r.URL.Query().Get("pageSize"))
-		if err == nil && ps > 0 {
-			pageSize = ps
-		}
+	page, ok := parsePage(w, r)
+	if !ok {
+		return
 	}
 
-	posts, total, err := c.postService.GetUserPosts(userID, page, pageSize)
+	posts, err := c.postService.GetUserPosts(r.Context(), userID, page)
 	if err != nil {
 		http.Error(w, "获取帖子列表失败: "+err.Error(), http.StatusInternalServerError)
 		return
@@ -281,11 +253,10 @@ func (c *PostController) GetUserPosts(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"code": 200,
 		"msg":  "success",
-		"data": map[string]interface{}{
-			"list":  posts,
-			"total": total,
-			"page":  page,
-			"pageSize": pageSize,
-		},
+		"data": pageFields(map[string]interface{}{
+			"list":     posts.Items,
+			"page":     page.Number,
+			"pageSize": page.Size,
+		}, posts),
 	})
 }
\ No newline at end of file
@@ -0,0 +1,336 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"online-education-api/models"
+	"online-education-api/services"
+	"online-education-api/utils"
+)
+
+// ReviewController 课程评价控制器
+type ReviewController struct {
+	reviewService services.ReviewService
+}
+
+// NewReviewController 创建课程评价控制器实例
+func NewReviewController(reviewService services.ReviewService) *ReviewController {
+	return &ReviewController{
+		reviewService: reviewService,
+	}
+}
+
+// reviewSorts 评价列表支持的排序方式
+var reviewSorts = []string{"newest", "highest", "lowest"}
+
+// writeReviewError 将评价服务的错误转换为对应的状态码
+func writeReviewError(w http.ResponseWriter, prefix string, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, services.ErrReviewNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, services.ErrReviewForbidden), errors.Is(err, services.ErrNotEnrolled):
+		status = http.StatusForbidden
+	case errors.Is(err, services.ErrReviewExists), errors.Is(err, services.ErrReviewReported):
+		status = http.StatusConflict
+	case errors.Is(err, services.ErrCursorNotAllowed):
+		status = http.StatusBadRequest
+	}
+	http.Error(w, prefix+err.Error(), status)
+}
+
+// reviewID 解析路径中的评价ID
+func reviewID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "无效的评价ID", http.StatusBadRequest)
+		return 0, false
+	}
+	return id, true
+}
+
+// GetCourseReviews 获取课程的评价列表
+func (c *ReviewController) GetCourseReviews(w http.ResponseWriter, r *http.Request) {
+	courseID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "无效的课程ID", http.StatusBadRequest)
+		return
+	}
+
+	query := r.URL.Query()
+	sort := query.Get("sort")
+	if sort != "" && !slices.Contains(reviewSorts, sort) {
+		http.Error(w, "无效的排序方式: "+sort, http.StatusBadRequest)
+		return
+	}
+	rating := 0
+	if query.Get("rating") != "" {
+		rating, err = strconv.Atoi(query.Get("rating"))
+		if err != nil || rating < 1 || rating > 5 {
+			http.Error(w, "无效的评分", http.StatusBadRequest)
+			return
+		}
+	}
+	page, ok := parsePage(w, r)
+	if !ok {
+		return
+	}
+
+	reviews, err := c.reviewService.GetReviewList(r.Context(), courseID, sort, rating, page)
+	if err != nil {
+		writeReviewError(w, "获取评价列表失败: ", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"code": 200,
+		"msg":  "success",
+		"data": pageFields(map[string]interface{}{
+			"list":     reviews.Items,
+			"page":     page.Number,
+			"pageSize": page.Size,
+		}, reviews),
+	})
+}
+
+// CreateReview 评价课程
+func (c *ReviewController) CreateReview(w http.ResponseWriter, r *http.Request) {
+	courseID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "无效的课程ID", http.StatusBadRequest)
+		return
+	}
+
+	// 从上下文获取用户ID
+	userID, ok := r.Context().Value("userID").(int64)
+	if !ok {
+		http.Error(w, "未登录", http.StatusUnauthorized)
+		return
+	}
+
+	var req models.CreateReviewRequest
+	if err := utils.DecodeJSON(w, r, &req); err != nil {
+		utils.WriteRequestError(w, err)
+		return
+	}
+
+	review := &models.CourseReview{
+		CourseID: courseID,
+		UserID:   userID,
+		Rating:   req.Rating,
+		Content:  req.Content,
+	}
+	if err := c.reviewService.CreateReview(r.Context(), review); err != nil {
+		writeReviewError(w, "评价失败: ", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"code": 200,
+		"msg":  "评价成功",
+		"data": review,
+	})
+}
+
+// UpdateReview 修改自己的评价
+func (c *ReviewController) UpdateReview(w http.ResponseWriter, r *http.Request) {
+	id, ok := reviewID(w, r)
+	if !ok {
+		return
+	}
+
+	// 从上下文获取用户ID
+	userID, ok := r.Context().Value("userID").(int64)
+	if !ok {
+		http.Error(w, "未登录", http.StatusUnauthorized)
+		return
+	}
+
+	var req models.CreateReviewRequest
+	if err := utils.DecodeJSON(w, r, &req); err != nil {
+		utils.WriteRequestError(w, err)
+		return
+	}
+
+	review := &models.CourseReview{ID: id, Rating: req.Rating, Content: req.Content}
+	if err := c.reviewService.UpdateReview(r.Context(), userID, review); err != nil {
+		writeReviewError(w, "修改评价失败: ", err)
+		return
+	}
+	updated, err := c.reviewService.GetReview(r.Context(), id)
+	if err != nil {
+		writeReviewError(w, "获取评价失败: ", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"code": 200,
+		"msg":  "修改成功",
+		"data": updated,
+	})
+}
+
+// DeleteReview 删除评价，评价人和管理员可以删除
+func (c *ReviewController) DeleteReview(w http.ResponseWriter, r *http.Request) {
+	id, ok := reviewID(w, r)
+	if !ok {
+		return
+	}
+
+	// 从上下文获取用户ID
+	userID, ok := r.Context().Value("userID").(int64)
+	if !ok {
+		http.Error(w, "未登录", http.StatusUnauthorized)
+		return
+	}
+	role, _ := r.Context().Value("role").(string)
+
+	if err := c.reviewService.DeleteReview(r.Context(), id, userID, role == "admin"); err != nil {
+		writeReviewError(w, "删除评价失败: ", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"code": 200,
+		"msg":  "删除成功",
+	})
+}
+
+// ReplyReview 课程教师回复评价
+func (c *ReviewController) ReplyReview(w http.ResponseWriter, r *http.Request) {
+	id, ok := reviewID(w, r)
+	if !ok {
+		return
+	}
+
+	// 从上下文获取用户ID
+	userID, ok := r.Context().Value("userID").(int64)
+	if !ok {
+		http.Error(w, "未登录", http.StatusUnauthorized)
+		return
+	}
+
+	var req models.ReplyReviewRequest
+	if err := utils.DecodeJSON(w, r, &req); err != nil {
+		utils.WriteRequestError(w, err)
+		return
+	}
+
+	if err := c.reviewService.ReplyReview(r.Context(), id, userID, req.Reply); err != nil {
+		writeReviewError(w, "回复评价失败: ", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"code": 200,
+		"msg":  "回复成功",
+	})
+}
+
+// ReportReview 举报评价
+func (c *ReviewController) ReportReview(w http.ResponseWriter, r *http.Request) {
+	id, ok := reviewID(w, r)
+	if !ok {
+		return
+	}
+
+	// 从上下文获取用户ID
+	userID, ok := r.Context().Value("userID").(int64)
+	if !ok {
+		http.Error(w, "未登录", http.StatusUnauthorized)
+		return
+	}
+
+	var req models.ReportReviewRequest
+	if err := utils.DecodeJSON(w, r, &req); err != nil {
+		utils.WriteRequestError(w, err)
+		return
+	}
+
+	report := &models.ReviewReport{ReviewID: id, UserID: userID, Reason: req.Reason}
+	if err := c.reviewService.ReportReview(r.Context(), report); err != nil {
+		writeReviewError(w, "举报失败: ", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"code": 200,
+		"msg":  "举报成功",
+	})
+}
+
+// GetReportedReviews 获取待处理的被举报评价 (仅管理员)
+func (c *ReviewController) GetReportedReviews(w http.ResponseWriter, r *http.Request) {
+	// 检查用户角色
+	role, ok := r.Context().Value("role").(string)
+	if !ok || role != "admin" {
+		http.Error(w, "权限不足，需要管理员角色", http.StatusForbidden)
+		return
+	}
+
+	page, ok := parsePage(w, r)
+	if !ok {
+		return
+	}
+
+	reviews, err := c.reviewService.GetReportedReviews(r.Context(), page)
+	if err != nil {
+		writeReviewError(w, "获取被举报的评价失败: ", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"code": 200,
+		"msg":  "success",
+		"data": pageFields(map[string]interface{}{
+			"list":     reviews.Items,
+			"page":     page.Number,
+			"pageSize": page.Size,
+		}, reviews),
+	})
+}
+
+// ModerateReview 处理评价的举报 (仅管理员)
+func (c *ReviewController) ModerateReview(w http.ResponseWriter, r *http.Request) {
+	// 检查用户角色
+	role, ok := r.Context().Value("role").(string)
+	if !ok || role != "admin" {
+		http.Error(w, "权限不足，需要管理员角色", http.StatusForbidden)
+		return
+	}
+
+	id, ok := reviewID(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.ModerateReviewRequest
+	if err := utils.DecodeJSON(w, r, &req); err != nil {
+		utils.WriteRequestError(w, err)
+		return
+	}
+
+	if err := c.reviewService.ModerateReview(r.Context(), id, req.Action); err != nil {
+		writeReviewError(w, "处理举报失败: ", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"code": 200,
+		"msg":  "处理成功",
+	})
+}
@@ -0,0 +1,109 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"online-education-api/search"
+	"online-education-api/services"
+)
+
+// maxSearchPageSize 搜索结果每页最大条数
+const maxSearchPageSize = 50
+
+// SearchController 搜索控制器
+type SearchController struct {
+	searchService services.SearchService
+}
+
+// NewSearchController 创建搜索控制器实例
+func NewSearchController(searchService services.SearchService) *SearchController {
+	return &SearchController{
+		searchService: searchService,
+	}
+}
+
+// Search 搜索课程、视频和帖子
+// 参数：q 关键词；type 类型，多个用逗号分隔(course,video,post)；category 分面中的分类值；
+// level 课程难度；min_price、max_price 课程价格范围；page、pageSize 分页
+func (c *SearchController) Search(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	page := 1
+	pageSize := 10
+
+	if p, err := strconv.Atoi(query.Get("page")); err == nil && p > 0 {
+		page = p
+	}
+	if ps, err := strconv.Atoi(query.Get("pageSize")); err == nil && ps > 0 {
+		pageSize = min(ps, maxSearchPageSize)
+	}
+
+	q := search.Query{
+		Text:     strings.TrimSpace(query.Get("q")),
+		Category: query.Get("category"),
+		Offset:   (page - 1) * pageSize,
+		Limit:    pageSize,
+	}
+
+	if types := query.Get("type"); types != "" {
+		for _, t := range strings.Split(types, ",") {
+			switch t = strings.TrimSpace(t); t {
+			case search.TypeCourse, search.TypeVideo, search.TypePost:
+				q.Types = append(q.Types, t)
+			default:
+				http.Error(w, "无效的类型: "+t, http.StatusBadRequest)
+				return
+			}
+		}
+	}
+
+	if level := query.Get("level"); level != "" {
+		l, err := strconv.Atoi(level)
+		if err != nil || l < 0 {
+			http.Error(w, "无效的难度", http.StatusBadRequest)
+			return
+		}
+		q.Level = l
+	}
+
+	for _, p := range []struct {
+		name  string
+		value **float64
+	}{{"min_price", &q.MinPrice}, {"max_price", &q.MaxPrice}} {
+		if v := query.Get(p.name); v != "" {
+			price, err := strconv.ParseFloat(v, 64)
+			if err != nil || price < 0 {
+				http.Error(w, "无效的价格: "+p.name, http.StatusBadRequest)
+				return
+			}
+			*p.value = &price
+		}
+	}
+
+	result, err := c.searchService.Search(r.Context(), q)
+	if errors.Is(err, search.ErrQueryTooLong) {
+		http.Error(w, fmt.Sprintf("搜索词不能超过%d个字符或%d个词", search.MaxQueryRunes, search.MaxQueryTerms), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, "搜索失败: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"code": 200,
+		"msg":  "success",
+		"data": map[string]interface{}{
+			"list":     result.Hits,
+			"total":    result.Total,
+			"facets":   result.Facets,
+			"page":     page,
+			"pageSize": pageSize,
+		},
+	})
+}
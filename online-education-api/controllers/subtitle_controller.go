@@ -0,0 +1,312 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"online-education-api/models"
+	"online-education-api/repository"
+	"online-education-api/services"
+	"online-education-api/subtitle"
+	"online-education-api/utils"
+)
+
+// maxSubtitleField 上传表单中文本字段的最大字节数
+const maxSubtitleField = 255
+
+// SubtitleController 视频和课时的字幕控制器
+type SubtitleController struct {
+	subtitleService services.SubtitleService
+}
+
+// NewSubtitleController 创建字幕控制器实例
+func NewSubtitleController(subtitleService services.SubtitleService) *SubtitleController {
+	return &SubtitleController{
+		subtitleService: subtitleService,
+	}
+}
+
+// writeSubtitleError 将字幕服务的错误转换为对应的状态码
+func writeSubtitleError(w http.ResponseWriter, prefix string, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, services.ErrSubtitleNotFound), errors.Is(err, services.ErrVideoNotFound),
+		errors.Is(err, services.ErrLessonNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, services.ErrSubtitleForbidden), errors.Is(err, services.ErrSubtitleLocked):
+		status = http.StatusForbidden
+	case errors.Is(err, services.ErrSubtitleLanguage), errors.Is(err, services.ErrSubtitleLabel),
+		errors.Is(err, services.ErrSubtitleQuery):
+		status = http.StatusBadRequest
+	case errors.Is(err, services.ErrSubtitleTooLarge):
+		status = http.StatusRequestEntityTooLarge
+	case errors.Is(err, services.ErrSubtitleFormat):
+		status = http.StatusUnprocessableEntity
+	case errors.Is(err, repository.ErrDuplicate):
+		// 同时上传同一语言的字幕
+		status = http.StatusConflict
+	}
+	http.Error(w, prefix+err.Error(), status)
+}
+
+// subtitleOwner 解析路径中的视频或课时ID
+func subtitleOwner(w http.ResponseWriter, r *http.Request, lesson bool) (repository.SubtitleOwner, bool) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 32)
+	if err != nil || id <= 0 {
+		if lesson {
+			http.Error(w, "无效的课时ID", http.StatusBadRequest)
+		} else {
+			http.Error(w, "无效的视频ID", http.StatusBadRequest)
+		}
+		return repository.SubtitleOwner{}, false
+	}
+	if lesson {
+		return repository.SubtitleOwner{LessonID: id}, true
+	}
+	return repository.SubtitleOwner{VideoID: int(id)}, true
+}
+
+// subtitleID 解析路径中的字幕ID
+func subtitleID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "无效的字幕ID", http.StatusBadRequest)
+		return 0, false
+	}
+	return id, true
+}
+
+// writeSubtitleData 返回字幕数据
+func writeSubtitleData(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    data,
+	})
+}
+
+// UploadVideoSubtitle 上传视频字幕
+func (c *SubtitleController) UploadVideoSubtitle(w http.ResponseWriter, r *http.Request) {
+	c.upload(w, r, false)
+}
+
+// UploadLessonSubtitle 上传课时字幕
+func (c *SubtitleController) UploadLessonSubtitle(w http.ResponseWriter, r *http.Request) {
+	c.upload(w, r, true)
+}
+
+// upload 以multipart/form-data上传WebVTT或SRT字幕，文件字段名为file；
+// language字段必填，label和is_default字段可选，这些字段需在文件之前
+func (c *SubtitleController) upload(w http.ResponseWriter, r *http.Request, lesson bool) {
+	userID, isAdmin, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+	owner, ok := subtitleOwner(w, r, lesson)
+	if !ok {
+		return
+	}
+
+	reader, err := r.MultipartReader()
+	if err != nil {
+		http.Error(w, "请求必须为multipart/form-data", http.StatusBadRequest)
+		return
+	}
+	var req models.UploadSubtitleRequest
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			http.Error(w, "缺少文件字段file", http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			http.Error(w, "解析表单失败: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		switch name := part.FormName(); name {
+		case "language", "label", "is_default":
+			value, err := io.ReadAll(io.LimitReader(part, maxSubtitleField+1))
+			part.Close()
+			if err != nil {
+				http.Error(w, "解析表单失败: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+			if len(value) > maxSubtitleField {
+				http.Error(w, name+"字段过长", http.StatusBadRequest)
+				return
+			}
+			switch name {
+			case "language":
+				req.Language = string(value)
+			case "label":
+				req.Label = string(value)
+			default:
+				isDefault, err := strconv.ParseBool(string(value))
+				if err != nil {
+					http.Error(w, "无效的is_default参数", http.StatusBadRequest)
+					return
+				}
+				req.IsDefault = &isDefault
+			}
+			continue
+		}
+		if part.FormName() != "file" || part.FileName() == "" {
+			part.Close()
+			continue
+		}
+		if req.Language == "" {
+			part.Close()
+			http.Error(w, "缺少language字段，需在文件之前", http.StatusBadRequest)
+			return
+		}
+
+		sub, err := c.subtitleService.UploadSubtitle(r.Context(), owner, &req, part, userID, isAdmin)
+		part.Close()
+		if err != nil {
+			writeSubtitleError(w, "上传字幕失败: ", err)
+			return
+		}
+
+		w.Header().Set("Location", sub.URL)
+		writeSubtitleData(w, http.StatusCreated, sub)
+		return
+	}
+}
+
+// GetVideoSubtitles 获取视频的字幕轨道
+func (c *SubtitleController) GetVideoSubtitles(w http.ResponseWriter, r *http.Request) {
+	c.list(w, r, false)
+}
+
+// GetLessonSubtitles 获取课时的字幕轨道
+func (c *SubtitleController) GetLessonSubtitles(w http.ResponseWriter, r *http.Request) {
+	c.list(w, r, true)
+}
+
+func (c *SubtitleController) list(w http.ResponseWriter, r *http.Request, lesson bool) {
+	owner, ok := subtitleOwner(w, r, lesson)
+	if !ok {
+		return
+	}
+	userID, _ := r.Context().Value("userID").(int64)
+	role, _ := r.Context().Value("role").(string)
+
+	list, err := c.subtitleService.ListSubtitles(r.Context(), owner, userID, role == "admin")
+	if err != nil {
+		writeSubtitleError(w, "获取字幕失败: ", err)
+		return
+	}
+	if list == nil {
+		list = []*models.Subtitle{}
+	}
+	w.Header().Add("Vary", "Authorization")
+	writeSubtitleData(w, http.StatusOK, list)
+}
+
+// SearchVideoSubtitles 在视频字幕中搜索，参数q必填，language可选
+func (c *SubtitleController) SearchVideoSubtitles(w http.ResponseWriter, r *http.Request) {
+	c.search(w, r, false)
+}
+
+// SearchLessonSubtitles 在课时字幕中搜索，参数q必填，language可选
+func (c *SubtitleController) SearchLessonSubtitles(w http.ResponseWriter, r *http.Request) {
+	c.search(w, r, true)
+}
+
+func (c *SubtitleController) search(w http.ResponseWriter, r *http.Request, lesson bool) {
+	owner, ok := subtitleOwner(w, r, lesson)
+	if !ok {
+		return
+	}
+	userID, _ := r.Context().Value("userID").(int64)
+	role, _ := r.Context().Value("role").(string)
+	query := r.URL.Query()
+
+	matches, err := c.subtitleService.SearchSubtitles(r.Context(), owner, query.Get("language"), query.Get("q"), userID, role == "admin")
+	if err != nil {
+		writeSubtitleError(w, "搜索字幕失败: ", err)
+		return
+	}
+	if matches == nil {
+		matches = []*models.SubtitleMatch{}
+	}
+	w.Header().Add("Vary", "Authorization")
+	writeSubtitleData(w, http.StatusOK, matches)
+}
+
+// GetSubtitleFile 下载字幕文件，参数format为vtt(默认)或srt
+func (c *SubtitleController) GetSubtitleFile(w http.ResponseWriter, r *http.Request) {
+	id, ok := subtitleID(w, r)
+	if !ok {
+		return
+	}
+	format := subtitle.Format(r.URL.Query().Get("format"))
+	if format == "" {
+		format = subtitle.VTT
+	}
+	if format != subtitle.VTT && format != subtitle.SRT {
+		http.Error(w, "format必须为vtt或srt", http.StatusBadRequest)
+		return
+	}
+	userID, _ := r.Context().Value("userID").(int64)
+	role, _ := r.Context().Value("role").(string)
+
+	data, err := c.subtitleService.ExportSubtitle(r.Context(), id, format, userID, role == "admin")
+	if err != nil {
+		writeSubtitleError(w, "获取字幕失败: ", err)
+		return
+	}
+	w.Header().Add("Vary", "Authorization")
+	w.Header().Set("Content-Type", format.ContentType())
+	w.Header().Set("Content-Disposition", `inline; filename="`+strconv.FormatInt(id, 10)+"."+string(format)+`"`)
+	w.Write(data)
+}
+
+// UpdateSubtitle 修改字幕名称和默认标记
+func (c *SubtitleController) UpdateSubtitle(w http.ResponseWriter, r *http.Request) {
+	userID, isAdmin, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+	id, ok := subtitleID(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.UpdateSubtitleRequest
+	if err := utils.DecodeJSON(w, r, &req); err != nil {
+		utils.WriteRequestError(w, err)
+		return
+	}
+
+	sub, err := c.subtitleService.UpdateSubtitle(r.Context(), id, &req, userID, isAdmin)
+	if err != nil {
+		writeSubtitleError(w, "更新字幕失败: ", err)
+		return
+	}
+	writeSubtitleData(w, http.StatusOK, sub)
+}
+
+// DeleteSubtitle 删除字幕
+func (c *SubtitleController) DeleteSubtitle(w http.ResponseWriter, r *http.Request) {
+	userID, isAdmin, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+	id, ok := subtitleID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := c.subtitleService.DeleteSubtitle(r.Context(), id, userID, isAdmin); err != nil {
+		writeSubtitleError(w, "删除字幕失败: ", err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
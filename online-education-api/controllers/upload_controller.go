@@ -0,0 +1,259 @@
+package controllers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"online-education-api/models"
+	"online-education-api/services"
+	"online-education-api/utils"
+)
+
+// statusChecksumMismatch 分片校验和不匹配时返回的状态码，与tus协议一致
+const statusChecksumMismatch = 460
+
+// uploadReadTimeout 接收文件的请求的读写超时，替代服务器默认的较短超时
+const uploadReadTimeout = time.Hour
+
+// UploadController 视频上传控制器
+type UploadController struct {
+	uploadService services.UploadService
+}
+
+// NewUploadController 创建视频上传控制器实例
+func NewUploadController(uploadService services.UploadService) *UploadController {
+	return &UploadController{
+		uploadService: uploadService,
+	}
+}
+
+// writeUploadError 将上传服务的错误转换为对应的状态码
+func writeUploadError(w http.ResponseWriter, prefix string, err error) {
+	status := http.StatusInternalServerError
+	var maxBytesErr *http.MaxBytesError
+	switch {
+	case errors.Is(err, services.ErrUploadNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, services.ErrUploadForbidden):
+		status = http.StatusForbidden
+	case errors.Is(err, services.ErrUploadCompleted), errors.Is(err, services.ErrUploadIncomplete),
+		errors.Is(err, services.ErrUploadBusy), errors.Is(err, services.ErrUploadOffset),
+		errors.Is(err, services.ErrUploadUsed):
+		status = http.StatusConflict
+	case errors.Is(err, services.ErrUploadTooLarge), errors.As(err, &maxBytesErr):
+		status = http.StatusRequestEntityTooLarge
+	case errors.Is(err, services.ErrUploadType):
+		status = http.StatusUnsupportedMediaType
+	case errors.Is(err, services.ErrUploadChecksum):
+		status = statusChecksumMismatch
+	}
+	http.Error(w, prefix+err.Error(), status)
+}
+
+// uploaderID 获取上传者的用户ID，只有教师和管理员可以上传视频
+func uploaderID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	userID, ok := r.Context().Value("userID").(int64)
+	if !ok {
+		http.Error(w, "未登录", http.StatusUnauthorized)
+		return 0, false
+	}
+	role, _ := r.Context().Value("role").(string)
+	if role != "teacher" && role != "admin" {
+		http.Error(w, "权限不足，需要教师或管理员角色", http.StatusForbidden)
+		return 0, false
+	}
+	return userID, true
+}
+
+// extendDeadline 延长接收文件的请求的读写超时
+func extendDeadline(w http.ResponseWriter) {
+	rc := http.NewResponseController(w)
+	deadline := time.Now().Add(uploadReadTimeout)
+	rc.SetReadDeadline(deadline)
+	rc.SetWriteDeadline(deadline)
+}
+
+// setUploadHeaders 设置上传进度的响应头
+func setUploadHeaders(w http.ResponseWriter, upload *models.Upload) {
+	w.Header().Set("Upload-Offset", strconv.FormatInt(upload.Received, 10))
+	w.Header().Set("Upload-Length", strconv.FormatInt(upload.Size, 10))
+	w.Header().Set("Cache-Control", "no-store")
+}
+
+// CreateUpload 创建分片上传
+func (c *UploadController) CreateUpload(w http.ResponseWriter, r *http.Request) {
+	userID, ok := uploaderID(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.CreateUploadRequest
+	if err := utils.DecodeJSON(w, r, &req); err != nil {
+		utils.WriteRequestError(w, err)
+		return
+	}
+
+	upload, err := c.uploadService.CreateUpload(r.Context(), userID, req.FileName, req.Size)
+	if err != nil {
+		writeUploadError(w, "创建上传失败: ", err)
+		return
+	}
+
+	setUploadHeaders(w, upload)
+	w.Header().Set("Location", "/api/uploads/"+upload.ID)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    upload,
+	})
+}
+
+// GetUpload 获取上传详情
+func (c *UploadController) GetUpload(w http.ResponseWriter, r *http.Request) {
+	userID, ok := uploaderID(w, r)
+	if !ok {
+		return
+	}
+
+	upload, err := c.uploadService.GetUpload(r.Context(), mux.Vars(r)["id"], userID)
+	if err != nil {
+		writeUploadError(w, "获取上传失败: ", err)
+		return
+	}
+
+	setUploadHeaders(w, upload)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    upload,
+	})
+}
+
+// HeadUpload 查询已接收的字节数，客户端据此继续上传
+func (c *UploadController) HeadUpload(w http.ResponseWriter, r *http.Request) {
+	userID, ok := uploaderID(w, r)
+	if !ok {
+		return
+	}
+
+	upload, err := c.uploadService.GetUpload(r.Context(), mux.Vars(r)["id"], userID)
+	if err != nil {
+		writeUploadError(w, "获取上传失败: ", err)
+		return
+	}
+
+	setUploadHeaders(w, upload)
+	w.WriteHeader(http.StatusOK)
+}
+
+// PatchUpload 提交一个分片
+// 请求头Upload-Offset为分片的起始位置，可选的Upload-Checksum格式为"sha256 <Base64编码的摘要>"
+func (c *UploadController) PatchUpload(w http.ResponseWriter, r *http.Request) {
+	userID, ok := uploaderID(w, r)
+	if !ok {
+		return
+	}
+
+	if r.Header.Get("Content-Type") != "application/offset+octet-stream" {
+		http.Error(w, "Content-Type必须为application/offset+octet-stream", http.StatusUnsupportedMediaType)
+		return
+	}
+	offset, err := strconv.ParseInt(r.Header.Get("Upload-Offset"), 10, 64)
+	if err != nil || offset < 0 {
+		http.Error(w, "无效的Upload-Offset", http.StatusBadRequest)
+		return
+	}
+	var checksum []byte
+	if header := r.Header.Get("Upload-Checksum"); header != "" {
+		algorithm, value, _ := strings.Cut(header, " ")
+		if algorithm != "sha256" {
+			http.Error(w, "Upload-Checksum只支持sha256", http.StatusBadRequest)
+			return
+		}
+		if checksum, err = base64.StdEncoding.DecodeString(value); err != nil {
+			http.Error(w, "无效的Upload-Checksum", http.StatusBadRequest)
+			return
+		}
+	}
+
+	extendDeadline(w)
+	upload, err := c.uploadService.WriteChunk(r.Context(), mux.Vars(r)["id"], userID, offset, r.Body, checksum)
+	if err != nil {
+		writeUploadError(w, "上传分片失败: ", err)
+		return
+	}
+
+	setUploadHeaders(w, upload)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// DeleteUpload 取消未完成的上传
+func (c *UploadController) DeleteUpload(w http.ResponseWriter, r *http.Request) {
+	userID, ok := uploaderID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := c.uploadService.CancelUpload(r.Context(), mux.Vars(r)["id"], userID); err != nil {
+		writeUploadError(w, "取消上传失败: ", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// UploadMultipart 以multipart/form-data一次上传整个文件，文件字段名为file
+func (c *UploadController) UploadMultipart(w http.ResponseWriter, r *http.Request) {
+	userID, ok := uploaderID(w, r)
+	if !ok {
+		return
+	}
+
+	// 逐个读取表单字段，文件直接写入暂存文件，不读入内存
+	reader, err := r.MultipartReader()
+	if err != nil {
+		http.Error(w, "请求必须为multipart/form-data", http.StatusBadRequest)
+		return
+	}
+	extendDeadline(w)
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			http.Error(w, "缺少文件字段file", http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			http.Error(w, "解析表单失败: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if part.FormName() != "file" || part.FileName() == "" {
+			part.Close()
+			continue
+		}
+
+		upload, err := c.uploadService.Upload(r.Context(), userID, part.FileName(), part)
+		part.Close()
+		if err != nil {
+			writeUploadError(w, "上传失败: ", err)
+			return
+		}
+
+		w.Header().Set("Location", "/api/uploads/"+upload.ID)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": true,
+			"data":    upload,
+		})
+		return
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/gorilla/mux"
 	"online-education-api/models"
 	"online-education-api/services"
+	"online-education-api/utils"
 )
 
 // UserController 用户控制器
@@ -23,12 +24,12 @@ func NewUserController(userService services.UserService) *UserController {
 // Register 处理用户注册请求
 func (c *UserController) Register(w http.ResponseWriter, r *http.Request) {
 	var registerReq models.UserRegisterRequest
-	if err := json.NewDecoder(r.Body).Decode(&registerReq); err != nil {
-		http.Error(w, "无效的请求数据", http.StatusBadRequest)
+	if err := utils.DecodeJSON(w, r, &registerReq); err != nil {
+		utils.WriteRequestError(w, err)
 		return
 	}
 
-	user, err := c.userService.Register(&registerReq)
+	user, err := c.userService.Register(r.Context(), &registerReq)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
@@ -55,12 +56,12 @@ func (c *UserController) Register(w http.ResponseWriter, r *http.Request) {
 // Login 处理用户登录请求
 func (c *UserController) Login(w http.ResponseWriter, r *http.Request) {
 	var loginReq models.UserLoginRequest
-	if err := json.NewDecoder(r.Body).Decode(&loginReq); err != nil {
-		http.Error(w, "无效的请求数据", http.StatusBadRequest)
+	if err := utils.DecodeJSON(w, r, &loginReq); err != nil {
+		utils.WriteRequestError(w, err)
 		return
 	}
 
-	user, token, err := c.userService.Login(&loginReq)
+	user, token, err := c.userService.Login(r.Context(), &loginReq)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusUnauthorized)
 		return
@@ -96,7 +97,7 @@ func (c *UserController) GetProfile(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user, err := c.userService.GetUserByID(userID)
+	user, err := c.userService.GetUserByID(r.Context(), userID)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusNotFound)
 		return
@@ -128,12 +129,12 @@ func (c *UserController) UpdateProfile(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var updateReq models.UserUpdateRequest
-	if err := json.NewDecoder(r.Body).Decode(&updateReq); err != nil {
-		http.Error(w, "无效的请求数据", http.StatusBadRequest)
+	if err := utils.DecodeJSON(w, r, &updateReq); err != nil {
+		utils.WriteRequestError(w, err)
 		return
 	}
 
-	user, err := c.userService.UpdateUser(userID, &updateReq)
+	user, err := c.userService.UpdateUser(r.Context(), userID, &updateReq)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
@@ -164,17 +165,14 @@ func (c *UserController) ChangePassword(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	var req struct {
-		OldPassword string `json:"old_password"`
-		NewPassword string `json:"new_password"`
-	}
+	var req models.ChangePasswordRequest
 
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "无效的请求数据", http.StatusBadRequest)
+	if err := utils.DecodeJSON(w, r, &req); err != nil {
+		utils.WriteRequestError(w, err)
 		return
 	}
 
-	err := c.userService.ChangePassword(userID, req.OldPassword, req.NewPassword)
+	err := c.userService.ChangePassword(r.Context(), userID, req.OldPassword, req.NewPassword)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
@@ -193,37 +191,22 @@ func (c *UserController) GetUserList(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	
-	// 获取分页参数
-	pageStr := r.URL.Query().Get("page")
-	pageSizeStr := r.URL.Query().Get("pageSize")
-	
-	// 默认为第1页，每页10条
-	page := 1
-	pageSize := 10
-	
-	// 转换分页参数
-	if pageStr != "" {
-		if p, err := strconv.Atoi(pageStr); err == nil && p > 0 {
-			page = p
-		}
-	}
-	
-	if pageSizeStr != "" {
-		if ps, err := strconv.Atoi(pageSizeStr); err == nil && ps > 0 {
-			pageSize = ps
-		}
+	// 获取分页参数，默认为第1页，每页10条
+	page, ok := parsePage(w, r)
+	if !ok {
+		return
 	}
 	
 	// 调用服务层获取用户列表
-	users, total, err := c.userService.GetUserList(page, pageSize)
+	users, err := c.userService.GetUserList(r.Context(), page)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 	
 	// 转换为响应模型
-	var userResponses []models.UserResponse
-	for _, user := range users {
+	userResponses := []models.UserResponse{}
+	for _, user := range users.Items {
 		userResponses = append(userResponses, models.UserResponse{
 			ID:        user.ID,
 			Username:  user.Username,
@@ -239,10 +222,9 @@ func (c *UserController) GetUserList(w http.ResponseWriter, r *http.Request) {
 	
 	// 返回用户列表和总数
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"list":  userResponses,
-		"total": total,
-	})
+	json.NewEncoder(w).Encode(pageFields(map[string]interface{}{
+		"list": userResponses,
+	}, users))
 }
 
 // CreateUser 创建新用户
@@ -255,12 +237,12 @@ func (c *UserController) CreateUser(w http.ResponseWriter, r *http.Request) {
 	}
 	
 	var createReq models.UserCreateRequest
-	if err := json.NewDecoder(r.Body).Decode(&createReq); err != nil {
-		http.Error(w, "无效的请求数据", http.StatusBadRequest)
+	if err := utils.DecodeJSON(w, r, &createReq); err != nil {
+		utils.WriteRequestError(w, err)
 		return
 	}
 
-	user, err := c.userService.CreateUser(&createReq)
+	user, err := c.userService.CreateUser(r.Context(), &createReq)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
@@ -295,7 +277,7 @@ func (c *UserController) GetUserByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user, err := c.userService.GetUserByID(id)
+	user, err := c.userService.GetUserByID(r.Context(), id)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusNotFound)
 		return
@@ -337,12 +319,12 @@ func (c *UserController) UpdateUser(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var updateReq models.UserUpdateRequest
-	if err := json.NewDecoder(r.Body).Decode(&updateReq); err != nil {
-		http.Error(w, "无效的请求数据", http.StatusBadRequest)
+	if err := utils.DecodeJSON(w, r, &updateReq); err != nil {
+		utils.WriteRequestError(w, err)
 		return
 	}
 
-	user, err := c.userService.UpdateUser(id, &updateReq)
+	user, err := c.userService.UpdateUser(r.Context(), id, &updateReq)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
@@ -383,7 +365,7 @@ func (c *UserController) DeleteUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = c.userService.DeleteUser(id)
+	err = c.userService.DeleteUser(r.Context(), id)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -40,7 +40,7 @@ func (c *UserCourseController) EnrollCourse(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	if err := c.userCourseService.EnrollCourse(userID, courseID); err != nil {
+	if err := c.userCourseService.EnrollCourse(r.Context(), userID, courseID); err != nil {
 		http.Error(w, "报名失败: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -79,7 +79,7 @@ func (c *UserCourseController) GetUserCourses(w http.ResponseWriter, r *http.Req
 		}
 	}
 
-	courses, total, err := c.userCourseService.GetUserCourses(userID, page, pageSize)
+	courses, total, err := c.userCourseService.GetUserCourses(r.Context(), userID, page, pageSize)
 	if err != nil {
 		http.Error(w, "获取课程列表失败: "+err.Error(), http.StatusInternalServerError)
 		return
@@ -117,7 +117,7 @@ func (c *UserCourseController) GetUserCourseByID(w http.ResponseWriter, r *http.
 		return
 	}
 
-	userCourse, err := c.userCourseService.GetUserCourseByID(userID, courseID)
+	userCourse, err := c.userCourseService.GetUserCourseByID(r.Context(), userID, courseID)
 	if err != nil {
 		http.Error(w, "获取报名记录失败: "+err.Error(), http.StatusInternalServerError)
 		return
@@ -150,7 +150,7 @@ func (c *UserCourseController) UnenrollCourse(w http.ResponseWriter, r *http.Req
 		return
 	}
 
-	if err := c.userCourseService.UnenrollCourse(userID, courseID); err != nil {
+	if err := c.userCourseService.UnenrollCourse(r.Context(), userID, courseID); err != nil {
 		http.Error(w, "取消报名失败: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
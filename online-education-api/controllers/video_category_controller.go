@@ -0,0 +1,179 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"online-education-api/models"
+	"online-education-api/services"
+	"online-education-api/utils"
+)
+
+// VideoCategoryController 视频分类控制器，分类的读取不需要认证，修改只有管理员可以操作
+type VideoCategoryController struct {
+	videoService services.VideoService
+}
+
+// NewVideoCategoryController 创建视频分类控制器实例
+func NewVideoCategoryController(videoService services.VideoService) *VideoCategoryController {
+	return &VideoCategoryController{
+		videoService: videoService,
+	}
+}
+
+// writeVideoCategoryError 将视频分类的错误转换为对应的状态码
+func writeVideoCategoryError(w http.ResponseWriter, prefix string, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, services.ErrVideoCategoryNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, services.ErrVideoCategoryName), errors.Is(err, services.ErrVideoCategoryParent),
+		errors.Is(err, services.ErrVideoCategoryReassign):
+		status = http.StatusBadRequest
+	case errors.Is(err, services.ErrVideoCategoryHasChildren), errors.Is(err, services.ErrVideoCategoryInUse):
+		status = http.StatusConflict
+	}
+	http.Error(w, prefix+err.Error(), status)
+}
+
+// requireAdmin 检查当前用户是管理员，调用方在ok为false时直接返回
+func requireAdmin(w http.ResponseWriter, r *http.Request) bool {
+	_, isAdmin, ok := currentUser(w, r)
+	if ok && !isAdmin {
+		http.Error(w, "只有管理员可以执行该操作", http.StatusForbidden)
+	}
+	return ok && isAdmin
+}
+
+// videoCategoryID 解析路径中的分类ID
+func videoCategoryID(w http.ResponseWriter, r *http.Request) (int, bool) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "无效的分类ID", http.StatusBadRequest)
+		return 0, false
+	}
+	return id, true
+}
+
+// writeVideoCategory 返回视频分类
+func writeVideoCategory(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    data,
+	})
+}
+
+// GetCategoryTree 获取视频分类树
+func (c *VideoCategoryController) GetCategoryTree(w http.ResponseWriter, r *http.Request) {
+	categories, err := c.videoService.GetVideoCategoryTree(r.Context())
+	if err != nil {
+		writeVideoCategoryError(w, "获取视频分类失败: ", err)
+		return
+	}
+	if categories == nil {
+		categories = []*models.VideoCategory{}
+	}
+	writeVideoCategory(w, http.StatusOK, categories)
+}
+
+// GetCategory 获取视频分类及其全部下级分类
+func (c *VideoCategoryController) GetCategory(w http.ResponseWriter, r *http.Request) {
+	id, ok := videoCategoryID(w, r)
+	if !ok {
+		return
+	}
+
+	category, err := c.videoService.GetVideoCategory(r.Context(), id)
+	if err != nil {
+		writeVideoCategoryError(w, "获取视频分类失败: ", err)
+		return
+	}
+	writeVideoCategory(w, http.StatusOK, category)
+}
+
+// CreateCategory 创建视频分类
+func (c *VideoCategoryController) CreateCategory(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+
+	var req models.VideoCategoryRequest
+	if err := utils.DecodeJSON(w, r, &req); err != nil {
+		utils.WriteRequestError(w, err)
+		return
+	}
+
+	category := models.VideoCategory{
+		Name:        req.Name,
+		Description: req.Description,
+		ParentID:    req.ParentID,
+		SortOrder:   req.SortOrder,
+	}
+	if err := c.videoService.CreateVideoCategory(r.Context(), &category); err != nil {
+		writeVideoCategoryError(w, "创建视频分类失败: ", err)
+		return
+	}
+	writeVideoCategory(w, http.StatusCreated, category)
+}
+
+// UpdateCategory 更新视频分类，未提供parent_id时移为一级分类
+func (c *VideoCategoryController) UpdateCategory(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+	id, ok := videoCategoryID(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.VideoCategoryRequest
+	if err := utils.DecodeJSON(w, r, &req); err != nil {
+		utils.WriteRequestError(w, err)
+		return
+	}
+
+	category := models.VideoCategory{
+		ID:          id,
+		Name:        req.Name,
+		Description: req.Description,
+		ParentID:    req.ParentID,
+		SortOrder:   req.SortOrder,
+	}
+	if err := c.videoService.UpdateVideoCategory(r.Context(), &category); err != nil {
+		writeVideoCategoryError(w, "更新视频分类失败: ", err)
+		return
+	}
+	writeVideoCategory(w, http.StatusOK, category)
+}
+
+// DeleteCategory 删除视频分类，分类下有视频时需要用reassign_to指定视频移动到的分类，0为未分类
+func (c *VideoCategoryController) DeleteCategory(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+	id, ok := videoCategoryID(w, r)
+	if !ok {
+		return
+	}
+	var reassignTo *int
+	if value := r.URL.Query().Get("reassign_to"); value != "" {
+		target, err := strconv.Atoi(value)
+		if err != nil || target < 0 {
+			http.Error(w, "无效的reassign_to参数", http.StatusBadRequest)
+			return
+		}
+		reassignTo = &target
+	}
+
+	if err := c.videoService.DeleteVideoCategory(r.Context(), id, reassignTo); err != nil {
+		writeVideoCategoryError(w, "删除视频分类失败: ", err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -2,40 +2,95 @@ package controllers
 
 import (
 	"encoding/json"
+	"errors"
+	"math"
 	"net/http"
+	"slices"
 	"strconv"
 
 	"github.com/gorilla/mux"
 
 	"online-education-api/models"
+	"online-education-api/pagination"
 	"online-education-api/services"
+	"online-education-api/urlsign"
+	"online-education-api/utils"
 )
 
 // VideoController 视频控制器
 type VideoController struct {
-	videoService services.VideoService
+	videoService     services.VideoService
+	transcodeService services.TranscodeService
+	playbackService  services.PlaybackService
+	analyticsService services.AnalyticsService
+	playlistService  services.PlaylistService
 }
 
-// NewVideoController 创建视频控制器实例
-func NewVideoController(videoService services.VideoService) *VideoController {
+// NewVideoController 创建视频控制器实例，transcodeService为nil时不支持重新转码
+func NewVideoController(videoService services.VideoService, transcodeService services.TranscodeService, playbackService services.PlaybackService, analyticsService services.AnalyticsService, playlistService services.PlaylistService) *VideoController {
 	return &VideoController{
-		videoService: videoService,
+		videoService:     videoService,
+		transcodeService: transcodeService,
+		playbackService:  playbackService,
+		analyticsService: analyticsService,
+		playlistService:  playlistService,
 	}
 }
 
-// CreateVideo 创建视频
+// videoVisibilities 视频可见性的取值
+var videoVisibilities = []string{models.VideoVisibilityPublic, models.VideoVisibilityUnlisted, models.VideoVisibilityPrivate}
+
+// writeVideoError 将视频服务的错误转换为对应的状态码
+func writeVideoError(w http.ResponseWriter, prefix string, err error) {
+	status := http.StatusInternalServerError
+	var rateErr *services.RateLimitError
+	switch {
+	case errors.Is(err, services.ErrVideoNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, services.ErrVideoForbidden):
+		status = http.StatusForbidden
+	case errors.As(err, &rateErr):
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(rateErr.RetryAfter.Seconds()))))
+		status = http.StatusTooManyRequests
+	case errors.Is(err, services.ErrViewSessionsFull):
+		status = http.StatusServiceUnavailable
+	}
+	http.Error(w, prefix+err.Error(), status)
+}
+
+// CreateVideo 创建视频，作者为当前登录用户
 func (c *VideoController) CreateVideo(w http.ResponseWriter, r *http.Request) {
-	var video models.Video
-	if err := json.NewDecoder(r.Body).Decode(&video); err != nil {
-		http.Error(w, "无效的请求体: " + err.Error(), http.StatusBadRequest)
+	userID, ok := r.Context().Value("userID").(int64)
+	if !ok {
+		http.Error(w, "未登录", http.StatusUnauthorized)
 		return
 	}
 
-	// 在实际应用中，应该从认证信息中获取用户ID
-	// video.AuthorID = getUserIDFromContext(r.Context())
+	var req models.CreateVideoRequest
+	if err := utils.DecodeJSON(w, r, &req); err != nil {
+		utils.WriteRequestError(w, err)
+		return
+	}
 
-	if err := c.videoService.CreateVideo(&video); err != nil {
-		http.Error(w, "创建视频失败: " + err.Error(), http.StatusInternalServerError)
+	if (req.VideoURL == "") == (req.UploadID == "") {
+		http.Error(w, "video_url和upload_id必须且只能提供一个", http.StatusBadRequest)
+		return
+	}
+
+	video := models.Video{
+		Title:         req.Title,
+		Description:   req.Description,
+		VideoURL:      req.VideoURL,
+		UploadID:      req.UploadID,
+		CoverImageURL: req.CoverImageURL,
+		Duration:      req.Duration,
+		CategoryID:    req.CategoryID,
+		AuthorID:      userID,
+		Visibility:    models.VideoVisibility(req.Visibility, req.IsPublic),
+	}
+
+	if err := c.videoService.CreateVideo(r.Context(), &video); err != nil {
+		writeUploadError(w, "创建视频失败: ", err)
 		return
 	}
 
@@ -47,52 +102,85 @@ func (c *VideoController) CreateVideo(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-// GetVideoList 获取视频列表
+// GetVideoList 获取视频列表，默认只返回公开视频；管理员可以用visibility参数查看其他可见性的视频，all表示全部
 func (c *VideoController) GetVideoList(w http.ResponseWriter, r *http.Request) {
 	// 获取查询参数
-	pageStr := r.URL.Query().Get("page")
-	pageSizeStr := r.URL.Query().Get("pageSize")
 	categoryIDStr := r.URL.Query().Get("categoryID")
 
 	// 默认值
-	page := 1
-	pageSize := 10
-	categoryID := 0
+	q := models.VideoListQuery{Visibility: models.VideoVisibilityPublic}
 
 	// 解析参数
-	if pageStr != "" {
-		if p, err := strconv.Atoi(pageStr); err == nil && p > 0 {
-			page = p
-		}
+	page, ok := parsePage(w, r)
+	if !ok {
+		return
 	}
 
-	if pageSizeStr != "" {
-		if ps, err := strconv.Atoi(pageSizeStr); err == nil && ps > 0 {
-			pageSize = ps
+	if categoryIDStr != "" {
+		if cid, err := strconv.Atoi(categoryIDStr); err == nil && cid > 0 {
+			q.CategoryID = cid
 		}
 	}
+	q.IncludeSubcategories = r.URL.Query().Get("includeSubcategories") == "true"
 
-	if categoryIDStr != "" {
-		if cid, err := strconv.Atoi(categoryIDStr); err == nil && cid > 0 {
-			categoryID = cid
+	if visibility := r.URL.Query().Get("visibility"); visibility != "" && visibility != models.VideoVisibilityPublic {
+		if visibility != "all" && !slices.Contains(videoVisibilities, visibility) {
+			http.Error(w, "无效的可见性: "+visibility, http.StatusBadRequest)
+			return
+		}
+		if role, _ := r.Context().Value("role").(string); role != "admin" {
+			http.Error(w, "只有管理员可以查看非公开的视频", http.StatusForbidden)
+			return
 		}
+		q.Visibility = visibility
+		if visibility == "all" {
+			q.Visibility = ""
+		}
+	}
+
+	c.writeVideoList(w, r, page, q)
+}
+
+// GetMyVideos 获取当前用户自己的视频，包含全部可见性，可以用visibility参数筛选
+func (c *VideoController) GetMyVideos(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("userID").(int64)
+	if !ok {
+		http.Error(w, "未登录", http.StatusUnauthorized)
+		return
+	}
+
+	page, ok := parsePage(w, r)
+	if !ok {
+		return
 	}
 
+	q := models.VideoListQuery{AuthorID: userID, Visibility: r.URL.Query().Get("visibility")}
+	if q.Visibility != "" && !slices.Contains(videoVisibilities, q.Visibility) {
+		http.Error(w, "无效的可见性: "+q.Visibility, http.StatusBadRequest)
+		return
+	}
+
+	c.writeVideoList(w, r, page, q)
+}
+
+// writeVideoList 查询并返回视频列表
+func (c *VideoController) writeVideoList(w http.ResponseWriter, r *http.Request, page pagination.Page, q models.VideoListQuery) {
 	// 调用服务方法
-	videos, total, err := c.videoService.GetVideoList(page, pageSize, categoryID)
+	videos, err := c.videoService.GetVideoList(r.Context(), page, q)
 	if err != nil {
 		http.Error(w, "获取视频列表失败: " + err.Error(), http.StatusInternalServerError)
 		return
 	}
 
+	// 列表内容随登录用户变化
+	w.Header().Add("Vary", "Authorization")
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"success": true,
-		"data":    videos,
-		"total":   total,
-		"page":    page,
-		"pageSize": pageSize,
-	})
+	json.NewEncoder(w).Encode(pageFields(map[string]interface{}{
+		"success":  true,
+		"data":     videos.Items,
+		"page":     page.Number,
+		"pageSize": page.Size,
+	}, videos))
 }
 
 // GetVideoByID 获取视频详情
@@ -106,14 +194,31 @@ func (c *VideoController) GetVideoByID(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "无效的视频ID", http.StatusBadRequest)
 		return
 	}
+	// playlist为正在播放的播放列表，未提供时使用作者的系列
+	var playlistID int64
+	if value := r.URL.Query().Get("playlist"); value != "" {
+		if playlistID, err = strconv.ParseInt(value, 10, 64); err != nil || playlistID <= 0 {
+			http.Error(w, "无效的播放列表ID", http.StatusBadRequest)
+			return
+		}
+	}
 
-	// 调用服务方法
-	video, err := c.videoService.GetVideoByID(id)
+	// 私有视频只有作者和管理员可以查看，播放地址按当前用户签名
+	userID, _ := r.Context().Value("userID").(int64)
+	role, _ := r.Context().Value("role").(string)
+	video, err := c.videoService.GetVideoByID(r.Context(), id, userID, role == "admin")
 	if err != nil {
-		http.Error(w, "获取视频详情失败: " + err.Error(), http.StatusInternalServerError)
+		writeVideoError(w, "获取视频详情失败: ", err)
+		return
+	}
+	if video.Playlist, err = c.playlistService.VideoContext(r.Context(), video, playlistID, userID, role == "admin"); err != nil {
+		http.Error(w, "获取播放列表失败: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 
+	w.Header().Add("Vary", "Authorization")
+	c.playbackService.SignVideo(video, userID, role == "admin", urlsign.ClientIP(r))
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"success": true,
@@ -121,8 +226,15 @@ func (c *VideoController) GetVideoByID(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-// UpdateVideo 更新视频
+// UpdateVideo 更新视频，只有作者和管理员可以操作
 func (c *VideoController) UpdateVideo(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("userID").(int64)
+	if !ok {
+		http.Error(w, "未登录", http.StatusUnauthorized)
+		return
+	}
+	role, _ := r.Context().Value("role").(string)
+
 	// 获取路径参数
 	vars := mux.Vars(r)
 	idStr := vars["id"]
@@ -134,21 +246,24 @@ func (c *VideoController) UpdateVideo(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// 解析请求体
-	var video models.Video
-	if err := json.NewDecoder(r.Body).Decode(&video); err != nil {
-		http.Error(w, "无效的请求体: " + err.Error(), http.StatusBadRequest)
+	var req models.UpdateVideoRequest
+	if err := utils.DecodeJSON(w, r, &req); err != nil {
+		utils.WriteRequestError(w, err)
 		return
 	}
 
-	// 设置视频ID
-	video.ID = id
-
-	// 在实际应用中，应该从认证信息中获取用户ID并验证权限
-	// video.AuthorID = getUserIDFromContext(r.Context())
+	video := models.Video{
+		ID:            id,
+		Title:         req.Title,
+		Description:   req.Description,
+		CoverImageURL: req.CoverImageURL,
+		CategoryID:    req.CategoryID,
+		Visibility:    models.VideoVisibility(req.Visibility, req.IsPublic),
+	}
 
 	// 调用服务方法
-	if err := c.videoService.UpdateVideo(&video); err != nil {
-		http.Error(w, "更新视频失败: " + err.Error(), http.StatusInternalServerError)
+	if err := c.videoService.UpdateVideo(r.Context(), &video, userID, role == "admin"); err != nil {
+		writeVideoError(w, "更新视频失败: ", err)
 		return
 	}
 
@@ -159,8 +274,15 @@ func (c *VideoController) UpdateVideo(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-// DeleteVideo 删除视频
+// DeleteVideo 删除视频，只有作者和管理员可以操作
 func (c *VideoController) DeleteVideo(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("userID").(int64)
+	if !ok {
+		http.Error(w, "未登录", http.StatusUnauthorized)
+		return
+	}
+	role, _ := r.Context().Value("role").(string)
+
 	// 获取路径参数
 	vars := mux.Vars(r)
 	idStr := vars["id"]
@@ -171,11 +293,9 @@ func (c *VideoController) DeleteVideo(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// 在实际应用中，应该验证用户权限
-
 	// 调用服务方法
-	if err := c.videoService.DeleteVideo(id); err != nil {
-		http.Error(w, "删除视频失败: " + err.Error(), http.StatusInternalServerError)
+	if err := c.videoService.DeleteVideo(r.Context(), id, userID, role == "admin"); err != nil {
+		writeVideoError(w, "删除视频失败: ", err)
 		return
 	}
 
@@ -189,7 +309,7 @@ func (c *VideoController) DeleteVideo(w http.ResponseWriter, r *http.Request) {
 // GetVideoCategories 获取视频分类
 func (c *VideoController) GetVideoCategories(w http.ResponseWriter, r *http.Request) {
 	// 调用服务方法
-	categories, err := c.videoService.GetVideoCategories()
+	categories, err := c.videoService.GetVideoCategories(r.Context())
 	if err != nil {
 		http.Error(w, "获取视频分类失败: " + err.Error(), http.StatusInternalServerError)
 		return
@@ -200,4 +320,97 @@ func (c *VideoController) GetVideoCategories(w http.ResponseWriter, r *http.Requ
 		"success": true,
 		"data":    categories,
 	})
-}
\ No newline at end of file
+}
+// TranscodeVideo 重新转码由上传的文件创建的视频，用于转码失败后重试
+func (c *VideoController) TranscodeVideo(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "无效的视频ID", http.StatusBadRequest)
+		return
+	}
+
+	userID, ok := r.Context().Value("userID").(int64)
+	if !ok {
+		http.Error(w, "未登录", http.StatusUnauthorized)
+		return
+	}
+	role, _ := r.Context().Value("role").(string)
+
+	if c.transcodeService == nil {
+		http.Error(w, "未启用视频转码", http.StatusServiceUnavailable)
+		return
+	}
+
+	video, err := c.transcodeService.Retranscode(r.Context(), id, userID, role == "admin")
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, services.ErrVideoNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, services.ErrVideoForbidden):
+			status = http.StatusForbidden
+		case errors.Is(err, services.ErrTranscodeSource), errors.Is(err, services.ErrTranscodeBusy):
+			status = http.StatusConflict
+		}
+		http.Error(w, "重新转码失败: "+err.Error(), status)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    video,
+	})
+}
+
+// RecordViewEvent 记录播放器的播放事件，未登录用户按请求中的session_id去重，每个IP开始新会话的频率受限
+func (c *VideoController) RecordViewEvent(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "无效的视频ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.ViewEventRequest
+	if err := utils.DecodeJSON(w, r, &req); err != nil {
+		utils.WriteRequestError(w, err)
+		return
+	}
+
+	userID, _ := r.Context().Value("userID").(int64)
+	role, _ := r.Context().Value("role").(string)
+	if err := c.analyticsService.RecordEvent(r.Context(), id, userID, role == "admin", urlsign.ClientIP(r), &req); err != nil {
+		writeVideoError(w, "记录播放事件失败: ", err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetVideoStats 获取视频的观看次数、观看时长、完播率和观众留存，只有作者和管理员可以查看
+func (c *VideoController) GetVideoStats(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("userID").(int64)
+	if !ok {
+		http.Error(w, "未登录", http.StatusUnauthorized)
+		return
+	}
+	role, _ := r.Context().Value("role").(string)
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "无效的视频ID", http.StatusBadRequest)
+		return
+	}
+
+	stats, err := c.analyticsService.GetVideoStats(r.Context(), id, userID, role == "admin")
+	if err != nil {
+		writeVideoError(w, "获取播放统计失败: ", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    stats,
+	})
+}
@@ -0,0 +1,197 @@
+// Package imaging 图片的解码、缩放和编码
+//
+// 只使用标准库，支持JPEG、PNG和GIF(只取第一帧)。缩放使用区域平均，缩小时不会产生锯齿，
+// 在预乘Alpha的RGBA上计算，透明边缘不会出现黑边。
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	_ "image/gif" // 注册GIF解码器
+	"image/jpeg"
+	"image/png"
+	"io"
+	"math"
+)
+
+// jpegQuality 缩略图的JPEG质量
+const jpegQuality = 85
+
+// 解码图片时返回的错误
+var (
+	ErrFormat   = errors.New("无法解析的图片")
+	ErrTooLarge = errors.New("图片的像素数过多")
+)
+
+// Size 一种标准缩略图尺寸
+type Size struct {
+	Name   string
+	Width  int
+	Height int
+	Crop   bool // 为true时居中裁剪为Width×Height，否则等比缩放到不超过Width×Height
+}
+
+// Sizes 上传图片时生成的标准缩略图：small用于头像和列表，medium和large用于详情页
+var Sizes = []Size{
+	{Name: "small", Width: 160, Height: 160, Crop: true},
+	{Name: "medium", Width: 640, Height: 640},
+	{Name: "large", Width: 1280, Height: 1280},
+}
+
+// Generated 判断原图为width×height时是否生成该尺寸的缩略图。
+// 裁剪的尺寸总是生成；等比缩放的尺寸只在原图超出范围时生成，否则直接使用原图
+func (s Size) Generated(width, height int) bool {
+	return s.Crop || width > s.Width || height > s.Height
+}
+
+// Decode 解码图片，返回图片和格式名(jpeg、png或gif)。先读取尺寸，像素数超过maxPixels时不解码，避免解压炸弹占满内存
+func Decode(data []byte, maxPixels int) (image.Image, string, error) {
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %v", ErrFormat, err)
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 {
+		return nil, "", ErrFormat
+	}
+	if maxPixels > 0 && cfg.Width*cfg.Height > maxPixels {
+		return nil, "", fmt.Errorf("%w: %dx%d", ErrTooLarge, cfg.Width, cfg.Height)
+	}
+	img, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %v", ErrFormat, err)
+	}
+	return img, format, nil
+}
+
+// Thumbnail 按尺寸生成缩略图，不放大：原图小于目标尺寸时裁剪的尺寸按比例缩小目标，等比缩放的尺寸保持原图大小
+func Thumbnail(img image.Image, s Size) image.Image {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+
+	if !s.Crop {
+		f := min(1, float64(s.Width)/float64(w), float64(s.Height)/float64(h))
+		return Resize(img, scaled(w, f), scaled(h, f))
+	}
+
+	tw, th := s.Width, s.Height
+	if f := min(1, float64(w)/float64(tw), float64(h)/float64(th)); f < 1 {
+		tw, th = scaled(tw, f), scaled(th, f)
+	}
+	// 从原图中心取与目标宽高比相同的最大区域
+	cw, ch := w, h
+	if w*th > h*tw {
+		cw = max(1, h*tw/th)
+	} else {
+		ch = max(1, w*th/tw)
+	}
+	x0, y0 := b.Min.X+(w-cw)/2, b.Min.Y+(h-ch)/2
+	return Resize(subImage(img, image.Rect(x0, y0, x0+cw, y0+ch)), tw, th)
+}
+
+// scaled 按比例缩放边长，至少为1
+func scaled(n int, f float64) int {
+	return max(1, int(math.Round(float64(n)*f)))
+}
+
+// subImage 返回图片的一个区域，图片类型不支持取子图时复制该区域
+func subImage(img image.Image, r image.Rectangle) image.Image {
+	if sub, ok := img.(interface {
+		SubImage(image.Rectangle) image.Image
+	}); ok {
+		return sub.SubImage(r)
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, r.Dx(), r.Dy()))
+	draw.Draw(dst, dst.Bounds(), img, r.Min, draw.Src)
+	return dst
+}
+
+// Resize 使用区域平均将图片缩放为width×height，先按行缩放宽度再按列缩放高度
+func Resize(img image.Image, width, height int) *image.RGBA {
+	b := img.Bounds()
+	src, ok := img.(*image.RGBA)
+	if !ok || src.Bounds().Min != (image.Point{}) {
+		src = image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+		draw.Draw(src, src.Bounds(), img, b.Min, draw.Src)
+	}
+	sw, sh := src.Bounds().Dx(), src.Bounds().Dy()
+
+	// 宽度缩放后的中间结果，每个像素4个通道
+	xs := boxWeights(sw, width)
+	tmp := make([]float32, sh*width*4)
+	for y := 0; y < sh; y++ {
+		row := src.Pix[y*src.Stride:]
+		out := tmp[y*width*4:]
+		for x, c := range xs {
+			var r, g, bl, a float32
+			for i, w := range c.weights {
+				p := row[(c.start+i)*4:]
+				r += float32(p[0]) * w
+				g += float32(p[1]) * w
+				bl += float32(p[2]) * w
+				a += float32(p[3]) * w
+			}
+			out[x*4], out[x*4+1], out[x*4+2], out[x*4+3] = r, g, bl, a
+		}
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y, c := range boxWeights(sh, height) {
+		out := dst.Pix[y*dst.Stride:]
+		for x := 0; x < width; x++ {
+			var r, g, bl, a float32
+			for i, w := range c.weights {
+				p := tmp[((c.start+i)*width+x)*4:]
+				r += p[0] * w
+				g += p[1] * w
+				bl += p[2] * w
+				a += p[3] * w
+			}
+			out[x*4], out[x*4+1], out[x*4+2], out[x*4+3] = clamp(r), clamp(g), clamp(bl), clamp(a)
+		}
+	}
+	return dst
+}
+
+// contribution 目标的一个像素覆盖的源像素范围及各自的权重，权重之和为1
+type contribution struct {
+	start   int
+	weights []float32
+}
+
+// boxWeights 计算将src个像素缩放为dst个像素时每个目标像素的权重
+func boxWeights(src, dst int) []contribution {
+	scale := float64(src) / float64(dst)
+	out := make([]contribution, dst)
+	for i := range out {
+		lo, hi := float64(i)*scale, float64(i+1)*scale
+		start, end := int(lo), min(src, int(math.Ceil(hi)))
+		weights := make([]float32, end-start)
+		for j := range weights {
+			covered := min(hi, float64(start+j+1)) - max(lo, float64(start+j))
+			weights[j] = float32(covered / scale)
+		}
+		out[i] = contribution{start: start, weights: weights}
+	}
+	return out
+}
+
+func clamp(v float32) uint8 {
+	switch {
+	case v <= 0:
+		return 0
+	case v >= 255:
+		return 255
+	}
+	return uint8(v + 0.5)
+}
+
+// Encode 编码缩略图，原图为JPEG时编码为JPEG，否则编码为PNG以保留透明度
+func Encode(w io.Writer, img image.Image, format string) error {
+	if format == "jpeg" {
+		return jpeg.Encode(w, img, &jpeg.Options{Quality: jpegQuality})
+	}
+	return png.Encode(w, img)
+}
@@ -0,0 +1,86 @@
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+// encodePNG 生成左半红色、右半蓝色的PNG
+func encodePNG(t *testing.T, width, height int) []byte {
+	t.Helper()
+
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			c := color.NRGBA{R: 255, A: 255}
+			if x >= width/2 {
+				c = color.NRGBA{B: 255, A: 255}
+			}
+			img.SetNRGBA(x, y, c)
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestDecode(t *testing.T) {
+	data := encodePNG(t, 40, 30)
+	img, format, err := Decode(data, 0)
+	if err != nil || format != "png" || img.Bounds().Dx() != 40 {
+		t.Fatalf("Decode = %v, %q, %v", img.Bounds(), format, err)
+	}
+	if _, _, err := Decode(data, 1000); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("Decode with pixel limit err = %v, want ErrTooLarge", err)
+	}
+	if _, _, err := Decode([]byte("GIF89a not really"), 0); !errors.Is(err, ErrFormat) {
+		t.Errorf("Decode garbage err = %v, want ErrFormat", err)
+	}
+}
+
+func TestThumbnail(t *testing.T) {
+	img, _, err := Decode(encodePNG(t, 400, 200), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		size          Size
+		width, height int
+	}{
+		{Size{Width: 160, Height: 160, Crop: true}, 160, 160},
+		{Size{Width: 300, Height: 300, Crop: true}, 200, 200}, // 不放大，按原图的短边缩小目标
+		{Size{Width: 100, Height: 100}, 100, 50},
+		{Size{Width: 640, Height: 640}, 400, 200},
+	}
+	for _, tt := range tests {
+		got := Thumbnail(img, tt.size).Bounds()
+		if got.Dx() != tt.width || got.Dy() != tt.height {
+			t.Errorf("Thumbnail(%+v) = %dx%d, want %dx%d", tt.size, got.Dx(), got.Dy(), tt.width, tt.height)
+		}
+	}
+
+	// 居中裁剪后左右两半的颜色保持不变，区域平均不跨越边界
+	small := Thumbnail(img, Size{Width: 10, Height: 10, Crop: true})
+	if r, _, b, _ := small.At(0, 5).RGBA(); r>>8 != 255 || b != 0 {
+		t.Errorf("left pixel = %v, want red", small.At(0, 5))
+	}
+	if r, _, b, _ := small.At(9, 5).RGBA(); r != 0 || b>>8 != 255 {
+		t.Errorf("right pixel = %v, want blue", small.At(9, 5))
+	}
+}
+
+func TestSizeGenerated(t *testing.T) {
+	if !Sizes[0].Generated(100, 100) {
+		t.Error("crop size should always be generated")
+	}
+	if Sizes[1].Generated(640, 480) || !Sizes[1].Generated(641, 480) {
+		t.Error("fit size should only be generated for larger images")
+	}
+}
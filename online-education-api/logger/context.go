@@ -0,0 +1,49 @@
+package logger
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+type contextKey int
+
+const (
+	requestIDKey contextKey = iota
+	requestInfoKey
+)
+
+// RequestInfo 请求级别的可变信息，由访问日志中间件创建，供内层中间件补充
+type RequestInfo struct {
+	userID atomic.Int64
+}
+
+// UserID 返回已认证的用户ID，未认证时为0
+func (i *RequestInfo) UserID() int64 {
+	return i.userID.Load()
+}
+
+// WithRequestID 将请求ID写入上下文
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey, id)
+}
+
+// RequestIDFromContext 从上下文中读取请求ID
+func RequestIDFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
+
+// WithRequestInfo 将请求信息写入上下文
+func WithRequestInfo(ctx context.Context, info *RequestInfo) context.Context {
+	return context.WithValue(ctx, requestInfoKey, info)
+}
+
+// SetUserID 记录当前请求的用户ID，供访问日志使用
+func SetUserID(ctx context.Context, userID int64) {
+	if info, ok := ctx.Value(requestInfoKey).(*RequestInfo); ok {
+		info.userID.Store(userID)
+	}
+}
@@ -0,0 +1,144 @@
+package logger
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync/atomic"
+)
+
+// Config 日志配置
+type Config struct {
+	Level         slog.Level            // 默认日志级别
+	PackageLevels map[string]slog.Level // 按包名覆盖的日志级别
+	Output        io.Writer             // 日志输出，默认为标准输出
+}
+
+// settings 当前生效的日志设置
+type settings struct {
+	level         slog.Level
+	packageLevels map[string]slog.Level
+	out           slog.Handler
+}
+
+var current atomic.Pointer[settings]
+
+func init() {
+	Init(Config{Level: slog.LevelInfo})
+}
+
+// Init 初始化全局日志设置，可以在运行时重复调用
+func Init(cfg Config) {
+	out := cfg.Output
+	if out == nil {
+		out = os.Stdout
+	}
+
+	packageLevels := make(map[string]slog.Level, len(cfg.PackageLevels))
+	for pkg, level := range cfg.PackageLevels {
+		packageLevels[pkg] = level
+	}
+
+	current.Store(&settings{
+		level:         cfg.Level,
+		packageLevels: packageLevels,
+		out: slog.NewJSONHandler(out, &slog.HandlerOptions{
+			// 级别过滤由pkgHandler负责，这里放行所有级别
+			Level:       slog.Level(-8),
+			ReplaceAttr: redact,
+		}),
+	})
+
+	slog.SetDefault(New("default"))
+}
+
+// New 创建指定包名的日志记录器，日志级别按包名单独控制
+func New(pkg string) *slog.Logger {
+	return slog.New(&pkgHandler{pkg: pkg}).With(slog.String("pkg", pkg))
+}
+
+// ParseLevel 解析日志级别字符串(debug, info, warn, error)
+func ParseLevel(s string) (slog.Level, bool) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(strings.TrimSpace(s))); err != nil {
+		return slog.LevelInfo, false
+	}
+	return level, true
+}
+
+// ParsePackageLevels 解析形如"services=debug,utils=warn"的按包日志级别
+func ParsePackageLevels(s string) map[string]slog.Level {
+	levels := make(map[string]slog.Level)
+	for _, item := range strings.Split(s, ",") {
+		pkg, lvl, ok := strings.Cut(item, "=")
+		if !ok {
+			continue
+		}
+		if level, ok := ParseLevel(lvl); ok {
+			levels[strings.TrimSpace(pkg)] = level
+		}
+	}
+	return levels
+}
+
+// pkgHandler 按包过滤级别并附加请求上下文信息的slog.Handler
+type pkgHandler struct {
+	pkg string
+	ops []func(slog.Handler) slog.Handler
+}
+
+// Enabled 判断该包是否启用指定级别
+func (h *pkgHandler) Enabled(_ context.Context, level slog.Level) bool {
+	s := current.Load()
+	if pkgLevel, ok := s.packageLevels[h.pkg]; ok {
+		return level >= pkgLevel
+	}
+	return level >= s.level
+}
+
+// Handle 输出日志记录，自动附加上下文中的请求ID
+func (h *pkgHandler) Handle(ctx context.Context, r slog.Record) error {
+	var out slog.Handler = current.Load().out
+	for _, op := range h.ops {
+		out = op(out)
+	}
+	if id := RequestIDFromContext(ctx); id != "" {
+		r.AddAttrs(slog.String("request_id", id))
+	}
+	return out.Handle(ctx, r)
+}
+
+// WithAttrs 返回附加了属性的Handler
+func (h *pkgHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return h.with(func(out slog.Handler) slog.Handler { return out.WithAttrs(attrs) })
+}
+
+// WithGroup 返回附加了分组的Handler
+func (h *pkgHandler) WithGroup(name string) slog.Handler {
+	return h.with(func(out slog.Handler) slog.Handler { return out.WithGroup(name) })
+}
+
+func (h *pkgHandler) with(op func(slog.Handler) slog.Handler) *pkgHandler {
+	ops := make([]func(slog.Handler) slog.Handler, len(h.ops), len(h.ops)+1)
+	copy(ops, h.ops)
+	return &pkgHandler{pkg: h.pkg, ops: append(ops, op)}
+}
+
+// sensitiveKeys 需要脱敏的字段名关键字
+var sensitiveKeys = []string{"password", "passwd", "token", "authorization", "secret"}
+
+// redact 对令牌、密码等敏感字段脱敏
+func redact(_ []string, a slog.Attr) slog.Attr {
+	key := strings.ToLower(a.Key)
+	for _, k := range sensitiveKeys {
+		if strings.Contains(key, k) {
+			return slog.String(a.Key, "[REDACTED]")
+		}
+	}
+	if a.Value.Kind() == slog.KindString && strings.HasPrefix(a.Value.String(), "Bearer ") {
+		return slog.String(a.Key, "Bearer [REDACTED]")
+	}
+	return a
+}
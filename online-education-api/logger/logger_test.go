@@ -0,0 +1,56 @@
+package logger_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"online-education-api/logger"
+)
+
+func TestContextLoggerLevelsAndRedaction(t *testing.T) {
+	var buf bytes.Buffer
+	logger.Init(logger.Config{
+		Level:         slog.LevelInfo,
+		PackageLevels: logger.ParsePackageLevels("noisy=error, verbose=debug,bad=loud"),
+		Output:        &buf,
+	})
+	t.Cleanup(func() { logger.Init(logger.Config{Level: slog.LevelInfo}) })
+
+	ctx := logger.WithRequestID(context.Background(), "req-1")
+	logger.New("noisy").WarnContext(ctx, "dropped")
+	logger.New("verbose").DebugContext(ctx, "kept", "password", "p", "header", "Bearer abc")
+	logger.New("other").Info("no request")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("log lines = %q", lines)
+	}
+	var entry map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[0]), &entry); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{"msg": "kept", "pkg": "verbose", "request_id": "req-1", "password": "[REDACTED]", "header": "Bearer [REDACTED]"}
+	for k, v := range want {
+		if entry[k] != v {
+			t.Errorf("%s = %v, want %v", k, entry[k], v)
+		}
+	}
+	if strings.Contains(lines[1], "request_id") {
+		t.Errorf("log without context has request_id: %s", lines[1])
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	for s, want := range map[string]slog.Level{"debug": slog.LevelDebug, " WARN ": slog.LevelWarn, "error": slog.LevelError} {
+		if got, ok := logger.ParseLevel(s); !ok || got != want {
+			t.Errorf("ParseLevel(%q) = %v, %v", s, got, ok)
+		}
+	}
+	if got, ok := logger.ParseLevel("loud"); ok || got != slog.LevelInfo {
+		t.Errorf("ParseLevel(loud) = %v, %v", got, ok)
+	}
+}
@@ -1,53 +1,191 @@
 package main
 
 import (
-	"log"
-	"net/http"
+	"context"
+	"crypto/rand"
+	"os"
+	"time"
 
+	"online-education-api/cache"
 	"online-education-api/config"
 	"online-education-api/controllers"
+	"online-education-api/logger"
+	"online-education-api/metrics"
 	"online-education-api/middleware"
+	"online-education-api/migrations"
+	"online-education-api/repository/mysql"
 	"online-education-api/routes"
+	"online-education-api/server"
 	"online-education-api/services"
+	"online-education-api/storage"
+	"online-education-api/transcode"
+	"online-education-api/urlsign"
 )
 
+// analyticsFlushTimeout 关闭时写入剩余播放统计的最长时间，不受等待请求的时间影响
+const analyticsFlushTimeout = 5 * time.Second
+
 func main() {
+	// 初始化日志
+	logger.Init(config.GetLogConfig())
+	log := logger.New("main")
+
 	// 获取数据库配置
 	dbConfig := config.GetDBConfig()
 
 	// 初始化数据库连接
 	db, err := config.InitDB(dbConfig)
 	if err != nil {
-		log.Fatalf("无法初始化数据库: %v", err)
+		log.Error("无法初始化数据库", "error", err)
+		os.Exit(1)
+	}
+	metrics.RegisterDBStats(db)
+
+	// 检查表结构，不一致时只输出警告，需要执行 oectl db migrate up
+	if drifts, err := migrations.CheckDrift(context.Background(), db); err != nil {
+		log.Warn("无法检查表结构", "error", err)
+	} else {
+		for _, d := range drifts {
+			log.Warn("表结构与服务层不一致", "drift", d.String())
+		}
+	}
+
+	// 上传的文件保存在对象存储中
+	files, err := storage.New(config.GetStorageConfig())
+	if err != nil {
+		log.Error("无法初始化对象存储", "error", err)
+		db.Close()
+		os.Exit(1)
 	}
-	defer db.Close()
 
 	// 创建服务实例
-	videoService := services.NewVideoService(db)
-	userService := services.NewUserService(db)
-	courseCategoryService := services.NewCourseCategoryService(db)
-	courseService := services.NewCourseService(db)
-	userCourseService := services.NewUserCourseService(db)
-	postService := services.NewPostService(db)
-	paymentService := services.NewPaymentService(db)
-	commentService := services.NewCommentService(db)
+	svc := services.New(mysql.New(db), files, config.GetUploadConfig())
+	if cacheConfig := config.GetCacheConfig(); cacheConfig.Size > 0 {
+		svc.UseCache(cache.NewLRU(cacheConfig.Size), cacheConfig.TTL)
+	}
+
+	// 转码需要本地安装ffmpeg，未安装时上传的视频直接使用原文件播放
+	if transcodeConfig := config.GetTranscodeConfig(); transcodeConfig.Workers > 0 {
+		ffmpeg := &transcode.FFmpeg{FFmpegPath: transcodeConfig.FFmpegPath, FFprobePath: transcodeConfig.FFprobePath}
+		if err := ffmpeg.Check(); err != nil {
+			log.Warn("未找到ffmpeg，不转码上传的视频", "error", err)
+		} else if err := svc.UseTranscoder(ffmpeg, transcodeConfig); err != nil {
+			log.Error("无效的转码配置", "error", err)
+			db.Close()
+			os.Exit(1)
+		}
+	}
+
+	// 由API服务器提供下载的视频文件只能通过签名地址访问，其他存储需要由CDN或存储服务自行保护
+	var signer *urlsign.Signer
+	if local, ok := files.(*storage.Local); ok && local.PublicPath() != "" {
+		playbackConfig := config.GetPlaybackConfig()
+		key := []byte(playbackConfig.SigningKey)
+		if len(key) == 0 {
+			log.Warn("未设置PLAYBACK_SIGNING_KEY，使用随机密钥，重启后已签发的播放地址失效")
+			key = []byte(rand.Text())
+		}
+		signer = urlsign.New(key, local.PublicPath(), playbackConfig.URLTTL, playbackConfig.BindIP)
+		svc.UseSigner(signer)
+	} else {
+		log.Warn("对象存储不由API服务器提供下载，不签名播放地址")
+	}
+
+	// 播放统计在进程内去重和缓冲，定期批量写入数据库
+	svc.UseAnalytics(config.GetAnalyticsConfig())
+	svc.UseDanmaku(config.GetDanmakuConfig())
+
+	// 搜索索引保存在进程内，启动时从数据库加载
+	if n, err := svc.Search.Rebuild(context.Background()); err != nil {
+		log.Warn("无法建立搜索索引", "error", err)
+	} else {
+		log.Info("搜索索引已建立", "documents", n)
+	}
 
 	// 创建控制器实例
-	videoController := controllers.NewVideoController(videoService)
-	userController := controllers.NewUserController(userService)
-	courseCategoryController := controllers.NewCourseCategoryController(courseCategoryService)
-	courseController := controllers.NewCourseController(courseService)
-	userCourseController := controllers.NewUserCourseController(userCourseService)
-	postController := controllers.NewPostController(postService)
-	paymentController := controllers.NewPaymentController(paymentService)
-	commentController := controllers.NewCommentController(commentService)
+	videoController := controllers.NewVideoController(svc.Video, svc.Transcode, svc.Playback, svc.Analytics, svc.Playlist)
+	userController := controllers.NewUserController(svc.User)
+	courseCategoryController := controllers.NewCourseCategoryController(svc.CourseCategory)
+	courseController := controllers.NewCourseController(svc.Course, svc.Playback)
+	userCourseController := controllers.NewUserCourseController(svc.UserCourse)
+	postController := controllers.NewPostController(svc.Post)
+	paymentController := controllers.NewPaymentController(svc.Payment)
+	commentController := controllers.NewCommentController(svc.Comment)
+	healthController := controllers.NewHealthController(svc.Health)
+	searchController := controllers.NewSearchController(svc.Search)
+	reviewController := controllers.NewReviewController(svc.Review)
+	uploadController := controllers.NewUploadController(svc.Upload)
+	imageController := controllers.NewImageController(svc.Image)
+	danmakuController := controllers.NewDanmakuController(svc.Danmaku)
+	subtitleController := controllers.NewSubtitleController(svc.Subtitle)
+	playlistController := controllers.NewPlaylistController(svc.Playlist)
+	videoCategoryController := controllers.NewVideoCategoryController(svc.Video)
 
 	// 设置路由
-	r := routes.SetupRoutes(videoController, userController, courseCategoryController, courseController, userCourseController, postController, paymentController, commentController)
+	r := routes.SetupRoutes(videoController, userController, courseCategoryController, courseController, userCourseController, postController, paymentController, commentController, healthController, searchController, reviewController, uploadController, imageController, danmakuController, subtitleController, playlistController, videoCategoryController)
+	routes.MountMedia(r, files, signer)
+
+	// 应用请求ID、访问日志、CORS中间件和请求统计
+	handler := middleware.RequestIDMiddleware(middleware.AccessLogMiddleware(middleware.CORSMiddleware(middleware.MetricsHandler(r))))
+
+	srv, err := server.New(config.GetServerConfig(), handler)
+	if err != nil {
+		log.Error("无法创建服务器", "error", err)
+		db.Close()
+		os.Exit(1)
+	}
+
+	// 关闭时按注册的相反顺序执行，数据库连接池最先注册、最后关闭
+	srv.OnShutdown("database", func(ctx context.Context) error {
+		return db.Close()
+	})
+
+	// 后台转码任务，关闭时中止正在执行的ffmpeg，未完成的视频在下次启动时重新转码
+	if svc.Transcode != nil {
+		transcodeCtx, stopTranscode := context.WithCancel(context.Background())
+		transcodeDone := make(chan struct{})
+		go func() {
+			defer close(transcodeDone)
+			svc.Transcode.Run(transcodeCtx)
+		}()
+		srv.OnShutdown("transcode", func(ctx context.Context) error {
+			stopTranscode()
+			select {
+			case <-transcodeDone:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+	}
+
+	// 后台写入播放统计，关闭时在数据库连接池关闭之前写入剩余的计数
+	analyticsCtx, stopAnalytics := context.WithCancel(context.Background())
+	analyticsDone := make(chan struct{})
+	go func() {
+		defer close(analyticsDone)
+		svc.Analytics.Run(analyticsCtx)
+	}()
+	// 后台任务未能及时退出时也写入剩余的计数，Flush可以与进行中的写入并发执行
+	srv.OnShutdown("analytics", func(ctx context.Context) error {
+		stopAnalytics()
+		select {
+		case <-analyticsDone:
+		case <-ctx.Done():
+		}
+		flushCtx, cancel := context.WithTimeout(context.Background(), analyticsFlushTimeout)
+		defer cancel()
+		return svc.Analytics.Flush(flushCtx)
+	})
+
+	// 关闭HTTP服务器不会断开WebSocket连接，由弹幕服务通知观看者断开
+	srv.OnShutdown("danmaku", func(ctx context.Context) error {
+		svc.Danmaku.Close()
+		return nil
+	})
 
-	// 应用CORS中间件
-	log.Println("服务器启动在 http://localhost:8082")
-	if err := http.ListenAndServe(":8082", middleware.CORSMiddleware(r)); err != nil {
-		log.Fatalf("服务器启动失败: %v", err)
+	if err := srv.Run(context.Background()); err != nil {
+		log.Error("服务器异常退出", "error", err)
+		os.Exit(1)
 	}
-}
\ No newline at end of file
+}
@@ -0,0 +1,25 @@
+package metrics
+
+import "database/sql"
+
+// RegisterDBStats 注册数据库连接池统计指标
+func RegisterDBStats(db *sql.DB) {
+	NewGaugeFunc("db_pool_max_open_connections", "Maximum number of open connections to the database.", func() float64 {
+		return float64(db.Stats().MaxOpenConnections)
+	})
+	NewGaugeFunc("db_pool_open_connections", "The number of established connections both in use and idle.", func() float64 {
+		return float64(db.Stats().OpenConnections)
+	})
+	NewGaugeFunc("db_pool_in_use_connections", "The number of connections currently in use.", func() float64 {
+		return float64(db.Stats().InUse)
+	})
+	NewGaugeFunc("db_pool_idle_connections", "The number of idle connections.", func() float64 {
+		return float64(db.Stats().Idle)
+	})
+	NewGaugeFunc("db_pool_wait_count", "The total number of connections waited for.", func() float64 {
+		return float64(db.Stats().WaitCount)
+	})
+	NewGaugeFunc("db_pool_wait_duration_seconds", "The total time blocked waiting for a new connection.", func() float64 {
+		return db.Stats().WaitDuration.Seconds()
+	})
+}
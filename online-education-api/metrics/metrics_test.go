@@ -0,0 +1,112 @@
+package metrics_test
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"online-education-api/metrics"
+)
+
+var runs atomic.Int64
+
+// uniqueName 返回本次运行唯一的指标名，默认注册表是全局的，-count多次运行时不能复用已有的指标
+func uniqueName(base string) string {
+	return fmt.Sprintf("%s_%d", base, runs.Add(1))
+}
+
+// expand 将期望输出中的NAME替换为指标名
+func expand(name string, lines ...string) []string {
+	for i, line := range lines {
+		lines[i] = strings.ReplaceAll(line, "NAME", name)
+	}
+	return lines
+}
+
+// family 返回输出中指定指标的全部行
+func family(t *testing.T, name string) []string {
+	t.Helper()
+
+	var sb strings.Builder
+	metrics.DefaultRegistry.WriteText(&sb)
+	var lines []string
+	for _, line := range strings.Split(sb.String(), "\n") {
+		if strings.HasPrefix(line, name+"{") || strings.HasPrefix(line, name+"_") || strings.HasPrefix(line, name+" ") ||
+			strings.HasPrefix(line, "# HELP "+name+" ") || strings.HasPrefix(line, "# TYPE "+name+" ") {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+func expectLines(t *testing.T, got, want []string) {
+	t.Helper()
+
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestCounterExposition(t *testing.T) {
+	name := uniqueName("test_requests")
+	c := metrics.NewCounterVec(name, "Requests.\nSecond line with \\.", "path", "code")
+	c.Inc("/b", "200")
+	c.Add(2, `/a"quoted"\n`+"\n", "500")
+	c.Add(-1, "/b", "200")
+
+	expectLines(t, family(t, name), expand(name,
+		`# HELP NAME Requests.\nSecond line with \\.`,
+		`# TYPE NAME counter`,
+		`NAME{path="/a\"quoted\"\\n\n",code="500"} 2`,
+		`NAME{path="/b",code="200"} 1`,
+	))
+	if v := c.Value("/b", "200"); v != 1 {
+		t.Errorf("Value = %v, want 1", v)
+	}
+
+	// 不带标签的计数器在没有计数时也输出0
+	events := uniqueName("test_events")
+	metrics.NewCounter(events, "Events.")
+	expectLines(t, family(t, events), expand(events,
+		`# HELP NAME Events.`,
+		`# TYPE NAME counter`,
+		`NAME 0`,
+	))
+}
+
+func TestHistogramExposition(t *testing.T) {
+	name := uniqueName("test_latency")
+	h := metrics.NewHistogramVec(name, "Latency.", []float64{1, 0.1}, "route")
+	for _, v := range []float64{0.05, 0.5, 2} {
+		h.Observe(v, "/x")
+	}
+
+	// 分桶排序后累计输出，+Inf等于总次数
+	expectLines(t, family(t, name), expand(name,
+		`# HELP NAME Latency.`,
+		`# TYPE NAME histogram`,
+		`NAME_bucket{route="/x",le="0.1"} 1`,
+		`NAME_bucket{route="/x",le="1"} 2`,
+		`NAME_bucket{route="/x",le="+Inf"} 3`,
+		`NAME_sum{route="/x"} 2.55`,
+		`NAME_count{route="/x"} 3`,
+	))
+}
+
+func TestGaugeFuncAndHandler(t *testing.T) {
+	value := 1.5
+	name := uniqueName("test_temperature")
+	metrics.NewGaugeFunc(name, "Temperature.", func() float64 { return value })
+	value = 3
+
+	rec := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	if body := rec.Body.String(); !strings.Contains(body, fmt.Sprintf("# TYPE %s gauge\n%s 3\n", name, name)) {
+		t.Errorf("gauge not in output:\n%s", body)
+	}
+}
@@ -0,0 +1,139 @@
+package middleware_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"online-education-api/logger"
+	"online-education-api/middleware"
+)
+
+// accessEntry 经过请求ID和访问日志中间件处理请求，返回唯一的访问日志
+func accessEntry(t *testing.T, handler http.HandlerFunc, req *http.Request) (*httptest.ResponseRecorder, map[string]interface{}) {
+	t.Helper()
+
+	buf := captureLogs(t)
+	rec := httptest.NewRecorder()
+	middleware.RequestIDMiddleware(middleware.AccessLogMiddleware(handler)).ServeHTTP(rec, req)
+
+	var access []map[string]interface{}
+	for _, entry := range logEntries(t, buf) {
+		if entry["msg"] == "http_request" {
+			access = append(access, entry)
+		}
+	}
+	if len(access) != 1 {
+		t.Fatalf("access log entries = %v", access)
+	}
+	return rec, access[0]
+}
+
+func TestAccessLogRecordsStatusAndBytes(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		status  float64
+		bytes   float64
+		level   string
+	}{
+		{"implicit 200", func(w http.ResponseWriter, r *http.Request) { io.WriteString(w, "hello") }, 200, 5, "INFO"},
+		{"no body", func(w http.ResponseWriter, r *http.Request) {}, 200, 0, "INFO"},
+		{"explicit status", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusCreated)
+			w.WriteHeader(http.StatusBadRequest) // 重复调用不覆盖
+			io.WriteString(w, "ab")
+			io.WriteString(w, "cd")
+		}, 201, 4, "INFO"},
+		{"server error", func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "boom", http.StatusInternalServerError)
+		}, 500, 5, "ERROR"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/api/items?secret=1", nil)
+			req.Header.Set("User-Agent", "test-agent")
+			rec, entry := accessEntry(t, tt.handler, req)
+			if entry["status"] != tt.status || entry["bytes"] != tt.bytes || entry["level"] != tt.level {
+				t.Errorf("entry = %v, want status %v bytes %v level %s", entry, tt.status, tt.bytes, tt.level)
+			}
+			if entry["method"] != "POST" || entry["path"] != "/api/items" || entry["user_agent"] != "test-agent" {
+				t.Errorf("entry = %v", entry)
+			}
+			if entry["request_id"] != rec.Header().Get(middleware.RequestIDHeader) {
+				t.Errorf("request_id = %v, header = %q", entry["request_id"], rec.Header().Get(middleware.RequestIDHeader))
+			}
+		})
+	}
+}
+
+func TestAccessLogRecordsUserID(t *testing.T) {
+	_, entry := accessEntry(t, func(w http.ResponseWriter, r *http.Request) {
+		logger.SetUserID(r.Context(), 42)
+	}, httptest.NewRequest("GET", "/", nil))
+	if entry["user_id"] != float64(42) {
+		t.Errorf("user_id = %v, want 42", entry["user_id"])
+	}
+}
+
+func TestAccessLogSupportsHijack(t *testing.T) {
+	buf := captureLogs(t)
+	// WebSocket升级需要接管连接，访问日志的包装不能妨碍
+	handler := middleware.AccessLogMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, rw, err := http.NewResponseController(w).Hijack()
+		if err != nil {
+			t.Errorf("Hijack = %v", err)
+			return
+		}
+		defer conn.Close()
+		rw.WriteString("HTTP/1.1 101 Switching Protocols\r\nConnection: Upgrade\r\nUpgrade: test\r\n\r\n")
+		rw.Flush()
+	}))
+	done := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer close(done)
+		handler.ServeHTTP(w, r)
+	}))
+	defer srv.Close()
+
+	req, err := http.NewRequest("GET", srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "test")
+	resp, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("status = %d, want 101", resp.StatusCode)
+	}
+	<-done // 接管的连接不会被Close等待，处理器返回后访问日志已写入
+
+	var found bool
+	for _, entry := range logEntries(t, buf) {
+		if entry["msg"] == "http_request" {
+			found = entry["status"] == float64(http.StatusSwitchingProtocols)
+		}
+	}
+	if !found {
+		t.Errorf("access log without status 101:\n%s", buf.String())
+	}
+}
+
+func TestStatusRecorderUnwrap(t *testing.T) {
+	// 包装后的ResponseWriter仍然可以通过ResponseController刷新
+	rec := httptest.NewRecorder()
+	middleware.AccessLogMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "chunk")
+		if err := http.NewResponseController(w).Flush(); err != nil {
+			t.Errorf("Flush = %v", err)
+		}
+	})).ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	if !rec.Flushed {
+		t.Error("response was not flushed")
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"strings"
 
+	"online-education-api/logger"
 	"online-education-api/utils"
 )
 
@@ -32,6 +33,9 @@ func AuthMiddleware(next http.Handler) http.Handler {
 			return
 		}
 
+		// 记录用户ID供访问日志使用
+		logger.SetUserID(r.Context(), claims.UserID)
+
 		// 将用户信息和角色添加到请求上下文中
 	ctx := context.WithValue(r.Context(), "userID", claims.UserID)
 	ctx = context.WithValue(ctx, "username", claims.Username)
@@ -40,4 +44,15 @@ func AuthMiddleware(next http.Handler) http.Handler {
 		// 继续处理请求
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
-}
\ No newline at end of file
+}
+// OptionalAuthMiddleware 可选的JWT认证中间件，未提供令牌时作为匿名用户继续处理，提供了令牌则必须有效
+func OptionalAuthMiddleware(next http.Handler) http.Handler {
+	auth := AuthMiddleware(next)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		auth.ServeHTTP(w, r)
+	})
+}
@@ -12,7 +12,9 @@ func CORSMiddleware(next http.Handler) http.Handler {
 		// 允许的请求方法
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
 		// 允许的请求头
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Request-ID, If-None-Match")
+		// 允许前端读取的响应头
+		w.Header().Set("Access-Control-Expose-Headers", "X-Request-ID, ETag")
 		// 允许credentials
 		w.Header().Set("Access-Control-Allow-Credentials", "true")
 
@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"online-education-api/metrics"
+)
+
+var (
+	httpRequestsTotal = metrics.NewCounterVec("http_requests_total",
+		"Total number of HTTP requests by route template.", "method", "route", "status")
+	httpRequestDuration = metrics.NewHistogramVec("http_request_duration_seconds",
+		"HTTP request latency by route template.", nil, "method", "route")
+)
+
+// MetricsHandler 包装路由器，按路由模板统计请求数和耗时。
+// 包装在路由器外层而不是通过mux.Router.Use注册，没有匹配的路由(404/405)也会计入，路由记为unknown
+func MetricsHandler(router *mux.Router) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w}
+
+		route := "unknown"
+		var match mux.RouteMatch
+		if router.Match(r, &match) && match.Route != nil {
+			if tpl, err := match.Route.GetPathTemplate(); err == nil {
+				route = tpl
+			}
+		}
+
+		router.ServeHTTP(rec, r)
+
+		status := rec.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+
+		httpRequestsTotal.Inc(r.Method, route, strconv.Itoa(status))
+		httpRequestDuration.Observe(time.Since(start).Seconds(), r.Method, route)
+	})
+}
@@ -0,0 +1,98 @@
+package middleware_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"online-education-api/logger"
+	"online-education-api/middleware"
+)
+
+// captureLogs 将日志输出到缓冲区，测试结束时恢复默认设置
+func captureLogs(t *testing.T) *bytes.Buffer {
+	t.Helper()
+
+	var buf bytes.Buffer
+	logger.Init(logger.Config{Level: slog.LevelInfo, Output: &buf})
+	t.Cleanup(func() { logger.Init(logger.Config{Level: slog.LevelInfo}) })
+	return &buf
+}
+
+// logEntries 解析缓冲区中的JSON日志
+func logEntries(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	t.Helper()
+
+	var entries []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var entry map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("invalid log line %q: %v", line, err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func TestRequestIDMiddleware(t *testing.T) {
+	buf := captureLogs(t)
+	log := logger.New("test")
+
+	var seen string
+	handler := middleware.RequestIDMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = logger.RequestIDFromContext(r.Context())
+		log.InfoContext(r.Context(), "handled")
+	}))
+
+	tests := []struct {
+		name      string
+		header    string
+		propagate bool
+	}{
+		{"generated", "", false},
+		{"propagated", "abc-123_DEF", true},
+		{"invalid characters", "abc\n{\"injected\":1}", false},
+		{"too long", strings.Repeat("a", 65), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf.Reset()
+			req := httptest.NewRequest("GET", "/", nil)
+			if tt.header != "" {
+				req.Header.Set(middleware.RequestIDHeader, tt.header)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			id := rec.Header().Get(middleware.RequestIDHeader)
+			if tt.propagate && id != tt.header {
+				t.Errorf("response id = %q, want %q", id, tt.header)
+			}
+			if !tt.propagate && (len(id) != 32 || id == tt.header) {
+				t.Errorf("response id = %q, want a generated id", id)
+			}
+			if seen != id {
+				t.Errorf("context id = %q, response id = %q", seen, id)
+			}
+			entries := logEntries(t, buf)
+			if len(entries) != 1 || entries[0]["request_id"] != id || entries[0]["pkg"] != "test" {
+				t.Errorf("log entries = %v, want request_id %s", entries, id)
+			}
+		})
+	}
+
+	// 每个请求生成不同的ID
+	a, b := httptest.NewRecorder(), httptest.NewRecorder()
+	handler.ServeHTTP(a, httptest.NewRequest("GET", "/", nil))
+	handler.ServeHTTP(b, httptest.NewRequest("GET", "/", nil))
+	if a.Header().Get(middleware.RequestIDHeader) == b.Header().Get(middleware.RequestIDHeader) {
+		t.Error("generated request ids are equal")
+	}
+}
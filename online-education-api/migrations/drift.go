@@ -0,0 +1,208 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+)
+
+// 列的类型分类，只检查服务层扫描结果时依赖的类型
+const (
+	kindAny    = ""
+	kindInt    = "int"
+	kindString = "string"
+)
+
+// column 服务层依赖的列
+type column struct {
+	name string
+	kind string
+}
+
+func col(name string) column       { return column{name: name} }
+func intCol(name string) column    { return column{name: name, kind: kindInt} }
+func stringCol(name string) column { return column{name: name, kind: kindString} }
+
+// expectedSchema 服务层SQL读写的表和列，修改服务中的SQL时需要同步更新
+var expectedSchema = map[string][]column{
+	"users": {
+		intCol("id"), stringCol("username"), stringCol("email"), stringCol("password"), stringCol("role"),
+		col("avatar"), col("bio"), intCol("status"), col("last_login"), col("created_at"), col("updated_at"),
+	},
+	"course_categories": {
+		intCol("id"), stringCol("name"), intCol("parent_id"), intCol("sort_order"), col("created_at"), col("updated_at"),
+	},
+	"courses": {
+		intCol("id"), stringCol("title"), col("description"), col("cover_image"), col("price"), col("original_price"),
+		intCol("category_id"), intCol("teacher_id"), intCol("level"), intCol("duration"), intCol("student_count"),
+		col("rating"), intCol("review_count"), intCol("rating_total"), intCol("status"), col("created_at"), col("updated_at"),
+	},
+	"course_reviews": {
+		intCol("id"), intCol("course_id"), intCol("user_id"), intCol("rating"), col("content"), col("reply"),
+		col("replied_at"), intCol("status"), intCol("report_count"), col("created_at"), col("updated_at"),
+	},
+	"course_review_reports": {
+		intCol("id"), intCol("review_id"), intCol("user_id"), stringCol("reason"), col("created_at"),
+	},
+	"chapters": {
+		intCol("id"), intCol("course_id"), stringCol("title"), intCol("sort_order"), col("created_at"),
+	},
+	"lessons": {
+		intCol("id"), intCol("chapter_id"), stringCol("title"), col("video_url"), intCol("duration"),
+		intCol("sort_order"), intCol("free"), col("created_at"),
+	},
+	"user_courses": {
+		intCol("id"), intCol("user_id"), intCol("course_id"), col("order_id"), col("price"), intCol("status"),
+		col("created_at"), col("updated_at"),
+	},
+	"payments": {
+		intCol("id"), stringCol("order_id"), intCol("user_id"), intCol("course_id"), col("amount"),
+		stringCol("payment_method"), stringCol("status"), col("transaction_id"), col("created_at"), col("updated_at"),
+	},
+	"posts": {
+		intCol("id"), intCol("user_id"), stringCol("title"), stringCol("content"), col("category"),
+		intCol("view_count"), intCol("comment_count"), intCol("like_count"), intCol("status"),
+		col("created_at"), col("updated_at"),
+	},
+	"videos": {
+		intCol("id"), stringCol("title"), col("description"), stringCol("video_url"), col("upload_id"), col("cover_image_url"),
+		intCol("duration"), intCol("author_id"), intCol("category_id"), intCol("view_count"), intCol("like_count"),
+		intCol("favorite_count"), stringCol("visibility"), stringCol("status"), col("hls_url"), col("thumbnails_url"),
+		col("processing_error"), col("created_at"), col("updated_at"),
+	},
+	"video_stats": {
+		intCol("video_id"), intCol("views"), intCol("watch_seconds"), intCol("completions"), col("updated_at"),
+	},
+	"video_retention": {
+		intCol("video_id"), intCol("bucket"), intCol("viewers"),
+	},
+	"video_renditions": {
+		intCol("id"), intCol("video_id"), stringCol("name"), intCol("width"), intCol("height"), intCol("bandwidth"),
+		stringCol("playlist_url"),
+	},
+	"images": {
+		intCol("id"), col("user_id"), stringCol("file_name"), stringCol("file_path"), intCol("file_size"),
+		stringCol("mime_type"), col("width"), col("height"), col("alt_text"), col("entity_type"), col("entity_id"),
+		intCol("is_primary"), col("created_at"), col("updated_at"),
+	},
+	"uploads": {
+		stringCol("id"), intCol("user_id"), stringCol("file_name"), intCol("size"), intCol("received"), col("mime_type"),
+		col("checksum"), col("storage_key"), stringCol("status"), col("created_at"), col("updated_at"),
+	},
+	"video_categories": {
+		intCol("id"), stringCol("name"), col("description"), col("parent_id"), intCol("sort_order"), col("created_at"),
+		col("updated_at"),
+	},
+	"video_comments": {
+		intCol("id"), intCol("video_id"), intCol("user_id"), col("content"), col("created_at"), col("updated_at"),
+	},
+	"playlists": {
+		intCol("id"), intCol("user_id"), stringCol("title"), stringCol("description"), stringCol("visibility"),
+		col("created_at"), col("updated_at"),
+	},
+	"playlist_videos": {
+		intCol("playlist_id"), intCol("video_id"), intCol("position"), col("added_at"),
+	},
+	"video_progress": {
+		intCol("user_id"), intCol("video_id"), intCol("position_ms"), intCol("completed"), col("updated_at"),
+	},
+	"subtitles": {
+		intCol("id"), col("video_id"), col("lesson_id"), stringCol("language"), stringCol("label"), intCol("is_default"),
+		intCol("cue_count"), col("created_at"), col("updated_at"),
+	},
+	"subtitle_cues": {
+		intCol("subtitle_id"), intCol("seq"), intCol("start_ms"), intCol("end_ms"), col("text"),
+	},
+	"video_danmaku": {
+		intCol("id"), intCol("video_id"), intCol("user_id"), stringCol("content"), intCol("offset_ms"), stringCol("color"),
+		stringCol("mode"), col("created_at"),
+	},
+}
+
+// Drift 线上库与服务层期望不一致的地方
+type Drift struct {
+	Table    string `json:"table"`
+	Column   string `json:"column,omitempty"`
+	Expected string `json:"expected,omitempty"`
+	Actual   string `json:"actual,omitempty"`
+	Problem  string `json:"problem"`
+}
+
+func (d Drift) String() string {
+	switch {
+	case d.Column == "":
+		return fmt.Sprintf("%s: %s", d.Table, d.Problem)
+	case d.Expected != "":
+		return fmt.Sprintf("%s.%s: %s (期望%s, 实际%s)", d.Table, d.Column, d.Problem, d.Expected, d.Actual)
+	default:
+		return fmt.Sprintf("%s.%s: %s", d.Table, d.Column, d.Problem)
+	}
+}
+
+// CheckDrift 比较当前数据库的表结构与服务层期望的表结构
+func CheckDrift(ctx context.Context, db *sql.DB) ([]Drift, error) {
+	rows, err := db.QueryContext(ctx, "SELECT table_name, column_name, data_type FROM information_schema.columns WHERE table_schema = DATABASE()")
+	if err != nil {
+		return nil, fmt.Errorf("查询表结构失败: %w", err)
+	}
+	defer rows.Close()
+
+	actual := make(map[string]map[string]string)
+	for rows.Next() {
+		var table, name, dataType string
+		if err := rows.Scan(&table, &name, &dataType); err != nil {
+			return nil, fmt.Errorf("读取表结构失败: %w", err)
+		}
+		if actual[table] == nil {
+			actual[table] = make(map[string]string)
+		}
+		actual[table][name] = dataType
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("读取表结构失败: %w", err)
+	}
+
+	return compareSchema(expectedSchema, actual), nil
+}
+
+// compareSchema 按表名和列名顺序返回所有不一致的地方
+func compareSchema(expected map[string][]column, actual map[string]map[string]string) []Drift {
+	tables := make([]string, 0, len(expected))
+	for table := range expected {
+		tables = append(tables, table)
+	}
+	sort.Strings(tables)
+
+	var drifts []Drift
+	for _, table := range tables {
+		columns, ok := actual[table]
+		if !ok {
+			drifts = append(drifts, Drift{Table: table, Problem: "表不存在"})
+			continue
+		}
+		for _, c := range expected[table] {
+			dataType, ok := columns[c.name]
+			if !ok {
+				drifts = append(drifts, Drift{Table: table, Column: c.name, Problem: "列不存在"})
+				continue
+			}
+			if c.kind != kindAny && kindOf(dataType) != c.kind {
+				drifts = append(drifts, Drift{Table: table, Column: c.name, Expected: c.kind, Actual: dataType, Problem: "类型不匹配"})
+			}
+		}
+	}
+	return drifts
+}
+
+// kindOf 将information_schema中的data_type归类
+func kindOf(dataType string) string {
+	switch dataType {
+	case "tinyint", "smallint", "mediumint", "int", "integer", "bigint":
+		return kindInt
+	case "char", "varchar", "tinytext", "text", "mediumtext", "longtext", "enum", "set":
+		return kindString
+	default:
+		return dataType
+	}
+}
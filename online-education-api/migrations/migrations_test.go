@@ -0,0 +1,90 @@
+package migrations
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoadEmbeddedMigrations(t *testing.T) {
+	migrations, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(migrations) == 0 {
+		t.Fatal("expected embedded migrations")
+	}
+	for i, mig := range migrations {
+		if want := int64(i + 1); mig.Version != want {
+			t.Fatalf("migration %d has version %d, want %d", i, mig.Version, want)
+		}
+		if len(SplitStatements(mig.Up)) == 0 || len(SplitStatements(mig.Down)) == 0 {
+			t.Fatalf("migration %04d_%s has an empty up or down script", mig.Version, mig.Name)
+		}
+	}
+}
+
+func TestLoadRejectsIncompleteMigrations(t *testing.T) {
+	fsys := fstest.MapFS{
+		"sql/0001_init.up.sql": {Data: []byte("CREATE TABLE a (id int);")},
+	}
+	if _, err := load(fsys, "sql"); err == nil {
+		t.Fatal("expected an error for a migration without a down file")
+	}
+
+	fsys = fstest.MapFS{
+		"sql/1-init.sql": {Data: []byte("CREATE TABLE a (id int);")},
+	}
+	if _, err := load(fsys, "sql"); err == nil {
+		t.Fatal("expected an error for a badly named file")
+	}
+}
+
+func TestSplitStatements(t *testing.T) {
+	script := `-- 注释; 不应拆分
+CREATE TABLE t (
+  name varchar(10) DEFAULT 'a;b', -- 行尾注释;
+  note varchar(10) DEFAULT 'it''s; fine',
+  ` + "`odd;name`" + ` int /* 块注释; */
+);
+# 井号注释;
+UPDATE t SET name = "x\";y";
+
+`
+	got := SplitStatements(script)
+	if len(got) != 2 {
+		t.Fatalf("expected 2 statements, got %d: %q", len(got), got)
+	}
+	for _, want := range []string{"'a;b'", "'it''s; fine'", "`odd;name`"} {
+		if !strings.Contains(got[0], want) {
+			t.Fatalf("first statement lost %s: %q", want, got[0])
+		}
+	}
+	if strings.Contains(got[0], "注释") {
+		t.Fatalf("comments should be stripped: %q", got[0])
+	}
+	if got[1] != `UPDATE t SET name = "x\";y"` {
+		t.Fatalf("unexpected second statement: %q", got[1])
+	}
+}
+
+func TestCompareSchema(t *testing.T) {
+	expected := map[string][]column{
+		"posts":    {intCol("id"), intCol("view_count"), intCol("status"), col("content")},
+		"payments": {intCol("id")},
+	}
+	actual := map[string]map[string]string{
+		"posts": {"id": "int", "views": "int", "status": "enum", "content": "text"},
+	}
+
+	got := compareSchema(expected, actual)
+	want := []Drift{
+		{Table: "payments", Problem: "表不存在"},
+		{Table: "posts", Column: "view_count", Problem: "列不存在"},
+		{Table: "posts", Column: "status", Expected: "int", Actual: "enum", Problem: "类型不匹配"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("compareSchema() = %v, want %v", got, want)
+	}
+}
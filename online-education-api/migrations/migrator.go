@@ -0,0 +1,280 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"online-education-api/logger"
+)
+
+var migrateLog = logger.New("migrations")
+
+// lockName 防止多个实例同时执行迁移的MySQL命名锁
+const lockName = "online_education_schema_migrations"
+
+// lockTimeout 等待其他实例释放迁移锁的最长时间(秒)
+const lockTimeout = 30
+
+// ErrDirty 上一次迁移执行失败，需要人工修复后使用force清除标记
+var ErrDirty = errors.New("数据库处于dirty状态")
+
+// Status 迁移的执行状态
+type Status struct {
+	Version   int64      `json:"version"`
+	Name      string     `json:"name"`
+	Applied   bool       `json:"applied"`
+	Dirty     bool       `json:"dirty"`
+	AppliedAt *time.Time `json:"applied_at,omitempty"`
+}
+
+// appliedMigration schema_migrations中的一条记录
+type appliedMigration struct {
+	version   int64
+	name      string
+	dirty     bool
+	appliedAt time.Time
+}
+
+// Migrator 数据库迁移执行器
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+}
+
+// New 使用内嵌的迁移文件创建迁移执行器
+func New(db *sql.DB) (*Migrator, error) {
+	migrations, err := Load()
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, migrations: migrations}, nil
+}
+
+// Up 执行未应用的迁移，steps<=0时执行全部，返回本次执行的迁移
+func (m *Migrator) Up(ctx context.Context, steps int) ([]Migration, error) {
+	var done []Migration
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if err := checkDirty(applied); err != nil {
+			return err
+		}
+
+		for _, mig := range m.migrations {
+			if _, ok := applied[mig.Version]; ok {
+				continue
+			}
+			if steps > 0 && len(done) >= steps {
+				break
+			}
+			if err := m.apply(ctx, conn, mig); err != nil {
+				return err
+			}
+			done = append(done, mig)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Down 按版本倒序回滚已应用的迁移，steps<=0时回滚全部，返回本次回滚的迁移
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	var done []Migration
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if err := checkDirty(applied); err != nil {
+			return err
+		}
+
+		for i := len(m.migrations) - 1; i >= 0; i-- {
+			mig := m.migrations[i]
+			if _, ok := applied[mig.Version]; !ok {
+				continue
+			}
+			if steps > 0 && len(done) >= steps {
+				break
+			}
+			if err := m.revert(ctx, conn, mig); err != nil {
+				return err
+			}
+			done = append(done, mig)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Status 返回每个迁移的执行状态
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("获取数据库连接失败: %w", err)
+	}
+	defer conn.Close()
+
+	applied, err := m.applied(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, mig := range m.migrations {
+		s := Status{Version: mig.Version, Name: mig.Name}
+		if a, ok := applied[mig.Version]; ok {
+			appliedAt := a.appliedAt
+			s.Applied = true
+			s.Dirty = a.dirty
+			s.AppliedAt = &appliedAt
+		}
+		statuses = append(statuses, s)
+	}
+	return statuses, nil
+}
+
+// Force 人工修复失败的迁移后，将数据库标记为已应用到version(包含)的干净状态
+func (m *Migrator) Force(ctx context.Context, version int64) error {
+	return m.withLock(ctx, func(conn *sql.Conn) error {
+		if _, err := conn.ExecContext(ctx, "DELETE FROM schema_migrations WHERE version > ?", version); err != nil {
+			return fmt.Errorf("更新迁移记录失败: %w", err)
+		}
+		if _, err := conn.ExecContext(ctx, "UPDATE schema_migrations SET dirty = 0 WHERE version = ?", version); err != nil {
+			return fmt.Errorf("更新迁移记录失败: %w", err)
+		}
+		for _, mig := range m.migrations {
+			if mig.Version > version {
+				break
+			}
+			if _, err := conn.ExecContext(ctx, "INSERT IGNORE INTO schema_migrations (version, name, dirty) VALUES (?, ?, 0)", mig.Version, mig.Name); err != nil {
+				return fmt.Errorf("更新迁移记录失败: %w", err)
+			}
+		}
+		migrateLog.WarnContext(ctx, "已强制设置迁移版本", "version", version)
+		return nil
+	})
+}
+
+// withLock 在持有迁移锁的单个连接上执行fn
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("获取数据库连接失败: %w", err)
+	}
+	defer conn.Close()
+
+	var locked sql.NullInt64
+	if err := conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, ?)", lockName, lockTimeout).Scan(&locked); err != nil {
+		return fmt.Errorf("获取迁移锁失败: %w", err)
+	}
+	if locked.Int64 != 1 {
+		return errors.New("获取迁移锁超时，可能有其他实例正在执行迁移")
+	}
+	defer conn.ExecContext(context.Background(), "SELECT RELEASE_LOCK(?)", lockName)
+
+	if err := ensureTable(ctx, conn); err != nil {
+		return err
+	}
+	return fn(conn)
+}
+
+// ensureTable 创建schema_migrations表
+func ensureTable(ctx context.Context, conn *sql.Conn) error {
+	_, err := conn.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version BIGINT NOT NULL PRIMARY KEY,
+		name VARCHAR(255) NOT NULL,
+		dirty TINYINT(1) NOT NULL DEFAULT 0,
+		applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+	) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4`)
+	if err != nil {
+		return fmt.Errorf("创建schema_migrations表失败: %w", err)
+	}
+	return nil
+}
+
+// applied 查询已应用的迁移，schema_migrations表不存在时视为没有应用任何迁移
+func (m *Migrator) applied(ctx context.Context, conn *sql.Conn) (map[int64]appliedMigration, error) {
+	var exists int
+	err := conn.QueryRowContext(ctx, "SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = DATABASE() AND table_name = 'schema_migrations'").Scan(&exists)
+	if err != nil {
+		return nil, fmt.Errorf("查询迁移记录失败: %w", err)
+	}
+	applied := make(map[int64]appliedMigration)
+	if exists == 0 {
+		return applied, nil
+	}
+
+	rows, err := conn.QueryContext(ctx, "SELECT version, name, dirty, applied_at FROM schema_migrations ORDER BY version")
+	if err != nil {
+		return nil, fmt.Errorf("查询迁移记录失败: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var a appliedMigration
+		if err := rows.Scan(&a.version, &a.name, &a.dirty, &a.appliedAt); err != nil {
+			return nil, fmt.Errorf("读取迁移记录失败: %w", err)
+		}
+		applied[a.version] = a
+	}
+	return applied, rows.Err()
+}
+
+// checkDirty 存在失败的迁移时拒绝继续执行
+func checkDirty(applied map[int64]appliedMigration) error {
+	for _, a := range applied {
+		if a.dirty {
+			return fmt.Errorf("%w: 迁移%04d_%s执行失败，请人工修复后执行 migrate force %d", ErrDirty, a.version, a.name, a.version)
+		}
+	}
+	return nil
+}
+
+// apply 执行一个迁移的up脚本
+// MySQL的DDL无法在事务中回滚，因此先写入dirty记录，全部语句成功后再清除
+func (m *Migrator) apply(ctx context.Context, conn *sql.Conn, mig Migration) error {
+	migrateLog.InfoContext(ctx, "执行迁移", "version", mig.Version, "name", mig.Name)
+
+	if _, err := conn.ExecContext(ctx, "INSERT INTO schema_migrations (version, name, dirty) VALUES (?, ?, 1)", mig.Version, mig.Name); err != nil {
+		return fmt.Errorf("写入迁移记录失败: %w", err)
+	}
+	if err := execScript(ctx, conn, mig.Up); err != nil {
+		return fmt.Errorf("迁移%04d_%s执行失败: %w", mig.Version, mig.Name, err)
+	}
+	if _, err := conn.ExecContext(ctx, "UPDATE schema_migrations SET dirty = 0, applied_at = CURRENT_TIMESTAMP WHERE version = ?", mig.Version); err != nil {
+		return fmt.Errorf("更新迁移记录失败: %w", err)
+	}
+	return nil
+}
+
+// revert 执行一个迁移的down脚本
+func (m *Migrator) revert(ctx context.Context, conn *sql.Conn, mig Migration) error {
+	migrateLog.InfoContext(ctx, "回滚迁移", "version", mig.Version, "name", mig.Name)
+
+	if _, err := conn.ExecContext(ctx, "UPDATE schema_migrations SET dirty = 1 WHERE version = ?", mig.Version); err != nil {
+		return fmt.Errorf("更新迁移记录失败: %w", err)
+	}
+	if err := execScript(ctx, conn, mig.Down); err != nil {
+		return fmt.Errorf("回滚%04d_%s失败: %w", mig.Version, mig.Name, err)
+	}
+	if _, err := conn.ExecContext(ctx, "DELETE FROM schema_migrations WHERE version = ?", mig.Version); err != nil {
+		return fmt.Errorf("删除迁移记录失败: %w", err)
+	}
+	return nil
+}
+
+// execScript 逐条执行脚本中的语句，遇到错误立即停止
+func execScript(ctx context.Context, conn *sql.Conn, script string) error {
+	for i, stmt := range SplitStatements(script) {
+		if _, err := conn.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("第%d条语句: %w", i+1, err)
+		}
+	}
+	return nil
+}
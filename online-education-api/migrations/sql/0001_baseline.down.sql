@@ -0,0 +1,10 @@
+DROP TABLE IF EXISTS `video_comments`;
+DROP TABLE IF EXISTS `videos`;
+DROP TABLE IF EXISTS `video_categories`;
+DROP TABLE IF EXISTS `posts`;
+DROP TABLE IF EXISTS `user_courses`;
+DROP TABLE IF EXISTS `lessons`;
+DROP TABLE IF EXISTS `chapters`;
+DROP TABLE IF EXISTS `courses`;
+DROP TABLE IF EXISTS `course_categories`;
+DROP TABLE IF EXISTS `users`;
@@ -0,0 +1 @@
+ALTER TABLE `lessons` DROP COLUMN `free`;
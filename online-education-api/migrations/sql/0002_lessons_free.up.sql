@@ -0,0 +1,2 @@
+-- 课时是否免费试看，课程详情接口依赖该字段
+ALTER TABLE `lessons` ADD COLUMN `free` tinyint NOT NULL DEFAULT '0' COMMENT '1: 免费, 0: 收费' AFTER `sort_order`;
@@ -0,0 +1,6 @@
+ALTER TABLE `user_courses`
+  DROP COLUMN `updated_at`,
+  RENAME COLUMN `created_at` TO `enrolled_at`,
+  DROP COLUMN `status`,
+  DROP COLUMN `price`,
+  DROP COLUMN `order_id`;
@@ -0,0 +1,7 @@
+-- 报名记录增加订单、价格和状态，取消报名改为将status置0
+ALTER TABLE `user_courses`
+  ADD COLUMN `order_id` varchar(64) DEFAULT NULL AFTER `course_id`,
+  ADD COLUMN `price` decimal(10,2) NOT NULL DEFAULT '0.00' AFTER `order_id`,
+  ADD COLUMN `status` tinyint NOT NULL DEFAULT '1' COMMENT '1: 已报名, 0: 已取消' AFTER `price`,
+  RENAME COLUMN `enrolled_at` TO `created_at`,
+  ADD COLUMN `updated_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP;
@@ -0,0 +1,15 @@
+DROP INDEX `idx_posts_status_created_at` ON `posts`;
+
+ALTER TABLE `posts` RENAME COLUMN `status` TO `status_code`;
+
+ALTER TABLE `posts` ADD COLUMN `status` enum('draft','published','archived') DEFAULT 'published' AFTER `like_count`;
+
+UPDATE `posts` SET `status` = CASE `status_code`
+  WHEN 1 THEN 'published'
+  WHEN 2 THEN 'archived'
+  ELSE 'draft'
+END;
+
+ALTER TABLE `posts` DROP COLUMN `status_code`;
+
+ALTER TABLE `posts` RENAME COLUMN `view_count` TO `views`;
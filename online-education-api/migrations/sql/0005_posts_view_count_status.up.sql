@@ -0,0 +1,17 @@
+-- 与models.Post保持一致：views改名为view_count，status由字符串改为整数
+-- 0: 草稿, 1: 已发布, 2: 已归档
+ALTER TABLE `posts` RENAME COLUMN `views` TO `view_count`;
+
+ALTER TABLE `posts` ADD COLUMN `status_code` tinyint NOT NULL DEFAULT '1' AFTER `like_count`;
+
+UPDATE `posts` SET `status_code` = CASE `status`
+  WHEN 'published' THEN 1
+  WHEN 'archived' THEN 2
+  ELSE 0
+END;
+
+ALTER TABLE `posts` DROP COLUMN `status`;
+
+ALTER TABLE `posts` RENAME COLUMN `status_code` TO `status`;
+
+CREATE INDEX `idx_posts_status_created_at` ON `posts` (`status`, `created_at`);
@@ -0,0 +1,4 @@
+ALTER TABLE `users`
+  MODIFY COLUMN `role` enum('student','teacher','admin') DEFAULT 'student',
+  DROP COLUMN `last_login`,
+  DROP COLUMN `status`;
@@ -0,0 +1,5 @@
+-- 用户禁用和最后登录时间，供后台管理使用
+ALTER TABLE `users`
+  ADD COLUMN `status` tinyint NOT NULL DEFAULT '1' COMMENT '1: 正常, 0: 禁用' AFTER `bio`,
+  ADD COLUMN `last_login` timestamp NULL DEFAULT NULL AFTER `status`,
+  MODIFY COLUMN `role` enum('student','teacher','admin') NOT NULL DEFAULT 'student';
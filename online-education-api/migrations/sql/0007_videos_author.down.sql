@@ -0,0 +1,18 @@
+DROP TABLE IF EXISTS `video_favorites`;
+
+DROP TABLE IF EXISTS `video_likes`;
+
+ALTER TABLE `video_categories`
+  DROP COLUMN `updated_at`,
+  DROP COLUMN `description`;
+
+ALTER TABLE `videos` DROP FOREIGN KEY `fk_videos_author`;
+
+ALTER TABLE `videos`
+  DROP KEY `idx_videos_created_at`,
+  DROP KEY `idx_videos_category_id`,
+  RENAME INDEX `idx_videos_author_id` TO `user_id`,
+  RENAME COLUMN `author_id` TO `user_id`;
+
+ALTER TABLE `videos`
+  ADD CONSTRAINT `videos_ibfk_1` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON DELETE SET NULL;
@@ -0,0 +1,40 @@
+-- 视频作者字段统一为author_id，与视频服务和权限检查保持一致
+-- category_id为0表示未分类，因此不加外键约束
+ALTER TABLE `videos` DROP FOREIGN KEY `videos_ibfk_1`;
+
+ALTER TABLE `videos`
+  RENAME COLUMN `user_id` TO `author_id`,
+  RENAME INDEX `user_id` TO `idx_videos_author_id`,
+  ADD KEY `idx_videos_category_id` (`category_id`),
+  ADD KEY `idx_videos_created_at` (`created_at`);
+
+ALTER TABLE `videos`
+  ADD CONSTRAINT `fk_videos_author` FOREIGN KEY (`author_id`) REFERENCES `users` (`id`) ON DELETE SET NULL;
+
+ALTER TABLE `video_categories`
+  ADD COLUMN `description` text AFTER `name`,
+  ADD COLUMN `updated_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP;
+
+CREATE TABLE `video_likes` (
+  `id` int NOT NULL AUTO_INCREMENT,
+  `video_id` int NOT NULL,
+  `user_id` int NOT NULL,
+  `created_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_video_user` (`video_id`,`user_id`),
+  KEY `idx_user_id` (`user_id`),
+  CONSTRAINT `video_likes_ibfk_1` FOREIGN KEY (`video_id`) REFERENCES `videos` (`id`) ON DELETE CASCADE,
+  CONSTRAINT `video_likes_ibfk_2` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE `video_favorites` (
+  `id` int NOT NULL AUTO_INCREMENT,
+  `video_id` int NOT NULL,
+  `user_id` int NOT NULL,
+  `created_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_video_user` (`video_id`,`user_id`),
+  KEY `idx_user_id` (`user_id`),
+  CONSTRAINT `video_favorites_ibfk_1` FOREIGN KEY (`video_id`) REFERENCES `videos` (`id`) ON DELETE CASCADE,
+  CONSTRAINT `video_favorites_ibfk_2` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
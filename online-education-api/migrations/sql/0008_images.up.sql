@@ -0,0 +1,19 @@
+-- 图片元数据，entity_type/entity_id关联用户、课程、帖子等
+CREATE TABLE `images` (
+  `id` int NOT NULL AUTO_INCREMENT,
+  `file_name` varchar(255) NOT NULL,
+  `file_path` varchar(512) NOT NULL,
+  `file_size` int NOT NULL COMMENT '文件大小(字节)',
+  `mime_type` varchar(100) NOT NULL,
+  `width` int DEFAULT NULL,
+  `height` int DEFAULT NULL,
+  `alt_text` varchar(255) DEFAULT NULL,
+  `entity_type` varchar(50) DEFAULT NULL,
+  `entity_id` int DEFAULT NULL,
+  `is_primary` tinyint NOT NULL DEFAULT '0',
+  `created_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  KEY `idx_images_entity` (`entity_type`,`entity_id`),
+  KEY `idx_images_file_path` (`file_path`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,5 @@
+ALTER TABLE `courses`
+  DROP KEY `idx_courses_status_price`,
+  DROP KEY `idx_courses_status_rating`,
+  DROP KEY `idx_courses_status_student_count`,
+  DROP KEY `idx_courses_status_created_at`;
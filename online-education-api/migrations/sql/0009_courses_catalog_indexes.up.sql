@@ -0,0 +1,6 @@
+-- 课程列表只返回已上架课程并支持按热度、评分和价格排序
+ALTER TABLE `courses`
+  ADD KEY `idx_courses_status_created_at` (`status`,`created_at`),
+  ADD KEY `idx_courses_status_student_count` (`status`,`student_count`),
+  ADD KEY `idx_courses_status_rating` (`status`,`rating`),
+  ADD KEY `idx_courses_status_price` (`status`,`price`);
@@ -0,0 +1,5 @@
+ALTER TABLE `courses`
+  DROP COLUMN `rating_total`,
+  DROP COLUMN `review_count`;
+DROP TABLE IF EXISTS `course_review_reports`;
+DROP TABLE IF EXISTS `course_reviews`;
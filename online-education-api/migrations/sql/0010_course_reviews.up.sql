@@ -0,0 +1,40 @@
+-- 课程评价，每个用户对每门课程最多一条；report_count为未处理的举报数，管理员处理后清零
+CREATE TABLE `course_reviews` (
+  `id` int NOT NULL AUTO_INCREMENT,
+  `course_id` int NOT NULL,
+  `user_id` int NOT NULL,
+  `rating` tinyint NOT NULL COMMENT '1-5星',
+  `content` text,
+  `reply` text COMMENT '教师回复',
+  `replied_at` timestamp NULL DEFAULT NULL,
+  `status` tinyint NOT NULL DEFAULT '1' COMMENT '1: 显示, 0: 已被管理员隐藏',
+  `report_count` int NOT NULL DEFAULT '0',
+  `created_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_course_reviews_course_user` (`course_id`,`user_id`),
+  KEY `idx_course_reviews_course_status_created_at` (`course_id`,`status`,`created_at`),
+  KEY `idx_course_reviews_status_report_count` (`status`,`report_count`),
+  KEY `idx_course_reviews_user_id` (`user_id`),
+  CONSTRAINT `course_reviews_ibfk_1` FOREIGN KEY (`course_id`) REFERENCES `courses` (`id`) ON DELETE CASCADE,
+  CONSTRAINT `course_reviews_ibfk_2` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- 评价举报，每个用户对每条评价最多举报一次
+CREATE TABLE `course_review_reports` (
+  `id` int NOT NULL AUTO_INCREMENT,
+  `review_id` int NOT NULL,
+  `user_id` int NOT NULL,
+  `reason` varchar(255) NOT NULL,
+  `created_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_course_review_reports_review_user` (`review_id`,`user_id`),
+  KEY `idx_course_review_reports_user_id` (`user_id`),
+  CONSTRAINT `course_review_reports_ibfk_1` FOREIGN KEY (`review_id`) REFERENCES `course_reviews` (`id`) ON DELETE CASCADE,
+  CONSTRAINT `course_review_reports_ibfk_2` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- 课程评分由显示中的评价计算：rating = rating_total / review_count，随评价的增删改增量更新
+ALTER TABLE `courses`
+  ADD COLUMN `review_count` int NOT NULL DEFAULT '0' AFTER `rating`,
+  ADD COLUMN `rating_total` int NOT NULL DEFAULT '0' AFTER `review_count`;
@@ -0,0 +1,5 @@
+ALTER TABLE `videos`
+  DROP FOREIGN KEY `videos_upload_fk`,
+  DROP INDEX `uk_videos_upload_id`,
+  DROP COLUMN `upload_id`;
+DROP TABLE IF EXISTS `uploads`;
@@ -0,0 +1,24 @@
+-- 视频上传，分片上传时received为已接收的字节数，完成后记录文件的SHA256和在对象存储中的位置
+CREATE TABLE `uploads` (
+  `id` char(32) NOT NULL,
+  `user_id` int NOT NULL,
+  `file_name` varchar(255) NOT NULL,
+  `size` bigint NOT NULL,
+  `received` bigint NOT NULL DEFAULT '0',
+  `mime_type` varchar(100) DEFAULT NULL,
+  `checksum` char(64) DEFAULT NULL COMMENT 'SHA256',
+  `storage_key` varchar(512) DEFAULT NULL,
+  `status` varchar(20) NOT NULL DEFAULT 'uploading' COMMENT 'uploading, completed',
+  `created_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  KEY `idx_uploads_user_id` (`user_id`),
+  KEY `idx_uploads_status_updated_at` (`status`,`updated_at`),
+  CONSTRAINT `uploads_ibfk_1` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- 由上传的文件创建的视频记录来源，一个上传只能创建一个视频
+ALTER TABLE `videos`
+  ADD COLUMN `upload_id` char(32) DEFAULT NULL AFTER `video_url`,
+  ADD UNIQUE KEY `uk_videos_upload_id` (`upload_id`),
+  ADD CONSTRAINT `videos_upload_fk` FOREIGN KEY (`upload_id`) REFERENCES `uploads` (`id`) ON DELETE SET NULL;
@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS `video_renditions`;
+ALTER TABLE `videos`
+  DROP INDEX `idx_videos_status`,
+  DROP COLUMN `processing_error`,
+  DROP COLUMN `thumbnails_url`,
+  DROP COLUMN `hls_url`,
+  DROP COLUMN `status`;
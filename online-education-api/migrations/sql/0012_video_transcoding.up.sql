@@ -0,0 +1,21 @@
+-- 视频转码状态，已有的视频直接使用video_url播放，视为ready
+ALTER TABLE `videos`
+  ADD COLUMN `status` varchar(20) NOT NULL DEFAULT 'ready' COMMENT 'processing, ready, failed' AFTER `is_public`,
+  ADD COLUMN `hls_url` varchar(512) DEFAULT NULL AFTER `status`,
+  ADD COLUMN `thumbnails_url` varchar(512) DEFAULT NULL AFTER `hls_url`,
+  ADD COLUMN `processing_error` varchar(500) DEFAULT NULL AFTER `thumbnails_url`,
+  ADD KEY `idx_videos_status` (`status`);
+
+-- 转码生成的HLS清晰度，重新转码时整体替换
+CREATE TABLE `video_renditions` (
+  `id` int NOT NULL AUTO_INCREMENT,
+  `video_id` int NOT NULL,
+  `name` varchar(20) NOT NULL,
+  `width` int NOT NULL,
+  `height` int NOT NULL,
+  `bandwidth` int NOT NULL COMMENT 'bit/s',
+  `playlist_url` varchar(512) NOT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_video_renditions_video_name` (`video_id`,`name`),
+  CONSTRAINT `video_renditions_ibfk_1` FOREIGN KEY (`video_id`) REFERENCES `videos` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,4 @@
+ALTER TABLE `images`
+  DROP FOREIGN KEY `images_user_fk`,
+  DROP INDEX `idx_images_created_at`,
+  DROP COLUMN `user_id`;
@@ -0,0 +1,5 @@
+-- 上传图片的用户，只有上传者和管理员可以修改、关联和删除图片；用户删除后图片保留
+ALTER TABLE `images`
+  ADD COLUMN `user_id` int DEFAULT NULL AFTER `id`,
+  ADD KEY `idx_images_created_at` (`created_at`),
+  ADD CONSTRAINT `images_user_fk` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON DELETE SET NULL;
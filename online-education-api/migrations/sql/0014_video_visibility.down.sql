@@ -0,0 +1,9 @@
+ALTER TABLE `videos`
+  ADD COLUMN `is_public` tinyint(1) DEFAULT '1' AFTER `favorite_count`;
+
+-- unlisted的视频不出现在列表中，回退后视为不公开
+UPDATE `videos` SET `is_public` = 0 WHERE `visibility` <> 'public';
+
+ALTER TABLE `videos`
+  DROP INDEX `idx_videos_visibility_created_at`,
+  DROP COLUMN `visibility`;
@@ -0,0 +1,9 @@
+-- 视频可见性取代is_public：public出现在列表和搜索中，unlisted只能通过地址观看，private只有作者和管理员可见
+ALTER TABLE `videos`
+  ADD COLUMN `visibility` varchar(20) NOT NULL DEFAULT 'public' COMMENT 'public, unlisted, private' AFTER `is_public`;
+
+UPDATE `videos` SET `visibility` = 'private' WHERE `is_public` = 0;
+
+ALTER TABLE `videos`
+  DROP COLUMN `is_public`,
+  ADD KEY `idx_videos_visibility_created_at` (`visibility`, `created_at`);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS `video_retention`;
+DROP TABLE IF EXISTS `video_stats`;
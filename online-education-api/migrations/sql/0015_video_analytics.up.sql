@@ -0,0 +1,19 @@
+-- 视频播放统计，由播放事件去重后批量累加；views只统计启用播放事件之后的观看，videos.view_count同时累加
+CREATE TABLE `video_stats` (
+  `video_id` int NOT NULL,
+  `views` int NOT NULL DEFAULT '0',
+  `watch_seconds` bigint NOT NULL DEFAULT '0',
+  `completions` int NOT NULL DEFAULT '0' COMMENT '看到最后5%的观看次数',
+  `updated_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (`video_id`),
+  CONSTRAINT `video_stats_ibfk_1` FOREIGN KEY (`video_id`) REFERENCES `videos` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- 观众留存，视频时长按20等分，每段记录看到该段的观看次数
+CREATE TABLE `video_retention` (
+  `video_id` int NOT NULL,
+  `bucket` tinyint NOT NULL,
+  `viewers` int NOT NULL DEFAULT '0',
+  PRIMARY KEY (`video_id`,`bucket`),
+  CONSTRAINT `video_retention_ibfk_1` FOREIGN KEY (`video_id`) REFERENCES `videos` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
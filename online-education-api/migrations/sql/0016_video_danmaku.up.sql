@@ -0,0 +1,16 @@
+-- 弹幕，按视频和播放位置查询；视频或用户删除时一并删除
+CREATE TABLE `video_danmaku` (
+  `id` bigint NOT NULL AUTO_INCREMENT,
+  `video_id` int NOT NULL,
+  `user_id` int NOT NULL,
+  `content` varchar(100) NOT NULL,
+  `offset_ms` int unsigned NOT NULL COMMENT '弹幕出现的播放位置(毫秒)',
+  `color` char(7) NOT NULL DEFAULT '#FFFFFF',
+  `mode` varchar(10) NOT NULL DEFAULT 'scroll',
+  `created_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  KEY `idx_video_danmaku_video_offset` (`video_id`,`offset_ms`),
+  KEY `video_danmaku_user_fk` (`user_id`),
+  CONSTRAINT `video_danmaku_video_fk` FOREIGN KEY (`video_id`) REFERENCES `videos` (`id`) ON DELETE CASCADE,
+  CONSTRAINT `video_danmaku_user_fk` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS `subtitle_cues`;
+DROP TABLE IF EXISTS `subtitles`;
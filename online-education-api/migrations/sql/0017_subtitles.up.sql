@@ -0,0 +1,29 @@
+-- 字幕轨道，属于视频或课时(二者只能设置一个)，每个视频或课时每种语言只有一条轨道；视频或课时删除时一并删除
+CREATE TABLE `subtitles` (
+  `id` bigint NOT NULL AUTO_INCREMENT,
+  `video_id` int DEFAULT NULL,
+  `lesson_id` int DEFAULT NULL,
+  `language` varchar(35) NOT NULL COMMENT 'BCP 47语言标签，如zh-CN',
+  `label` varchar(50) NOT NULL DEFAULT '',
+  `is_default` tinyint(1) NOT NULL DEFAULT '0',
+  `cue_count` int NOT NULL DEFAULT '0',
+  `created_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_subtitles_video_language` (`video_id`,`language`),
+  UNIQUE KEY `uk_subtitles_lesson_language` (`lesson_id`,`language`),
+  CONSTRAINT `subtitles_video_fk` FOREIGN KEY (`video_id`) REFERENCES `videos` (`id`) ON DELETE CASCADE,
+  CONSTRAINT `subtitles_lesson_fk` FOREIGN KEY (`lesson_id`) REFERENCES `lessons` (`id`) ON DELETE CASCADE,
+  CONSTRAINT `subtitles_owner_chk` CHECK ((`video_id` IS NULL) <> (`lesson_id` IS NULL))
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- 字幕条，文本为去除样式后的纯文本，用于搜索和按格式输出
+CREATE TABLE `subtitle_cues` (
+  `subtitle_id` bigint NOT NULL,
+  `seq` int NOT NULL,
+  `start_ms` int unsigned NOT NULL,
+  `end_ms` int unsigned NOT NULL,
+  `text` text NOT NULL,
+  PRIMARY KEY (`subtitle_id`,`seq`),
+  CONSTRAINT `subtitle_cues_ibfk_1` FOREIGN KEY (`subtitle_id`) REFERENCES `subtitles` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
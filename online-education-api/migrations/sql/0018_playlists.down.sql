@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS `video_progress`;
+DROP TABLE IF EXISTS `playlist_videos`;
+DROP TABLE IF EXISTS `playlists`;
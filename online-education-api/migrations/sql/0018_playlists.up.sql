@@ -0,0 +1,40 @@
+-- 用户创建的播放列表，也用作创作者的系列视频；用户删除时一并删除
+CREATE TABLE `playlists` (
+  `id` bigint NOT NULL AUTO_INCREMENT,
+  `user_id` int NOT NULL,
+  `title` varchar(100) NOT NULL,
+  `description` varchar(500) NOT NULL DEFAULT '',
+  `visibility` varchar(20) NOT NULL DEFAULT 'public' COMMENT 'public, private',
+  `created_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  KEY `idx_playlists_user_created_at` (`user_id`,`created_at`),
+  KEY `idx_playlists_visibility_created_at` (`visibility`,`created_at`),
+  CONSTRAINT `playlists_user_fk` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- 播放列表中的视频，position从1开始连续编号；视频删除时从列表中移除
+CREATE TABLE `playlist_videos` (
+  `playlist_id` bigint NOT NULL,
+  `video_id` int NOT NULL,
+  `position` int NOT NULL,
+  `added_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`playlist_id`,`video_id`),
+  KEY `idx_playlist_videos_position` (`playlist_id`,`position`),
+  KEY `playlist_videos_video_fk` (`video_id`),
+  CONSTRAINT `playlist_videos_playlist_fk` FOREIGN KEY (`playlist_id`) REFERENCES `playlists` (`id`) ON DELETE CASCADE,
+  CONSTRAINT `playlist_videos_video_fk` FOREIGN KEY (`video_id`) REFERENCES `videos` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- 登录用户在每个视频的观看进度，由播放事件批量写入；看完标记一旦设置不再清除
+CREATE TABLE `video_progress` (
+  `user_id` int NOT NULL,
+  `video_id` int NOT NULL,
+  `position_ms` int unsigned NOT NULL DEFAULT '0' COMMENT '最近的播放位置(毫秒)',
+  `completed` tinyint(1) NOT NULL DEFAULT '0',
+  `updated_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (`user_id`,`video_id`),
+  KEY `video_progress_video_fk` (`video_id`),
+  CONSTRAINT `video_progress_user_fk` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON DELETE CASCADE,
+  CONSTRAINT `video_progress_video_fk` FOREIGN KEY (`video_id`) REFERENCES `videos` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
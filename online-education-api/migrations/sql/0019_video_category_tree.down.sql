@@ -0,0 +1,6 @@
+ALTER TABLE `video_categories` DROP FOREIGN KEY `video_categories_parent_fk`;
+
+ALTER TABLE `video_categories`
+  DROP KEY `idx_video_categories_parent_id`,
+  DROP COLUMN `sort_order`,
+  DROP COLUMN `parent_id`;
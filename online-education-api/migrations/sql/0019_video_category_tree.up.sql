@@ -0,0 +1,7 @@
+-- 视频分类支持层级和排序，与课程分类一致
+-- 有子分类的分类不能删除，因此上级分类的外键不级联删除
+ALTER TABLE `video_categories`
+  ADD COLUMN `parent_id` int DEFAULT NULL AFTER `description`,
+  ADD COLUMN `sort_order` int NOT NULL DEFAULT '0' AFTER `parent_id`,
+  ADD KEY `idx_video_categories_parent_id` (`parent_id`),
+  ADD CONSTRAINT `video_categories_parent_fk` FOREIGN KEY (`parent_id`) REFERENCES `video_categories` (`id`);
@@ -33,6 +33,6 @@ type CreateChapterRequest struct {
 
 // UpdateChapterRequest 更新章节请求
 type UpdateChapterRequest struct {
-	Title     string `json:"title" min=2,max=100`
+	Title     string `json:"title" binding:"omitempty,min=2,max=100"`
 	SortOrder int    `json:"sort_order"`
 }
\ No newline at end of file
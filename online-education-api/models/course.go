@@ -18,6 +18,7 @@ type Course struct {
 	Duration      int       `json:"duration"` // 课程时长(分钟)
 	StudentCount  int       `json:"student_count"`
 	Rating        float64   `json:"rating"`
+	ReviewCount   int       `json:"review_count"`
 	Status        int       `json:"status"` // 1: 上架, 0: 下架
 	CreatedAt     time.Time `json:"created_at"`
 	UpdatedAt     time.Time `json:"updated_at"`
@@ -39,10 +40,51 @@ type CourseResponse struct {
 	Duration      int        `json:"duration"`
 	StudentCount  int        `json:"student_count"`
 	Rating        float64    `json:"rating"`
+	ReviewCount   int        `json:"review_count"`
 	Status        int        `json:"status"`
 	CreatedAt     time.Time  `json:"created_at"`
 }
 
+// FacetCount 分面中的一个取值及其数量
+type FacetCount struct {
+	Value string `json:"value"`
+	Label string `json:"label,omitempty"`
+	Count int    `json:"count"`
+}
+
+// CourseFacets 课程列表的分面统计，按数量倒序
+type CourseFacets struct {
+	Categories []FacetCount `json:"categories"`
+	Levels     []FacetCount `json:"levels"`
+	Prices     []FacetCount `json:"prices"`
+}
+
+// CourseListQuery 课程列表查询条件，零值表示不限
+type CourseListQuery struct {
+	Page                 int      `json:"page"`
+	PageSize             int      `json:"page_size"`
+	CategoryID           int64    `json:"category_id"`
+	IncludeSubcategories bool     `json:"include_subcategories"` // 同时返回子分类(任意层级)的课程
+	Level                int64    `json:"level"`
+	Search               string   `json:"search"`
+	MinPrice             *float64 `json:"min_price"`
+	MaxPrice             *float64 `json:"max_price"`
+	FreeOnly             bool     `json:"free_only"`
+	TeacherID            int64    `json:"teacher_id"`
+	MinRating            float64  `json:"min_rating"`
+	Sort                 string   `json:"sort"` // newest(默认)、popular、rating、price_asc、price_desc
+	// 以下由控制器根据当前用户设置：管理员可以看到全部未上架课程，教师可以看到自己未上架的课程
+	IncludeDrafts bool  `json:"include_drafts"`
+	DraftsOf      int64 `json:"drafts_of"`
+}
+
+// CourseListResult 课程列表查询结果
+type CourseListResult struct {
+	List   []*CourseResponse `json:"list"`
+	Total  int               `json:"total"`
+	Facets *CourseFacets     `json:"facets"`
+}
+
 // CourseDetailResponse 课程详情响应模型
 type CourseDetailResponse struct {
 	Course
@@ -66,12 +108,12 @@ type CreateCourseRequest struct {
 
 // UpdateCourseRequest 更新课程请求
 type UpdateCourseRequest struct {
-	Title         string  `json:"title" min=2,max=100`
+	Title         string  `json:"title" binding:"omitempty,min=2,max=100"`
 	Description   string  `json:"description"`
 	CoverImage    string  `json:"cover_image"`
 	Price         float64 `json:"price"`
 	OriginalPrice float64 `json:"original_price"`
 	CategoryID    int64   `json:"category_id"`
-	Level         int     `json:"level" oneof=1 2 3`
-	Status        int     `json:"status" oneof=0 1`
+	Level         int     `json:"level" binding:"omitempty,oneof=1 2 3"`
+	Status        *int    `json:"status" binding:"omitempty,oneof=0 1"` // 为空时保持原状态
 }
\ No newline at end of file
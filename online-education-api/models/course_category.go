@@ -23,4 +23,10 @@ type CourseCategoryResponse struct {
 	SortOrder int                    `json:"sort_order"`
 	CreatedAt time.Time              `json:"created_at"`
 	Children  []*CourseCategoryResponse `json:"children,omitempty"`
-}
\ No newline at end of file
+}
+// CourseCategoryRequest 创建/更新课程分类请求
+type CourseCategoryRequest struct {
+	Name      string `json:"name" binding:"required,max=50"`
+	ParentID  *int64 `json:"parent_id"`
+	SortOrder int    `json:"sort_order" binding:"min=0"`
+}
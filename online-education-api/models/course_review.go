@@ -0,0 +1,58 @@
+package models
+
+import (
+	"time"
+)
+
+// CourseReview 课程评价模型
+type CourseReview struct {
+	ID          int64      `json:"id"`
+	CourseID    int64      `json:"course_id"`
+	UserID      int64      `json:"user_id"`
+	Rating      int        `json:"rating"` // 1-5星
+	Content     string     `json:"content"`
+	Reply       string     `json:"reply,omitempty"` // 课程教师的回复
+	RepliedAt   *time.Time `json:"replied_at,omitempty"`
+	Status      int        `json:"status"`       // 1: 显示, 0: 已被管理员隐藏
+	ReportCount int        `json:"report_count"` // 未处理的举报数
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// CourseReviewResponse 带评价人信息的课程评价
+type CourseReviewResponse struct {
+	CourseReview
+	UserName string `json:"user_name"`
+	Avatar   string `json:"avatar,omitempty"`
+}
+
+// ReviewReport 评价举报
+type ReviewReport struct {
+	ID        int64     `json:"id"`
+	ReviewID  int64     `json:"review_id"`
+	UserID    int64     `json:"user_id"`
+	Reason    string    `json:"reason"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// CreateReviewRequest 创建或修改评价请求
+type CreateReviewRequest struct {
+	Rating  int    `json:"rating" binding:"required,min=1,max=5"`
+	Content string `json:"content" binding:"max=2000"`
+}
+
+// ReplyReviewRequest 教师回复评价请求
+type ReplyReviewRequest struct {
+	Reply string `json:"reply" binding:"required,min=1,max=1000"`
+}
+
+// ReportReviewRequest 举报评价请求
+type ReportReviewRequest struct {
+	Reason string `json:"reason" binding:"required,min=1,max=255"`
+}
+
+// ModerateReviewRequest 管理员处理举报请求
+type ModerateReviewRequest struct {
+	// hide: 隐藏评价, restore: 恢复显示已隐藏的评价, dismiss: 驳回举报并保留评价
+	Action string `json:"action" binding:"required,oneof=hide restore dismiss"`
+}
@@ -0,0 +1,46 @@
+package models
+
+import "time"
+
+// 弹幕的显示方式
+const (
+	DanmakuModeScroll = "scroll" // 从右向左滚动
+	DanmakuModeTop    = "top"    // 固定在顶部
+	DanmakuModeBottom = "bottom" // 固定在底部
+)
+
+// DanmakuDefaultColor 未指定颜色时弹幕的颜色
+const DanmakuDefaultColor = "#FFFFFF"
+
+// 推送给观看者的弹幕消息类型
+const (
+	DanmakuMessageCreate = "danmaku" // 新弹幕，Danmaku为弹幕内容
+	DanmakuMessageDelete = "delete"  // 弹幕被删除，ID为弹幕ID
+)
+
+// Danmaku 视频弹幕，在播放到Offset时显示
+type Danmaku struct {
+	ID        int64     `json:"id"`
+	VideoID   int       `json:"video_id"`
+	UserID    int64     `json:"user_id"`
+	Content   string    `json:"content"`
+	Offset    float64   `json:"offset"` // 播放位置(秒)，保存时精确到毫秒
+	Color     string    `json:"color"`  // #RRGGBB
+	Mode      string    `json:"mode"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// CreateDanmakuRequest 发送弹幕请求
+type CreateDanmakuRequest struct {
+	Content string  `json:"content" binding:"required,max=100"`
+	Offset  float64 `json:"offset" binding:"min=0"`
+	Color   string  `json:"color" binding:"omitempty,len=7"`
+	Mode    string  `json:"mode" binding:"omitempty,oneof=scroll top bottom"`
+}
+
+// DanmakuMessage 通过WebSocket推送给同一视频观看者的消息
+type DanmakuMessage struct {
+	Type    string   `json:"type"`
+	Danmaku *Danmaku `json:"danmaku,omitempty"`
+	ID      int64    `json:"id,omitempty"`
+}
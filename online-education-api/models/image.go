@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+// 图片可以关联的实体类型，作为主图时写入实体的图片字段
+const (
+	ImageEntityUser   = "user"   // 用户头像
+	ImageEntityCourse = "course" // 课程封面
+	ImageEntityVideo  = "video"  // 视频封面
+)
+
+// Image 上传的图片
+type Image struct {
+	ID         int64             `json:"id"`
+	UserID     int64             `json:"user_id"`
+	FileName   string            `json:"file_name"`
+	FilePath   string            `json:"-"` // 原图在对象存储中的键
+	FileSize   int64             `json:"file_size"`
+	MimeType   string            `json:"mime_type"`
+	Width      int               `json:"width"`
+	Height     int               `json:"height"`
+	AltText    string            `json:"alt_text"`
+	EntityType string            `json:"entity_type,omitempty"`
+	EntityID   int64             `json:"entity_id,omitempty"`
+	IsPrimary  bool              `json:"is_primary"`
+	URL        string            `json:"url"`        // 原图的访问地址，不保存在数据库中
+	Thumbnails map[string]string `json:"thumbnails"` // 各标准尺寸缩略图的访问地址，不保存在数据库中
+	CreatedAt  time.Time         `json:"created_at"`
+	UpdatedAt  time.Time         `json:"updated_at"`
+}
+
+// UpdateImageRequest 更新图片请求
+type UpdateImageRequest struct {
+	AltText string `json:"alt_text" binding:"max=255"`
+}
+
+// AttachImageRequest 关联图片请求
+type AttachImageRequest struct {
+	EntityType string `json:"entity_type" binding:"required,oneof=user course video"`
+	EntityID   int64  `json:"entity_id" binding:"required,min=1"`
+	IsPrimary  bool   `json:"is_primary"` // 设为主图时替换实体当前的头像或封面
+}
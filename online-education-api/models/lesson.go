@@ -40,9 +40,9 @@ type CreateLessonRequest struct {
 
 // UpdateLessonRequest 更新课时请求
 type UpdateLessonRequest struct {
-	Title     string `json:"title" min=2,max=100`
+	Title     string `json:"title" binding:"omitempty,min=2,max=100"`
 	VideoURL  string `json:"video_url"`
 	Duration  int    `json:"duration"`
 	SortOrder int    `json:"sort_order"`
-	Free      int    `json:"free" oneof=0 1`
+	Free      int    `json:"free" binding:"oneof=0 1"`
 }
\ No newline at end of file
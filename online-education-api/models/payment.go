@@ -20,8 +20,8 @@ type Payment struct {
 
 // CreatePaymentRequest 创建支付请求
 type CreatePaymentRequest struct {
-	CourseID      int64  `json:"course_id"`
-	PaymentMethod string `json:"payment_method"` // wechat, alipay
+	CourseID      int64  `json:"course_id" binding:"required"`
+	PaymentMethod string `json:"payment_method" binding:"required,oneof=wechat alipay"` // wechat, alipay
 }
 
 // PaymentResponse 支付响应
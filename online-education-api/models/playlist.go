@@ -0,0 +1,96 @@
+package models
+
+import "time"
+
+// 播放列表的可见性
+const (
+	PlaylistVisibilityPublic  = "public"  // 出现在列表中，所有用户可以查看
+	PlaylistVisibilityPrivate = "private" // 只有创建者和管理员可见
+)
+
+// Playlist 播放列表，创作者把自己的视频按顺序放入公开的播放列表即为系列视频
+type Playlist struct {
+	ID          int64     `json:"id"`
+	UserID      int64     `json:"user_id"`
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	Visibility  string    `json:"visibility"`
+	VideoCount  int       `json:"video_count"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+
+	// 以下字段只在播放列表详情中返回，只包含当前用户可以观看的视频
+	TotalDuration int               `json:"total_duration,omitempty"` // 视频时长之和(秒)
+	Items         []*PlaylistItem   `json:"items,omitempty"`
+	Progress      *PlaylistProgress `json:"progress,omitempty"` // 登录用户的观看进度
+	// 创建者看不到的视频(其他用户设为私有的视频)的ID，只返回给创建者，可以用来移除这些视频
+	HiddenVideoIDs []int `json:"hidden_video_ids,omitempty"`
+}
+
+// PlaylistItem 播放列表中的一个视频
+type PlaylistItem struct {
+	Position int            `json:"position"` // 从1开始
+	AddedAt  time.Time      `json:"added_at"`
+	Video    Video          `json:"video"` // 只包含列表需要的字段
+	Progress *VideoProgress `json:"progress,omitempty"`
+}
+
+// PlaylistProgress 用户观看播放列表的进度
+type PlaylistProgress struct {
+	Completed      int     `json:"completed"`               // 看完的视频数
+	WatchedSeconds int     `json:"watched_seconds"`         // 看完的视频按时长计，其他按播放位置计
+	Percent        float64 `json:"percent"`                 // WatchedSeconds占总时长的比例，0到1
+	NextVideoID    int     `json:"next_video_id,omitempty"` // 第一个没有看完的视频，全部看完时为0
+}
+
+// VideoProgress 用户观看一个视频的进度
+type VideoProgress struct {
+	UserID    int64     `json:"-"`
+	VideoID   int       `json:"video_id"`
+	Position  float64   `json:"position"`  // 最近的播放位置(秒)
+	Completed bool      `json:"completed"` // 曾经看到最后5%
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// VideoRef 视频的简要信息
+type VideoRef struct {
+	ID            int    `json:"id"`
+	Title         string `json:"title"`
+	CoverImageURL string `json:"cover_image_url,omitempty"`
+	Duration      int    `json:"duration"`
+}
+
+// VideoPlaylistContext 视频在播放列表中的位置，播放器据此自动播放下一个视频
+type VideoPlaylistContext struct {
+	ID         int64     `json:"id"`
+	Title      string    `json:"title"`
+	Position   int       `json:"position"` // 当前视频的位置，从1开始
+	VideoCount int       `json:"video_count"`
+	Previous   *VideoRef `json:"previous,omitempty"`
+	Next       *VideoRef `json:"next,omitempty"` // 最后一个视频时为空
+}
+
+// CreatePlaylistRequest 创建播放列表请求
+type CreatePlaylistRequest struct {
+	Title       string `json:"title" binding:"required,max=100"`
+	Description string `json:"description" binding:"max=500"`
+	Visibility  string `json:"visibility" binding:"omitempty,oneof=public private"` // 默认为public
+}
+
+// UpdatePlaylistRequest 更新播放列表请求，未提供的字段保持不变
+type UpdatePlaylistRequest struct {
+	Title       *string `json:"title" binding:"omitempty,max=100"`
+	Description *string `json:"description" binding:"omitempty,max=500"`
+	Visibility  *string `json:"visibility" binding:"omitempty,oneof=public private"`
+}
+
+// AddPlaylistVideoRequest 向播放列表添加视频的请求
+type AddPlaylistVideoRequest struct {
+	VideoID  int `json:"video_id" binding:"required,min=1"`
+	Position int `json:"position" binding:"min=0"` // 插入的位置，按详情中的position从1开始；为0或超过末尾时添加到末尾
+}
+
+// ReorderPlaylistRequest 调整播放列表顺序的请求，video_ids必须恰好包含列表中当前用户可以观看的全部视频
+type ReorderPlaylistRequest struct {
+	VideoIDs []int `json:"video_ids" binding:"required,max=500"`
+}
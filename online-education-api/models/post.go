@@ -14,7 +14,7 @@ type Post struct {
 	ViewCount   int       `json:"view_count"`
 	CommentCount int      `json:"comment_count"`
 	LikeCount   int       `json:"like_count"`
-	Status      int       `json:"status"` // 1: 正常, 0: 禁用
+	Status      int       `json:"status"` // 0: 草稿, 1: 已发布, 2: 已归档
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
 }
@@ -45,7 +45,7 @@ type CreatePostRequest struct {
 
 // UpdatePostRequest 更新帖子请求
 type UpdatePostRequest struct {
-	Title   string `json:"title" min=2,max=100`
-	Content string `json:"content" min=10`
-	Status  int    `json:"status" oneof=0 1`
+	Title   string `json:"title" binding:"omitempty,min=2,max=100"`
+	Content string `json:"content" binding:"omitempty,min=10"`
+	Status  int    `json:"status" binding:"oneof=0 1"`
 }
\ No newline at end of file
@@ -0,0 +1,44 @@
+package models
+
+import "time"
+
+// Subtitle 视频或课时的字幕轨道，VideoID和LessonID只设置一个
+type Subtitle struct {
+	ID        int64     `json:"id"`
+	VideoID   int       `json:"video_id,omitempty"`
+	LessonID  int64     `json:"lesson_id,omitempty"`
+	Language  string    `json:"language"` // BCP 47语言标签，如zh-CN
+	Label     string    `json:"label"`    // 播放器中显示的名称，如"中文"
+	IsDefault bool      `json:"is_default"`
+	CueCount  int       `json:"cue_count"`
+	URL       string    `json:"url"` // WebVTT文件的地址，加上format=srt获取SRT文件，不保存在数据库中
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// SubtitleCue 一条字幕，时间为秒
+type SubtitleCue struct {
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+	Text  string  `json:"text"`
+}
+
+// SubtitleMatch 字幕搜索结果，Start为提到搜索词的播放位置
+type SubtitleMatch struct {
+	SubtitleID int64  `json:"subtitle_id"`
+	Language   string `json:"language"`
+	SubtitleCue
+}
+
+// UpdateSubtitleRequest 更新字幕轨道请求，未提供的字段保持不变
+type UpdateSubtitleRequest struct {
+	Label     *string `json:"label" binding:"omitempty,max=50"`
+	IsDefault *bool   `json:"is_default"`
+}
+
+// UploadSubtitleRequest 上传字幕的表单字段，IsDefault为nil时第一条轨道设为默认，重新上传的轨道保持原来的默认标记
+type UploadSubtitleRequest struct {
+	Language  string
+	Label     string
+	IsDefault *bool
+}
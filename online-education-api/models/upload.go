@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// 上传状态
+const (
+	UploadStatusUploading = "uploading" // 分片上传中
+	UploadStatusCompleted = "completed" // 已写入对象存储
+)
+
+// Upload 上传的视频文件
+type Upload struct {
+	ID         string    `json:"id"`
+	UserID     int64     `json:"user_id"`
+	FileName   string    `json:"file_name"`
+	Size       int64     `json:"size"`
+	Received   int64     `json:"received"`            // 已接收的字节数
+	MimeType   string    `json:"mime_type,omitempty"` // 根据文件内容识别，接收到足够的字节后确定
+	Checksum   string    `json:"checksum,omitempty"`  // 完成后文件的SHA256，十六进制
+	StorageKey string    `json:"-"`
+	URL        string    `json:"url,omitempty"` // 完成后的访问地址，不保存在数据库中
+	Status     string    `json:"status"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// CreateUploadRequest 创建分片上传请求
+type CreateUploadRequest struct {
+	FileName string `json:"filename" binding:"required,max=255"`
+	Size     int64  `json:"size" binding:"required,min=1"`
+}
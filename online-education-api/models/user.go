@@ -35,9 +35,15 @@ type UserRegisterRequest struct {
 
 // UserUpdateRequest 用户信息更新请求
 type UserUpdateRequest struct {
-	Nickname string `json:"nickname"`
-	Avatar   string `json:"avatar"`
-	Bio      string `json:"bio"`
+	Nickname string `json:"nickname" binding:"max=50"`
+	Avatar   string `json:"avatar" binding:"max=255"`
+	Bio      string `json:"bio" binding:"max=500"`
+}
+
+// ChangePasswordRequest 修改密码请求
+type ChangePasswordRequest struct {
+	OldPassword string `json:"old_password" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required,min=6"`
 }
 
 // UserCreateRequest 创建用户请求
@@ -45,7 +51,7 @@ type UserCreateRequest struct {
 	Username string `json:"username" binding:"required,min=3,max=50"`
 	Email    string `json:"email" binding:"required,email"`
 	Password string `json:"password" binding:"required,min=6"`
-	Role     string `json:"role"` // admin, teacher, student
+	Role     string `json:"role" binding:"omitempty,oneof=admin teacher student"` // admin, teacher, student
 }
 
 // UserResponse 用户响应模型
@@ -10,6 +10,7 @@ type Video struct {
 	Title          string    `json:"title"`
 	Description    string    `json:"description,omitempty"`
 	VideoURL       string    `json:"video_url"`
+	UploadID       string    `json:"upload_id,omitempty"`
 	CoverImageURL  string    `json:"cover_image_url,omitempty"`
 	Duration       int       `json:"duration"`
 	AuthorID       int64     `json:"author_id"`
@@ -17,7 +18,89 @@ type Video struct {
 	ViewCount      int       `json:"view_count"`
 	LikeCount      int       `json:"like_count"`
 	FavoriteCount  int       `json:"favorite_count"`
-	IsPublic       bool      `json:"is_public"`
+	Visibility     string    `json:"visibility"`
 	CreatedAt      time.Time `json:"created_at"`
 	UpdatedAt      time.Time `json:"updated_at"`
-}
\ No newline at end of file
+
+	// 转码状态，由上传文件创建的视频转码完成前为processing
+	Status          string           `json:"status"`
+	HLSURL          string           `json:"hls_url,omitempty"`
+	ThumbnailsURL   string           `json:"thumbnails_url,omitempty"` // 进度条预览缩略图的WebVTT索引
+	ProcessingError string           `json:"processing_error,omitempty"`
+	Renditions      []VideoRendition `json:"renditions,omitempty"`
+
+	// 字幕轨道，只在视频详情中返回
+	Subtitles []*Subtitle `json:"subtitles,omitempty"`
+
+	// 所在播放列表中的位置和前后的视频，只在视频详情中返回
+	Playlist *VideoPlaylistContext `json:"playlist,omitempty"`
+}
+
+// 视频的转码状态
+const (
+	VideoStatusProcessing = "processing"
+	VideoStatusReady      = "ready"
+	VideoStatusFailed     = "failed"
+)
+
+// 视频的可见性
+const (
+	VideoVisibilityPublic   = "public"   // 出现在列表和搜索中
+	VideoVisibilityUnlisted = "unlisted" // 不出现在列表和搜索中，知道地址的用户可以观看
+	VideoVisibilityPrivate  = "private"  // 只有作者和管理员可见
+)
+
+// VideoVisibility 返回请求中的可见性，未提供visibility时按旧客户端的is_public设置，都未提供时返回空字符串
+func VideoVisibility(visibility string, isPublic *bool) string {
+	switch {
+	case visibility != "":
+		return visibility
+	case isPublic == nil:
+		return ""
+	case *isPublic:
+		return VideoVisibilityPublic
+	default:
+		return VideoVisibilityPrivate
+	}
+}
+
+// VideoListQuery 视频列表查询条件，零值表示不限
+type VideoListQuery struct {
+	CategoryID int
+	AuthorID   int64
+	Visibility string
+	// 同时返回下级分类(任意层级)的视频
+	IncludeSubcategories bool
+}
+
+// VideoRendition 视频转码生成的一种HLS清晰度
+type VideoRendition struct {
+	Name        string `json:"name"`
+	Width       int    `json:"width"`
+	Height      int    `json:"height"`
+	Bandwidth   int    `json:"bandwidth"`
+	PlaylistURL string `json:"playlist_url"`
+}
+
+// CreateVideoRequest 创建视频请求
+type CreateVideoRequest struct {
+	Title         string `json:"title" binding:"required,max=255"`
+	Description   string `json:"description"`
+	VideoURL      string `json:"video_url" binding:"max=512"` // 与upload_id二选一
+	UploadID      string `json:"upload_id" binding:"max=32"`
+	CoverImageURL string `json:"cover_image_url" binding:"max=512"`
+	Duration      int    `json:"duration" binding:"min=0"`
+	CategoryID    int    `json:"category_id" binding:"min=0"`
+	Visibility    string `json:"visibility" binding:"omitempty,oneof=public unlisted private"` // 默认为public
+	IsPublic      *bool  `json:"is_public"`                                                    // 已废弃，未提供visibility时true为public，false为private
+}
+
+// UpdateVideoRequest 更新视频请求
+type UpdateVideoRequest struct {
+	Title         string `json:"title" binding:"required,max=255"`
+	Description   string `json:"description"`
+	CoverImageURL string `json:"cover_image_url" binding:"max=512"`
+	CategoryID    int    `json:"category_id" binding:"min=0"`
+	Visibility    string `json:"visibility" binding:"omitempty,oneof=public unlisted private"` // 不提供时保持不变
+	IsPublic      *bool  `json:"is_public"`                                                    // 已废弃，同CreateVideoRequest
+}
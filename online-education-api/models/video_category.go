@@ -9,6 +9,22 @@ type VideoCategory struct {
 	ID          int       `json:"id"`
 	Name        string    `json:"name"`
 	Description string    `json:"description,omitempty"`
+	ParentID    *int      `json:"parent_id,omitempty"` // 上级分类ID，一级分类为空
+	SortOrder   int       `json:"sort_order"`
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
-}
\ No newline at end of file
+
+	// 分类下公开视频的数量，VideoCount不包含下级分类，TotalVideoCount包含全部下级分类
+	VideoCount      int `json:"video_count"`
+	TotalVideoCount int `json:"total_video_count"`
+
+	Children []*VideoCategory `json:"children,omitempty"` // 下级分类，只在分类树中返回
+}
+
+// VideoCategoryRequest 创建/更新视频分类请求
+type VideoCategoryRequest struct {
+	Name        string `json:"name" binding:"required,max=100"`
+	Description string `json:"description" binding:"max=500"`
+	ParentID    *int   `json:"parent_id"`
+	SortOrder   int    `json:"sort_order" binding:"min=0"`
+}
@@ -0,0 +1,44 @@
+package models
+
+// RetentionBuckets 统计观众留存时把视频时长等分的段数
+const RetentionBuckets = 20
+
+// 播放事件类型
+const (
+	ViewEventPlay      = "play"      // 开始播放，去重后计为一次观看
+	ViewEventHeartbeat = "heartbeat" // 播放期间定期发送，累计观看时长和播放进度
+)
+
+// ViewEventRequest 播放事件请求
+type ViewEventRequest struct {
+	Type      string  `json:"type" binding:"required,oneof=play heartbeat"`
+	SessionID string  `json:"session_id" binding:"required,max=64"` // 播放器生成的随机ID，未登录时用于去重
+	Position  float64 `json:"position" binding:"min=0"`             // 当前的播放位置(秒)
+}
+
+// VideoStatsDelta 一个视频在两次写入数据库之间累计的播放统计
+type VideoStatsDelta struct {
+	VideoID      int
+	Views        int
+	WatchSeconds int64
+	Completions  int
+	Retention    [RetentionBuckets]int // 各段新增的观看次数
+}
+
+// VideoStats 视频的播放统计，只包含启用播放事件之后的观看
+type VideoStats struct {
+	VideoID         int              `json:"video_id"`
+	Views           int              `json:"views"` // 去重后的观看次数
+	WatchSeconds    int64            `json:"watch_seconds"`
+	AvgWatchSeconds float64          `json:"avg_watch_seconds"`
+	Completions     int              `json:"completions"` // 看到最后一段的观看次数
+	CompletionRate  float64          `json:"completion_rate"`
+	Retention       []RetentionPoint `json:"retention"`
+}
+
+// RetentionPoint 留存曲线上的一段
+type RetentionPoint struct {
+	Position float64 `json:"position"` // 该段起点占视频时长的比例
+	Viewers  int     `json:"viewers"`
+	Rate     float64 `json:"rate"` // 看到该段的观看占全部观看的比例
+}
@@ -0,0 +1,138 @@
+// Package pagination 列表接口的分页参数
+//
+// 列表支持两种分页方式：按页码分页(page、pageSize)，以及按上一页返回的游标继续读取的键集分页
+// (cursor、pageSize)。键集分页不使用OFFSET，翻到很靠后的页时也不需要扫描前面的记录，
+// 两页之间插入或删除记录时也不会重复或遗漏。
+package pagination
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 每页条数
+const (
+	DefaultPageSize = 10
+	MaxPageSize     = 100
+)
+
+// ErrInvalidCursor 游标无法解析
+var ErrInvalidCursor = errors.New("无效的游标")
+
+// cursorVersion 游标格式版本，格式变化时旧游标会被拒绝而不是被误读
+const cursorVersion = "1"
+
+// Cursor 键集分页的位置，指向上一页的最后一条记录
+// 按(created_at, id)倒序的列表使用两个字段，按ID排序的列表只使用ID
+type Cursor struct {
+	CreatedAt time.Time
+	ID        int64
+}
+
+// String 将游标编码为不透明的字符串，客户端只应原样传回
+func (c Cursor) String() string {
+	raw := cursorVersion + ":" + strconv.FormatInt(c.CreatedAt.UnixNano(), 10) + ":" + strconv.FormatInt(c.ID, 10)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// ParseCursor 解析 Cursor.String 生成的字符串
+func ParseCursor(s string) (*Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	parts := strings.Split(string(raw), ":")
+	if len(parts) != 3 || parts[0] != cursorVersion {
+		return nil, ErrInvalidCursor
+	}
+	nanos, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	id, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	return &Cursor{CreatedAt: time.Unix(0, nanos), ID: id}, nil
+}
+
+// Page 分页请求
+type Page struct {
+	Number    int     // 页码，从1开始，设置了After时忽略
+	Size      int     // 每页条数
+	After     *Cursor // 上一页返回的游标，设置后使用键集分页
+	SkipTotal bool    // 不统计总数，省去一次COUNT查询
+}
+
+// Offset 页码分页的偏移量，键集分页时为0
+func (p Page) Offset() int {
+	if p.After != nil || p.Number < 1 {
+		return 0
+	}
+	return (p.Number - 1) * p.Size
+}
+
+// Limit 存储层需要读取的条数，比每页条数多一条，用于判断是否还有下一页
+func (p Page) Limit() int {
+	return p.Size + 1
+}
+
+// FromRequest 从查询参数page、pageSize、cursor和skipTotal解析分页请求
+// 无效的page和pageSize使用默认值，pageSize超过 MaxPageSize 时按 MaxPageSize 处理；cursor无效时返回 ErrInvalidCursor
+func FromRequest(r *http.Request) (Page, error) {
+	query := r.URL.Query()
+	p := Page{Number: 1, Size: DefaultPageSize}
+
+	if n, err := strconv.Atoi(query.Get("page")); err == nil && n > 0 {
+		p.Number = n
+	}
+	if size, err := strconv.Atoi(query.Get("pageSize")); err == nil && size > 0 {
+		p.Size = min(size, MaxPageSize)
+	}
+	if s := query.Get("cursor"); s != "" {
+		cursor, err := ParseCursor(s)
+		if err != nil {
+			return p, err
+		}
+		p.After = cursor
+	}
+	p.SkipTotal = query.Get("skipTotal") == "true"
+	return p, nil
+}
+
+// List 一页数据
+type List[T any] struct {
+	Items      []T
+	Total      *int   // 设置了SkipTotal时为nil
+	NextCursor string // 下一页的游标，没有下一页时为空
+}
+
+// NewList 根据按 Page.Limit 读取的记录构建一页数据：去掉多读的一条，并用最后一条记录生成下一页的游标
+func NewList[T any](items []T, p Page, cursor func(T) Cursor) *List[T] {
+	list := &List[T]{Items: items}
+	if p.Size > 0 && len(items) > p.Size {
+		list.Items = items[:p.Size]
+		list.NextCursor = cursor(list.Items[len(list.Items)-1]).String()
+	}
+	if list.Items == nil {
+		list.Items = []T{}
+	}
+	return list
+}
+
+// SetTotal 统计总数，设置了SkipTotal时不调用count
+func (l *List[T]) SetTotal(p Page, count func() (int, error)) error {
+	if p.SkipTotal {
+		return nil
+	}
+	total, err := count()
+	if err != nil {
+		return err
+	}
+	l.Total = &total
+	return nil
+}
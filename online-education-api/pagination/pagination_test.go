@@ -0,0 +1,70 @@
+package pagination
+
+import (
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	c := Cursor{CreatedAt: time.Date(2024, 5, 1, 8, 30, 0, 123, time.UTC), ID: 42}
+	got, err := ParseCursor(c.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.CreatedAt.Equal(c.CreatedAt) || got.ID != c.ID {
+		t.Fatalf("got %+v, want %+v", got, c)
+	}
+
+	for _, s := range []string{"", "not base64!", "MjoxOjE", "MTp4OjE"} {
+		if _, err := ParseCursor(s); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("%q: expected ErrInvalidCursor, got %v", s, err)
+		}
+	}
+}
+
+func TestFromRequest(t *testing.T) {
+	p, err := FromRequest(httptest.NewRequest("GET", "/?page=3&pageSize=1000000&skipTotal=true", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Number != 3 || p.Size != MaxPageSize || !p.SkipTotal || p.Offset() != 2*MaxPageSize {
+		t.Fatalf("unexpected page %+v", p)
+	}
+
+	p, _ = FromRequest(httptest.NewRequest("GET", "/?page=-1&pageSize=abc", nil))
+	if p.Number != 1 || p.Size != DefaultPageSize {
+		t.Fatalf("expected defaults, got %+v", p)
+	}
+
+	cursor := Cursor{ID: 7}
+	p, _ = FromRequest(httptest.NewRequest("GET", "/?page=5&cursor="+cursor.String(), nil))
+	if p.After == nil || p.After.ID != 7 || p.Offset() != 0 {
+		t.Fatalf("expected the cursor to replace the offset, got %+v", p)
+	}
+	if _, err := FromRequest(httptest.NewRequest("GET", "/?cursor=bogus", nil)); !errors.Is(err, ErrInvalidCursor) {
+		t.Fatalf("expected ErrInvalidCursor, got %v", err)
+	}
+}
+
+func TestNewList(t *testing.T) {
+	p := Page{Size: 2}
+	byID := func(id int64) Cursor { return Cursor{ID: id} }
+
+	list := NewList([]int64{5, 4, 3}, p, byID)
+	if len(list.Items) != 2 || list.NextCursor != (Cursor{ID: 4}).String() {
+		t.Fatalf("unexpected list %+v", list)
+	}
+	list = NewList([]int64{2, 1}, p, byID)
+	if len(list.Items) != 2 || list.NextCursor != "" {
+		t.Fatalf("expected the last page to have no cursor, got %+v", list)
+	}
+	if list = NewList[int64](nil, p, byID); list.Items == nil {
+		t.Fatal("expected an empty slice rather than nil")
+	}
+
+	if err := list.SetTotal(Page{SkipTotal: true}, func() (int, error) { t.Fatal("count called"); return 0, nil }); err != nil || list.Total != nil {
+		t.Fatalf("expected no total, got %v %v", list.Total, err)
+	}
+}
@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"context"
+
+	"online-education-api/models"
+)
+
+// CourseFilter 课程列表的筛选条件，零值表示不限
+type CourseFilter struct {
+	CategoryIDs []int64 // 属于其中任一分类
+	Level       int64
+	Search      string // 匹配标题或简介
+	MinPrice    *float64
+	MaxPrice    *float64
+	TeacherID   int64
+	MinRating   float64
+	// PublishedOnly 只返回已上架的课程，DraftsOf不为0时同时返回该教师未上架的课程
+	PublishedOnly bool
+	DraftsOf      int64
+}
+
+// CourseSort 课程列表的排序方式，相同时按创建时间和ID倒序
+type CourseSort string
+
+// 课程排序方式
+const (
+	CourseSortNewest    CourseSort = "newest"     // 最新发布
+	CourseSortPopular   CourseSort = "popular"    // 学生数量从多到少
+	CourseSortRating    CourseSort = "rating"     // 评分从高到低
+	CourseSortPriceAsc  CourseSort = "price_asc"  // 价格从低到高
+	CourseSortPriceDesc CourseSort = "price_desc" // 价格从高到低
+)
+
+// CoursePriceRanges 课程价格分面的区间，左闭右开，Max为0表示不限
+var CoursePriceRanges = []struct {
+	Value    string
+	Min, Max float64
+}{
+	{"free", 0, 0.01},
+	{"0-100", 0.01, 100},
+	{"100-500", 100, 500},
+	{"500+", 500, 0},
+}
+
+// CoursePriceRange 返回价格所属的分面区间
+func CoursePriceRange(price float64) string {
+	for _, r := range CoursePriceRanges {
+		if price >= r.Min && (r.Max == 0 || price < r.Max) {
+			return r.Value
+		}
+	}
+	return ""
+}
+
+// CourseRepository 课程数据访问接口，章节和课时属于课程聚合
+type CourseRepository interface {
+	// List 按排序方式返回课程，包含分类名和教师名
+	List(ctx context.Context, filter CourseFilter, sort CourseSort, offset, limit int) ([]*models.CourseResponse, error)
+	Count(ctx context.Context, filter CourseFilter) (int, error)
+	// Facets 统计满足筛选条件的课程按分类、难度和价格区间的数量
+	Facets(ctx context.Context, filter CourseFilter) (*models.CourseFacets, error)
+	GetByID(ctx context.Context, id int64) (*models.Course, error)
+	// Chapters 按排序返回课程的章节，每个章节包含其课时，查询次数固定
+	Chapters(ctx context.Context, courseID int64) ([]*models.Chapter, error)
+	CountChapters(ctx context.Context, courseID int64) (int, error)
+	// GetLesson 返回课时及其所属课程的ID
+	GetLesson(ctx context.Context, id int64) (*models.Lesson, int64, error)
+	Create(ctx context.Context, course *models.Course) error
+	Update(ctx context.Context, course *models.Course) error
+	Delete(ctx context.Context, id int64) error
+	// Import 原子地创建课程及其章节和课时
+	Import(ctx context.Context, course *models.Course, chapters []*models.Chapter) error
+	// AddStudents 调整课程的学生数量，delta可以为负数
+	AddStudents(ctx context.Context, id int64, delta int) error
+}
+
+// CategoryRepository 课程分类数据访问接口
+type CategoryRepository interface {
+	GetByID(ctx context.Context, id int64) (*models.CourseCategory, error)
+	// ListByParent 按排序返回子分类，parentID为nil时返回一级分类
+	ListByParent(ctx context.Context, parentID *int64) ([]*models.CourseCategory, error)
+	// ListAll 按排序返回全部分类，由调用方组装分类树
+	ListAll(ctx context.Context) ([]*models.CourseCategory, error)
+	Create(ctx context.Context, category *models.CourseCategory) error
+	Update(ctx context.Context, category *models.CourseCategory) error
+	Delete(ctx context.Context, id int64) error
+}
@@ -0,0 +1,16 @@
+package repository
+
+import (
+	"context"
+
+	"online-education-api/models"
+)
+
+// DanmakuRepository 弹幕数据访问接口
+type DanmakuRepository interface {
+	Create(ctx context.Context, danmaku *models.Danmaku) error
+	GetByID(ctx context.Context, id int64) (*models.Danmaku, error)
+	// ListRange 返回视频在[from, to]秒之间的弹幕，按播放位置排序，最多limit条
+	ListRange(ctx context.Context, videoID int, from, to float64, limit int) ([]*models.Danmaku, error)
+	Delete(ctx context.Context, id int64) error
+}
@@ -0,0 +1,19 @@
+package repository
+
+import (
+	"context"
+
+	"online-education-api/models"
+)
+
+// EnrollmentRepository 报名记录数据访问接口，每个用户和课程最多一条记录
+type EnrollmentRepository interface {
+	// Get 返回报名记录，包括已取消的记录
+	Get(ctx context.Context, userID, courseID int64) (*models.UserCourse, error)
+	// Save 创建报名记录，已有记录时覆盖订单、价格和状态
+	Save(ctx context.Context, enrollment *models.UserCourse) error
+	UpdateStatus(ctx context.Context, userID, courseID int64, status int) error
+	// ListActive 按报名时间倒序返回用户有效的报名记录，包含课程信息
+	ListActive(ctx context.Context, userID int64, offset, limit int) ([]*models.UserCourseResponse, error)
+	CountActive(ctx context.Context, userID int64) (int, error)
+}
@@ -0,0 +1,27 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"online-education-api/models"
+)
+
+// ImageRepository 图片数据访问接口
+//
+// 图片作为实体的主图时，实体的图片字段(用户头像、课程封面、视频封面)保存主图的访问地址，
+// 关联和删除图片时在同一事务中更新该字段。
+type ImageRepository interface {
+	Create(ctx context.Context, image *models.Image) error
+	GetByID(ctx context.Context, id int64) (*models.Image, error)
+	// ListByEntity 返回实体的图片，主图在前，其余按上传顺序
+	ListByEntity(ctx context.Context, entityType string, entityID int64) ([]*models.Image, error)
+	UpdateAltText(ctx context.Context, id int64, altText string) error
+	// Attach 按image的EntityType、EntityID和IsPrimary关联图片。设为主图时取消实体的其他主图并将实体的图片字段设为url；
+	// 不是主图时，实体的图片字段等于url的清空
+	Attach(ctx context.Context, image *models.Image, url string) error
+	// Delete 删除图片，实体的图片字段等于url时清空
+	Delete(ctx context.Context, image *models.Image, url string) error
+	// ListOrphans 返回创建时间早于before、未关联实体或关联的实体已删除的图片，最多limit条
+	ListOrphans(ctx context.Context, before time.Time, limit int) ([]*models.Image, error)
+}
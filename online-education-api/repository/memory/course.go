@@ -0,0 +1,426 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"strconv"
+	"time"
+
+	"online-education-api/models"
+	"online-education-api/repository"
+)
+
+// courseRepository 课程、章节和课时数据访问
+type courseRepository struct {
+	*store
+}
+
+// match 判断课程是否满足筛选条件，调用方需持有读锁
+func (r *courseRepository) match(course *models.Course, filter repository.CourseFilter) bool {
+	if len(filter.CategoryIDs) > 0 && !slices.Contains(filter.CategoryIDs, course.CategoryID) {
+		return false
+	}
+	if filter.Level > 0 && int64(course.Level) != filter.Level {
+		return false
+	}
+	if filter.Search != "" && !contains(course.Title, filter.Search) && !contains(course.Description, filter.Search) {
+		return false
+	}
+	if filter.MinPrice != nil && course.Price < *filter.MinPrice {
+		return false
+	}
+	if filter.MaxPrice != nil && course.Price > *filter.MaxPrice {
+		return false
+	}
+	if filter.TeacherID > 0 && course.TeacherID != filter.TeacherID {
+		return false
+	}
+	if filter.MinRating > 0 && course.Rating < filter.MinRating {
+		return false
+	}
+	if filter.PublishedOnly && course.Status != 1 && (filter.DraftsOf == 0 || course.TeacherID != filter.DraftsOf) {
+		return false
+	}
+	return true
+}
+
+// compareCourses 按排序方式比较两门课程，相同时按创建时间和ID倒序
+func compareCourses(sort repository.CourseSort, a, b *models.CourseResponse) int {
+	var c int
+	switch sort {
+	case repository.CourseSortPopular:
+		c = cmp.Compare(b.StudentCount, a.StudentCount)
+	case repository.CourseSortRating:
+		c = cmp.Compare(b.Rating, a.Rating)
+	case repository.CourseSortPriceAsc:
+		c = cmp.Compare(a.Price, b.Price)
+	case repository.CourseSortPriceDesc:
+		c = cmp.Compare(b.Price, a.Price)
+	}
+	return cmp.Or(c, newest(a.CreatedAt, b.CreatedAt, a.ID, b.ID))
+}
+
+func (r *courseRepository) List(ctx context.Context, filter repository.CourseFilter, sort repository.CourseSort, offset, limit int) ([]*models.CourseResponse, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var courses []*models.CourseResponse
+	for _, c := range r.courses {
+		if !r.match(c, filter) {
+			continue
+		}
+		course := &models.CourseResponse{
+			ID:            c.ID,
+			Title:         c.Title,
+			Description:   c.Description,
+			CoverImage:    c.CoverImage,
+			Price:         c.Price,
+			OriginalPrice: c.OriginalPrice,
+			CategoryID:    c.CategoryID,
+			TeacherID:     c.TeacherID,
+			Level:         c.Level,
+			Duration:      c.Duration,
+			StudentCount:  c.StudentCount,
+			Rating:        c.Rating,
+			ReviewCount:   c.ReviewCount,
+			Status:        c.Status,
+			CreatedAt:     c.CreatedAt,
+		}
+		if category, ok := r.categories[c.CategoryID]; ok {
+			course.CategoryName = category.Name
+		}
+		if teacher, ok := r.users[c.TeacherID]; ok {
+			course.TeacherName = teacher.Username
+		}
+		courses = append(courses, course)
+	}
+	slices.SortFunc(courses, func(a, b *models.CourseResponse) int {
+		return compareCourses(sort, a, b)
+	})
+	return page(courses, offset, limit), nil
+}
+
+func (r *courseRepository) Count(ctx context.Context, filter repository.CourseFilter) (int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	total := 0
+	for _, course := range r.courses {
+		if r.match(course, filter) {
+			total++
+		}
+	}
+	return total, nil
+}
+
+func (r *courseRepository) Facets(ctx context.Context, filter repository.CourseFilter) (*models.CourseFacets, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	categories := make(map[string]*models.FacetCount)
+	levels := make(map[string]*models.FacetCount)
+	prices := make(map[string]*models.FacetCount)
+	add := func(counts map[string]*models.FacetCount, value, label string) {
+		if fc, ok := counts[value]; ok {
+			fc.Count++
+			return
+		}
+		counts[value] = &models.FacetCount{Value: value, Label: label, Count: 1}
+	}
+
+	for _, course := range r.courses {
+		if !r.match(course, filter) {
+			continue
+		}
+		label := ""
+		if category, ok := r.categories[course.CategoryID]; ok {
+			label = category.Name
+		}
+		add(categories, strconv.FormatInt(course.CategoryID, 10), label)
+		add(levels, strconv.Itoa(course.Level), "")
+		add(prices, repository.CoursePriceRange(course.Price), "")
+	}
+
+	return &models.CourseFacets{
+		Categories: sortedFacets(categories),
+		Levels:     sortedFacets(levels),
+		Prices:     sortedFacets(prices),
+	}, nil
+}
+
+// sortedFacets 按数量倒序返回分面，数量相同时按取值排序
+func sortedFacets(counts map[string]*models.FacetCount) []models.FacetCount {
+	result := make([]models.FacetCount, 0, len(counts))
+	for _, fc := range counts {
+		result = append(result, *fc)
+	}
+	slices.SortFunc(result, func(a, b models.FacetCount) int {
+		return cmp.Or(cmp.Compare(b.Count, a.Count), cmp.Compare(a.Value, b.Value))
+	})
+	return result
+}
+
+func (r *courseRepository) GetByID(ctx context.Context, id int64) (*models.Course, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	course, ok := r.courses[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	copied := *course
+	return &copied, nil
+}
+
+func (r *courseRepository) Chapters(ctx context.Context, courseID int64) ([]*models.Chapter, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var chapters []*models.Chapter
+	byID := make(map[int64]*models.Chapter)
+	for _, c := range r.chapters {
+		if c.CourseID != courseID {
+			continue
+		}
+		chapter := *c
+		chapter.Lessons = nil
+		chapters = append(chapters, &chapter)
+		byID[chapter.ID] = &chapter
+	}
+	for _, l := range r.lessons {
+		if chapter, ok := byID[l.ChapterID]; ok {
+			lesson := *l
+			chapter.Lessons = append(chapter.Lessons, &lesson)
+		}
+	}
+
+	for _, chapter := range chapters {
+		slices.SortFunc(chapter.Lessons, func(a, b *models.Lesson) int {
+			return cmp.Or(cmp.Compare(a.SortOrder, b.SortOrder), cmp.Compare(a.ID, b.ID))
+		})
+	}
+	slices.SortFunc(chapters, func(a, b *models.Chapter) int {
+		return cmp.Or(cmp.Compare(a.SortOrder, b.SortOrder), cmp.Compare(a.ID, b.ID))
+	})
+	return chapters, nil
+}
+
+func (r *courseRepository) CountChapters(ctx context.Context, courseID int64) (int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	count := 0
+	for _, chapter := range r.chapters {
+		if chapter.CourseID == courseID {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *courseRepository) GetLesson(ctx context.Context, id int64) (*models.Lesson, int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	lesson, ok := r.lessons[id]
+	if !ok {
+		return nil, 0, repository.ErrNotFound
+	}
+	chapter, ok := r.chapters[lesson.ChapterID]
+	if !ok {
+		return nil, 0, repository.ErrNotFound
+	}
+	copied := *lesson
+	return &copied, chapter.CourseID, nil
+}
+
+func (r *courseRepository) Create(ctx context.Context, course *models.Course) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.insertCourse(course, time.Now())
+	return nil
+}
+
+// insertCourse 保存课程的副本，调用方需持有写锁
+func (r *courseRepository) insertCourse(course *models.Course, now time.Time) {
+	course.ID = r.nextID("courses")
+	course.CreatedAt = now
+	course.UpdatedAt = now
+	copied := *course
+	r.courses[course.ID] = &copied
+}
+
+func (r *courseRepository) Update(ctx context.Context, course *models.Course) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.courses[course.ID]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	// 与mysql实现一致，不修改教师、学生数、评分和评价数
+	stored.Title = course.Title
+	stored.Description = course.Description
+	stored.CoverImage = course.CoverImage
+	stored.Price = course.Price
+	stored.OriginalPrice = course.OriginalPrice
+	stored.CategoryID = course.CategoryID
+	stored.Level = course.Level
+	stored.Duration = course.Duration
+	stored.Status = course.Status
+	stored.UpdatedAt = time.Now()
+	return nil
+}
+
+func (r *courseRepository) Delete(ctx context.Context, id int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.courses[id]; !ok {
+		return repository.ErrNotFound
+	}
+	delete(r.courses, id)
+	return nil
+}
+
+func (r *courseRepository) Import(ctx context.Context, course *models.Course, chapters []*models.Chapter) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	course.StudentCount = 0
+	course.Rating = 0
+	course.ReviewCount = 0
+	r.insertCourse(course, now)
+
+	for _, chapter := range chapters {
+		chapter.ID = r.nextID("chapters")
+		chapter.CourseID = course.ID
+		chapter.CreatedAt = now
+		chapter.UpdatedAt = now
+		stored := *chapter
+		stored.Lessons = nil
+		r.chapters[chapter.ID] = &stored
+
+		for _, lesson := range chapter.Lessons {
+			lesson.ID = r.nextID("lessons")
+			lesson.ChapterID = chapter.ID
+			lesson.CreatedAt = now
+			lesson.UpdatedAt = now
+			copied := *lesson
+			r.lessons[lesson.ID] = &copied
+		}
+	}
+	return nil
+}
+
+func (r *courseRepository) AddStudents(ctx context.Context, id int64, delta int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if course, ok := r.courses[id]; ok {
+		course.StudentCount += delta
+	}
+	return nil
+}
+
+// categoryRepository 课程分类数据访问
+type categoryRepository struct {
+	*store
+}
+
+// copyCategory 复制分类，ParentID指向新的变量
+func copyCategory(category *models.CourseCategory) *models.CourseCategory {
+	copied := *category
+	if category.ParentID != nil {
+		parentID := *category.ParentID
+		copied.ParentID = &parentID
+	}
+	copied.Children = nil
+	return &copied
+}
+
+func (r *categoryRepository) GetByID(ctx context.Context, id int64) (*models.CourseCategory, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	category, ok := r.categories[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return copyCategory(category), nil
+}
+
+func (r *categoryRepository) ListByParent(ctx context.Context, parentID *int64) ([]*models.CourseCategory, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var categories []*models.CourseCategory
+	for _, category := range r.categories {
+		switch {
+		case parentID == nil && category.ParentID != nil:
+			continue
+		case parentID != nil && (category.ParentID == nil || *category.ParentID != *parentID):
+			continue
+		}
+		categories = append(categories, copyCategory(category))
+	}
+	slices.SortFunc(categories, func(a, b *models.CourseCategory) int {
+		return cmp.Or(cmp.Compare(a.SortOrder, b.SortOrder), cmp.Compare(a.ID, b.ID))
+	})
+	return categories, nil
+}
+
+func (r *categoryRepository) ListAll(ctx context.Context) ([]*models.CourseCategory, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	categories := make([]*models.CourseCategory, 0, len(r.categories))
+	for _, category := range r.categories {
+		categories = append(categories, copyCategory(category))
+	}
+	slices.SortFunc(categories, func(a, b *models.CourseCategory) int {
+		return cmp.Or(cmp.Compare(a.SortOrder, b.SortOrder), cmp.Compare(a.ID, b.ID))
+	})
+	return categories, nil
+}
+
+func (r *categoryRepository) Create(ctx context.Context, category *models.CourseCategory) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	category.ID = r.nextID("course_categories")
+	category.CreatedAt = now
+	category.UpdatedAt = now
+	r.categories[category.ID] = copyCategory(category)
+	return nil
+}
+
+func (r *categoryRepository) Update(ctx context.Context, category *models.CourseCategory) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.categories[category.ID]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	updated := copyCategory(category)
+	updated.CreatedAt = stored.CreatedAt
+	updated.UpdatedAt = time.Now()
+	r.categories[category.ID] = updated
+	return nil
+}
+
+func (r *categoryRepository) Delete(ctx context.Context, id int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.categories[id]; !ok {
+		return repository.ErrNotFound
+	}
+	delete(r.categories, id)
+	return nil
+}
@@ -0,0 +1,74 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"math"
+	"slices"
+	"time"
+
+	"online-education-api/models"
+	"online-education-api/repository"
+)
+
+// danmakuRepository 弹幕数据访问
+type danmakuRepository struct{ *store }
+
+func (r *danmakuRepository) Create(ctx context.Context, danmaku *models.Danmaku) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// 与mysql实现一样按毫秒保存播放位置
+	danmaku.Offset = math.Round(danmaku.Offset*1000) / 1000
+	danmaku.ID = r.nextID("video_danmaku")
+	danmaku.CreatedAt = time.Now()
+	copied := *danmaku
+	r.danmaku[danmaku.ID] = &copied
+	return nil
+}
+
+func (r *danmakuRepository) GetByID(ctx context.Context, id int64) (*models.Danmaku, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	danmaku, ok := r.danmaku[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	copied := *danmaku
+	return &copied, nil
+}
+
+func (r *danmakuRepository) ListRange(ctx context.Context, videoID int, from, to float64, limit int) ([]*models.Danmaku, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var list []*models.Danmaku
+	for _, danmaku := range r.danmaku {
+		if danmaku.VideoID == videoID && danmaku.Offset >= from && danmaku.Offset <= to {
+			copied := *danmaku
+			list = append(list, &copied)
+		}
+	}
+	slices.SortFunc(list, func(a, b *models.Danmaku) int {
+		if c := cmp.Compare(a.Offset, b.Offset); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.ID, b.ID)
+	})
+	if len(list) > limit {
+		list = list[:limit]
+	}
+	return list, nil
+}
+
+func (r *danmakuRepository) Delete(ctx context.Context, id int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.danmaku[id]; !ok {
+		return repository.ErrNotFound
+	}
+	delete(r.danmaku, id)
+	return nil
+}
@@ -0,0 +1,116 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"online-education-api/models"
+	"online-education-api/repository"
+)
+
+// enrollmentKey 报名记录的唯一键
+type enrollmentKey struct {
+	userID, courseID int64
+}
+
+// enrollmentRepository 报名记录数据访问
+type enrollmentRepository struct {
+	*store
+}
+
+func (r *enrollmentRepository) Get(ctx context.Context, userID, courseID int64) (*models.UserCourse, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	enrollment, ok := r.enrollments[enrollmentKey{userID, courseID}]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	copied := *enrollment
+	return &copied, nil
+}
+
+func (r *enrollmentRepository) Save(ctx context.Context, enrollment *models.UserCourse) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	key := enrollmentKey{enrollment.UserID, enrollment.CourseID}
+	if stored, ok := r.enrollments[key]; ok {
+		// 取消报名后重新报名时复用原记录
+		stored.OrderID = enrollment.OrderID
+		stored.Price = enrollment.Price
+		stored.Status = enrollment.Status
+		stored.UpdatedAt = now
+		enrollment.ID = stored.ID
+		enrollment.CreatedAt = stored.CreatedAt
+		enrollment.UpdatedAt = now
+		return nil
+	}
+
+	enrollment.ID = r.nextID("user_courses")
+	enrollment.CreatedAt = now
+	enrollment.UpdatedAt = now
+	copied := *enrollment
+	r.enrollments[key] = &copied
+	return nil
+}
+
+func (r *enrollmentRepository) UpdateStatus(ctx context.Context, userID, courseID int64, status int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	enrollment, ok := r.enrollments[enrollmentKey{userID, courseID}]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	enrollment.Status = status
+	enrollment.UpdatedAt = time.Now()
+	return nil
+}
+
+func (r *enrollmentRepository) ListActive(ctx context.Context, userID int64, offset, limit int) ([]*models.UserCourseResponse, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var enrollments []*models.UserCourseResponse
+	for key, e := range r.enrollments {
+		if key.userID != userID || e.Status != 1 {
+			continue
+		}
+		course := &models.Course{ID: e.CourseID}
+		if c, ok := r.courses[e.CourseID]; ok {
+			course.Title = c.Title
+			course.CoverImage = c.CoverImage
+			course.TeacherID = c.TeacherID
+			course.Level = c.Level
+			course.Duration = c.Duration
+		}
+		enrollments = append(enrollments, &models.UserCourseResponse{
+			ID:        e.ID,
+			CourseID:  e.CourseID,
+			Course:    course,
+			Price:     e.Price,
+			Status:    e.Status,
+			CreatedAt: e.CreatedAt,
+		})
+	}
+	slices.SortFunc(enrollments, func(a, b *models.UserCourseResponse) int {
+		return newest(a.CreatedAt, b.CreatedAt, a.ID, b.ID)
+	})
+	return page(enrollments, offset, limit), nil
+}
+
+func (r *enrollmentRepository) CountActive(ctx context.Context, userID int64) (int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	total := 0
+	for key, e := range r.enrollments {
+		if key.userID == userID && e.Status == 1 {
+			total++
+		}
+	}
+	return total, nil
+}
@@ -0,0 +1,164 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"time"
+
+	"online-education-api/models"
+	"online-education-api/repository"
+)
+
+// imageRepository 图片数据访问
+type imageRepository struct{ *store }
+
+// copyImage 复制图片，不复制只在服务层设置的访问地址
+func copyImage(image *models.Image) *models.Image {
+	copied := *image
+	copied.URL = ""
+	copied.Thumbnails = nil
+	return &copied
+}
+
+func (r *imageRepository) Create(ctx context.Context, image *models.Image) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	image.ID = r.nextID("images")
+	image.CreatedAt = now
+	image.UpdatedAt = now
+	r.images[image.ID] = copyImage(image)
+	return nil
+}
+
+func (r *imageRepository) GetByID(ctx context.Context, id int64) (*models.Image, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	image, ok := r.images[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return copyImage(image), nil
+}
+
+func (r *imageRepository) ListByEntity(ctx context.Context, entityType string, entityID int64) ([]*models.Image, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var images []*models.Image
+	for _, image := range r.images {
+		if image.EntityType == entityType && image.EntityID == entityID {
+			images = append(images, copyImage(image))
+		}
+	}
+	slices.SortFunc(images, func(a, b *models.Image) int {
+		if a.IsPrimary != b.IsPrimary {
+			if a.IsPrimary {
+				return -1
+			}
+			return 1
+		}
+		return cmp.Compare(a.ID, b.ID)
+	})
+	return images, nil
+}
+
+func (r *imageRepository) UpdateAltText(ctx context.Context, id int64, altText string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	image, ok := r.images[id]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	image.AltText = altText
+	image.UpdatedAt = time.Now()
+	return nil
+}
+
+func (r *imageRepository) Attach(ctx context.Context, image *models.Image, url string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.images[image.ID]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	if image.IsPrimary {
+		for _, other := range r.images {
+			if other.EntityType == image.EntityType && other.EntityID == image.EntityID {
+				other.IsPrimary = false
+			}
+		}
+	}
+	stored.EntityType = image.EntityType
+	stored.EntityID = image.EntityID
+	stored.IsPrimary = image.IsPrimary
+	stored.UpdatedAt = time.Now()
+	image.UpdatedAt = stored.UpdatedAt
+
+	if field := r.entityImage(image.EntityType, image.EntityID); field != nil {
+		if image.IsPrimary {
+			*field = url
+		} else if *field == url {
+			*field = ""
+		}
+	}
+	return nil
+}
+
+func (r *imageRepository) Delete(ctx context.Context, image *models.Image, url string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.images[image.ID]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	if field := r.entityImage(stored.EntityType, stored.EntityID); field != nil && *field == url {
+		*field = ""
+	}
+	delete(r.images, image.ID)
+	return nil
+}
+
+func (r *imageRepository) ListOrphans(ctx context.Context, before time.Time, limit int) ([]*models.Image, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var images []*models.Image
+	for _, image := range r.images {
+		if image.CreatedAt.Before(before) && r.entityImage(image.EntityType, image.EntityID) == nil {
+			images = append(images, copyImage(image))
+		}
+	}
+	slices.SortFunc(images, func(a, b *models.Image) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+	if len(images) > limit {
+		images = images[:limit]
+	}
+	return images, nil
+}
+
+// entityImage 返回实体的图片字段，实体不存在时返回nil，调用方需持有锁
+func (r *imageRepository) entityImage(entityType string, entityID int64) *string {
+	switch entityType {
+	case models.ImageEntityUser:
+		if user, ok := r.users[entityID]; ok {
+			return &user.Avatar
+		}
+	case models.ImageEntityCourse:
+		if course, ok := r.courses[entityID]; ok {
+			return &course.CoverImage
+		}
+	case models.ImageEntityVideo:
+		if video, ok := r.videos[int(entityID)]; ok {
+			return &video.CoverImageURL
+		}
+	}
+	return nil
+}
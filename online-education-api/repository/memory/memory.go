@@ -0,0 +1,153 @@
+// Package memory 基于内存的数据访问实现
+//
+// 数据只保存在进程内，不依赖MySQL，用于测试和本地运行。
+// 查询语义与mysql实现保持一致：列表按创建时间倒序、ID倒序排列，
+// 搜索不区分大小写，关联查询的字段在关联记录不存在时为零值。
+package memory
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"online-education-api/models"
+	"online-education-api/pagination"
+	"online-education-api/repository"
+)
+
+// store 所有聚合共享的数据，关联查询(如课程的教师名)需要访问其他聚合
+type store struct {
+	mu sync.RWMutex
+
+	seq             map[string]int64
+	users           map[int64]*models.User
+	categories      map[int64]*models.CourseCategory
+	courses         map[int64]*models.Course
+	chapters        map[int64]*models.Chapter
+	lessons         map[int64]*models.Lesson
+	enrollments     map[enrollmentKey]*models.UserCourse
+	payments        map[int64]*models.Payment
+	posts           map[int64]*models.Post
+	videos          map[int]*models.Video
+	videoCategories map[int]*models.VideoCategory
+	videoStats      map[int]*models.VideoStatsDelta // 视频的播放统计累计值，对应video_stats和video_retention
+	comments        map[int]*models.VideoComment
+	reviews         map[int64]*models.CourseReview
+	reviewReports   map[reviewReportKey]*models.ReviewReport
+	ratingTotals    map[int64]int // 课程显示中评价的星级之和，对应courses.rating_total
+	uploads         map[string]*models.Upload
+	images          map[int64]*models.Image
+	danmaku         map[int64]*models.Danmaku
+	subtitles       map[int64]*models.Subtitle
+	subtitleCues    map[int64][]models.SubtitleCue
+	playlists       map[int64]*models.Playlist
+	playlistVideos  map[int64][]playlistEntry // 按位置排列
+	videoProgress   map[progressKey]*models.VideoProgress
+}
+
+// New 创建一份空的内存数据
+func New() *repository.Repositories {
+	s := &store{
+		seq:             make(map[string]int64),
+		users:           make(map[int64]*models.User),
+		categories:      make(map[int64]*models.CourseCategory),
+		courses:         make(map[int64]*models.Course),
+		chapters:        make(map[int64]*models.Chapter),
+		lessons:         make(map[int64]*models.Lesson),
+		enrollments:     make(map[enrollmentKey]*models.UserCourse),
+		payments:        make(map[int64]*models.Payment),
+		posts:           make(map[int64]*models.Post),
+		videos:          make(map[int]*models.Video),
+		videoCategories: make(map[int]*models.VideoCategory),
+		videoStats:      make(map[int]*models.VideoStatsDelta),
+		comments:        make(map[int]*models.VideoComment),
+		reviews:         make(map[int64]*models.CourseReview),
+		reviewReports:   make(map[reviewReportKey]*models.ReviewReport),
+		ratingTotals:    make(map[int64]int),
+		uploads:         make(map[string]*models.Upload),
+		images:          make(map[int64]*models.Image),
+		danmaku:         make(map[int64]*models.Danmaku),
+		subtitles:       make(map[int64]*models.Subtitle),
+		subtitleCues:    make(map[int64][]models.SubtitleCue),
+		playlists:       make(map[int64]*models.Playlist),
+		playlistVideos:  make(map[int64][]playlistEntry),
+		videoProgress:   make(map[progressKey]*models.VideoProgress),
+	}
+	return &repository.Repositories{
+		Users:       &userRepository{s},
+		Categories:  &categoryRepository{s},
+		Courses:     &courseRepository{s},
+		Enrollments: &enrollmentRepository{s},
+		Payments:    &paymentRepository{s},
+		Posts:       &postRepository{s},
+		Videos:      &videoRepository{s},
+		Comments:    &commentRepository{s},
+		Reviews:     &reviewRepository{s},
+		Uploads:     &uploadRepository{s},
+		Images:      &imageRepository{s},
+		Danmaku:     &danmakuRepository{s},
+		Subtitles:   &subtitleRepository{s},
+		Playlists:   &playlistRepository{s},
+		Health:      s,
+	}
+}
+
+// Ping 内存存储始终可用，只检查上下文是否已结束
+func (s *store) Ping(ctx context.Context) error {
+	return ctx.Err()
+}
+
+// nextID 生成表的自增ID，调用方需持有写锁
+func (s *store) nextID(table string) int64 {
+	s.seq[table]++
+	return s.seq[table]
+}
+
+// newest 按创建时间倒序、ID倒序比较，与mysql实现的排序一致
+func newest(aTime, bTime time.Time, aID, bID int64) int {
+	if c := bTime.Compare(aTime); c != 0 {
+		return c
+	}
+	return cmp.Compare(bID, aID)
+}
+
+// page 返回offset和limit指定的一页数据
+func page[T any](items []T, offset, limit int) []T {
+	if offset < 0 {
+		offset = 0
+	}
+	if offset >= len(items) || limit <= 0 {
+		return nil
+	}
+	end := offset + limit
+	if end > len(items) {
+		end = len(items)
+	}
+	return items[offset:end]
+}
+
+// window 返回已排序记录中的一页：设置了游标时从第一条排在游标之后的记录开始，否则从偏移量开始，读取 Page.Limit 条
+// after只在设置了游标时调用
+func window[T any](items []T, p pagination.Page, after func(T) bool) []T {
+	if p.After == nil {
+		return page(items, p.Offset(), p.Limit())
+	}
+	start := slices.IndexFunc(items, after)
+	if start < 0 {
+		return nil
+	}
+	return page(items, start, p.Limit())
+}
+
+// afterNewest 判断按(created_at, id)倒序排列时记录是否排在游标之后
+func afterNewest(c *pagination.Cursor, createdAt time.Time, id int64) bool {
+	return newest(c.CreatedAt, createdAt, c.ID, id) < 0
+}
+
+// contains 不区分大小写的包含匹配，模拟MySQL默认排序规则下的LIKE
+func contains(s, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
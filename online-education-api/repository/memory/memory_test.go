@@ -0,0 +1,187 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"online-education-api/models"
+	"online-education-api/pagination"
+	"online-education-api/repository"
+)
+
+func TestPostsListNewestFirstWithFilters(t *testing.T) {
+	ctx := context.Background()
+	repos := New()
+
+	author := &models.User{Username: "alice", Avatar: "a.png"}
+	if err := repos.Users.Create(ctx, author); err != nil {
+		t.Fatal(err)
+	}
+	for i, title := range []string{"Go 入门", "Rust 入门", "go 并发"} {
+		post := &models.Post{UserID: author.ID, Title: title, Status: 1}
+		if i == 1 {
+			post.Status = 0
+		}
+		if err := repos.Posts.Create(ctx, post); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	filter := repository.PostFilter{PublishedOnly: true, Search: "GO"}
+	posts, err := repos.Posts.List(ctx, filter, pagination.Page{Number: 1, Size: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(posts) != 2 || posts[0].Title != "go 并发" || posts[1].Title != "Go 入门" {
+		t.Fatalf("unexpected posts %+v", posts)
+	}
+	if posts[0].UserName != "alice" || posts[0].Avatar != "a.png" {
+		t.Fatalf("expected author info, got %+v", posts[0])
+	}
+	if total, _ := repos.Posts.Count(ctx, filter); total != 2 {
+		t.Fatalf("expected 2 posts, got %d", total)
+	}
+
+	page, err := repos.Posts.List(ctx, repository.PostFilter{UserID: author.ID}, pagination.Page{Number: 2, Size: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page) != 1 || page[0].Title != "Go 入门" {
+		t.Fatalf("unexpected last page %+v", page)
+	}
+}
+
+func TestListAfterCursor(t *testing.T) {
+	ctx := context.Background()
+	repos := New()
+
+	var users []*models.User
+	for _, name := range []string{"alice", "bob", "carol"} {
+		user := &models.User{Username: name}
+		if err := repos.Users.Create(ctx, user); err != nil {
+			t.Fatal(err)
+		}
+		users = append(users, user)
+	}
+	got, err := repos.Users.List(ctx, pagination.Page{Size: 1, After: &pagination.Cursor{ID: users[0].ID}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 多读一条用于判断是否有下一页
+	if len(got) != 2 || got[0].Username != "bob" || got[1].Username != "carol" {
+		t.Fatalf("unexpected users after cursor %+v", got)
+	}
+
+	// 按(created_at, id)倒序排列，游标之后只返回排在游标记录之后的评论
+	video := &models.Video{Title: "Go"}
+	if err := repos.Videos.Create(ctx, video); err != nil {
+		t.Fatal(err)
+	}
+	for range 3 {
+		if err := repos.Comments.Create(ctx, &models.VideoComment{VideoID: video.ID, UserID: users[0].ID}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	all, _ := repos.Comments.List(ctx, video.ID, pagination.Page{Size: 10})
+	cursor := &pagination.Cursor{CreatedAt: all[1].CreatedAt, ID: int64(all[1].ID)}
+	rest, err := repos.Comments.List(ctx, video.ID, pagination.Page{Size: 10, After: cursor})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rest) != 1 || rest[0].ID != all[2].ID {
+		t.Fatalf("unexpected comments after cursor %+v", rest)
+	}
+}
+
+func TestReturnedRecordsAreCopies(t *testing.T) {
+	ctx := context.Background()
+	repos := New()
+
+	course := &models.Course{Title: "Go"}
+	if err := repos.Courses.Create(ctx, course); err != nil {
+		t.Fatal(err)
+	}
+	course.Title = "changed"
+
+	got, err := repos.Courses.GetByID(ctx, course.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got.StudentCount = 100
+	if again, _ := repos.Courses.GetByID(ctx, course.ID); again.Title != "Go" || again.StudentCount != 0 {
+		t.Fatalf("stored course was modified through a returned pointer: %+v", again)
+	}
+
+	if _, err := repos.Courses.GetByID(ctx, course.ID+1); !errors.Is(err, repository.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestImportCourseOrdersChaptersAndLessons(t *testing.T) {
+	ctx := context.Background()
+	repos := New()
+
+	course := &models.Course{Title: "Go"}
+	chapters := []*models.Chapter{
+		{Title: "第二章", SortOrder: 2, Lessons: []*models.Lesson{{Title: "2-2", SortOrder: 2}, {Title: "2-1", SortOrder: 1}}},
+		{Title: "第一章", SortOrder: 1, Lessons: []*models.Lesson{{Title: "1-1", SortOrder: 1}}},
+	}
+	if err := repos.Courses.Import(ctx, course, chapters); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := repos.Courses.Chapters(ctx, course.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0].Title != "第一章" || got[1].Lessons[0].Title != "2-1" || got[1].Lessons[0].ChapterID != got[1].ID {
+		t.Fatalf("unexpected chapters %+v", got)
+	}
+	if count, _ := repos.Courses.CountChapters(ctx, course.ID); count != 2 {
+		t.Fatalf("expected 2 chapters, got %d", count)
+	}
+}
+
+func TestReviewsAdjustCourseRating(t *testing.T) {
+	ctx := context.Background()
+	repos := New()
+
+	course := &models.Course{Title: "Go", Rating: 4.9}
+	if err := repos.Courses.Create(ctx, course); err != nil {
+		t.Fatal(err)
+	}
+	var reviews []*models.CourseReview
+	for i, rating := range []int{5, 4, 4} {
+		review := &models.CourseReview{CourseID: course.ID, UserID: int64(i + 1), Rating: rating}
+		if err := repos.Reviews.Create(ctx, review); err != nil {
+			t.Fatal(err)
+		}
+		reviews = append(reviews, review)
+	}
+	// 评分由评价计算，不保留创建课程时的初始值
+	if got, _ := repos.Courses.GetByID(ctx, course.ID); got.Rating != 4.33 || got.ReviewCount != 3 {
+		t.Fatalf("expected rating 4.33 from 3 reviews, got %v from %d", got.Rating, got.ReviewCount)
+	}
+	if err := repos.Reviews.Create(ctx, &models.CourseReview{CourseID: course.ID, UserID: 1, Rating: 1}); !errors.Is(err, repository.ErrDuplicate) {
+		t.Fatalf("expected ErrDuplicate, got %v", err)
+	}
+
+	// 隐藏的评价修改星级时不影响课程评分
+	if err := repos.Reviews.Moderate(ctx, reviews[0].ID, 0); err != nil {
+		t.Fatal(err)
+	}
+	reviews[0].Rating = 1
+	if err := repos.Reviews.Update(ctx, reviews[0]); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := repos.Courses.GetByID(ctx, course.ID); got.Rating != 4 || got.ReviewCount != 2 {
+		t.Fatalf("expected rating 4 from 2 reviews, got %v from %d", got.Rating, got.ReviewCount)
+	}
+	if err := repos.Reviews.Delete(ctx, reviews[0].ID); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := repos.Courses.GetByID(ctx, course.ID); got.ReviewCount != 2 {
+		t.Fatalf("expected deleting a hidden review to keep 2 reviews, got %d", got.ReviewCount)
+	}
+}
@@ -0,0 +1,136 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"time"
+
+	"online-education-api/models"
+	"online-education-api/pagination"
+	"online-education-api/repository"
+)
+
+// paymentRepository 支付记录数据访问
+type paymentRepository struct {
+	*store
+}
+
+func (r *paymentRepository) Create(ctx context.Context, payment *models.Payment) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	payment.ID = r.nextID("payments")
+	payment.CreatedAt = now
+	payment.UpdatedAt = now
+	copied := *payment
+	r.payments[payment.ID] = &copied
+	return nil
+}
+
+func (r *paymentRepository) GetByID(ctx context.Context, id int64) (*models.Payment, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	payment, ok := r.payments[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	copied := *payment
+	return &copied, nil
+}
+
+func (r *paymentRepository) GetByOrderID(ctx context.Context, orderID string) (*models.Payment, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if payment := r.byOrderID(orderID); payment != nil {
+		copied := *payment
+		return &copied, nil
+	}
+	return nil, repository.ErrNotFound
+}
+
+// byOrderID 按订单号查找，调用方需持有锁
+func (r *paymentRepository) byOrderID(orderID string) *models.Payment {
+	for _, payment := range r.payments {
+		if payment.OrderID == orderID {
+			return payment
+		}
+	}
+	return nil
+}
+
+func (r *paymentRepository) ListByUser(ctx context.Context, userID int64, p pagination.Page) ([]*models.PaymentResponse, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var payments []*models.PaymentResponse
+	for _, p := range r.payments {
+		if p.UserID != userID {
+			continue
+		}
+		payment := &models.PaymentResponse{
+			ID:            p.ID,
+			OrderID:       p.OrderID,
+			CourseID:      p.CourseID,
+			Amount:        p.Amount,
+			PaymentMethod: p.PaymentMethod,
+			Status:        p.Status,
+			CreatedAt:     p.CreatedAt,
+		}
+		if course, ok := r.courses[p.CourseID]; ok {
+			payment.CourseTitle = course.Title
+		}
+		payments = append(payments, payment)
+	}
+	slices.SortFunc(payments, func(a, b *models.PaymentResponse) int {
+		return newest(a.CreatedAt, b.CreatedAt, a.ID, b.ID)
+	})
+	return window(payments, p, func(payment *models.PaymentResponse) bool {
+		return afterNewest(p.After, payment.CreatedAt, payment.ID)
+	}), nil
+}
+
+func (r *paymentRepository) CountByUser(ctx context.Context, userID int64) (int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	total := 0
+	for _, payment := range r.payments {
+		if payment.UserID == userID {
+			total++
+		}
+	}
+	return total, nil
+}
+
+func (r *paymentRepository) ListByStatus(ctx context.Context, status string, createdBefore time.Time) ([]*models.Payment, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var payments []*models.Payment
+	for _, payment := range r.payments {
+		if payment.Status == status && payment.CreatedAt.Before(createdBefore) {
+			copied := *payment
+			payments = append(payments, &copied)
+		}
+	}
+	slices.SortFunc(payments, func(a, b *models.Payment) int { return cmp.Compare(a.ID, b.ID) })
+	return payments, nil
+}
+
+func (r *paymentRepository) UpdateStatus(ctx context.Context, orderID, transactionID, status string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	payment := r.byOrderID(orderID)
+	if payment == nil {
+		return repository.ErrNotFound
+	}
+	payment.Status = status
+	payment.TransactionID = transactionID
+	payment.UpdatedAt = time.Now()
+	return nil
+}
@@ -0,0 +1,231 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"online-education-api/models"
+	"online-education-api/pagination"
+	"online-education-api/repository"
+)
+
+// playlistEntry 播放列表中的一个视频，位置由在切片中的顺序决定
+type playlistEntry struct {
+	videoID int
+	addedAt time.Time
+}
+
+// playlistRepository 播放列表数据访问
+type playlistRepository struct{ *store }
+
+// copyPlaylist 返回播放列表的副本并设置视频数量，调用方需持有锁
+func (r *playlistRepository) copyPlaylist(p *models.Playlist) *models.Playlist {
+	copied := *p
+	copied.VideoCount = len(r.playlistVideos[p.ID])
+	return &copied
+}
+
+func (r *playlistRepository) Create(ctx context.Context, playlist *models.Playlist) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	playlist.ID = r.nextID("playlists")
+	playlist.CreatedAt = now
+	playlist.UpdatedAt = now
+	copied := *playlist
+	r.playlists[playlist.ID] = &copied
+	return nil
+}
+
+func (r *playlistRepository) GetByID(ctx context.Context, id int64) (*models.Playlist, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	playlist, ok := r.playlists[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return r.copyPlaylist(playlist), nil
+}
+
+// matchPlaylist 判断播放列表是否满足筛选条件
+func matchPlaylist(playlist *models.Playlist, filter repository.PlaylistFilter) bool {
+	return (filter.UserID == 0 || playlist.UserID == filter.UserID) &&
+		(filter.Visibility == "" || playlist.Visibility == filter.Visibility)
+}
+
+func (r *playlistRepository) List(ctx context.Context, filter repository.PlaylistFilter, p pagination.Page) ([]*models.Playlist, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var list []*models.Playlist
+	for _, playlist := range r.playlists {
+		if matchPlaylist(playlist, filter) {
+			list = append(list, r.copyPlaylist(playlist))
+		}
+	}
+	slices.SortFunc(list, func(a, b *models.Playlist) int {
+		return newest(a.CreatedAt, b.CreatedAt, a.ID, b.ID)
+	})
+	return window(list, p, func(playlist *models.Playlist) bool {
+		return afterNewest(p.After, playlist.CreatedAt, playlist.ID)
+	}), nil
+}
+
+func (r *playlistRepository) Count(ctx context.Context, filter repository.PlaylistFilter) (int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	count := 0
+	for _, playlist := range r.playlists {
+		if matchPlaylist(playlist, filter) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *playlistRepository) Update(ctx context.Context, playlist *models.Playlist) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.playlists[playlist.ID]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	stored.Title = playlist.Title
+	stored.Description = playlist.Description
+	stored.Visibility = playlist.Visibility
+	stored.UpdatedAt = time.Now()
+	playlist.UpdatedAt = stored.UpdatedAt
+	return nil
+}
+
+func (r *playlistRepository) Delete(ctx context.Context, id int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.playlists[id]; !ok {
+		return repository.ErrNotFound
+	}
+	delete(r.playlists, id)
+	delete(r.playlistVideos, id)
+	return nil
+}
+
+func (r *playlistRepository) Items(ctx context.Context, id int64) ([]*models.PlaylistItem, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var items []*models.PlaylistItem
+	for i, entry := range r.playlistVideos[id] {
+		v, ok := r.videos[entry.videoID]
+		if !ok {
+			continue
+		}
+		// 与mysql实现一致，只包含列表需要的字段
+		items = append(items, &models.PlaylistItem{
+			Position: i + 1,
+			AddedAt:  entry.addedAt,
+			Video: models.Video{
+				ID:            v.ID,
+				Title:         v.Title,
+				CoverImageURL: v.CoverImageURL,
+				Duration:      v.Duration,
+				AuthorID:      v.AuthorID,
+				Visibility:    v.Visibility,
+				Status:        v.Status,
+				CreatedAt:     v.CreatedAt,
+			},
+		})
+	}
+	return items, nil
+}
+
+// touch 更新播放列表的修改时间，调用方需持有写锁
+func (r *playlistRepository) touch(id int64) {
+	r.playlists[id].UpdatedAt = time.Now()
+}
+
+func (r *playlistRepository) AddVideo(ctx context.Context, id int64, videoID int, position int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.playlists[id]; !ok {
+		return repository.ErrNotFound
+	}
+	if _, ok := r.videos[videoID]; !ok {
+		return repository.ErrNotFound
+	}
+	entries := r.playlistVideos[id]
+	if slices.ContainsFunc(entries, func(e playlistEntry) bool { return e.videoID == videoID }) {
+		return repository.ErrDuplicate
+	}
+	index := len(entries)
+	if position > 0 && position <= len(entries) {
+		index = position - 1
+	}
+	r.playlistVideos[id] = slices.Insert(entries, index, playlistEntry{videoID: videoID, addedAt: time.Now()})
+	r.touch(id)
+	return nil
+}
+
+func (r *playlistRepository) RemoveVideo(ctx context.Context, id int64, videoID int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entries := r.playlistVideos[id]
+	index := slices.IndexFunc(entries, func(e playlistEntry) bool { return e.videoID == videoID })
+	if index < 0 {
+		return repository.ErrNotFound
+	}
+	r.playlistVideos[id] = slices.Delete(entries, index, index+1)
+	r.touch(id)
+	return nil
+}
+
+func (r *playlistRepository) Reorder(ctx context.Context, id int64, videoIDs []int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.playlists[id]; !ok {
+		return repository.ErrNotFound
+	}
+	addedAt := make(map[int]time.Time)
+	for _, entry := range r.playlistVideos[id] {
+		addedAt[entry.videoID] = entry.addedAt
+	}
+	entries := make([]playlistEntry, 0, len(videoIDs))
+	for _, videoID := range videoIDs {
+		if t, ok := addedAt[videoID]; ok {
+			entries = append(entries, playlistEntry{videoID: videoID, addedAt: t})
+		}
+	}
+	r.playlistVideos[id] = entries
+	r.touch(id)
+	return nil
+}
+
+func (r *playlistRepository) FindByVideo(ctx context.Context, videoID int, userID int64, visibility string) (*models.Playlist, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var found *models.Playlist
+	for _, playlist := range r.playlists {
+		if playlist.UserID != userID || (visibility != "" && playlist.Visibility != visibility) {
+			continue
+		}
+		if found != nil && found.ID < playlist.ID {
+			continue
+		}
+		if slices.ContainsFunc(r.playlistVideos[playlist.ID], func(e playlistEntry) bool { return e.videoID == videoID }) {
+			found = playlist
+		}
+	}
+	if found == nil {
+		return nil, repository.ErrNotFound
+	}
+	return r.copyPlaylist(found), nil
+}
@@ -0,0 +1,145 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"online-education-api/models"
+	"online-education-api/pagination"
+	"online-education-api/repository"
+)
+
+// postRepository 帖子数据访问
+type postRepository struct {
+	*store
+}
+
+// response 转换为带作者信息的帖子，调用方需持有读锁
+func (r *postRepository) response(p *models.Post) *models.PostResponse {
+	post := &models.PostResponse{
+		ID:           p.ID,
+		UserID:       p.UserID,
+		Title:        p.Title,
+		Content:      p.Content,
+		Category:     p.Category,
+		ViewCount:    p.ViewCount,
+		CommentCount: p.CommentCount,
+		LikeCount:    p.LikeCount,
+		Status:       p.Status,
+		CreatedAt:    p.CreatedAt,
+		UpdatedAt:    p.UpdatedAt,
+	}
+	if user, ok := r.users[p.UserID]; ok {
+		post.UserName = user.Username
+		post.Avatar = user.Avatar
+	}
+	return post
+}
+
+func matchPost(post *models.Post, filter repository.PostFilter) bool {
+	if filter.UserID > 0 && post.UserID != filter.UserID {
+		return false
+	}
+	if filter.PublishedOnly && post.Status != 1 {
+		return false
+	}
+	if filter.Search != "" && !contains(post.Title, filter.Search) && !contains(post.Content, filter.Search) {
+		return false
+	}
+	return true
+}
+
+func (r *postRepository) List(ctx context.Context, filter repository.PostFilter, p pagination.Page) ([]*models.PostResponse, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var posts []*models.PostResponse
+	for _, post := range r.posts {
+		if matchPost(post, filter) {
+			posts = append(posts, r.response(post))
+		}
+	}
+	slices.SortFunc(posts, func(a, b *models.PostResponse) int {
+		return newest(a.CreatedAt, b.CreatedAt, a.ID, b.ID)
+	})
+	return window(posts, p, func(post *models.PostResponse) bool {
+		return afterNewest(p.After, post.CreatedAt, post.ID)
+	}), nil
+}
+
+func (r *postRepository) Count(ctx context.Context, filter repository.PostFilter) (int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	total := 0
+	for _, post := range r.posts {
+		if matchPost(post, filter) {
+			total++
+		}
+	}
+	return total, nil
+}
+
+func (r *postRepository) GetByID(ctx context.Context, id int64) (*models.PostResponse, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	post, ok := r.posts[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return r.response(post), nil
+}
+
+func (r *postRepository) IncrementViews(ctx context.Context, id int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if post, ok := r.posts[id]; ok {
+		post.ViewCount++
+	}
+	return nil
+}
+
+func (r *postRepository) Create(ctx context.Context, post *models.Post) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	post.ID = r.nextID("posts")
+	post.CreatedAt = now
+	post.UpdatedAt = now
+	copied := *post
+	r.posts[post.ID] = &copied
+	return nil
+}
+
+func (r *postRepository) Update(ctx context.Context, post *models.Post) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.posts[post.ID]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	stored.Title = post.Title
+	stored.Content = post.Content
+	stored.Category = post.Category
+	stored.Status = post.Status
+	stored.UpdatedAt = time.Now()
+	return nil
+}
+
+func (r *postRepository) UpdateStatus(ctx context.Context, id int64, status int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	post, ok := r.posts[id]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	post.Status = status
+	post.UpdatedAt = time.Now()
+	return nil
+}
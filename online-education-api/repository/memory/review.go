@@ -0,0 +1,261 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"math"
+	"slices"
+	"time"
+
+	"online-education-api/models"
+	"online-education-api/pagination"
+	"online-education-api/repository"
+)
+
+// reviewReportKey 评价举报的唯一键
+type reviewReportKey struct {
+	reviewID, userID int64
+}
+
+// reviewRepository 课程评价数据访问
+type reviewRepository struct {
+	*store
+}
+
+// copyReview 复制评价，RepliedAt指向新的变量
+func copyReview(review *models.CourseReview) *models.CourseReview {
+	copied := *review
+	if review.RepliedAt != nil {
+		repliedAt := *review.RepliedAt
+		copied.RepliedAt = &repliedAt
+	}
+	return &copied
+}
+
+// match 判断评价是否满足筛选条件
+func (r *reviewRepository) match(review *models.CourseReview, filter repository.ReviewFilter) bool {
+	if filter.CourseID > 0 && review.CourseID != filter.CourseID {
+		return false
+	}
+	if filter.Rating > 0 && review.Rating != filter.Rating {
+		return false
+	}
+	if (filter.VisibleOnly || filter.Reported) && review.Status != 1 {
+		return false
+	}
+	if filter.Reported && review.ReportCount == 0 {
+		return false
+	}
+	return true
+}
+
+// compareReviews 按排序方式比较两条评价，与mysql实现的ORDER BY一致
+func compareReviews(sort repository.ReviewSort, a, b *models.CourseReviewResponse) int {
+	var c int
+	switch sort {
+	case repository.ReviewSortHighest:
+		c = cmp.Compare(b.Rating, a.Rating)
+	case repository.ReviewSortLowest:
+		c = cmp.Compare(a.Rating, b.Rating)
+	case repository.ReviewSortReports:
+		c = cmp.Compare(b.ReportCount, a.ReportCount)
+	}
+	return cmp.Or(c, newest(a.CreatedAt, b.CreatedAt, a.ID, b.ID))
+}
+
+func (r *reviewRepository) List(ctx context.Context, filter repository.ReviewFilter, sort repository.ReviewSort, p pagination.Page) ([]*models.CourseReviewResponse, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var reviews []*models.CourseReviewResponse
+	for _, review := range r.reviews {
+		if !r.match(review, filter) {
+			continue
+		}
+		item := &models.CourseReviewResponse{CourseReview: *copyReview(review)}
+		if user, ok := r.users[review.UserID]; ok {
+			item.UserName = user.Username
+			item.Avatar = user.Avatar
+		}
+		reviews = append(reviews, item)
+	}
+	slices.SortFunc(reviews, func(a, b *models.CourseReviewResponse) int {
+		return compareReviews(sort, a, b)
+	})
+	if sort != repository.ReviewSortNewest {
+		p.After = nil
+	}
+	return window(reviews, p, func(review *models.CourseReviewResponse) bool {
+		return afterNewest(p.After, review.CreatedAt, review.ID)
+	}), nil
+}
+
+func (r *reviewRepository) Count(ctx context.Context, filter repository.ReviewFilter) (int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	total := 0
+	for _, review := range r.reviews {
+		if r.match(review, filter) {
+			total++
+		}
+	}
+	return total, nil
+}
+
+func (r *reviewRepository) GetByID(ctx context.Context, id int64) (*models.CourseReview, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	review, ok := r.reviews[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return copyReview(review), nil
+}
+
+func (r *reviewRepository) GetByUser(ctx context.Context, courseID, userID int64) (*models.CourseReview, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, review := range r.reviews {
+		if review.CourseID == courseID && review.UserID == userID {
+			return copyReview(review), nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+// adjustCourseRating 调整课程的评价数和星级之和，并重新计算评分，调用方需持有写锁
+func (r *reviewRepository) adjustCourseRating(courseID int64, count, total int) {
+	course, ok := r.courses[courseID]
+	if !ok {
+		return
+	}
+	course.ReviewCount += count
+	r.ratingTotals[courseID] += total
+	course.Rating = 0
+	if course.ReviewCount > 0 {
+		// 与mysql实现的ROUND(rating_total / review_count, 2)一致
+		course.Rating = math.Round(float64(r.ratingTotals[courseID])/float64(course.ReviewCount)*100) / 100
+	}
+}
+
+func (r *reviewRepository) Create(ctx context.Context, review *models.CourseReview) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, stored := range r.reviews {
+		if stored.CourseID == review.CourseID && stored.UserID == review.UserID {
+			return repository.ErrDuplicate
+		}
+	}
+	now := time.Now()
+	review.ID = r.nextID("course_reviews")
+	review.Reply = ""
+	review.RepliedAt = nil
+	review.Status = 1
+	review.ReportCount = 0
+	review.CreatedAt = now
+	review.UpdatedAt = now
+	r.reviews[review.ID] = copyReview(review)
+	r.adjustCourseRating(review.CourseID, 1, review.Rating)
+	return nil
+}
+
+func (r *reviewRepository) Update(ctx context.Context, review *models.CourseReview) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.reviews[review.ID]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	if stored.Status == 1 {
+		r.adjustCourseRating(stored.CourseID, 0, review.Rating-stored.Rating)
+	}
+	stored.Rating = review.Rating
+	stored.Content = review.Content
+	stored.UpdatedAt = time.Now()
+	review.UpdatedAt = stored.UpdatedAt
+	return nil
+}
+
+func (r *reviewRepository) Delete(ctx context.Context, id int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.reviews[id]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	if stored.Status == 1 {
+		r.adjustCourseRating(stored.CourseID, -1, -stored.Rating)
+	}
+	delete(r.reviews, id)
+	for key := range r.reviewReports {
+		if key.reviewID == id {
+			delete(r.reviewReports, key)
+		}
+	}
+	return nil
+}
+
+func (r *reviewRepository) Reply(ctx context.Context, id int64, reply string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.reviews[id]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	now := time.Now()
+	stored.Reply = reply
+	stored.RepliedAt = &now
+	return nil
+}
+
+func (r *reviewRepository) Report(ctx context.Context, report *models.ReviewReport) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.reviews[report.ReviewID]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	key := reviewReportKey{report.ReviewID, report.UserID}
+	if _, ok := r.reviewReports[key]; ok {
+		return repository.ErrDuplicate
+	}
+	report.ID = r.nextID("course_review_reports")
+	report.CreatedAt = time.Now()
+	copied := *report
+	r.reviewReports[key] = &copied
+	stored.ReportCount++
+	return nil
+}
+
+func (r *reviewRepository) Moderate(ctx context.Context, id int64, status int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.reviews[id]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	switch {
+	case stored.Status == 1 && status != 1:
+		r.adjustCourseRating(stored.CourseID, -1, -stored.Rating)
+	case stored.Status != 1 && status == 1:
+		r.adjustCourseRating(stored.CourseID, 1, stored.Rating)
+	}
+	stored.Status = status
+	stored.ReportCount = 0
+	stored.UpdatedAt = time.Now()
+	for key := range r.reviewReports {
+		if key.reviewID == id {
+			delete(r.reviewReports, key)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,163 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"online-education-api/models"
+	"online-education-api/repository"
+)
+
+// subtitleRepository 字幕数据访问
+type subtitleRepository struct{ *store }
+
+// owns 判断轨道是否属于视频或课时
+func owns(subtitle *models.Subtitle, owner repository.SubtitleOwner) bool {
+	return subtitle.VideoID == owner.VideoID && subtitle.LessonID == owner.LessonID
+}
+
+// clearDefault 取消同一视频或课时中除id以外的默认轨道，调用方需持有写锁
+func (r *subtitleRepository) clearDefault(owner repository.SubtitleOwner, id int64) {
+	for _, subtitle := range r.subtitles {
+		if subtitle.ID != id && owns(subtitle, owner) {
+			subtitle.IsDefault = false
+		}
+	}
+}
+
+func (r *subtitleRepository) Save(ctx context.Context, subtitle *models.Subtitle, cues []models.SubtitleCue) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	owner := repository.SubtitleOwner{VideoID: subtitle.VideoID, LessonID: subtitle.LessonID}
+	now := time.Now()
+	subtitle.ID, subtitle.CreatedAt = 0, now
+	for _, existing := range r.subtitles {
+		if owns(existing, owner) && strings.EqualFold(existing.Language, subtitle.Language) {
+			subtitle.ID, subtitle.CreatedAt = existing.ID, existing.CreatedAt
+			break
+		}
+	}
+	if subtitle.ID == 0 {
+		subtitle.ID = r.nextID("subtitles")
+	}
+	subtitle.CueCount = len(cues)
+	subtitle.UpdatedAt = now
+
+	// 与mysql实现一样按毫秒保存时间
+	stored := make([]models.SubtitleCue, len(cues))
+	for i, cue := range cues {
+		stored[i] = models.SubtitleCue{Start: roundMS(cue.Start), End: roundMS(cue.End), Text: cue.Text}
+	}
+	copied := *subtitle
+	r.subtitles[subtitle.ID] = &copied
+	r.subtitleCues[subtitle.ID] = stored
+	if subtitle.IsDefault {
+		r.clearDefault(owner, subtitle.ID)
+	}
+	return nil
+}
+
+// roundMS 将秒数舍入到毫秒
+func roundMS(seconds float64) float64 {
+	return math.Round(seconds*1000) / 1000
+}
+
+func (r *subtitleRepository) GetByID(ctx context.Context, id int64) (*models.Subtitle, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	subtitle, ok := r.subtitles[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	copied := *subtitle
+	return &copied, nil
+}
+
+func (r *subtitleRepository) List(ctx context.Context, owner repository.SubtitleOwner) ([]*models.Subtitle, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var list []*models.Subtitle
+	for _, subtitle := range r.subtitles {
+		if owns(subtitle, owner) {
+			copied := *subtitle
+			list = append(list, &copied)
+		}
+	}
+	slices.SortFunc(list, func(a, b *models.Subtitle) int {
+		if a.IsDefault != b.IsDefault {
+			if a.IsDefault {
+				return -1
+			}
+			return 1
+		}
+		return cmp.Compare(a.Language, b.Language)
+	})
+	return list, nil
+}
+
+func (r *subtitleRepository) Cues(ctx context.Context, id int64) ([]models.SubtitleCue, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return slices.Clone(r.subtitleCues[id]), nil
+}
+
+func (r *subtitleRepository) Search(ctx context.Context, owner repository.SubtitleOwner, language, q string, limit int) ([]*models.SubtitleMatch, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var matches []*models.SubtitleMatch
+	for _, subtitle := range r.subtitles {
+		if !owns(subtitle, owner) || (language != "" && !strings.EqualFold(subtitle.Language, language)) {
+			continue
+		}
+		for _, cue := range r.subtitleCues[subtitle.ID] {
+			if contains(cue.Text, q) {
+				matches = append(matches, &models.SubtitleMatch{SubtitleID: subtitle.ID, Language: subtitle.Language, SubtitleCue: cue})
+			}
+		}
+	}
+	slices.SortFunc(matches, func(a, b *models.SubtitleMatch) int {
+		return cmp.Or(cmp.Compare(a.Start, b.Start), cmp.Compare(a.SubtitleID, b.SubtitleID))
+	})
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches, nil
+}
+
+func (r *subtitleRepository) Update(ctx context.Context, subtitle *models.Subtitle) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.subtitles[subtitle.ID]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	stored.Label = subtitle.Label
+	stored.IsDefault = subtitle.IsDefault
+	stored.UpdatedAt = time.Now()
+	if stored.IsDefault {
+		r.clearDefault(repository.SubtitleOwner{VideoID: stored.VideoID, LessonID: stored.LessonID}, stored.ID)
+	}
+	return nil
+}
+
+func (r *subtitleRepository) Delete(ctx context.Context, id int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.subtitles[id]; !ok {
+		return repository.ErrNotFound
+	}
+	delete(r.subtitles, id)
+	delete(r.subtitleCues, id)
+	return nil
+}
@@ -0,0 +1,108 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"online-education-api/models"
+	"online-education-api/repository"
+)
+
+// uploadRepository 视频上传数据访问
+type uploadRepository struct{ *store }
+
+func (r *uploadRepository) Create(ctx context.Context, upload *models.Upload) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.uploads[upload.ID]; ok {
+		return repository.ErrDuplicate
+	}
+	now := time.Now()
+	upload.CreatedAt = now
+	upload.UpdatedAt = now
+	copied := *upload
+	r.uploads[upload.ID] = &copied
+	return nil
+}
+
+func (r *uploadRepository) GetByID(ctx context.Context, id string) (*models.Upload, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	upload, ok := r.uploads[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	copied := *upload
+	return &copied, nil
+}
+
+func (r *uploadRepository) UpdateReceived(ctx context.Context, id string, received int64, mimeType string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	upload, ok := r.uploads[id]
+	if !ok || upload.Status != models.UploadStatusUploading {
+		return repository.ErrNotFound
+	}
+	upload.Received = received
+	upload.MimeType = mimeType
+	upload.UpdatedAt = time.Now()
+	return nil
+}
+
+func (r *uploadRepository) Complete(ctx context.Context, upload *models.Upload) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.uploads[upload.ID]
+	if !ok || stored.Status != models.UploadStatusUploading {
+		return repository.ErrNotFound
+	}
+	upload.Status = models.UploadStatusCompleted
+	upload.UpdatedAt = time.Now()
+	stored.Received = upload.Received
+	stored.MimeType = upload.MimeType
+	stored.Checksum = upload.Checksum
+	stored.StorageKey = upload.StorageKey
+	stored.Status = upload.Status
+	stored.UpdatedAt = upload.UpdatedAt
+	return nil
+}
+
+func (r *uploadRepository) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.uploads[id]; !ok {
+		return repository.ErrNotFound
+	}
+	delete(r.uploads, id)
+	for _, v := range r.videos {
+		if v.UploadID == id {
+			v.UploadID = ""
+		}
+	}
+	return nil
+}
+
+func (r *uploadRepository) ListExpired(ctx context.Context, before time.Time, limit int) ([]models.Upload, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var uploads []models.Upload
+	for _, u := range r.uploads {
+		if u.Status == models.UploadStatusUploading && u.UpdatedAt.Before(before) {
+			uploads = append(uploads, *u)
+		}
+	}
+	slices.SortFunc(uploads, func(a, b models.Upload) int {
+		return a.UpdatedAt.Compare(b.UpdatedAt)
+	})
+	if len(uploads) > limit {
+		uploads = uploads[:limit]
+	}
+	return uploads, nil
+}
@@ -0,0 +1,153 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"time"
+
+	"online-education-api/models"
+	"online-education-api/pagination"
+	"online-education-api/repository"
+)
+
+// userRepository 用户数据访问
+type userRepository struct {
+	*store
+}
+
+func (r *userRepository) GetByID(ctx context.Context, id int64) (*models.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	user, ok := r.users[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	copied := *user
+	return &copied, nil
+}
+
+func (r *userRepository) GetByUsername(ctx context.Context, username string) (*models.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, user := range r.users {
+		if user.Username == username {
+			copied := *user
+			return &copied, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *userRepository) UsernameExists(ctx context.Context, username string) (bool, error) {
+	_, err := r.GetByUsername(ctx, username)
+	return err == nil, nil
+}
+
+func (r *userRepository) EmailExists(ctx context.Context, email string) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, user := range r.users {
+		if user.Email == email {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *userRepository) List(ctx context.Context, p pagination.Page) ([]*models.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	users := make([]*models.User, 0, len(r.users))
+	for _, user := range r.users {
+		copied := *user
+		users = append(users, &copied)
+	}
+	slices.SortFunc(users, func(a, b *models.User) int { return cmp.Compare(a.ID, b.ID) })
+	return window(users, p, func(user *models.User) bool { return user.ID > p.After.ID }), nil
+}
+
+func (r *userRepository) Count(ctx context.Context) (int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return int64(len(r.users)), nil
+}
+
+func (r *userRepository) Create(ctx context.Context, user *models.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	user.ID = r.nextID("users")
+	user.CreatedAt = now
+	user.UpdatedAt = now
+	copied := *user
+	r.users[user.ID] = &copied
+	return nil
+}
+
+// update 在写锁内修改用户
+func (r *userRepository) update(id int64, fn func(user *models.User)) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[id]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	fn(user)
+	user.UpdatedAt = time.Now()
+	return nil
+}
+
+func (r *userRepository) UpdateProfile(ctx context.Context, id int64, profile *models.UserUpdateRequest) error {
+	return r.update(id, func(user *models.User) {
+		if profile.Nickname != "" {
+			user.Nickname = profile.Nickname
+		}
+		if profile.Avatar != "" {
+			user.Avatar = profile.Avatar
+		}
+		if profile.Bio != "" {
+			user.Bio = profile.Bio
+		}
+	})
+}
+
+func (r *userRepository) UpdatePassword(ctx context.Context, id int64, passwordHash string) error {
+	return r.update(id, func(user *models.User) { user.Password = passwordHash })
+}
+
+func (r *userRepository) UpdateRole(ctx context.Context, id int64, role string) error {
+	return r.update(id, func(user *models.User) { user.Role = role })
+}
+
+func (r *userRepository) UpdateStatus(ctx context.Context, id int64, status int) error {
+	return r.update(id, func(user *models.User) { user.Status = status })
+}
+
+func (r *userRepository) UpdateLastLogin(ctx context.Context, id int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// 与mysql实现一致，用户不存在时不报错
+	if user, ok := r.users[id]; ok {
+		user.LastLogin = time.Now()
+	}
+	return nil
+}
+
+func (r *userRepository) Delete(ctx context.Context, id int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.users[id]; !ok {
+		return repository.ErrNotFound
+	}
+	delete(r.users, id)
+	return nil
+}
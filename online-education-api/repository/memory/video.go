@@ -0,0 +1,438 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"math"
+	"slices"
+	"time"
+
+	"online-education-api/models"
+	"online-education-api/pagination"
+	"online-education-api/repository"
+)
+
+// videoRepository 视频和视频分类数据访问
+type videoRepository struct {
+	*store
+}
+
+func (r *videoRepository) Create(ctx context.Context, video *models.Video) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if video.UploadID != "" {
+		for _, v := range r.videos {
+			if v.UploadID == video.UploadID {
+				return repository.ErrDuplicate
+			}
+		}
+	}
+
+	now := time.Now()
+	if video.Status == "" {
+		video.Status = models.VideoStatusReady
+	}
+	video.ID = int(r.nextID("videos"))
+	video.CreatedAt = now
+	video.UpdatedAt = now
+	copied := *video
+	r.videos[video.ID] = &copied
+	return nil
+}
+
+// matchVideo 判断视频是否满足筛选条件
+func matchVideo(video *models.Video, filter repository.VideoFilter) bool {
+	return (len(filter.CategoryIDs) == 0 || slices.Contains(filter.CategoryIDs, video.CategoryID)) &&
+		(filter.AuthorID == 0 || video.AuthorID == filter.AuthorID) &&
+		(filter.Visibility == "" || video.Visibility == filter.Visibility)
+}
+
+func (r *videoRepository) List(ctx context.Context, filter repository.VideoFilter, p pagination.Page) ([]models.Video, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var videos []models.Video
+	for _, v := range r.videos {
+		if !matchVideo(v, filter) {
+			continue
+		}
+		// 与mysql实现一致，列表只包含基本信息
+		videos = append(videos, models.Video{
+			ID:            v.ID,
+			Title:         v.Title,
+			Description:   v.Description,
+			CoverImageURL: v.CoverImageURL,
+			Duration:      v.Duration,
+			AuthorID:      v.AuthorID,
+			CategoryID:    v.CategoryID,
+			Visibility:    v.Visibility,
+			Status:        v.Status,
+			CreatedAt:     v.CreatedAt,
+		})
+	}
+	slices.SortFunc(videos, func(a, b models.Video) int {
+		return newest(a.CreatedAt, b.CreatedAt, int64(a.ID), int64(b.ID))
+	})
+	return window(videos, p, func(v models.Video) bool {
+		return afterNewest(p.After, v.CreatedAt, int64(v.ID))
+	}), nil
+}
+
+func (r *videoRepository) Count(ctx context.Context, filter repository.VideoFilter) (int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	total := 0
+	for _, video := range r.videos {
+		if matchVideo(video, filter) {
+			total++
+		}
+	}
+	return total, nil
+}
+
+func (r *videoRepository) GetByID(ctx context.Context, id int) (*models.Video, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	video, ok := r.videos[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	copied := *video
+	copied.Renditions = slices.Clone(video.Renditions)
+	return &copied, nil
+}
+
+func (r *videoRepository) IDsByStatus(ctx context.Context, status string, limit int) ([]int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var ids []int
+	for _, video := range r.videos {
+		if video.Status == status {
+			ids = append(ids, video.ID)
+		}
+	}
+	slices.Sort(ids)
+	if len(ids) > limit {
+		ids = ids[:limit]
+	}
+	return ids, nil
+}
+
+func (r *videoRepository) UpdateStatus(ctx context.Context, id int, status, processingError string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	video, ok := r.videos[id]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	video.Status = status
+	video.ProcessingError = processingError
+	video.UpdatedAt = time.Now()
+	return nil
+}
+
+func (r *videoRepository) UpdateMedia(ctx context.Context, video *models.Video) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.videos[video.ID]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	now := time.Now()
+	stored.Duration = video.Duration
+	if stored.CoverImageURL == "" {
+		stored.CoverImageURL = video.CoverImageURL
+	}
+	stored.HLSURL = video.HLSURL
+	stored.ThumbnailsURL = video.ThumbnailsURL
+	stored.Renditions = slices.Clone(video.Renditions)
+	stored.Status = models.VideoStatusReady
+	stored.ProcessingError = ""
+	stored.UpdatedAt = now
+
+	video.CoverImageURL = stored.CoverImageURL
+	video.Status = models.VideoStatusReady
+	video.ProcessingError = ""
+	video.UpdatedAt = now
+	return nil
+}
+
+func (r *videoRepository) AddStats(ctx context.Context, deltas []*models.VideoStatsDelta) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, delta := range deltas {
+		video, ok := r.videos[delta.VideoID]
+		if !ok {
+			continue
+		}
+		video.ViewCount += delta.Views
+
+		stats, ok := r.videoStats[delta.VideoID]
+		if !ok {
+			stats = &models.VideoStatsDelta{VideoID: delta.VideoID}
+			r.videoStats[delta.VideoID] = stats
+		}
+		stats.Views += delta.Views
+		stats.WatchSeconds += delta.WatchSeconds
+		stats.Completions += delta.Completions
+		for i, viewers := range delta.Retention {
+			stats.Retention[i] += viewers
+		}
+	}
+	return nil
+}
+
+func (r *videoRepository) Stats(ctx context.Context, id int) (*models.VideoStats, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	stats := &models.VideoStats{VideoID: id, Retention: make([]models.RetentionPoint, models.RetentionBuckets)}
+	if total, ok := r.videoStats[id]; ok {
+		stats.Views = total.Views
+		stats.WatchSeconds = total.WatchSeconds
+		stats.Completions = total.Completions
+		for i, viewers := range total.Retention {
+			stats.Retention[i].Viewers = viewers
+		}
+	}
+	return stats, nil
+}
+
+// progressKey 观看进度的主键
+type progressKey struct {
+	userID  int64
+	videoID int
+}
+
+func (r *videoRepository) SaveProgress(ctx context.Context, progress []*models.VideoProgress) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, p := range progress {
+		if _, ok := r.videos[p.VideoID]; !ok {
+			continue
+		}
+		key := progressKey{p.UserID, p.VideoID}
+		completed := p.Completed
+		if stored, ok := r.videoProgress[key]; ok {
+			completed = completed || stored.Completed
+		}
+		copied := *p
+		copied.Position = math.Round(p.Position*1000) / 1000
+		copied.Completed = completed
+		r.videoProgress[key] = &copied
+	}
+	return nil
+}
+
+func (r *videoRepository) Progress(ctx context.Context, userID int64, videoIDs []int) (map[int]*models.VideoProgress, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	progress := make(map[int]*models.VideoProgress)
+	for _, videoID := range videoIDs {
+		if p, ok := r.videoProgress[progressKey{userID, videoID}]; ok {
+			copied := *p
+			progress[videoID] = &copied
+		}
+	}
+	return progress, nil
+}
+
+func (r *videoRepository) Update(ctx context.Context, video *models.Video) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.videos[video.ID]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	stored.Title = video.Title
+	stored.Description = video.Description
+	stored.CoverImageURL = video.CoverImageURL
+	stored.CategoryID = video.CategoryID
+	stored.Visibility = video.Visibility
+	stored.UpdatedAt = time.Now()
+	return nil
+}
+
+func (r *videoRepository) Delete(ctx context.Context, id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.videos[id]; !ok {
+		return repository.ErrNotFound
+	}
+	delete(r.videos, id)
+	delete(r.videoStats, id)
+	for danmakuID, danmaku := range r.danmaku {
+		if danmaku.VideoID == id {
+			delete(r.danmaku, danmakuID)
+		}
+	}
+	for playlistID, entries := range r.playlistVideos {
+		r.playlistVideos[playlistID] = slices.DeleteFunc(entries, func(e playlistEntry) bool { return e.videoID == id })
+	}
+	for key := range r.videoProgress {
+		if key.videoID == id {
+			delete(r.videoProgress, key)
+		}
+	}
+	for subtitleID, subtitle := range r.subtitles {
+		if subtitle.VideoID == id {
+			delete(r.subtitles, subtitleID)
+			delete(r.subtitleCues, subtitleID)
+		}
+	}
+	return nil
+}
+
+func (r *videoRepository) Categories(ctx context.Context) ([]models.VideoCategory, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	counts := make(map[int]int)
+	for _, video := range r.videos {
+		if video.Visibility == models.VideoVisibilityPublic {
+			counts[video.CategoryID]++
+		}
+	}
+	var categories []models.VideoCategory
+	for _, category := range r.videoCategories {
+		copied := *category
+		copied.VideoCount = counts[category.ID]
+		categories = append(categories, copied)
+	}
+	slices.SortFunc(categories, func(a, b models.VideoCategory) int {
+		return cmp.Or(cmp.Compare(a.SortOrder, b.SortOrder), cmp.Compare(a.ID, b.ID))
+	})
+	return categories, nil
+}
+
+func (r *videoRepository) CreateCategory(ctx context.Context, category *models.VideoCategory) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	category.ID = int(r.nextID("video_categories"))
+	category.CreatedAt = now
+	category.UpdatedAt = now
+	copied := *category
+	r.videoCategories[category.ID] = &copied
+	return nil
+}
+
+func (r *videoRepository) UpdateCategory(ctx context.Context, category *models.VideoCategory) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.videoCategories[category.ID]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	stored.Name = category.Name
+	stored.Description = category.Description
+	stored.ParentID = category.ParentID
+	stored.SortOrder = category.SortOrder
+	stored.UpdatedAt = time.Now()
+	category.CreatedAt = stored.CreatedAt
+	category.UpdatedAt = stored.UpdatedAt
+	return nil
+}
+
+func (r *videoRepository) DeleteCategory(ctx context.Context, id, reassignTo int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.videoCategories[id]; !ok {
+		return repository.ErrNotFound
+	}
+	for _, video := range r.videos {
+		if video.CategoryID == id {
+			video.CategoryID = reassignTo
+		}
+	}
+	delete(r.videoCategories, id)
+	return nil
+}
+
+// commentRepository 视频评论数据访问
+type commentRepository struct {
+	*store
+}
+
+func (r *commentRepository) Create(ctx context.Context, comment *models.VideoComment) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	comment.ID = int(r.nextID("video_comments"))
+	comment.CreatedAt = now
+	comment.UpdatedAt = now
+	copied := *comment
+	r.comments[comment.ID] = &copied
+	return nil
+}
+
+func (r *commentRepository) List(ctx context.Context, videoID int, p pagination.Page) ([]models.VideoCommentWithUserInfo, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var comments []models.VideoCommentWithUserInfo
+	for _, c := range r.comments {
+		if videoID > 0 && c.VideoID != videoID {
+			continue
+		}
+		// 与mysql实现的内连接一致，作者或视频已删除的评论不返回
+		user, ok := r.users[c.UserID]
+		if !ok {
+			continue
+		}
+		video, ok := r.videos[c.VideoID]
+		if !ok {
+			continue
+		}
+		comments = append(comments, models.VideoCommentWithUserInfo{
+			VideoComment: *c,
+			AuthorName:   user.Username,
+			VideoTitle:   video.Title,
+		})
+	}
+	slices.SortFunc(comments, func(a, b models.VideoCommentWithUserInfo) int {
+		return newest(a.CreatedAt, b.CreatedAt, int64(a.ID), int64(b.ID))
+	})
+	return window(comments, p, func(c models.VideoCommentWithUserInfo) bool {
+		return afterNewest(p.After, c.CreatedAt, int64(c.ID))
+	}), nil
+}
+
+func (r *commentRepository) Count(ctx context.Context, videoID int) (int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	total := 0
+	for _, comment := range r.comments {
+		if videoID == 0 || comment.VideoID == videoID {
+			total++
+		}
+	}
+	return total, nil
+}
+
+func (r *commentRepository) Delete(ctx context.Context, id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.comments[id]; !ok {
+		return repository.ErrNotFound
+	}
+	delete(r.comments, id)
+	return nil
+}
@@ -0,0 +1,437 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"online-education-api/models"
+	"online-education-api/repository"
+)
+
+// courseRepository 课程、章节和课时数据访问
+type courseRepository struct {
+	db *sql.DB
+}
+
+// courseWhere 根据筛选条件构建WHERE子句，列名使用c.前缀
+func courseWhere(filter repository.CourseFilter) (string, []interface{}) {
+	where := ` WHERE 1=1`
+	params := []interface{}{}
+
+	if len(filter.CategoryIDs) > 0 {
+		where += ` AND c.category_id IN (?` + strings.Repeat(`, ?`, len(filter.CategoryIDs)-1) + `)`
+		for _, id := range filter.CategoryIDs {
+			params = append(params, id)
+		}
+	}
+	if filter.Level > 0 {
+		where += ` AND c.level = ?`
+		params = append(params, filter.Level)
+	}
+	if filter.Search != "" {
+		where += ` AND (c.title LIKE ? ESCAPE '\\' OR c.description LIKE ? ESCAPE '\\')`
+		searchParam := likeContains(filter.Search)
+		params = append(params, searchParam, searchParam)
+	}
+	if filter.MinPrice != nil {
+		where += ` AND c.price >= ?`
+		params = append(params, *filter.MinPrice)
+	}
+	if filter.MaxPrice != nil {
+		where += ` AND c.price <= ?`
+		params = append(params, *filter.MaxPrice)
+	}
+	if filter.TeacherID > 0 {
+		where += ` AND c.teacher_id = ?`
+		params = append(params, filter.TeacherID)
+	}
+	if filter.MinRating > 0 {
+		where += ` AND c.rating >= ?`
+		params = append(params, filter.MinRating)
+	}
+	if filter.PublishedOnly {
+		if filter.DraftsOf > 0 {
+			where += ` AND (c.status = 1 OR c.teacher_id = ?)`
+			params = append(params, filter.DraftsOf)
+		} else {
+			where += ` AND c.status = 1`
+		}
+	}
+	return where, params
+}
+
+// courseOrderBy 排序方式对应的ORDER BY子句，未知的排序方式按最新发布
+func courseOrderBy(sort repository.CourseSort) string {
+	switch sort {
+	case repository.CourseSortPopular:
+		return ` ORDER BY c.student_count DESC, c.created_at DESC, c.id DESC`
+	case repository.CourseSortRating:
+		return ` ORDER BY c.rating DESC, c.created_at DESC, c.id DESC`
+	case repository.CourseSortPriceAsc:
+		return ` ORDER BY c.price ASC, c.created_at DESC, c.id DESC`
+	case repository.CourseSortPriceDesc:
+		return ` ORDER BY c.price DESC, c.created_at DESC, c.id DESC`
+	default:
+		return ` ORDER BY c.created_at DESC, c.id DESC`
+	}
+}
+
+func (r *courseRepository) List(ctx context.Context, filter repository.CourseFilter, sort repository.CourseSort, offset, limit int) ([]*models.CourseResponse, error) {
+	where, params := courseWhere(filter)
+	query := `SELECT c.id, c.title, c.description, c.cover_image, c.price, c.original_price, c.category_id, c.teacher_id, c.level, c.duration, c.student_count, c.rating, c.review_count, c.status, c.created_at, COALESCE(cc.name, ''), COALESCE(u.username, '') FROM courses c LEFT JOIN course_categories cc ON c.category_id = cc.id LEFT JOIN users u ON c.teacher_id = u.id` +
+		where + courseOrderBy(sort) + ` LIMIT ? OFFSET ?`
+	params = append(params, limit, offset)
+
+	rows, err := r.db.QueryContext(ctx, query, params...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var courses []*models.CourseResponse
+	for rows.Next() {
+		var course models.CourseResponse
+		if err := rows.Scan(&course.ID, &course.Title, &course.Description, &course.CoverImage, &course.Price, &course.OriginalPrice, &course.CategoryID, &course.TeacherID, &course.Level, &course.Duration, &course.StudentCount, &course.Rating, &course.ReviewCount, &course.Status, &course.CreatedAt, &course.CategoryName, &course.TeacherName); err != nil {
+			return nil, err
+		}
+		courses = append(courses, &course)
+	}
+	return courses, rows.Err()
+}
+
+func (r *courseRepository) Count(ctx context.Context, filter repository.CourseFilter) (int, error) {
+	where, params := courseWhere(filter)
+	var total int
+	err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM courses c`+where, params...).Scan(&total)
+	return total, err
+}
+
+// Facets 用三条GROUP BY查询分别统计分类、难度和价格区间
+func (r *courseRepository) Facets(ctx context.Context, filter repository.CourseFilter) (*models.CourseFacets, error) {
+	where, params := courseWhere(filter)
+
+	// 价格区间用CASE表达式分组，区间与 repository.CoursePriceRanges 一致
+	priceCase := `CASE`
+	var priceParams []interface{}
+	for _, pr := range repository.CoursePriceRanges {
+		if pr.Max == 0 {
+			priceCase += ` WHEN c.price >= ? THEN ?`
+			priceParams = append(priceParams, pr.Min, pr.Value)
+		} else {
+			priceCase += ` WHEN c.price >= ? AND c.price < ? THEN ?`
+			priceParams = append(priceParams, pr.Min, pr.Max, pr.Value)
+		}
+	}
+	priceCase += ` ELSE '' END`
+
+	facets := &models.CourseFacets{}
+	queries := []struct {
+		query  string
+		params []interface{}
+		target *[]models.FacetCount
+	}{
+		{`SELECT CAST(c.category_id AS CHAR), COALESCE(MAX(cc.name), ''), COUNT(*) FROM courses c LEFT JOIN course_categories cc ON c.category_id = cc.id` + where + ` GROUP BY c.category_id ORDER BY COUNT(*) DESC, c.category_id`, params, &facets.Categories},
+		{`SELECT CAST(c.level AS CHAR), '', COUNT(*) FROM courses c` + where + ` GROUP BY c.level ORDER BY COUNT(*) DESC, c.level`, params, &facets.Levels},
+		{`SELECT price_range, '', COUNT(*) FROM (SELECT ` + priceCase + ` AS price_range FROM courses c` + where + `) p GROUP BY price_range ORDER BY COUNT(*) DESC, price_range`, append(priceParams, params...), &facets.Prices},
+	}
+	for _, q := range queries {
+		counts, err := r.facetCounts(ctx, q.query, q.params)
+		if err != nil {
+			return nil, err
+		}
+		*q.target = counts
+	}
+	return facets, nil
+}
+
+// facetCounts 执行返回(取值, 名称, 数量)的分组查询
+func (r *courseRepository) facetCounts(ctx context.Context, query string, params []interface{}) ([]models.FacetCount, error) {
+	rows, err := r.db.QueryContext(ctx, query, params...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := []models.FacetCount{}
+	for rows.Next() {
+		var fc models.FacetCount
+		if err := rows.Scan(&fc.Value, &fc.Label, &fc.Count); err != nil {
+			return nil, err
+		}
+		counts = append(counts, fc)
+	}
+	return counts, rows.Err()
+}
+
+func (r *courseRepository) GetByID(ctx context.Context, id int64) (*models.Course, error) {
+	query := `SELECT id, title, description, cover_image, price, original_price, category_id, teacher_id, level, duration, student_count, rating, review_count, status, created_at FROM courses WHERE id = ?`
+
+	var course models.Course
+	err := r.db.QueryRowContext(ctx, query, id).Scan(&course.ID, &course.Title, &course.Description, &course.CoverImage, &course.Price, &course.OriginalPrice, &course.CategoryID, &course.TeacherID, &course.Level, &course.Duration, &course.StudentCount, &course.Rating, &course.ReviewCount, &course.Status, &course.CreatedAt)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return &course, nil
+}
+
+// Chapters 用两条查询取出课程的全部章节和课时，查询次数与章节数量无关
+func (r *courseRepository) Chapters(ctx context.Context, courseID int64) ([]*models.Chapter, error) {
+	query := `SELECT id, course_id, title, sort_order, created_at FROM chapters WHERE course_id = ? ORDER BY sort_order ASC, id ASC`
+	rows, err := r.db.QueryContext(ctx, query, courseID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var chapters []*models.Chapter
+	byID := make(map[int64]*models.Chapter)
+	for rows.Next() {
+		var chapter models.Chapter
+		if err := rows.Scan(&chapter.ID, &chapter.CourseID, &chapter.Title, &chapter.SortOrder, &chapter.CreatedAt); err != nil {
+			return nil, err
+		}
+		chapters = append(chapters, &chapter)
+		byID[chapter.ID] = &chapter
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	// 先关闭章节结果集再查询课时，避免同时占用两个连接
+	rows.Close()
+
+	if len(chapters) == 0 {
+		return chapters, nil
+	}
+
+	query = `SELECT l.id, l.chapter_id, l.title, l.video_url, l.duration, l.sort_order, l.free, l.created_at FROM lessons l JOIN chapters ch ON l.chapter_id = ch.id WHERE ch.course_id = ? ORDER BY l.sort_order ASC, l.id ASC`
+	lessonRows, err := r.db.QueryContext(ctx, query, courseID)
+	if err != nil {
+		return nil, err
+	}
+	defer lessonRows.Close()
+
+	for lessonRows.Next() {
+		var lesson models.Lesson
+		if err := lessonRows.Scan(&lesson.ID, &lesson.ChapterID, &lesson.Title, &lesson.VideoURL, &lesson.Duration, &lesson.SortOrder, &lesson.Free, &lesson.CreatedAt); err != nil {
+			return nil, err
+		}
+		if chapter, ok := byID[lesson.ChapterID]; ok {
+			chapter.Lessons = append(chapter.Lessons, &lesson)
+		}
+	}
+	return chapters, lessonRows.Err()
+}
+
+func (r *courseRepository) CountChapters(ctx context.Context, courseID int64) (int, error) {
+	var count int
+	err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM chapters WHERE course_id = ?`, courseID).Scan(&count)
+	return count, err
+}
+
+func (r *courseRepository) GetLesson(ctx context.Context, id int64) (*models.Lesson, int64, error) {
+	query := `SELECT l.id, l.chapter_id, l.title, l.video_url, l.duration, l.sort_order, l.free, l.created_at, ch.course_id FROM lessons l JOIN chapters ch ON l.chapter_id = ch.id WHERE l.id = ?`
+
+	var lesson models.Lesson
+	var courseID int64
+	err := r.db.QueryRowContext(ctx, query, id).Scan(&lesson.ID, &lesson.ChapterID, &lesson.Title, &lesson.VideoURL, &lesson.Duration, &lesson.SortOrder, &lesson.Free, &lesson.CreatedAt, &courseID)
+	if err != nil {
+		return nil, 0, notFound(err)
+	}
+	return &lesson, courseID, nil
+}
+
+func (r *courseRepository) Create(ctx context.Context, course *models.Course) error {
+	query := `INSERT INTO courses (title, description, cover_image, price, original_price, category_id, teacher_id, level, duration, student_count, rating, status, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	now := time.Now()
+
+	result, err := r.db.ExecContext(ctx, query, course.Title, course.Description, course.CoverImage, course.Price, course.OriginalPrice, course.CategoryID, course.TeacherID, course.Level, course.Duration, course.StudentCount, course.Rating, course.Status, now, now)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	course.ID = id
+	course.CreatedAt = now
+	course.UpdatedAt = now
+	return nil
+}
+
+func (r *courseRepository) Update(ctx context.Context, course *models.Course) error {
+	query := `UPDATE courses SET title = ?, description = ?, cover_image = ?, price = ?, original_price = ?, category_id = ?, level = ?, duration = ?, status = ?, updated_at = ? WHERE id = ?`
+	result, err := r.db.ExecContext(ctx, query, course.Title, course.Description, course.CoverImage, course.Price, course.OriginalPrice, course.CategoryID, course.Level, course.Duration, course.Status, time.Now(), course.ID)
+	if err != nil {
+		return err
+	}
+	return checkAffected(ctx, r.db, result, `SELECT EXISTS(SELECT 1 FROM courses WHERE id = ?)`, course.ID)
+}
+
+func (r *courseRepository) Delete(ctx context.Context, id int64) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM courses WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+	return checkAffected(ctx, r.db, result, "")
+}
+
+func (r *courseRepository) Import(ctx context.Context, course *models.Course, chapters []*models.Chapter) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("开启事务失败: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	query := `INSERT INTO courses (title, description, cover_image, price, original_price, category_id, teacher_id, level, duration, student_count, rating, status, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, 0, 0, ?, ?, ?)`
+	result, err := tx.ExecContext(ctx, query, course.Title, course.Description, course.CoverImage, course.Price, course.OriginalPrice, course.CategoryID, course.TeacherID, course.Level, course.Duration, course.Status, now, now)
+	if err != nil {
+		return fmt.Errorf("创建课程失败: %w", err)
+	}
+	if course.ID, err = result.LastInsertId(); err != nil {
+		return fmt.Errorf("获取课程ID失败: %w", err)
+	}
+	course.CreatedAt = now
+	course.UpdatedAt = now
+
+	for _, chapter := range chapters {
+		query = `INSERT INTO chapters (course_id, title, sort_order, created_at, updated_at) VALUES (?, ?, ?, ?, ?)`
+		result, err := tx.ExecContext(ctx, query, course.ID, chapter.Title, chapter.SortOrder, now, now)
+		if err != nil {
+			return fmt.Errorf("创建章节失败: %w", err)
+		}
+		if chapter.ID, err = result.LastInsertId(); err != nil {
+			return fmt.Errorf("获取章节ID失败: %w", err)
+		}
+		chapter.CourseID = course.ID
+
+		for _, lesson := range chapter.Lessons {
+			query = `INSERT INTO lessons (chapter_id, title, video_url, duration, sort_order, free, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
+			result, err := tx.ExecContext(ctx, query, chapter.ID, lesson.Title, lesson.VideoURL, lesson.Duration, lesson.SortOrder, lesson.Free, now, now)
+			if err != nil {
+				return fmt.Errorf("创建课时失败: %w", err)
+			}
+			if lesson.ID, err = result.LastInsertId(); err != nil {
+				return fmt.Errorf("获取课时ID失败: %w", err)
+			}
+			lesson.ChapterID = chapter.ID
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("提交事务失败: %w", err)
+	}
+	return nil
+}
+
+func (r *courseRepository) AddStudents(ctx context.Context, id int64, delta int) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE courses SET student_count = student_count + ? WHERE id = ?`, delta, id)
+	return err
+}
+
+// categoryRepository 课程分类数据访问
+type categoryRepository struct {
+	db *sql.DB
+}
+
+func scanCategory(row interface{ Scan(...interface{}) error }) (*models.CourseCategory, error) {
+	var category models.CourseCategory
+	var parentID sql.NullInt64
+	if err := row.Scan(&category.ID, &category.Name, &parentID, &category.SortOrder, &category.CreatedAt); err != nil {
+		return nil, err
+	}
+	if parentID.Valid {
+		category.ParentID = &parentID.Int64
+	}
+	return &category, nil
+}
+
+func (r *categoryRepository) GetByID(ctx context.Context, id int64) (*models.CourseCategory, error) {
+	query := `SELECT id, name, parent_id, sort_order, created_at FROM course_categories WHERE id = ?`
+	category, err := scanCategory(r.db.QueryRowContext(ctx, query, id))
+	return category, notFound(err)
+}
+
+func (r *categoryRepository) ListByParent(ctx context.Context, parentID *int64) ([]*models.CourseCategory, error) {
+	query := `SELECT id, name, parent_id, sort_order, created_at FROM course_categories WHERE parent_id IS NULL ORDER BY sort_order ASC, id ASC`
+	var args []interface{}
+	if parentID != nil {
+		query = `SELECT id, name, parent_id, sort_order, created_at FROM course_categories WHERE parent_id = ? ORDER BY sort_order ASC, id ASC`
+		args = append(args, *parentID)
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var categories []*models.CourseCategory
+	for rows.Next() {
+		category, err := scanCategory(rows)
+		if err != nil {
+			return nil, err
+		}
+		categories = append(categories, category)
+	}
+	return categories, rows.Err()
+}
+
+func (r *categoryRepository) ListAll(ctx context.Context) ([]*models.CourseCategory, error) {
+	query := `SELECT id, name, parent_id, sort_order, created_at FROM course_categories ORDER BY sort_order ASC, id ASC`
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var categories []*models.CourseCategory
+	for rows.Next() {
+		category, err := scanCategory(rows)
+		if err != nil {
+			return nil, err
+		}
+		categories = append(categories, category)
+	}
+	return categories, rows.Err()
+}
+
+func (r *categoryRepository) Create(ctx context.Context, category *models.CourseCategory) error {
+	query := `INSERT INTO course_categories (name, parent_id, sort_order, created_at, updated_at) VALUES (?, ?, ?, ?, ?)`
+	now := time.Now()
+
+	result, err := r.db.ExecContext(ctx, query, category.Name, category.ParentID, category.SortOrder, now, now)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	category.ID = id
+	category.CreatedAt = now
+	category.UpdatedAt = now
+	return nil
+}
+
+func (r *categoryRepository) Update(ctx context.Context, category *models.CourseCategory) error {
+	query := `UPDATE course_categories SET name = ?, parent_id = ?, sort_order = ?, updated_at = ? WHERE id = ?`
+	result, err := r.db.ExecContext(ctx, query, category.Name, category.ParentID, category.SortOrder, time.Now(), category.ID)
+	if err != nil {
+		return err
+	}
+	return checkAffected(ctx, r.db, result, `SELECT EXISTS(SELECT 1 FROM course_categories WHERE id = ?)`, category.ID)
+}
+
+func (r *categoryRepository) Delete(ctx context.Context, id int64) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM course_categories WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+	return checkAffected(ctx, r.db, result, "")
+}
@@ -0,0 +1,89 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"math"
+	"time"
+
+	"online-education-api/models"
+)
+
+// danmakuRepository 弹幕数据访问，播放位置按毫秒保存在offset_ms列
+type danmakuRepository struct {
+	db *sql.DB
+}
+
+const danmakuColumns = `id, video_id, user_id, content, offset_ms, color, mode, created_at`
+
+func scanDanmaku(row interface{ Scan(...interface{}) error }) (*models.Danmaku, error) {
+	var danmaku models.Danmaku
+	var offsetMS int64
+	err := row.Scan(&danmaku.ID, &danmaku.VideoID, &danmaku.UserID, &danmaku.Content, &offsetMS,
+		&danmaku.Color, &danmaku.Mode, &danmaku.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	danmaku.Offset = float64(offsetMS) / 1000
+	return &danmaku, nil
+}
+
+// offsetMS 将播放位置(秒)转换为毫秒
+func offsetMS(offset float64) int64 {
+	return int64(math.Round(offset * 1000))
+}
+
+func (r *danmakuRepository) Create(ctx context.Context, danmaku *models.Danmaku) error {
+	now := time.Now()
+	ms := offsetMS(danmaku.Offset)
+	result, err := r.db.ExecContext(ctx,
+		`INSERT INTO video_danmaku (video_id, user_id, content, offset_ms, color, mode, created_at) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		danmaku.VideoID, danmaku.UserID, danmaku.Content, ms, danmaku.Color, danmaku.Mode, now)
+	if err != nil {
+		return err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	danmaku.ID = id
+	danmaku.Offset = float64(ms) / 1000
+	danmaku.CreatedAt = now
+	return nil
+}
+
+func (r *danmakuRepository) GetByID(ctx context.Context, id int64) (*models.Danmaku, error) {
+	danmaku, err := scanDanmaku(r.db.QueryRowContext(ctx, `SELECT `+danmakuColumns+` FROM video_danmaku WHERE id = ?`, id))
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return danmaku, nil
+}
+
+func (r *danmakuRepository) ListRange(ctx context.Context, videoID int, from, to float64, limit int) ([]*models.Danmaku, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT `+danmakuColumns+` FROM video_danmaku WHERE video_id = ? AND offset_ms BETWEEN ? AND ? ORDER BY offset_ms, id LIMIT ?`,
+		videoID, offsetMS(from), offsetMS(to), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []*models.Danmaku
+	for rows.Next() {
+		danmaku, err := scanDanmaku(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, danmaku)
+	}
+	return list, rows.Err()
+}
+
+func (r *danmakuRepository) Delete(ctx context.Context, id int64) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM video_danmaku WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+	return checkAffected(ctx, r.db, result, "")
+}
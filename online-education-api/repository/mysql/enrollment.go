@@ -0,0 +1,75 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"online-education-api/models"
+)
+
+// enrollmentRepository 报名记录数据访问
+type enrollmentRepository struct {
+	db *sql.DB
+}
+
+func (r *enrollmentRepository) Get(ctx context.Context, userID, courseID int64) (*models.UserCourse, error) {
+	query := `SELECT id, user_id, course_id, COALESCE(order_id, ''), price, status, created_at FROM user_courses WHERE user_id = ? AND course_id = ?`
+
+	var enrollment models.UserCourse
+	err := r.db.QueryRowContext(ctx, query, userID, courseID).Scan(&enrollment.ID, &enrollment.UserID, &enrollment.CourseID, &enrollment.OrderID, &enrollment.Price, &enrollment.Status, &enrollment.CreatedAt)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return &enrollment, nil
+}
+
+func (r *enrollmentRepository) Save(ctx context.Context, enrollment *models.UserCourse) error {
+	// 取消报名后重新报名时复用原记录
+	query := `INSERT INTO user_courses (user_id, course_id, order_id, price, status, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?) ON DUPLICATE KEY UPDATE order_id = VALUES(order_id), price = VALUES(price), status = VALUES(status), updated_at = VALUES(updated_at)`
+	now := time.Now()
+
+	_, err := r.db.ExecContext(ctx, query, enrollment.UserID, enrollment.CourseID, enrollment.OrderID, enrollment.Price, enrollment.Status, now, now)
+	if err != nil {
+		return err
+	}
+	enrollment.UpdatedAt = now
+	return nil
+}
+
+func (r *enrollmentRepository) UpdateStatus(ctx context.Context, userID, courseID int64, status int) error {
+	query := `UPDATE user_courses SET status = ?, updated_at = ? WHERE user_id = ? AND course_id = ?`
+	result, err := r.db.ExecContext(ctx, query, status, time.Now(), userID, courseID)
+	if err != nil {
+		return err
+	}
+	return checkAffected(ctx, r.db, result, `SELECT EXISTS(SELECT 1 FROM user_courses WHERE user_id = ? AND course_id = ?)`, userID, courseID)
+}
+
+func (r *enrollmentRepository) ListActive(ctx context.Context, userID int64, offset, limit int) ([]*models.UserCourseResponse, error) {
+	query := `SELECT uc.id, uc.course_id, uc.price, uc.status, uc.created_at, COALESCE(c.title, ''), COALESCE(c.cover_image, ''), COALESCE(c.teacher_id, 0), COALESCE(c.level, 0), COALESCE(c.duration, 0) FROM user_courses uc LEFT JOIN courses c ON uc.course_id = c.id WHERE uc.user_id = ? AND uc.status = 1 ORDER BY uc.created_at DESC, uc.id DESC LIMIT ? OFFSET ?`
+	rows, err := r.db.QueryContext(ctx, query, userID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var enrollments []*models.UserCourseResponse
+	for rows.Next() {
+		var enrollment models.UserCourseResponse
+		var course models.Course
+		if err := rows.Scan(&enrollment.ID, &enrollment.CourseID, &enrollment.Price, &enrollment.Status, &enrollment.CreatedAt, &course.Title, &course.CoverImage, &course.TeacherID, &course.Level, &course.Duration); err != nil {
+			return nil, err
+		}
+		course.ID = enrollment.CourseID
+		enrollment.Course = &course
+		enrollments = append(enrollments, &enrollment)
+	}
+	return enrollments, rows.Err()
+}
+
+func (r *enrollmentRepository) CountActive(ctx context.Context, userID int64) (int, error) {
+	var total int
+	err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM user_courses WHERE user_id = ? AND status = 1`, userID).Scan(&total)
+	return total, err
+}
@@ -0,0 +1,193 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"online-education-api/models"
+	"online-education-api/repository"
+)
+
+// imageRepository 图片数据访问
+type imageRepository struct {
+	db *sql.DB
+}
+
+// imageEntityFields 实体类型对应的表和保存主图地址的列
+var imageEntityFields = map[string]struct{ table, column string }{
+	models.ImageEntityUser:   {"users", "avatar"},
+	models.ImageEntityCourse: {"courses", "cover_image"},
+	models.ImageEntityVideo:  {"videos", "cover_image_url"},
+}
+
+const imageColumns = `i.id, COALESCE(i.user_id, 0), i.file_name, i.file_path, i.file_size, i.mime_type,
+	COALESCE(i.width, 0), COALESCE(i.height, 0), COALESCE(i.alt_text, ''), COALESCE(i.entity_type, ''),
+	COALESCE(i.entity_id, 0), i.is_primary, i.created_at, i.updated_at`
+
+func scanImage(row interface{ Scan(...interface{}) error }) (*models.Image, error) {
+	var image models.Image
+	err := row.Scan(&image.ID, &image.UserID, &image.FileName, &image.FilePath, &image.FileSize, &image.MimeType,
+		&image.Width, &image.Height, &image.AltText, &image.EntityType,
+		&image.EntityID, &image.IsPrimary, &image.CreatedAt, &image.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &image, nil
+}
+
+// queryImages 执行查询并扫描全部图片
+func (r *imageRepository) queryImages(ctx context.Context, query string, args ...interface{}) ([]*models.Image, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var images []*models.Image
+	for rows.Next() {
+		image, err := scanImage(rows)
+		if err != nil {
+			return nil, err
+		}
+		images = append(images, image)
+	}
+	return images, rows.Err()
+}
+
+func (r *imageRepository) Create(ctx context.Context, image *models.Image) error {
+	query := `
+	INSERT INTO images (user_id, file_name, file_path, file_size, mime_type, width, height, alt_text, is_primary, created_at, updated_at)
+	VALUES (NULLIF(?, 0), ?, ?, ?, ?, ?, ?, NULLIF(?, ''), 0, ?, ?)
+	`
+	now := time.Now()
+	result, err := r.db.ExecContext(ctx, query,
+		image.UserID, image.FileName, image.FilePath, image.FileSize, image.MimeType,
+		image.Width, image.Height, image.AltText, now, now)
+	if err != nil {
+		return err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	image.ID = id
+	image.CreatedAt = now
+	image.UpdatedAt = now
+	return nil
+}
+
+func (r *imageRepository) GetByID(ctx context.Context, id int64) (*models.Image, error) {
+	image, err := scanImage(r.db.QueryRowContext(ctx, `SELECT `+imageColumns+` FROM images i WHERE i.id = ?`, id))
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return image, nil
+}
+
+func (r *imageRepository) ListByEntity(ctx context.Context, entityType string, entityID int64) ([]*models.Image, error) {
+	return r.queryImages(ctx,
+		`SELECT `+imageColumns+` FROM images i WHERE i.entity_type = ? AND i.entity_id = ? ORDER BY i.is_primary DESC, i.id`,
+		entityType, entityID)
+}
+
+func (r *imageRepository) UpdateAltText(ctx context.Context, id int64, altText string) error {
+	result, err := r.db.ExecContext(ctx,
+		`UPDATE images SET alt_text = NULLIF(?, ''), updated_at = ? WHERE id = ?`, altText, time.Now(), id)
+	if err != nil {
+		return err
+	}
+	return checkAffected(ctx, r.db, result, "SELECT EXISTS(SELECT 1 FROM images WHERE id = ?)", id)
+}
+
+func (r *imageRepository) Attach(ctx context.Context, image *models.Image, url string) error {
+	field, ok := imageEntityFields[image.EntityType]
+	if !ok {
+		return fmt.Errorf("未知的实体类型: %s", image.EntityType)
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("开启事务失败: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	if image.IsPrimary {
+		_, err := tx.ExecContext(ctx,
+			`UPDATE images SET is_primary = 0 WHERE entity_type = ? AND entity_id = ? AND is_primary = 1`,
+			image.EntityType, image.EntityID)
+		if err != nil {
+			return err
+		}
+	}
+	result, err := tx.ExecContext(ctx,
+		`UPDATE images SET entity_type = ?, entity_id = ?, is_primary = ?, updated_at = ? WHERE id = ?`,
+		image.EntityType, image.EntityID, image.IsPrimary, now, image.ID)
+	if err != nil {
+		return err
+	}
+	if affected, err := result.RowsAffected(); err != nil {
+		return fmt.Errorf("获取影响行数失败: %w", err)
+	} else if affected == 0 {
+		return repository.ErrNotFound
+	}
+
+	// 表名和列名来自imageEntityFields，不是用户输入
+	if image.IsPrimary {
+		_, err = tx.ExecContext(ctx, fmt.Sprintf("UPDATE %s SET %s = ? WHERE id = ?", field.table, field.column), url, image.EntityID)
+	} else {
+		_, err = tx.ExecContext(ctx, fmt.Sprintf("UPDATE %s SET %s = '' WHERE id = ? AND %s = ?", field.table, field.column, field.column), image.EntityID, url)
+	}
+	if err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("提交事务失败: %w", err)
+	}
+	image.UpdatedAt = now
+	return nil
+}
+
+func (r *imageRepository) Delete(ctx context.Context, image *models.Image, url string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("开启事务失败: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, "DELETE FROM images WHERE id = ?", image.ID)
+	if err != nil {
+		return err
+	}
+	if affected, err := result.RowsAffected(); err != nil {
+		return fmt.Errorf("获取影响行数失败: %w", err)
+	} else if affected == 0 {
+		return repository.ErrNotFound
+	}
+	if field, ok := imageEntityFields[image.EntityType]; ok {
+		query := fmt.Sprintf("UPDATE %s SET %s = '' WHERE id = ? AND %s = ?", field.table, field.column, field.column)
+		if _, err := tx.ExecContext(ctx, query, image.EntityID, url); err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("提交事务失败: %w", err)
+	}
+	return nil
+}
+
+func (r *imageRepository) ListOrphans(ctx context.Context, before time.Time, limit int) ([]*models.Image, error) {
+	query := `
+	SELECT ` + imageColumns + `
+	FROM images i
+	LEFT JOIN users u ON i.entity_type = 'user' AND u.id = i.entity_id
+	LEFT JOIN courses c ON i.entity_type = 'course' AND c.id = i.entity_id
+	LEFT JOIN videos v ON i.entity_type = 'video' AND v.id = i.entity_id
+	WHERE i.created_at < ? AND u.id IS NULL AND c.id IS NULL AND v.id IS NULL
+	ORDER BY i.id
+	LIMIT ?
+	`
+	return r.queryImages(ctx, query, before, limit)
+}
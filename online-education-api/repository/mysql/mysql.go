@@ -0,0 +1,108 @@
+// Package mysql 基于MySQL的数据访问实现
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	driver "github.com/go-sql-driver/mysql"
+
+	"online-education-api/pagination"
+	"online-education-api/repository"
+)
+
+// New 创建基于MySQL连接池的数据访问实现
+func New(db *sql.DB) *repository.Repositories {
+	return &repository.Repositories{
+		Users:       &userRepository{db: db},
+		Categories:  &categoryRepository{db: db},
+		Courses:     &courseRepository{db: db},
+		Enrollments: &enrollmentRepository{db: db},
+		Payments:    &paymentRepository{db: db},
+		Posts:       &postRepository{db: db},
+		Videos:      &videoRepository{db: db},
+		Comments:    &commentRepository{db: db},
+		Reviews:     &reviewRepository{db: db},
+		Uploads:     &uploadRepository{db: db},
+		Images:      &imageRepository{db: db},
+		Danmaku:     &danmakuRepository{db: db},
+		Subtitles:   &subtitleRepository{db: db},
+		Playlists:   &playlistRepository{db: db},
+		Health:      pinger{db: db},
+	}
+}
+
+// pinger 检查数据库连接池是否可用
+type pinger struct {
+	db *sql.DB
+}
+
+func (p pinger) Ping(ctx context.Context) error {
+	return p.db.PingContext(ctx)
+}
+
+// checkAffected 检查UPDATE或DELETE是否命中记录
+// 值未变化时MySQL也返回0行，此时用exists查询确认记录是否存在，exists为空时直接视为不存在
+func checkAffected(ctx context.Context, db *sql.DB, result sql.Result, exists string, args ...interface{}) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("获取影响行数失败: %w", err)
+	}
+	if affected > 0 {
+		return nil
+	}
+	if exists == "" {
+		return repository.ErrNotFound
+	}
+
+	var found bool
+	if err := db.QueryRowContext(ctx, exists, args...).Scan(&found); err != nil {
+		return err
+	}
+	if !found {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+// notFound 将sql.ErrNoRows转换为repository.ErrNotFound
+func notFound(err error) error {
+	if err == sql.ErrNoRows {
+		return repository.ErrNotFound
+	}
+	return err
+}
+
+// duplicate 将唯一键冲突(MySQL错误1062)转换为repository.ErrDuplicate
+func duplicate(err error) error {
+	var mysqlErr *driver.MySQLError
+	if errors.As(err, &mysqlErr) && mysqlErr.Number == 1062 {
+		return repository.ErrDuplicate
+	}
+	return err
+}
+
+// likeEscaper 转义LIKE中的通配符和转义符
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// likeContains 返回匹配包含s的LIKE模式，s按字面匹配，需要配合 ESCAPE '\\' 使用
+func likeContains(s string) string {
+	return "%" + likeEscaper.Replace(s) + "%"
+}
+
+// afterNewest 按(created_at, id)倒序的键集分页条件，返回排在游标之后的记录；未设置游标时返回空条件
+func afterNewest(page pagination.Page, createdAt, id string) (string, []interface{}) {
+	if page.After == nil {
+		return "", nil
+	}
+	c := page.After
+	return ` AND (` + createdAt + ` < ? OR (` + createdAt + ` = ? AND ` + id + ` < ?))`, []interface{}{c.CreatedAt, c.CreatedAt, c.ID}
+}
+
+// limitOffset 分页的LIMIT子句，多读一条用于判断是否有下一页；键集分页时OFFSET为0
+func limitOffset(page pagination.Page) (string, []interface{}) {
+	return ` LIMIT ? OFFSET ?`, []interface{}{page.Limit(), page.Offset()}
+}
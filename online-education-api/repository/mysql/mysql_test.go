@@ -0,0 +1,17 @@
+package mysql
+
+import "testing"
+
+func TestLikeContainsEscapesWildcards(t *testing.T) {
+	tests := map[string]string{
+		"goroutine": `%goroutine%`,
+		"%":         `%\%%`,
+		"50%_off":   `%50\%\_off%`,
+		`C:\go`:     `%C:\\go%`,
+	}
+	for in, want := range tests {
+		if got := likeContains(in); got != want {
+			t.Errorf("likeContains(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
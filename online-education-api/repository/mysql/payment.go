@@ -0,0 +1,109 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"online-education-api/models"
+	"online-education-api/pagination"
+)
+
+// paymentRepository 支付记录数据访问
+type paymentRepository struct {
+	db *sql.DB
+}
+
+const paymentColumns = `id, order_id, user_id, course_id, amount, payment_method, status, COALESCE(transaction_id, ''), created_at, updated_at`
+
+func scanPayment(row interface{ Scan(...interface{}) error }) (*models.Payment, error) {
+	var payment models.Payment
+	if err := row.Scan(&payment.ID, &payment.OrderID, &payment.UserID, &payment.CourseID, &payment.Amount, &payment.PaymentMethod, &payment.Status, &payment.TransactionID, &payment.CreatedAt, &payment.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &payment, nil
+}
+
+func (r *paymentRepository) Create(ctx context.Context, payment *models.Payment) error {
+	now := time.Now()
+	query := `INSERT INTO payments (order_id, user_id, course_id, amount, payment_method, status, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
+	result, err := r.db.ExecContext(ctx, query, payment.OrderID, payment.UserID, payment.CourseID, payment.Amount, payment.PaymentMethod, payment.Status, now, now)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	payment.ID = id
+	payment.CreatedAt = now
+	payment.UpdatedAt = now
+	return nil
+}
+
+func (r *paymentRepository) GetByID(ctx context.Context, id int64) (*models.Payment, error) {
+	payment, err := scanPayment(r.db.QueryRowContext(ctx, `SELECT `+paymentColumns+` FROM payments WHERE id = ?`, id))
+	return payment, notFound(err)
+}
+
+func (r *paymentRepository) GetByOrderID(ctx context.Context, orderID string) (*models.Payment, error) {
+	payment, err := scanPayment(r.db.QueryRowContext(ctx, `SELECT `+paymentColumns+` FROM payments WHERE order_id = ?`, orderID))
+	return payment, notFound(err)
+}
+
+func (r *paymentRepository) ListByUser(ctx context.Context, userID int64, page pagination.Page) ([]*models.PaymentResponse, error) {
+	after, afterArgs := afterNewest(page, "p.created_at", "p.id")
+	limit, limitArgs := limitOffset(page)
+	query := `SELECT p.id, p.order_id, p.course_id, p.amount, p.payment_method, p.status, p.created_at, COALESCE(c.title, '') FROM payments p LEFT JOIN courses c ON p.course_id = c.id WHERE p.user_id = ?` + after + ` ORDER BY p.created_at DESC, p.id DESC` + limit
+	args := append(append([]interface{}{userID}, afterArgs...), limitArgs...)
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var payments []*models.PaymentResponse
+	for rows.Next() {
+		var payment models.PaymentResponse
+		if err := rows.Scan(&payment.ID, &payment.OrderID, &payment.CourseID, &payment.Amount, &payment.PaymentMethod, &payment.Status, &payment.CreatedAt, &payment.CourseTitle); err != nil {
+			return nil, err
+		}
+		payments = append(payments, &payment)
+	}
+	return payments, rows.Err()
+}
+
+func (r *paymentRepository) CountByUser(ctx context.Context, userID int64) (int, error) {
+	var total int
+	err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM payments WHERE user_id = ?`, userID).Scan(&total)
+	return total, err
+}
+
+func (r *paymentRepository) ListByStatus(ctx context.Context, status string, createdBefore time.Time) ([]*models.Payment, error) {
+	query := `SELECT ` + paymentColumns + ` FROM payments WHERE status = ? AND created_at < ? ORDER BY id`
+	rows, err := r.db.QueryContext(ctx, query, status, createdBefore)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var payments []*models.Payment
+	for rows.Next() {
+		payment, err := scanPayment(rows)
+		if err != nil {
+			return nil, err
+		}
+		payments = append(payments, payment)
+	}
+	return payments, rows.Err()
+}
+
+func (r *paymentRepository) UpdateStatus(ctx context.Context, orderID, transactionID, status string) error {
+	query := `UPDATE payments SET status = ?, transaction_id = ?, updated_at = ? WHERE order_id = ?`
+	result, err := r.db.ExecContext(ctx, query, status, transactionID, time.Now(), orderID)
+	if err != nil {
+		return err
+	}
+	return checkAffected(ctx, r.db, result, `SELECT EXISTS(SELECT 1 FROM payments WHERE order_id = ?)`, orderID)
+}
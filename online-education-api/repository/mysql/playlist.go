@@ -0,0 +1,270 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"online-education-api/models"
+	"online-education-api/pagination"
+	"online-education-api/repository"
+)
+
+// playlistRepository 播放列表数据访问
+type playlistRepository struct {
+	db *sql.DB
+}
+
+// playlistColumns 播放列表的列，视频数量由子查询统计
+const playlistColumns = `p.id, p.user_id, p.title, p.description, p.visibility,
+	(SELECT COUNT(*) FROM playlist_videos c WHERE c.playlist_id = p.id), p.created_at, p.updated_at`
+
+func scanPlaylist(row interface{ Scan(...interface{}) error }) (*models.Playlist, error) {
+	var p models.Playlist
+	err := row.Scan(&p.ID, &p.UserID, &p.Title, &p.Description, &p.Visibility, &p.VideoCount, &p.CreatedAt, &p.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func playlistWhere(filter repository.PlaylistFilter) (string, []interface{}) {
+	where := ` WHERE 1=1`
+	var args []interface{}
+	if filter.UserID > 0 {
+		where += ` AND p.user_id = ?`
+		args = append(args, filter.UserID)
+	}
+	if filter.Visibility != "" {
+		where += ` AND p.visibility = ?`
+		args = append(args, filter.Visibility)
+	}
+	return where, args
+}
+
+func (r *playlistRepository) Create(ctx context.Context, playlist *models.Playlist) error {
+	now := time.Now()
+	result, err := r.db.ExecContext(ctx,
+		`INSERT INTO playlists (user_id, title, description, visibility, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?)`,
+		playlist.UserID, playlist.Title, playlist.Description, playlist.Visibility, now, now)
+	if err != nil {
+		return err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	playlist.ID = id
+	playlist.CreatedAt = now
+	playlist.UpdatedAt = now
+	return nil
+}
+
+func (r *playlistRepository) GetByID(ctx context.Context, id int64) (*models.Playlist, error) {
+	playlist, err := scanPlaylist(r.db.QueryRowContext(ctx, `SELECT `+playlistColumns+` FROM playlists p WHERE p.id = ?`, id))
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return playlist, nil
+}
+
+func (r *playlistRepository) List(ctx context.Context, filter repository.PlaylistFilter, page pagination.Page) ([]*models.Playlist, error) {
+	where, args := playlistWhere(filter)
+	after, afterArgs := afterNewest(page, "p.created_at", "p.id")
+	limit, limitArgs := limitOffset(page)
+	query := `SELECT ` + playlistColumns + ` FROM playlists p` + where + after + ` ORDER BY p.created_at DESC, p.id DESC` + limit
+	args = append(append(args, afterArgs...), limitArgs...)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []*models.Playlist
+	for rows.Next() {
+		playlist, err := scanPlaylist(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, playlist)
+	}
+	return list, rows.Err()
+}
+
+func (r *playlistRepository) Count(ctx context.Context, filter repository.PlaylistFilter) (int, error) {
+	where, args := playlistWhere(filter)
+	var total int
+	err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM playlists p`+where, args...).Scan(&total)
+	return total, err
+}
+
+func (r *playlistRepository) Update(ctx context.Context, playlist *models.Playlist) error {
+	now := time.Now()
+	result, err := r.db.ExecContext(ctx, `UPDATE playlists SET title = ?, description = ?, visibility = ?, updated_at = ? WHERE id = ?`,
+		playlist.Title, playlist.Description, playlist.Visibility, now, playlist.ID)
+	if err != nil {
+		return err
+	}
+	playlist.UpdatedAt = now
+	return checkAffected(ctx, r.db, result, `SELECT EXISTS(SELECT 1 FROM playlists WHERE id = ?)`, playlist.ID)
+}
+
+func (r *playlistRepository) Delete(ctx context.Context, id int64) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM playlists WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+	return checkAffected(ctx, r.db, result, "")
+}
+
+func (r *playlistRepository) Items(ctx context.Context, id int64) ([]*models.PlaylistItem, error) {
+	rows, err := r.db.QueryContext(ctx, `
+	SELECT pv.position, pv.added_at, v.id, v.title, COALESCE(v.cover_image_url, ''), v.duration,
+		COALESCE(v.author_id, 0), v.visibility, v.status, v.created_at
+	FROM playlist_videos pv JOIN videos v ON pv.video_id = v.id
+	WHERE pv.playlist_id = ?
+	ORDER BY pv.position`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []*models.PlaylistItem
+	for rows.Next() {
+		var item models.PlaylistItem
+		var position int
+		v := &item.Video
+		if err := rows.Scan(&position, &item.AddedAt, &v.ID, &v.Title, &v.CoverImageURL, &v.Duration,
+			&v.AuthorID, &v.Visibility, &v.Status, &v.CreatedAt); err != nil {
+			return nil, err
+		}
+		// 按顺序重新编号，跳过视频删除留下的空缺
+		item.Position = len(items) + 1
+		items = append(items, &item)
+	}
+	return items, rows.Err()
+}
+
+// lockPlaylist 锁住播放列表，串行化同一列表的位置修改，并返回最大的位置
+// 视频删除时级联删除的行会在位置中留下空缺，因此末尾的位置不一定等于视频数量
+func lockPlaylist(ctx context.Context, tx *sql.Tx, id int64) (int, error) {
+	var locked int64
+	if err := tx.QueryRowContext(ctx, `SELECT id FROM playlists WHERE id = ? FOR UPDATE`, id).Scan(&locked); err != nil {
+		return 0, notFound(err)
+	}
+	var last int
+	err := tx.QueryRowContext(ctx, `SELECT COALESCE(MAX(position), 0) FROM playlist_videos WHERE playlist_id = ?`, id).Scan(&last)
+	return last, err
+}
+
+// AddVideo 在事务中后移插入位置之后的视频并插入新视频
+func (r *playlistRepository) AddVideo(ctx context.Context, id int64, videoID int, position int) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("开启事务失败: %w", err)
+	}
+	defer tx.Rollback()
+
+	last, err := lockPlaylist(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+	var exists bool
+	if err := tx.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM playlist_videos WHERE playlist_id = ? AND video_id = ?)`, id, videoID).Scan(&exists); err != nil {
+		return err
+	}
+	if exists {
+		return repository.ErrDuplicate
+	}
+	if position <= 0 || position > last {
+		position = last + 1
+	} else if _, err := tx.ExecContext(ctx, `UPDATE playlist_videos SET position = position + 1 WHERE playlist_id = ? AND position >= ?`, id, position); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	result, err := tx.ExecContext(ctx,
+		`INSERT INTO playlist_videos (playlist_id, video_id, position, added_at) SELECT ?, id, ?, ? FROM videos WHERE id = ?`,
+		id, position, now, videoID)
+	if err != nil {
+		return duplicate(err)
+	}
+	if affected, err := result.RowsAffected(); err != nil {
+		return err
+	} else if affected == 0 {
+		return repository.ErrNotFound
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE playlists SET updated_at = ? WHERE id = ?`, now, id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// RemoveVideo 在事务中删除视频并前移之后的视频
+func (r *playlistRepository) RemoveVideo(ctx context.Context, id int64, videoID int) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("开启事务失败: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := lockPlaylist(ctx, tx, id); err != nil {
+		return err
+	}
+	var position int
+	err = tx.QueryRowContext(ctx, `SELECT position FROM playlist_videos WHERE playlist_id = ? AND video_id = ?`, id, videoID).Scan(&position)
+	if err != nil {
+		return notFound(err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM playlist_videos WHERE playlist_id = ? AND video_id = ?`, id, videoID); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE playlist_videos SET position = position - 1 WHERE playlist_id = ? AND position > ?`, id, position); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE playlists SET updated_at = ? WHERE id = ?`, time.Now(), id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Reorder 在事务中逐条更新位置，同时消除视频删除留下的空缺
+func (r *playlistRepository) Reorder(ctx context.Context, id int64, videoIDs []int) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("开启事务失败: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := lockPlaylist(ctx, tx, id); err != nil {
+		return err
+	}
+	for i, videoID := range videoIDs {
+		if _, err := tx.ExecContext(ctx, `UPDATE playlist_videos SET position = ? WHERE playlist_id = ? AND video_id = ?`, i+1, id, videoID); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE playlists SET updated_at = ? WHERE id = ?`, time.Now(), id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *playlistRepository) FindByVideo(ctx context.Context, videoID int, userID int64, visibility string) (*models.Playlist, error) {
+	query := `SELECT ` + playlistColumns + ` FROM playlists p JOIN playlist_videos pv ON pv.playlist_id = p.id
+	WHERE pv.video_id = ? AND p.user_id = ?`
+	args := []interface{}{videoID, userID}
+	if visibility != "" {
+		query += ` AND p.visibility = ?`
+		args = append(args, visibility)
+	}
+	query += ` ORDER BY p.id LIMIT 1`
+
+	playlist, err := scanPlaylist(r.db.QueryRowContext(ctx, query, args...))
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return playlist, nil
+}
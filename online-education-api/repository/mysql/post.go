@@ -0,0 +1,124 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"online-education-api/models"
+	"online-education-api/pagination"
+	"online-education-api/repository"
+)
+
+// postRepository 帖子数据访问
+type postRepository struct {
+	db *sql.DB
+}
+
+const postColumns = `p.id, p.user_id, p.title, p.content, COALESCE(p.category, ''), p.view_count, p.comment_count, p.like_count, p.status, p.created_at, p.updated_at, COALESCE(u.username, ''), COALESCE(u.avatar, '')`
+
+func scanPost(row interface{ Scan(...interface{}) error }) (*models.PostResponse, error) {
+	var post models.PostResponse
+	if err := row.Scan(&post.ID, &post.UserID, &post.Title, &post.Content, &post.Category, &post.ViewCount, &post.CommentCount, &post.LikeCount, &post.Status, &post.CreatedAt, &post.UpdatedAt, &post.UserName, &post.Avatar); err != nil {
+		return nil, err
+	}
+	return &post, nil
+}
+
+// postWhere 根据筛选条件构建WHERE子句，列名使用p.前缀
+func postWhere(filter repository.PostFilter) (string, []interface{}) {
+	where := ` WHERE 1=1`
+	params := []interface{}{}
+
+	if filter.UserID > 0 {
+		where += ` AND p.user_id = ?`
+		params = append(params, filter.UserID)
+	}
+	if filter.PublishedOnly {
+		where += ` AND p.status = 1`
+	}
+	if filter.Search != "" {
+		where += ` AND (p.title LIKE ? ESCAPE '\\' OR p.content LIKE ? ESCAPE '\\')`
+		searchParam := likeContains(filter.Search)
+		params = append(params, searchParam, searchParam)
+	}
+	return where, params
+}
+
+func (r *postRepository) List(ctx context.Context, filter repository.PostFilter, page pagination.Page) ([]*models.PostResponse, error) {
+	where, params := postWhere(filter)
+	after, afterParams := afterNewest(page, "p.created_at", "p.id")
+	limit, limitParams := limitOffset(page)
+	query := `SELECT ` + postColumns + ` FROM posts p LEFT JOIN users u ON p.user_id = u.id` + where + after + ` ORDER BY p.created_at DESC, p.id DESC` + limit
+	params = append(append(params, afterParams...), limitParams...)
+
+	rows, err := r.db.QueryContext(ctx, query, params...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var posts []*models.PostResponse
+	for rows.Next() {
+		post, err := scanPost(rows)
+		if err != nil {
+			return nil, err
+		}
+		posts = append(posts, post)
+	}
+	return posts, rows.Err()
+}
+
+func (r *postRepository) Count(ctx context.Context, filter repository.PostFilter) (int, error) {
+	where, params := postWhere(filter)
+	var total int
+	err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM posts p`+where, params...).Scan(&total)
+	return total, err
+}
+
+func (r *postRepository) GetByID(ctx context.Context, id int64) (*models.PostResponse, error) {
+	query := `SELECT ` + postColumns + ` FROM posts p LEFT JOIN users u ON p.user_id = u.id WHERE p.id = ?`
+	post, err := scanPost(r.db.QueryRowContext(ctx, query, id))
+	return post, notFound(err)
+}
+
+func (r *postRepository) IncrementViews(ctx context.Context, id int64) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE posts SET view_count = view_count + 1 WHERE id = ?`, id)
+	return err
+}
+
+func (r *postRepository) Create(ctx context.Context, post *models.Post) error {
+	query := `INSERT INTO posts (user_id, title, content, category, view_count, comment_count, like_count, status, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	now := time.Now()
+
+	result, err := r.db.ExecContext(ctx, query, post.UserID, post.Title, post.Content, post.Category, post.ViewCount, post.CommentCount, post.LikeCount, post.Status, now, now)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	post.ID = id
+	post.CreatedAt = now
+	post.UpdatedAt = now
+	return nil
+}
+
+func (r *postRepository) Update(ctx context.Context, post *models.Post) error {
+	query := `UPDATE posts SET title = ?, content = ?, category = ?, status = ?, updated_at = ? WHERE id = ?`
+	result, err := r.db.ExecContext(ctx, query, post.Title, post.Content, post.Category, post.Status, time.Now(), post.ID)
+	if err != nil {
+		return err
+	}
+	return checkAffected(ctx, r.db, result, `SELECT EXISTS(SELECT 1 FROM posts WHERE id = ?)`, post.ID)
+}
+
+func (r *postRepository) UpdateStatus(ctx context.Context, id int64, status int) error {
+	result, err := r.db.ExecContext(ctx, `UPDATE posts SET status = ?, updated_at = ? WHERE id = ?`, status, time.Now(), id)
+	if err != nil {
+		return err
+	}
+	return checkAffected(ctx, r.db, result, `SELECT EXISTS(SELECT 1 FROM posts WHERE id = ?)`, id)
+}
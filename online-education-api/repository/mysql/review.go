@@ -0,0 +1,296 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"online-education-api/models"
+	"online-education-api/pagination"
+	"online-education-api/repository"
+)
+
+// reviewRepository 课程评价数据访问
+type reviewRepository struct {
+	db *sql.DB
+}
+
+const reviewColumns = `r.id, r.course_id, r.user_id, r.rating, COALESCE(r.content, ''), COALESCE(r.reply, ''), r.replied_at, r.status, r.report_count, r.created_at, r.updated_at`
+
+func scanReview(row interface{ Scan(...interface{}) error }, extra ...interface{}) (*models.CourseReview, error) {
+	var review models.CourseReview
+	var repliedAt sql.NullTime
+	dest := append([]interface{}{&review.ID, &review.CourseID, &review.UserID, &review.Rating, &review.Content, &review.Reply, &repliedAt, &review.Status, &review.ReportCount, &review.CreatedAt, &review.UpdatedAt}, extra...)
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+	if repliedAt.Valid {
+		review.RepliedAt = &repliedAt.Time
+	}
+	return &review, nil
+}
+
+// reviewWhere 根据筛选条件构建WHERE子句，列名使用r.前缀
+func reviewWhere(filter repository.ReviewFilter) (string, []interface{}) {
+	where := ` WHERE 1=1`
+	params := []interface{}{}
+
+	if filter.CourseID > 0 {
+		where += ` AND r.course_id = ?`
+		params = append(params, filter.CourseID)
+	}
+	if filter.Rating > 0 {
+		where += ` AND r.rating = ?`
+		params = append(params, filter.Rating)
+	}
+	if filter.VisibleOnly || filter.Reported {
+		where += ` AND r.status = 1`
+	}
+	if filter.Reported {
+		where += ` AND r.report_count > 0`
+	}
+	return where, params
+}
+
+// reviewOrderBy 根据排序方式构建ORDER BY子句
+func reviewOrderBy(sort repository.ReviewSort) string {
+	switch sort {
+	case repository.ReviewSortHighest:
+		return ` ORDER BY r.rating DESC, r.created_at DESC, r.id DESC`
+	case repository.ReviewSortLowest:
+		return ` ORDER BY r.rating ASC, r.created_at DESC, r.id DESC`
+	case repository.ReviewSortReports:
+		return ` ORDER BY r.report_count DESC, r.created_at DESC, r.id DESC`
+	default:
+		return ` ORDER BY r.created_at DESC, r.id DESC`
+	}
+}
+
+func (r *reviewRepository) List(ctx context.Context, filter repository.ReviewFilter, sort repository.ReviewSort, page pagination.Page) ([]*models.CourseReviewResponse, error) {
+	where, params := reviewWhere(filter)
+	after, afterParams := "", []interface{}(nil)
+	if sort == repository.ReviewSortNewest {
+		after, afterParams = afterNewest(page, "r.created_at", "r.id")
+	}
+	limit, limitParams := limitOffset(page)
+	query := `SELECT ` + reviewColumns + `, COALESCE(u.username, ''), COALESCE(u.avatar, '') FROM course_reviews r LEFT JOIN users u ON r.user_id = u.id` + where + after + reviewOrderBy(sort) + limit
+	params = append(append(params, afterParams...), limitParams...)
+
+	rows, err := r.db.QueryContext(ctx, query, params...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var reviews []*models.CourseReviewResponse
+	for rows.Next() {
+		var item models.CourseReviewResponse
+		review, err := scanReview(rows, &item.UserName, &item.Avatar)
+		if err != nil {
+			return nil, err
+		}
+		item.CourseReview = *review
+		reviews = append(reviews, &item)
+	}
+	return reviews, rows.Err()
+}
+
+func (r *reviewRepository) Count(ctx context.Context, filter repository.ReviewFilter) (int, error) {
+	where, params := reviewWhere(filter)
+	var total int
+	err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM course_reviews r`+where, params...).Scan(&total)
+	return total, err
+}
+
+func (r *reviewRepository) GetByID(ctx context.Context, id int64) (*models.CourseReview, error) {
+	review, err := scanReview(r.db.QueryRowContext(ctx, `SELECT `+reviewColumns+` FROM course_reviews r WHERE r.id = ?`, id))
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return review, nil
+}
+
+func (r *reviewRepository) GetByUser(ctx context.Context, courseID, userID int64) (*models.CourseReview, error) {
+	review, err := scanReview(r.db.QueryRowContext(ctx, `SELECT `+reviewColumns+` FROM course_reviews r WHERE r.course_id = ? AND r.user_id = ?`, courseID, userID))
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return review, nil
+}
+
+// adjustCourseRating 在事务中调整课程的评价数和星级之和，并重新计算评分
+// MySQL按顺序执行单表UPDATE的赋值，计算rating时使用的是更新后的review_count和rating_total
+func adjustCourseRating(ctx context.Context, tx *sql.Tx, courseID int64, count, total int) error {
+	if count == 0 && total == 0 {
+		return nil
+	}
+	query := `UPDATE courses SET review_count = review_count + ?, rating_total = rating_total + ?, rating = IF(review_count > 0, ROUND(rating_total / review_count, 2), 0) WHERE id = ?`
+	if _, err := tx.ExecContext(ctx, query, count, total, courseID); err != nil {
+		return fmt.Errorf("更新课程评分失败: %w", err)
+	}
+	return nil
+}
+
+// lockReview 在事务中锁定评价，返回课程ID、星级和显示状态
+func lockReview(ctx context.Context, tx *sql.Tx, id int64) (courseID int64, rating, status int, err error) {
+	err = tx.QueryRowContext(ctx, `SELECT course_id, rating, status FROM course_reviews WHERE id = ? FOR UPDATE`, id).Scan(&courseID, &rating, &status)
+	return courseID, rating, status, notFound(err)
+}
+
+func (r *reviewRepository) Create(ctx context.Context, review *models.CourseReview) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("开启事务失败: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	query := `INSERT INTO course_reviews (course_id, user_id, rating, content, status, report_count, created_at, updated_at) VALUES (?, ?, ?, ?, 1, 0, ?, ?)`
+	result, err := tx.ExecContext(ctx, query, review.CourseID, review.UserID, review.Rating, review.Content, now, now)
+	if err != nil {
+		return duplicate(err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	if err := adjustCourseRating(ctx, tx, review.CourseID, 1, review.Rating); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("提交事务失败: %w", err)
+	}
+
+	review.ID = id
+	review.Status = 1
+	review.ReportCount = 0
+	review.CreatedAt = now
+	review.UpdatedAt = now
+	return nil
+}
+
+func (r *reviewRepository) Update(ctx context.Context, review *models.CourseReview) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("开启事务失败: %w", err)
+	}
+	defer tx.Rollback()
+
+	courseID, oldRating, status, err := lockReview(ctx, tx, review.ID)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	if _, err := tx.ExecContext(ctx, `UPDATE course_reviews SET rating = ?, content = ?, updated_at = ? WHERE id = ?`, review.Rating, review.Content, now, review.ID); err != nil {
+		return err
+	}
+	if status == 1 {
+		if err := adjustCourseRating(ctx, tx, courseID, 0, review.Rating-oldRating); err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("提交事务失败: %w", err)
+	}
+	review.UpdatedAt = now
+	return nil
+}
+
+func (r *reviewRepository) Delete(ctx context.Context, id int64) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("开启事务失败: %w", err)
+	}
+	defer tx.Rollback()
+
+	courseID, rating, status, err := lockReview(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM course_reviews WHERE id = ?`, id); err != nil {
+		return err
+	}
+	if status == 1 {
+		if err := adjustCourseRating(ctx, tx, courseID, -1, -rating); err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("提交事务失败: %w", err)
+	}
+	return nil
+}
+
+func (r *reviewRepository) Reply(ctx context.Context, id int64, reply string) error {
+	now := time.Now()
+	result, err := r.db.ExecContext(ctx, `UPDATE course_reviews SET reply = ?, replied_at = ? WHERE id = ?`, reply, now, id)
+	if err != nil {
+		return err
+	}
+	return checkAffected(ctx, r.db, result, `SELECT EXISTS(SELECT 1 FROM course_reviews WHERE id = ?)`, id)
+}
+
+func (r *reviewRepository) Report(ctx context.Context, report *models.ReviewReport) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("开启事务失败: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, _, _, err := lockReview(ctx, tx, report.ReviewID); err != nil {
+		return err
+	}
+	now := time.Now()
+	result, err := tx.ExecContext(ctx, `INSERT INTO course_review_reports (review_id, user_id, reason, created_at) VALUES (?, ?, ?, ?)`, report.ReviewID, report.UserID, report.Reason, now)
+	if err != nil {
+		return duplicate(err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE course_reviews SET report_count = report_count + 1 WHERE id = ?`, report.ReviewID); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("提交事务失败: %w", err)
+	}
+
+	report.ID = id
+	report.CreatedAt = now
+	return nil
+}
+
+func (r *reviewRepository) Moderate(ctx context.Context, id int64, status int) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("开启事务失败: %w", err)
+	}
+	defer tx.Rollback()
+
+	courseID, rating, oldStatus, err := lockReview(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE course_reviews SET status = ?, report_count = 0, updated_at = ? WHERE id = ?`, status, time.Now(), id); err != nil {
+		return err
+	}
+	// 举报记录与report_count保持一致，否则已举报过的用户无法再次举报
+	if _, err := tx.ExecContext(ctx, `DELETE FROM course_review_reports WHERE review_id = ?`, id); err != nil {
+		return err
+	}
+	switch {
+	case oldStatus == 1 && status != 1:
+		err = adjustCourseRating(ctx, tx, courseID, -1, -rating)
+	case oldStatus != 1 && status == 1:
+		err = adjustCourseRating(ctx, tx, courseID, 1, rating)
+	}
+	if err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("提交事务失败: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,220 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"online-education-api/models"
+	"online-education-api/repository"
+)
+
+// subtitleRepository 字幕数据访问，字幕条的时间按毫秒保存在start_ms和end_ms列
+type subtitleRepository struct {
+	db *sql.DB
+}
+
+// subtitleCueBatch 每条INSERT语句写入的字幕条数量，避免超过max_allowed_packet
+const subtitleCueBatch = 500
+
+const subtitleColumns = `id, video_id, lesson_id, language, label, is_default, cue_count, created_at, updated_at`
+
+func scanSubtitle(row interface{ Scan(...interface{}) error }) (*models.Subtitle, error) {
+	var subtitle models.Subtitle
+	var videoID, lessonID sql.NullInt64
+	err := row.Scan(&subtitle.ID, &videoID, &lessonID, &subtitle.Language, &subtitle.Label,
+		&subtitle.IsDefault, &subtitle.CueCount, &subtitle.CreatedAt, &subtitle.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	subtitle.VideoID = int(videoID.Int64)
+	subtitle.LessonID = lessonID.Int64
+	return &subtitle, nil
+}
+
+// ownerCondition 返回筛选视频或课时的条件
+func ownerCondition(owner repository.SubtitleOwner) (string, interface{}) {
+	if owner.VideoID != 0 {
+		return "video_id = ?", owner.VideoID
+	}
+	return "lesson_id = ?", owner.LessonID
+}
+
+// nullID 将0转换为NULL
+func nullID(id int64) sql.NullInt64 {
+	return sql.NullInt64{Int64: id, Valid: id != 0}
+}
+
+// Save 在事务中保存轨道，替换全部字幕条并维护默认标记
+func (r *subtitleRepository) Save(ctx context.Context, subtitle *models.Subtitle, cues []models.SubtitleCue) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("开启事务失败: %w", err)
+	}
+	defer tx.Rollback()
+
+	owner, ownerID := ownerCondition(repository.SubtitleOwner{VideoID: subtitle.VideoID, LessonID: subtitle.LessonID})
+	now := time.Now()
+	subtitle.CueCount = len(cues)
+	subtitle.UpdatedAt = now
+
+	err = tx.QueryRowContext(ctx, `SELECT id, created_at FROM subtitles WHERE `+owner+` AND language = ? FOR UPDATE`,
+		ownerID, subtitle.Language).Scan(&subtitle.ID, &subtitle.CreatedAt)
+	switch {
+	case err == sql.ErrNoRows:
+		result, err := tx.ExecContext(ctx,
+			`INSERT INTO subtitles (video_id, lesson_id, language, label, is_default, cue_count, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+			nullID(int64(subtitle.VideoID)), nullID(subtitle.LessonID), subtitle.Language, subtitle.Label,
+			subtitle.IsDefault, subtitle.CueCount, now, now)
+		if err != nil {
+			return duplicate(err)
+		}
+		if subtitle.ID, err = result.LastInsertId(); err != nil {
+			return err
+		}
+		subtitle.CreatedAt = now
+	case err != nil:
+		return err
+	default:
+		if _, err := tx.ExecContext(ctx, `UPDATE subtitles SET label = ?, is_default = ?, cue_count = ?, updated_at = ? WHERE id = ?`,
+			subtitle.Label, subtitle.IsDefault, subtitle.CueCount, now, subtitle.ID); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `DELETE FROM subtitle_cues WHERE subtitle_id = ?`, subtitle.ID); err != nil {
+			return err
+		}
+	}
+
+	if subtitle.IsDefault {
+		if _, err := tx.ExecContext(ctx, `UPDATE subtitles SET is_default = 0 WHERE `+owner+` AND id <> ?`, ownerID, subtitle.ID); err != nil {
+			return err
+		}
+	}
+
+	for start := 0; start < len(cues); start += subtitleCueBatch {
+		batch := cues[start:min(start+subtitleCueBatch, len(cues))]
+		placeholders := make([]string, len(batch))
+		args := make([]interface{}, 0, len(batch)*5)
+		for i, cue := range batch {
+			placeholders[i] = "(?, ?, ?, ?, ?)"
+			args = append(args, subtitle.ID, start+i, offsetMS(cue.Start), offsetMS(cue.End), cue.Text)
+		}
+		if _, err := tx.ExecContext(ctx, `INSERT INTO subtitle_cues (subtitle_id, seq, start_ms, end_ms, text) VALUES `+strings.Join(placeholders, ", "), args...); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (r *subtitleRepository) GetByID(ctx context.Context, id int64) (*models.Subtitle, error) {
+	subtitle, err := scanSubtitle(r.db.QueryRowContext(ctx, `SELECT `+subtitleColumns+` FROM subtitles WHERE id = ?`, id))
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return subtitle, nil
+}
+
+func (r *subtitleRepository) List(ctx context.Context, owner repository.SubtitleOwner) ([]*models.Subtitle, error) {
+	condition, ownerID := ownerCondition(owner)
+	rows, err := r.db.QueryContext(ctx, `SELECT `+subtitleColumns+` FROM subtitles WHERE `+condition+` ORDER BY is_default DESC, language`, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []*models.Subtitle
+	for rows.Next() {
+		subtitle, err := scanSubtitle(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, subtitle)
+	}
+	return list, rows.Err()
+}
+
+func (r *subtitleRepository) Cues(ctx context.Context, id int64) ([]models.SubtitleCue, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT start_ms, end_ms, text FROM subtitle_cues WHERE subtitle_id = ? ORDER BY seq`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var cues []models.SubtitleCue
+	for rows.Next() {
+		var startMS, endMS int64
+		var cue models.SubtitleCue
+		if err := rows.Scan(&startMS, &endMS, &cue.Text); err != nil {
+			return nil, err
+		}
+		cue.Start, cue.End = float64(startMS)/1000, float64(endMS)/1000
+		cues = append(cues, cue)
+	}
+	return cues, rows.Err()
+}
+
+func (r *subtitleRepository) Search(ctx context.Context, owner repository.SubtitleOwner, language, q string, limit int) ([]*models.SubtitleMatch, error) {
+	condition, ownerID := ownerCondition(owner)
+	query := `SELECT s.id, s.language, c.start_ms, c.end_ms, c.text FROM subtitle_cues c JOIN subtitles s ON c.subtitle_id = s.id WHERE s.` + condition + ` AND c.text LIKE ? ESCAPE '\\'`
+	args := []interface{}{ownerID, likeContains(q)}
+	if language != "" {
+		query += ` AND s.language = ?`
+		args = append(args, language)
+	}
+	query += ` ORDER BY c.start_ms, s.id LIMIT ?`
+	args = append(args, limit)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var matches []*models.SubtitleMatch
+	for rows.Next() {
+		var startMS, endMS int64
+		var match models.SubtitleMatch
+		if err := rows.Scan(&match.SubtitleID, &match.Language, &startMS, &endMS, &match.Text); err != nil {
+			return nil, err
+		}
+		match.Start, match.End = float64(startMS)/1000, float64(endMS)/1000
+		matches = append(matches, &match)
+	}
+	return matches, rows.Err()
+}
+
+// Update 在事务中更新轨道并维护默认标记
+func (r *subtitleRepository) Update(ctx context.Context, subtitle *models.Subtitle) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("开启事务失败: %w", err)
+	}
+	defer tx.Rollback()
+
+	var videoID, lessonID sql.NullInt64
+	err = tx.QueryRowContext(ctx, `SELECT video_id, lesson_id FROM subtitles WHERE id = ? FOR UPDATE`, subtitle.ID).Scan(&videoID, &lessonID)
+	if err != nil {
+		return notFound(err)
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE subtitles SET label = ?, is_default = ?, updated_at = ? WHERE id = ?`,
+		subtitle.Label, subtitle.IsDefault, time.Now(), subtitle.ID); err != nil {
+		return err
+	}
+	if subtitle.IsDefault {
+		owner, ownerID := ownerCondition(repository.SubtitleOwner{VideoID: int(videoID.Int64), LessonID: lessonID.Int64})
+		if _, err := tx.ExecContext(ctx, `UPDATE subtitles SET is_default = 0 WHERE `+owner+` AND id <> ?`, ownerID, subtitle.ID); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (r *subtitleRepository) Delete(ctx context.Context, id int64) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM subtitles WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+	return checkAffected(ctx, r.db, result, "")
+}
@@ -0,0 +1,110 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"online-education-api/models"
+)
+
+// uploadRepository 视频上传数据访问
+type uploadRepository struct {
+	db *sql.DB
+}
+
+const uploadColumns = `id, user_id, file_name, size, received, COALESCE(mime_type, ''), COALESCE(checksum, ''),
+	COALESCE(storage_key, ''), status, created_at, updated_at`
+
+func scanUpload(row interface{ Scan(...interface{}) error }) (*models.Upload, error) {
+	var u models.Upload
+	err := row.Scan(&u.ID, &u.UserID, &u.FileName, &u.Size, &u.Received, &u.MimeType, &u.Checksum,
+		&u.StorageKey, &u.Status, &u.CreatedAt, &u.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+func (r *uploadRepository) Create(ctx context.Context, upload *models.Upload) error {
+	query := `
+	INSERT INTO uploads (id, user_id, file_name, size, received, mime_type, checksum, storage_key, status, created_at, updated_at)
+	VALUES (?, ?, ?, ?, ?, NULLIF(?, ''), NULLIF(?, ''), NULLIF(?, ''), ?, ?, ?)
+	`
+	now := time.Now()
+	_, err := r.db.ExecContext(ctx, query,
+		upload.ID, upload.UserID, upload.FileName, upload.Size, upload.Received,
+		upload.MimeType, upload.Checksum, upload.StorageKey, upload.Status, now, now)
+	if err != nil {
+		return duplicate(err)
+	}
+	upload.CreatedAt = now
+	upload.UpdatedAt = now
+	return nil
+}
+
+func (r *uploadRepository) GetByID(ctx context.Context, id string) (*models.Upload, error) {
+	upload, err := scanUpload(r.db.QueryRowContext(ctx, `SELECT `+uploadColumns+` FROM uploads WHERE id = ?`, id))
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return upload, nil
+}
+
+func (r *uploadRepository) UpdateReceived(ctx context.Context, id string, received int64, mimeType string) error {
+	result, err := r.db.ExecContext(ctx,
+		`UPDATE uploads SET received = ?, mime_type = NULLIF(?, ''), updated_at = ? WHERE id = ? AND status = ?`,
+		received, mimeType, time.Now(), id, models.UploadStatusUploading)
+	if err != nil {
+		return err
+	}
+	return checkAffected(ctx, r.db, result, "")
+}
+
+func (r *uploadRepository) Complete(ctx context.Context, upload *models.Upload) error {
+	query := `
+	UPDATE uploads SET received = ?, mime_type = ?, checksum = ?, storage_key = ?, status = ?, updated_at = ?
+	WHERE id = ? AND status = ?
+	`
+	now := time.Now()
+	result, err := r.db.ExecContext(ctx, query,
+		upload.Received, upload.MimeType, upload.Checksum, upload.StorageKey, models.UploadStatusCompleted, now,
+		upload.ID, models.UploadStatusUploading)
+	if err != nil {
+		return err
+	}
+	if err := checkAffected(ctx, r.db, result, ""); err != nil {
+		return err
+	}
+	upload.Status = models.UploadStatusCompleted
+	upload.UpdatedAt = now
+	return nil
+}
+
+func (r *uploadRepository) Delete(ctx context.Context, id string) error {
+	result, err := r.db.ExecContext(ctx, "DELETE FROM uploads WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+	return checkAffected(ctx, r.db, result, "")
+}
+
+func (r *uploadRepository) ListExpired(ctx context.Context, before time.Time, limit int) ([]models.Upload, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT `+uploadColumns+` FROM uploads WHERE status = ? AND updated_at < ? ORDER BY updated_at LIMIT ?`,
+		models.UploadStatusUploading, before, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var uploads []models.Upload
+	for rows.Next() {
+		upload, err := scanUpload(rows)
+		if err != nil {
+			return nil, err
+		}
+		uploads = append(uploads, *upload)
+	}
+	return uploads, rows.Err()
+}
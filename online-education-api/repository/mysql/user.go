@@ -0,0 +1,162 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"online-education-api/models"
+	"online-education-api/pagination"
+)
+
+// userRepository 用户数据访问
+type userRepository struct {
+	db *sql.DB
+}
+
+const userColumns = "id, username, email, password, COALESCE(avatar, ''), COALESCE(bio, ''), created_at, updated_at, role, status"
+
+func scanUser(row interface{ Scan(...interface{}) error }) (*models.User, error) {
+	var user models.User
+	err := row.Scan(
+		&user.ID, &user.Username, &user.Email, &user.Password, &user.Avatar, &user.Bio,
+		&user.CreatedAt, &user.UpdatedAt, &user.Role, &user.Status,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (r *userRepository) GetByID(ctx context.Context, id int64) (*models.User, error) {
+	user, err := scanUser(r.db.QueryRowContext(ctx, "SELECT "+userColumns+" FROM users WHERE id = ?", id))
+	return user, notFound(err)
+}
+
+func (r *userRepository) GetByUsername(ctx context.Context, username string) (*models.User, error) {
+	user, err := scanUser(r.db.QueryRowContext(ctx, "SELECT "+userColumns+" FROM users WHERE username = ?", username))
+	return user, notFound(err)
+}
+
+func (r *userRepository) UsernameExists(ctx context.Context, username string) (bool, error) {
+	var exists bool
+	err := r.db.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM users WHERE username = ?)", username).Scan(&exists)
+	return exists, err
+}
+
+func (r *userRepository) EmailExists(ctx context.Context, email string) (bool, error) {
+	var exists bool
+	err := r.db.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM users WHERE email = ?)", email).Scan(&exists)
+	return exists, err
+}
+
+func (r *userRepository) List(ctx context.Context, page pagination.Page) ([]*models.User, error) {
+	query := "SELECT " + userColumns + " FROM users"
+	var params []interface{}
+	if page.After != nil {
+		query += " WHERE id > ?"
+		params = append(params, page.After.ID)
+	}
+	limit, limitParams := limitOffset(page)
+	rows, err := r.db.QueryContext(ctx, query+" ORDER BY id"+limit, append(params, limitParams...)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []*models.User
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	return users, rows.Err()
+}
+
+func (r *userRepository) Count(ctx context.Context) (int64, error) {
+	var total int64
+	err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM users").Scan(&total)
+	return total, err
+}
+
+func (r *userRepository) Create(ctx context.Context, user *models.User) error {
+	now := time.Now()
+	query := "INSERT INTO users (username, email, password, role, status, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?)"
+	result, err := r.db.ExecContext(ctx, query, user.Username, user.Email, user.Password, user.Role, user.Status, now, now)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	user.ID = id
+	user.CreatedAt = now
+	user.UpdatedAt = now
+	return nil
+}
+
+func (r *userRepository) UpdateProfile(ctx context.Context, id int64, profile *models.UserUpdateRequest) error {
+	query := "UPDATE users SET updated_at = ?"
+	args := []interface{}{time.Now()}
+
+	if profile.Nickname != "" {
+		query += ", nickname = ?"
+		args = append(args, profile.Nickname)
+	}
+	if profile.Avatar != "" {
+		query += ", avatar = ?"
+		args = append(args, profile.Avatar)
+	}
+	if profile.Bio != "" {
+		query += ", bio = ?"
+		args = append(args, profile.Bio)
+	}
+
+	query += " WHERE id = ?"
+	args = append(args, id)
+
+	result, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	return checkAffected(ctx, r.db, result, "SELECT EXISTS(SELECT 1 FROM users WHERE id = ?)", id)
+}
+
+func (r *userRepository) UpdatePassword(ctx context.Context, id int64, passwordHash string) error {
+	return r.updateField(ctx, id, "password", passwordHash)
+}
+
+func (r *userRepository) UpdateRole(ctx context.Context, id int64, role string) error {
+	return r.updateField(ctx, id, "role", role)
+}
+
+func (r *userRepository) UpdateStatus(ctx context.Context, id int64, status int) error {
+	return r.updateField(ctx, id, "status", status)
+}
+
+// updateField 更新用户的单个字段，column只能传入固定的列名
+func (r *userRepository) updateField(ctx context.Context, id int64, column string, value interface{}) error {
+	query := "UPDATE users SET " + column + " = ?, updated_at = ? WHERE id = ?"
+	result, err := r.db.ExecContext(ctx, query, value, time.Now(), id)
+	if err != nil {
+		return err
+	}
+	return checkAffected(ctx, r.db, result, "SELECT EXISTS(SELECT 1 FROM users WHERE id = ?)", id)
+}
+
+func (r *userRepository) UpdateLastLogin(ctx context.Context, id int64) error {
+	_, err := r.db.ExecContext(ctx, "UPDATE users SET last_login = NOW() WHERE id = ?", id)
+	return err
+}
+
+func (r *userRepository) Delete(ctx context.Context, id int64) error {
+	result, err := r.db.ExecContext(ctx, "DELETE FROM users WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+	return checkAffected(ctx, r.db, result, "")
+}
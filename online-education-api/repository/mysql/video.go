@@ -0,0 +1,603 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"online-education-api/models"
+	"online-education-api/pagination"
+	"online-education-api/repository"
+)
+
+// videoRepository 视频和视频分类数据访问
+type videoRepository struct {
+	db *sql.DB
+}
+
+func (r *videoRepository) Create(ctx context.Context, video *models.Video) error {
+	query := `
+	INSERT INTO videos (
+		title, description, video_url, upload_id, cover_image_url, duration,
+		author_id, category_id, visibility, status, created_at, updated_at
+	) VALUES (?, ?, ?, NULLIF(?, ''), ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	now := time.Now()
+	if video.Status == "" {
+		video.Status = models.VideoStatusReady
+	}
+
+	result, err := r.db.ExecContext(ctx,
+		query,
+		video.Title,
+		video.Description,
+		video.VideoURL,
+		video.UploadID,
+		video.CoverImageURL,
+		video.Duration,
+		video.AuthorID,
+		video.CategoryID,
+		video.Visibility,
+		video.Status,
+		now,
+		now,
+	)
+	if err != nil {
+		return duplicate(err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	video.ID = int(id)
+	video.CreatedAt = now
+	video.UpdatedAt = now
+	return nil
+}
+
+// videoWhere 根据筛选条件构造WHERE子句
+func videoWhere(filter repository.VideoFilter) (string, []interface{}) {
+	where := ` WHERE 1=1`
+	var args []interface{}
+	if len(filter.CategoryIDs) > 0 {
+		where += ` AND category_id IN (?` + strings.Repeat(`, ?`, len(filter.CategoryIDs)-1) + `)`
+		for _, id := range filter.CategoryIDs {
+			args = append(args, id)
+		}
+	}
+	if filter.AuthorID > 0 {
+		where += ` AND author_id = ?`
+		args = append(args, filter.AuthorID)
+	}
+	if filter.Visibility != "" {
+		where += ` AND visibility = ?`
+		args = append(args, filter.Visibility)
+	}
+	return where, args
+}
+
+func (r *videoRepository) List(ctx context.Context, filter repository.VideoFilter, page pagination.Page) ([]models.Video, error) {
+	where, args := videoWhere(filter)
+	after, afterArgs := afterNewest(page, "created_at", "id")
+	limit, limitArgs := limitOffset(page)
+	query := `
+	SELECT id, title, COALESCE(description, ''), COALESCE(cover_image_url, ''), duration,
+		COALESCE(author_id, 0), COALESCE(category_id, 0), visibility, status, created_at
+	FROM videos` + where + after + `
+	ORDER BY created_at DESC, id DESC` + limit
+	args = append(append(args, afterArgs...), limitArgs...)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var videos []models.Video
+	for rows.Next() {
+		var video models.Video
+		err := rows.Scan(
+			&video.ID,
+			&video.Title,
+			&video.Description,
+			&video.CoverImageURL,
+			&video.Duration,
+			&video.AuthorID,
+			&video.CategoryID,
+			&video.Visibility,
+			&video.Status,
+			&video.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		videos = append(videos, video)
+	}
+	return videos, rows.Err()
+}
+
+func (r *videoRepository) Count(ctx context.Context, filter repository.VideoFilter) (int, error) {
+	where, args := videoWhere(filter)
+	var total int
+	err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM videos"+where, args...).Scan(&total)
+	return total, err
+}
+
+func (r *videoRepository) GetByID(ctx context.Context, id int) (*models.Video, error) {
+	query := `
+	SELECT id, title, COALESCE(description, ''), video_url, COALESCE(upload_id, ''), COALESCE(cover_image_url, ''), duration,
+			COALESCE(author_id, 0), COALESCE(category_id, 0), view_count, like_count, favorite_count,
+			visibility, status, COALESCE(hls_url, ''), COALESCE(thumbnails_url, ''), COALESCE(processing_error, ''),
+			created_at, updated_at
+	FROM videos
+	WHERE id = ?
+	`
+
+	var video models.Video
+	err := r.db.QueryRowContext(ctx, query, id).Scan(
+		&video.ID,
+		&video.Title,
+		&video.Description,
+		&video.VideoURL,
+		&video.UploadID,
+		&video.CoverImageURL,
+		&video.Duration,
+		&video.AuthorID,
+		&video.CategoryID,
+		&video.ViewCount,
+		&video.LikeCount,
+		&video.FavoriteCount,
+		&video.Visibility,
+		&video.Status,
+		&video.HLSURL,
+		&video.ThumbnailsURL,
+		&video.ProcessingError,
+		&video.CreatedAt,
+		&video.UpdatedAt,
+	)
+	if err != nil {
+		return nil, notFound(err)
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+	SELECT name, width, height, bandwidth, playlist_url
+	FROM video_renditions
+	WHERE video_id = ?
+	ORDER BY height ASC`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var rendition models.VideoRendition
+		if err := rows.Scan(&rendition.Name, &rendition.Width, &rendition.Height, &rendition.Bandwidth, &rendition.PlaylistURL); err != nil {
+			return nil, err
+		}
+		video.Renditions = append(video.Renditions, rendition)
+	}
+	return &video, rows.Err()
+}
+
+func (r *videoRepository) IDsByStatus(ctx context.Context, status string, limit int) ([]int, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT id FROM videos WHERE status = ? ORDER BY id ASC LIMIT ?", status, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+func (r *videoRepository) UpdateStatus(ctx context.Context, id int, status, processingError string) error {
+	result, err := r.db.ExecContext(ctx,
+		"UPDATE videos SET status = ?, processing_error = NULLIF(?, ''), updated_at = ? WHERE id = ?",
+		status, processingError, time.Now(), id)
+	if err != nil {
+		return err
+	}
+	return checkAffected(ctx, r.db, result, "")
+}
+
+func (r *videoRepository) UpdateMedia(ctx context.Context, video *models.Video) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("开启事务失败: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	// 只在没有封面时使用截取的封面，转码开始后作者设置的封面不被覆盖
+	query := `
+	UPDATE videos SET
+		duration = ?, cover_image_url = COALESCE(NULLIF(cover_image_url, ''), ?), hls_url = ?, thumbnails_url = ?,
+		status = ?, processing_error = NULL, updated_at = ?
+	WHERE id = ?
+	`
+	result, err := tx.ExecContext(ctx, query,
+		video.Duration,
+		video.CoverImageURL,
+		video.HLSURL,
+		video.ThumbnailsURL,
+		models.VideoStatusReady,
+		now,
+		video.ID,
+	)
+	if err != nil {
+		return err
+	}
+	if affected, err := result.RowsAffected(); err != nil {
+		return fmt.Errorf("获取影响行数失败: %w", err)
+	} else if affected == 0 {
+		return repository.ErrNotFound
+	}
+	var cover string
+	if err := tx.QueryRowContext(ctx, "SELECT COALESCE(cover_image_url, '') FROM videos WHERE id = ?", video.ID).Scan(&cover); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM video_renditions WHERE video_id = ?", video.ID); err != nil {
+		return err
+	}
+	for _, rendition := range video.Renditions {
+		_, err := tx.ExecContext(ctx,
+			"INSERT INTO video_renditions (video_id, name, width, height, bandwidth, playlist_url) VALUES (?, ?, ?, ?, ?, ?)",
+			video.ID, rendition.Name, rendition.Width, rendition.Height, rendition.Bandwidth, rendition.PlaylistURL)
+		if err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("提交事务失败: %w", err)
+	}
+
+	video.CoverImageURL = cover
+	video.Status = models.VideoStatusReady
+	video.ProcessingError = ""
+	video.UpdatedAt = now
+	return nil
+}
+
+func (r *videoRepository) AddStats(ctx context.Context, deltas []*models.VideoStatsDelta) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("开启事务失败: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, delta := range deltas {
+		// 锁住视频防止统计期间被删除，已删除的视频跳过
+		var id int
+		if err := tx.QueryRowContext(ctx, "SELECT id FROM videos WHERE id = ? FOR SHARE", delta.VideoID).Scan(&id); err == sql.ErrNoRows {
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		if delta.Views > 0 {
+			if _, err := tx.ExecContext(ctx, "UPDATE videos SET view_count = view_count + ? WHERE id = ?", delta.Views, delta.VideoID); err != nil {
+				return err
+			}
+		}
+		_, err := tx.ExecContext(ctx, `
+		INSERT INTO video_stats (video_id, views, watch_seconds, completions) VALUES (?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE views = views + VALUES(views), watch_seconds = watch_seconds + VALUES(watch_seconds),
+			completions = completions + VALUES(completions)`,
+			delta.VideoID, delta.Views, delta.WatchSeconds, delta.Completions)
+		if err != nil {
+			return err
+		}
+		for bucket, viewers := range delta.Retention {
+			if viewers == 0 {
+				continue
+			}
+			_, err := tx.ExecContext(ctx, `
+			INSERT INTO video_retention (video_id, bucket, viewers) VALUES (?, ?, ?)
+			ON DUPLICATE KEY UPDATE viewers = viewers + VALUES(viewers)`,
+				delta.VideoID, bucket, viewers)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("提交事务失败: %w", err)
+	}
+	return nil
+}
+
+func (r *videoRepository) Stats(ctx context.Context, id int) (*models.VideoStats, error) {
+	stats := &models.VideoStats{VideoID: id, Retention: make([]models.RetentionPoint, models.RetentionBuckets)}
+	err := r.db.QueryRowContext(ctx, "SELECT views, watch_seconds, completions FROM video_stats WHERE video_id = ?", id).
+		Scan(&stats.Views, &stats.WatchSeconds, &stats.Completions)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	rows, err := r.db.QueryContext(ctx, "SELECT bucket, viewers FROM video_retention WHERE video_id = ?", id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var bucket, viewers int
+		if err := rows.Scan(&bucket, &viewers); err != nil {
+			return nil, err
+		}
+		if bucket >= 0 && bucket < models.RetentionBuckets {
+			stats.Retention[bucket].Viewers = viewers
+		}
+	}
+	return stats, rows.Err()
+}
+
+func (r *videoRepository) Update(ctx context.Context, video *models.Video) error {
+	query := `
+	UPDATE videos SET
+		title = ?, description = ?, cover_image_url = ?,
+		category_id = ?, visibility = ?
+	WHERE id = ?
+	`
+
+	result, err := r.db.ExecContext(ctx,
+		query,
+		video.Title,
+		video.Description,
+		video.CoverImageURL,
+		video.CategoryID,
+		video.Visibility,
+		video.ID,
+	)
+	if err != nil {
+		return err
+	}
+	return checkAffected(ctx, r.db, result, "SELECT EXISTS(SELECT 1 FROM videos WHERE id = ?)", video.ID)
+}
+
+func (r *videoRepository) Delete(ctx context.Context, id int) error {
+	result, err := r.db.ExecContext(ctx, "DELETE FROM videos WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+	return checkAffected(ctx, r.db, result, "")
+}
+
+func (r *videoRepository) Categories(ctx context.Context) ([]models.VideoCategory, error) {
+	query := `
+	SELECT c.id, c.name, COALESCE(c.description, ''), c.parent_id, c.sort_order, c.created_at, c.updated_at,
+		(SELECT COUNT(*) FROM videos v WHERE v.category_id = c.id AND v.visibility = 'public')
+	FROM video_categories c
+	ORDER BY c.sort_order ASC, c.id ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var categories []models.VideoCategory
+	for rows.Next() {
+		var category models.VideoCategory
+		var parentID sql.NullInt64
+		var updatedAt sql.NullTime
+		if err := rows.Scan(&category.ID, &category.Name, &category.Description, &parentID, &category.SortOrder,
+			&category.CreatedAt, &updatedAt, &category.VideoCount); err != nil {
+			return nil, err
+		}
+		if parentID.Valid {
+			id := int(parentID.Int64)
+			category.ParentID = &id
+		}
+		category.UpdatedAt = updatedAt.Time
+		categories = append(categories, category)
+	}
+	return categories, rows.Err()
+}
+
+func (r *videoRepository) CreateCategory(ctx context.Context, category *models.VideoCategory) error {
+	query := `INSERT INTO video_categories (name, description, parent_id, sort_order, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?)`
+	now := time.Now()
+
+	result, err := r.db.ExecContext(ctx, query, category.Name, category.Description, category.ParentID, category.SortOrder, now, now)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	category.ID = int(id)
+	category.CreatedAt = now
+	category.UpdatedAt = now
+	return nil
+}
+
+func (r *videoRepository) UpdateCategory(ctx context.Context, category *models.VideoCategory) error {
+	query := `UPDATE video_categories SET name = ?, description = ?, parent_id = ?, sort_order = ?, updated_at = ? WHERE id = ?`
+	now := time.Now()
+	result, err := r.db.ExecContext(ctx, query, category.Name, category.Description, category.ParentID, category.SortOrder, now, category.ID)
+	if err != nil {
+		return err
+	}
+	if err := checkAffected(ctx, r.db, result, `SELECT EXISTS(SELECT 1 FROM video_categories WHERE id = ?)`, category.ID); err != nil {
+		return err
+	}
+	category.UpdatedAt = now
+	return nil
+}
+
+// DeleteCategory 在同一事务中移动视频并删除分类，检查之后新加入该分类的视频也会被移动
+func (r *videoRepository) DeleteCategory(ctx context.Context, id, reassignTo int) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("开启事务失败: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `UPDATE videos SET category_id = ? WHERE category_id = ?`, reassignTo, id); err != nil {
+		return err
+	}
+	result, err := tx.ExecContext(ctx, `DELETE FROM video_categories WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("获取影响行数失败: %w", err)
+	}
+	if affected == 0 {
+		return repository.ErrNotFound
+	}
+	return tx.Commit()
+}
+
+// commentRepository 视频评论数据访问
+type commentRepository struct {
+	db *sql.DB
+}
+
+func (r *commentRepository) Create(ctx context.Context, comment *models.VideoComment) error {
+	now := time.Now()
+	query := `INSERT INTO video_comments (video_id, user_id, content, created_at, updated_at) VALUES (?, ?, ?, ?, ?)`
+	result, err := r.db.ExecContext(ctx, query, comment.VideoID, comment.UserID, comment.Content, now, now)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	comment.ID = int(id)
+	comment.CreatedAt = now
+	comment.UpdatedAt = now
+	return nil
+}
+
+func (r *commentRepository) List(ctx context.Context, videoID int, page pagination.Page) ([]models.VideoCommentWithUserInfo, error) {
+	where := ` WHERE 1=1`
+	var args []interface{}
+	if videoID > 0 {
+		where += ` AND vc.video_id = ?`
+		args = append(args, videoID)
+	}
+	after, afterArgs := afterNewest(page, "vc.created_at", "vc.id")
+	limit, limitArgs := limitOffset(page)
+	query := `
+	SELECT vc.id, vc.video_id, vc.user_id, vc.content, vc.created_at, vc.updated_at,
+	       u.username as author_name, v.title as video_title
+	FROM video_comments vc
+	JOIN users u ON vc.user_id = u.id
+	JOIN videos v ON vc.video_id = v.id` + where + after + `
+	ORDER BY vc.created_at DESC, vc.id DESC` + limit
+	args = append(append(args, afterArgs...), limitArgs...)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var comments []models.VideoCommentWithUserInfo
+	for rows.Next() {
+		var comment models.VideoCommentWithUserInfo
+		err := rows.Scan(
+			&comment.ID,
+			&comment.VideoID,
+			&comment.UserID,
+			&comment.Content,
+			&comment.CreatedAt,
+			&comment.UpdatedAt,
+			&comment.AuthorName,
+			&comment.VideoTitle,
+		)
+		if err != nil {
+			return nil, err
+		}
+		comments = append(comments, comment)
+	}
+	return comments, rows.Err()
+}
+
+func (r *commentRepository) Count(ctx context.Context, videoID int) (int, error) {
+	var total int
+	var err error
+	if videoID > 0 {
+		err = r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM video_comments WHERE video_id = ?", videoID).Scan(&total)
+	} else {
+		err = r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM video_comments").Scan(&total)
+	}
+	return total, err
+}
+
+func (r *commentRepository) Delete(ctx context.Context, id int) error {
+	result, err := r.db.ExecContext(ctx, "DELETE FROM video_comments WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+	return checkAffected(ctx, r.db, result, "")
+}
+
+// SaveProgress 逐条写入观看进度，只插入仍然存在的视频，避免外键错误使整批写入失败
+func (r *videoRepository) SaveProgress(ctx context.Context, progress []*models.VideoProgress) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("开启事务失败: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, p := range progress {
+		_, err := tx.ExecContext(ctx, `
+		INSERT INTO video_progress (user_id, video_id, position_ms, completed, updated_at)
+		SELECT ?, id, ?, ?, ? FROM videos WHERE id = ?
+		ON DUPLICATE KEY UPDATE position_ms = VALUES(position_ms), completed = completed OR VALUES(completed),
+			updated_at = VALUES(updated_at)`,
+			p.UserID, offsetMS(p.Position), p.Completed, p.UpdatedAt, p.VideoID)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (r *videoRepository) Progress(ctx context.Context, userID int64, videoIDs []int) (map[int]*models.VideoProgress, error) {
+	progress := make(map[int]*models.VideoProgress)
+	if len(videoIDs) == 0 {
+		return progress, nil
+	}
+	args := []interface{}{userID}
+	for _, id := range videoIDs {
+		args = append(args, id)
+	}
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT video_id, position_ms, completed, updated_at FROM video_progress WHERE user_id = ? AND video_id IN (?`+strings.Repeat(`, ?`, len(videoIDs)-1)+`)`,
+		args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		p := models.VideoProgress{UserID: userID}
+		var positionMS int64
+		if err := rows.Scan(&p.VideoID, &positionMS, &p.Completed, &p.UpdatedAt); err != nil {
+			return nil, err
+		}
+		p.Position = float64(positionMS) / 1000
+		progress[p.VideoID] = &p
+	}
+	return progress, rows.Err()
+}
@@ -0,0 +1,22 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"online-education-api/models"
+	"online-education-api/pagination"
+)
+
+// PaymentRepository 支付记录数据访问接口
+type PaymentRepository interface {
+	Create(ctx context.Context, payment *models.Payment) error
+	GetByID(ctx context.Context, id int64) (*models.Payment, error)
+	GetByOrderID(ctx context.Context, orderID string) (*models.Payment, error)
+	// ListByUser 按创建时间倒序返回用户的支付记录，包含课程标题
+	ListByUser(ctx context.Context, userID int64, page pagination.Page) ([]*models.PaymentResponse, error)
+	CountByUser(ctx context.Context, userID int64) (int, error)
+	// ListByStatus 返回指定状态且创建时间早于createdBefore的支付记录
+	ListByStatus(ctx context.Context, status string, createdBefore time.Time) ([]*models.Payment, error)
+	UpdateStatus(ctx context.Context, orderID, transactionID, status string) error
+}
@@ -0,0 +1,38 @@
+package repository
+
+import (
+	"context"
+
+	"online-education-api/models"
+	"online-education-api/pagination"
+)
+
+// PlaylistFilter 播放列表的筛选条件，零值表示不限
+type PlaylistFilter struct {
+	UserID     int64
+	Visibility string
+}
+
+// PlaylistRepository 播放列表数据访问接口，列表中的视频属于播放列表聚合
+type PlaylistRepository interface {
+	Create(ctx context.Context, playlist *models.Playlist) error
+	// GetByID 返回播放列表，VideoCount为列表中全部视频的数量
+	GetByID(ctx context.Context, id int64) (*models.Playlist, error)
+	// List 按创建时间倒序返回播放列表，包含VideoCount
+	List(ctx context.Context, filter PlaylistFilter, page pagination.Page) ([]*models.Playlist, error)
+	Count(ctx context.Context, filter PlaylistFilter) (int, error)
+	// Update 更新标题、简介和可见性，权限由调用方检查
+	Update(ctx context.Context, playlist *models.Playlist) error
+	Delete(ctx context.Context, id int64) error
+	// Items 按位置返回列表中的视频，Video只包含ID、标题、封面、时长、作者、可见性和转码状态
+	Items(ctx context.Context, id int64) ([]*models.PlaylistItem, error)
+	// AddVideo 将视频插入到position(从1开始)，之后的视频依次后移；position为0或超过末尾时添加到末尾
+	// 视频已在列表中时返回ErrDuplicate，播放列表或视频不存在时返回ErrNotFound
+	AddVideo(ctx context.Context, id int64, videoID int, position int) error
+	// RemoveVideo 移除视频，之后的视频依次前移
+	RemoveVideo(ctx context.Context, id int64, videoID int) error
+	// Reorder 按videoIDs的顺序设置位置，videoIDs需包含列表中的全部视频，由调用方检查
+	Reorder(ctx context.Context, id int64, videoIDs []int) error
+	// FindByVideo 返回用户创建的包含视频的播放列表，visibility不为空时只查找该可见性；有多个时返回ID最小的
+	FindByVideo(ctx context.Context, videoID int, userID int64, visibility string) (*models.Playlist, error)
+}
@@ -0,0 +1,27 @@
+package repository
+
+import (
+	"context"
+
+	"online-education-api/models"
+	"online-education-api/pagination"
+)
+
+// PostFilter 帖子列表的筛选条件，零值表示不限
+type PostFilter struct {
+	UserID        int64
+	PublishedOnly bool
+	Search        string // 匹配标题或内容
+}
+
+// PostRepository 帖子数据访问接口
+type PostRepository interface {
+	// List 按创建时间倒序返回帖子，包含作者信息
+	List(ctx context.Context, filter PostFilter, page pagination.Page) ([]*models.PostResponse, error)
+	Count(ctx context.Context, filter PostFilter) (int, error)
+	GetByID(ctx context.Context, id int64) (*models.PostResponse, error)
+	IncrementViews(ctx context.Context, id int64) error
+	Create(ctx context.Context, post *models.Post) error
+	Update(ctx context.Context, post *models.Post) error
+	UpdateStatus(ctx context.Context, id int64, status int) error
+}
@@ -0,0 +1,43 @@
+// Package repository 定义按聚合划分的数据访问接口
+//
+// 服务层只依赖这里的接口，mysql子包是生产环境使用的实现，
+// memory子包是不依赖数据库的内存实现，用于测试和本地运行。
+//
+// 接收 pagination.Page 的列表方法读取 Page.Limit 条记录(比每页条数多一条，由服务层判断是否有下一页)，
+// 设置了游标时从游标之后读取，否则从 Page.Offset 开始读取。
+package repository
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrNotFound 记录不存在，服务层将其转换为各自的错误信息
+var ErrNotFound = errors.New("记录不存在")
+
+// ErrDuplicate 违反唯一约束，如用户重复评价同一门课程
+var ErrDuplicate = errors.New("记录已存在")
+
+// Pinger 检查底层存储是否可用
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// Repositories 一种存储实现提供的全部数据访问接口
+type Repositories struct {
+	Users       UserRepository
+	Categories  CategoryRepository
+	Courses     CourseRepository
+	Enrollments EnrollmentRepository
+	Payments    PaymentRepository
+	Posts       PostRepository
+	Videos      VideoRepository
+	Comments    CommentRepository
+	Reviews     ReviewRepository
+	Uploads     UploadRepository
+	Images      ImageRepository
+	Danmaku     DanmakuRepository
+	Subtitles   SubtitleRepository
+	Playlists   PlaylistRepository
+	Health      Pinger
+}
@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"context"
+
+	"online-education-api/models"
+	"online-education-api/pagination"
+)
+
+// ReviewFilter 评价列表的筛选条件，零值表示不限
+type ReviewFilter struct {
+	CourseID    int64
+	Rating      int
+	VisibleOnly bool // 只返回显示中的评价
+	Reported    bool // 只返回有未处理举报且显示中的评价
+}
+
+// ReviewSort 评价列表的排序方式，相同时按创建时间和ID倒序
+type ReviewSort string
+
+// 评价排序方式
+const (
+	ReviewSortNewest  ReviewSort = "newest"  // 最新发布
+	ReviewSortHighest ReviewSort = "highest" // 评分从高到低
+	ReviewSortLowest  ReviewSort = "lowest"  // 评分从低到高
+	ReviewSortReports ReviewSort = "reports" // 未处理的举报数从多到少
+)
+
+// ReviewRepository 课程评价数据访问接口
+// 显示中的评价计入课程的评分和评价数，改变评价星级或显示状态的写操作在同一事务中增量更新课程
+type ReviewRepository interface {
+	// List 按排序方式返回评价，包含评价人信息；只有ReviewSortNewest支持游标
+	List(ctx context.Context, filter ReviewFilter, sort ReviewSort, page pagination.Page) ([]*models.CourseReviewResponse, error)
+	Count(ctx context.Context, filter ReviewFilter) (int, error)
+	GetByID(ctx context.Context, id int64) (*models.CourseReview, error)
+	// GetByUser 返回用户对课程的评价
+	GetByUser(ctx context.Context, courseID, userID int64) (*models.CourseReview, error)
+	// Create 创建评价，用户已评价过该课程时返回ErrDuplicate
+	Create(ctx context.Context, review *models.CourseReview) error
+	// Update 修改评价的星级和内容
+	Update(ctx context.Context, review *models.CourseReview) error
+	Delete(ctx context.Context, id int64) error
+	// Reply 保存教师的回复，覆盖之前的回复
+	Reply(ctx context.Context, id int64, reply string) error
+	// Report 记录举报并增加评价的未处理举报数，用户已举报过该评价时返回ErrDuplicate
+	Report(ctx context.Context, report *models.ReviewReport) error
+	// Moderate 设置评价的显示状态，删除已处理的举报并清空举报数，之后用户可以再次举报
+	Moderate(ctx context.Context, id int64, status int) error
+}
@@ -0,0 +1,30 @@
+package repository
+
+import (
+	"context"
+
+	"online-education-api/models"
+)
+
+// SubtitleOwner 字幕所属的视频或课时，只设置其中一个
+type SubtitleOwner struct {
+	VideoID  int
+	LessonID int64
+}
+
+// SubtitleRepository 字幕数据访问接口
+type SubtitleRepository interface {
+	// Save 保存字幕轨道和全部字幕条。视频或课时已有相同语言的轨道时替换其字幕条并更新标签，subtitle.ID设为已有轨道的ID；
+	// IsDefault为true时取消同一视频或课时的其他默认轨道
+	Save(ctx context.Context, subtitle *models.Subtitle, cues []models.SubtitleCue) error
+	GetByID(ctx context.Context, id int64) (*models.Subtitle, error)
+	// List 返回视频或课时的字幕轨道，默认轨道在前，其余按语言排序
+	List(ctx context.Context, owner SubtitleOwner) ([]*models.Subtitle, error)
+	// Cues 按开始时间返回轨道的全部字幕条
+	Cues(ctx context.Context, id int64) ([]models.SubtitleCue, error)
+	// Search 返回视频或课时的字幕中文本包含q的字幕条，不区分大小写；language不为空时只查找该语言，按开始时间排序，最多limit条
+	Search(ctx context.Context, owner SubtitleOwner, language, q string, limit int) ([]*models.SubtitleMatch, error)
+	// Update 更新轨道的标签和默认标记，设为默认时取消同一视频或课时的其他默认轨道
+	Update(ctx context.Context, subtitle *models.Subtitle) error
+	Delete(ctx context.Context, id int64) error
+}
@@ -0,0 +1,21 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"online-education-api/models"
+)
+
+// UploadRepository 视频上传数据访问接口
+type UploadRepository interface {
+	Create(ctx context.Context, upload *models.Upload) error
+	GetByID(ctx context.Context, id string) (*models.Upload, error)
+	// UpdateReceived 更新上传中记录的已接收字节数和文件类型，上传不存在或已完成时返回ErrNotFound
+	UpdateReceived(ctx context.Context, id string, received int64, mimeType string) error
+	// Complete 将上传中的记录标记为完成，并保存校验和与存储位置
+	Complete(ctx context.Context, upload *models.Upload) error
+	Delete(ctx context.Context, id string) error
+	// ListExpired 返回最后更新时间早于before的未完成上传，最多limit条
+	ListExpired(ctx context.Context, before time.Time, limit int) ([]models.Upload, error)
+}
@@ -0,0 +1,29 @@
+package repository
+
+import (
+	"context"
+
+	"online-education-api/models"
+	"online-education-api/pagination"
+)
+
+// UserRepository 用户数据访问接口
+// 查询返回的用户包含密码哈希(Password字段)，由服务层决定是否返回给调用方
+type UserRepository interface {
+	GetByID(ctx context.Context, id int64) (*models.User, error)
+	GetByUsername(ctx context.Context, username string) (*models.User, error)
+	UsernameExists(ctx context.Context, username string) (bool, error)
+	EmailExists(ctx context.Context, email string) (bool, error)
+	// List 按ID顺序返回用户，键集分页时只使用游标的ID
+	List(ctx context.Context, page pagination.Page) ([]*models.User, error)
+	Count(ctx context.Context) (int64, error)
+	// Create 创建用户，user.Password为密码哈希，成功后设置ID和创建时间
+	Create(ctx context.Context, user *models.User) error
+	// UpdateProfile 更新个人资料，空字段保持不变
+	UpdateProfile(ctx context.Context, id int64, profile *models.UserUpdateRequest) error
+	UpdatePassword(ctx context.Context, id int64, passwordHash string) error
+	UpdateRole(ctx context.Context, id int64, role string) error
+	UpdateStatus(ctx context.Context, id int64, status int) error
+	UpdateLastLogin(ctx context.Context, id int64) error
+	Delete(ctx context.Context, id int64) error
+}
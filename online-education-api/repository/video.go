@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"context"
+
+	"online-education-api/models"
+	"online-education-api/pagination"
+)
+
+// VideoFilter 视频列表的筛选条件，零值表示不限
+type VideoFilter struct {
+	CategoryIDs []int // 属于其中任一分类
+	AuthorID    int64
+	Visibility  string
+}
+
+// VideoRepository 视频数据访问接口，视频分类属于视频聚合
+type VideoRepository interface {
+	// Create 创建视频，UploadID已被其他视频使用时返回ErrDuplicate
+	Create(ctx context.Context, video *models.Video) error
+	// List 按创建时间倒序返回视频
+	List(ctx context.Context, filter VideoFilter, page pagination.Page) ([]models.Video, error)
+	Count(ctx context.Context, filter VideoFilter) (int, error)
+	// GetByID 返回视频详情，包含转码生成的清晰度
+	GetByID(ctx context.Context, id int) (*models.Video, error)
+	// IDsByStatus 按ID升序返回处于status的视频
+	IDsByStatus(ctx context.Context, status string, limit int) ([]int, error)
+	// UpdateStatus 更新转码状态和失败原因
+	UpdateStatus(ctx context.Context, id int, status, processingError string) error
+	// UpdateMedia 保存转码结果：时长、封面、HLS地址和清晰度，状态设为ready。
+	// 转码期间作者设置了封面时保留作者的封面，video.CoverImageURL更新为保存后的封面
+	UpdateMedia(ctx context.Context, video *models.Video) error
+	// AddStats 累加播放统计，观看次数同时累加到视频的view_count，已删除的视频被忽略
+	AddStats(ctx context.Context, deltas []*models.VideoStatsDelta) error
+	// Stats 返回视频的播放统计，Retention按段的顺序包含RetentionBuckets个点，只设置Viewers；没有统计时各项为0
+	Stats(ctx context.Context, id int) (*models.VideoStats, error)
+	// SaveProgress 保存用户的观看进度，Completed一旦为true不再清除，已删除的视频被忽略
+	SaveProgress(ctx context.Context, progress []*models.VideoProgress) error
+	// Progress 返回用户在给定视频中有进度的记录，按视频ID索引
+	Progress(ctx context.Context, userID int64, videoIDs []int) (map[int]*models.VideoProgress, error)
+	// Update 更新视频的标题、简介、封面、分类和可见性，权限由调用方检查
+	Update(ctx context.Context, video *models.Video) error
+	Delete(ctx context.Context, id int) error
+	// Categories 按排序返回全部视频分类，VideoCount为分类下公开视频的数量，由调用方组装分类树
+	Categories(ctx context.Context) ([]models.VideoCategory, error)
+	CreateCategory(ctx context.Context, category *models.VideoCategory) error
+	// UpdateCategory 更新分类的名称、简介、上级分类和排序
+	UpdateCategory(ctx context.Context, category *models.VideoCategory) error
+	// DeleteCategory 将分类下的视频移到reassignTo(0为未分类)后删除分类，有下级分类时由调用方拒绝
+	DeleteCategory(ctx context.Context, id, reassignTo int) error
+}
+
+// CommentRepository 视频评论数据访问接口
+type CommentRepository interface {
+	Create(ctx context.Context, comment *models.VideoComment) error
+	// List 按创建时间倒序返回评论，包含作者名和视频标题，videoID为0时不限视频
+	List(ctx context.Context, videoID int, page pagination.Page) ([]models.VideoCommentWithUserInfo, error)
+	Count(ctx context.Context, videoID int) (int, error)
+	Delete(ctx context.Context, id int) error
+}
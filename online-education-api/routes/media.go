@@ -0,0 +1,29 @@
+package routes
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+
+	"online-education-api/storage"
+	"online-education-api/urlsign"
+)
+
+// protectedMedia 只能通过签名地址访问的对象键前缀：上传的原文件和HLS转码结果，封面等图片不受保护
+var protectedMedia = []string{"videos/", "hls/"}
+
+// MountMedia 使用本地存储且访问地址为路径时，由API服务器提供上传文件的下载。
+// signer不为nil时校验签名地址，视频文件只能通过签名地址访问
+func MountMedia(r *mux.Router, files storage.Storage, signer *urlsign.Signer) {
+	local, ok := files.(*storage.Local)
+	if !ok || local.PublicPath() == "" {
+		return
+	}
+	prefix := local.PublicPath()
+	handler := local.Handler()
+	if signer != nil {
+		handler = signer.Handler(handler, protectedMedia...)
+	}
+	r.PathPrefix(prefix).Handler(http.StripPrefix(strings.TrimSuffix(prefix, "/"), handler)).Methods("GET", "HEAD")
+}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/gorilla/mux"
 	"online-education-api/controllers"
+	"online-education-api/metrics"
 	"online-education-api/middleware"
 )
 
@@ -18,25 +19,114 @@ func SetupRoutes(
 	postController *controllers.PostController,
 	paymentController *controllers.PaymentController,
 	commentController *controllers.CommentController,
+	healthController *controllers.HealthController,
+	searchController *controllers.SearchController,
+	reviewController *controllers.ReviewController,
+	uploadController *controllers.UploadController,
+	imageController *controllers.ImageController,
+	danmakuController *controllers.DanmakuController,
+	subtitleController *controllers.SubtitleController,
+	playlistController *controllers.PlaylistController,
+	videoCategoryController *controllers.VideoCategoryController,
 ) *mux.Router {
 	// 创建路由器
 	r := mux.NewRouter()
 
+	// 健康检查和监控路由
+	r.HandleFunc("/healthz", healthController.Healthz).Methods("GET")
+	r.HandleFunc("/readyz", healthController.Readyz).Methods("GET")
+	r.Handle("/metrics", metrics.Handler()).Methods("GET")
+
+	// 搜索路由
+	r.HandleFunc("/api/search", searchController.Search).Methods("GET")
+
 	// 视频路由
 	videoRoutes := r.PathPrefix("/api/videos").Subrouter()
-	videoRoutes.HandleFunc("", videoController.GetVideoList).Methods("GET")
-	videoRoutes.HandleFunc("", videoController.CreateVideo).Methods("POST")
-	videoRoutes.HandleFunc("/{id}", videoController.GetVideoByID).Methods("GET")
-	videoRoutes.HandleFunc("/{id}", videoController.UpdateVideo).Methods("PUT")
-	videoRoutes.HandleFunc("/{id}", videoController.DeleteVideo).Methods("DELETE")
 
-	// 视频分类路由
-	videoRoutes.HandleFunc("/categories", videoController.GetVideoCategories).Methods("GET")
+	// 视频分类和我的视频路由，需要在/{id}之前注册
+	videoRoutes.Handle("/categories", middleware.ETagMiddleware(http.HandlerFunc(videoController.GetVideoCategories))).Methods("GET")
+
+	// 管理员可以查看非公开的视频
+	videoRoutes.Handle("", middleware.OptionalAuthMiddleware(http.HandlerFunc(videoController.GetVideoList))).Methods("GET")
+	videoRoutes.Handle("", middleware.AuthMiddleware(http.HandlerFunc(videoController.CreateVideo))).Methods("POST")
+	videoRoutes.Handle("/mine", middleware.AuthMiddleware(http.HandlerFunc(videoController.GetMyVideos))).Methods("GET")
+	// 私有视频只对作者和管理员可见，播放地址按登录用户签名
+	videoRoutes.Handle("/{id}", middleware.OptionalAuthMiddleware(http.HandlerFunc(videoController.GetVideoByID))).Methods("GET")
+	videoRoutes.Handle("/{id}", middleware.AuthMiddleware(http.HandlerFunc(videoController.UpdateVideo))).Methods("PUT")
+	videoRoutes.Handle("/{id}", middleware.AuthMiddleware(http.HandlerFunc(videoController.DeleteVideo))).Methods("DELETE")
+	videoRoutes.Handle("/{id}/transcode", middleware.AuthMiddleware(http.HandlerFunc(videoController.TranscodeVideo))).Methods("POST")
+	// 播放事件允许匿名发送，播放统计只对作者和管理员可见
+	videoRoutes.Handle("/{id}/events", middleware.OptionalAuthMiddleware(http.HandlerFunc(videoController.RecordViewEvent))).Methods("POST")
+	videoRoutes.Handle("/{id}/stats", middleware.AuthMiddleware(http.HandlerFunc(videoController.GetVideoStats))).Methods("GET")
+	// 弹幕，WebSocket连接推送同一视频的新弹幕
+	videoRoutes.Handle("/{id}/danmaku", middleware.OptionalAuthMiddleware(http.HandlerFunc(danmakuController.GetDanmaku))).Methods("GET")
+	videoRoutes.Handle("/{id}/danmaku", middleware.AuthMiddleware(http.HandlerFunc(danmakuController.SendDanmaku))).Methods("POST")
+	videoRoutes.Handle("/{id}/danmaku/ws", middleware.OptionalAuthMiddleware(http.HandlerFunc(danmakuController.StreamDanmaku))).Methods("GET")
+	videoRoutes.Handle("/{id}/danmaku/{danmakuID}", middleware.AuthMiddleware(http.HandlerFunc(danmakuController.DeleteDanmaku))).Methods("DELETE")
+	// 字幕，权限与视频一致
+	videoRoutes.Handle("/{id}/subtitles", middleware.OptionalAuthMiddleware(http.HandlerFunc(subtitleController.GetVideoSubtitles))).Methods("GET")
+	videoRoutes.Handle("/{id}/subtitles", middleware.AuthMiddleware(http.HandlerFunc(subtitleController.UploadVideoSubtitle))).Methods("POST")
+	videoRoutes.Handle("/{id}/subtitles/search", middleware.OptionalAuthMiddleware(http.HandlerFunc(subtitleController.SearchVideoSubtitles))).Methods("GET")
+
+	// 课时字幕路由，免费课时或已购买课程的用户可以查看
+	lessonRoutes := r.PathPrefix("/api/lessons").Subrouter()
+	lessonRoutes.Handle("/{id}/subtitles", middleware.OptionalAuthMiddleware(http.HandlerFunc(subtitleController.GetLessonSubtitles))).Methods("GET")
+	lessonRoutes.Handle("/{id}/subtitles", middleware.AuthMiddleware(http.HandlerFunc(subtitleController.UploadLessonSubtitle))).Methods("POST")
+	lessonRoutes.Handle("/{id}/subtitles/search", middleware.OptionalAuthMiddleware(http.HandlerFunc(subtitleController.SearchLessonSubtitles))).Methods("GET")
+
+	// 字幕文件路由
+	subtitleRoutes := r.PathPrefix("/api/subtitles").Subrouter()
+	subtitleRoutes.Handle("/{id}", middleware.OptionalAuthMiddleware(http.HandlerFunc(subtitleController.GetSubtitleFile))).Methods("GET")
+	subtitleRoutes.Handle("/{id}", middleware.AuthMiddleware(http.HandlerFunc(subtitleController.UpdateSubtitle))).Methods("PUT")
+	subtitleRoutes.Handle("/{id}", middleware.AuthMiddleware(http.HandlerFunc(subtitleController.DeleteSubtitle))).Methods("DELETE")
+
+	// 播放列表路由，我的播放列表需要在/{id}之前注册；私有播放列表只对创建者和管理员可见
+	playlistRoutes := r.PathPrefix("/api/playlists").Subrouter()
+	playlistRoutes.HandleFunc("", playlistController.GetPlaylists).Methods("GET")
+	playlistRoutes.Handle("", middleware.AuthMiddleware(http.HandlerFunc(playlistController.CreatePlaylist))).Methods("POST")
+	playlistRoutes.Handle("/mine", middleware.AuthMiddleware(http.HandlerFunc(playlistController.GetMyPlaylists))).Methods("GET")
+	playlistRoutes.Handle("/{id}", middleware.OptionalAuthMiddleware(http.HandlerFunc(playlistController.GetPlaylist))).Methods("GET")
+	playlistRoutes.Handle("/{id}", middleware.AuthMiddleware(http.HandlerFunc(playlistController.UpdatePlaylist))).Methods("PUT")
+	playlistRoutes.Handle("/{id}", middleware.AuthMiddleware(http.HandlerFunc(playlistController.DeletePlaylist))).Methods("DELETE")
+	playlistRoutes.Handle("/{id}/videos", middleware.AuthMiddleware(http.HandlerFunc(playlistController.AddVideo))).Methods("POST")
+	playlistRoutes.Handle("/{id}/videos/{videoID}", middleware.AuthMiddleware(http.HandlerFunc(playlistController.RemoveVideo))).Methods("DELETE")
+	playlistRoutes.Handle("/{id}/order", middleware.AuthMiddleware(http.HandlerFunc(playlistController.ReorderVideos))).Methods("PUT")
+
+	// 视频上传路由，multipart需要在/{id}之前注册
+	uploadRoutes := r.PathPrefix("/api/uploads").Subrouter()
+	uploadRoutes.Use(middleware.AuthMiddleware)
+	uploadRoutes.HandleFunc("", uploadController.CreateUpload).Methods("POST")
+	uploadRoutes.HandleFunc("/multipart", uploadController.UploadMultipart).Methods("POST")
+	uploadRoutes.HandleFunc("/{id}", uploadController.GetUpload).Methods("GET")
+	uploadRoutes.HandleFunc("/{id}", uploadController.HeadUpload).Methods("HEAD")
+	uploadRoutes.HandleFunc("/{id}", uploadController.PatchUpload).Methods("PATCH")
+	uploadRoutes.HandleFunc("/{id}", uploadController.DeleteUpload).Methods("DELETE")
+
+	// 图片路由
+	imageRoutes := r.PathPrefix("/api/images").Subrouter()
+	imageRoutes.HandleFunc("", imageController.GetImages).Methods("GET")
+	imageRoutes.HandleFunc("/{id}", imageController.GetImage).Methods("GET")
+
+	// 受保护的图片路由
+	protectedImageRoutes := imageRoutes.PathPrefix("").Subrouter()
+	protectedImageRoutes.Use(middleware.AuthMiddleware)
+	protectedImageRoutes.HandleFunc("", imageController.UploadImage).Methods("POST")
+	protectedImageRoutes.HandleFunc("/{id}", imageController.UpdateImage).Methods("PUT")
+	protectedImageRoutes.HandleFunc("/{id}", imageController.DeleteImage).Methods("DELETE")
+	protectedImageRoutes.HandleFunc("/{id}/attach", imageController.AttachImage).Methods("POST")
+
+	// 视频分类路由，/api/videos/categories返回按排序的分类列表，这里返回分类树；修改只有管理员可以操作
+	videoCategoryRoutes := r.PathPrefix("/api/video-categories").Subrouter()
+	videoCategoryRoutes.Handle("", middleware.ETagMiddleware(http.HandlerFunc(videoCategoryController.GetCategoryTree))).Methods("GET")
+	videoCategoryRoutes.Handle("/{id}", middleware.ETagMiddleware(http.HandlerFunc(videoCategoryController.GetCategory))).Methods("GET")
+	videoCategoryRoutes.Handle("", middleware.AuthMiddleware(http.HandlerFunc(videoCategoryController.CreateCategory))).Methods("POST")
+	videoCategoryRoutes.Handle("/{id}", middleware.AuthMiddleware(http.HandlerFunc(videoCategoryController.UpdateCategory))).Methods("PUT")
+	videoCategoryRoutes.Handle("/{id}", middleware.AuthMiddleware(http.HandlerFunc(videoCategoryController.DeleteCategory))).Methods("DELETE")
 
 	// 课程分类路由
 	courseCategoryRoutes := r.PathPrefix("/api/course-categories").Subrouter()
-	courseCategoryRoutes.HandleFunc("", courseCategoryController.GetAllCategories).Methods("GET")
-	courseCategoryRoutes.HandleFunc("/{id}", courseCategoryController.GetCategoryByID).Methods("GET")
+	courseCategoryRoutes.Handle("", middleware.ETagMiddleware(http.HandlerFunc(courseCategoryController.GetAllCategories))).Methods("GET")
+	courseCategoryRoutes.Handle("/{id}", middleware.ETagMiddleware(http.HandlerFunc(courseCategoryController.GetCategoryByID))).Methods("GET")
 
 	// 受保护的课程分类路由
 	protectedCourseCategoryRoutes := courseCategoryRoutes.PathPrefix("").Subrouter()
@@ -47,8 +137,10 @@ func SetupRoutes(
 
 	// 课程路由
 	courseRoutes := r.PathPrefix("/api/courses").Subrouter()
-	courseRoutes.HandleFunc("", courseController.GetCourseList).Methods("GET")
-	courseRoutes.HandleFunc("/{id}", courseController.GetCourseDetail).Methods("GET")
+	// 登录的教师和管理员可以看到未上架的课程
+	courseRoutes.Handle("", middleware.OptionalAuthMiddleware(middleware.ETagMiddleware(http.HandlerFunc(courseController.GetCourseList)))).Methods("GET")
+	courseRoutes.Handle("/{id}", middleware.OptionalAuthMiddleware(middleware.ETagMiddleware(http.HandlerFunc(courseController.GetCourseDetail)))).Methods("GET")
+	courseRoutes.HandleFunc("/{id}/reviews", reviewController.GetCourseReviews).Methods("GET")
 
 	// 受保护的课程路由
 	protectedCourseRoutes := courseRoutes.PathPrefix("").Subrouter()
@@ -56,6 +148,17 @@ func SetupRoutes(
 	protectedCourseRoutes.HandleFunc("", courseController.CreateCourse).Methods("POST")
 	protectedCourseRoutes.HandleFunc("/{id}", courseController.UpdateCourse).Methods("PUT")
 	protectedCourseRoutes.HandleFunc("/{id}", courseController.DeleteCourse).Methods("DELETE")
+	protectedCourseRoutes.HandleFunc("/{id}/reviews", reviewController.CreateReview).Methods("POST")
+
+	// 课程评价路由，待处理举报的路由需要在/{id}之前注册
+	reviewRoutes := r.PathPrefix("/api/reviews").Subrouter()
+	reviewRoutes.Use(middleware.AuthMiddleware)
+	reviewRoutes.HandleFunc("/reported", reviewController.GetReportedReviews).Methods("GET")
+	reviewRoutes.HandleFunc("/{id}", reviewController.UpdateReview).Methods("PUT")
+	reviewRoutes.HandleFunc("/{id}", reviewController.DeleteReview).Methods("DELETE")
+	reviewRoutes.HandleFunc("/{id}/reply", reviewController.ReplyReview).Methods("PUT")
+	reviewRoutes.HandleFunc("/{id}/report", reviewController.ReportReview).Methods("POST")
+	reviewRoutes.HandleFunc("/{id}/moderation", reviewController.ModerateReview).Methods("PUT")
 
 	// 用户课程路由
 	userCourseRoutes := r.PathPrefix("/api/user-courses").Subrouter()
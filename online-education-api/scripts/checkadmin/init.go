@@ -1,3 +0,0 @@
-package checkadmin
-
-// 空的init.go文件，用于标识checkadmin包
\ No newline at end of file
@@ -1,3 +0,0 @@
-package testadmin
-
-// 空的init.go文件，用于标识testadmin包
\ No newline at end of file
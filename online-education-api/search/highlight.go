@@ -0,0 +1,59 @@
+package search
+
+import (
+	"html"
+	"strings"
+)
+
+// snippetLength 摘要的最大长度(字符数)
+const snippetLength = 120
+
+// highlight 用<em>包围text中属于terms的词，其余内容做HTML转义
+// maxLen大于0时截取第一个匹配附近的maxLen个字符作为摘要
+func highlight(text string, terms map[string]bool, maxLen int) string {
+	runes := []rune(text)
+
+	// 合并相互重叠的匹配区间，中文二元组相邻时会重叠
+	var marks []span
+	for _, sp := range tokenSpans(runes) {
+		if !terms[sp.term] {
+			continue
+		}
+		if n := len(marks); n > 0 && sp.start <= marks[n-1].end {
+			marks[n-1].end = max(marks[n-1].end, sp.end)
+			continue
+		}
+		marks = append(marks, sp)
+	}
+
+	from, to := 0, len(runes)
+	if maxLen > 0 && len(runes) > maxLen {
+		if len(marks) > 0 {
+			from = max(0, marks[0].start-maxLen/4)
+		}
+		to = min(len(runes), from+maxLen)
+		from = max(0, to-maxLen)
+	}
+
+	var b strings.Builder
+	if from > 0 {
+		b.WriteString("…")
+	}
+	pos := from
+	for _, m := range marks {
+		if m.end <= from || m.start >= to {
+			continue
+		}
+		start, end := max(m.start, from), min(m.end, to)
+		b.WriteString(html.EscapeString(string(runes[pos:start])))
+		b.WriteString("<em>")
+		b.WriteString(html.EscapeString(string(runes[start:end])))
+		b.WriteString("</em>")
+		pos = end
+	}
+	b.WriteString(html.EscapeString(string(runes[pos:to])))
+	if to < len(runes) {
+		b.WriteString("…")
+	}
+	return b.String()
+}
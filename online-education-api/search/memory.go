@@ -0,0 +1,366 @@
+package search
+
+import (
+	"cmp"
+	"context"
+	"math"
+	"slices"
+	"strconv"
+	"sync"
+	"unicode/utf8"
+)
+
+// BM25参数
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+
+	// titleBoost 标题命中的权重倍数
+	titleBoost = 2.0
+	// fuzzyWeight 拼写容错命中的权重
+	fuzzyWeight = 0.5
+	// maxFuzzyTerms 每次搜索最多对几个索引中不存在的词做拼写容错
+	maxFuzzyTerms = 3
+)
+
+// posting 词在一个文档中的出现次数
+type posting struct {
+	title, body int
+}
+
+// indexedDoc 已索引的文档
+type indexedDoc struct {
+	Document
+	titleLen, bodyLen int
+	terms             []string // 文档包含的词，删除时使用
+}
+
+// MemoryIndex 进程内倒排索引，重启后需要重建
+type MemoryIndex struct {
+	mu       sync.RWMutex
+	docs     map[string]*indexedDoc
+	postings map[string]map[string]posting // 词 -> 文档Key -> 出现次数
+	byLength map[int]map[string]struct{}   // 字符数 -> 索引中的词，拼写容错只比较长度相近的词
+
+	totalTitleLen, totalBodyLen int
+}
+
+// NewMemoryIndex 创建空的进程内索引
+func NewMemoryIndex() *MemoryIndex {
+	return &MemoryIndex{
+		docs:     make(map[string]*indexedDoc),
+		postings: make(map[string]map[string]posting),
+		byLength: make(map[int]map[string]struct{}),
+	}
+}
+
+// Index 添加或替换文档
+func (idx *MemoryIndex) Index(ctx context.Context, doc Document) error {
+	titleTerms := tokenize(doc.Title)
+	bodyTerms := tokenize(doc.Body)
+
+	counts := make(map[string]posting)
+	for _, term := range titleTerms {
+		p := counts[term]
+		p.title++
+		counts[term] = p
+	}
+	for _, term := range bodyTerms {
+		p := counts[term]
+		p.body++
+		counts[term] = p
+	}
+
+	d := &indexedDoc{Document: doc, titleLen: len(titleTerms), bodyLen: len(bodyTerms)}
+	for term := range counts {
+		d.terms = append(d.terms, term)
+	}
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	key := doc.Key()
+	idx.remove(key)
+	for term, p := range counts {
+		if idx.postings[term] == nil {
+			idx.postings[term] = make(map[string]posting)
+			n := utf8.RuneCountInString(term)
+			if idx.byLength[n] == nil {
+				idx.byLength[n] = make(map[string]struct{})
+			}
+			idx.byLength[n][term] = struct{}{}
+		}
+		idx.postings[term][key] = p
+	}
+	idx.docs[key] = d
+	idx.totalTitleLen += d.titleLen
+	idx.totalBodyLen += d.bodyLen
+	return nil
+}
+
+// Delete 删除文档
+func (idx *MemoryIndex) Delete(ctx context.Context, docType string, id int64) error {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	idx.remove(docType + ":" + strconv.FormatInt(id, 10))
+	return nil
+}
+
+// Reset 清空索引
+func (idx *MemoryIndex) Reset(ctx context.Context) error {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	idx.docs = make(map[string]*indexedDoc)
+	idx.postings = make(map[string]map[string]posting)
+	idx.byLength = make(map[int]map[string]struct{})
+	idx.totalTitleLen, idx.totalBodyLen = 0, 0
+	return nil
+}
+
+// Len 返回已索引的文档数
+func (idx *MemoryIndex) Len() int {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return len(idx.docs)
+}
+
+// remove 删除文档及其倒排记录，调用方需持有写锁
+func (idx *MemoryIndex) remove(key string) {
+	d, ok := idx.docs[key]
+	if !ok {
+		return
+	}
+	for _, term := range d.terms {
+		delete(idx.postings[term], key)
+		if len(idx.postings[term]) == 0 {
+			delete(idx.postings, term)
+			n := utf8.RuneCountInString(term)
+			delete(idx.byLength[n], term)
+			if len(idx.byLength[n]) == 0 {
+				delete(idx.byLength, n)
+			}
+		}
+	}
+	delete(idx.docs, key)
+	idx.totalTitleLen -= d.titleLen
+	idx.totalBodyLen -= d.bodyLen
+}
+
+// expansion 查询词对应的索引词及其权重
+type expansion struct {
+	term   string
+	weight float64
+}
+
+// expand 查询词在索引中存在时原样使用，否则在fuzzy为true时查找编辑距离允许范围内的词，调用方需持有读锁
+// 编辑距离不小于长度差，只需比较长度相差不超过允许编辑次数的词
+func (idx *MemoryIndex) expand(term string, fuzzy bool) []expansion {
+	if _, ok := idx.postings[term]; ok {
+		return []expansion{{term: term, weight: 1}}
+	}
+	limit := maxEdits(term)
+	if !fuzzy || limit == 0 {
+		return nil
+	}
+
+	var expansions []expansion
+	n := utf8.RuneCountInString(term)
+	for length := n - limit; length <= n+limit; length++ {
+		for candidate := range idx.byLength[length] {
+			if editDistance(term, candidate, limit) <= limit {
+				expansions = append(expansions, expansion{term: candidate, weight: fuzzyWeight})
+			}
+		}
+	}
+	return expansions
+}
+
+// matches 判断文档是否满足筛选条件
+func (q *Query) matches(d *indexedDoc) bool {
+	if len(q.Types) > 0 && !slices.Contains(q.Types, d.Type) {
+		return false
+	}
+	if q.Category != "" && d.Type+":"+d.Category != q.Category {
+		return false
+	}
+	if q.Level > 0 && d.Level != q.Level {
+		return false
+	}
+	if q.MinPrice != nil || q.MaxPrice != nil {
+		if d.Price == nil {
+			return false
+		}
+		if q.MinPrice != nil && *d.Price < *q.MinPrice {
+			return false
+		}
+		if q.MaxPrice != nil && *d.Price > *q.MaxPrice {
+			return false
+		}
+	}
+	return true
+}
+
+// bm25 计算一个字段的词频得分
+func bm25(tf, fieldLen int, avgLen float64) float64 {
+	if tf == 0 {
+		return 0
+	}
+	norm := 1.0
+	if avgLen > 0 {
+		norm = 1 - bm25B + bm25B*float64(fieldLen)/avgLen
+	}
+	return float64(tf) * (bm25K1 + 1) / (float64(tf) + bm25K1*norm)
+}
+
+// Search 搜索文档，文本为空时按筛选条件返回全部文档，按创建时间倒序
+// 搜索文本过长时返回ErrQueryTooLong，只对前 maxFuzzyTerms 个索引中不存在的词做拼写容错
+func (idx *MemoryIndex) Search(ctx context.Context, q Query) (*Result, error) {
+	if err := q.Validate(); err != nil {
+		return nil, err
+	}
+
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	scores := make(map[string]float64)
+	terms := make(map[string]bool)
+
+	if text := tokenize(q.Text); len(text) > 0 {
+		n := float64(len(idx.docs))
+		avgTitle := float64(idx.totalTitleLen) / n
+		avgBody := float64(idx.totalBodyLen) / n
+
+		seen := make(map[string]bool)
+		fuzzy := 0
+		for _, term := range text {
+			if seen[term] {
+				continue
+			}
+			seen[term] = true
+			_, known := idx.postings[term]
+			if !known && maxEdits(term) > 0 {
+				fuzzy++
+			}
+			for _, e := range idx.expand(term, fuzzy <= maxFuzzyTerms) {
+				postings := idx.postings[e.term]
+				df := float64(len(postings))
+				idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+				terms[e.term] = true
+
+				for key, p := range postings {
+					d := idx.docs[key]
+					if !q.matches(d) {
+						continue
+					}
+					tf := titleBoost*bm25(p.title, d.titleLen, avgTitle) + bm25(p.body, d.bodyLen, avgBody)
+					scores[key] += e.weight * idf * tf
+				}
+			}
+		}
+	} else {
+		for key, d := range idx.docs {
+			if q.matches(d) {
+				scores[key] = 0
+			}
+		}
+	}
+
+	type scored struct {
+		*indexedDoc
+		score float64
+	}
+	matched := make([]scored, 0, len(scores))
+	docs := make([]*indexedDoc, 0, len(scores))
+	for key, score := range scores {
+		matched = append(matched, scored{indexedDoc: idx.docs[key], score: score})
+		docs = append(docs, idx.docs[key])
+	}
+	slices.SortFunc(matched, func(a, b scored) int {
+		return cmp.Or(
+			cmp.Compare(b.score, a.score),
+			b.CreatedAt.Compare(a.CreatedAt),
+			cmp.Compare(a.Type, b.Type),
+			cmp.Compare(b.ID, a.ID),
+		)
+	})
+
+	result := &Result{Total: len(matched), Hits: []Hit{}, Facets: facets(docs)}
+	start := min(max(q.Offset, 0), len(matched))
+	end := len(matched)
+	if q.Limit > 0 {
+		end = min(start+q.Limit, len(matched))
+	}
+	for _, d := range matched[start:end] {
+		result.Hits = append(result.Hits, Hit{
+			Type:      d.Type,
+			ID:        d.ID,
+			Score:     math.Round(d.score*1000) / 1000,
+			Title:     d.Title,
+			Highlight: Highlight{Title: highlight(d.Title, terms, 0), Body: highlight(d.Body, terms, snippetLength)},
+			Category:  d.CategoryName,
+			Level:     d.Level,
+			Price:     d.Price,
+			CreatedAt: d.CreatedAt,
+		})
+	}
+	return result, nil
+}
+
+// facets 统计匹配文档的类型、分类、难度和价格区间
+func facets(docs []*indexedDoc) Facets {
+	types := newFacetCounter()
+	categories := newFacetCounter()
+	levels := newFacetCounter()
+	prices := newFacetCounter()
+
+	for _, d := range docs {
+		types.add(d.Type, "")
+		if d.Category != "" {
+			categories.add(d.Type+":"+d.Category, d.CategoryName)
+		}
+		if d.Level > 0 {
+			levels.add(strconv.Itoa(d.Level), "")
+		}
+		if d.Price != nil {
+			prices.add(priceRange(*d.Price), "")
+		}
+	}
+
+	return Facets{
+		Types:      types.sorted(),
+		Categories: categories.sorted(),
+		Levels:     levels.sorted(),
+		Prices:     prices.sorted(),
+	}
+}
+
+// facetCounter 按取值计数，保留每个取值的显示名称
+type facetCounter struct {
+	counts map[string]*FacetCount
+}
+
+func newFacetCounter() *facetCounter {
+	return &facetCounter{counts: make(map[string]*FacetCount)}
+}
+
+func (c *facetCounter) add(value, label string) {
+	if fc, ok := c.counts[value]; ok {
+		fc.Count++
+		return
+	}
+	c.counts[value] = &FacetCount{Value: value, Label: label, Count: 1}
+}
+
+// sorted 按文档数倒序返回，数量相同时按取值排序
+func (c *facetCounter) sorted() []FacetCount {
+	result := make([]FacetCount, 0, len(c.counts))
+	for _, fc := range c.counts {
+		result = append(result, *fc)
+	}
+	slices.SortFunc(result, func(a, b FacetCount) int {
+		return cmp.Or(cmp.Compare(b.Count, a.Count), cmp.Compare(a.Value, b.Value))
+	})
+	return result
+}
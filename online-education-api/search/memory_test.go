@@ -0,0 +1,213 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func price(p float64) *float64 { return &p }
+
+// newTestIndex 创建包含课程、视频和帖子的索引
+func newTestIndex(t *testing.T) *MemoryIndex {
+	t.Helper()
+	idx := NewMemoryIndex()
+	now := time.Now()
+
+	docs := []Document{
+		{Type: TypeCourse, ID: 1, Title: "Go语言并发编程", Body: "goroutine与channel的实战课程", Category: "1", CategoryName: "后端开发", Level: 2, Price: price(199), CreatedAt: now.Add(-3 * time.Hour)},
+		{Type: TypeCourse, ID: 2, Title: "Python数据分析", Body: "pandas入门，也会讲一点并发", Category: "2", CategoryName: "数据科学", Level: 1, Price: price(0), CreatedAt: now.Add(-2 * time.Hour)},
+		{Type: TypeVideo, ID: 3, Title: "Kubernetes部署实践", Body: "把Go服务部署到集群", Category: "5", CategoryName: "运维", CreatedAt: now.Add(-time.Hour)},
+		{Type: TypePost, ID: 4, Title: "求助：并发读写map报错", Body: "fatal error: concurrent map writes <script>", Category: "问答", CategoryName: "问答", CreatedAt: now},
+	}
+	for _, doc := range docs {
+		if err := idx.Index(context.Background(), doc); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return idx
+}
+
+// hitKeys 返回结果中文档的Key
+func hitKeys(result *Result) []string {
+	var keys []string
+	for _, hit := range result.Hits {
+		keys = append(keys, (&Document{Type: hit.Type, ID: hit.ID}).Key())
+	}
+	return keys
+}
+
+func TestTokenize(t *testing.T) {
+	got := tokenize("Go语言并发, HTTP/2 入门!")
+	want := []string{"go", "语言", "言并", "并发", "http", "2", "入门"}
+	if !slices.Equal(got, want) {
+		t.Fatalf("tokenize = %q, want %q", got, want)
+	}
+	if got := tokenize("学"); !slices.Equal(got, []string{"学"}) {
+		t.Fatalf("expected a single character to be kept, got %q", got)
+	}
+}
+
+func TestSearchRanksTitleMatchesFirst(t *testing.T) {
+	idx := newTestIndex(t)
+
+	result, err := idx.Search(context.Background(), Query{Text: "并发"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := hitKeys(result)
+	if result.Total != 3 || len(keys) != 3 || keys[2] != "course:2" {
+		t.Fatalf("expected the body-only match last, got %v", keys)
+	}
+	if result.Hits[0].Highlight.Title == result.Hits[0].Title {
+		t.Fatalf("expected the title to be highlighted, got %q", result.Hits[0].Highlight.Title)
+	}
+
+	// 英文不区分大小写，标题和正文都参与匹配
+	result, _ = idx.Search(context.Background(), Query{Text: "GO"})
+	if keys := hitKeys(result); len(keys) != 2 || keys[0] != "course:1" {
+		t.Fatalf("unexpected hits for GO: %v", keys)
+	}
+}
+
+func TestSearchToleratesTypos(t *testing.T) {
+	idx := newTestIndex(t)
+
+	for _, text := range []string{"kubernets", "goroutnie"} {
+		result, err := idx.Search(context.Background(), Query{Text: text})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Total != 1 {
+			t.Fatalf("%s: expected 1 hit, got %v", text, hitKeys(result))
+		}
+	}
+
+	// 短词不做容错
+	if result, _ := idx.Search(context.Background(), Query{Text: "gp"}); result.Total != 0 {
+		t.Fatalf("expected no fuzzy matches for short terms, got %v", hitKeys(result))
+	}
+}
+
+func TestSearchLimitsQueryAndFuzzyTerms(t *testing.T) {
+	idx := newTestIndex(t)
+	ctx := context.Background()
+
+	for _, text := range []string{strings.Repeat("并", MaxQueryRunes+1), strings.Repeat("go ", MaxQueryTerms+1)} {
+		if _, err := idx.Search(ctx, Query{Text: text}); !errors.Is(err, ErrQueryTooLong) {
+			t.Errorf("Search(%d runes) err = %v, want ErrQueryTooLong", len([]rune(text)), err)
+		}
+	}
+	if _, err := idx.Search(ctx, Query{Text: strings.Repeat("go ", MaxQueryTerms)}); err != nil {
+		t.Errorf("Search at the limit err = %v", err)
+	}
+
+	// 只对前几个不存在的词做拼写容错
+	misspelled := "aaaa bbbb cccc kubernets"
+	if result, err := idx.Search(ctx, Query{Text: misspelled}); err != nil || result.Total != 0 {
+		t.Fatalf("Search(%q) = %v, %v, want no fuzzy match after %d terms", misspelled, hitKeys(result), err, maxFuzzyTerms)
+	}
+	if result, err := idx.Search(ctx, Query{Text: "aaaa kubernets"}); err != nil || result.Total != 1 {
+		t.Fatalf("Search(aaaa kubernets) = %v, %v, want 1 hit", hitKeys(result), err)
+	}
+
+	// 删除和清空后按长度分组的词同步更新
+	if err := idx.Delete(ctx, TypeVideo, 3); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := idx.byLength[len("kubernetes")]["kubernetes"]; ok {
+		t.Error("deleted term still in length buckets")
+	}
+	if err := idx.Reset(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(idx.byLength) != 0 {
+		t.Errorf("length buckets after reset = %v", idx.byLength)
+	}
+}
+
+func TestSearchFiltersAndFacets(t *testing.T) {
+	idx := newTestIndex(t)
+	ctx := context.Background()
+
+	result, err := idx.Search(ctx, Query{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Total != 4 || hitKeys(result)[0] != "post:4" {
+		t.Fatalf("expected all documents newest first, got %v", hitKeys(result))
+	}
+	if len(result.Facets.Types) != 3 || result.Facets.Types[0] != (FacetCount{Value: "course", Count: 2}) {
+		t.Fatalf("unexpected type facets %+v", result.Facets.Types)
+	}
+	if !slices.Contains(result.Facets.Prices, FacetCount{Value: "free", Count: 1}) ||
+		!slices.Contains(result.Facets.Categories, FacetCount{Value: "video:5", Label: "运维", Count: 1}) {
+		t.Fatalf("unexpected facets %+v", result.Facets)
+	}
+
+	result, _ = idx.Search(ctx, Query{Text: "并发", MinPrice: price(100)})
+	if keys := hitKeys(result); !slices.Equal(keys, []string{"course:1"}) {
+		t.Fatalf("expected only the paid course, got %v", keys)
+	}
+	result, _ = idx.Search(ctx, Query{Text: "并发", Types: []string{TypePost, TypeVideo}})
+	if keys := hitKeys(result); !slices.Equal(keys, []string{"post:4"}) {
+		t.Fatalf("expected only the post, got %v", keys)
+	}
+	result, _ = idx.Search(ctx, Query{Category: "course:2", Level: 1})
+	if keys := hitKeys(result); !slices.Equal(keys, []string{"course:2"}) {
+		t.Fatalf("expected the course in category 2, got %v", keys)
+	}
+
+	result, _ = idx.Search(ctx, Query{Offset: 3, Limit: 2})
+	if result.Total != 4 || len(result.Hits) != 1 {
+		t.Fatalf("unexpected last page %d %v", result.Total, hitKeys(result))
+	}
+}
+
+func TestIndexReplacesAndDeletes(t *testing.T) {
+	idx := newTestIndex(t)
+	ctx := context.Background()
+
+	if err := idx.Index(ctx, Document{Type: TypeCourse, ID: 1, Title: "Rust入门"}); err != nil {
+		t.Fatal(err)
+	}
+	if result, _ := idx.Search(ctx, Query{Text: "goroutine"}); result.Total != 0 {
+		t.Fatalf("expected the old content to be gone, got %v", hitKeys(result))
+	}
+	if result, _ := idx.Search(ctx, Query{Text: "rust"}); result.Total != 1 {
+		t.Fatalf("expected the new content, got %v", hitKeys(result))
+	}
+
+	if err := idx.Delete(ctx, TypeCourse, 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := idx.Delete(ctx, TypeCourse, 404); err != nil {
+		t.Fatal(err)
+	}
+	if result, _ := idx.Search(ctx, Query{Text: "rust"}); result.Total != 0 || idx.Len() != 3 {
+		t.Fatalf("expected the course to be deleted, got %v with %d docs", hitKeys(result), idx.Len())
+	}
+}
+
+func TestHighlight(t *testing.T) {
+	terms := map[string]bool{"并发": true, "发编": true, "map": true}
+
+	if got := highlight("Go并发编程", terms, 0); got != "Go<em>并发编</em>程" {
+		t.Fatalf("unexpected highlight %q", got)
+	}
+	if got := highlight("<b>map</b> & maps", terms, 0); got != "&lt;b&gt;<em>map</em>&lt;/b&gt; &amp; maps" {
+		t.Fatalf("expected escaped text with whole-word matches, got %q", got)
+	}
+
+	long := strings.Repeat("文", 200) + "并发" + strings.Repeat("字", 200)
+	got := highlight(long, terms, 20)
+	if !strings.HasPrefix(got, "…") || !strings.HasSuffix(got, "…") || !strings.Contains(got, "<em>并发</em>") {
+		t.Fatalf("unexpected snippet %q", got)
+	}
+	if n := len([]rune(strings.NewReplacer("<em>", "", "</em>", "", "…", "").Replace(got))); n != 20 {
+		t.Fatalf("expected a 20 character snippet, got %d", n)
+	}
+}
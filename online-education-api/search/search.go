@@ -0,0 +1,143 @@
+// Package search 课程、视频和帖子的全文搜索
+//
+// Index 是可替换的索引接口，MemoryIndex 是进程内的倒排索引实现：中文按二元组切分
+// (与MySQL FULLTEXT的ngram解析器一致)，英文按单词切分并转为小写，使用BM25计算相关度，
+// 支持拼写容错、高亮和分面统计。
+package search
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// 搜索文本的限制，拼写容错需要与索引中的词逐个比较，过长的搜索文本会长时间占用CPU和索引
+const (
+	MaxQueryRunes = 100 // 搜索文本的最大字符数
+	MaxQueryTerms = 10  // 搜索文本中以空白分隔的最大词数
+)
+
+// ErrQueryTooLong 搜索文本超过 MaxQueryRunes 或 MaxQueryTerms
+var ErrQueryTooLong = errors.New("搜索词过长")
+
+// 文档类型
+const (
+	TypeCourse = "course"
+	TypeVideo  = "video"
+	TypePost   = "post"
+)
+
+// Document 被索引的文档
+type Document struct {
+	Type         string
+	ID           int64
+	Title        string
+	Body         string
+	Category     string // 分类标识，课程和视频为分类ID，帖子为分类名
+	CategoryName string
+	Level        int      // 课程难度，其他类型为0
+	Price        *float64 // 课程价格，其他类型为nil
+	CreatedAt    time.Time
+}
+
+// Key 文档在索引中的唯一标识
+func (d *Document) Key() string {
+	return d.Type + ":" + strconv.FormatInt(d.ID, 10)
+}
+
+// Query 搜索条件，零值表示不限
+type Query struct {
+	Text     string
+	Types    []string
+	Category string // 分面中的分类值，格式为"类型:分类标识"
+	Level    int
+	MinPrice *float64 // 设置价格条件时只返回课程
+	MaxPrice *float64
+	Offset   int
+	Limit    int
+}
+
+// Validate 检查搜索文本的长度和词数
+func (q *Query) Validate() error {
+	if utf8.RuneCountInString(q.Text) > MaxQueryRunes || len(strings.Fields(q.Text)) > MaxQueryTerms {
+		return ErrQueryTooLong
+	}
+	return nil
+}
+
+// Hit 一条搜索结果
+type Hit struct {
+	Type      string    `json:"type"`
+	ID        int64     `json:"id"`
+	Score     float64   `json:"score"`
+	Title     string    `json:"title"`
+	Highlight Highlight `json:"highlight"`
+	Category  string    `json:"category_name,omitempty"`
+	Level     int       `json:"level,omitempty"`
+	Price     *float64  `json:"price,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Highlight 高亮后的标题和摘要，匹配的词用<em>包围，其余内容已做HTML转义
+type Highlight struct {
+	Title string `json:"title"`
+	Body  string `json:"body"`
+}
+
+// FacetCount 分面中的一个取值及其文档数
+type FacetCount struct {
+	Value string `json:"value"`
+	Label string `json:"label,omitempty"`
+	Count int    `json:"count"`
+}
+
+// Facets 匹配文档的分面统计
+type Facets struct {
+	Types      []FacetCount `json:"types"`
+	Categories []FacetCount `json:"categories"`
+	Levels     []FacetCount `json:"levels"`
+	Prices     []FacetCount `json:"prices"`
+}
+
+// Result 搜索结果
+type Result struct {
+	Total  int    `json:"total"`
+	Hits   []Hit  `json:"hits"`
+	Facets Facets `json:"facets"`
+}
+
+// Index 搜索索引接口
+type Index interface {
+	// Index 添加或替换文档
+	Index(ctx context.Context, doc Document) error
+	// Delete 删除文档，文档不存在时不返回错误
+	Delete(ctx context.Context, docType string, id int64) error
+	// Reset 清空索引
+	Reset(ctx context.Context) error
+	// Search 搜索文档，搜索文本超过限制时返回ErrQueryTooLong
+	Search(ctx context.Context, q Query) (*Result, error)
+}
+
+// 价格分面的区间
+var priceRanges = []struct {
+	Value    string
+	Min, Max float64 // 左闭右开，Max为0表示不限
+}{
+	{"free", 0, 0.01},
+	{"0-100", 0.01, 100},
+	{"100-500", 100, 500},
+	{"500+", 500, 0},
+}
+
+// priceRange 返回价格所属的区间
+func priceRange(price float64) string {
+	for _, r := range priceRanges {
+		if price >= r.Min && (r.Max == 0 || price < r.Max) {
+			return r.Value
+		}
+	}
+	return ""
+}
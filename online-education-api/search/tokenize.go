@@ -0,0 +1,123 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+)
+
+// isCJK 判断是否为中日韩文字，这些文字之间没有空格，按二元组切分
+func isCJK(r rune) bool {
+	return unicode.Is(unicode.Han, r) || unicode.Is(unicode.Hiragana, r) ||
+		unicode.Is(unicode.Katakana, r) || unicode.Is(unicode.Hangul, r)
+}
+
+// span 词在原文中的位置，start和end为rune下标，左闭右开
+type span struct {
+	term       string
+	start, end int
+}
+
+// tokenize 切分文本：英文和数字按单词切分并转为小写，连续的中文切分为二元组，单个汉字保留为一元组
+func tokenize(text string) []string {
+	spans := tokenSpans([]rune(text))
+	tokens := make([]string, len(spans))
+	for i, sp := range spans {
+		tokens[i] = sp.term
+	}
+	return tokens
+}
+
+// tokenSpans 按 tokenize 的规则切分文本并记录每个词的位置，用于高亮
+func tokenSpans(runes []rune) []span {
+	var spans []span
+	wordStart, cjkStart := -1, -1
+
+	flushWord := func(end int) {
+		if wordStart >= 0 {
+			spans = append(spans, span{term: strings.ToLower(string(runes[wordStart:end])), start: wordStart, end: end})
+			wordStart = -1
+		}
+	}
+	flushCJK := func(end int) {
+		switch {
+		case cjkStart < 0:
+			return
+		case end-cjkStart == 1:
+			spans = append(spans, span{term: string(runes[cjkStart:end]), start: cjkStart, end: end})
+		default:
+			for i := cjkStart; i+1 < end; i++ {
+				spans = append(spans, span{term: string(runes[i : i+2]), start: i, end: i + 2})
+			}
+		}
+		cjkStart = -1
+	}
+
+	for i, r := range runes {
+		switch {
+		case isCJK(r):
+			flushWord(i)
+			if cjkStart < 0 {
+				cjkStart = i
+			}
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			flushCJK(i)
+			if wordStart < 0 {
+				wordStart = i
+			}
+		default:
+			flushWord(i)
+			flushCJK(i)
+		}
+	}
+	flushWord(len(runes))
+	flushCJK(len(runes))
+	return spans
+}
+
+// maxEdits 返回词允许的拼写错误数，中文和短词不做容错
+func maxEdits(term string) int {
+	n := len([]rune(term))
+	switch {
+	case n < 4 || strings.IndexFunc(term, isCJK) >= 0:
+		return 0
+	case n < 8:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// editDistance 计算编辑距离(相邻字符交换算一次编辑)，超过limit时提前返回limit+1
+func editDistance(a, b string, limit int) int {
+	ra, rb := []rune(a), []rune(b)
+	if d := len(ra) - len(rb); d > limit || -d > limit {
+		return limit + 1
+	}
+
+	prev2 := make([]int, len(rb)+1)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		rowMin := cur[0]
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				cur[j] = min(cur[j], prev2[j-2]+1)
+			}
+			rowMin = min(rowMin, cur[j])
+		}
+		if rowMin > limit {
+			return limit + 1
+		}
+		prev2, prev, cur = prev, cur, prev2
+	}
+	return prev[len(rb)]
+}
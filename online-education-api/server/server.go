@@ -0,0 +1,150 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"online-education-api/config"
+	"online-education-api/logger"
+)
+
+var serverLog = logger.New("server")
+
+// defaultHookTimeout 未配置HookTimeout时每个清理函数的最长执行时间
+const defaultHookTimeout = 10 * time.Second
+
+// shutdownHook 关闭时执行的清理函数
+type shutdownHook struct {
+	name string
+	fn   func(ctx context.Context) error
+}
+
+// Server 支持优雅关闭的HTTP服务器
+type Server struct {
+	cfg      *config.ServerConfig
+	http     *http.Server
+	reloader *CertReloader
+
+	mu    sync.Mutex
+	hooks []shutdownHook
+}
+
+// New 创建HTTP服务器，配置了读写超时、空闲超时和最大请求头大小
+func New(cfg *config.ServerConfig, handler http.Handler) (*Server, error) {
+	s := &Server{
+		cfg: cfg,
+		http: &http.Server{
+			Addr:              cfg.Addr,
+			Handler:           handler,
+			ReadTimeout:       cfg.ReadTimeout,
+			ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+			WriteTimeout:      cfg.WriteTimeout,
+			IdleTimeout:       cfg.IdleTimeout,
+			MaxHeaderBytes:    cfg.MaxHeaderBytes,
+		},
+	}
+
+	if cfg.TLSCertFile != "" {
+		reloader, err := NewCertReloader(cfg.TLSCertFile, cfg.TLSKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		s.reloader = reloader
+		s.http.TLSConfig = &tls.Config{
+			MinVersion:     tls.VersionTLS12,
+			GetCertificate: reloader.GetCertificate,
+		}
+	}
+
+	return s, nil
+}
+
+// OnShutdown 注册关闭时执行的清理函数
+// HTTP请求处理完成后按注册的相反顺序执行，因此应最先注册数据库连接池等底层资源。
+// 每个清理函数的ctx有单独的HookTimeout期限，等待请求处理超时不会使后面的清理函数失去执行时间
+func (s *Server) OnShutdown(name string, fn func(ctx context.Context) error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hooks = append(s.hooks, shutdownHook{name: name, fn: fn})
+}
+
+// Run 启动服务器并阻塞，直到收到SIGINT/SIGTERM或ctx被取消后优雅关闭
+func (s *Server) Run(ctx context.Context) error {
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if s.reloader != nil {
+		go s.reloader.WatchSignal(ctx)
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		var err error
+		if s.reloader != nil {
+			serverLog.Info("HTTPS服务器启动", "addr", s.cfg.Addr)
+			err = s.http.ListenAndServeTLS("", "")
+		} else {
+			serverLog.Info("HTTP服务器启动", "addr", s.cfg.Addr)
+			err = s.http.ListenAndServe()
+		}
+		if !errors.Is(err, http.ErrServerClosed) {
+			errCh <- err
+		}
+		close(errCh)
+	}()
+
+	select {
+	case err, ok := <-errCh:
+		if ok {
+			s.runHooks()
+			return err
+		}
+		return nil
+	case <-ctx.Done():
+	}
+	stop()
+
+	serverLog.Info("收到退出信号，开始优雅关闭", "timeout", s.cfg.ShutdownTimeout.String())
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), s.cfg.ShutdownTimeout)
+	defer cancel()
+
+	err := s.http.Shutdown(shutdownCtx)
+	if err != nil {
+		serverLog.Error("等待请求处理完成超时，强制关闭连接", "error", err)
+		s.http.Close()
+	}
+
+	s.runHooks()
+	serverLog.Info("服务器已关闭")
+	return err
+}
+
+// runHooks 按注册的相反顺序执行清理函数，每个清理函数有单独的超时
+func (s *Server) runHooks() {
+	s.mu.Lock()
+	hooks := append([]shutdownHook(nil), s.hooks...)
+	s.mu.Unlock()
+
+	timeout := s.cfg.HookTimeout
+	if timeout <= 0 {
+		timeout = defaultHookTimeout
+	}
+	for i := len(hooks) - 1; i >= 0; i-- {
+		hook := hooks[i]
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		err := hook.fn(ctx)
+		cancel()
+		if err != nil {
+			serverLog.Error("关闭失败", "component", hook.name, "error", err)
+			continue
+		}
+		serverLog.Info("已关闭", "component", hook.name)
+	}
+}
@@ -0,0 +1,151 @@
+package server
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"slices"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"online-education-api/config"
+)
+
+// freeAddr 返回一个空闲的本地地址
+func freeAddr(t *testing.T) string {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	return ln.Addr().String()
+}
+
+// waitListening 等待服务器开始监听
+func waitListening(t *testing.T, addr string) {
+	t.Helper()
+
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if conn, err := net.Dial("tcp", addr); err == nil {
+			conn.Close()
+			return
+		}
+	}
+	t.Fatalf("server did not listen on %s", addr)
+}
+
+func TestRunDrainsInFlightRequestOnSIGTERM(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		io.WriteString(w, "done")
+	})
+	cfg := &config.ServerConfig{Addr: freeAddr(t), ShutdownTimeout: 5 * time.Second}
+	srv, err := New(cfg, handler)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var hookRan bool
+	srv.OnShutdown("check", func(ctx context.Context) error {
+		hookRan = true
+		return nil
+	})
+
+	runErr := make(chan error, 1)
+	go func() { runErr <- srv.Run(context.Background()) }()
+	waitListening(t, cfg.Addr)
+
+	type result struct {
+		body string
+		err  error
+	}
+	resCh := make(chan result, 1)
+	go func() {
+		resp, err := http.Get("http://" + cfg.Addr + "/")
+		if err != nil {
+			resCh <- result{err: err}
+			return
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		resCh <- result{string(body), err}
+	}()
+	<-started
+
+	if err := syscall.Kill(syscall.Getpid(), syscall.SIGTERM); err != nil {
+		t.Fatal(err)
+	}
+	// 收到信号后不再接受新连接，正在处理的请求继续执行
+	select {
+	case err := <-runErr:
+		t.Fatalf("Run returned %v before the in-flight request finished", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+	close(release)
+
+	if res := <-resCh; res.err != nil || res.body != "done" {
+		t.Fatalf("in-flight request = %q, %v", res.body, res.err)
+	}
+	if err := <-runErr; err != nil {
+		t.Fatalf("Run = %v", err)
+	}
+	if !hookRan {
+		t.Error("shutdown hook did not run")
+	}
+}
+
+func TestShutdownHooksRunInReverseOrderWithOwnDeadline(t *testing.T) {
+	started := make(chan struct{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-r.Context().Done()
+	})
+	// 请求不会自行结束，等待请求的时间耗尽后清理函数仍有各自的期限
+	cfg := &config.ServerConfig{Addr: freeAddr(t), ShutdownTimeout: 50 * time.Millisecond, HookTimeout: time.Second}
+	srv, err := New(cfg, handler)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var mu sync.Mutex
+	var order []string
+	for _, name := range []string{"database", "transcode", "analytics"} {
+		srv.OnShutdown(name, func(ctx context.Context) error {
+			if err := ctx.Err(); err != nil {
+				t.Errorf("hook %s got expired context: %v", name, err)
+			}
+			if deadline, ok := ctx.Deadline(); !ok || time.Until(deadline) < 500*time.Millisecond {
+				t.Errorf("hook %s deadline = %v, %v", name, deadline, ok)
+			}
+			mu.Lock()
+			order = append(order, name)
+			mu.Unlock()
+			return nil
+		})
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	runErr := make(chan error, 1)
+	go func() { runErr <- srv.Run(ctx) }()
+	waitListening(t, cfg.Addr)
+	go func() {
+		if resp, err := http.Get("http://" + cfg.Addr + "/"); err == nil {
+			resp.Body.Close()
+		}
+	}()
+	<-started
+	cancel()
+
+	if err := <-runErr; err == nil {
+		t.Error("Run = nil, want the drain timeout error")
+	}
+	if want := []string{"analytics", "transcode", "database"}; !slices.Equal(order, want) {
+		t.Errorf("hook order = %v, want %v", order, want)
+	}
+}
@@ -0,0 +1,108 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// certCheckInterval 检查证书文件是否更新的最小间隔
+const certCheckInterval = 10 * time.Second
+
+// CertReloader 证书热加载器，证书文件更新或收到SIGHUP时重新加载
+type CertReloader struct {
+	certFile string
+	keyFile  string
+
+	mu        sync.RWMutex
+	cert      *tls.Certificate
+	modTime   time.Time
+	lastCheck time.Time
+}
+
+// NewCertReloader 创建证书热加载器并立即加载证书
+func NewCertReloader(certFile, keyFile string) (*CertReloader, error) {
+	r := &CertReloader{certFile: certFile, keyFile: keyFile}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Reload 重新加载证书
+func (r *CertReloader) Reload() error {
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("加载TLS证书失败: %w", err)
+	}
+
+	info, err := os.Stat(r.certFile)
+	if err != nil {
+		return fmt.Errorf("读取TLS证书信息失败: %w", err)
+	}
+
+	r.mu.Lock()
+	r.cert = &cert
+	r.modTime = info.ModTime()
+	r.lastCheck = time.Now()
+	r.mu.Unlock()
+	return nil
+}
+
+// GetCertificate 供tls.Config使用，证书文件修改后自动重新加载
+func (r *CertReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.maybeReload()
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cert, nil
+}
+
+// maybeReload 按间隔检查证书文件的修改时间
+func (r *CertReloader) maybeReload() {
+	r.mu.Lock()
+	if time.Since(r.lastCheck) < certCheckInterval {
+		r.mu.Unlock()
+		return
+	}
+	r.lastCheck = time.Now()
+	modTime := r.modTime
+	r.mu.Unlock()
+
+	info, err := os.Stat(r.certFile)
+	if err != nil || !info.ModTime().After(modTime) {
+		return
+	}
+
+	if err := r.Reload(); err != nil {
+		// 加载失败时继续使用旧证书
+		serverLog.Error("重新加载TLS证书失败", "error", err)
+		return
+	}
+	serverLog.Info("TLS证书已重新加载")
+}
+
+// WatchSignal 收到SIGHUP时重新加载证书，直到ctx被取消
+func (r *CertReloader) WatchSignal(ctx context.Context) {
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, syscall.SIGHUP)
+	defer signal.Stop(ch)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ch:
+			if err := r.Reload(); err != nil {
+				serverLog.Error("重新加载TLS证书失败", "error", err)
+				continue
+			}
+			serverLog.Info("收到SIGHUP，TLS证书已重新加载")
+		}
+	}
+}
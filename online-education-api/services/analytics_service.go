@@ -0,0 +1,481 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"online-education-api/config"
+	"online-education-api/models"
+	"online-education-api/repository"
+)
+
+// 默认的播放统计配置，AnalyticsConfig中对应的字段为零值时使用
+const (
+	defaultDedupWindow        = 30 * time.Minute
+	defaultStatsFlushInterval = 10 * time.Second
+	defaultHeartbeatInterval  = 15 * time.Second
+	defaultMaxViewSessions    = 100000
+	defaultSessionRateLimit   = 30
+	defaultSessionRateWindow  = time.Minute
+)
+
+// videoInfoTTL 缓存视频时长和可见性的时间，修改可见性后最多延迟这段时间对播放事件生效
+const videoInfoTTL = time.Minute
+
+// ErrViewSessionsFull 播放会话数达到上限，暂时不接受新的匿名会话
+var ErrViewSessionsFull = errors.New("播放会话过多，请稍后再试")
+
+// maxPlaybackRate 判断两次事件之间是否连续播放时允许的最大倍速
+const maxPlaybackRate = 2
+
+// AnalyticsService 视频播放统计服务接口
+//
+// 播放器开始播放时发送play事件，播放期间每隔心跳间隔发送heartbeat事件。同一用户(未登录时为同一会话)
+// 在去重窗口内重复播放同一视频只计一次观看；心跳累计观看时长，并记录连续播放经过的时长分段，用于计算观众留存和完播率。
+// 计数和登录用户的观看进度先在内存中缓冲，由 Run 定期批量写入数据库；多实例部署时各实例分别去重。
+type AnalyticsService interface {
+	// RecordEvent 记录播放事件，私有视频只接受作者和管理员的事件，其他用户返回ErrVideoNotFound。
+	// 新的匿名会话按clientIP限流，超过限制时返回*RateLimitError，会话数达到上限时返回ErrViewSessionsFull
+	RecordEvent(ctx context.Context, videoID int, userID int64, isAdmin bool, clientIP string, event *models.ViewEventRequest) error
+	// GetVideoStats 获取视频的播放统计，包含尚未写入数据库的计数，只有作者和管理员可以查看
+	GetVideoStats(ctx context.Context, videoID int, userID int64, isAdmin bool) (*models.VideoStats, error)
+	// Flush 将缓冲的计数和观看进度写入数据库，失败时保留到下次写入
+	Flush(ctx context.Context) error
+	// Run 定期写入缓冲的计数并清理过期的播放会话，直到ctx被取消；退出后需要调用 Flush 写入剩余的计数
+	Run(ctx context.Context)
+}
+
+// viewSession 一个用户或会话观看一个视频的状态
+type viewSession struct {
+	duration  float64   // 视频时长(秒)，为0时不统计留存和完播
+	startedAt time.Time // 最近一次计为观看的时间，为零值时尚未计数
+	lastSeen  time.Time
+	position  float64
+	reached   [models.RetentionBuckets]bool
+	completed bool
+}
+
+// cachedVideo 缓存的视频时长和可见性，新的播放会话不需要每次读取数据库
+type cachedVideo struct {
+	video    *models.Video // 只包含检查权限和统计需要的字段
+	loadedAt time.Time
+}
+
+// analyticsService 播放统计服务实现
+type analyticsService struct {
+	videos repository.VideoRepository
+	cfg    config.AnalyticsConfig
+	now    func() time.Time
+
+	mu          sync.Mutex
+	sessions    map[string]*viewSession
+	newSessions map[string][]time.Time // 每个IP在限流窗口内开始匿名会话的时间，从早到晚
+	videoInfo   map[int]*cachedVideo
+	pending     map[int]*models.VideoStatsDelta
+	watched     map[int]time.Duration // 尚未写入的观看时长，不足1秒的部分留到下次写入
+	progress    map[progressKey]*models.VideoProgress
+}
+
+// progressKey 用户和视频，同一用户对同一视频的进度只保留最新的一条
+type progressKey struct {
+	userID  int64
+	videoID int
+}
+
+// NewAnalyticsService 创建播放统计服务实例，cfg中为零值的字段使用默认值
+func NewAnalyticsService(videos repository.VideoRepository, cfg *config.AnalyticsConfig) AnalyticsService {
+	c := *cfg
+	if c.DedupWindow <= 0 {
+		c.DedupWindow = defaultDedupWindow
+	}
+	if c.FlushInterval <= 0 {
+		c.FlushInterval = defaultStatsFlushInterval
+	}
+	if c.HeartbeatInterval <= 0 {
+		c.HeartbeatInterval = defaultHeartbeatInterval
+	}
+	if c.MaxSessions <= 0 {
+		c.MaxSessions = defaultMaxViewSessions
+	}
+	if c.SessionRateLimit <= 0 {
+		c.SessionRateLimit = defaultSessionRateLimit
+	}
+	if c.SessionRateWindow <= 0 {
+		c.SessionRateWindow = defaultSessionRateWindow
+	}
+	return &analyticsService{
+		videos:      videos,
+		cfg:         c,
+		now:         time.Now,
+		sessions:    make(map[string]*viewSession),
+		newSessions: make(map[string][]time.Time),
+		videoInfo:   make(map[int]*cachedVideo),
+		pending:     make(map[int]*models.VideoStatsDelta),
+		watched:     make(map[int]time.Duration),
+		progress:    make(map[progressKey]*models.VideoProgress),
+	}
+}
+
+// UseAnalytics 按配置替换播放统计服务，需要在处理请求之前调用
+func (s *Services) UseAnalytics(cfg *config.AnalyticsConfig) {
+	s.Analytics = NewAnalyticsService(s.repos.Videos, cfg)
+}
+
+// viewerKey 返回播放会话的键，登录用户按用户去重，未登录时按播放器生成的会话ID去重
+func viewerKey(videoID int, userID int64, sessionID string) string {
+	if userID > 0 {
+		return strconv.Itoa(videoID) + ":u" + strconv.FormatInt(userID, 10)
+	}
+	return strconv.Itoa(videoID) + ":s" + sessionID
+}
+
+// RecordEvent 记录播放事件
+func (s *analyticsService) RecordEvent(ctx context.Context, videoID int, userID int64, isAdmin bool, clientIP string, event *models.ViewEventRequest) error {
+	key := viewerKey(videoID, userID, event.SessionID)
+
+	// 新的会话需要检查权限和读取时长，视频不在缓存中时读取数据库，读取期间不持有锁
+	s.mu.Lock()
+	_, ok := s.liveSession(key)
+	if !ok && userID == 0 {
+		if err := s.admitAnonymous(clientIP); err != nil {
+			s.mu.Unlock()
+			return err
+		}
+	}
+	s.mu.Unlock()
+	var duration float64
+	if !ok {
+		video, err := s.visibleVideo(ctx, videoID, userID, isAdmin)
+		if err != nil {
+			return err
+		}
+		duration = float64(video.Duration)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	session, ok := s.liveSession(key)
+	if !ok {
+		// 读取视频期间其他请求可能已经占满会话
+		if userID == 0 && len(s.sessions) >= s.cfg.MaxSessions {
+			return ErrViewSessionsFull
+		}
+		session = &viewSession{duration: duration, lastSeen: now, position: event.Position}
+		s.sessions[key] = session
+	}
+	delta := s.delta(videoID)
+	viewEventsTotal.Inc(event.Type)
+
+	switch event.Type {
+	case models.ViewEventPlay:
+		// 去重窗口内重新播放只更新进度
+		if session.startedAt.IsZero() || now.Sub(session.startedAt) >= s.cfg.DedupWindow {
+			s.startView(session, delta, now)
+		}
+		session.reach(event.Position, event.Position, delta)
+	case models.ViewEventHeartbeat:
+		// 服务重启或会话过期后只收到心跳时从当前位置开始计数
+		if session.startedAt.IsZero() {
+			s.startView(session, delta, now)
+			session.reach(event.Position, event.Position, delta)
+			break
+		}
+		elapsed := min(now.Sub(session.lastSeen), 2*s.cfg.HeartbeatInterval)
+		s.watched[videoID] += elapsed
+		// 连续播放时经过的分段都计为看过，拖动进度条时只计落点所在的分段
+		from := event.Position
+		if advance := event.Position - session.position; advance >= 0 && advance <= elapsed.Seconds()*maxPlaybackRate+1 {
+			from = session.position
+		}
+		session.reach(from, event.Position, delta)
+	}
+	session.lastSeen = now
+	session.position = event.Position
+
+	// 登录用户记录观看进度，看完标记在写入数据库之前也不会被新的观看清除
+	if userID > 0 {
+		key := progressKey{userID, videoID}
+		completed := session.completed
+		if p, ok := s.progress[key]; ok {
+			completed = completed || p.Completed
+		}
+		s.progress[key] = &models.VideoProgress{UserID: userID, VideoID: videoID, Position: event.Position, Completed: completed, UpdatedAt: now}
+	}
+	return nil
+}
+
+// admitAnonymous 检查能否开始新的匿名会话，允许时记录本次开始的时间，调用方需持有锁
+func (s *analyticsService) admitAnonymous(clientIP string) error {
+	if len(s.sessions) >= s.cfg.MaxSessions {
+		return ErrViewSessionsFull
+	}
+
+	now := s.now()
+	start := now.Add(-s.cfg.SessionRateWindow)
+	started := s.newSessions[clientIP]
+	// 丢弃窗口之前的记录
+	i := 0
+	for i < len(started) && !started[i].After(start) {
+		i++
+	}
+	started = started[i:]
+	if len(started) >= s.cfg.SessionRateLimit {
+		s.newSessions[clientIP] = started
+		return &RateLimitError{RetryAfter: started[0].Sub(start)}
+	}
+	s.newSessions[clientIP] = append(started, now)
+	return nil
+}
+
+// visibleVideo 获取当前用户可以观看的视频，优先使用缓存
+func (s *analyticsService) visibleVideo(ctx context.Context, id int, userID int64, isAdmin bool) (*models.Video, error) {
+	var video *models.Video
+	s.mu.Lock()
+	if cached, ok := s.videoInfo[id]; ok && s.now().Sub(cached.loadedAt) < videoInfoTTL {
+		video = cached.video
+	}
+	s.mu.Unlock()
+
+	if video == nil {
+		ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+		defer cancel()
+
+		v, err := s.videos.GetByID(ctx, id)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return nil, ErrVideoNotFound
+			}
+			return nil, fmt.Errorf("获取视频失败: %w", err)
+		}
+		video = &models.Video{ID: v.ID, Duration: v.Duration, AuthorID: v.AuthorID, Visibility: v.Visibility}
+		s.mu.Lock()
+		s.videoInfo[id] = &cachedVideo{video: video, loadedAt: s.now()}
+		s.mu.Unlock()
+	}
+	if !canViewVideo(video, userID, isAdmin) {
+		return nil, ErrVideoNotFound
+	}
+	return video, nil
+}
+
+// liveSession 返回未过期的播放会话，调用方需持有锁
+func (s *analyticsService) liveSession(key string) (*viewSession, bool) {
+	session, ok := s.sessions[key]
+	if !ok || s.now().Sub(session.lastSeen) > s.cfg.DedupWindow {
+		return nil, false
+	}
+	return session, true
+}
+
+// delta 返回视频尚未写入的计数，调用方需持有锁
+func (s *analyticsService) delta(videoID int) *models.VideoStatsDelta {
+	delta, ok := s.pending[videoID]
+	if !ok {
+		delta = &models.VideoStatsDelta{VideoID: videoID}
+		s.pending[videoID] = delta
+	}
+	return delta
+}
+
+// startView 计为一次新的观看，重新统计经过的分段
+func (s *analyticsService) startView(session *viewSession, delta *models.VideoStatsDelta, now time.Time) {
+	session.startedAt = now
+	session.reached = [models.RetentionBuckets]bool{}
+	session.completed = false
+	delta.Views++
+	viewsTotal.Inc()
+}
+
+// bucket 返回播放位置所在的分段
+func (v *viewSession) bucket(position float64) int {
+	b := int(position / v.duration * models.RetentionBuckets)
+	return max(0, min(b, models.RetentionBuckets-1))
+}
+
+// reach 记录从from到to经过的分段，每次观看每段只计一次，到达最后一段时计为看完
+func (v *viewSession) reach(from, to float64, delta *models.VideoStatsDelta) {
+	if v.duration <= 0 {
+		return
+	}
+	for b := v.bucket(from); b <= v.bucket(to); b++ {
+		if !v.reached[b] {
+			v.reached[b] = true
+			delta.Retention[b]++
+		}
+	}
+	if !v.completed && v.reached[models.RetentionBuckets-1] {
+		v.completed = true
+		delta.Completions++
+	}
+}
+
+// GetVideoStats 获取视频的播放统计
+func (s *analyticsService) GetVideoStats(ctx context.Context, videoID int, userID int64, isAdmin bool) (*models.VideoStats, error) {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
+	video, err := s.videos.GetByID(ctx, videoID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrVideoNotFound
+		}
+		return nil, fmt.Errorf("获取视频失败: %w", err)
+	}
+	if video.AuthorID != userID && !isAdmin {
+		return nil, ErrVideoForbidden
+	}
+
+	stats, err := s.videos.Stats(ctx, videoID)
+	if err != nil {
+		return nil, fmt.Errorf("获取播放统计失败: %w", err)
+	}
+
+	s.mu.Lock()
+	if delta, ok := s.pending[videoID]; ok {
+		stats.Views += delta.Views
+		stats.Completions += delta.Completions
+		for i, viewers := range delta.Retention {
+			stats.Retention[i].Viewers += viewers
+		}
+	}
+	stats.WatchSeconds += int64(s.watched[videoID] / time.Second)
+	s.mu.Unlock()
+
+	if stats.Views > 0 {
+		stats.AvgWatchSeconds = float64(stats.WatchSeconds) / float64(stats.Views)
+		stats.CompletionRate = float64(stats.Completions) / float64(stats.Views)
+	}
+	for i := range stats.Retention {
+		stats.Retention[i].Position = float64(i) / models.RetentionBuckets
+		if stats.Views > 0 {
+			stats.Retention[i].Rate = float64(stats.Retention[i].Viewers) / float64(stats.Views)
+		}
+	}
+	return stats, nil
+}
+
+// Flush 写入缓冲的计数
+func (s *analyticsService) Flush(ctx context.Context) error {
+	s.mu.Lock()
+	for videoID, d := range s.watched {
+		if seconds := d / time.Second; seconds > 0 {
+			s.delta(videoID).WatchSeconds += int64(seconds)
+			s.watched[videoID] = d % time.Second
+		}
+	}
+	pending := s.pending
+	s.pending = make(map[int]*models.VideoStatsDelta)
+	pendingProgress := s.progress
+	s.progress = make(map[progressKey]*models.VideoProgress)
+	s.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(ctx, writeTimeout)
+	defer cancel()
+
+	var errs []error
+	if len(pending) > 0 {
+		deltas := make([]*models.VideoStatsDelta, 0, len(pending))
+		for _, delta := range pending {
+			deltas = append(deltas, delta)
+		}
+		if err := s.videos.AddStats(ctx, deltas); err != nil {
+			s.restore(deltas)
+			errs = append(errs, fmt.Errorf("写入播放统计失败: %w", err))
+		}
+	}
+	if len(pendingProgress) > 0 {
+		progress := make([]*models.VideoProgress, 0, len(pendingProgress))
+		for _, p := range pendingProgress {
+			progress = append(progress, p)
+		}
+		if err := s.videos.SaveProgress(ctx, progress); err != nil {
+			s.restoreProgress(progress)
+			errs = append(errs, fmt.Errorf("写入观看进度失败: %w", err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// restoreProgress 写入失败时将进度放回缓冲，期间收到的更新的进度优先
+func (s *analyticsService) restoreProgress(progress []*models.VideoProgress) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, p := range progress {
+		key := progressKey{p.UserID, p.VideoID}
+		if newer, ok := s.progress[key]; ok {
+			newer.Completed = newer.Completed || p.Completed
+			continue
+		}
+		s.progress[key] = p
+	}
+}
+
+// restore 写入失败时将计数合并回缓冲
+func (s *analyticsService) restore(deltas []*models.VideoStatsDelta) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, d := range deltas {
+		delta := s.delta(d.VideoID)
+		delta.Views += d.Views
+		delta.WatchSeconds += d.WatchSeconds
+		delta.Completions += d.Completions
+		for i, viewers := range d.Retention {
+			delta.Retention[i] += viewers
+		}
+	}
+}
+
+// sweep 删除最后一个事件早于去重窗口的播放会话，以及过期的限流记录和视频缓存
+func (s *analyticsService) sweep() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	for key, session := range s.sessions {
+		if now.Sub(session.lastSeen) > s.cfg.DedupWindow {
+			delete(s.sessions, key)
+		}
+	}
+	for videoID, d := range s.watched {
+		if d == 0 {
+			delete(s.watched, videoID)
+		}
+	}
+	start := now.Add(-s.cfg.SessionRateWindow)
+	for ip, started := range s.newSessions {
+		if len(started) == 0 || !started[len(started)-1].After(start) {
+			delete(s.newSessions, ip)
+		}
+	}
+	for id, cached := range s.videoInfo {
+		if now.Sub(cached.loadedAt) >= videoInfoTTL {
+			delete(s.videoInfo, id)
+		}
+	}
+}
+
+// Run 后台写入任务
+func (s *analyticsService) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.cfg.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		s.sweep()
+		if err := s.Flush(ctx); err != nil && ctx.Err() == nil {
+			serviceLog.Error("写入播放统计失败", "error", err)
+		}
+	}
+}
@@ -0,0 +1,210 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"online-education-api/config"
+	"online-education-api/models"
+	"online-education-api/repository"
+	"online-education-api/repository/memory"
+)
+
+// newTestAnalytics 创建使用可控时钟的播放统计服务，视频时长为100秒
+func newTestAnalytics(t *testing.T) (*analyticsService, *repository.Repositories, *models.Video, *time.Time) {
+	t.Helper()
+
+	repos := memory.New()
+	video := &models.Video{Title: "Go", VideoURL: "/v.mp4", Duration: 100, AuthorID: 10, Visibility: models.VideoVisibilityPublic}
+	if err := repos.Videos.Create(context.Background(), video); err != nil {
+		t.Fatal(err)
+	}
+	svc := NewAnalyticsService(repos.Videos, &config.AnalyticsConfig{DedupWindow: time.Hour, HeartbeatInterval: 10 * time.Second}).(*analyticsService)
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	svc.now = func() time.Time { return now }
+	return svc, repos, video, &now
+}
+
+func TestAnalyticsDeduplicatesViews(t *testing.T) {
+	ctx := context.Background()
+	svc, _, video, now := newTestAnalytics(t)
+
+	play := func(userID int64, session string) {
+		t.Helper()
+		if err := svc.RecordEvent(ctx, video.ID, userID, false, "127.0.0.1", &models.ViewEventRequest{Type: models.ViewEventPlay, SessionID: session}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 登录用户换会话重复播放、匿名会话重复播放都只计一次
+	play(1, "a")
+	play(1, "b")
+	play(0, "c")
+	play(0, "c")
+	play(0, "d")
+	*now = now.Add(time.Hour)
+	play(1, "a")
+
+	stats, err := svc.GetVideoStats(ctx, video.ID, video.AuthorID, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Views != 4 {
+		t.Errorf("Views = %d, want 4", stats.Views)
+	}
+	if _, err := svc.GetVideoStats(ctx, video.ID, 11, false); !errors.Is(err, ErrVideoForbidden) {
+		t.Errorf("stats by another user err = %v, want ErrVideoForbidden", err)
+	}
+}
+
+func TestAnalyticsWatchTimeAndRetention(t *testing.T) {
+	ctx := context.Background()
+	svc, repos, video, now := newTestAnalytics(t)
+
+	event := func(userID int64, typ string, position float64, after time.Duration) {
+		t.Helper()
+		*now = now.Add(after)
+		if err := svc.RecordEvent(ctx, video.ID, userID, false, "127.0.0.1", &models.ViewEventRequest{Type: typ, SessionID: "s", Position: position}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 用户1连续播放到结尾，中途暂停的时间只按两倍心跳间隔计入
+	event(1, models.ViewEventPlay, 0, 0)
+	for position := 10.0; position <= 100; position += 10 {
+		event(1, models.ViewEventHeartbeat, position, 10*time.Second)
+	}
+	event(1, models.ViewEventHeartbeat, 100, time.Minute)
+	// 用户2看了前20秒后拖到结尾，跳过的分段不计入留存
+	event(2, models.ViewEventPlay, 0, 0)
+	event(2, models.ViewEventHeartbeat, 20, 10*time.Second)
+	event(2, models.ViewEventHeartbeat, 99, 10*time.Second)
+
+	if err := svc.Flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+	stats, err := svc.GetVideoStats(ctx, video.ID, 0, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Views != 2 || stats.WatchSeconds != 140 || stats.AvgWatchSeconds != 70 {
+		t.Errorf("stats = %d views, %d seconds, avg %v; want 2, 140, 70", stats.Views, stats.WatchSeconds, stats.AvgWatchSeconds)
+	}
+	if stats.Completions != 2 || stats.CompletionRate != 1 {
+		t.Errorf("completions = %d, rate %v; want 2, 1", stats.Completions, stats.CompletionRate)
+	}
+	if len(stats.Retention) != models.RetentionBuckets {
+		t.Fatalf("len(Retention) = %d, want %d", len(stats.Retention), models.RetentionBuckets)
+	}
+	for i, want := range map[int]int{0: 2, 4: 2, 5: 1, 18: 1, 19: 2} {
+		if got := stats.Retention[i].Viewers; got != want {
+			t.Errorf("Retention[%d].Viewers = %d, want %d", i, got, want)
+		}
+	}
+	if stats.Retention[5].Position != 0.25 || stats.Retention[5].Rate != 0.5 {
+		t.Errorf("Retention[5] = %+v, want position 0.25, rate 0.5", stats.Retention[5])
+	}
+
+	// 写入数据库后观看次数同步到视频
+	got, err := repos.Videos.GetByID(ctx, video.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.ViewCount != 2 {
+		t.Errorf("ViewCount = %d, want 2", got.ViewCount)
+	}
+}
+
+func TestAnalyticsPrivateVideo(t *testing.T) {
+	ctx := context.Background()
+	svc, repos, video, _ := newTestAnalytics(t)
+	video.Visibility = models.VideoVisibilityPrivate
+	if err := repos.Videos.Update(ctx, video); err != nil {
+		t.Fatal(err)
+	}
+
+	event := &models.ViewEventRequest{Type: models.ViewEventPlay, SessionID: "s"}
+	if err := svc.RecordEvent(ctx, video.ID, 11, false, "127.0.0.1", event); !errors.Is(err, ErrVideoNotFound) {
+		t.Errorf("event by another user err = %v, want ErrVideoNotFound", err)
+	}
+	if err := svc.RecordEvent(ctx, 999, 0, false, "127.0.0.1", event); !errors.Is(err, ErrVideoNotFound) {
+		t.Errorf("event for missing video err = %v, want ErrVideoNotFound", err)
+	}
+	if err := svc.RecordEvent(ctx, video.ID, video.AuthorID, false, "127.0.0.1", event); err != nil {
+		t.Errorf("event by author err = %v", err)
+	}
+}
+
+func TestAnalyticsLimitsAnonymousSessions(t *testing.T) {
+	ctx := context.Background()
+	repos := memory.New()
+	video := &models.Video{Title: "Go", VideoURL: "/v.mp4", Duration: 100, AuthorID: 10, Visibility: models.VideoVisibilityPublic}
+	if err := repos.Videos.Create(ctx, video); err != nil {
+		t.Fatal(err)
+	}
+	svc := NewAnalyticsService(repos.Videos, &config.AnalyticsConfig{MaxSessions: 3, SessionRateLimit: 2, SessionRateWindow: time.Minute}).(*analyticsService)
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	svc.now = func() time.Time { return now }
+
+	play := func(userID int64, ip, session string) error {
+		return svc.RecordEvent(ctx, video.ID, userID, false, ip, &models.ViewEventRequest{Type: models.ViewEventPlay, SessionID: session})
+	}
+	// 同一IP轮换会话ID时超过限制的新会话被拒绝，已有会话的事件不受影响
+	for _, session := range []string{"a", "b"} {
+		if err := play(0, "1.1.1.1", session); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var rateErr *RateLimitError
+	if err := play(0, "1.1.1.1", "c"); !errors.As(err, &rateErr) || rateErr.RetryAfter != time.Minute {
+		t.Errorf("third session err = %v, want RateLimitError after 1m", err)
+	}
+	if err := play(0, "1.1.1.1", "a"); err != nil {
+		t.Errorf("existing session err = %v", err)
+	}
+
+	// 会话数达到上限后拒绝新的匿名会话，登录用户不受影响
+	if err := play(0, "2.2.2.2", "d"); err != nil {
+		t.Fatal(err)
+	}
+	if err := play(0, "3.3.3.3", "e"); !errors.Is(err, ErrViewSessionsFull) {
+		t.Errorf("session over limit err = %v, want ErrViewSessionsFull", err)
+	}
+	if err := play(1, "3.3.3.3", "e"); err != nil {
+		t.Errorf("logged-in session err = %v", err)
+	}
+
+	// 会话和限流记录过期后可以重新开始
+	now = now.Add(time.Hour)
+	svc.sweep()
+	if len(svc.sessions) != 0 || len(svc.newSessions) != 0 {
+		t.Fatalf("sweep left %d sessions, %d rate records", len(svc.sessions), len(svc.newSessions))
+	}
+	if err := play(0, "1.1.1.1", "c"); err != nil {
+		t.Errorf("session after sweep err = %v", err)
+	}
+}
+
+func TestAnalyticsCachesVideoInfo(t *testing.T) {
+	ctx := context.Background()
+	svc, repos, video, now := newTestAnalytics(t)
+
+	play := func(userID int64) error {
+		return svc.RecordEvent(ctx, video.ID, userID, false, "127.0.0.1", &models.ViewEventRequest{Type: models.ViewEventPlay, SessionID: "s"})
+	}
+	if err := play(1); err != nil {
+		t.Fatal(err)
+	}
+	// 缓存有效期内新会话不读取数据库，修改可见性延迟生效
+	video.Visibility = models.VideoVisibilityPrivate
+	if err := repos.Videos.Update(ctx, video); err != nil {
+		t.Fatal(err)
+	}
+	if err := play(2); err != nil {
+		t.Errorf("cached video err = %v", err)
+	}
+	*now = now.Add(videoInfoTTL)
+	if err := play(3); !errors.Is(err, ErrVideoNotFound) {
+		t.Errorf("expired cache err = %v, want ErrVideoNotFound", err)
+	}
+}
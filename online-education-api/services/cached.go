@@ -0,0 +1,271 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"online-education-api/cache"
+	"online-education-api/models"
+)
+
+// 缓存键前缀，写操作按前缀失效
+const (
+	courseCachePrefix   = "course:"
+	categoryCachePrefix = "category:"
+	videoCachePrefix    = "video:"
+)
+
+// UseCache 为课程、课程分类和视频分类的读取接口启用读穿缓存
+// 课程、分类、视频和评价的写操作会使相关缓存失效；学生数量等由其他服务修改的字段最多延迟ttl后更新。
+// 进程内缓存只能使本实例的缓存失效，多实例部署时需要使用共享缓存或较短的ttl。
+func (s *Services) UseCache(c cache.Cache, ttl time.Duration) {
+	s.Course = &cachedCourseService{CourseService: s.Course, cache: c, ttl: ttl}
+	s.CourseCategory = &cachedCourseCategoryService{CourseCategoryService: s.CourseCategory, cache: c, ttl: ttl}
+	s.Video = &cachedVideoService{VideoService: s.Video, cache: c, ttl: ttl}
+	s.Review = &cachedReviewService{ReviewService: s.Review, cache: c}
+	s.Image = &cachedImageService{ImageService: s.Image, cache: c}
+}
+
+// readThrough 读取缓存，未命中时调用load并写入缓存，load返回错误时不缓存
+// 缓存的值是JSON，每次命中都解码出新的对象，调用方修改返回值不会影响缓存
+func readThrough[T any](ctx context.Context, c cache.Cache, ttl time.Duration, key string, load func() (T, error)) (T, error) {
+	if data, ok := c.Get(ctx, key); ok {
+		var v T
+		if err := json.Unmarshal(data, &v); err == nil {
+			cacheRequestsTotal.Inc("hit")
+			return v, nil
+		}
+		serviceLog.WarnContext(ctx, "缓存数据无法解析", "key", key)
+	}
+	cacheRequestsTotal.Inc("miss")
+
+	v, err := load()
+	if err != nil {
+		return v, err
+	}
+	if data, err := json.Marshal(v); err == nil {
+		c.Set(ctx, key, data, ttl)
+	}
+	return v, nil
+}
+
+// cachedCourseService 带缓存的课程服务
+type cachedCourseService struct {
+	CourseService
+	cache cache.Cache
+	ttl   time.Duration
+}
+
+// GetCourseList 获取课程列表，查询条件(包括可见范围)不同的结果分别缓存
+func (s *cachedCourseService) GetCourseList(ctx context.Context, q models.CourseListQuery) (*models.CourseListResult, error) {
+	key, err := json.Marshal(q)
+	if err != nil {
+		return nil, err
+	}
+	return readThrough(ctx, s.cache, s.ttl, courseCachePrefix+"list:"+string(key), func() (*models.CourseListResult, error) {
+		return s.CourseService.GetCourseList(ctx, q)
+	})
+}
+
+// GetCoursesByCategory 根据分类ID获取已上架的课程列表
+func (s *cachedCourseService) GetCoursesByCategory(ctx context.Context, categoryID int64, page, pageSize int) ([]*models.CourseResponse, int, error) {
+	result, err := s.GetCourseList(ctx, models.CourseListQuery{Page: page, PageSize: pageSize, CategoryID: categoryID})
+	if err != nil {
+		return nil, 0, err
+	}
+	return result.List, result.Total, nil
+}
+
+// GetCourseDetail 获取课程详情
+func (s *cachedCourseService) GetCourseDetail(ctx context.Context, id int64) (*models.CourseDetailResponse, error) {
+	key := fmt.Sprintf("%sdetail:%d", courseCachePrefix, id)
+	return readThrough(ctx, s.cache, s.ttl, key, func() (*models.CourseDetailResponse, error) {
+		return s.CourseService.GetCourseDetail(ctx, id)
+	})
+}
+
+// CreateCourse 创建课程
+func (s *cachedCourseService) CreateCourse(ctx context.Context, course *models.Course) error {
+	defer s.cache.DeletePrefix(ctx, courseCachePrefix)
+	return s.CourseService.CreateCourse(ctx, course)
+}
+
+// UpdateCourse 更新课程
+func (s *cachedCourseService) UpdateCourse(ctx context.Context, course *models.Course) error {
+	defer s.cache.DeletePrefix(ctx, courseCachePrefix)
+	return s.CourseService.UpdateCourse(ctx, course)
+}
+
+// DeleteCourse 删除课程
+func (s *cachedCourseService) DeleteCourse(ctx context.Context, id int64) error {
+	defer s.cache.DeletePrefix(ctx, courseCachePrefix)
+	return s.CourseService.DeleteCourse(ctx, id)
+}
+
+// ImportCourse 导入课程
+func (s *cachedCourseService) ImportCourse(ctx context.Context, course *models.Course, chapters []*models.Chapter) error {
+	defer s.cache.DeletePrefix(ctx, courseCachePrefix)
+	return s.CourseService.ImportCourse(ctx, course, chapters)
+}
+
+// cachedCourseCategoryService 带缓存的课程分类服务
+type cachedCourseCategoryService struct {
+	CourseCategoryService
+	cache cache.Cache
+	ttl   time.Duration
+}
+
+// GetAllCategories 获取所有课程分类
+func (s *cachedCourseCategoryService) GetAllCategories(ctx context.Context) ([]*models.CourseCategoryResponse, error) {
+	return readThrough(ctx, s.cache, s.ttl, categoryCachePrefix+"all", func() ([]*models.CourseCategoryResponse, error) {
+		return s.CourseCategoryService.GetAllCategories(ctx)
+	})
+}
+
+// GetCategoryByID 根据ID获取课程分类
+func (s *cachedCourseCategoryService) GetCategoryByID(ctx context.Context, id int64) (*models.CourseCategoryResponse, error) {
+	key := fmt.Sprintf("%s%d", categoryCachePrefix, id)
+	return readThrough(ctx, s.cache, s.ttl, key, func() (*models.CourseCategoryResponse, error) {
+		return s.CourseCategoryService.GetCategoryByID(ctx, id)
+	})
+}
+
+// invalidate 使分类缓存失效，课程列表和详情包含分类信息，一并失效
+func (s *cachedCourseCategoryService) invalidate(ctx context.Context) {
+	s.cache.DeletePrefix(ctx, categoryCachePrefix)
+	s.cache.DeletePrefix(ctx, courseCachePrefix)
+}
+
+// CreateCategory 创建课程分类
+func (s *cachedCourseCategoryService) CreateCategory(ctx context.Context, category *models.CourseCategory) error {
+	defer s.invalidate(ctx)
+	return s.CourseCategoryService.CreateCategory(ctx, category)
+}
+
+// UpdateCategory 更新课程分类
+func (s *cachedCourseCategoryService) UpdateCategory(ctx context.Context, category *models.CourseCategory) error {
+	defer s.invalidate(ctx)
+	return s.CourseCategoryService.UpdateCategory(ctx, category)
+}
+
+// DeleteCategory 删除课程分类
+func (s *cachedCourseCategoryService) DeleteCategory(ctx context.Context, id int64) error {
+	defer s.invalidate(ctx)
+	return s.CourseCategoryService.DeleteCategory(ctx, id)
+}
+
+// cachedVideoService 带缓存的视频服务，只缓存视频分类
+// 分类包含视频数量，视频的创建、更新和删除也会使分类缓存失效
+type cachedVideoService struct {
+	VideoService
+	cache cache.Cache
+	ttl   time.Duration
+}
+
+// GetVideoCategories 获取视频分类
+func (s *cachedVideoService) GetVideoCategories(ctx context.Context) ([]models.VideoCategory, error) {
+	return readThrough(ctx, s.cache, s.ttl, videoCachePrefix+"categories", func() ([]models.VideoCategory, error) {
+		return s.VideoService.GetVideoCategories(ctx)
+	})
+}
+
+// GetVideoCategoryTree 获取视频分类树
+func (s *cachedVideoService) GetVideoCategoryTree(ctx context.Context) ([]*models.VideoCategory, error) {
+	return readThrough(ctx, s.cache, s.ttl, videoCachePrefix+"categories:tree", func() ([]*models.VideoCategory, error) {
+		return s.VideoService.GetVideoCategoryTree(ctx)
+	})
+}
+
+// GetVideoCategory 根据ID获取视频分类
+func (s *cachedVideoService) GetVideoCategory(ctx context.Context, id int) (*models.VideoCategory, error) {
+	key := fmt.Sprintf("%scategories:%d", videoCachePrefix, id)
+	return readThrough(ctx, s.cache, s.ttl, key, func() (*models.VideoCategory, error) {
+		return s.VideoService.GetVideoCategory(ctx, id)
+	})
+}
+
+// CreateVideo 创建视频
+func (s *cachedVideoService) CreateVideo(ctx context.Context, video *models.Video) error {
+	defer s.cache.DeletePrefix(ctx, videoCachePrefix)
+	return s.VideoService.CreateVideo(ctx, video)
+}
+
+// UpdateVideo 更新视频
+func (s *cachedVideoService) UpdateVideo(ctx context.Context, video *models.Video, userID int64, isAdmin bool) error {
+	defer s.cache.DeletePrefix(ctx, videoCachePrefix)
+	return s.VideoService.UpdateVideo(ctx, video, userID, isAdmin)
+}
+
+// DeleteVideo 删除视频
+func (s *cachedVideoService) DeleteVideo(ctx context.Context, id int, userID int64, isAdmin bool) error {
+	defer s.cache.DeletePrefix(ctx, videoCachePrefix)
+	return s.VideoService.DeleteVideo(ctx, id, userID, isAdmin)
+}
+
+// CreateVideoCategory 创建视频分类
+func (s *cachedVideoService) CreateVideoCategory(ctx context.Context, category *models.VideoCategory) error {
+	defer s.cache.DeletePrefix(ctx, videoCachePrefix)
+	return s.VideoService.CreateVideoCategory(ctx, category)
+}
+
+// UpdateVideoCategory 更新视频分类
+func (s *cachedVideoService) UpdateVideoCategory(ctx context.Context, category *models.VideoCategory) error {
+	defer s.cache.DeletePrefix(ctx, videoCachePrefix)
+	return s.VideoService.UpdateVideoCategory(ctx, category)
+}
+
+// DeleteVideoCategory 删除视频分类
+func (s *cachedVideoService) DeleteVideoCategory(ctx context.Context, id int, reassignTo *int) error {
+	defer s.cache.DeletePrefix(ctx, videoCachePrefix)
+	return s.VideoService.DeleteVideoCategory(ctx, id, reassignTo)
+}
+
+// cachedReviewService 评价写操作后使课程缓存失效，课程列表和详情包含评分和评价数
+type cachedReviewService struct {
+	ReviewService
+	cache cache.Cache
+}
+
+// CreateReview 创建评价
+func (s *cachedReviewService) CreateReview(ctx context.Context, review *models.CourseReview) error {
+	defer s.cache.DeletePrefix(ctx, courseCachePrefix)
+	return s.ReviewService.CreateReview(ctx, review)
+}
+
+// UpdateReview 修改评价
+func (s *cachedReviewService) UpdateReview(ctx context.Context, userID int64, review *models.CourseReview) error {
+	defer s.cache.DeletePrefix(ctx, courseCachePrefix)
+	return s.ReviewService.UpdateReview(ctx, userID, review)
+}
+
+// DeleteReview 删除评价
+func (s *cachedReviewService) DeleteReview(ctx context.Context, id, userID int64, isAdmin bool) error {
+	defer s.cache.DeletePrefix(ctx, courseCachePrefix)
+	return s.ReviewService.DeleteReview(ctx, id, userID, isAdmin)
+}
+
+// ModerateReview 处理评价的举报
+func (s *cachedReviewService) ModerateReview(ctx context.Context, id int64, action string) error {
+	defer s.cache.DeletePrefix(ctx, courseCachePrefix)
+	return s.ReviewService.ModerateReview(ctx, id, action)
+}
+
+// cachedImageService 图片关联和删除后使课程缓存失效，课程列表和详情包含封面和讲师头像
+type cachedImageService struct {
+	ImageService
+	cache cache.Cache
+}
+
+// AttachImage 关联图片
+func (s *cachedImageService) AttachImage(ctx context.Context, id, userID int64, isAdmin bool, entityType string, entityID int64, primary bool) (*models.Image, error) {
+	defer s.cache.DeletePrefix(ctx, courseCachePrefix)
+	return s.ImageService.AttachImage(ctx, id, userID, isAdmin, entityType, entityID, primary)
+}
+
+// DeleteImage 删除图片
+func (s *cachedImageService) DeleteImage(ctx context.Context, id, userID int64, isAdmin bool) error {
+	defer s.cache.DeletePrefix(ctx, courseCachePrefix)
+	return s.ImageService.DeleteImage(ctx, id, userID, isAdmin)
+}
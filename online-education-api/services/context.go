@@ -0,0 +1,9 @@
+package services
+
+import "time"
+
+// 数据库操作超时时间，避免慢查询或客户端断开后长时间占用连接池
+const (
+	queryTimeout = 5 * time.Second  // 读操作
+	writeTimeout = 10 * time.Second // 写操作，可能包含多条语句
+)
@@ -0,0 +1,179 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"online-education-api/config"
+	"online-education-api/models"
+	"online-education-api/pagination"
+	"online-education-api/repository/mysql"
+	"online-education-api/storage"
+)
+
+// blockingDriver 模拟慢查询的数据库驱动，每条语句都阻塞到上下文结束
+type blockingDriver struct {
+	mu        sync.Mutex
+	deadlines []time.Time
+}
+
+func (d *blockingDriver) Open(string) (driver.Conn, error) {
+	return &blockingConn{driver: d}, nil
+}
+
+// record 记录语句收到的截止时间，没有截止时间时记录零值
+func (d *blockingDriver) record(ctx context.Context) {
+	deadline, _ := ctx.Deadline()
+	d.mu.Lock()
+	d.deadlines = append(d.deadlines, deadline)
+	d.mu.Unlock()
+}
+
+type blockingConn struct {
+	driver *blockingDriver
+}
+
+func (c *blockingConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("not supported")
+}
+
+func (c *blockingConn) Close() error { return nil }
+
+func (c *blockingConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("not supported")
+}
+
+func (c *blockingConn) QueryContext(ctx context.Context, _ string, _ []driver.NamedValue) (driver.Rows, error) {
+	c.driver.record(ctx)
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (c *blockingConn) ExecContext(ctx context.Context, _ string, _ []driver.NamedValue) (driver.Result, error) {
+	c.driver.record(ctx)
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+// blockingConnector 让每个测试使用独立的驱动实例
+type blockingConnector struct {
+	driver *blockingDriver
+}
+
+func (c *blockingConnector) Connect(context.Context) (driver.Conn, error) {
+	return c.driver.Open("")
+}
+
+func (c *blockingConnector) Driver() driver.Driver { return c.driver }
+
+func newBlockingDB(t *testing.T) (*sql.DB, *blockingDriver) {
+	t.Helper()
+	d := &blockingDriver{}
+	db := sql.OpenDB(&blockingConnector{driver: d})
+	t.Cleanup(func() { db.Close() })
+	return db, d
+}
+
+// serviceCalls 覆盖各个服务的读写方法
+func serviceCalls(db *sql.DB) map[string]func(ctx context.Context) error {
+	svc := New(mysql.New(db), storage.NewMemory(), &config.UploadConfig{})
+	return map[string]func(ctx context.Context) error{
+		"CourseService.GetCourseList": func(ctx context.Context) error {
+			_, err := svc.Course.GetCourseList(ctx, models.CourseListQuery{Page: 1, PageSize: 10})
+			return err
+		},
+		"CourseService.CreateCourse": func(ctx context.Context) error {
+			return svc.Course.CreateCourse(ctx, &models.Course{Title: "Go"})
+		},
+		"CourseCategoryService.GetAllCategories": func(ctx context.Context) error {
+			_, err := svc.CourseCategory.GetAllCategories(ctx)
+			return err
+		},
+		"UserService.GetUserByID": func(ctx context.Context) error {
+			_, err := svc.User.GetUserByID(ctx, 1)
+			return err
+		},
+		"UserCourseService.EnrollCourse": func(ctx context.Context) error {
+			return svc.UserCourse.EnrollCourse(ctx, 1, 1)
+		},
+		"PaymentService.GetPaymentsByUserID": func(ctx context.Context) error {
+			_, err := svc.Payment.GetPaymentsByUserID(ctx, 1, pagination.Page{Number: 1, Size: 10})
+			return err
+		},
+		"PaymentService.UpdatePaymentStatus": func(ctx context.Context) error {
+			return svc.Payment.UpdatePaymentStatus(ctx, "ORD-1", "TX-1", "completed")
+		},
+		"PostService.GetPostDetail": func(ctx context.Context) error {
+			_, err := svc.Post.GetPostDetail(ctx, 1)
+			return err
+		},
+		"VideoService.DeleteVideo": func(ctx context.Context) error {
+			return svc.Video.DeleteVideo(ctx, 1, 1, true)
+		},
+		"CommentService.GetCommentList": func(ctx context.Context) error {
+			_, err := svc.Comment.GetCommentList(ctx, pagination.Page{Number: 1, Size: 10}, 0)
+			return err
+		},
+	}
+}
+
+func TestServicesStopWhenRequestIsCancelled(t *testing.T) {
+	db, _ := newBlockingDB(t)
+
+	for name, call := range serviceCalls(db) {
+		t.Run(name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			time.AfterFunc(20*time.Millisecond, cancel)
+
+			done := make(chan error, 1)
+			go func() { done <- call(ctx) }()
+
+			select {
+			case err := <-done:
+				if !errors.Is(err, context.Canceled) {
+					t.Fatalf("expected context.Canceled, got %v", err)
+				}
+			case <-time.After(2 * time.Second):
+				t.Fatal("service call did not return after the context was cancelled")
+			}
+		})
+	}
+}
+
+func TestServicesApplyQueryDeadline(t *testing.T) {
+	db, d := newBlockingDB(t)
+	svc := New(mysql.New(db), storage.NewMemory(), &config.UploadConfig{})
+
+	// 父上下文已有更短的截止时间时应保留该截止时间
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+	if _, err := svc.User.GetUserByID(ctx, 1); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+	}
+
+	// 父上下文没有截止时间时应使用服务层的超时设置
+	start := time.Now()
+	bg, stop := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, stop)
+	svc.User.GetUserByID(bg, 1)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if len(d.deadlines) != 2 {
+		t.Fatalf("expected 2 statements, got %d", len(d.deadlines))
+	}
+	if d.deadlines[0].IsZero() || d.deadlines[0].After(start) {
+		t.Fatalf("expected the caller's deadline to be kept, got %v", d.deadlines[0])
+	}
+	if d.deadlines[1].IsZero() {
+		t.Fatal("expected a query deadline to be applied")
+	}
+	if max := start.Add(queryTimeout + time.Second); d.deadlines[1].After(max) {
+		t.Fatalf("deadline %v exceeds queryTimeout", d.deadlines[1])
+	}
+}
@@ -1,196 +1,135 @@
 package services
 
 import (
-	"database/sql"
+	"context"
 	"errors"
 	"online-education-api/models"
-	"time"
+	"online-education-api/repository"
 )
 
 // CourseCategoryService 课程分类服务接口
 type CourseCategoryService interface {
-	GetAllCategories() ([]*models.CourseCategoryResponse, error)
-	GetCategoryByID(id int64) (*models.CourseCategoryResponse, error)
-	CreateCategory(category *models.CourseCategory) error
-	UpdateCategory(category *models.CourseCategory) error
-	DeleteCategory(id int64) error
+	GetAllCategories(ctx context.Context) ([]*models.CourseCategoryResponse, error)
+	GetCategoryByID(ctx context.Context, id int64) (*models.CourseCategoryResponse, error)
+	CreateCategory(ctx context.Context, category *models.CourseCategory) error
+	UpdateCategory(ctx context.Context, category *models.CourseCategory) error
+	DeleteCategory(ctx context.Context, id int64) error
 }
 
 // courseCategoryService 课程分类服务实现
- type courseCategoryService struct {
-	db *sql.DB
+type courseCategoryService struct {
+	categories repository.CategoryRepository
+	courses    repository.CourseRepository
 }
 
 // NewCourseCategoryService 创建课程分类服务实例
-func NewCourseCategoryService(db *sql.DB) CourseCategoryService {
-	return &courseCategoryService{db: db}
+func NewCourseCategoryService(categories repository.CategoryRepository, courses repository.CourseRepository) CourseCategoryService {
+	return &courseCategoryService{categories: categories, courses: courses}
 }
 
-// GetAllCategories 获取所有课程分类
-func (s *courseCategoryService) GetAllCategories() ([]*models.CourseCategoryResponse, error) {
-	// 查询一级分类
-	query := `SELECT id, name, parent_id, sort_order, created_at FROM course_categories WHERE parent_id IS NULL ORDER BY sort_order ASC`
-	rows, err := s.db.Query(query)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var categories []*models.CourseCategoryResponse
-	for rows.Next() {
-		var category models.CourseCategoryResponse
-		var parentID sql.NullInt64
-		
-		if err := rows.Scan(&category.ID, &category.Name, &parentID, &category.SortOrder, &category.CreatedAt); err != nil {
-			return nil, err
-		}
-		
-		if parentID.Valid {
-			category.ParentID = &parentID.Int64
-		}
-		
-		// 查询子分类
-		children, err := s.getChildrenCategories(category.ID)
-		if err != nil {
-			return nil, err
-		}
-		category.Children = children
-		
-		categories = append(categories, &category)
-	}
+// GetAllCategories 获取所有课程分类，一次查询取出全部分类后在内存中组装任意层级的分类树
+func (s *courseCategoryService) GetAllCategories(ctx context.Context) ([]*models.CourseCategoryResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
 
-	if err = rows.Err(); err != nil {
+	categories, err := s.categories.ListAll(ctx)
+	if err != nil {
 		return nil, err
 	}
 
-	return categories, nil
+	roots, _ := buildCategoryTree(categories)
+	return roots, nil
 }
 
-// getChildrenCategories 获取子分类
-func (s *courseCategoryService) getChildrenCategories(parentID int64) ([]*models.CourseCategoryResponse, error) {
-	query := `SELECT id, name, parent_id, sort_order, created_at FROM course_categories WHERE parent_id = ? ORDER BY sort_order ASC`
-	rows, err := s.db.Query(query, parentID)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var children []*models.CourseCategoryResponse
-	for rows.Next() {
-		var child models.CourseCategoryResponse
-		var childParentID sql.NullInt64
-		
-		if err := rows.Scan(&child.ID, &child.Name, &childParentID, &child.SortOrder, &child.CreatedAt); err != nil {
-			return nil, err
-		}
-		
-	if childParentID.Valid {
-		child.ParentID = &childParentID.Int64
-	}
-	
-	// 递归获取子分类
-	grandChildren, err := s.getChildrenCategories(child.ID)
-	if err != nil {
-		return nil, err
-	}
-	child.Children = grandChildren
-	
-	children = append(children, &child)
+// buildCategoryTree 将按排序返回的分类组装为分类树，返回一级分类和按ID索引的全部节点
+// 父分类不存在的分类不会出现在树中；parent_id成环的分类也无法从一级分类到达，不会导致死循环
+func buildCategoryTree(categories []*models.CourseCategory) ([]*models.CourseCategoryResponse, map[int64]*models.CourseCategoryResponse) {
+	nodes := make(map[int64]*models.CourseCategoryResponse, len(categories))
+	for _, category := range categories {
+		nodes[category.ID] = categoryResponse(category)
 	}
 
-	if err = rows.Err(); err != nil {
-		return nil, err
+	var roots []*models.CourseCategoryResponse
+	for _, category := range categories {
+		node := nodes[category.ID]
+		if category.ParentID == nil {
+			roots = append(roots, node)
+			continue
+		}
+		if parent, ok := nodes[*category.ParentID]; ok {
+			parent.Children = append(parent.Children, node)
+		}
 	}
-
-	return children, nil
+	return roots, nodes
 }
 
-// GetCategoryByID 根据ID获取课程分类
-func (s *courseCategoryService) GetCategoryByID(id int64) (*models.CourseCategoryResponse, error) {
-	query := `SELECT id, name, parent_id, sort_order, created_at FROM course_categories WHERE id = ?`
-	row := s.db.QueryRow(query, id)
-
-	var category models.CourseCategoryResponse
-	var parentID sql.NullInt64
-	
-	if err := row.Scan(&category.ID, &category.Name, &parentID, &category.SortOrder, &category.CreatedAt); err != nil {
-		if err == sql.ErrNoRows {
-			return nil, errors.New("分类不存在")
-		}
-		return nil, err
+// categoryResponse 转换为分类响应，不包含子分类
+func categoryResponse(category *models.CourseCategory) *models.CourseCategoryResponse {
+	return &models.CourseCategoryResponse{
+		ID:        category.ID,
+		Name:      category.Name,
+		ParentID:  category.ParentID,
+		SortOrder: category.SortOrder,
+		CreatedAt: category.CreatedAt,
 	}
+}
 
-	if parentID.Valid {
-		category.ParentID = &parentID.Int64
-	}
+// GetCategoryByID 根据ID获取课程分类及其全部下级分类
+func (s *courseCategoryService) GetCategoryByID(ctx context.Context, id int64) (*models.CourseCategoryResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
 
-	// 获取子分类
-	children, err := s.getChildrenCategories(category.ID)
+	categories, err := s.categories.ListAll(ctx)
 	if err != nil {
 		return nil, err
 	}
-	category.Children = children
 
-	return &category, nil
+	_, nodes := buildCategoryTree(categories)
+	category, ok := nodes[id]
+	if !ok {
+		return nil, errors.New("分类不存在")
+	}
+	return category, nil
 }
 
 // CreateCategory 创建课程分类
-func (s *courseCategoryService) CreateCategory(category *models.CourseCategory) error {
-	query := `INSERT INTO course_categories (name, parent_id, sort_order, created_at, updated_at) VALUES (?, ?, ?, ?, ?)`
-	now := time.Now()
-
-	result, err := s.db.Exec(query, category.Name, category.ParentID, category.SortOrder, now, now)
-	if err != nil {
-		return err
-	}
+func (s *courseCategoryService) CreateCategory(ctx context.Context, category *models.CourseCategory) error {
+	ctx, cancel := context.WithTimeout(ctx, writeTimeout)
+	defer cancel()
 
-	id, err := result.LastInsertId()
-	if err != nil {
-		return err
-	}
-	category.ID = id
-
-	return nil
+	return s.categories.Create(ctx, category)
 }
 
 // UpdateCategory 更新课程分类
-func (s *courseCategoryService) UpdateCategory(category *models.CourseCategory) error {
-	query := `UPDATE course_categories SET name = ?, parent_id = ?, sort_order = ?, updated_at = ? WHERE id = ?`
-	now := time.Now()
-
-	result, err := s.db.Exec(query, category.Name, category.ParentID, category.SortOrder, now, category.ID)
-	if err != nil {
-		return err
-	}
+func (s *courseCategoryService) UpdateCategory(ctx context.Context, category *models.CourseCategory) error {
+	ctx, cancel := context.WithTimeout(ctx, writeTimeout)
+	defer cancel()
 
-	affected, err := result.RowsAffected()
-	if err != nil {
-		return err
-	}
-
-	if affected == 0 {
+	err := s.categories.Update(ctx, category)
+	if errors.Is(err, repository.ErrNotFound) {
 		return errors.New("分类不存在")
 	}
-
-	return nil
+	return err
 }
 
 // DeleteCategory 删除课程分类
-func (s *courseCategoryService) DeleteCategory(id int64) error {
+func (s *courseCategoryService) DeleteCategory(ctx context.Context, id int64) error {
+	ctx, cancel := context.WithTimeout(ctx, writeTimeout)
+	defer cancel()
+
 	// 检查是否有子分类
-	var count int
-	query := `SELECT COUNT(*) FROM course_categories WHERE parent_id = ?`
-	if err := s.db.QueryRow(query, id).Scan(&count); err != nil {
+	children, err := s.categories.ListByParent(ctx, &id)
+	if err != nil {
 		return err
 	}
 
-	if count > 0 {
+	if len(children) > 0 {
 		return errors.New("该分类下有子分类，无法删除")
 	}
 
 	// 检查是否有课程使用该分类
-	query = `SELECT COUNT(*) FROM courses WHERE category_id = ?`
-	if err := s.db.QueryRow(query, id).Scan(&count); err != nil {
+	count, err := s.courses.Count(ctx, repository.CourseFilter{CategoryIDs: []int64{id}})
+	if err != nil {
 		return err
 	}
 
@@ -199,20 +138,9 @@ func (s *courseCategoryService) DeleteCategory(id int64) error {
 	}
 
 	// 删除分类
-	query = `DELETE FROM course_categories WHERE id = ?`
-	result, err := s.db.Exec(query, id)
-	if err != nil {
-		return err
-	}
-
-	affected, err := result.RowsAffected()
-	if err != nil {
-		return err
-	}
-
-	if affected == 0 {
+	err = s.categories.Delete(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
 		return errors.New("分类不存在")
 	}
-
-	return nil
-}
\ No newline at end of file
+	return err
+}
@@ -0,0 +1,207 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"online-education-api/config"
+	"online-education-api/models"
+	"online-education-api/repository/memory"
+	"online-education-api/repository/mysql"
+	"online-education-api/storage"
+)
+
+// countingDriver 按表名返回预置数据的数据库驱动，统计查询次数并为每次查询模拟一次网络往返
+type countingDriver struct {
+	latency time.Duration
+	queries atomic.Int64
+	tables  map[string][][]driver.Value
+}
+
+func (d *countingDriver) Open(string) (driver.Conn, error) {
+	return &countingConn{driver: d}, nil
+}
+
+type countingConn struct {
+	driver *countingDriver
+}
+
+func (c *countingConn) Prepare(string) (driver.Stmt, error) {
+	return nil, fmt.Errorf("not supported")
+}
+
+func (c *countingConn) Close() error { return nil }
+
+func (c *countingConn) Begin() (driver.Tx, error) {
+	return nil, fmt.Errorf("not supported")
+}
+
+func (c *countingConn) QueryContext(ctx context.Context, query string, _ []driver.NamedValue) (driver.Rows, error) {
+	c.driver.queries.Add(1)
+	if c.driver.latency > 0 {
+		time.Sleep(c.driver.latency)
+	}
+	return &countingRows{data: c.driver.tables[fromTable(query)]}, nil
+}
+
+// fromTable 返回查询中第一个FROM后的表名
+func fromTable(query string) string {
+	_, rest, ok := strings.Cut(query, " FROM ")
+	if !ok {
+		return ""
+	}
+	name, _, _ := strings.Cut(rest, " ")
+	return name
+}
+
+type countingRows struct {
+	data [][]driver.Value
+	next int
+}
+
+func (r *countingRows) Columns() []string {
+	if len(r.data) == 0 {
+		return nil
+	}
+	return make([]string, len(r.data[0]))
+}
+
+func (r *countingRows) Close() error { return nil }
+
+func (r *countingRows) Next(dest []driver.Value) error {
+	if r.next >= len(r.data) {
+		return io.EOF
+	}
+	copy(dest, r.data[r.next])
+	r.next++
+	return nil
+}
+
+// newCourseDB 创建包含一门课程的模拟数据库，课程有chapters个章节，每章lessons个课时
+func newCourseDB(tb testing.TB, chapters, lessons int, latency time.Duration) (*sql.DB, *countingDriver) {
+	tb.Helper()
+	now := time.Now()
+
+	d := &countingDriver{latency: latency, tables: map[string][][]driver.Value{
+		"courses":           {{int64(1), "Go语言基础入门", "", "", 99.0, 199.0, int64(1), int64(7), int64(1), int64(600), int64(0), 0.0, int64(0), int64(1), now}},
+		"course_categories": {{int64(1), "编程开发", nil, int64(1), now}},
+		"users":             {{int64(7), "teacher", "t@example.com", "", "", "", now, now, "teacher", int64(1)}},
+	}}
+	for i := 1; i <= chapters; i++ {
+		d.tables["chapters"] = append(d.tables["chapters"], []driver.Value{int64(i), int64(1), fmt.Sprintf("第%d章", i), int64(i), now})
+		for j := 1; j <= lessons; j++ {
+			id := int64((i-1)*lessons + j)
+			d.tables["lessons"] = append(d.tables["lessons"], []driver.Value{id, int64(i), fmt.Sprintf("%d-%d", i, j), "", int64(10), int64(j), int64(0), now})
+		}
+	}
+
+	db := sql.OpenDB(&countingConnector{driver: d})
+	tb.Cleanup(func() { db.Close() })
+	return db, d
+}
+
+type countingConnector struct {
+	driver *countingDriver
+}
+
+func (c *countingConnector) Connect(context.Context) (driver.Conn, error) {
+	return c.driver.Open("")
+}
+
+func (c *countingConnector) Driver() driver.Driver { return c.driver }
+
+func TestCourseDetailQueryCountIsFixed(t *testing.T) {
+	for _, chapters := range []int{1, 50} {
+		db, d := newCourseDB(t, chapters, 4, 0)
+		svc := New(mysql.New(db), storage.NewMemory(), &config.UploadConfig{})
+
+		detail, err := svc.Course.GetCourseDetail(context.Background(), 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(detail.Chapters) != chapters || detail.TotalLessons != chapters*4 {
+			t.Fatalf("expected %d chapters and %d lessons, got %d and %d", chapters, chapters*4, len(detail.Chapters), detail.TotalLessons)
+		}
+		last := detail.Chapters[chapters-1]
+		if len(last.Lessons) != 4 || last.Lessons[0].ChapterID != last.ID {
+			t.Fatalf("lessons not grouped by chapter: %+v", last.Lessons)
+		}
+		if detail.Category.Name != "编程开发" || detail.Teacher.Username != "teacher" {
+			t.Fatalf("unexpected category or teacher %+v %+v", detail.Category, detail.Teacher)
+		}
+
+		// 课程、分类、教师、章节、课时各一次
+		if got := d.queries.Load(); got != 5 {
+			t.Fatalf("%d chapters: expected 5 queries, got %d", chapters, got)
+		}
+	}
+}
+
+func TestCategoryTreeOfAnyDepth(t *testing.T) {
+	ctx := context.Background()
+	svc := New(memory.New(), storage.NewMemory(), &config.UploadConfig{})
+
+	// 编程开发 > 后端 > Go > 并发，另有一个一级分类和一个父分类不存在的分类
+	var parent *int64
+	var ids []int64
+	for _, name := range []string{"编程开发", "后端", "Go", "并发"} {
+		category := &models.CourseCategory{Name: name, ParentID: parent}
+		if err := svc.CourseCategory.CreateCategory(ctx, category); err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, category.ID)
+		parent = &category.ID
+	}
+	orphanParent := int64(404)
+	for _, category := range []*models.CourseCategory{{Name: "设计", SortOrder: 1}, {Name: "孤立", ParentID: &orphanParent}} {
+		if err := svc.CourseCategory.CreateCategory(ctx, category); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	roots, err := svc.CourseCategory.GetAllCategories(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(roots) != 2 || roots[0].Name != "编程开发" || roots[1].Name != "设计" {
+		t.Fatalf("unexpected roots %+v", roots)
+	}
+	leaf := roots[0].Children[0].Children[0].Children[0]
+	if leaf.Name != "并发" || len(leaf.Children) != 0 {
+		t.Fatalf("unexpected leaf %+v", leaf)
+	}
+
+	sub, err := svc.CourseCategory.GetCategoryByID(ctx, ids[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sub.Name != "后端" || sub.Children[0].Children[0].Name != "并发" {
+		t.Fatalf("unexpected subtree %+v", sub)
+	}
+	if _, err := svc.CourseCategory.GetCategoryByID(ctx, 999); err == nil || err.Error() != "分类不存在" {
+		t.Fatalf("expected 分类不存在, got %v", err)
+	}
+}
+
+// BenchmarkCourseDetail 50个章节、每章4个课时的课程详情，每次查询模拟200µs的网络往返
+func BenchmarkCourseDetail(b *testing.B) {
+	db, d := newCourseDB(b, 50, 4, 200*time.Microsecond)
+	svc := New(mysql.New(db), storage.NewMemory(), &config.UploadConfig{})
+	ctx := context.Background()
+
+	var n int
+	for b.Loop() {
+		if _, err := svc.Course.GetCourseDetail(ctx, 1); err != nil {
+			b.Fatal(err)
+		}
+		n++
+	}
+	b.ReportMetric(float64(d.queries.Load())/float64(n), "queries/op")
+}
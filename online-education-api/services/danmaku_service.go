@@ -0,0 +1,377 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"online-education-api/config"
+	"online-education-api/models"
+	"online-education-api/repository"
+)
+
+// 弹幕服务返回的错误，控制器据此返回对应的状态码
+var (
+	ErrDanmakuNotFound  = errors.New("弹幕不存在")
+	ErrDanmakuForbidden = errors.New("无权限删除该弹幕")
+	ErrDanmakuContent   = errors.New("弹幕内容不能为空")
+	ErrDanmakuColor     = errors.New("颜色必须为#RRGGBB格式")
+	ErrDanmakuOffset    = errors.New("弹幕位置超出视频时长")
+	ErrDanmakuRange     = errors.New("无效的时间范围")
+	ErrDanmakuBlocked   = errors.New("弹幕包含屏蔽词")
+	ErrDanmakuDuplicate = errors.New("请勿重复发送相同的弹幕")
+	ErrDanmakuClosed    = errors.New("弹幕服务正在关闭")
+)
+
+// RateLimitError 发送过于频繁，RetryAfter之后可以再次发送
+type RateLimitError struct {
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("发送过于频繁，请%d秒后再试", int(math.Ceil(e.RetryAfter.Seconds())))
+}
+
+// 默认的弹幕限流配置，DanmakuConfig中对应的字段为零值时使用
+const (
+	defaultDanmakuRateLimit  = 5
+	defaultDanmakuRateWindow = 10 * time.Second
+)
+
+const (
+	// maxDanmakuOffset 视频时长未知时允许的最大播放位置(秒)
+	maxDanmakuOffset = 24 * 60 * 60
+	// danmakuListLimit 每次查询最多返回的弹幕数
+	danmakuListLimit = 1000
+	// danmakuBufferSize 每个观看者待推送的消息数，推送不及时的观看者会丢弃新消息
+	danmakuBufferSize = 64
+)
+
+// danmakuColorPattern 弹幕颜色的格式
+var danmakuColorPattern = regexp.MustCompile(`^#[0-9A-Fa-f]{6}$`)
+
+// DanmakuService 视频弹幕服务接口
+//
+// 新弹幕保存后推送给同一视频的全部订阅者，订阅者只在本实例内共享，多实例部署时各实例只推送本实例收到的弹幕。
+type DanmakuService interface {
+	// SendDanmaku 发送弹幕并推送给正在观看的用户，每个用户按配置限流，包含屏蔽词或重复的内容会被拒绝
+	SendDanmaku(ctx context.Context, danmaku *models.Danmaku, isAdmin bool) error
+	// ListDanmaku 获取视频在[from, to]秒之间的弹幕，按播放位置排序，最多返回1000条
+	ListDanmaku(ctx context.Context, videoID int, userID int64, isAdmin bool, from, to float64) ([]*models.Danmaku, error)
+	// DeleteDanmaku 删除弹幕，发送者、视频作者和管理员可以删除
+	DeleteDanmaku(ctx context.Context, videoID int, id, userID int64, isAdmin bool) error
+	// Subscribe 订阅视频的新弹幕和删除消息，返回的函数取消订阅；服务关闭时消息通道被关闭
+	Subscribe(ctx context.Context, videoID int, userID int64, isAdmin bool) (<-chan *models.DanmakuMessage, func(), error)
+	// Close 关闭全部订阅，之后的订阅返回ErrDanmakuClosed
+	Close()
+}
+
+// danmakuSender 用户在限流窗口内的发送记录
+type danmakuSender struct {
+	sent     []time.Time // 窗口内发送的时间，从早到晚
+	lastText string      // 最近一条弹幕的内容，窗口内不能重复发送
+}
+
+// danmakuService 弹幕服务实现
+type danmakuService struct {
+	danmaku repository.DanmakuRepository
+	videos  repository.VideoRepository
+	cfg     config.DanmakuConfig
+	blocked []string
+	now     func() time.Time
+
+	mu          sync.Mutex
+	senders     map[int64]*danmakuSender
+	subscribers map[int]map[chan *models.DanmakuMessage]struct{}
+	closed      bool
+}
+
+// NewDanmakuService 创建弹幕服务实例，cfg中为零值的字段使用默认值
+func NewDanmakuService(danmaku repository.DanmakuRepository, videos repository.VideoRepository, cfg *config.DanmakuConfig) DanmakuService {
+	c := *cfg
+	if c.RateLimit <= 0 {
+		c.RateLimit = defaultDanmakuRateLimit
+	}
+	if c.RateWindow <= 0 {
+		c.RateWindow = defaultDanmakuRateWindow
+	}
+	var blocked []string
+	for _, word := range strings.Split(c.BlockedWords, ",") {
+		if word = strings.ToLower(strings.TrimSpace(word)); word != "" {
+			blocked = append(blocked, word)
+		}
+	}
+	return &danmakuService{
+		danmaku:     danmaku,
+		videos:      videos,
+		cfg:         c,
+		blocked:     blocked,
+		now:         time.Now,
+		senders:     make(map[int64]*danmakuSender),
+		subscribers: make(map[int]map[chan *models.DanmakuMessage]struct{}),
+	}
+}
+
+// UseDanmaku 按配置替换弹幕服务，需要在处理请求之前调用
+func (s *Services) UseDanmaku(cfg *config.DanmakuConfig) {
+	s.Danmaku = NewDanmakuService(s.repos.Danmaku, s.repos.Videos, cfg)
+}
+
+// visibleVideo 获取当前用户可以观看的视频
+func (s *danmakuService) visibleVideo(ctx context.Context, id int, userID int64, isAdmin bool) (*models.Video, error) {
+	video, err := s.videos.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrVideoNotFound
+		}
+		return nil, fmt.Errorf("获取视频失败: %w", err)
+	}
+	if !canViewVideo(video, userID, isAdmin) {
+		return nil, ErrVideoNotFound
+	}
+	return video, nil
+}
+
+// normalizeDanmaku 整理弹幕内容和样式，换行等控制字符替换为空格
+func normalizeDanmaku(danmaku *models.Danmaku) error {
+	danmaku.Content = strings.TrimSpace(strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return ' '
+		}
+		return r
+	}, danmaku.Content))
+	if danmaku.Content == "" {
+		return ErrDanmakuContent
+	}
+
+	if danmaku.Color == "" {
+		danmaku.Color = models.DanmakuDefaultColor
+	}
+	if !danmakuColorPattern.MatchString(danmaku.Color) {
+		return ErrDanmakuColor
+	}
+	danmaku.Color = strings.ToUpper(danmaku.Color)
+
+	if danmaku.Mode == "" {
+		danmaku.Mode = models.DanmakuModeScroll
+	}
+	return nil
+}
+
+// checkBlocked 检查弹幕是否包含屏蔽词
+func (s *danmakuService) checkBlocked(content string) error {
+	content = strings.ToLower(content)
+	for _, word := range s.blocked {
+		if strings.Contains(content, word) {
+			return ErrDanmakuBlocked
+		}
+	}
+	return nil
+}
+
+// allow 按滑动窗口检查用户能否发送弹幕，允许时记录本次发送
+func (s *danmakuService) allow(userID int64, content string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	start := now.Add(-s.cfg.RateWindow)
+	sender, ok := s.senders[userID]
+	if !ok {
+		sender = &danmakuSender{}
+		s.senders[userID] = sender
+	}
+	// 丢弃窗口之前的记录
+	i := 0
+	for i < len(sender.sent) && !sender.sent[i].After(start) {
+		i++
+	}
+	sender.sent = sender.sent[i:]
+
+	if len(sender.sent) > 0 && strings.EqualFold(sender.lastText, content) {
+		return ErrDanmakuDuplicate
+	}
+	if len(sender.sent) >= s.cfg.RateLimit {
+		return &RateLimitError{RetryAfter: sender.sent[0].Sub(start)}
+	}
+	sender.sent = append(sender.sent, now)
+	sender.lastText = content
+
+	// 用户数较多时顺带清理窗口内没有发送过弹幕的用户
+	if len(s.senders) > 10000 {
+		for id, sender := range s.senders {
+			if len(sender.sent) == 0 || !sender.sent[len(sender.sent)-1].After(start) {
+				delete(s.senders, id)
+			}
+		}
+	}
+	return nil
+}
+
+// SendDanmaku 发送弹幕
+func (s *danmakuService) SendDanmaku(ctx context.Context, danmaku *models.Danmaku, isAdmin bool) error {
+	ctx, cancel := context.WithTimeout(ctx, writeTimeout)
+	defer cancel()
+
+	if err := normalizeDanmaku(danmaku); err != nil {
+		return err
+	}
+	if err := s.checkBlocked(danmaku.Content); err != nil {
+		danmakuRejectedTotal.Inc("blocked")
+		return err
+	}
+
+	video, err := s.visibleVideo(ctx, danmaku.VideoID, danmaku.UserID, isAdmin)
+	if err != nil {
+		return err
+	}
+	if danmaku.Offset > maxDanmakuOffset || video.Duration > 0 && danmaku.Offset > float64(video.Duration) {
+		return ErrDanmakuOffset
+	}
+
+	if err := s.allow(danmaku.UserID, danmaku.Content); err != nil {
+		reason := "rate_limited"
+		if errors.Is(err, ErrDanmakuDuplicate) {
+			reason = "duplicate"
+		}
+		danmakuRejectedTotal.Inc(reason)
+		return err
+	}
+
+	if err := s.danmaku.Create(ctx, danmaku); err != nil {
+		return fmt.Errorf("保存弹幕失败: %w", err)
+	}
+	danmakuSentTotal.Inc()
+
+	copied := *danmaku
+	s.publish(danmaku.VideoID, &models.DanmakuMessage{Type: models.DanmakuMessageCreate, Danmaku: &copied})
+	return nil
+}
+
+// ListDanmaku 获取时间范围内的弹幕
+func (s *danmakuService) ListDanmaku(ctx context.Context, videoID int, userID int64, isAdmin bool, from, to float64) ([]*models.Danmaku, error) {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
+	if from < 0 || to < from {
+		return nil, ErrDanmakuRange
+	}
+	if _, err := s.visibleVideo(ctx, videoID, userID, isAdmin); err != nil {
+		return nil, err
+	}
+
+	list, err := s.danmaku.ListRange(ctx, videoID, from, min(to, maxDanmakuOffset), danmakuListLimit)
+	if err != nil {
+		return nil, fmt.Errorf("获取弹幕失败: %w", err)
+	}
+	return list, nil
+}
+
+// DeleteDanmaku 删除弹幕
+func (s *danmakuService) DeleteDanmaku(ctx context.Context, videoID int, id, userID int64, isAdmin bool) error {
+	ctx, cancel := context.WithTimeout(ctx, writeTimeout)
+	defer cancel()
+
+	danmaku, err := s.danmaku.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrDanmakuNotFound
+		}
+		return fmt.Errorf("获取弹幕失败: %w", err)
+	}
+	if danmaku.VideoID != videoID {
+		return ErrDanmakuNotFound
+	}
+
+	if danmaku.UserID != userID && !isAdmin {
+		video, err := s.videos.GetByID(ctx, videoID)
+		if err != nil && !errors.Is(err, repository.ErrNotFound) {
+			return fmt.Errorf("获取视频失败: %w", err)
+		}
+		if video == nil || video.AuthorID != userID {
+			return ErrDanmakuForbidden
+		}
+	}
+
+	if err := s.danmaku.Delete(ctx, id); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrDanmakuNotFound
+		}
+		return fmt.Errorf("删除弹幕失败: %w", err)
+	}
+
+	s.publish(videoID, &models.DanmakuMessage{Type: models.DanmakuMessageDelete, ID: id})
+	return nil
+}
+
+// Subscribe 订阅视频的弹幕消息
+func (s *danmakuService) Subscribe(ctx context.Context, videoID int, userID int64, isAdmin bool) (<-chan *models.DanmakuMessage, func(), error) {
+	queryCtx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
+	if _, err := s.visibleVideo(queryCtx, videoID, userID, isAdmin); err != nil {
+		return nil, nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return nil, nil, ErrDanmakuClosed
+	}
+	ch := make(chan *models.DanmakuMessage, danmakuBufferSize)
+	if s.subscribers[videoID] == nil {
+		s.subscribers[videoID] = make(map[chan *models.DanmakuMessage]struct{})
+	}
+	s.subscribers[videoID][ch] = struct{}{}
+
+	unsubscribe := func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		// 服务关闭时通道已被关闭并移除
+		if _, ok := s.subscribers[videoID][ch]; !ok {
+			return
+		}
+		delete(s.subscribers[videoID], ch)
+		if len(s.subscribers[videoID]) == 0 {
+			delete(s.subscribers, videoID)
+		}
+		close(ch)
+	}
+	return ch, unsubscribe, nil
+}
+
+// publish 将消息推送给视频的订阅者，订阅者的缓冲已满时丢弃该消息
+func (s *danmakuService) publish(videoID int, msg *models.DanmakuMessage) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for ch := range s.subscribers[videoID] {
+		select {
+		case ch <- msg:
+		default:
+			danmakuDroppedTotal.Inc()
+		}
+	}
+}
+
+// Close 关闭全部订阅
+func (s *danmakuService) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.closed = true
+	for videoID, subscribers := range s.subscribers {
+		for ch := range subscribers {
+			close(ch)
+		}
+		delete(s.subscribers, videoID)
+	}
+}
@@ -0,0 +1,147 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"online-education-api/config"
+	"online-education-api/models"
+	"online-education-api/repository/memory"
+)
+
+// newTestDanmaku 创建使用可控时钟的弹幕服务和一个时长为60秒的视频
+func newTestDanmaku(t *testing.T, cfg *config.DanmakuConfig) (*danmakuService, *models.Video, *time.Time) {
+	t.Helper()
+
+	repos := memory.New()
+	video := &models.Video{Title: "Go", VideoURL: "/v.mp4", Duration: 60, AuthorID: 10, Visibility: models.VideoVisibilityPublic}
+	if err := repos.Videos.Create(context.Background(), video); err != nil {
+		t.Fatal(err)
+	}
+	svc := NewDanmakuService(repos.Danmaku, repos.Videos, cfg).(*danmakuService)
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	svc.now = func() time.Time { return now }
+	return svc, video, &now
+}
+
+func TestDanmakuSendAndList(t *testing.T) {
+	ctx := context.Background()
+	svc, video, _ := newTestDanmaku(t, &config.DanmakuConfig{RateLimit: 100, BlockedWords: "spam, 广告"})
+
+	tests := []struct {
+		name    string
+		danmaku models.Danmaku
+		want    error
+	}{
+		{"blank", models.Danmaku{Content: " \n "}, ErrDanmakuContent},
+		{"bad color", models.Danmaku{Content: "hi", Color: "red"}, ErrDanmakuColor},
+		{"past the end", models.Danmaku{Content: "hi", Offset: 61}, ErrDanmakuOffset},
+		{"blocked word", models.Danmaku{Content: "buy SPAM now"}, ErrDanmakuBlocked},
+		{"missing video", models.Danmaku{VideoID: 999, Content: "hi"}, ErrVideoNotFound},
+	}
+	for _, tt := range tests {
+		d := tt.danmaku
+		if d.VideoID == 0 {
+			d.VideoID = video.ID
+		}
+		d.UserID = 1
+		if err := svc.SendDanmaku(ctx, &d, false); !errors.Is(err, tt.want) {
+			t.Errorf("%s: err = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+
+	for i, offset := range []float64{30, 5.1234, 50} {
+		d := &models.Danmaku{VideoID: video.ID, UserID: int64(i + 1), Content: "第一\n条", Offset: offset, Color: "#ff0000"}
+		if err := svc.SendDanmaku(ctx, d, false); err != nil {
+			t.Fatal(err)
+		}
+		if d.Content != "第一 条" || d.Color != "#FF0000" || d.Mode != models.DanmakuModeScroll {
+			t.Errorf("normalized danmaku = %+v", d)
+		}
+	}
+
+	list, err := svc.ListDanmaku(ctx, video.ID, 0, false, 5, 30)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 || list[0].Offset != 5.123 || list[1].Offset != 30 {
+		t.Fatalf("ListDanmaku(5, 30) = %+v", list)
+	}
+	if _, err := svc.ListDanmaku(ctx, video.ID, 0, false, 30, 5); !errors.Is(err, ErrDanmakuRange) {
+		t.Errorf("reversed range err = %v, want ErrDanmakuRange", err)
+	}
+}
+
+func TestDanmakuRateLimit(t *testing.T) {
+	ctx := context.Background()
+	svc, video, now := newTestDanmaku(t, &config.DanmakuConfig{RateLimit: 2, RateWindow: 10 * time.Second})
+
+	send := func(userID int64, content string) error {
+		return svc.SendDanmaku(ctx, &models.Danmaku{VideoID: video.ID, UserID: userID, Content: content}, false)
+	}
+	if err := send(1, "a"); err != nil {
+		t.Fatal(err)
+	}
+	if err := send(1, "A"); !errors.Is(err, ErrDanmakuDuplicate) {
+		t.Errorf("repeated content err = %v, want ErrDanmakuDuplicate", err)
+	}
+	*now = now.Add(4 * time.Second)
+	if err := send(1, "b"); err != nil {
+		t.Fatal(err)
+	}
+	var rateErr *RateLimitError
+	if err := send(1, "c"); !errors.As(err, &rateErr) || rateErr.RetryAfter != 6*time.Second {
+		t.Errorf("third danmaku err = %v, want retry after 6s", err)
+	}
+	// 其他用户不受影响，窗口过后可以再次发送
+	if err := send(2, "c"); err != nil {
+		t.Errorf("other user err = %v", err)
+	}
+	*now = now.Add(6 * time.Second)
+	if err := send(1, "c"); err != nil {
+		t.Errorf("after window err = %v", err)
+	}
+}
+
+func TestDanmakuSubscribeAndDelete(t *testing.T) {
+	ctx := context.Background()
+	svc, video, _ := newTestDanmaku(t, &config.DanmakuConfig{})
+
+	messages, unsubscribe, err := svc.Subscribe(ctx, video.ID, 0, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer unsubscribe()
+
+	d := &models.Danmaku{VideoID: video.ID, UserID: 1, Content: "hello", Offset: 3}
+	if err := svc.SendDanmaku(ctx, d, false); err != nil {
+		t.Fatal(err)
+	}
+	if msg := <-messages; msg.Type != models.DanmakuMessageCreate || msg.Danmaku.ID != d.ID {
+		t.Fatalf("message = %+v, want new danmaku %d", msg, d.ID)
+	}
+
+	if err := svc.DeleteDanmaku(ctx, video.ID, d.ID, 2, false); !errors.Is(err, ErrDanmakuForbidden) {
+		t.Errorf("delete by another user err = %v, want ErrDanmakuForbidden", err)
+	}
+	if err := svc.DeleteDanmaku(ctx, video.ID+1, d.ID, 1, false); !errors.Is(err, ErrDanmakuNotFound) {
+		t.Errorf("delete under another video err = %v, want ErrDanmakuNotFound", err)
+	}
+	// 视频作者可以删除观众的弹幕
+	if err := svc.DeleteDanmaku(ctx, video.ID, d.ID, video.AuthorID, false); err != nil {
+		t.Fatal(err)
+	}
+	if msg := <-messages; msg.Type != models.DanmakuMessageDelete || msg.ID != d.ID {
+		t.Fatalf("message = %+v, want delete %d", msg, d.ID)
+	}
+
+	svc.Close()
+	if _, ok := <-messages; ok {
+		t.Error("channel still open after Close")
+	}
+	if _, _, err := svc.Subscribe(ctx, video.ID, 0, false); !errors.Is(err, ErrDanmakuClosed) {
+		t.Errorf("Subscribe after Close err = %v, want ErrDanmakuClosed", err)
+	}
+}
@@ -0,0 +1,30 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"online-education-api/repository"
+)
+
+// HealthService 健康检查服务接口
+type HealthService interface {
+	CheckReadiness(ctx context.Context) error
+}
+
+// healthService 健康检查服务实现
+type healthService struct {
+	store repository.Pinger
+}
+
+// NewHealthService 创建健康检查服务实例
+func NewHealthService(store repository.Pinger) HealthService {
+	return &healthService{store: store}
+}
+
+// CheckReadiness 检查数据存储是否可用
+func (s *healthService) CheckReadiness(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+	return s.store.Ping(ctx)
+}
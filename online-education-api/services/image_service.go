@@ -0,0 +1,389 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"online-education-api/config"
+	"online-education-api/imaging"
+	"online-education-api/models"
+	"online-education-api/repository"
+	"online-education-api/storage"
+)
+
+// 图片服务返回的错误，控制器据此返回对应的状态码
+var (
+	ErrImageNotFound  = errors.New("图片不存在")
+	ErrImageForbidden = errors.New("无权限操作该图片")
+	ErrImageTooLarge  = errors.New("图片超过大小限制")
+	ErrImageType      = errors.New("不支持的图片类型，只能上传JPEG、PNG或GIF图片")
+	ErrImageEntity    = errors.New("关联的对象不存在")
+	ErrImageAttached  = errors.New("图片已关联到其他对象")
+)
+
+// 默认的图片限制，UploadConfig中对应的字段为零值时使用
+const (
+	defaultMaxImageSize   = 10 << 20
+	defaultMaxImagePixels = 25_000_000
+	defaultImageOrphanTTL = 24 * time.Hour
+	// imagePurgeBatchSize 每次清理的图片数
+	imagePurgeBatchSize = 1000
+)
+
+// imageTypes 允许上传的图片类型及原图保存时使用的扩展名
+var imageTypes = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+}
+
+// ImageService 图片服务接口
+//
+// 上传的图片按内容识别类型，原图和标准尺寸的缩略图保存在对象存储的images/年/月/下。
+// 图片上传后需要关联到用户、课程或视频，作为主图时替换用户头像、课程封面或视频封面；
+// 超过保留时间仍未关联、或关联的对象已删除的图片由 PurgeOrphans 清理。
+type ImageService interface {
+	// UploadImage 上传图片并生成缩略图
+	UploadImage(ctx context.Context, userID int64, fileName, altText string, r io.Reader) (*models.Image, error)
+	GetImage(ctx context.Context, id int64) (*models.Image, error)
+	// ListImages 获取对象的图片，主图在前
+	ListImages(ctx context.Context, entityType string, entityID int64) ([]*models.Image, error)
+	// UpdateImage 修改替代文本，只有上传者和管理员可以操作
+	UpdateImage(ctx context.Context, id, userID int64, isAdmin bool, altText string) (*models.Image, error)
+	// AttachImage 将图片关联到对象，需要同时有权操作图片和对象：用户只能是自己，课程为自己教的课程，视频为自己的视频；管理员不受限制
+	AttachImage(ctx context.Context, id, userID int64, isAdmin bool, entityType string, entityID int64, primary bool) (*models.Image, error)
+	// DeleteImage 删除图片及其缩略图，图片是主图时清空对象的头像或封面
+	DeleteImage(ctx context.Context, id, userID int64, isAdmin bool) error
+	// PurgeOrphans 删除超过保留时间仍未关联、或关联的对象已删除的图片，返回删除的数量
+	PurgeOrphans(ctx context.Context) (int, error)
+}
+
+// imageService 图片服务实现
+type imageService struct {
+	images  repository.ImageRepository
+	courses repository.CourseRepository
+	videos  repository.VideoRepository
+	users   repository.UserRepository
+	files   storage.Storage
+	cfg     config.UploadConfig
+}
+
+// NewImageService 创建图片服务实例，cfg中图片相关的字段为零值时使用默认值
+func NewImageService(repos *repository.Repositories, files storage.Storage, cfg *config.UploadConfig) ImageService {
+	c := *cfg
+	if c.ImageMaxSize <= 0 {
+		c.ImageMaxSize = defaultMaxImageSize
+	}
+	if c.ImageMaxPixels <= 0 {
+		c.ImageMaxPixels = defaultMaxImagePixels
+	}
+	if c.ImageOrphanTTL <= 0 {
+		c.ImageOrphanTTL = defaultImageOrphanTTL
+	}
+	return &imageService{
+		images:  repos.Images,
+		courses: repos.Courses,
+		videos:  repos.Videos,
+		users:   repos.Users,
+		files:   files,
+		cfg:     c,
+	}
+}
+
+// thumbnailKey 返回缩略图在对象存储中的键：原图的键去掉扩展名后加尺寸名，JPEG以外的图片缩略图为PNG
+func thumbnailKey(image *models.Image, size imaging.Size) string {
+	ext := ".png"
+	if image.MimeType == "image/jpeg" {
+		ext = ".jpg"
+	}
+	return strings.TrimSuffix(image.FilePath, path.Ext(image.FilePath)) + "_" + size.Name + ext
+}
+
+// withURLs 设置原图和缩略图的访问地址，未生成的尺寸使用原图
+func (s *imageService) withURLs(image *models.Image) *models.Image {
+	image.URL = s.files.URL(image.FilePath)
+	image.Thumbnails = make(map[string]string, len(imaging.Sizes))
+	for _, size := range imaging.Sizes {
+		if size.Generated(image.Width, image.Height) {
+			image.Thumbnails[size.Name] = s.files.URL(thumbnailKey(image, size))
+		} else {
+			image.Thumbnails[size.Name] = image.URL
+		}
+	}
+	return image
+}
+
+// UploadImage 上传图片，图片大小有上限，整个文件读入内存处理
+func (s *imageService) UploadImage(ctx context.Context, userID int64, fileName, altText string, r io.Reader) (*models.Image, error) {
+	data, err := io.ReadAll(io.LimitReader(r, s.cfg.ImageMaxSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("接收文件失败: %w", err)
+	}
+	if int64(len(data)) > s.cfg.ImageMaxSize {
+		return nil, fmt.Errorf("%w: 最大%d字节", ErrImageTooLarge, s.cfg.ImageMaxSize)
+	}
+	mimeType := http.DetectContentType(data)
+	ext, ok := imageTypes[mimeType]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrImageType, mimeType)
+	}
+
+	img, format, err := imaging.Decode(data, s.cfg.ImageMaxPixels)
+	if errors.Is(err, imaging.ErrTooLarge) {
+		return nil, fmt.Errorf("%w: %v", ErrImageTooLarge, err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrImageType, err)
+	}
+
+	name := make([]byte, 16)
+	if _, err := rand.Read(name); err != nil {
+		return nil, fmt.Errorf("生成文件名失败: %w", err)
+	}
+	image := &models.Image{
+		UserID:   userID,
+		FileName: filepath.Base(fileName),
+		FilePath: fmt.Sprintf("images/%s/%s%s", time.Now().Format("2006/01"), hex.EncodeToString(name), ext),
+		FileSize: int64(len(data)),
+		MimeType: mimeType,
+		Width:    img.Bounds().Dx(),
+		Height:   img.Bounds().Dy(),
+		AltText:  altText,
+	}
+
+	// 先写入文件再创建记录，写入失败时删除已写入的文件
+	keys := []string{image.FilePath}
+	if err := s.files.Put(ctx, image.FilePath, bytes.NewReader(data), image.FileSize, mimeType); err != nil {
+		return nil, fmt.Errorf("保存图片失败: %w", err)
+	}
+	for _, size := range imaging.Sizes {
+		if !size.Generated(image.Width, image.Height) {
+			continue
+		}
+		var buf bytes.Buffer
+		if err := imaging.Encode(&buf, imaging.Thumbnail(img, size), format); err != nil {
+			s.deleteFiles(ctx, keys)
+			return nil, fmt.Errorf("生成缩略图失败: %w", err)
+		}
+		key := thumbnailKey(image, size)
+		contentType := "image/png"
+		if format == "jpeg" {
+			contentType = "image/jpeg"
+		}
+		if err := s.files.Put(ctx, key, &buf, int64(buf.Len()), contentType); err != nil {
+			s.deleteFiles(ctx, keys)
+			return nil, fmt.Errorf("保存缩略图失败: %w", err)
+		}
+		keys = append(keys, key)
+	}
+
+	dbCtx, cancel := context.WithTimeout(ctx, writeTimeout)
+	defer cancel()
+	if err := s.images.Create(dbCtx, image); err != nil {
+		s.deleteFiles(ctx, keys)
+		return nil, fmt.Errorf("保存图片失败: %w", err)
+	}
+	imagesUploadedTotal.Inc(mimeType)
+	return s.withURLs(image), nil
+}
+
+// deleteFiles 删除对象存储中的文件，失败时只记录日志，残留的文件不影响使用
+func (s *imageService) deleteFiles(ctx context.Context, keys []string) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), writeTimeout)
+	defer cancel()
+	for _, key := range keys {
+		if err := s.files.Delete(ctx, key); err != nil {
+			serviceLog.WarnContext(ctx, "删除图片文件失败", "key", key, "error", err)
+		}
+	}
+}
+
+// imageKeys 返回图片的原图和全部缩略图的键
+func imageKeys(image *models.Image) []string {
+	keys := []string{image.FilePath}
+	for _, size := range imaging.Sizes {
+		if size.Generated(image.Width, image.Height) {
+			keys = append(keys, thumbnailKey(image, size))
+		}
+	}
+	return keys
+}
+
+// GetImage 获取图片
+func (s *imageService) GetImage(ctx context.Context, id int64) (*models.Image, error) {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
+	image, err := s.images.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrImageNotFound
+		}
+		return nil, fmt.Errorf("获取图片失败: %w", err)
+	}
+	return s.withURLs(image), nil
+}
+
+// ListImages 获取对象的图片
+func (s *imageService) ListImages(ctx context.Context, entityType string, entityID int64) ([]*models.Image, error) {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
+	images, err := s.images.ListByEntity(ctx, entityType, entityID)
+	if err != nil {
+		return nil, fmt.Errorf("获取图片列表失败: %w", err)
+	}
+	for _, image := range images {
+		s.withURLs(image)
+	}
+	return images, nil
+}
+
+// ownedImage 获取图片并检查当前用户是否为上传者或管理员
+func (s *imageService) ownedImage(ctx context.Context, id, userID int64, isAdmin bool) (*models.Image, error) {
+	image, err := s.GetImage(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if image.UserID != userID && !isAdmin {
+		return nil, ErrImageForbidden
+	}
+	return image, nil
+}
+
+// UpdateImage 修改替代文本
+func (s *imageService) UpdateImage(ctx context.Context, id, userID int64, isAdmin bool, altText string) (*models.Image, error) {
+	image, err := s.ownedImage(ctx, id, userID, isAdmin)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, writeTimeout)
+	defer cancel()
+	if err := s.images.UpdateAltText(ctx, id, altText); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrImageNotFound
+		}
+		return nil, fmt.Errorf("更新图片失败: %w", err)
+	}
+	image.AltText = altText
+	return image, nil
+}
+
+// AttachImage 关联图片
+func (s *imageService) AttachImage(ctx context.Context, id, userID int64, isAdmin bool, entityType string, entityID int64, primary bool) (*models.Image, error) {
+	image, err := s.ownedImage(ctx, id, userID, isAdmin)
+	if err != nil {
+		return nil, err
+	}
+	// 已关联的图片只能在同一对象下切换是否为主图，关联到其他对象需要重新上传
+	if image.EntityType != "" && (image.EntityType != entityType || image.EntityID != entityID) {
+		return nil, ErrImageAttached
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, writeTimeout)
+	defer cancel()
+	if err := s.checkEntity(ctx, entityType, entityID, userID, isAdmin); err != nil {
+		return nil, err
+	}
+
+	image.EntityType = entityType
+	image.EntityID = entityID
+	image.IsPrimary = primary
+	if err := s.images.Attach(ctx, image, image.URL); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrImageNotFound
+		}
+		return nil, fmt.Errorf("关联图片失败: %w", err)
+	}
+	return image, nil
+}
+
+// checkEntity 检查对象是否存在以及当前用户能否为其设置图片
+func (s *imageService) checkEntity(ctx context.Context, entityType string, entityID, userID int64, isAdmin bool) error {
+	var ownerID int64
+	var err error
+	switch entityType {
+	case models.ImageEntityUser:
+		ownerID = entityID
+		_, err = s.users.GetByID(ctx, entityID)
+	case models.ImageEntityCourse:
+		var course *models.Course
+		if course, err = s.courses.GetByID(ctx, entityID); err == nil {
+			ownerID = course.TeacherID
+		}
+	case models.ImageEntityVideo:
+		var video *models.Video
+		if video, err = s.videos.GetByID(ctx, int(entityID)); err == nil {
+			ownerID = video.AuthorID
+		}
+	default:
+		return fmt.Errorf("%w: 未知的类型%s", ErrImageEntity, entityType)
+	}
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrImageEntity
+	}
+	if err != nil {
+		return fmt.Errorf("获取关联的对象失败: %w", err)
+	}
+	if ownerID != userID && !isAdmin {
+		return ErrImageForbidden
+	}
+	return nil
+}
+
+// DeleteImage 删除图片，先删除记录再删除文件，删除文件失败时只留下无人引用的文件
+func (s *imageService) DeleteImage(ctx context.Context, id, userID int64, isAdmin bool) error {
+	image, err := s.ownedImage(ctx, id, userID, isAdmin)
+	if err != nil {
+		return err
+	}
+	return s.delete(ctx, image)
+}
+
+// delete 删除图片记录和文件
+func (s *imageService) delete(ctx context.Context, image *models.Image) error {
+	dbCtx, cancel := context.WithTimeout(ctx, writeTimeout)
+	defer cancel()
+	if err := s.images.Delete(dbCtx, image, s.files.URL(image.FilePath)); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrImageNotFound
+		}
+		return fmt.Errorf("删除图片失败: %w", err)
+	}
+	s.deleteFiles(ctx, imageKeys(image))
+	return nil
+}
+
+// PurgeOrphans 清理无人引用的图片，每批最多imagePurgeBatchSize张，直到没有需要清理的图片
+func (s *imageService) PurgeOrphans(ctx context.Context) (int, error) {
+	before := time.Now().Add(-s.cfg.ImageOrphanTTL)
+	purged := 0
+	for {
+		queryCtx, cancel := context.WithTimeout(ctx, queryTimeout)
+		orphans, err := s.images.ListOrphans(queryCtx, before, imagePurgeBatchSize)
+		cancel()
+		if err != nil {
+			return purged, fmt.Errorf("获取无人引用的图片失败: %w", err)
+		}
+		for _, image := range orphans {
+			if err := s.delete(ctx, image); err != nil && !errors.Is(err, ErrImageNotFound) {
+				return purged, err
+			}
+			purged++
+		}
+		if len(orphans) < imagePurgeBatchSize {
+			return purged, nil
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/png"
+	"testing"
+	"time"
+
+	"online-education-api/config"
+	"online-education-api/models"
+	"online-education-api/repository/memory"
+	"online-education-api/storage"
+)
+
+// pngData 生成指定尺寸的PNG
+func pngData(t *testing.T, width, height int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, width, height))); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestImageUploadAndAttach(t *testing.T) {
+	ctx := context.Background()
+	repos := memory.New()
+	files := storage.NewMemory()
+	svc := NewImageService(repos, files, &config.UploadConfig{ImageMaxSize: 1 << 20})
+
+	course := &models.Course{Title: "Go", TeacherID: 10, CoverImage: "https://example.com/old.jpg"}
+	if err := repos.Courses.Create(ctx, course); err != nil {
+		t.Fatal(err)
+	}
+
+	img, err := svc.UploadImage(ctx, 10, "dir/cover.png", "封面", bytes.NewReader(pngData(t, 800, 400)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img.Width != 800 || img.Height != 400 || img.MimeType != "image/png" || img.FileName != "cover.png" {
+		t.Fatalf("uploaded image = %+v", img)
+	}
+	// small和medium生成缩略图，large超过原图尺寸使用原图
+	for _, name := range []string{"small", "medium"} {
+		if img.Thumbnails[name] == img.URL {
+			t.Errorf("thumbnail %s = original URL", name)
+		}
+	}
+	if img.Thumbnails["large"] != img.URL {
+		t.Errorf("large thumbnail = %q, want original %q", img.Thumbnails["large"], img.URL)
+	}
+	keys := imageKeys(img)
+	for _, key := range keys {
+		if _, err := files.Open(ctx, key); err != nil {
+			t.Errorf("Open(%s) err = %v", key, err)
+		}
+	}
+
+	// 其他教师不能为课程设置封面
+	if _, err := svc.AttachImage(ctx, img.ID, 11, false, models.ImageEntityCourse, course.ID, true); !errors.Is(err, ErrImageForbidden) {
+		t.Errorf("attach by another user err = %v, want ErrImageForbidden", err)
+	}
+	if _, err := svc.AttachImage(ctx, img.ID, 10, false, models.ImageEntityCourse, 999, true); !errors.Is(err, ErrImageEntity) {
+		t.Errorf("attach to missing course err = %v, want ErrImageEntity", err)
+	}
+	if _, err := svc.AttachImage(ctx, img.ID, 10, false, models.ImageEntityCourse, course.ID, true); err != nil {
+		t.Fatal(err)
+	}
+	got, _ := repos.Courses.GetByID(ctx, course.ID)
+	if got.CoverImage != img.URL {
+		t.Errorf("CoverImage = %q, want %q", got.CoverImage, img.URL)
+	}
+	if _, err := svc.AttachImage(ctx, img.ID, 10, false, models.ImageEntityUser, 10, true); !errors.Is(err, ErrImageAttached) {
+		t.Errorf("attach to another entity err = %v, want ErrImageAttached", err)
+	}
+
+	// 删除主图后清空封面并删除文件
+	if err := svc.DeleteImage(ctx, img.ID, 10, false); err != nil {
+		t.Fatal(err)
+	}
+	got, _ = repos.Courses.GetByID(ctx, course.ID)
+	if got.CoverImage != "" {
+		t.Errorf("CoverImage after delete = %q, want empty", got.CoverImage)
+	}
+	for _, key := range keys {
+		if _, err := files.Open(ctx, key); !errors.Is(err, storage.ErrNotFound) {
+			t.Errorf("Open(%s) after delete err = %v, want ErrNotFound", key, err)
+		}
+	}
+}
+
+func TestImageUploadRejects(t *testing.T) {
+	ctx := context.Background()
+	svc := NewImageService(memory.New(), storage.NewMemory(), &config.UploadConfig{ImageMaxSize: 1000, ImageMaxPixels: 100 * 100})
+
+	tests := []struct {
+		name string
+		data []byte
+		want error
+	}{
+		{"not an image", []byte("plain text"), ErrImageType},
+		{"too many bytes", bytes.Repeat([]byte{0}, 1001), ErrImageTooLarge},
+		{"too many pixels", pngData(t, 200, 200), ErrImageTooLarge},
+	}
+	for _, tt := range tests {
+		if _, err := svc.UploadImage(ctx, 1, "a.png", "", bytes.NewReader(tt.data)); !errors.Is(err, tt.want) {
+			t.Errorf("%s: err = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestImagePurgeOrphans(t *testing.T) {
+	ctx := context.Background()
+	repos := memory.New()
+	svc := NewImageService(repos, storage.NewMemory(), &config.UploadConfig{ImageOrphanTTL: time.Nanosecond})
+
+	user := &models.User{Username: "alice", Email: "alice@example.com"}
+	if err := repos.Users.Create(ctx, user); err != nil {
+		t.Fatal(err)
+	}
+	orphan, err := svc.UploadImage(ctx, user.ID, "a.png", "", bytes.NewReader(pngData(t, 10, 10)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	avatar, err := svc.UploadImage(ctx, user.ID, "b.png", "", bytes.NewReader(pngData(t, 10, 10)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.AttachImage(ctx, avatar.ID, user.ID, false, models.ImageEntityUser, user.ID, true); err != nil {
+		t.Fatal(err)
+	}
+
+	time.Sleep(time.Millisecond)
+	purged, err := svc.PurgeOrphans(ctx)
+	if err != nil || purged != 1 {
+		t.Fatalf("PurgeOrphans = %d, %v, want 1", purged, err)
+	}
+	if _, err := svc.GetImage(ctx, orphan.ID); !errors.Is(err, ErrImageNotFound) {
+		t.Errorf("orphan still exists: %v", err)
+	}
+	if _, err := svc.GetImage(ctx, avatar.ID); err != nil {
+		t.Errorf("attached image purged: %v", err)
+	}
+}
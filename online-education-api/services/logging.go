@@ -0,0 +1,6 @@
+package services
+
+import "online-education-api/logger"
+
+// serviceLog 服务层日志记录器
+var serviceLog = logger.New("services")
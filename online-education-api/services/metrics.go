@@ -0,0 +1,23 @@
+package services
+
+import "online-education-api/metrics"
+
+// 业务指标
+var (
+	registrationsTotal     = metrics.NewCounter("registrations_total", "Total number of user registrations.")
+	enrollmentsTotal       = metrics.NewCounter("enrollments_total", "Total number of course enrollments.")
+	paymentsCreatedTotal   = metrics.NewCounterVec("payments_created_total", "Total number of payment orders created.", "method")
+	paymentsCompletedTotal = metrics.NewCounter("payments_completed_total", "Total number of payments completed.")
+	cacheRequestsTotal     = metrics.NewCounterVec("cache_requests_total", "Total number of catalog cache lookups by result.", "result")
+	reviewsTotal           = metrics.NewCounter("course_reviews_total", "Total number of course reviews created.")
+	reviewReportsTotal     = metrics.NewCounter("course_review_reports_total", "Total number of course review reports.")
+	uploadsCompletedTotal  = metrics.NewCounterVec("uploads_completed_total", "Total number of video uploads completed by method.", "method")
+	uploadBytesTotal       = metrics.NewCounter("upload_bytes_total", "Total bytes of completed video uploads.")
+	transcodesTotal        = metrics.NewCounterVec("video_transcodes_total", "Total number of video transcodes by result.", "result")
+	imagesUploadedTotal    = metrics.NewCounterVec("images_uploaded_total", "Total number of images uploaded by MIME type.", "type")
+	viewEventsTotal        = metrics.NewCounterVec("video_view_events_total", "Total number of video playback events by type.", "type")
+	viewsTotal             = metrics.NewCounter("video_views_total", "Total number of deduplicated video views.")
+	danmakuSentTotal       = metrics.NewCounter("danmaku_sent_total", "Total number of danmaku sent.")
+	danmakuRejectedTotal   = metrics.NewCounterVec("danmaku_rejected_total", "Total number of danmaku rejected by reason.", "reason")
+	danmakuDroppedTotal    = metrics.NewCounter("danmaku_dropped_total", "Total number of danmaku messages dropped for slow subscribers.")
+)
@@ -0,0 +1,131 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"online-education-api/config"
+	"online-education-api/models"
+	"online-education-api/pagination"
+	"online-education-api/repository/memory"
+	"online-education-api/storage"
+)
+
+// newCourse 在内存存储中创建一门带分类的课程
+func newCourse(t *testing.T, svc *Services, price float64) *models.Course {
+	t.Helper()
+	ctx := context.Background()
+
+	category := &models.CourseCategory{Name: "编程开发"}
+	if err := svc.CourseCategory.CreateCategory(ctx, category); err != nil {
+		t.Fatal(err)
+	}
+	course := &models.Course{Title: "Go语言基础入门", Price: price, CategoryID: category.ID, Level: 1, Status: 1}
+	if err := svc.Course.CreateCourse(ctx, course); err != nil {
+		t.Fatal(err)
+	}
+	return course
+}
+
+func TestCompletedPaymentEnrollsUser(t *testing.T) {
+	ctx := context.Background()
+	svc := New(memory.New(), storage.NewMemory(), &config.UploadConfig{})
+	course := newCourse(t, svc, 99)
+
+	payment, err := svc.Payment.CreatePayment(ctx, 7, course.ID, "alipay")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if payment.Amount != 99 || payment.Status != "pending" {
+		t.Fatalf("unexpected payment %+v", payment)
+	}
+
+	if _, err := svc.UserCourse.GetUserCourseByID(ctx, 7, course.ID); err == nil {
+		t.Fatal("expected no enrollment before the payment completes")
+	}
+	if err := svc.Payment.UpdatePaymentStatus(ctx, payment.OrderID, "TX-1", "completed"); err != nil {
+		t.Fatal(err)
+	}
+
+	enrollment, err := svc.UserCourse.GetUserCourseByID(ctx, 7, course.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if enrollment.Status != 1 || enrollment.Price != 99 {
+		t.Fatalf("unexpected enrollment %+v", enrollment)
+	}
+
+	detail, err := svc.Course.GetCourseDetail(ctx, course.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if detail.StudentCount != 1 {
+		t.Fatalf("expected 1 student, got %d", detail.StudentCount)
+	}
+
+	payments, err := svc.Payment.GetPaymentsByUserID(ctx, 7, pagination.Page{Number: 1, Size: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *payments.Total != 1 || payments.Items[0].Status != "completed" || payments.Items[0].CourseTitle != course.Title {
+		t.Fatalf("unexpected payments %d %+v", *payments.Total, payments.Items)
+	}
+}
+
+func TestCreatePaymentRejectsUnknownCourse(t *testing.T) {
+	svc := New(memory.New(), storage.NewMemory(), &config.UploadConfig{})
+	if _, err := svc.Payment.CreatePayment(context.Background(), 7, 42, "wechat"); err == nil || err.Error() != "课程不存在" {
+		t.Fatalf("expected 课程不存在, got %v", err)
+	}
+	if err := svc.Payment.UpdatePaymentStatus(context.Background(), "ORD-404", "", "failed"); err == nil || err.Error() != "支付订单不存在" {
+		t.Fatalf("expected 支付订单不存在, got %v", err)
+	}
+}
+
+func TestReenrollAfterUnenroll(t *testing.T) {
+	ctx := context.Background()
+	svc := New(memory.New(), storage.NewMemory(), &config.UploadConfig{})
+	course := newCourse(t, svc, 0)
+
+	if err := svc.UserCourse.EnrollCourse(ctx, 7, course.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.UserCourse.EnrollCourse(ctx, 7, course.ID); err == nil || err.Error() != "您已报名该课程" {
+		t.Fatalf("expected duplicate enrollment to fail, got %v", err)
+	}
+	if err := svc.UserCourse.UnenrollCourse(ctx, 7, course.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.UserCourse.UnenrollCourse(ctx, 7, course.ID); err == nil {
+		t.Fatal("expected unenrolling twice to fail")
+	}
+
+	courses, total, err := svc.UserCourse.GetUserCourses(ctx, 7, 1, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 0 || len(courses) != 0 {
+		t.Fatalf("expected no active enrollments, got %d", total)
+	}
+
+	// 重新报名复用原记录
+	first, _ := svc.UserCourse.GetUserCourseByID(ctx, 7, course.ID)
+	if err := svc.UserCourse.EnrollCourse(ctx, 7, course.ID); err != nil {
+		t.Fatal(err)
+	}
+	again, err := svc.UserCourse.GetUserCourseByID(ctx, 7, course.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.ID != first.ID || again.Status != 1 {
+		t.Fatalf("expected enrollment %d to be reactivated, got %+v", first.ID, again)
+	}
+
+	courses, total, err = svc.UserCourse.GetUserCourses(ctx, 7, 1, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 1 || courses[0].Course.Title != course.Title {
+		t.Fatalf("unexpected enrollments %d %+v", total, courses)
+	}
+}
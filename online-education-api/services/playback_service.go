@@ -0,0 +1,120 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"online-education-api/models"
+	"online-education-api/repository"
+	"online-education-api/urlsign"
+)
+
+// PlaybackService 视频播放地址服务接口
+//
+// 课程详情和视频详情中的播放地址只返回给有权观看的用户，签名后的地址在有效期内只能由该用户使用。
+// 未启用签名时原样返回地址，只做权限过滤。外部链接不在对象存储中，不签名。
+type PlaybackService interface {
+	// SignCourse 为有权观看的课时签名视频地址，清空其他课时的地址。
+	// 免费课时对所有人开放，收费课时只对已购买的学生、课程的教师和管理员开放
+	SignCourse(ctx context.Context, course *models.CourseDetailResponse, userID int64, isAdmin bool, ip string) error
+	// SignVideo 为有权观看的用户签名视频的播放地址，清空其他用户看到的地址。
+	// 公开和不公开列出的视频对所有人开放，私有视频只对作者和管理员开放
+	SignVideo(video *models.Video, userID int64, isAdmin bool, ip string)
+}
+
+// playbackService 视频播放地址服务实现
+type playbackService struct {
+	enrollments repository.EnrollmentRepository
+	signer      *urlsign.Signer
+}
+
+// NewPlaybackService 创建视频播放地址服务实例，signer为nil时不签名
+func NewPlaybackService(enrollments repository.EnrollmentRepository, signer *urlsign.Signer) PlaybackService {
+	return &playbackService{
+		enrollments: enrollments,
+		signer:      signer,
+	}
+}
+
+// UseSigner 启用播放地址签名，对象存储中受保护的文件只能通过签名地址访问
+func (s *Services) UseSigner(signer *urlsign.Signer) {
+	s.Playback = NewPlaybackService(s.repos.Enrollments, signer)
+}
+
+// SignCourse 处理课程详情中课时的视频地址
+func (s *playbackService) SignCourse(ctx context.Context, course *models.CourseDetailResponse, userID int64, isAdmin bool, ip string) error {
+	entitled := isAdmin || userID != 0 && userID == course.TeacherID
+	if !entitled && userID != 0 && hasPaidLesson(course) {
+		var err error
+		if entitled, err = s.enrolled(ctx, userID, course.ID); err != nil {
+			return err
+		}
+	}
+
+	for _, chapter := range course.Chapters {
+		for _, lesson := range chapter.Lessons {
+			if entitled || lesson.Free == 1 {
+				lesson.VideoURL = s.sign(lesson.VideoURL, userID, ip)
+			} else {
+				lesson.VideoURL = ""
+			}
+		}
+	}
+	return nil
+}
+
+// enrolled 判断用户是否已购买课程，已退款的报名不算
+func (s *playbackService) enrolled(ctx context.Context, userID, courseID int64) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
+	enrollment, err := s.enrollments.Get(ctx, userID, courseID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("获取报名记录失败: %w", err)
+	}
+	return enrollment.Status == 1, nil
+}
+
+// hasPaidLesson 判断课程是否有带视频地址的收费课时，没有时不需要查询报名记录
+func hasPaidLesson(course *models.CourseDetailResponse) bool {
+	for _, chapter := range course.Chapters {
+		for _, lesson := range chapter.Lessons {
+			if lesson.Free != 1 && lesson.VideoURL != "" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// SignVideo 处理视频详情中的播放地址，封面不受保护，原样返回
+func (s *playbackService) SignVideo(video *models.Video, userID int64, isAdmin bool, ip string) {
+	if !canViewVideo(video, userID, isAdmin) {
+		video.VideoURL = ""
+		video.HLSURL = ""
+		video.ThumbnailsURL = ""
+		video.Renditions = nil
+		return
+	}
+
+	video.VideoURL = s.sign(video.VideoURL, userID, ip)
+	video.HLSURL = s.sign(video.HLSURL, userID, ip)
+	video.ThumbnailsURL = s.sign(video.ThumbnailsURL, userID, ip)
+	for i := range video.Renditions {
+		video.Renditions[i].PlaylistURL = s.sign(video.Renditions[i].PlaylistURL, userID, ip)
+	}
+}
+
+// sign 签名一个地址。HLS播放列表和WebVTT用相对路径引用同目录下的分片和雪碧图，签名覆盖所在目录
+func (s *playbackService) sign(url string, userID int64, ip string) string {
+	if s.signer == nil || url == "" {
+		return url
+	}
+	dir := strings.HasSuffix(url, ".m3u8") || strings.HasSuffix(url, ".vtt")
+	return s.signer.Sign(url, dir, userID, ip)
+}
@@ -0,0 +1,124 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"online-education-api/models"
+	"online-education-api/repository/memory"
+	"online-education-api/urlsign"
+)
+
+// courseWithLessons 构造一门教师ID为10的课程，包含一个免费课时和一个收费课时
+func courseWithLessons() *models.CourseDetailResponse {
+	course := &models.CourseDetailResponse{}
+	course.ID = 1
+	course.TeacherID = 10
+	course.Chapters = []*models.Chapter{{Lessons: []*models.Lesson{
+		{Title: "试看", VideoURL: "/media/hls/1/master.m3u8", Free: 1},
+		{Title: "正课", VideoURL: "/media/videos/2026/10/a.mp4"},
+	}}}
+	return course
+}
+
+func TestPlaybackSignCourse(t *testing.T) {
+	ctx := context.Background()
+	repos := memory.New()
+	signer := urlsign.New([]byte("secret"), "/media", time.Hour, false)
+	playback := NewPlaybackService(repos.Enrollments, signer)
+
+	tests := []struct {
+		name    string
+		userID  int64
+		isAdmin bool
+		paid    bool
+	}{
+		{"anonymous", 0, false, false},
+		{"not enrolled", 20, false, false},
+		{"refunded", 21, false, false},
+		{"enrolled", 22, false, true},
+		{"teacher", 10, false, true},
+		{"admin", 30, true, true},
+	}
+	if err := repos.Enrollments.Save(ctx, &models.UserCourse{UserID: 21, CourseID: 1, Status: 0}); err != nil {
+		t.Fatal(err)
+	}
+	if err := repos.Enrollments.Save(ctx, &models.UserCourse{UserID: 22, CourseID: 1, Status: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range tests {
+		course := courseWithLessons()
+		if err := playback.SignCourse(ctx, course, tt.userID, tt.isAdmin, "203.0.113.7"); err != nil {
+			t.Fatal(err)
+		}
+		free, paid := course.Chapters[0].Lessons[0].VideoURL, course.Chapters[0].Lessons[1].VideoURL
+
+		// 播放列表的签名覆盖所在目录，分片使用同一个令牌
+		key, userID, err := signer.Verify(strings.TrimPrefix(free, "/media/"), "")
+		if err != nil || key != "hls/1/master.m3u8" || userID != tt.userID {
+			t.Errorf("%s: free lesson %q verifies as %q, %d, %v", tt.name, free, key, userID, err)
+		}
+		token, _, _ := strings.Cut(strings.TrimPrefix(free, "/media/_s/"), "/")
+		if _, _, err := signer.Verify("_s/"+token+"/hls/1/720p/segment_0000.ts", ""); err != nil {
+			t.Errorf("%s: segment not covered by playlist signature: %v", tt.name, err)
+		}
+
+		if !tt.paid {
+			if paid != "" {
+				t.Errorf("%s: paid lesson url = %q, want empty", tt.name, paid)
+			}
+			continue
+		}
+		key, _, err = signer.Verify(strings.TrimPrefix(paid, "/media/"), "")
+		if err != nil || key != "videos/2026/10/a.mp4" {
+			t.Errorf("%s: paid lesson %q verifies as %q, %v", tt.name, paid, key, err)
+		}
+	}
+}
+
+func TestPlaybackSignVideo(t *testing.T) {
+	signer := urlsign.New([]byte("secret"), "/media", time.Hour, true)
+	playback := NewPlaybackService(memory.New().Enrollments, signer)
+
+	newVideo := func(visibility string) *models.Video {
+		return &models.Video{
+			AuthorID:      7,
+			Visibility:    visibility,
+			VideoURL:      "https://cdn.example.com/a.mp4",
+			CoverImageURL: "/media/covers/1.jpg",
+			HLSURL:        "/media/hls/1/master.m3u8",
+			ThumbnailsURL: "/media/hls/1/thumbnails.vtt",
+			Renditions:    []models.VideoRendition{{Name: "720p", PlaylistURL: "/media/hls/1/720p/index.m3u8"}},
+		}
+	}
+
+	video := newVideo(models.VideoVisibilityUnlisted)
+	playback.SignVideo(video, 0, false, "203.0.113.7")
+	if video.VideoURL != "https://cdn.example.com/a.mp4" || video.CoverImageURL != "/media/covers/1.jpg" {
+		t.Fatalf("external and cover urls should not be signed: %+v", video)
+	}
+	// 绑定IP时其他地址不能使用
+	for _, url := range []string{video.HLSURL, video.ThumbnailsURL, video.Renditions[0].PlaylistURL} {
+		path := strings.TrimPrefix(url, "/media/")
+		if _, _, err := signer.Verify(path, "203.0.113.7"); err != nil {
+			t.Errorf("Verify(%q) = %v", url, err)
+		}
+		if _, _, err := signer.Verify(path, "198.51.100.1"); err == nil {
+			t.Errorf("Verify(%q) from another ip succeeded", url)
+		}
+	}
+
+	private := newVideo(models.VideoVisibilityPrivate)
+	playback.SignVideo(private, 8, false, "")
+	if private.VideoURL != "" || private.HLSURL != "" || private.ThumbnailsURL != "" || private.Renditions != nil {
+		t.Fatalf("private video leaked urls: %+v", private)
+	}
+	private = newVideo(models.VideoVisibilityPrivate)
+	playback.SignVideo(private, 7, false, "")
+	if !strings.HasPrefix(private.HLSURL, "/media/_s/") {
+		t.Fatalf("author should get signed url, got %q", private.HLSURL)
+	}
+}
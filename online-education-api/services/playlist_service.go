@@ -0,0 +1,438 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"online-education-api/models"
+	"online-education-api/pagination"
+	"online-education-api/repository"
+)
+
+// 播放列表服务返回的错误，控制器据此返回对应的状态码
+var (
+	ErrPlaylistNotFound      = errors.New("播放列表不存在")
+	ErrPlaylistForbidden     = errors.New("无权限修改该播放列表")
+	ErrPlaylistTitle         = errors.New("标题不能为空")
+	ErrPlaylistFull          = errors.New("播放列表最多包含500个视频")
+	ErrPlaylistVideoExists   = errors.New("视频已在播放列表中")
+	ErrPlaylistVideoNotFound = errors.New("视频不在播放列表中")
+	ErrPlaylistOrder         = errors.New("video_ids必须恰好包含播放列表中可以观看的全部视频")
+)
+
+// maxPlaylistVideos 播放列表中视频的最大数量
+const maxPlaylistVideos = 500
+
+// PlaylistService 播放列表服务接口
+//
+// 用户可以把自己能观看的视频按顺序放入播放列表，创作者把自己的视频放入公开的播放列表即为系列视频。
+// 私有播放列表只有创建者和管理员可见；列表详情只包含当前用户可以观看的视频，私有视频对其他用户不出现。
+// 观看进度来自播放事件，由播放统计服务定期写入，最多延迟一个写入间隔。
+type PlaylistService interface {
+	CreatePlaylist(ctx context.Context, playlist *models.Playlist) error
+	// ListPlaylists 获取播放列表，可见性的权限由调用方检查
+	ListPlaylists(ctx context.Context, page pagination.Page, filter repository.PlaylistFilter) (*pagination.List[*models.Playlist], error)
+	// GetPlaylist 获取播放列表详情，包含视频、总时长和登录用户的观看进度
+	GetPlaylist(ctx context.Context, id, userID int64, isAdmin bool) (*models.Playlist, error)
+	// UpdatePlaylist 修改标题、简介和可见性，只有创建者和管理员可以操作
+	UpdatePlaylist(ctx context.Context, id int64, req *models.UpdatePlaylistRequest, userID int64, isAdmin bool) (*models.Playlist, error)
+	DeletePlaylist(ctx context.Context, id, userID int64, isAdmin bool) error
+	// AddVideo 添加当前用户可以观看的视频，返回更新后的播放列表详情
+	AddVideo(ctx context.Context, id int64, req *models.AddPlaylistVideoRequest, userID int64, isAdmin bool) (*models.Playlist, error)
+	RemoveVideo(ctx context.Context, id int64, videoID int, userID int64, isAdmin bool) error
+	// ReorderVideos 按videoIDs的顺序重新排列当前用户可以观看的视频，看不到的视频位置不变，返回更新后的播放列表详情
+	ReorderVideos(ctx context.Context, id int64, videoIDs []int, userID int64, isAdmin bool) (*models.Playlist, error)
+	// VideoContext 返回视频在播放列表中的位置和前后的视频，用于自动播放下一个视频。
+	// playlistID为0时使用视频作者包含该视频的公开播放列表(系列)；播放列表不可见或不包含该视频时返回nil
+	VideoContext(ctx context.Context, video *models.Video, playlistID, userID int64, isAdmin bool) (*models.VideoPlaylistContext, error)
+}
+
+type playlistService struct {
+	playlists repository.PlaylistRepository
+	videos    repository.VideoRepository
+}
+
+// NewPlaylistService 创建播放列表服务实例
+func NewPlaylistService(playlists repository.PlaylistRepository, videos repository.VideoRepository) PlaylistService {
+	return &playlistService{playlists: playlists, videos: videos}
+}
+
+// playlistCursor 播放列表按创建时间倒序分页的游标
+func playlistCursor(p *models.Playlist) pagination.Cursor {
+	return pagination.Cursor{CreatedAt: p.CreatedAt, ID: p.ID}
+}
+
+// canViewPlaylist 判断用户能否查看播放列表，私有播放列表只有创建者和管理员可以查看
+func canViewPlaylist(playlist *models.Playlist, userID int64, isAdmin bool) bool {
+	return playlist.Visibility != models.PlaylistVisibilityPrivate || isAdmin || (userID != 0 && playlist.UserID == userID)
+}
+
+// getPlaylist 获取当前用户可以查看的播放列表，write为true时还要求是创建者或管理员
+func (s *playlistService) getPlaylist(ctx context.Context, id, userID int64, isAdmin, write bool) (*models.Playlist, error) {
+	playlist, err := s.playlists.GetByID(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrPlaylistNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("获取播放列表失败: %w", err)
+	}
+	if !canViewPlaylist(playlist, userID, isAdmin) {
+		return nil, ErrPlaylistNotFound
+	}
+	if write && playlist.UserID != userID && !isAdmin {
+		return nil, ErrPlaylistForbidden
+	}
+	return playlist, nil
+}
+
+// CreatePlaylist 创建播放列表
+func (s *playlistService) CreatePlaylist(ctx context.Context, playlist *models.Playlist) error {
+	ctx, cancel := context.WithTimeout(ctx, writeTimeout)
+	defer cancel()
+
+	if playlist.Title = strings.TrimSpace(playlist.Title); playlist.Title == "" {
+		return ErrPlaylistTitle
+	}
+	if playlist.Visibility == "" {
+		playlist.Visibility = models.PlaylistVisibilityPublic
+	}
+	if err := s.playlists.Create(ctx, playlist); err != nil {
+		return fmt.Errorf("创建播放列表失败: %w", err)
+	}
+	return nil
+}
+
+// ListPlaylists 获取播放列表
+func (s *playlistService) ListPlaylists(ctx context.Context, page pagination.Page, filter repository.PlaylistFilter) (*pagination.List[*models.Playlist], error) {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
+	playlists, err := s.playlists.List(ctx, filter, page)
+	if err != nil {
+		return nil, fmt.Errorf("获取播放列表失败: %w", err)
+	}
+	list := pagination.NewList(playlists, page, playlistCursor)
+	if err := list.SetTotal(page, func() (int, error) { return s.playlists.Count(ctx, filter) }); err != nil {
+		return nil, fmt.Errorf("获取播放列表总数失败: %w", err)
+	}
+	return list, nil
+}
+
+// GetPlaylist 获取播放列表详情
+func (s *playlistService) GetPlaylist(ctx context.Context, id, userID int64, isAdmin bool) (*models.Playlist, error) {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
+	playlist, err := s.getPlaylist(ctx, id, userID, isAdmin, false)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.loadItems(ctx, playlist, userID, isAdmin); err != nil {
+		return nil, err
+	}
+	return playlist, nil
+}
+
+// visibleItems 返回播放列表中当前用户可以观看的视频，位置按可见的视频重新编号，同时返回看不到的视频ID
+func (s *playlistService) visibleItems(ctx context.Context, id, userID int64, isAdmin bool) ([]*models.PlaylistItem, []int, error) {
+	items, err := s.playlists.Items(ctx, id)
+	if err != nil {
+		return nil, nil, fmt.Errorf("获取播放列表视频失败: %w", err)
+	}
+	visible := items[:0]
+	var hidden []int
+	for _, item := range items {
+		if canViewVideo(&item.Video, userID, isAdmin) {
+			item.Position = len(visible) + 1
+			visible = append(visible, item)
+		} else {
+			hidden = append(hidden, item.Video.ID)
+		}
+	}
+	return visible, hidden, nil
+}
+
+// loadItems 设置播放列表的视频、总时长和登录用户的观看进度
+func (s *playlistService) loadItems(ctx context.Context, playlist *models.Playlist, userID int64, isAdmin bool) error {
+	items, hidden, err := s.visibleItems(ctx, playlist.ID, userID, isAdmin)
+	if err != nil {
+		return err
+	}
+	playlist.Items = items
+	// 只告诉创建者看不到的视频，以便移除
+	if userID != 0 && playlist.UserID == userID {
+		playlist.HiddenVideoIDs = hidden
+	}
+	playlist.VideoCount = len(items)
+	playlist.TotalDuration = 0
+	for _, item := range items {
+		playlist.TotalDuration += item.Video.Duration
+	}
+	if userID == 0 || len(items) == 0 {
+		return nil
+	}
+
+	videoIDs := make([]int, len(items))
+	for i, item := range items {
+		videoIDs[i] = item.Video.ID
+	}
+	progress, err := s.videos.Progress(ctx, userID, videoIDs)
+	if err != nil {
+		return fmt.Errorf("获取观看进度失败: %w", err)
+	}
+
+	summary := &models.PlaylistProgress{}
+	for _, item := range items {
+		p, ok := progress[item.Video.ID]
+		if ok {
+			item.Progress = p
+		}
+		switch {
+		case ok && p.Completed:
+			summary.Completed++
+			summary.WatchedSeconds += item.Video.Duration
+		case ok:
+			summary.WatchedSeconds += min(int(p.Position), item.Video.Duration)
+			fallthrough
+		default:
+			if summary.NextVideoID == 0 {
+				summary.NextVideoID = item.Video.ID
+			}
+		}
+	}
+	if playlist.TotalDuration > 0 {
+		summary.Percent = float64(summary.WatchedSeconds) / float64(playlist.TotalDuration)
+	}
+	playlist.Progress = summary
+	return nil
+}
+
+// UpdatePlaylist 修改播放列表，未提供的字段保持不变
+func (s *playlistService) UpdatePlaylist(ctx context.Context, id int64, req *models.UpdatePlaylistRequest, userID int64, isAdmin bool) (*models.Playlist, error) {
+	ctx, cancel := context.WithTimeout(ctx, writeTimeout)
+	defer cancel()
+
+	playlist, err := s.getPlaylist(ctx, id, userID, isAdmin, true)
+	if err != nil {
+		return nil, err
+	}
+	if req.Title != nil {
+		if playlist.Title = strings.TrimSpace(*req.Title); playlist.Title == "" {
+			return nil, ErrPlaylistTitle
+		}
+	}
+	if req.Description != nil {
+		playlist.Description = *req.Description
+	}
+	if req.Visibility != nil {
+		playlist.Visibility = *req.Visibility
+	}
+	if err := s.playlists.Update(ctx, playlist); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrPlaylistNotFound
+		}
+		return nil, fmt.Errorf("更新播放列表失败: %w", err)
+	}
+	return playlist, nil
+}
+
+// DeletePlaylist 删除播放列表，列表中的视频不受影响
+func (s *playlistService) DeletePlaylist(ctx context.Context, id, userID int64, isAdmin bool) error {
+	ctx, cancel := context.WithTimeout(ctx, writeTimeout)
+	defer cancel()
+
+	if _, err := s.getPlaylist(ctx, id, userID, isAdmin, true); err != nil {
+		return err
+	}
+	if err := s.playlists.Delete(ctx, id); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrPlaylistNotFound
+		}
+		return fmt.Errorf("删除播放列表失败: %w", err)
+	}
+	return nil
+}
+
+// AddVideo 向播放列表添加视频
+func (s *playlistService) AddVideo(ctx context.Context, id int64, req *models.AddPlaylistVideoRequest, userID int64, isAdmin bool) (*models.Playlist, error) {
+	ctx, cancel := context.WithTimeout(ctx, writeTimeout)
+	defer cancel()
+
+	playlist, err := s.getPlaylist(ctx, id, userID, isAdmin, true)
+	if err != nil {
+		return nil, err
+	}
+	if playlist.VideoCount >= maxPlaylistVideos {
+		return nil, ErrPlaylistFull
+	}
+	// 只能添加自己可以观看的视频，私有视频对其他用户视为不存在
+	video, err := s.videos.GetByID(ctx, req.VideoID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrVideoNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("获取视频失败: %w", err)
+	}
+	if !canViewVideo(video, userID, isAdmin) {
+		return nil, ErrVideoNotFound
+	}
+
+	position, err := s.storedPosition(ctx, id, req.Position, userID, isAdmin)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.playlists.AddVideo(ctx, id, req.VideoID, position); err != nil {
+		switch {
+		case errors.Is(err, repository.ErrDuplicate):
+			return nil, ErrPlaylistVideoExists
+		case errors.Is(err, repository.ErrNotFound):
+			return nil, ErrVideoNotFound
+		}
+		return nil, fmt.Errorf("添加视频失败: %w", err)
+	}
+	if err := s.loadItems(ctx, playlist, userID, isAdmin); err != nil {
+		return nil, err
+	}
+	return playlist, nil
+}
+
+// storedPosition 把按可见视频编号的插入位置换算为存储的位置，即插入到第position个可见视频之前；
+// 超过可见视频的数量时返回0，添加到末尾
+func (s *playlistService) storedPosition(ctx context.Context, id int64, position int, userID int64, isAdmin bool) (int, error) {
+	if position <= 0 {
+		return 0, nil
+	}
+	items, err := s.playlists.Items(ctx, id)
+	if err != nil {
+		return 0, fmt.Errorf("获取播放列表视频失败: %w", err)
+	}
+	for _, item := range items {
+		if !canViewVideo(&item.Video, userID, isAdmin) {
+			continue
+		}
+		if position--; position == 0 {
+			return item.Position, nil
+		}
+	}
+	return 0, nil
+}
+
+// RemoveVideo 从播放列表移除视频
+func (s *playlistService) RemoveVideo(ctx context.Context, id int64, videoID int, userID int64, isAdmin bool) error {
+	ctx, cancel := context.WithTimeout(ctx, writeTimeout)
+	defer cancel()
+
+	if _, err := s.getPlaylist(ctx, id, userID, isAdmin, true); err != nil {
+		return err
+	}
+	if err := s.playlists.RemoveVideo(ctx, id, videoID); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrPlaylistVideoNotFound
+		}
+		return fmt.Errorf("移除视频失败: %w", err)
+	}
+	return nil
+}
+
+// ReorderVideos 调整播放列表的顺序，video_ids必须是当前用户可以观看的视频的一个排列
+// 看不到的私有视频保持原来的位置，其余位置按video_ids的顺序依次放入可见的视频
+func (s *playlistService) ReorderVideos(ctx context.Context, id int64, videoIDs []int, userID int64, isAdmin bool) (*models.Playlist, error) {
+	ctx, cancel := context.WithTimeout(ctx, writeTimeout)
+	defer cancel()
+
+	playlist, err := s.getPlaylist(ctx, id, userID, isAdmin, true)
+	if err != nil {
+		return nil, err
+	}
+	items, err := s.playlists.Items(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("获取播放列表视频失败: %w", err)
+	}
+	visible := make(map[int]bool, len(items))
+	for _, item := range items {
+		if canViewVideo(&item.Video, userID, isAdmin) {
+			visible[item.Video.ID] = true
+		}
+	}
+	if len(videoIDs) != len(visible) {
+		return nil, ErrPlaylistOrder
+	}
+	for _, videoID := range videoIDs {
+		if !visible[videoID] {
+			return nil, ErrPlaylistOrder
+		}
+		// 重复的ID
+		delete(visible, videoID)
+	}
+
+	order := make([]int, len(items))
+	next := 0
+	for i, item := range items {
+		if canViewVideo(&item.Video, userID, isAdmin) {
+			order[i] = videoIDs[next]
+			next++
+		} else {
+			order[i] = item.Video.ID
+		}
+	}
+	if err := s.playlists.Reorder(ctx, id, order); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrPlaylistNotFound
+		}
+		return nil, fmt.Errorf("调整播放列表顺序失败: %w", err)
+	}
+	if err := s.loadItems(ctx, playlist, userID, isAdmin); err != nil {
+		return nil, err
+	}
+	return playlist, nil
+}
+
+// VideoContext 返回视频在播放列表中的位置
+func (s *playlistService) VideoContext(ctx context.Context, video *models.Video, playlistID, userID int64, isAdmin bool) (*models.VideoPlaylistContext, error) {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
+	var playlist *models.Playlist
+	var err error
+	if playlistID != 0 {
+		playlist, err = s.getPlaylist(ctx, playlistID, userID, isAdmin, false)
+		if errors.Is(err, ErrPlaylistNotFound) {
+			return nil, nil
+		}
+	} else {
+		// 没有指定播放列表时使用作者的系列
+		playlist, err = s.playlists.FindByVideo(ctx, video.ID, video.AuthorID, models.PlaylistVisibilityPublic)
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, nil
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("获取播放列表失败: %w", err)
+	}
+
+	items, _, err := s.visibleItems(ctx, playlist.ID, userID, isAdmin)
+	if err != nil {
+		return nil, err
+	}
+	for i, item := range items {
+		if item.Video.ID != video.ID {
+			continue
+		}
+		c := &models.VideoPlaylistContext{ID: playlist.ID, Title: playlist.Title, Position: item.Position, VideoCount: len(items)}
+		if i > 0 {
+			c.Previous = videoRef(&items[i-1].Video)
+		}
+		if i+1 < len(items) {
+			c.Next = videoRef(&items[i+1].Video)
+		}
+		return c, nil
+	}
+	return nil, nil
+}
+
+// videoRef 返回视频的简要信息
+func videoRef(v *models.Video) *models.VideoRef {
+	return &models.VideoRef{ID: v.ID, Title: v.Title, CoverImageURL: v.CoverImageURL, Duration: v.Duration}
+}
@@ -0,0 +1,188 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"online-education-api/models"
+	"online-education-api/repository/memory"
+)
+
+func TestPlaylistProgress(t *testing.T) {
+	ctx := context.Background()
+	repos := memory.New()
+	var videos []*models.Video
+	for _, duration := range []int{100, 200, 300} {
+		video := &models.Video{Title: "Go", VideoURL: "/v.mp4", AuthorID: 10, Duration: duration, Visibility: models.VideoVisibilityPublic}
+		if err := repos.Videos.Create(ctx, video); err != nil {
+			t.Fatal(err)
+		}
+		videos = append(videos, video)
+	}
+	svc := NewPlaylistService(repos.Playlists, repos.Videos)
+	playlist := &models.Playlist{UserID: 10, Title: " 系列 "}
+	if err := svc.CreatePlaylist(ctx, playlist); err != nil {
+		t.Fatal(err)
+	}
+	if playlist.Title != "系列" || playlist.Visibility != models.PlaylistVisibilityPublic {
+		t.Fatalf("unexpected playlist %+v", playlist)
+	}
+	for _, video := range videos {
+		if _, err := svc.AddVideo(ctx, playlist.ID, &models.AddPlaylistVideoRequest{VideoID: video.ID}, 10, false); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 第一个视频看完，第二个看了50秒
+	err := repos.Videos.SaveProgress(ctx, []*models.VideoProgress{
+		{UserID: 20, VideoID: videos[0].ID, Position: 90, Completed: true},
+		{UserID: 20, VideoID: videos[1].ID, Position: 50},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := svc.GetPlaylist(ctx, playlist.ID, 20, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.TotalDuration != 600 || got.Progress == nil {
+		t.Fatalf("unexpected playlist %+v", got)
+	}
+	want := models.PlaylistProgress{Completed: 1, WatchedSeconds: 150, Percent: 0.25, NextVideoID: videos[1].ID}
+	if *got.Progress != want {
+		t.Errorf("progress = %+v, want %+v", *got.Progress, want)
+	}
+
+	// 匿名用户没有进度
+	if got, err = svc.GetPlaylist(ctx, playlist.ID, 0, false); err != nil || got.Progress != nil {
+		t.Errorf("anonymous progress = %+v, %v", got.Progress, err)
+	}
+}
+
+func TestReorderPlaylistValidation(t *testing.T) {
+	ctx := context.Background()
+	repos := memory.New()
+	svc := NewPlaylistService(repos.Playlists, repos.Videos)
+	playlist := &models.Playlist{UserID: 10, Title: "列表"}
+	if err := svc.CreatePlaylist(ctx, playlist); err != nil {
+		t.Fatal(err)
+	}
+	var ids []int
+	for range 2 {
+		video := &models.Video{Title: "Go", VideoURL: "/v.mp4", AuthorID: 10, Visibility: models.VideoVisibilityPublic}
+		if err := repos.Videos.Create(ctx, video); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := svc.AddVideo(ctx, playlist.ID, &models.AddPlaylistVideoRequest{VideoID: video.ID}, 10, false); err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, video.ID)
+	}
+
+	for _, order := range [][]int{{ids[0]}, {ids[0], ids[0]}, {ids[0], 999}, {ids[1], ids[0], ids[0]}} {
+		if _, err := svc.ReorderVideos(ctx, playlist.ID, order, 10, false); !errors.Is(err, ErrPlaylistOrder) {
+			t.Errorf("ReorderVideos(%v) err = %v, want ErrPlaylistOrder", order, err)
+		}
+	}
+	if _, err := svc.ReorderVideos(ctx, playlist.ID, []int{ids[1], ids[0]}, 20, false); !errors.Is(err, ErrPlaylistForbidden) {
+		t.Errorf("other user err = %v, want ErrPlaylistForbidden", err)
+	}
+	got, err := svc.ReorderVideos(ctx, playlist.ID, []int{ids[1], ids[0]}, 10, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Items[0].Video.ID != ids[1] || got.Items[0].Position != 1 || got.Items[1].Video.ID != ids[0] {
+		t.Errorf("unexpected order %v, %v", got.Items[0], got.Items[1])
+	}
+}
+
+func TestPlaylistKeepsHiddenVideosInPlace(t *testing.T) {
+	ctx := context.Background()
+	repos := memory.New()
+	svc := NewPlaylistService(repos.Playlists, repos.Videos)
+	playlist := &models.Playlist{UserID: 10, Title: "列表"}
+	if err := svc.CreatePlaylist(ctx, playlist); err != nil {
+		t.Fatal(err)
+	}
+	newVideo := func(authorID int64) *models.Video {
+		video := &models.Video{Title: "Go", VideoURL: "/v.mp4", AuthorID: authorID, Visibility: models.VideoVisibilityPublic}
+		if err := repos.Videos.Create(ctx, video); err != nil {
+			t.Fatal(err)
+		}
+		return video
+	}
+	// a、c、d是创建者的视频，b是其他用户的视频
+	a, b, c, d := newVideo(10), newVideo(20), newVideo(10), newVideo(10)
+	for _, video := range []*models.Video{a, b, c} {
+		if _, err := svc.AddVideo(ctx, playlist.ID, &models.AddPlaylistVideoRequest{VideoID: video.ID}, 10, false); err != nil {
+			t.Fatal(err)
+		}
+	}
+	b.Visibility = models.VideoVisibilityPrivate
+	if err := repos.Videos.Update(ctx, b); err != nil {
+		t.Fatal(err)
+	}
+
+	// order 返回用户看到的视频ID
+	order := func(p *models.Playlist) []int {
+		var ids []int
+		for _, item := range p.Items {
+			ids = append(ids, item.Video.ID)
+		}
+		return ids
+	}
+	// stored 返回b的作者看到的全部视频ID
+	stored := func() []int {
+		got, err := svc.GetPlaylist(ctx, playlist.ID, 20, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.HiddenVideoIDs != nil {
+			t.Errorf("hidden videos %v returned to another user", got.HiddenVideoIDs)
+		}
+		return order(got)
+	}
+
+	got, err := svc.GetPlaylist(ctx, playlist.ID, 10, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(order(got), []int{a.ID, c.ID}) || !slices.Equal(got.HiddenVideoIDs, []int{b.ID}) {
+		t.Fatalf("owner sees %v, hidden %v", order(got), got.HiddenVideoIDs)
+	}
+
+	// 只按可见的视频排序，b保持在第二位
+	if _, err := svc.ReorderVideos(ctx, playlist.ID, []int{c.ID, a.ID, b.ID}, 10, false); !errors.Is(err, ErrPlaylistOrder) {
+		t.Errorf("reorder with hidden video err = %v, want ErrPlaylistOrder", err)
+	}
+	if got, err = svc.ReorderVideos(ctx, playlist.ID, []int{c.ID, a.ID}, 10, false); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(order(got), []int{c.ID, a.ID}) || got.Items[1].Position != 2 {
+		t.Errorf("owner sees %v after reorder", order(got))
+	}
+	if ids := stored(); !slices.Equal(ids, []int{c.ID, b.ID, a.ID}) {
+		t.Errorf("stored order %v, want %v", ids, []int{c.ID, b.ID, a.ID})
+	}
+
+	// 插入到可见的第二位，即a之前
+	if got, err = svc.AddVideo(ctx, playlist.ID, &models.AddPlaylistVideoRequest{VideoID: d.ID, Position: 2}, 10, false); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(order(got), []int{c.ID, d.ID, a.ID}) {
+		t.Errorf("owner sees %v after add", order(got))
+	}
+	if ids := stored(); !slices.Equal(ids, []int{c.ID, b.ID, d.ID, a.ID}) {
+		t.Errorf("stored order %v, want %v", ids, []int{c.ID, b.ID, d.ID, a.ID})
+	}
+
+	// 创建者可以移除看不到的视频
+	if err := svc.RemoveVideo(ctx, playlist.ID, b.ID, 10, false); err != nil {
+		t.Fatal(err)
+	}
+	if got, err = svc.GetPlaylist(ctx, playlist.ID, 10, false); err != nil || got.HiddenVideoIDs != nil || got.VideoCount != 3 {
+		t.Errorf("after removing hidden video: %+v, %v", got, err)
+	}
+}
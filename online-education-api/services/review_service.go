@@ -0,0 +1,270 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"online-education-api/models"
+	"online-education-api/pagination"
+	"online-education-api/repository"
+)
+
+// 评价服务返回的错误，控制器据此返回对应的状态码
+var (
+	ErrReviewNotFound   = errors.New("评价不存在")
+	ErrReviewForbidden  = errors.New("无权限操作该评价")
+	ErrNotEnrolled      = errors.New("报名课程后才能评价")
+	ErrReviewExists     = errors.New("您已评价过该课程")
+	ErrReviewReported   = errors.New("您已举报过该评价")
+	ErrCursorNotAllowed = errors.New("按评分排序时不支持游标分页")
+)
+
+// 管理员处理举报的方式
+const (
+	ModerationHide    = "hide"    // 隐藏评价，不再计入课程评分
+	ModerationRestore = "restore" // 恢复显示已隐藏的评价
+	ModerationDismiss = "dismiss" // 驳回举报，评价保持原状态
+)
+
+// ReviewService 课程评价服务接口
+type ReviewService interface {
+	// GetReviewList 获取课程显示中的评价，sort为空时按最新排序，rating为0时不限星级
+	GetReviewList(ctx context.Context, courseID int64, sort string, rating int, page pagination.Page) (*pagination.List[*models.CourseReviewResponse], error)
+	GetReview(ctx context.Context, id int64) (*models.CourseReview, error)
+	// CreateReview 创建评价，只有有效报名的用户可以评价，每个用户对每门课程只能评价一次
+	CreateReview(ctx context.Context, review *models.CourseReview) error
+	// UpdateReview 修改评价的星级和内容，只有评价人可以修改
+	UpdateReview(ctx context.Context, userID int64, review *models.CourseReview) error
+	// DeleteReview 删除评价，评价人和管理员可以删除
+	DeleteReview(ctx context.Context, id, userID int64, isAdmin bool) error
+	// ReplyReview 回复评价，只有课程的教师可以回复
+	ReplyReview(ctx context.Context, id, teacherID int64, reply string) error
+	// ReportReview 举报评价，每个用户对每条评价只能举报一次
+	ReportReview(ctx context.Context, report *models.ReviewReport) error
+	// GetReportedReviews 获取待处理的被举报评价，按未处理的举报数倒序
+	GetReportedReviews(ctx context.Context, page pagination.Page) (*pagination.List[*models.CourseReviewResponse], error)
+	// ModerateReview 处理评价的举报，action为 ModerationHide、ModerationRestore 或 ModerationDismiss
+	ModerateReview(ctx context.Context, id int64, action string) error
+}
+
+// reviewService 课程评价服务实现
+type reviewService struct {
+	reviews     repository.ReviewRepository
+	courses     repository.CourseRepository
+	enrollments repository.EnrollmentRepository
+}
+
+// NewReviewService 创建课程评价服务实例
+func NewReviewService(reviews repository.ReviewRepository, courses repository.CourseRepository, enrollments repository.EnrollmentRepository) ReviewService {
+	return &reviewService{reviews: reviews, courses: courses, enrollments: enrollments}
+}
+
+// reviewCursor 返回评价在按最新排序的列表中的游标
+func reviewCursor(review *models.CourseReviewResponse) pagination.Cursor {
+	return pagination.Cursor{CreatedAt: review.CreatedAt, ID: review.ID}
+}
+
+// listReviews 查询一页评价，只有按最新排序时返回下一页的游标
+func (s *reviewService) listReviews(ctx context.Context, filter repository.ReviewFilter, sort repository.ReviewSort, page pagination.Page) (*pagination.List[*models.CourseReviewResponse], error) {
+	if sort != repository.ReviewSortNewest && page.After != nil {
+		return nil, ErrCursorNotAllowed
+	}
+
+	reviews, err := s.reviews.List(ctx, filter, sort, page)
+	if err != nil {
+		return nil, fmt.Errorf("获取评价列表失败: %w", err)
+	}
+	list := pagination.NewList(reviews, page, reviewCursor)
+	if sort != repository.ReviewSortNewest {
+		list.NextCursor = ""
+	}
+
+	if err := list.SetTotal(page, func() (int, error) { return s.reviews.Count(ctx, filter) }); err != nil {
+		return nil, fmt.Errorf("获取评价总数失败: %w", err)
+	}
+	return list, nil
+}
+
+// GetReviewList 获取课程的评价列表
+func (s *reviewService) GetReviewList(ctx context.Context, courseID int64, sort string, rating int, page pagination.Page) (*pagination.List[*models.CourseReviewResponse], error) {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
+	if sort == "" {
+		sort = string(repository.ReviewSortNewest)
+	}
+	filter := repository.ReviewFilter{CourseID: courseID, Rating: rating, VisibleOnly: true}
+	return s.listReviews(ctx, filter, repository.ReviewSort(sort), page)
+}
+
+// GetReview 根据ID获取评价
+func (s *reviewService) GetReview(ctx context.Context, id int64) (*models.CourseReview, error) {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
+	review, err := s.reviews.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrReviewNotFound
+		}
+		return nil, fmt.Errorf("获取评价失败: %w", err)
+	}
+	return review, nil
+}
+
+// CreateReview 创建评价
+func (s *reviewService) CreateReview(ctx context.Context, review *models.CourseReview) error {
+	ctx, cancel := context.WithTimeout(ctx, writeTimeout)
+	defer cancel()
+
+	// 已取消的报名不能评价
+	enrollment, err := s.enrollments.Get(ctx, review.UserID, review.CourseID)
+	if errors.Is(err, repository.ErrNotFound) || err == nil && enrollment.Status != 1 {
+		return ErrNotEnrolled
+	}
+	if err != nil {
+		return fmt.Errorf("获取报名记录失败: %w", err)
+	}
+
+	// 唯一键保证并发提交时也只有一条评价
+	if err := s.reviews.Create(ctx, review); err != nil {
+		if errors.Is(err, repository.ErrDuplicate) {
+			return ErrReviewExists
+		}
+		return fmt.Errorf("创建评价失败: %w", err)
+	}
+
+	reviewsTotal.Inc()
+	return nil
+}
+
+// UpdateReview 修改评价
+func (s *reviewService) UpdateReview(ctx context.Context, userID int64, review *models.CourseReview) error {
+	ctx, cancel := context.WithTimeout(ctx, writeTimeout)
+	defer cancel()
+
+	stored, err := s.GetReview(ctx, review.ID)
+	if err != nil {
+		return err
+	}
+	if stored.UserID != userID {
+		return ErrReviewForbidden
+	}
+
+	if err := s.reviews.Update(ctx, review); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrReviewNotFound
+		}
+		return fmt.Errorf("修改评价失败: %w", err)
+	}
+	return nil
+}
+
+// DeleteReview 删除评价
+func (s *reviewService) DeleteReview(ctx context.Context, id, userID int64, isAdmin bool) error {
+	ctx, cancel := context.WithTimeout(ctx, writeTimeout)
+	defer cancel()
+
+	stored, err := s.GetReview(ctx, id)
+	if err != nil {
+		return err
+	}
+	if stored.UserID != userID && !isAdmin {
+		return ErrReviewForbidden
+	}
+
+	if err := s.reviews.Delete(ctx, id); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrReviewNotFound
+		}
+		return fmt.Errorf("删除评价失败: %w", err)
+	}
+	return nil
+}
+
+// ReplyReview 教师回复评价
+func (s *reviewService) ReplyReview(ctx context.Context, id, teacherID int64, reply string) error {
+	ctx, cancel := context.WithTimeout(ctx, writeTimeout)
+	defer cancel()
+
+	review, err := s.GetReview(ctx, id)
+	if err != nil {
+		return err
+	}
+	course, err := s.courses.GetByID(ctx, review.CourseID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrReviewNotFound
+		}
+		return fmt.Errorf("获取课程失败: %w", err)
+	}
+	if course.TeacherID != teacherID {
+		return ErrReviewForbidden
+	}
+
+	if err := s.reviews.Reply(ctx, id, reply); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrReviewNotFound
+		}
+		return fmt.Errorf("回复评价失败: %w", err)
+	}
+	return nil
+}
+
+// ReportReview 举报评价
+func (s *reviewService) ReportReview(ctx context.Context, report *models.ReviewReport) error {
+	ctx, cancel := context.WithTimeout(ctx, writeTimeout)
+	defer cancel()
+
+	if err := s.reviews.Report(ctx, report); err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			return ErrReviewNotFound
+		case errors.Is(err, repository.ErrDuplicate):
+			return ErrReviewReported
+		}
+		return fmt.Errorf("举报评价失败: %w", err)
+	}
+
+	reviewReportsTotal.Inc()
+	return nil
+}
+
+// GetReportedReviews 获取待处理的被举报评价
+func (s *reviewService) GetReportedReviews(ctx context.Context, page pagination.Page) (*pagination.List[*models.CourseReviewResponse], error) {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
+	return s.listReviews(ctx, repository.ReviewFilter{Reported: true}, repository.ReviewSortReports, page)
+}
+
+// ModerateReview 处理评价的举报
+func (s *reviewService) ModerateReview(ctx context.Context, id int64, action string) error {
+	ctx, cancel := context.WithTimeout(ctx, writeTimeout)
+	defer cancel()
+
+	review, err := s.GetReview(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	status := review.Status
+	switch action {
+	case ModerationHide:
+		status = 0
+	case ModerationRestore:
+		status = 1
+	case ModerationDismiss:
+	default:
+		return fmt.Errorf("无效的处理方式: %s", action)
+	}
+
+	if err := s.reviews.Moderate(ctx, id, status); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrReviewNotFound
+		}
+		return fmt.Errorf("处理评价举报失败: %w", err)
+	}
+	return nil
+}
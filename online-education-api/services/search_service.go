@@ -0,0 +1,387 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"online-education-api/models"
+	"online-education-api/pagination"
+	"online-education-api/repository"
+	"online-education-api/search"
+)
+
+// rebuildBatchSize 重建索引时每次读取的记录数
+const rebuildBatchSize = 500
+
+// SearchService 搜索服务接口
+type SearchService interface {
+	// Search 搜索文档，搜索文本超过 search.MaxQueryRunes 个字符或 search.MaxQueryTerms 个词时返回 search.ErrQueryTooLong
+	Search(ctx context.Context, q search.Query) (*search.Result, error)
+	// Rebuild 清空索引并从存储重新加载全部上架课程、公开视频和已发布帖子，返回索引的文档数
+	Rebuild(ctx context.Context) (int, error)
+}
+
+// searchService 搜索服务实现，同时负责在写操作后同步索引
+type searchService struct {
+	index      search.Index
+	courses    repository.CourseRepository
+	categories repository.CategoryRepository
+	videos     repository.VideoRepository
+	posts      repository.PostRepository
+}
+
+// NewSearchService 创建搜索服务实例
+func NewSearchService(index search.Index, courses repository.CourseRepository, categories repository.CategoryRepository, videos repository.VideoRepository, posts repository.PostRepository) SearchService {
+	return &searchService{index: index, courses: courses, categories: categories, videos: videos, posts: posts}
+}
+
+// Search 搜索课程、视频和帖子
+func (s *searchService) Search(ctx context.Context, q search.Query) (*search.Result, error) {
+	if err := q.Validate(); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
+	return s.index.Search(ctx, q)
+}
+
+// Rebuild 重建索引
+func (s *searchService) Rebuild(ctx context.Context) (int, error) {
+	if err := s.index.Reset(ctx); err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for offset := 0; ; offset += rebuildBatchSize {
+		courses, err := s.courses.List(ctx, repository.CourseFilter{PublishedOnly: true}, repository.CourseSortNewest, offset, rebuildBatchSize)
+		if err != nil {
+			return count, err
+		}
+		for _, c := range courses {
+			doc := courseDocument(&models.Course{ID: c.ID, Title: c.Title, Description: c.Description, CategoryID: c.CategoryID, Level: c.Level, Price: c.Price, CreatedAt: c.CreatedAt}, c.CategoryName)
+			if err := s.index.Index(ctx, doc); err != nil {
+				return count, err
+			}
+			count++
+		}
+		if len(courses) < rebuildBatchSize {
+			break
+		}
+	}
+
+	// 视频和帖子按游标分批读取，重建期间新增的记录不会导致重复或遗漏
+	videos, err := s.indexVideos(ctx, repository.VideoFilter{})
+	count += videos
+	if err != nil {
+		return count, err
+	}
+
+	page := pagination.Page{Size: rebuildBatchSize}
+	for {
+		posts, err := s.posts.List(ctx, repository.PostFilter{PublishedOnly: true}, page)
+		if err != nil {
+			return count, err
+		}
+		list := pagination.NewList(posts, page, postCursor)
+		for _, post := range list.Items {
+			if err := s.index.Index(ctx, postDocument(post)); err != nil {
+				return count, err
+			}
+			count++
+		}
+		if list.NextCursor == "" {
+			break
+		}
+		cursor := postCursor(list.Items[len(list.Items)-1])
+		page.After = &cursor
+	}
+
+	return count, nil
+}
+
+// indexVideos 按游标分批读取满足筛选条件的公开视频并写入索引，返回写入的数量
+func (s *searchService) indexVideos(ctx context.Context, filter repository.VideoFilter) (int, error) {
+	names, err := s.videoCategoryNames(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	filter.Visibility = models.VideoVisibilityPublic
+	page := pagination.Page{Size: rebuildBatchSize}
+	for {
+		videos, err := s.videos.List(ctx, filter, page)
+		if err != nil {
+			return count, err
+		}
+		list := pagination.NewList(videos, page, videoCursor)
+		for i := range list.Items {
+			if err := s.index.Index(ctx, videoDocument(&list.Items[i], names)); err != nil {
+				return count, err
+			}
+			count++
+		}
+		if list.NextCursor == "" {
+			return count, nil
+		}
+		cursor := videoCursor(list.Items[len(list.Items)-1])
+		page.After = &cursor
+	}
+}
+
+// courseDocument 将课程转换为索引文档
+func courseDocument(course *models.Course, categoryName string) search.Document {
+	price := course.Price
+	return search.Document{
+		Type:         search.TypeCourse,
+		ID:           course.ID,
+		Title:        course.Title,
+		Body:         course.Description,
+		Category:     strconv.FormatInt(course.CategoryID, 10),
+		CategoryName: categoryName,
+		Level:        course.Level,
+		Price:        &price,
+		CreatedAt:    course.CreatedAt,
+	}
+}
+
+// videoDocument 将视频转换为索引文档
+func videoDocument(video *models.Video, categoryNames map[int]string) search.Document {
+	doc := search.Document{
+		Type:      search.TypeVideo,
+		ID:        int64(video.ID),
+		Title:     video.Title,
+		Body:      video.Description,
+		CreatedAt: video.CreatedAt,
+	}
+	if video.CategoryID > 0 {
+		doc.Category = strconv.Itoa(video.CategoryID)
+		doc.CategoryName = categoryNames[video.CategoryID]
+	}
+	return doc
+}
+
+// postDocument 将帖子转换为索引文档
+func postDocument(post *models.PostResponse) search.Document {
+	return search.Document{
+		Type:         search.TypePost,
+		ID:           post.ID,
+		Title:        post.Title,
+		Body:         post.Content,
+		Category:     post.Category,
+		CategoryName: post.Category,
+		CreatedAt:    post.CreatedAt,
+	}
+}
+
+// videoCategoryNames 返回视频分类ID到名称的映射
+func (s *searchService) videoCategoryNames(ctx context.Context) (map[int]string, error) {
+	categories, err := s.videos.Categories(ctx)
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[int]string, len(categories))
+	for _, category := range categories {
+		names[category.ID] = category.Name
+	}
+	return names, nil
+}
+
+// syncCourse 按存储中的最新状态更新课程的索引，课程不存在或已下架时从索引中删除
+func (s *searchService) syncCourse(ctx context.Context, id int64) {
+	err := func() error {
+		course, err := s.courses.GetByID(ctx, id)
+		if errors.Is(err, repository.ErrNotFound) || err == nil && course.Status != 1 {
+			return s.index.Delete(ctx, search.TypeCourse, id)
+		}
+		if err != nil {
+			return err
+		}
+
+		categoryName := ""
+		if category, err := s.categories.GetByID(ctx, course.CategoryID); err == nil {
+			categoryName = category.Name
+		}
+		return s.index.Index(ctx, courseDocument(course, categoryName))
+	}()
+	if err != nil {
+		serviceLog.WarnContext(ctx, "同步课程索引失败", "course_id", id, "error", err)
+	}
+}
+
+// syncVideo 按存储中的最新状态更新视频的索引，视频不存在或不公开时从索引中删除
+func (s *searchService) syncVideo(ctx context.Context, id int) {
+	err := func() error {
+		video, err := s.videos.GetByID(ctx, id)
+		if errors.Is(err, repository.ErrNotFound) || err == nil && video.Visibility != models.VideoVisibilityPublic {
+			return s.index.Delete(ctx, search.TypeVideo, int64(id))
+		}
+		if err != nil {
+			return err
+		}
+
+		names, err := s.videoCategoryNames(ctx)
+		if err != nil {
+			return err
+		}
+		return s.index.Index(ctx, videoDocument(video, names))
+	}()
+	if err != nil {
+		serviceLog.WarnContext(ctx, "同步视频索引失败", "video_id", id, "error", err)
+	}
+}
+
+// syncPost 按存储中的最新状态更新帖子的索引，帖子不存在或未发布时从索引中删除
+func (s *searchService) syncPost(ctx context.Context, id int64) {
+	err := func() error {
+		post, err := s.posts.GetByID(ctx, id)
+		if errors.Is(err, repository.ErrNotFound) || err == nil && post.Status != 1 {
+			return s.index.Delete(ctx, search.TypePost, id)
+		}
+		if err != nil {
+			return err
+		}
+		return s.index.Index(ctx, postDocument(post))
+	}()
+	if err != nil {
+		serviceLog.WarnContext(ctx, "同步帖子索引失败", "post_id", id, "error", err)
+	}
+}
+
+// indexedCourseService 写操作成功后同步搜索索引的课程服务
+type indexedCourseService struct {
+	CourseService
+	search *searchService
+}
+
+// CreateCourse 创建课程
+func (s *indexedCourseService) CreateCourse(ctx context.Context, course *models.Course) error {
+	if err := s.CourseService.CreateCourse(ctx, course); err != nil {
+		return err
+	}
+	s.search.syncCourse(ctx, course.ID)
+	return nil
+}
+
+// UpdateCourse 更新课程
+func (s *indexedCourseService) UpdateCourse(ctx context.Context, course *models.Course) error {
+	if err := s.CourseService.UpdateCourse(ctx, course); err != nil {
+		return err
+	}
+	s.search.syncCourse(ctx, course.ID)
+	return nil
+}
+
+// DeleteCourse 删除课程
+func (s *indexedCourseService) DeleteCourse(ctx context.Context, id int64) error {
+	if err := s.CourseService.DeleteCourse(ctx, id); err != nil {
+		return err
+	}
+	s.search.syncCourse(ctx, id)
+	return nil
+}
+
+// ImportCourse 导入课程
+func (s *indexedCourseService) ImportCourse(ctx context.Context, course *models.Course, chapters []*models.Chapter) error {
+	if err := s.CourseService.ImportCourse(ctx, course, chapters); err != nil {
+		return err
+	}
+	s.search.syncCourse(ctx, course.ID)
+	return nil
+}
+
+// indexedVideoService 写操作成功后同步搜索索引的视频服务
+type indexedVideoService struct {
+	VideoService
+	search *searchService
+}
+
+// CreateVideo 创建视频
+func (s *indexedVideoService) CreateVideo(ctx context.Context, video *models.Video) error {
+	if err := s.VideoService.CreateVideo(ctx, video); err != nil {
+		return err
+	}
+	s.search.syncVideo(ctx, video.ID)
+	return nil
+}
+
+// UpdateVideo 更新视频
+func (s *indexedVideoService) UpdateVideo(ctx context.Context, video *models.Video, userID int64, isAdmin bool) error {
+	if err := s.VideoService.UpdateVideo(ctx, video, userID, isAdmin); err != nil {
+		return err
+	}
+	s.search.syncVideo(ctx, video.ID)
+	return nil
+}
+
+// DeleteVideo 删除视频
+func (s *indexedVideoService) DeleteVideo(ctx context.Context, id int, userID int64, isAdmin bool) error {
+	if err := s.VideoService.DeleteVideo(ctx, id, userID, isAdmin); err != nil {
+		return err
+	}
+	s.search.syncVideo(ctx, id)
+	return nil
+}
+
+// syncCategoryVideos 重新索引分类下的公开视频，categoryID为0时为未分类的视频
+func (s *searchService) syncCategoryVideos(ctx context.Context, categoryID int) {
+	if _, err := s.indexVideos(ctx, repository.VideoFilter{CategoryIDs: []int{categoryID}}); err != nil {
+		serviceLog.WarnContext(ctx, "同步分类视频索引失败", "category_id", categoryID, "error", err)
+	}
+}
+
+// UpdateVideoCategory 更新视频分类，分类名称包含在视频的索引中
+func (s *indexedVideoService) UpdateVideoCategory(ctx context.Context, category *models.VideoCategory) error {
+	if err := s.VideoService.UpdateVideoCategory(ctx, category); err != nil {
+		return err
+	}
+	s.search.syncCategoryVideos(ctx, category.ID)
+	return nil
+}
+
+// DeleteVideoCategory 删除视频分类，分类下的视频移到新的分类后重新索引
+func (s *indexedVideoService) DeleteVideoCategory(ctx context.Context, id int, reassignTo *int) error {
+	if err := s.VideoService.DeleteVideoCategory(ctx, id, reassignTo); err != nil {
+		return err
+	}
+	if reassignTo != nil {
+		s.search.syncCategoryVideos(ctx, *reassignTo)
+	}
+	return nil
+}
+
+// indexedPostService 写操作成功后同步搜索索引的帖子服务
+type indexedPostService struct {
+	PostService
+	search *searchService
+}
+
+// CreatePost 创建帖子
+func (s *indexedPostService) CreatePost(ctx context.Context, post *models.Post) error {
+	if err := s.PostService.CreatePost(ctx, post); err != nil {
+		return err
+	}
+	s.search.syncPost(ctx, post.ID)
+	return nil
+}
+
+// UpdatePost 更新帖子
+func (s *indexedPostService) UpdatePost(ctx context.Context, post *models.Post) error {
+	if err := s.PostService.UpdatePost(ctx, post); err != nil {
+		return err
+	}
+	s.search.syncPost(ctx, post.ID)
+	return nil
+}
+
+// DeletePost 删除帖子
+func (s *indexedPostService) DeletePost(ctx context.Context, id int64) error {
+	if err := s.PostService.DeletePost(ctx, id); err != nil {
+		return err
+	}
+	s.search.syncPost(ctx, id)
+	return nil
+}
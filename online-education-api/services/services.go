@@ -0,0 +1,75 @@
+package services
+
+import (
+	"online-education-api/config"
+	"online-education-api/repository"
+	"online-education-api/search"
+	"online-education-api/storage"
+)
+
+// Services 全部服务实例
+type Services struct {
+	User           UserService
+	CourseCategory CourseCategoryService
+	Course         CourseService
+	UserCourse     UserCourseService
+	Payment        PaymentService
+	Post           PostService
+	Video          VideoService
+	Comment        CommentService
+	Review         ReviewService
+	Upload         UploadService
+	Image          ImageService
+	Subtitle       SubtitleService
+	Playlist       PlaylistService
+	Health         HealthService
+	Search         SearchService
+	// Playback 调用 UseSigner 前只按权限过滤播放地址，不签名
+	Playback PlaybackService
+	// Analytics 使用默认配置，调用 UseAnalytics 可以替换
+	Analytics AnalyticsService
+	// Danmaku 使用默认的限流配置，调用 UseDanmaku 可以替换
+	Danmaku DanmakuService
+	// Transcode 调用 UseTranscoder 后可用，未启用转码时为nil
+	Transcode TranscodeService
+
+	repos *repository.Repositories
+	files storage.Storage
+}
+
+// New 基于一种存储实现创建全部服务，并注入服务之间的依赖。files保存上传的文件，uploads为上传限制。
+// 课程、视频和帖子的写操作会同步到进程内搜索索引，启动时需调用 Search.Rebuild 加载已有数据
+func New(repos *repository.Repositories, files storage.Storage, uploads *config.UploadConfig) *Services {
+	userCourse := NewUserCourseService(repos.Enrollments, repos.Courses)
+	upload := NewUploadService(repos.Uploads, files, uploads)
+	searcher := &searchService{
+		index:      search.NewMemoryIndex(),
+		courses:    repos.Courses,
+		categories: repos.Categories,
+		videos:     repos.Videos,
+		posts:      repos.Posts,
+	}
+
+	return &Services{
+		User:           NewUserService(repos.Users),
+		CourseCategory: NewCourseCategoryService(repos.Categories, repos.Courses),
+		Course:         &indexedCourseService{CourseService: NewCourseService(repos.Courses, repos.Users, repos.Categories), search: searcher},
+		UserCourse:     userCourse,
+		Payment:        NewPaymentService(repos.Payments, repos.Courses, userCourse),
+		Post:           &indexedPostService{PostService: NewPostService(repos.Posts), search: searcher},
+		Video:          &indexedVideoService{VideoService: NewVideoService(repos.Videos, repos.Subtitles, upload), search: searcher},
+		Comment:        NewCommentService(repos.Comments),
+		Review:         NewReviewService(repos.Reviews, repos.Courses, repos.Enrollments),
+		Upload:         upload,
+		Image:          NewImageService(repos, files, uploads),
+		Health:         NewHealthService(repos.Health),
+		Search:         searcher,
+		Playback:       NewPlaybackService(repos.Enrollments, nil),
+		Analytics:      NewAnalyticsService(repos.Videos, &config.AnalyticsConfig{}),
+		Subtitle:       NewSubtitleService(repos),
+		Playlist:       NewPlaylistService(repos.Playlists, repos.Videos),
+		Danmaku:        NewDanmakuService(repos.Danmaku, repos.Videos, &config.DanmakuConfig{}),
+		repos:          repos,
+		files:          files,
+	}
+}
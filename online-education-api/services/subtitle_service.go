@@ -0,0 +1,368 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"online-education-api/models"
+	"online-education-api/repository"
+	"online-education-api/subtitle"
+)
+
+// 字幕服务返回的错误，控制器据此返回对应的状态码
+var (
+	ErrSubtitleNotFound  = errors.New("字幕不存在")
+	ErrSubtitleForbidden = errors.New("无权限管理该字幕")
+	ErrSubtitleLocked    = errors.New("购买课程后才能查看该课时的字幕")
+	ErrSubtitleTooLarge  = errors.New("字幕文件过大")
+	ErrSubtitleLanguage  = errors.New("无效的语言标签")
+	ErrSubtitleLabel     = errors.New("字幕名称不能超过50个字符")
+	ErrSubtitleQuery     = errors.New("搜索词长度必须为1到100个字符")
+	ErrLessonNotFound    = errors.New("课时不存在")
+	// ErrSubtitleFormat 字幕文件无法解析，返回的错误包含具体原因和行号
+	ErrSubtitleFormat = subtitle.ErrFormat
+)
+
+const (
+	// maxSubtitleSize 字幕文件的最大字节数
+	maxSubtitleSize = 2 << 20
+	// maxSubtitleLabel 字幕名称的最大字符数
+	maxSubtitleLabel = 50
+	// maxSubtitleQuery 搜索词的最大字符数
+	maxSubtitleQuery = 100
+	// subtitleSearchLimit 每次搜索最多返回的字幕条数
+	subtitleSearchLimit = 50
+)
+
+// languagePattern BCP 47语言标签的简化格式，如en、zh-CN、zh-Hans-CN
+var languagePattern = regexp.MustCompile(`^[a-zA-Z]{2,3}(-[a-zA-Z0-9]{2,8})*$`)
+
+// SubtitleService 视频和课时的字幕服务接口
+//
+// 上传的WebVTT或SRT文件解析为字幕条后保存，原文件不保留，下载时按请求的格式重新生成。
+// 视频的字幕权限与视频一致：能观看视频的用户可以查看字幕，作者和管理员可以管理字幕。
+// 课时的字幕在课时免费或用户已购买课程时可以查看，课程教师和管理员可以管理字幕。
+type SubtitleService interface {
+	// UploadSubtitle 上传字幕，视频或课时已有相同语言的字幕时替换
+	UploadSubtitle(ctx context.Context, owner repository.SubtitleOwner, req *models.UploadSubtitleRequest, r io.Reader, userID int64, isAdmin bool) (*models.Subtitle, error)
+	// ListSubtitles 获取视频或课时的字幕轨道，默认轨道在前
+	ListSubtitles(ctx context.Context, owner repository.SubtitleOwner, userID int64, isAdmin bool) ([]*models.Subtitle, error)
+	// ExportSubtitle 以指定格式生成字幕文件
+	ExportSubtitle(ctx context.Context, id int64, format subtitle.Format, userID int64, isAdmin bool) ([]byte, error)
+	// UpdateSubtitle 修改字幕名称和默认标记
+	UpdateSubtitle(ctx context.Context, id int64, req *models.UpdateSubtitleRequest, userID int64, isAdmin bool) (*models.Subtitle, error)
+	DeleteSubtitle(ctx context.Context, id, userID int64, isAdmin bool) error
+	// SearchSubtitles 在视频或课时的字幕中查找包含q的字幕条，按出现时间排序，最多返回50条
+	SearchSubtitles(ctx context.Context, owner repository.SubtitleOwner, language, q string, userID int64, isAdmin bool) ([]*models.SubtitleMatch, error)
+}
+
+type subtitleService struct {
+	subtitles   repository.SubtitleRepository
+	videos      repository.VideoRepository
+	courses     repository.CourseRepository
+	enrollments repository.EnrollmentRepository
+}
+
+// NewSubtitleService 创建字幕服务实例
+func NewSubtitleService(repos *repository.Repositories) SubtitleService {
+	return &subtitleService{
+		subtitles:   repos.Subtitles,
+		videos:      repos.Videos,
+		courses:     repos.Courses,
+		enrollments: repos.Enrollments,
+	}
+}
+
+// setSubtitleURL 设置字幕文件的地址
+func setSubtitleURL(s *models.Subtitle) {
+	s.URL = "/api/subtitles/" + strconv.FormatInt(s.ID, 10)
+}
+
+// normalizeLanguage 校验语言标签并转换为规范的大小写：语言小写，地区大写，文字首字母大写，如zh-hans-cn转换为zh-Hans-CN
+func normalizeLanguage(language string) (string, error) {
+	if len(language) > 35 || !languagePattern.MatchString(language) {
+		return "", fmt.Errorf("%w: %q", ErrSubtitleLanguage, language)
+	}
+	parts := strings.Split(language, "-")
+	parts[0] = strings.ToLower(parts[0])
+	for i, part := range parts[1:] {
+		switch len(part) {
+		case 2:
+			parts[i+1] = strings.ToUpper(part)
+		case 4:
+			parts[i+1] = strings.ToUpper(part[:1]) + strings.ToLower(part[1:])
+		default:
+			parts[i+1] = strings.ToLower(part)
+		}
+	}
+	return strings.Join(parts, "-"), nil
+}
+
+// authorize 检查用户能否查看(write为false)或管理(write为true)视频或课时的字幕
+// 私有视频对其他用户视为不存在
+func (s *subtitleService) authorize(ctx context.Context, owner repository.SubtitleOwner, userID int64, isAdmin, write bool) error {
+	if owner.VideoID != 0 {
+		video, err := s.videos.GetByID(ctx, owner.VideoID)
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrVideoNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("获取视频失败: %w", err)
+		}
+		if !canViewVideo(video, userID, isAdmin) {
+			return ErrVideoNotFound
+		}
+		if write && video.AuthorID != userID && !isAdmin {
+			return ErrSubtitleForbidden
+		}
+		return nil
+	}
+
+	lesson, courseID, err := s.courses.GetLesson(ctx, owner.LessonID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrLessonNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("获取课时失败: %w", err)
+	}
+	course, err := s.courses.GetByID(ctx, courseID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrLessonNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("获取课程失败: %w", err)
+	}
+	if isAdmin || (userID != 0 && course.TeacherID == userID) {
+		return nil
+	}
+	if write {
+		return ErrSubtitleForbidden
+	}
+	if lesson.Free == 1 {
+		return nil
+	}
+	if userID == 0 {
+		return ErrSubtitleLocked
+	}
+	enrollment, err := s.enrollments.Get(ctx, userID, courseID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrSubtitleLocked
+	}
+	if err != nil {
+		return fmt.Errorf("获取报名记录失败: %w", err)
+	}
+	// 已退款的报名不算
+	if enrollment.Status != 1 {
+		return ErrSubtitleLocked
+	}
+	return nil
+}
+
+// getSubtitle 获取字幕并检查权限，无法查看所属视频时视为字幕不存在
+func (s *subtitleService) getSubtitle(ctx context.Context, id, userID int64, isAdmin, write bool) (*models.Subtitle, error) {
+	sub, err := s.subtitles.GetByID(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrSubtitleNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("获取字幕失败: %w", err)
+	}
+	owner := repository.SubtitleOwner{VideoID: sub.VideoID, LessonID: sub.LessonID}
+	if err := s.authorize(ctx, owner, userID, isAdmin, write); err != nil {
+		if errors.Is(err, ErrVideoNotFound) || errors.Is(err, ErrLessonNotFound) {
+			return nil, ErrSubtitleNotFound
+		}
+		return nil, err
+	}
+	setSubtitleURL(sub)
+	return sub, nil
+}
+
+// UploadSubtitle 上传字幕，整个文件读入内存解析
+func (s *subtitleService) UploadSubtitle(ctx context.Context, owner repository.SubtitleOwner, req *models.UploadSubtitleRequest, r io.Reader, userID int64, isAdmin bool) (*models.Subtitle, error) {
+	language, err := normalizeLanguage(req.Language)
+	if err != nil {
+		return nil, err
+	}
+	label := strings.TrimSpace(req.Label)
+	if utf8.RuneCountInString(label) > maxSubtitleLabel {
+		return nil, ErrSubtitleLabel
+	}
+	if label == "" {
+		label = language
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r, maxSubtitleSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("接收文件失败: %w", err)
+	}
+	if len(data) > maxSubtitleSize {
+		return nil, fmt.Errorf("%w: 最大%d字节", ErrSubtitleTooLarge, maxSubtitleSize)
+	}
+	cues, _, err := subtitle.Parse(data)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, writeTimeout)
+	defer cancel()
+
+	if err := s.authorize(ctx, owner, userID, isAdmin, true); err != nil {
+		return nil, err
+	}
+
+	sub := &models.Subtitle{
+		VideoID:  owner.VideoID,
+		LessonID: owner.LessonID,
+		Language: language,
+		Label:    label,
+	}
+	if req.IsDefault != nil {
+		sub.IsDefault = *req.IsDefault
+	} else {
+		existing, err := s.subtitles.List(ctx, owner)
+		if err != nil {
+			return nil, fmt.Errorf("获取字幕失败: %w", err)
+		}
+		sub.IsDefault = len(existing) == 0
+		for _, e := range existing {
+			if strings.EqualFold(e.Language, language) {
+				sub.IsDefault = e.IsDefault
+			}
+		}
+	}
+
+	stored := make([]models.SubtitleCue, len(cues))
+	for i, cue := range cues {
+		stored[i] = models.SubtitleCue{Start: cue.Start.Seconds(), End: cue.End.Seconds(), Text: cue.Text}
+	}
+	if err := s.subtitles.Save(ctx, sub, stored); err != nil {
+		return nil, fmt.Errorf("保存字幕失败: %w", err)
+	}
+	setSubtitleURL(sub)
+	return sub, nil
+}
+
+// ListSubtitles 获取视频或课时的字幕轨道
+func (s *subtitleService) ListSubtitles(ctx context.Context, owner repository.SubtitleOwner, userID int64, isAdmin bool) ([]*models.Subtitle, error) {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
+	if err := s.authorize(ctx, owner, userID, isAdmin, false); err != nil {
+		return nil, err
+	}
+	list, err := s.subtitles.List(ctx, owner)
+	if err != nil {
+		return nil, fmt.Errorf("获取字幕失败: %w", err)
+	}
+	for _, sub := range list {
+		setSubtitleURL(sub)
+	}
+	return list, nil
+}
+
+// ExportSubtitle 以指定格式生成字幕文件
+func (s *subtitleService) ExportSubtitle(ctx context.Context, id int64, format subtitle.Format, userID int64, isAdmin bool) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
+	if _, err := s.getSubtitle(ctx, id, userID, isAdmin, false); err != nil {
+		return nil, err
+	}
+	stored, err := s.subtitles.Cues(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("获取字幕失败: %w", err)
+	}
+
+	cues := make([]subtitle.Cue, len(stored))
+	for i, cue := range stored {
+		cues[i] = subtitle.Cue{Start: cueTime(cue.Start), End: cueTime(cue.End), Text: cue.Text}
+	}
+	var buf bytes.Buffer
+	if err := subtitle.Write(&buf, format, cues); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// cueTime 将秒数转换为时间，舍入到毫秒
+func cueTime(s float64) time.Duration {
+	return time.Duration(s*1000+0.5) * time.Millisecond
+}
+
+// UpdateSubtitle 修改字幕名称和默认标记，未提供的字段保持不变
+func (s *subtitleService) UpdateSubtitle(ctx context.Context, id int64, req *models.UpdateSubtitleRequest, userID int64, isAdmin bool) (*models.Subtitle, error) {
+	ctx, cancel := context.WithTimeout(ctx, writeTimeout)
+	defer cancel()
+
+	sub, err := s.getSubtitle(ctx, id, userID, isAdmin, true)
+	if err != nil {
+		return nil, err
+	}
+	if req.Label != nil {
+		if sub.Label = strings.TrimSpace(*req.Label); sub.Label == "" {
+			sub.Label = sub.Language
+		}
+	}
+	if req.IsDefault != nil {
+		sub.IsDefault = *req.IsDefault
+	}
+	if err := s.subtitles.Update(ctx, sub); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrSubtitleNotFound
+		}
+		return nil, fmt.Errorf("更新字幕失败: %w", err)
+	}
+	return sub, nil
+}
+
+// DeleteSubtitle 删除字幕
+func (s *subtitleService) DeleteSubtitle(ctx context.Context, id, userID int64, isAdmin bool) error {
+	ctx, cancel := context.WithTimeout(ctx, writeTimeout)
+	defer cancel()
+
+	if _, err := s.getSubtitle(ctx, id, userID, isAdmin, true); err != nil {
+		return err
+	}
+	if err := s.subtitles.Delete(ctx, id); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrSubtitleNotFound
+		}
+		return fmt.Errorf("删除字幕失败: %w", err)
+	}
+	return nil
+}
+
+// SearchSubtitles 在字幕中查找搜索词，不区分大小写
+func (s *subtitleService) SearchSubtitles(ctx context.Context, owner repository.SubtitleOwner, language, q string, userID int64, isAdmin bool) ([]*models.SubtitleMatch, error) {
+	q = strings.TrimSpace(q)
+	if q == "" || utf8.RuneCountInString(q) > maxSubtitleQuery {
+		return nil, ErrSubtitleQuery
+	}
+	if language != "" {
+		var err error
+		if language, err = normalizeLanguage(language); err != nil {
+			return nil, err
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
+	if err := s.authorize(ctx, owner, userID, isAdmin, false); err != nil {
+		return nil, err
+	}
+	matches, err := s.subtitles.Search(ctx, owner, language, q, subtitleSearchLimit)
+	if err != nil {
+		return nil, fmt.Errorf("搜索字幕失败: %w", err)
+	}
+	return matches, nil
+}
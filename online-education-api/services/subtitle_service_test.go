@@ -0,0 +1,68 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"online-education-api/models"
+	"online-education-api/repository"
+	"online-education-api/repository/memory"
+)
+
+func TestNormalizeLanguage(t *testing.T) {
+	tests := map[string]string{
+		"EN":         "en",
+		"zh-cn":      "zh-CN",
+		"zh-hans-cn": "zh-Hans-CN",
+		"es-419":     "es-419",
+		"de-CH-1996": "de-CH-1996",
+	}
+	for in, want := range tests {
+		got, err := normalizeLanguage(in)
+		if err != nil || got != want {
+			t.Errorf("normalizeLanguage(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"", "e", "english", "zh_CN", "zh-", "en-" + strings.Repeat("a", 9)} {
+		if _, err := normalizeLanguage(in); !errors.Is(err, ErrSubtitleLanguage) {
+			t.Errorf("normalizeLanguage(%q) err = %v, want ErrSubtitleLanguage", in, err)
+		}
+	}
+}
+
+func TestUploadSubtitleLimits(t *testing.T) {
+	ctx := context.Background()
+	repos := memory.New()
+	video := &models.Video{Title: "Go", VideoURL: "/v.mp4", AuthorID: 10, Visibility: models.VideoVisibilityPublic}
+	if err := repos.Videos.Create(ctx, video); err != nil {
+		t.Fatal(err)
+	}
+	svc := NewSubtitleService(repos)
+	owner := repository.SubtitleOwner{VideoID: video.ID}
+	req := &models.UploadSubtitleRequest{Language: "en"}
+
+	large := "WEBVTT\n\n00:01.000 --> 00:02.000\n" + strings.Repeat("a", maxSubtitleSize)
+	if _, err := svc.UploadSubtitle(ctx, owner, req, strings.NewReader(large), 10, false); !errors.Is(err, ErrSubtitleTooLarge) {
+		t.Errorf("large file err = %v, want ErrSubtitleTooLarge", err)
+	}
+	if _, err := svc.UploadSubtitle(ctx, owner, req, strings.NewReader("WEBVTT\n"), 10, false); !errors.Is(err, ErrSubtitleFormat) {
+		t.Errorf("empty file err = %v, want ErrSubtitleFormat", err)
+	}
+	label := &models.UploadSubtitleRequest{Language: "en", Label: strings.Repeat("字", maxSubtitleLabel+1)}
+	if _, err := svc.UploadSubtitle(ctx, owner, label, strings.NewReader("WEBVTT\n"), 10, false); !errors.Is(err, ErrSubtitleLabel) {
+		t.Errorf("long label err = %v, want ErrSubtitleLabel", err)
+	}
+
+	// 明确设为非默认时第一条轨道也不是默认
+	isDefault := false
+	sub, err := svc.UploadSubtitle(ctx, owner, &models.UploadSubtitleRequest{Language: "en", IsDefault: &isDefault},
+		strings.NewReader("1\n00:00:01,000 --> 00:00:02,000\nhi\n"), 10, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sub.IsDefault || sub.Label != "en" {
+		t.Errorf("unexpected subtitle %+v", sub)
+	}
+}
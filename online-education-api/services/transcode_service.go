@@ -0,0 +1,451 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"online-education-api/config"
+	"online-education-api/models"
+	"online-education-api/repository"
+	"online-education-api/storage"
+	"online-education-api/transcode"
+)
+
+// 转码服务返回的错误
+var (
+	ErrTranscodeSource = errors.New("视频不是由上传的文件创建的，不能转码")
+	ErrTranscodeBusy   = errors.New("视频正在转码")
+)
+
+// 默认的转码参数，TranscodeConfig中对应的字段为零值时使用
+const (
+	defaultTranscodeTimeout      = 2 * time.Hour
+	defaultTranscodePollInterval = time.Minute
+	// transcodeBatchSize 每次查询的待转码视频数
+	transcodeBatchSize = 100
+	// maxProcessingError 保存的失败原因的最大长度，与processing_error列一致
+	maxProcessingError = 500
+)
+
+// transcodeContentTypes 转码输出文件的类型，mime包不一定能识别HLS相关的扩展名
+var transcodeContentTypes = map[string]string{
+	".m3u8": "application/vnd.apple.mpegurl",
+	".ts":   "video/mp2t",
+	".jpg":  "image/jpeg",
+	".vtt":  "text/vtt",
+}
+
+// TranscodeService 视频转码服务接口
+//
+// 待转码的视频以status=processing记录在数据库中，Run 在后台逐个处理：从对象存储下载上传的原文件，
+// 转码为多清晰度的HLS并截取封面和缩略图，输出文件保存在对象存储的hls/<视频ID>/下，封面保存为covers/<视频ID>.jpg。
+// 进程退出时未完成的视频保持processing，重启后重新转码。多实例部署时只在一个实例上启用转码。
+type TranscodeService interface {
+	// Enqueue 通知后台任务有新的待转码视频
+	Enqueue(id int)
+	// Retranscode 重新转码视频，只有作者和管理员可以操作，返回更新状态后的视频
+	Retranscode(ctx context.Context, id int, userID int64, isAdmin bool) (*models.Video, error)
+	// Run 持续处理待转码的视频，直到ctx取消并且正在转码的视频处理完成或中止
+	Run(ctx context.Context)
+	// ProcessPending 依次处理当前全部待转码的视频，返回完成或失败的视频数
+	ProcessPending(ctx context.Context) (int, error)
+}
+
+// transcodeService 视频转码服务实现
+type transcodeService struct {
+	videos  repository.VideoRepository
+	uploads repository.UploadRepository
+	files   storage.Storage
+	exec    transcode.Executor
+	ladder  []transcode.Rendition
+	cfg     config.TranscodeConfig
+
+	wake chan struct{}
+	sem  chan struct{} // 限制同时转码的视频数
+
+	mu       sync.Mutex
+	inFlight map[int]bool // 正在转码的视频
+}
+
+// NewTranscodeService 创建视频转码服务实例，cfg中为零值的字段使用默认值
+func NewTranscodeService(videos repository.VideoRepository, uploads repository.UploadRepository, files storage.Storage, exec transcode.Executor, cfg *config.TranscodeConfig) (TranscodeService, error) {
+	c := *cfg
+	if c.Workers <= 0 {
+		c.Workers = 1
+	}
+	if c.Timeout <= 0 {
+		c.Timeout = defaultTranscodeTimeout
+	}
+	if c.PollInterval <= 0 {
+		c.PollInterval = defaultTranscodePollInterval
+	}
+	ladder := transcode.DefaultLadder
+	if c.Renditions != "" {
+		var err error
+		if ladder, err = transcode.ParseLadder(c.Renditions); err != nil {
+			return nil, err
+		}
+	}
+	return &transcodeService{
+		videos:   videos,
+		uploads:  uploads,
+		files:    files,
+		exec:     exec,
+		ladder:   ladder,
+		cfg:      c,
+		wake:     make(chan struct{}, 1),
+		sem:      make(chan struct{}, c.Workers),
+		inFlight: make(map[int]bool),
+	}, nil
+}
+
+// UseTranscoder 启用视频转码：由上传的文件创建的视频先标记为processing，由 Transcode.Run 在后台转码
+func (s *Services) UseTranscoder(exec transcode.Executor, cfg *config.TranscodeConfig) error {
+	t, err := NewTranscodeService(s.repos.Videos, s.repos.Uploads, s.files, exec, cfg)
+	if err != nil {
+		return err
+	}
+	s.Transcode = t
+	s.Video = &transcodingVideoService{VideoService: s.Video, transcoder: t}
+	return nil
+}
+
+// Enqueue 唤醒后台任务，已有未处理的通知时忽略
+func (s *transcodeService) Enqueue(id int) {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Retranscode 重新转码视频
+func (s *transcodeService) Retranscode(ctx context.Context, id int, userID int64, isAdmin bool) (*models.Video, error) {
+	ctx, cancel := context.WithTimeout(ctx, writeTimeout)
+	defer cancel()
+
+	video, err := s.videos.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrVideoNotFound
+		}
+		return nil, fmt.Errorf("获取视频失败: %w", err)
+	}
+	if video.AuthorID != userID && !isAdmin {
+		return nil, ErrVideoForbidden
+	}
+	if video.UploadID == "" {
+		return nil, ErrTranscodeSource
+	}
+	if video.Status == models.VideoStatusProcessing {
+		return nil, ErrTranscodeBusy
+	}
+
+	if err := s.videos.UpdateStatus(ctx, id, models.VideoStatusProcessing, ""); err != nil {
+		return nil, fmt.Errorf("更新转码状态失败: %w", err)
+	}
+	s.Enqueue(id)
+	video.Status = models.VideoStatusProcessing
+	video.ProcessingError = ""
+	return video, nil
+}
+
+// Run 后台转码任务
+func (s *transcodeService) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.cfg.PollInterval)
+	defer ticker.Stop()
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	for {
+		if err := s.dispatch(ctx, &wg); err != nil && ctx.Err() == nil {
+			serviceLog.Error("获取待转码视频失败", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// dispatch 为待转码的视频启动转码，所有转码槽位都被占用时等待下次唤醒
+func (s *transcodeService) dispatch(ctx context.Context, wg *sync.WaitGroup) error {
+	ids, err := s.pending(ctx)
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		select {
+		case s.sem <- struct{}{}:
+		default:
+			return nil
+		}
+		if !s.claim(id) {
+			<-s.sem
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			done := s.process(ctx, id)
+			s.release(id)
+			<-s.sem
+			// 空出的槽位可以处理下一个视频，出错时等待下次轮询，避免数据库不可用时反复重试
+			if done {
+				s.Enqueue(id)
+			}
+		}()
+	}
+	return nil
+}
+
+// ProcessPending 依次处理待转码的视频
+func (s *transcodeService) ProcessPending(ctx context.Context) (int, error) {
+	ids, err := s.pending(ctx)
+	if err != nil {
+		return 0, err
+	}
+	processed := 0
+	for _, id := range ids {
+		if !s.claim(id) {
+			continue
+		}
+		if s.process(ctx, id) {
+			processed++
+		}
+		s.release(id)
+	}
+	return processed, nil
+}
+
+// pending 查询待转码的视频
+func (s *transcodeService) pending(ctx context.Context) ([]int, error) {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+	return s.videos.IDsByStatus(ctx, models.VideoStatusProcessing, transcodeBatchSize)
+}
+
+func (s *transcodeService) claim(id int) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.inFlight[id] {
+		return false
+	}
+	s.inFlight[id] = true
+	return true
+}
+
+func (s *transcodeService) release(id int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.inFlight, id)
+}
+
+// process 转码一个视频并保存结果，失败时将视频标记为failed并记录原因，视频不再待转码时返回true。
+// ctx取消导致的失败不记录，视频保持processing，下次启动时重新转码
+func (s *transcodeService) process(ctx context.Context, id int) bool {
+	video, err := s.videos.GetByID(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return true
+	}
+	if err != nil {
+		if ctx.Err() == nil {
+			serviceLog.Error("获取待转码视频失败", "video_id", id, "error", err)
+		}
+		return false
+	}
+	if video.Status != models.VideoStatusProcessing {
+		return true
+	}
+
+	start := time.Now()
+	err = s.transcode(ctx, video)
+	if err == nil {
+		transcodesTotal.Inc(models.VideoStatusReady)
+		serviceLog.Info("视频转码完成", "video_id", id, "elapsed", time.Since(start).Round(time.Second))
+		return true
+	}
+	if ctx.Err() != nil {
+		serviceLog.Warn("视频转码中止", "video_id", id, "error", err)
+		return false
+	}
+
+	transcodesTotal.Inc(models.VideoStatusFailed)
+	serviceLog.Error("视频转码失败", "video_id", id, "error", err)
+	msg := []rune(err.Error())
+	if len(msg) > maxProcessingError {
+		msg = msg[:maxProcessingError]
+	}
+	dbCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), writeTimeout)
+	defer cancel()
+	if err := s.videos.UpdateStatus(dbCtx, id, models.VideoStatusFailed, string(msg)); err != nil && !errors.Is(err, repository.ErrNotFound) {
+		serviceLog.Error("更新转码状态失败", "video_id", id, "error", err)
+		return false
+	}
+	return true
+}
+
+// transcode 下载原文件、转码并上传输出文件，最后保存转码结果
+func (s *transcodeService) transcode(ctx context.Context, video *models.Video) error {
+	if video.UploadID == "" {
+		return ErrTranscodeSource
+	}
+	upload, err := s.uploads.GetByID(ctx, video.UploadID)
+	if err != nil {
+		return fmt.Errorf("获取上传的文件失败: %w", err)
+	}
+
+	if s.cfg.WorkDir != "" {
+		if err := os.MkdirAll(s.cfg.WorkDir, 0o755); err != nil {
+			return err
+		}
+	}
+	work, err := os.MkdirTemp(s.cfg.WorkDir, "transcode-")
+	if err != nil {
+		return fmt.Errorf("创建临时目录失败: %w", err)
+	}
+	defer os.RemoveAll(work)
+
+	source := filepath.Join(work, "source"+path.Ext(upload.StorageKey))
+	if err := s.download(ctx, upload.StorageKey, source); err != nil {
+		return fmt.Errorf("下载原文件失败: %w", err)
+	}
+	out := filepath.Join(work, "out")
+	if err := os.Mkdir(out, 0o755); err != nil {
+		return err
+	}
+
+	tctx, cancel := context.WithTimeout(ctx, s.cfg.Timeout)
+	defer cancel()
+	result, err := transcode.Process(tctx, s.exec, source, out, s.ladder)
+	if err != nil {
+		return err
+	}
+
+	// 封面在列表中展示，不放在需要签名访问的hls/下；已有封面时不使用截取的封面
+	cover := filepath.Join(work, transcode.CoverImage)
+	if err := os.Rename(filepath.Join(out, transcode.CoverImage), cover); err != nil {
+		return err
+	}
+	coverKey := ""
+	if video.CoverImageURL == "" {
+		coverKey = fmt.Sprintf("covers/%d.jpg", video.ID)
+		if err := s.putFile(ctx, cover, coverKey); err != nil {
+			return fmt.Errorf("保存封面失败: %w", err)
+		}
+		video.CoverImageURL = s.files.URL(coverKey)
+	}
+
+	prefix := fmt.Sprintf("hls/%d/", video.ID)
+	if err := s.publish(ctx, out, prefix); err != nil {
+		return fmt.Errorf("保存转码结果失败: %w", err)
+	}
+
+	video.Duration = int(result.Info.Duration.Round(time.Second) / time.Second)
+	video.HLSURL = s.files.URL(prefix + transcode.MasterPlaylist)
+	video.ThumbnailsURL = s.files.URL(prefix + transcode.ThumbnailsVTT)
+	video.Renditions = nil
+	for _, r := range result.Renditions {
+		video.Renditions = append(video.Renditions, models.VideoRendition{
+			Name:        r.Name,
+			Width:       r.Width,
+			Height:      r.Height,
+			Bandwidth:   r.VideoBitrate + r.AudioBitrate,
+			PlaylistURL: s.files.URL(prefix + r.Playlist),
+		})
+	}
+
+	dbCtx, cancel := context.WithTimeout(ctx, writeTimeout)
+	defer cancel()
+	if err := s.videos.UpdateMedia(dbCtx, video); err != nil && !errors.Is(err, repository.ErrNotFound) {
+		return fmt.Errorf("保存转码结果失败: %w", err)
+	}
+	// 转码期间作者设置了封面时删除截取的封面
+	if coverKey != "" && video.CoverImageURL != s.files.URL(coverKey) {
+		if err := s.files.Delete(dbCtx, coverKey); err != nil {
+			serviceLog.WarnContext(ctx, "删除截取的封面失败", "video_id", video.ID, "error", err)
+		}
+	}
+	return nil
+}
+
+// download 将对象存储中的文件下载到本地
+func (s *transcodeService) download(ctx context.Context, key, dst string) error {
+	r, err := s.files.Open(ctx, key)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	f, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// publish 将目录中的全部文件上传到对象存储，键为prefix加相对路径
+func (s *transcodeService) publish(ctx context.Context, dir, prefix string) error {
+	return filepath.WalkDir(dir, func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, name)
+		if err != nil {
+			return err
+		}
+		return s.putFile(ctx, name, prefix+filepath.ToSlash(rel))
+	})
+}
+
+// putFile 将本地文件上传到对象存储，类型由扩展名决定
+func (s *transcodeService) putFile(ctx context.Context, name, key string) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	contentType := transcodeContentTypes[filepath.Ext(name)]
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	return s.files.Put(ctx, key, f, info.Size(), contentType)
+}
+
+// transcodingVideoService 由上传的文件创建视频后加入转码队列的视频服务
+type transcodingVideoService struct {
+	VideoService
+	transcoder TranscodeService
+}
+
+// CreateVideo 创建视频，使用上传的文件时转码完成前状态为processing
+func (s *transcodingVideoService) CreateVideo(ctx context.Context, video *models.Video) error {
+	if video.UploadID != "" {
+		video.Status = models.VideoStatusProcessing
+	}
+	if err := s.VideoService.CreateVideo(ctx, video); err != nil {
+		return err
+	}
+	if video.Status == models.VideoStatusProcessing {
+		s.transcoder.Enqueue(video.ID)
+	}
+	return nil
+}
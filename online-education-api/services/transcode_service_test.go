@@ -0,0 +1,188 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"online-education-api/config"
+	"online-education-api/models"
+	"online-education-api/repository"
+	"online-education-api/repository/memory"
+	"online-education-api/storage"
+	"online-education-api/transcode"
+)
+
+// newTranscodeTestServices 创建启用了转码的服务，返回服务、对象存储和转码执行器
+func newTranscodeTestServices(t *testing.T) (*Services, *storage.Memory, *transcode.Fake) {
+	t.Helper()
+
+	files := storage.NewMemory()
+	svc := New(memory.New(), files, &config.UploadConfig{TempDir: t.TempDir()})
+	fake := &transcode.Fake{}
+	if err := svc.UseTranscoder(fake, &config.TranscodeConfig{WorkDir: t.TempDir(), Renditions: "360p,720p,1080p"}); err != nil {
+		t.Fatal(err)
+	}
+	return svc, files, fake
+}
+
+// createUploadedVideo 上传文件并由其创建视频
+func createUploadedVideo(t *testing.T, svc *Services, authorID int64) *models.Video {
+	t.Helper()
+
+	ctx := context.Background()
+	data := append([]byte("\x00\x00\x00\x18ftypmp42\x00\x00\x00\x00mp42isom"), bytes.Repeat([]byte{1}, 1000)...)
+	upload, err := svc.Upload.Upload(ctx, authorID, "lesson.mp4", bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	video := &models.Video{Title: "第一课", AuthorID: authorID, UploadID: upload.ID, VideoURL: upload.URL}
+	if err := svc.Video.CreateVideo(ctx, video); err != nil {
+		t.Fatal(err)
+	}
+	return video
+}
+
+func TestTranscodeUploadedVideo(t *testing.T) {
+	ctx := context.Background()
+	svc, files, fake := newTranscodeTestServices(t)
+
+	video := createUploadedVideo(t, svc, 7)
+	if video.Status != models.VideoStatusProcessing {
+		t.Fatalf("Status = %q, want processing", video.Status)
+	}
+	// 直接提供地址的视频不需要转码
+	linked := &models.Video{Title: "外链", VideoURL: "https://example.com/a.mp4"}
+	if err := svc.Video.CreateVideo(ctx, linked); err != nil {
+		t.Fatal(err)
+	}
+	if linked.Status != models.VideoStatusReady {
+		t.Fatalf("linked video Status = %q, want ready", linked.Status)
+	}
+
+	if n, err := svc.Transcode.ProcessPending(ctx); err != nil || n != 1 {
+		t.Fatalf("ProcessPending = %d, %v, want 1", n, err)
+	}
+	got, err := svc.Video.GetVideoByID(ctx, video.ID, 0, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prefix := "/media/hls/" + strconv.Itoa(video.ID) + "/"
+	if got.Status != models.VideoStatusReady || got.Duration != 120 || got.HLSURL != prefix+"master.m3u8" ||
+		got.CoverImageURL != "/media/covers/"+strconv.Itoa(video.ID)+".jpg" || got.ThumbnailsURL != prefix+"thumbnails.vtt" {
+		t.Fatalf("unexpected video after transcoding %+v", got)
+	}
+	// 源文件为720p，不生成1080p
+	if len(got.Renditions) != 2 || got.Renditions[1].Name != "720p" || got.Renditions[1].PlaylistURL != prefix+"720p/index.m3u8" {
+		t.Fatalf("unexpected renditions %+v", got.Renditions)
+	}
+	if !slices.Contains(files.Keys(), "hls/"+strconv.Itoa(video.ID)+"/360p/segment_0000.ts") {
+		t.Errorf("segments not stored: %v", files.Keys())
+	}
+
+	// 只有作者和管理员可以重新转码，失败时记录原因
+	if _, err := svc.Transcode.Retranscode(ctx, video.ID, 8, false); !errors.Is(err, ErrVideoForbidden) {
+		t.Fatalf("Retranscode by another user err = %v", err)
+	}
+	if _, err := svc.Transcode.Retranscode(ctx, linked.ID, 1, true); !errors.Is(err, ErrTranscodeSource) {
+		t.Fatalf("Retranscode linked video err = %v", err)
+	}
+	if _, err := svc.Transcode.Retranscode(ctx, video.ID, 7, false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.Transcode.Retranscode(ctx, video.ID, 7, false); !errors.Is(err, ErrTranscodeBusy) {
+		t.Fatalf("Retranscode while processing err = %v", err)
+	}
+	fake.Err = errors.New("moov atom not found")
+	if _, err := svc.Transcode.ProcessPending(ctx); err != nil {
+		t.Fatal(err)
+	}
+	got, err = svc.Video.GetVideoByID(ctx, video.ID, 0, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Status != models.VideoStatusFailed || !strings.Contains(got.ProcessingError, "moov atom not found") {
+		t.Fatalf("unexpected failed video %+v", got)
+	}
+}
+
+func TestTranscodeRunPicksUpNewVideos(t *testing.T) {
+	svc, _, _ := newTranscodeTestServices(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		svc.Transcode.Run(ctx)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	video := createUploadedVideo(t, svc, 7)
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		got, err := svc.Video.GetVideoByID(context.Background(), video.ID, 0, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Status == models.VideoStatusReady {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("video still %s", got.Status)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// coverDuringTranscode 在保存转码结果之前模拟作者设置封面
+type coverDuringTranscode struct {
+	repository.VideoRepository
+	cover string
+}
+
+func (r *coverDuringTranscode) UpdateMedia(ctx context.Context, video *models.Video) error {
+	stored, err := r.GetByID(ctx, video.ID)
+	if err != nil {
+		return err
+	}
+	stored.CoverImageURL = r.cover
+	if err := r.Update(ctx, stored); err != nil {
+		return err
+	}
+	return r.VideoRepository.UpdateMedia(ctx, video)
+}
+
+func TestTranscodeKeepsCoverSetDuringTranscode(t *testing.T) {
+	ctx := context.Background()
+	repos := memory.New()
+	repos.Videos = &coverDuringTranscode{VideoRepository: repos.Videos, cover: "https://example.com/mine.jpg"}
+	files := storage.NewMemory()
+	svc := New(repos, files, &config.UploadConfig{TempDir: t.TempDir()})
+	if err := svc.UseTranscoder(&transcode.Fake{}, &config.TranscodeConfig{WorkDir: t.TempDir()}); err != nil {
+		t.Fatal(err)
+	}
+
+	video := createUploadedVideo(t, svc, 7)
+	if n, err := svc.Transcode.ProcessPending(ctx); err != nil || n != 1 {
+		t.Fatalf("ProcessPending = %d, %v, want 1", n, err)
+	}
+	got, err := svc.Video.GetVideoByID(ctx, video.ID, 0, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Status != models.VideoStatusReady || got.CoverImageURL != "https://example.com/mine.jpg" || got.HLSURL == "" {
+		t.Fatalf("unexpected video after transcoding %+v", got)
+	}
+	// 没有使用的截取封面被删除
+	if key := "covers/" + strconv.Itoa(video.ID) + ".jpg"; slices.Contains(files.Keys(), key) {
+		t.Errorf("unused cover %s not deleted", key)
+	}
+}
@@ -0,0 +1,428 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"online-education-api/config"
+	"online-education-api/models"
+	"online-education-api/repository"
+	"online-education-api/storage"
+)
+
+// 上传服务返回的错误，控制器据此返回对应的状态码
+var (
+	ErrUploadNotFound   = errors.New("上传不存在")
+	ErrUploadForbidden  = errors.New("无权限操作该上传")
+	ErrUploadCompleted  = errors.New("上传已完成")
+	ErrUploadIncomplete = errors.New("上传未完成")
+	ErrUploadBusy       = errors.New("该上传正在接收其他分片")
+	ErrUploadOffset     = errors.New("分片的偏移量与已接收的字节数不一致")
+	ErrUploadTooLarge   = errors.New("文件超过大小限制")
+	ErrUploadChecksum   = errors.New("分片的校验和不匹配")
+	ErrUploadType       = errors.New("不支持的文件类型，只能上传MP4、WebM或AVI视频")
+)
+
+// 默认的上传限制，UploadConfig中对应的字段为零值时使用
+const (
+	defaultMaxUploadSize    = 2 << 30
+	defaultUploadSessionTTL = 24 * time.Hour
+)
+
+// sniffLen http.DetectContentType 最多读取的字节数
+const sniffLen = 512
+
+// uploadTypes 允许上传的视频类型及保存时使用的扩展名
+var uploadTypes = map[string]string{
+	"video/mp4":  ".mp4",
+	"video/webm": ".webm",
+	"video/avi":  ".avi",
+}
+
+// UploadService 视频上传服务接口
+//
+// 分片上传先创建上传记录，再按顺序提交分片，每个分片的偏移量必须等于已接收的字节数，
+// 中断后可以查询已接收的字节数继续上传。分片暂存在UploadConfig.TempDir，全部接收后写入对象存储。
+type UploadService interface {
+	// CreateUpload 创建分片上传，size为文件的总字节数
+	CreateUpload(ctx context.Context, userID int64, fileName string, size int64) (*models.Upload, error)
+	// GetUpload 获取上传，只有上传者可以访问
+	GetUpload(ctx context.Context, id string, userID int64) (*models.Upload, error)
+	// WriteChunk 从offset处写入一个分片，checksum为分片的SHA256，为空时不校验。
+	// 连接中断时保留已收到的字节；接收完全部字节后将文件写入对象存储并将上传标记为完成
+	WriteChunk(ctx context.Context, id string, userID int64, offset int64, r io.Reader, checksum []byte) (*models.Upload, error)
+	// Upload 一次上传整个文件，文件大小由读取到的字节数确定
+	Upload(ctx context.Context, userID int64, fileName string, r io.Reader) (*models.Upload, error)
+	// CancelUpload 取消未完成的上传并删除已接收的字节
+	CancelUpload(ctx context.Context, id string, userID int64) error
+	// CompletedUpload 获取用户已完成的上传，用于由上传的文件创建视频
+	CompletedUpload(ctx context.Context, id string, userID int64) (*models.Upload, error)
+	// PurgeExpired 删除超过保留时间仍未完成的上传，返回删除的数量
+	PurgeExpired(ctx context.Context) (int, error)
+}
+
+// uploadService 视频上传服务实现
+type uploadService struct {
+	uploads repository.UploadRepository
+	files   storage.Storage
+	cfg     config.UploadConfig
+
+	mu   sync.Mutex
+	busy map[string]bool // 正在写入分片的上传，同一上传同时只接收一个分片
+}
+
+// NewUploadService 创建视频上传服务实例，cfg中为零值的字段使用默认值
+func NewUploadService(uploads repository.UploadRepository, files storage.Storage, cfg *config.UploadConfig) UploadService {
+	c := *cfg
+	if c.MaxSize <= 0 {
+		c.MaxSize = defaultMaxUploadSize
+	}
+	if c.TempDir == "" {
+		c.TempDir = filepath.Join(os.TempDir(), "online-education-uploads")
+	}
+	if c.SessionTTL <= 0 {
+		c.SessionTTL = defaultUploadSessionTTL
+	}
+	return &uploadService{uploads: uploads, files: files, cfg: c, busy: make(map[string]bool)}
+}
+
+// newUploadID 生成32位十六进制的上传ID，ID同时作为暂存文件名，不能被猜到
+func newUploadID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// stagingPath 返回上传暂存文件的路径
+func (s *uploadService) stagingPath(id string) string {
+	return filepath.Join(s.cfg.TempDir, id)
+}
+
+// withURL 为已完成的上传设置访问地址
+func (s *uploadService) withURL(upload *models.Upload) *models.Upload {
+	if upload.Status == models.UploadStatusCompleted && upload.StorageKey != "" {
+		upload.URL = s.files.URL(upload.StorageKey)
+	}
+	return upload
+}
+
+// CreateUpload 创建分片上传
+func (s *uploadService) CreateUpload(ctx context.Context, userID int64, fileName string, size int64) (*models.Upload, error) {
+	ctx, cancel := context.WithTimeout(ctx, writeTimeout)
+	defer cancel()
+
+	if size > s.cfg.MaxSize {
+		return nil, fmt.Errorf("%w: 最大%d字节", ErrUploadTooLarge, s.cfg.MaxSize)
+	}
+	id, err := newUploadID()
+	if err != nil {
+		return nil, fmt.Errorf("生成上传ID失败: %w", err)
+	}
+
+	upload := &models.Upload{
+		ID:       id,
+		UserID:   userID,
+		FileName: filepath.Base(fileName),
+		Size:     size,
+		Status:   models.UploadStatusUploading,
+	}
+	if err := s.uploads.Create(ctx, upload); err != nil {
+		return nil, fmt.Errorf("创建上传失败: %w", err)
+	}
+	return upload, nil
+}
+
+// GetUpload 获取上传
+func (s *uploadService) GetUpload(ctx context.Context, id string, userID int64) (*models.Upload, error) {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
+	upload, err := s.uploads.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrUploadNotFound
+		}
+		return nil, fmt.Errorf("获取上传失败: %w", err)
+	}
+	if upload.UserID != userID {
+		return nil, ErrUploadForbidden
+	}
+	return s.withURL(upload), nil
+}
+
+// CompletedUpload 获取已完成的上传
+func (s *uploadService) CompletedUpload(ctx context.Context, id string, userID int64) (*models.Upload, error) {
+	upload, err := s.GetUpload(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if upload.Status != models.UploadStatusCompleted {
+		return nil, ErrUploadIncomplete
+	}
+	return upload, nil
+}
+
+// lock 标记上传正在写入，已有分片在写入时返回false
+func (s *uploadService) lock(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.busy[id] {
+		return false
+	}
+	s.busy[id] = true
+	return true
+}
+
+func (s *uploadService) unlock(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.busy, id)
+}
+
+// WriteChunk 写入一个分片
+//
+// 分片的读取时间取决于客户端，只有数据库操作使用超时；对象存储的写入使用请求的上下文
+func (s *uploadService) WriteChunk(ctx context.Context, id string, userID int64, offset int64, r io.Reader, checksum []byte) (*models.Upload, error) {
+	if !s.lock(id) {
+		return nil, ErrUploadBusy
+	}
+	defer s.unlock(id)
+
+	upload, err := s.GetUpload(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if upload.Status != models.UploadStatusUploading {
+		return nil, ErrUploadCompleted
+	}
+	if offset != upload.Received {
+		return nil, ErrUploadOffset
+	}
+
+	if err := os.MkdirAll(s.cfg.TempDir, 0o755); err != nil {
+		return nil, fmt.Errorf("创建暂存目录失败: %w", err)
+	}
+	f, err := os.OpenFile(s.stagingPath(id), os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("打开暂存文件失败: %w", err)
+	}
+	defer f.Close()
+
+	// 数据库中的偏移量为准，丢弃上次失败的请求写了一半的内容
+	if err := f.Truncate(offset); err != nil {
+		return nil, fmt.Errorf("截断暂存文件失败: %w", err)
+	}
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("定位暂存文件失败: %w", err)
+	}
+
+	// 多读一个字节用于判断分片是否超出声明的文件大小
+	sum := sha256.New()
+	n, copyErr := io.Copy(io.MultiWriter(f, sum), io.LimitReader(r, upload.Size-offset+1))
+	switch {
+	case offset+n > upload.Size:
+		f.Truncate(offset)
+		return nil, fmt.Errorf("%w: 文件声明的大小为%d字节", ErrUploadTooLarge, upload.Size)
+	case checksum != nil && (copyErr != nil || !bytes.Equal(sum.Sum(nil), checksum)):
+		// 带校验和的分片只能完整接收
+		f.Truncate(offset)
+		if copyErr != nil {
+			return nil, fmt.Errorf("接收分片失败: %w", copyErr)
+		}
+		return nil, ErrUploadChecksum
+	}
+
+	received := offset + n
+	if upload.MimeType == "" && received >= min(sniffLen, upload.Size) {
+		upload.MimeType, err = sniffFile(f)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := uploadTypes[upload.MimeType]; !ok {
+			f.Close()
+			s.discard(ctx, id)
+			return nil, fmt.Errorf("%w: %s", ErrUploadType, upload.MimeType)
+		}
+	}
+
+	// 连接中断时保留已收到的字节，客户端可以从新的偏移量继续上传
+	dbCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), writeTimeout)
+	defer cancel()
+	if err := s.uploads.UpdateReceived(dbCtx, id, received, upload.MimeType); err != nil {
+		return nil, fmt.Errorf("更新上传进度失败: %w", err)
+	}
+	upload.Received = received
+	if copyErr != nil {
+		return nil, fmt.Errorf("接收分片失败: %w", copyErr)
+	}
+
+	if received == upload.Size {
+		if err := s.finish(ctx, upload, f); err != nil {
+			return nil, err
+		}
+		f.Close()
+		os.Remove(s.stagingPath(id))
+		uploadsCompletedTotal.Inc("chunked")
+		uploadBytesTotal.Add(float64(upload.Size))
+	}
+	return s.withURL(upload), nil
+}
+
+// Upload 一次上传整个文件，先写入暂存文件以计算大小和校验和
+func (s *uploadService) Upload(ctx context.Context, userID int64, fileName string, r io.Reader) (*models.Upload, error) {
+	if err := os.MkdirAll(s.cfg.TempDir, 0o755); err != nil {
+		return nil, fmt.Errorf("创建暂存目录失败: %w", err)
+	}
+	f, err := os.CreateTemp(s.cfg.TempDir, "multipart-*")
+	if err != nil {
+		return nil, fmt.Errorf("创建暂存文件失败: %w", err)
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	n, err := io.Copy(f, io.LimitReader(r, s.cfg.MaxSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("接收文件失败: %w", err)
+	}
+	if n > s.cfg.MaxSize {
+		return nil, fmt.Errorf("%w: 最大%d字节", ErrUploadTooLarge, s.cfg.MaxSize)
+	}
+
+	mimeType, err := sniffFile(f)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := uploadTypes[mimeType]; !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUploadType, mimeType)
+	}
+
+	id, err := newUploadID()
+	if err != nil {
+		return nil, fmt.Errorf("生成上传ID失败: %w", err)
+	}
+	upload := &models.Upload{
+		ID:       id,
+		UserID:   userID,
+		FileName: filepath.Base(fileName),
+		Size:     n,
+		Received: n,
+		MimeType: mimeType,
+		Status:   models.UploadStatusUploading,
+	}
+	dbCtx, cancel := context.WithTimeout(ctx, writeTimeout)
+	defer cancel()
+	if err := s.uploads.Create(dbCtx, upload); err != nil {
+		return nil, fmt.Errorf("创建上传失败: %w", err)
+	}
+
+	if err := s.finish(ctx, upload, f); err != nil {
+		s.discard(ctx, id)
+		return nil, err
+	}
+	uploadsCompletedTotal.Inc("multipart")
+	uploadBytesTotal.Add(float64(upload.Size))
+	return s.withURL(upload), nil
+}
+
+// finish 计算暂存文件的校验和并写入对象存储，然后将上传标记为完成
+func (s *uploadService) finish(ctx context.Context, upload *models.Upload, f *os.File) error {
+	sum := sha256.New()
+	if _, err := io.Copy(sum, io.NewSectionReader(f, 0, upload.Size)); err != nil {
+		return fmt.Errorf("计算校验和失败: %w", err)
+	}
+	upload.Checksum = hex.EncodeToString(sum.Sum(nil))
+	upload.StorageKey = fmt.Sprintf("videos/%s/%s%s", time.Now().Format("2006/01"), upload.ID, uploadTypes[upload.MimeType])
+
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("读取暂存文件失败: %w", err)
+	}
+	if err := s.files.Put(ctx, upload.StorageKey, f, upload.Size, upload.MimeType); err != nil {
+		return fmt.Errorf("保存文件失败: %w", err)
+	}
+
+	dbCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), writeTimeout)
+	defer cancel()
+	if err := s.uploads.Complete(dbCtx, upload); err != nil {
+		s.files.Delete(dbCtx, upload.StorageKey)
+		return fmt.Errorf("完成上传失败: %w", err)
+	}
+	return nil
+}
+
+// CancelUpload 取消未完成的上传
+func (s *uploadService) CancelUpload(ctx context.Context, id string, userID int64) error {
+	if !s.lock(id) {
+		return ErrUploadBusy
+	}
+	defer s.unlock(id)
+
+	upload, err := s.GetUpload(ctx, id, userID)
+	if err != nil {
+		return err
+	}
+	if upload.Status != models.UploadStatusUploading {
+		return ErrUploadCompleted
+	}
+	return s.discard(ctx, id)
+}
+
+// discard 删除上传记录和暂存文件
+func (s *uploadService) discard(ctx context.Context, id string) error {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), writeTimeout)
+	defer cancel()
+
+	if err := os.Remove(s.stagingPath(id)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("删除暂存文件失败: %w", err)
+	}
+	if err := s.uploads.Delete(ctx, id); err != nil && !errors.Is(err, repository.ErrNotFound) {
+		return fmt.Errorf("删除上传失败: %w", err)
+	}
+	return nil
+}
+
+// PurgeExpired 删除过期的上传
+func (s *uploadService) PurgeExpired(ctx context.Context) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, writeTimeout)
+	defer cancel()
+
+	expired, err := s.uploads.ListExpired(ctx, time.Now().Add(-s.cfg.SessionTTL), 1000)
+	if err != nil {
+		return 0, fmt.Errorf("获取过期上传失败: %w", err)
+	}
+	purged := 0
+	for _, upload := range expired {
+		if !s.lock(upload.ID) {
+			continue
+		}
+		err := s.discard(ctx, upload.ID)
+		s.unlock(upload.ID)
+		if err != nil {
+			return purged, err
+		}
+		purged++
+	}
+	return purged, nil
+}
+
+// sniffFile 根据文件开头的内容识别文件类型
+func sniffFile(f *os.File) (string, error) {
+	head := make([]byte, sniffLen)
+	n, err := f.ReadAt(head, 0)
+	if err != nil && err != io.EOF {
+		return "", fmt.Errorf("读取暂存文件失败: %w", err)
+	}
+	return http.DetectContentType(head[:n]), nil
+}
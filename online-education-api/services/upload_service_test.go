@@ -0,0 +1,99 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"online-education-api/config"
+	"online-education-api/models"
+	"online-education-api/repository/memory"
+	"online-education-api/storage"
+)
+
+// brokenReader 读出data后返回连接中断的错误
+type brokenReader struct {
+	data []byte
+}
+
+func (r *brokenReader) Read(p []byte) (int, error) {
+	if len(r.data) == 0 {
+		return 0, io.ErrUnexpectedEOF
+	}
+	n := copy(p, r.data)
+	r.data = r.data[n:]
+	return n, nil
+}
+
+func TestUploadResumesAfterDisconnect(t *testing.T) {
+	ctx := context.Background()
+	files := storage.NewMemory()
+	tempDir := t.TempDir()
+	svc := NewUploadService(memory.New().Uploads, files, &config.UploadConfig{TempDir: tempDir})
+
+	data := append([]byte("\x00\x00\x00\x18ftypmp42\x00\x00\x00\x00mp42isom"), bytes.Repeat([]byte{1}, 1000)...)
+	upload, err := svc.CreateUpload(ctx, 7, "../../lesson.mp4", int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if upload.FileName != "lesson.mp4" {
+		t.Errorf("FileName = %q, want the base name", upload.FileName)
+	}
+
+	// 连接在600字节后中断，已收到的字节被保留
+	if _, err := svc.WriteChunk(ctx, upload.ID, 7, 0, &brokenReader{data: data[:600]}, nil); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Fatalf("WriteChunk err = %v, want ErrUnexpectedEOF", err)
+	}
+	got, err := svc.GetUpload(ctx, upload.ID, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Received != 600 || got.MimeType != "video/mp4" {
+		t.Fatalf("after disconnect Received = %d, MimeType = %q", got.Received, got.MimeType)
+	}
+
+	done, err := svc.WriteChunk(ctx, upload.ID, 7, 600, bytes.NewReader(data[600:]), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if done.Status != models.UploadStatusCompleted || len(files.Keys()) != 1 {
+		t.Fatalf("upload not completed: %+v, objects %v", done, files.Keys())
+	}
+	if _, err := os.Stat(filepath.Join(tempDir, upload.ID)); !os.IsNotExist(err) {
+		t.Errorf("staging file not removed: %v", err)
+	}
+	if _, err := svc.CompletedUpload(ctx, upload.ID, 8); !errors.Is(err, ErrUploadForbidden) {
+		t.Errorf("CompletedUpload by another user err = %v", err)
+	}
+}
+
+func TestPurgeExpiredUploads(t *testing.T) {
+	ctx := context.Background()
+	tempDir := t.TempDir()
+	svc := NewUploadService(memory.New().Uploads, storage.NewMemory(), &config.UploadConfig{TempDir: tempDir, SessionTTL: time.Millisecond})
+
+	upload, err := svc.CreateUpload(ctx, 1, "a.mp4", 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.WriteChunk(ctx, upload.ID, 1, 0, bytes.NewReader([]byte("\x00\x00\x00\x18ftyp")), nil); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(5 * time.Millisecond)
+
+	n, err := svc.PurgeExpired(ctx)
+	if err != nil || n != 1 {
+		t.Fatalf("PurgeExpired = %d, %v, want 1", n, err)
+	}
+	if _, err := svc.GetUpload(ctx, upload.ID, 1); !errors.Is(err, ErrUploadNotFound) {
+		t.Errorf("GetUpload after purge err = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(tempDir, upload.ID)); !os.IsNotExist(err) {
+		t.Errorf("staging file not removed: %v", err)
+	}
+}
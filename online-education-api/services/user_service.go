@@ -1,44 +1,62 @@
 package services
 
 import (
-	"database/sql"
+	"context"
 	"errors"
 	"fmt"
-	"time"
 
 	"online-education-api/models"
+	"online-education-api/pagination"
+	"online-education-api/repository"
 	"online-education-api/utils"
 	"golang.org/x/crypto/bcrypt"
 )
 
 // UserService 用户服务接口
 type UserService interface {
-	Register(user *models.UserRegisterRequest) (*models.User, error)
-	Login(loginReq *models.UserLoginRequest) (*models.User, string, error)
-	GetUserByID(id int64) (*models.User, error)
-	UpdateUser(id int64, updateReq *models.UserUpdateRequest) (*models.User, error)
-	ChangePassword(userID int64, oldPassword, newPassword string) error
-	GetUserList(page, pageSize int) ([]*models.User, int64, error)
-	CreateUser(user *models.UserCreateRequest) (*models.User, error)
-	DeleteUser(id int64) error
+	Register(ctx context.Context, user *models.UserRegisterRequest) (*models.User, error)
+	Login(ctx context.Context, loginReq *models.UserLoginRequest) (*models.User, string, error)
+	GetUserByID(ctx context.Context, id int64) (*models.User, error)
+	UpdateUser(ctx context.Context, id int64, updateReq *models.UserUpdateRequest) (*models.User, error)
+	ChangePassword(ctx context.Context, userID int64, oldPassword, newPassword string) error
+	GetUserList(ctx context.Context, page pagination.Page) (*pagination.List[*models.User], error)
+	CreateUser(ctx context.Context, user *models.UserCreateRequest) (*models.User, error)
+	DeleteUser(ctx context.Context, id int64) error
+	GetUserByUsername(ctx context.Context, username string) (*models.User, error)
+	ResetPassword(ctx context.Context, id int64, newPassword string) error
+	SetRole(ctx context.Context, id int64, role string) error
+	SetStatus(ctx context.Context, id int64, status int) error
 }
 
+
 // userService 实现UserService接口
 type userService struct {
-	db *sql.DB
+	users repository.UserRepository
 }
 
 // NewUserService 创建用户服务实例
-func NewUserService(db *sql.DB) UserService {
-	return &userService{db: db}
+func NewUserService(users repository.UserRepository) UserService {
+	return &userService{users: users}
 }
 
 // Register 注册新用户
-func (s *userService) Register(user *models.UserRegisterRequest) (*models.User, error) {
+func (s *userService) Register(ctx context.Context, user *models.UserRegisterRequest) (*models.User, error) {
+	ctx, cancel := context.WithTimeout(ctx, writeTimeout)
+	defer cancel()
+
+	created, err := s.create(ctx, user.Username, user.Email, user.Password, "student")
+	if err != nil {
+		return nil, err
+	}
+
+	registrationsTotal.Inc()
+	return created, nil
+}
+
+// create 检查用户名和邮箱后创建用户，返回的用户不包含密码
+func (s *userService) create(ctx context.Context, username, email, password, role string) (*models.User, error) {
 	// 检查用户名是否已存在
-	var exists bool
-	query := "SELECT EXISTS(SELECT 1 FROM users WHERE username = ?)"
-	err := s.db.QueryRow(query, user.Username).Scan(&exists)
+	exists, err := s.users.UsernameExists(ctx, username)
 	if err != nil {
 		return nil, fmt.Errorf("查询用户名失败: %w", err)
 	}
@@ -47,8 +65,7 @@ func (s *userService) Register(user *models.UserRegisterRequest) (*models.User,
 	}
 
 	// 检查邮箱是否已存在
-	query = "SELECT EXISTS(SELECT 1 FROM users WHERE email = ?)"
-	err = s.db.QueryRow(query, user.Email).Scan(&exists)
+	exists, err = s.users.EmailExists(ctx, email)
 	if err != nil {
 		return nil, fmt.Errorf("查询邮箱失败: %w", err)
 	}
@@ -57,155 +74,125 @@ func (s *userService) Register(user *models.UserRegisterRequest) (*models.User,
 	}
 
 	// 加密密码
-	passwordHash, err := bcrypt.GenerateFromPassword([]byte(user.Password), bcrypt.DefaultCost)
+	passwordHash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	if err != nil {
 		return nil, fmt.Errorf("加密密码失败: %w", err)
 	}
 
 	// 创建用户
-	now := time.Now()
-	query = "INSERT INTO users (username, email, password, created_at, updated_at) VALUES (?, ?, ?, ?, ?)"
-	result, err := s.db.Exec(query, user.Username, user.Email, string(passwordHash), now, now)
-	if err != nil {
+	user := &models.User{
+		Username: username,
+		Email:    email,
+		Password: string(passwordHash),
+		Role:     role,
+		Status:   1,
+	}
+	if err := s.users.Create(ctx, user); err != nil {
 		return nil, fmt.Errorf("创建用户失败: %w", err)
 	}
 
-	// 获取新创建用户的ID
-	userID, err := result.LastInsertId()
-	if err != nil {
-		return nil, fmt.Errorf("获取用户ID失败: %w", err)
-	}
-
-	// 返回新创建的用户
-	return &models.User{
-		ID:        userID,
-		Username:  user.Username,
-		Email:     user.Email,
-		CreatedAt: now,
-		UpdatedAt: now,
-	}, nil
+	user.Password = ""
+	return user, nil
 }
 
 // Login 用户登录
-func (s *userService) Login(loginReq *models.UserLoginRequest) (*models.User, string, error) {
-	// 实现登录逻辑
+func (s *userService) Login(ctx context.Context, loginReq *models.UserLoginRequest) (*models.User, string, error) {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
 	// 1. 根据用户名查询用户
-	var user models.User
-	var passwordHash string
-	query := "SELECT id, username, email, password, COALESCE(avatar, ''), COALESCE(bio, ''), created_at, updated_at, role FROM users WHERE username = ?"
-	fmt.Printf("Login attempt for username: %s\n", loginReq.Username)
-	err := s.db.QueryRow(query, loginReq.Username).Scan(
-		&user.ID, &user.Username, &user.Email, &passwordHash, &user.Avatar, &user.Bio,
-		&user.CreatedAt, &user.UpdatedAt, &user.Role,
-	)
+	user, err := s.users.GetByUsername(ctx, loginReq.Username)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			fmt.Printf("User not found: %s\n", loginReq.Username)
+		if errors.Is(err, repository.ErrNotFound) {
+			serviceLog.InfoContext(ctx, "登录失败: 用户不存在")
 			return nil, "", errors.New("用户名或密码错误")
 		}
-		fmt.Printf("Database query error: %v\n", err)
+		serviceLog.ErrorContext(ctx, "登录查询用户失败", "error", err)
 		return nil, "", fmt.Errorf("查询用户失败: %w", err)
 	}
+	passwordHash := user.Password
+	user.Password = ""
 
-	// 2. 暂时移除用户状态检查，因为数据库中没有status字段
-	// if status == 0 {
-	// 	return nil, "", errors.New("账户已被禁用")
-	// }
+	// 2. 检查用户状态
+	if user.Status == 0 {
+		serviceLog.InfoContext(ctx, "登录失败: 账户已被禁用", "user_id", user.ID)
+		return nil, "", errors.New("账户已被禁用")
+	}
 
 	// 3. 验证密码
-	fmt.Printf("User found: %s, ID: %d\n", user.Username, user.ID)
 	err = bcrypt.CompareHashAndPassword([]byte(passwordHash), []byte(loginReq.Password))
 	if err != nil {
-		fmt.Printf("Password verification failed for user: %s\n", user.Username)
+		serviceLog.InfoContext(ctx, "登录失败: 密码错误", "user_id", user.ID)
 		return nil, "", errors.New("用户名或密码错误")
 	}
 
 	// 4. 生成JWT令牌，包含角色信息
-	fmt.Printf("Password verified, generating token for user: %s\n", user.Username)
 	token, err := utils.GenerateToken(user.ID, user.Username, user.Role)
 	if err != nil {
-		fmt.Printf("Token generation failed: %v\n", err)
+		serviceLog.ErrorContext(ctx, "生成令牌失败", "user_id", user.ID, "error", err)
 		return nil, "", fmt.Errorf("生成令牌失败: %w", err)
 	}
-	fmt.Printf("Token generated successfully for user: %s\n", user.Username)
+	serviceLog.DebugContext(ctx, "用户登录成功", "user_id", user.ID)
 
-	// 5. 更新最后登录时间 (暂时注释，因为数据库中没有last_login字段)
-	// updateQuery := "UPDATE users SET last_login = NOW() WHERE id = ?"
-	// _, err = s.db.Exec(updateQuery, user.ID)
-	// if err != nil {
-	// 	// 记录警告但不阻止登录
-	// 	fmt.Printf("更新最后登录时间失败: %v\n", err)
-	// }
+	// 5. 更新最后登录时间
+	if err := s.users.UpdateLastLogin(ctx, user.ID); err != nil {
+		// 记录警告但不阻止登录
+		serviceLog.WarnContext(ctx, "更新最后登录时间失败", "error", err)
+	}
 
-	return &user, token, nil
+	return user, token, nil
 }
 
 // GetUserByID 根据ID获取用户信息
-func (s *userService) GetUserByID(id int64) (*models.User, error) {
-	var user models.User
-	query := "SELECT id, username, email, COALESCE(avatar, ''), COALESCE(bio, ''), created_at, updated_at FROM users WHERE id = ?"
-	err := s.db.QueryRow(query, id).Scan(
-		&user.ID, &user.Username, &user.Email, &user.Avatar, &user.Bio,
-		&user.CreatedAt, &user.UpdatedAt,
-	)
+func (s *userService) GetUserByID(ctx context.Context, id int64) (*models.User, error) {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
+	user, err := s.users.GetByID(ctx, id)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, errors.New("用户不存在")
-		}
-		return nil, fmt.Errorf("查询用户失败: %w", err)
+		return nil, userError(err)
 	}
 
-	return &user, nil
+	user.Password = ""
+	return user, nil
 }
 
-// UpdateUser 更新用户信息
-func (s *userService) UpdateUser(id int64, updateReq *models.UserUpdateRequest) (*models.User, error) {
-	// 实现更新用户信息逻辑
-	query := "UPDATE users SET updated_at = ?"
-	args := []interface{}{time.Now()}
-
-	if updateReq.Nickname != "" {
-		query += ", nickname = ?"
-		args = append(args, updateReq.Nickname)
-	}
-
-	if updateReq.Avatar != "" {
-		query += ", avatar = ?"
-		args = append(args, updateReq.Avatar)
-	}
-
-	if updateReq.Bio != "" {
-		query += ", bio = ?"
-		args = append(args, updateReq.Bio)
+// userError 将数据访问错误转换为用户服务的错误信息
+func userError(err error) error {
+	if errors.Is(err, repository.ErrNotFound) {
+		return errors.New("用户不存在")
 	}
+	return fmt.Errorf("查询用户失败: %w", err)
+}
 
-	query += " WHERE id = ?"
-	args = append(args, id)
+// UpdateUser 更新用户信息
+func (s *userService) UpdateUser(ctx context.Context, id int64, updateReq *models.UserUpdateRequest) (*models.User, error) {
+	ctx, cancel := context.WithTimeout(ctx, writeTimeout)
+	defer cancel()
 
-	_, err := s.db.Exec(query, args...)
-	if err != nil {
+	if err := s.users.UpdateProfile(ctx, id, updateReq); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, errors.New("用户不存在")
+		}
 		return nil, fmt.Errorf("更新用户信息失败: %w", err)
 	}
 
 	// 返回更新后的用户信息
-	return s.GetUserByID(id)
+	return s.GetUserByID(ctx, id)
 }
 
 // ChangePassword 修改密码
-func (s *userService) ChangePassword(userID int64, oldPassword, newPassword string) error {
-	// 实现修改密码逻辑
-	var passwordHash string
-	query := "SELECT password FROM users WHERE id = ?"
-	err := s.db.QueryRow(query, userID).Scan(&passwordHash)
+func (s *userService) ChangePassword(ctx context.Context, userID int64, oldPassword, newPassword string) error {
+	ctx, cancel := context.WithTimeout(ctx, writeTimeout)
+	defer cancel()
+
+	user, err := s.users.GetByID(ctx, userID)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return errors.New("用户不存在")
-		}
-		return fmt.Errorf("查询密码失败: %w", err)
+		return userError(err)
 	}
 
 	// 验证旧密码
-	err = bcrypt.CompareHashAndPassword([]byte(passwordHash), []byte(oldPassword))
+	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(oldPassword))
 	if err != nil {
 		return errors.New("旧密码错误")
 	}
@@ -217,9 +204,7 @@ func (s *userService) ChangePassword(userID int64, oldPassword, newPassword stri
 	}
 
 	// 更新密码
-	query = "UPDATE users SET password = ?, updated_at = ? WHERE id = ?"
-	_, err = s.db.Exec(query, string(newPasswordHash), time.Now(), userID)
-	if err != nil {
+	if err := s.users.UpdatePassword(ctx, userID, string(newPasswordHash)); err != nil {
 		return fmt.Errorf("更新密码失败: %w", err)
 	}
 
@@ -227,121 +212,124 @@ func (s *userService) ChangePassword(userID int64, oldPassword, newPassword stri
 }
 
 // GetUserList 获取用户列表
-func (s *userService) GetUserList(page, pageSize int) ([]*models.User, int64, error) {
-	// 计算偏移量
-	offset := (page - 1) * pageSize
-	
-	// 获取用户列表
-	query := "SELECT id, username, email, COALESCE(avatar, ''), COALESCE(bio, ''), created_at, updated_at, role FROM users LIMIT ? OFFSET ?"
-	rows, err := s.db.Query(query, pageSize, offset)
+func (s *userService) GetUserList(ctx context.Context, page pagination.Page) (*pagination.List[*models.User], error) {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
+	// 获取用户列表，按ID排序，游标只使用ID
+	users, err := s.users.List(ctx, page)
 	if err != nil {
-		return nil, 0, fmt.Errorf("查询用户列表失败: %w", err)
-	}
-	defer rows.Close()
-	
-	var users []*models.User
-	for rows.Next() {
-		var user models.User
-		err := rows.Scan(
-			&user.ID, &user.Username, &user.Email, &user.Avatar,
-			&user.Bio, &user.CreatedAt, &user.UpdatedAt, &user.Role,
-		)
-		if err != nil {
-			return nil, 0, fmt.Errorf("扫描用户数据失败: %w", err)
-		}
-		users = append(users, &user)
+		return nil, fmt.Errorf("查询用户列表失败: %w", err)
 	}
-	
-	// 检查遍历过程中是否有错误
-	if err = rows.Err(); err != nil {
-		return nil, 0, fmt.Errorf("遍历用户数据失败: %w", err)
+	for _, user := range users {
+		user.Password = ""
 	}
-	
+	list := pagination.NewList(users, page, func(u *models.User) pagination.Cursor {
+		return pagination.Cursor{ID: u.ID}
+	})
+
 	// 获取用户总数
-	var total int64
-	countQuery := "SELECT COUNT(*) FROM users"
-	err = s.db.QueryRow(countQuery).Scan(&total)
+	err = list.SetTotal(page, func() (int, error) {
+		total, err := s.users.Count(ctx)
+		return int(total), err
+	})
 	if err != nil {
-		return nil, 0, fmt.Errorf("查询用户总数失败: %w", err)
+		return nil, fmt.Errorf("查询用户总数失败: %w", err)
 	}
-	
-	return users, total, nil
+
+	return list, nil
 }
 
 // CreateUser 创建新用户
-func (s *userService) CreateUser(user *models.UserCreateRequest) (*models.User, error) {
-	// 检查用户名是否已存在
-	var exists bool
-	query := "SELECT EXISTS(SELECT 1 FROM users WHERE username = ?)"
-	err := s.db.QueryRow(query, user.Username).Scan(&exists)
-	if err != nil {
-		return nil, fmt.Errorf("查询用户名失败: %w", err)
-	}
-	if exists {
-		return nil, errors.New("用户名已存在")
-	}
-
-	// 检查邮箱是否已存在
-	query = "SELECT EXISTS(SELECT 1 FROM users WHERE email = ?)"
-	err = s.db.QueryRow(query, user.Email).Scan(&exists)
-	if err != nil {
-		return nil, fmt.Errorf("查询邮箱失败: %w", err)
-	}
-	if exists {
-		return nil, errors.New("邮箱已被注册")
-	}
-
-	// 加密密码
-	passwordHash, err := bcrypt.GenerateFromPassword([]byte(user.Password), bcrypt.DefaultCost)
-	if err != nil {
-		return nil, fmt.Errorf("加密密码失败: %w", err)
-	}
+func (s *userService) CreateUser(ctx context.Context, user *models.UserCreateRequest) (*models.User, error) {
+	ctx, cancel := context.WithTimeout(ctx, writeTimeout)
+	defer cancel()
 
 	// 设置默认角色
-	role := "user"
+	role := "student"
 	if user.Role != "" {
 		role = user.Role
 	}
 
-	// 创建用户
-	now := time.Now()
-	query = "INSERT INTO users (username, email, password, role, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?)"
-	result, err := s.db.Exec(query, user.Username, user.Email, string(passwordHash), role, now, now)
-	if err != nil {
-		return nil, fmt.Errorf("创建用户失败: %w", err)
+	return s.create(ctx, user.Username, user.Email, user.Password, role)
+}
+
+// DeleteUser 删除用户
+func (s *userService) DeleteUser(ctx context.Context, id int64) error {
+	ctx, cancel := context.WithTimeout(ctx, writeTimeout)
+	defer cancel()
+
+	if err := s.users.Delete(ctx, id); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return errors.New("用户不存在")
+		}
+		return fmt.Errorf("删除用户失败: %w", err)
 	}
 
-	// 获取新创建用户的ID
-	userID, err := result.LastInsertId()
+	return nil
+}
+
+// GetUserByUsername 根据用户名获取用户信息
+func (s *userService) GetUserByUsername(ctx context.Context, username string) (*models.User, error) {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
+	user, err := s.users.GetByUsername(ctx, username)
 	if err != nil {
-		return nil, fmt.Errorf("获取用户ID失败: %w", err)
+		return nil, userError(err)
 	}
 
-	// 返回新创建的用户
-	return &models.User{
-		ID:        userID,
-		Username:  user.Username,
-		Email:     user.Email,
-		Role:      role,
-		CreatedAt: now,
-		UpdatedAt: now,
-	}, nil
+	user.Password = ""
+	return user, nil
 }
 
-// DeleteUser 删除用户
-func (s *userService) DeleteUser(id int64) error {
-	// 检查用户是否存在
-	_, err := s.GetUserByID(id)
+// ResetPassword 管理员重置密码，不校验旧密码
+func (s *userService) ResetPassword(ctx context.Context, id int64, newPassword string) error {
+	ctx, cancel := context.WithTimeout(ctx, writeTimeout)
+	defer cancel()
+
+	passwordHash, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
 	if err != nil {
-		return err
+		return fmt.Errorf("加密密码失败: %w", err)
 	}
 
-	// 删除用户
-	query := "DELETE FROM users WHERE id = ?"
-	_, err = s.db.Exec(query, id)
-	if err != nil {
-		return fmt.Errorf("删除用户失败: %w", err)
+	return userUpdateError(s.users.UpdatePassword(ctx, id, string(passwordHash)))
+}
+
+// SetRole 设置用户角色
+func (s *userService) SetRole(ctx context.Context, id int64, role string) error {
+	ctx, cancel := context.WithTimeout(ctx, writeTimeout)
+	defer cancel()
+
+	switch role {
+	case "admin", "teacher", "student":
+	default:
+		return fmt.Errorf("无效的角色: %s", role)
 	}
 
-	return nil
-}
\ No newline at end of file
+	return userUpdateError(s.users.UpdateRole(ctx, id, role))
+}
+
+// SetStatus 设置用户状态，0为禁用，禁用后无法登录
+func (s *userService) SetStatus(ctx context.Context, id int64, status int) error {
+	ctx, cancel := context.WithTimeout(ctx, writeTimeout)
+	defer cancel()
+
+	if status != 0 && status != 1 {
+		return fmt.Errorf("无效的用户状态: %d", status)
+	}
+
+	return userUpdateError(s.users.UpdateStatus(ctx, id, status))
+}
+
+// userUpdateError 将更新用户的数据访问错误转换为用户服务的错误信息
+func userUpdateError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, repository.ErrNotFound):
+		return errors.New("用户不存在")
+	default:
+		return fmt.Errorf("更新用户失败: %w", err)
+	}
+}
@@ -0,0 +1,72 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"online-education-api/config"
+	"online-education-api/models"
+	"online-education-api/repository/memory"
+	"online-education-api/storage"
+)
+
+func TestRegisterAndLogin(t *testing.T) {
+	ctx := context.Background()
+	svc := New(memory.New(), storage.NewMemory(), &config.UploadConfig{})
+
+	user, err := svc.User.Register(ctx, &models.UserRegisterRequest{Username: "alice", Email: "alice@example.com", Password: "secret1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Role != "student" || user.Password != "" {
+		t.Fatalf("unexpected user %+v", user)
+	}
+
+	if _, err := svc.User.Register(ctx, &models.UserRegisterRequest{Username: "alice", Email: "other@example.com", Password: "secret1"}); err == nil || err.Error() != "用户名已存在" {
+		t.Fatalf("expected 用户名已存在, got %v", err)
+	}
+	if _, err := svc.User.Register(ctx, &models.UserRegisterRequest{Username: "bob", Email: "alice@example.com", Password: "secret1"}); err == nil || err.Error() != "邮箱已被注册" {
+		t.Fatalf("expected 邮箱已被注册, got %v", err)
+	}
+
+	loggedIn, token, err := svc.User.Login(ctx, &models.UserLoginRequest{Username: "alice", Password: "secret1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loggedIn.ID != user.ID || token == "" || loggedIn.Password != "" {
+		t.Fatalf("unexpected login result %+v %q", loggedIn, token)
+	}
+	if _, _, err := svc.User.Login(ctx, &models.UserLoginRequest{Username: "alice", Password: "wrong"}); err == nil || err.Error() != "用户名或密码错误" {
+		t.Fatalf("expected 用户名或密码错误, got %v", err)
+	}
+}
+
+func TestBannedUserCannotLogin(t *testing.T) {
+	ctx := context.Background()
+	svc := New(memory.New(), storage.NewMemory(), &config.UploadConfig{})
+
+	user, err := svc.User.CreateUser(ctx, &models.UserCreateRequest{Username: "teacher1", Email: "t1@example.com", Password: "secret1", Role: "teacher"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.User.SetStatus(ctx, user.ID, 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := svc.User.Login(ctx, &models.UserLoginRequest{Username: "teacher1", Password: "secret1"}); err == nil || err.Error() != "账户已被禁用" {
+		t.Fatalf("expected 账户已被禁用, got %v", err)
+	}
+
+	if err := svc.User.SetStatus(ctx, user.ID, 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.User.ResetPassword(ctx, user.ID, "newsecret"); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := svc.User.Login(ctx, &models.UserLoginRequest{Username: "teacher1", Password: "newsecret"}); err != nil {
+		t.Fatalf("expected login with the reset password, got %v", err)
+	}
+
+	if err := svc.User.SetRole(ctx, 404, "admin"); err == nil || err.Error() != "用户不存在" {
+		t.Fatalf("expected 用户不存在, got %v", err)
+	}
+}
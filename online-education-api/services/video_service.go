@@ -1,275 +1,430 @@
 package services
 
 import (
-	"database/sql"
+	"context"
+	"errors"
 	"fmt"
-	"log"
+	"slices"
+	"strings"
 
 	"online-education-api/models"
+	"online-education-api/pagination"
+	"online-education-api/repository"
+)
+
+// 视频服务返回的错误
+var (
+	ErrVideoNotFound  = errors.New("视频不存在")
+	ErrVideoForbidden = errors.New("无权限操作该视频")
+	// ErrUploadUsed 上传的文件已用于创建其他视频
+	ErrUploadUsed = errors.New("该上传已用于创建其他视频")
+
+	ErrVideoCategoryNotFound    = errors.New("视频分类不存在")
+	ErrVideoCategoryName        = errors.New("分类名称不能为空")
+	ErrVideoCategoryParent      = errors.New("上级分类不存在，或为分类本身及其下级分类")
+	ErrVideoCategoryHasChildren = errors.New("该分类下有子分类，无法删除")
+	ErrVideoCategoryInUse       = errors.New("该分类下有视频，需要指定视频移动到的分类")
+	ErrVideoCategoryReassign    = errors.New("视频移动到的分类不存在")
 )
 
 // VideoService 视频服务接口
 type VideoService interface {
-	CreateVideo(video *models.Video) error
-	GetVideoList(page, pageSize int, categoryID int) ([]models.Video, int, error)
-	GetVideoByID(id int) (*models.Video, error)
-	UpdateVideo(video *models.Video) error
-	DeleteVideo(id int) error
-	GetVideoCategories() ([]models.VideoCategory, error)
+	// CreateVideo 创建作者为video.AuthorID的视频，设置了UploadID时使用作者已完成的上传作为视频文件，未设置可见性时为public
+	CreateVideo(ctx context.Context, video *models.Video) error
+	// GetVideoList 获取视频列表，可见性的权限由调用方检查
+	GetVideoList(ctx context.Context, page pagination.Page, q models.VideoListQuery) (*pagination.List[models.Video], error)
+	// GetVideoByID 获取视频详情，观看次数由播放事件统计，私有视频对作者和管理员以外的用户返回ErrVideoNotFound
+	GetVideoByID(ctx context.Context, id int, userID int64, isAdmin bool) (*models.Video, error)
+	// UpdateVideo 更新视频，只有作者和管理员可以操作，video.Visibility为空时保持不变
+	UpdateVideo(ctx context.Context, video *models.Video, userID int64, isAdmin bool) error
+	// DeleteVideo 删除视频，只有作者和管理员可以操作
+	DeleteVideo(ctx context.Context, id int, userID int64, isAdmin bool) error
+	// GetVideoCategories 按排序获取全部视频分类，包含公开视频的数量
+	GetVideoCategories(ctx context.Context) ([]models.VideoCategory, error)
+	// GetVideoCategoryTree 获取视频分类树，同级分类按sort_order排序
+	GetVideoCategoryTree(ctx context.Context) ([]*models.VideoCategory, error)
+	// GetVideoCategory 获取视频分类及其全部下级分类
+	GetVideoCategory(ctx context.Context, id int) (*models.VideoCategory, error)
+	// CreateVideoCategory 创建视频分类，上级分类必须存在；权限由调用方检查
+	CreateVideoCategory(ctx context.Context, category *models.VideoCategory) error
+	// UpdateVideoCategory 更新视频分类，上级分类不能是分类本身或其下级分类；权限由调用方检查
+	UpdateVideoCategory(ctx context.Context, category *models.VideoCategory) error
+	// DeleteVideoCategory 删除没有下级分类的视频分类。分类下有视频时，reassignTo为nil返回ErrVideoCategoryInUse，
+	// 否则将视频移到reassignTo(0为未分类)；权限由调用方检查
+	DeleteVideoCategory(ctx context.Context, id int, reassignTo *int) error
 }
 
 // videoService 视频服务实现
- type videoService struct {
-	db *sql.DB
+type videoService struct {
+	videos    repository.VideoRepository
+	subtitles repository.SubtitleRepository
+	uploads   UploadService
 }
 
 // NewVideoService 创建视频服务实例
-func NewVideoService(db *sql.DB) VideoService {
-	return &videoService{
-	db: db,
-	}
+func NewVideoService(videos repository.VideoRepository, subtitles repository.SubtitleRepository, uploads UploadService) VideoService {
+	return &videoService{videos: videos, subtitles: subtitles, uploads: uploads}
 }
 
 // CreateVideo 创建视频
-func (s *videoService) CreateVideo(video *models.Video) error {
-	query := `
-	INSERT INTO videos (
-		title, description, video_url, cover_image_url, duration, 
-		author_id, category_id, is_public
-	) VALUES (?, ?, ?, ?, ?, ?, ?, ?)
-	`
-
-	result, err := s.db.Exec(
-		query,
-		video.Title,
-		video.Description,
-		video.VideoURL,
-		video.CoverImageURL,
-		video.Duration,
-		video.AuthorID,
-		video.CategoryID,
-		video.IsPublic,
-	)
+func (s *videoService) CreateVideo(ctx context.Context, video *models.Video) error {
+	ctx, cancel := context.WithTimeout(ctx, writeTimeout)
+	defer cancel()
 
-	if err != nil {
-		return fmt.Errorf("创建视频失败: %w", err)
+	if video.UploadID != "" {
+		upload, err := s.uploads.CompletedUpload(ctx, video.UploadID, video.AuthorID)
+		if err != nil {
+			return err
+		}
+		video.VideoURL = upload.URL
 	}
-
-	id, err := result.LastInsertId()
-	if err != nil {
-		return fmt.Errorf("获取视频ID失败: %w", err)
+	if video.Visibility == "" {
+		video.Visibility = models.VideoVisibilityPublic
 	}
 
-	video.ID = int(id)
+	if err := s.videos.Create(ctx, video); err != nil {
+		if errors.Is(err, repository.ErrDuplicate) {
+			return ErrUploadUsed
+		}
+		return fmt.Errorf("创建视频失败: %w", err)
+	}
 	return nil
 }
 
 // GetVideoList 获取视频列表
-func (s *videoService) GetVideoList(page, pageSize int, categoryID int) ([]models.Video, int, error) {
-	// 计算偏移量
-	offset := (page - 1) * pageSize
-
-	// 构建查询条件
-	var query string
-	var args []interface{}
-	log.Println("获取视频列表，分类ID:", categoryID)
-
-	if categoryID > 0 {
-		query = `
-		SELECT id, title, description, cover_image_url, duration, 
-			created_at
-		FROM videos
-		WHERE category_id = ? 
-		ORDER BY created_at DESC
-		LIMIT ? OFFSET ?
-		`
-		args = []interface{}{categoryID, pageSize, offset}
-	} else {
-		query = `
-		SELECT id, title, description, cover_image_url, duration, 
-			created_at
-		FROM videos
-		ORDER BY created_at DESC
-		LIMIT ? OFFSET ?
-		`
-		args = []interface{}{pageSize, offset}
+func (s *videoService) GetVideoList(ctx context.Context, page pagination.Page, q models.VideoListQuery) (*pagination.List[models.Video], error) {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
+	filter := repository.VideoFilter{AuthorID: q.AuthorID, Visibility: q.Visibility}
+	if q.CategoryID > 0 {
+		filter.CategoryIDs = []int{q.CategoryID}
+		if q.IncludeSubcategories {
+			categories, err := s.videos.Categories(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("获取视频分类失败: %w", err)
+			}
+			_, nodes := buildVideoCategoryTree(categories)
+			filter.CategoryIDs = append(filter.CategoryIDs, descendantVideoCategoryIDs(nodes[q.CategoryID])...)
+		}
 	}
 
 	// 查询视频列表
-	log.Printf("执行SQL查询: %s", query)
-	log.Printf("查询参数: %v", args)
-	rows, err := s.db.Query(query, args...)
+	videos, err := s.videos.List(ctx, filter, page)
 	if err != nil {
-		log.Printf("SQL查询错误: %v", err)
-		return nil, 0, fmt.Errorf("获取视频列表失败: %w", err)
-	}
-	defer rows.Close()
-
-	var videos []models.Video
-	for rows.Next() {
-		var video models.Video
-		err := rows.Scan(
-			&video.ID,
-			&video.Title,
-			&video.Description,
-			&video.CoverImageURL,
-			&video.Duration,
-			&video.CreatedAt,
-		)
-		if err != nil {
-			log.Printf("扫描视频数据失败: %v", err)
-			continue
-		}
-		videos = append(videos, video)
+		return nil, fmt.Errorf("获取视频列表失败: %w", err)
 	}
+	list := pagination.NewList(videos, page, videoCursor)
 
 	// 获取总记录数
-	var total int
-	if categoryID > 0 {
-		err = s.db.QueryRow("SELECT COUNT(*) FROM videos WHERE category_id = ?", categoryID).Scan(&total)
-	} else {
-		err = s.db.QueryRow("SELECT COUNT(*) FROM videos").Scan(&total)
+	if err := list.SetTotal(page, func() (int, error) { return s.videos.Count(ctx, filter) }); err != nil {
+		return nil, fmt.Errorf("获取视频总数失败: %w", err)
 	}
 
-	if err != nil {
-		return videos, 0, fmt.Errorf("获取视频总数失败: %w", err)
-	}
+	return list, nil
+}
 
-	return videos, total, nil
+// canViewVideo 判断用户能否观看视频，私有视频只有作者和管理员可以观看
+func canViewVideo(video *models.Video, userID int64, isAdmin bool) bool {
+	return video.Visibility != models.VideoVisibilityPrivate || isAdmin || userID != 0 && userID == video.AuthorID
 }
 
-// GetVideoByID 获取视频详情
-func (s *videoService) GetVideoByID(id int) (*models.Video, error) {
-	query := `
-	SELECT id, title, description, video_url, cover_image_url, duration, 
-			author_id, category_id, view_count, like_count, favorite_count, 
-			is_public, created_at, updated_at
-	FROM videos
-	WHERE id = ?
-	`
-
-	var video models.Video
-	err := s.db.QueryRow(query, id).Scan(
-		&video.ID,
-		&video.Title,
-		&video.Description,
-		&video.VideoURL,
-		&video.CoverImageURL,
-		&video.Duration,
-		&video.AuthorID,
-		&video.CategoryID,
-		&video.ViewCount,
-		&video.LikeCount,
-		&video.FavoriteCount,
-		&video.IsPublic,
-		&video.CreatedAt,
-		&video.UpdatedAt,
-	)
+// videoCursor 视频列表按(created_at, id)倒序分页的游标
+func videoCursor(v models.Video) pagination.Cursor {
+	return pagination.Cursor{CreatedAt: v.CreatedAt, ID: int64(v.ID)}
+}
 
+// getVideo 获取视频，不存在时返回ErrVideoNotFound
+func (s *videoService) getVideo(ctx context.Context, id int) (*models.Video, error) {
+	video, err := s.videos.GetByID(ctx, id)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("视频不存在")
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrVideoNotFound
 		}
 		return nil, fmt.Errorf("获取视频详情失败: %w", err)
 	}
+	return video, nil
+}
+
+// GetVideoByID 获取视频详情
+func (s *videoService) GetVideoByID(ctx context.Context, id int, userID int64, isAdmin bool) (*models.Video, error) {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
 
-	// 更新观看次数
-	_, err = s.db.Exec("UPDATE videos SET view_count = view_count + 1 WHERE id = ?", id)
+	video, err := s.getVideo(ctx, id)
 	if err != nil {
-		log.Printf("更新视频观看次数失败: %v", err)
+		return nil, err
+	}
+	// 私有视频对其他用户视为不存在，不暴露视频是否存在
+	if !canViewVideo(video, userID, isAdmin) {
+		return nil, ErrVideoNotFound
 	}
 
-	return &video, nil
+	if video.Subtitles, err = s.subtitles.List(ctx, repository.SubtitleOwner{VideoID: id}); err != nil {
+		return nil, fmt.Errorf("获取视频字幕失败: %w", err)
+	}
+	for _, sub := range video.Subtitles {
+		setSubtitleURL(sub)
+	}
+	return video, nil
+}
+
+// ownedVideo 获取视频并检查当前用户是否为作者或管理员
+func (s *videoService) ownedVideo(ctx context.Context, id int, userID int64, isAdmin bool) (*models.Video, error) {
+	video, err := s.getVideo(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if video.AuthorID != userID && !isAdmin {
+		return nil, ErrVideoForbidden
+	}
+	return video, nil
 }
 
 // UpdateVideo 更新视频
-func (s *videoService) UpdateVideo(video *models.Video) error {
-	query := `
-	UPDATE videos SET
-		title = ?, description = ?, cover_image_url = ?, 
-		category_id = ?, is_public = ?
-	WHERE id = ? AND author_id = ?
-	`
-
-	result, err := s.db.Exec(
-		query,
-		video.Title,
-		video.Description,
-		video.CoverImageURL,
-		video.CategoryID,
-		video.IsPublic,
-		video.ID,
-		video.AuthorID,
-	)
+func (s *videoService) UpdateVideo(ctx context.Context, video *models.Video, userID int64, isAdmin bool) error {
+	ctx, cancel := context.WithTimeout(ctx, writeTimeout)
+	defer cancel()
 
+	existing, err := s.ownedVideo(ctx, video.ID, userID, isAdmin)
 	if err != nil {
+		return err
+	}
+	video.AuthorID = existing.AuthorID
+	if video.Visibility == "" {
+		video.Visibility = existing.Visibility
+	}
+
+	if err := s.videos.Update(ctx, video); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrVideoNotFound
+		}
 		return fmt.Errorf("更新视频失败: %w", err)
 	}
 
-	affected, err := result.RowsAffected()
+	return nil
+}
+
+// DeleteVideo 删除视频
+func (s *videoService) DeleteVideo(ctx context.Context, id int, userID int64, isAdmin bool) error {
+	ctx, cancel := context.WithTimeout(ctx, writeTimeout)
+	defer cancel()
+
+	if _, err := s.ownedVideo(ctx, id, userID, isAdmin); err != nil {
+		return err
+	}
+	if err := s.videos.Delete(ctx, id); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrVideoNotFound
+		}
+		return fmt.Errorf("删除视频失败: %w", err)
+	}
+
+	return nil
+}
+
+// GetVideoCategories 获取视频分类
+func (s *videoService) GetVideoCategories(ctx context.Context) ([]models.VideoCategory, error) {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
+	categories, err := s.videos.Categories(ctx)
 	if err != nil {
-		return fmt.Errorf("获取受影响行数失败: %w", err)
+		return nil, fmt.Errorf("获取视频分类失败: %w", err)
 	}
 
-	if affected == 0 {
-		return fmt.Errorf("视频不存在或无权限更新")
+	// 列表中的分类不包含下级分类，只设置包含下级分类的视频数量
+	_, nodes := buildVideoCategoryTree(categories)
+	for i := range categories {
+		categories[i].TotalVideoCount = nodes[categories[i].ID].TotalVideoCount
 	}
+	return categories, nil
+}
 
-	return nil
+// GetVideoCategoryTree 获取视频分类树
+func (s *videoService) GetVideoCategoryTree(ctx context.Context) ([]*models.VideoCategory, error) {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
+	categories, err := s.videos.Categories(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("获取视频分类失败: %w", err)
+	}
+	roots, _ := buildVideoCategoryTree(categories)
+	return roots, nil
 }
 
-// DeleteVideo 删除视频
-func (s *videoService) DeleteVideo(id int) error {
-	query := `
-	DELETE FROM videos WHERE id = ?
-	`
+// GetVideoCategory 根据ID获取视频分类
+func (s *videoService) GetVideoCategory(ctx context.Context, id int) (*models.VideoCategory, error) {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
 
-	result, err := s.db.Exec(query, id)
+	categories, err := s.videos.Categories(ctx)
 	if err != nil {
-		return fmt.Errorf("删除视频失败: %w", err)
+		return nil, fmt.Errorf("获取视频分类失败: %w", err)
+	}
+	_, nodes := buildVideoCategoryTree(categories)
+	category, ok := nodes[id]
+	if !ok {
+		return nil, ErrVideoCategoryNotFound
+	}
+	return category, nil
+}
+
+// buildVideoCategoryTree 将按排序返回的分类组装为分类树，返回一级分类和按ID索引的全部节点，并计算包含下级分类的视频数量
+// 与课程分类一致，上级分类不存在的分类不会出现在树中；parent_id成环的分类无法从一级分类到达，不会导致死循环
+func buildVideoCategoryTree(categories []models.VideoCategory) ([]*models.VideoCategory, map[int]*models.VideoCategory) {
+	nodes := make(map[int]*models.VideoCategory, len(categories))
+	for _, category := range categories {
+		node := category
+		node.TotalVideoCount = node.VideoCount
+		nodes[category.ID] = &node
 	}
 
-	affected, err := result.RowsAffected()
+	var roots []*models.VideoCategory
+	for _, category := range categories {
+		node := nodes[category.ID]
+		if category.ParentID == nil {
+			roots = append(roots, node)
+			continue
+		}
+		if parent, ok := nodes[*category.ParentID]; ok {
+			parent.Children = append(parent.Children, node)
+		}
+	}
+
+	var total func(node *models.VideoCategory) int
+	total = func(node *models.VideoCategory) int {
+		node.TotalVideoCount = node.VideoCount
+		for _, child := range node.Children {
+			node.TotalVideoCount += total(child)
+		}
+		return node.TotalVideoCount
+	}
+	for _, root := range roots {
+		total(root)
+	}
+	return roots, nodes
+}
+
+// descendantVideoCategoryIDs 返回分类的全部下级分类ID，不包含分类本身；node为nil时返回nil
+func descendantVideoCategoryIDs(node *models.VideoCategory) []int {
+	if node == nil {
+		return nil
+	}
+	// parent_id成环时分类会重复出现，记录已访问的分类避免死循环
+	visited := map[int]bool{node.ID: true}
+	var ids []int
+	queue := slices.Clone(node.Children)
+	for len(queue) > 0 {
+		child := queue[0]
+		queue = queue[1:]
+		if visited[child.ID] {
+			continue
+		}
+		visited[child.ID] = true
+		ids = append(ids, child.ID)
+		queue = append(queue, child.Children...)
+	}
+	return ids
+}
+
+// checkVideoCategoryParent 检查上级分类存在，且不是分类本身或其下级分类
+func (s *videoService) checkVideoCategoryParent(ctx context.Context, category *models.VideoCategory) error {
+	if category.ParentID == nil {
+		return nil
+	}
+	categories, err := s.videos.Categories(ctx)
 	if err != nil {
-		return fmt.Errorf("获取受影响行数失败: %w", err)
+		return fmt.Errorf("获取视频分类失败: %w", err)
+	}
+	_, nodes := buildVideoCategoryTree(categories)
+	if _, ok := nodes[*category.ParentID]; !ok || *category.ParentID == category.ID {
+		return ErrVideoCategoryParent
 	}
+	if slices.Contains(descendantVideoCategoryIDs(nodes[category.ID]), *category.ParentID) {
+		return ErrVideoCategoryParent
+	}
+	return nil
+}
+
+// CreateVideoCategory 创建视频分类
+func (s *videoService) CreateVideoCategory(ctx context.Context, category *models.VideoCategory) error {
+	ctx, cancel := context.WithTimeout(ctx, writeTimeout)
+	defer cancel()
 
-	if affected == 0 {
-		return fmt.Errorf("视频不存在")
+	if category.Name = strings.TrimSpace(category.Name); category.Name == "" {
+		return ErrVideoCategoryName
 	}
+	if err := s.checkVideoCategoryParent(ctx, category); err != nil {
+		return err
+	}
+	if err := s.videos.CreateCategory(ctx, category); err != nil {
+		return fmt.Errorf("创建视频分类失败: %w", err)
+	}
+	return nil
+}
+
+// UpdateVideoCategory 更新视频分类
+func (s *videoService) UpdateVideoCategory(ctx context.Context, category *models.VideoCategory) error {
+	ctx, cancel := context.WithTimeout(ctx, writeTimeout)
+	defer cancel()
 
+	if category.Name = strings.TrimSpace(category.Name); category.Name == "" {
+		return ErrVideoCategoryName
+	}
+	if err := s.checkVideoCategoryParent(ctx, category); err != nil {
+		return err
+	}
+	if err := s.videos.UpdateCategory(ctx, category); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrVideoCategoryNotFound
+		}
+		return fmt.Errorf("更新视频分类失败: %w", err)
+	}
 	return nil
 }
 
-// GetVideoCategories 获取视频分类
-func (s *videoService) GetVideoCategories() ([]models.VideoCategory, error) {
-	query := `
-	SELECT id, name, description, created_at
-	FROM video_categories
-	ORDER BY created_at ASC
-	`
-
-	rows, err := s.db.Query(query)
+// DeleteVideoCategory 删除视频分类
+func (s *videoService) DeleteVideoCategory(ctx context.Context, id int, reassignTo *int) error {
+	ctx, cancel := context.WithTimeout(ctx, writeTimeout)
+	defer cancel()
+
+	categories, err := s.videos.Categories(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("获取视频分类失败: %w", err)
+		return fmt.Errorf("获取视频分类失败: %w", err)
+	}
+	_, nodes := buildVideoCategoryTree(categories)
+	category, ok := nodes[id]
+	if !ok {
+		return ErrVideoCategoryNotFound
+	}
+	if len(category.Children) > 0 {
+		return ErrVideoCategoryHasChildren
 	}
-	defer rows.Close()
-
-	var categories []models.VideoCategory
-	for rows.Next() {
-		var category models.VideoCategory
-		err := rows.Scan(
-			&category.ID,
-			&category.Name,
-			&category.Description,
-			&category.CreatedAt,
-		)
+
+	target := 0
+	if reassignTo != nil {
+		target = *reassignTo
+		if _, ok := nodes[target]; target == id || target != 0 && !ok {
+			return ErrVideoCategoryReassign
+		}
+	} else {
+		// 分类中的视频数量包含非公开的视频
+		count, err := s.videos.Count(ctx, repository.VideoFilter{CategoryIDs: []int{id}})
 		if err != nil {
-			log.Printf("扫描分类数据失败: %v", err)
-			continue
+			return fmt.Errorf("获取分类视频数量失败: %w", err)
+		}
+		if count > 0 {
+			return fmt.Errorf("%w (%d个视频)", ErrVideoCategoryInUse, count)
 		}
-		categories = append(categories, category)
 	}
 
-	return categories, nil
-}
\ No newline at end of file
+	if err := s.videos.DeleteCategory(ctx, id, target); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrVideoCategoryNotFound
+		}
+		return fmt.Errorf("删除视频分类失败: %w", err)
+	}
+	return nil
+}
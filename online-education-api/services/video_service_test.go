@@ -0,0 +1,36 @@
+package services
+
+import (
+	"slices"
+	"testing"
+
+	"online-education-api/models"
+)
+
+func TestVideoCategoryTreeCounts(t *testing.T) {
+	parent := func(id int) *int { return &id }
+	// 1 > 2 > 3，4和5的parent_id成环，6的上级分类不存在
+	categories := []models.VideoCategory{
+		{ID: 1, VideoCount: 1},
+		{ID: 2, ParentID: parent(1), VideoCount: 2},
+		{ID: 3, ParentID: parent(2), VideoCount: 4},
+		{ID: 4, ParentID: parent(5), VideoCount: 8},
+		{ID: 5, ParentID: parent(4)},
+		{ID: 6, ParentID: parent(404), VideoCount: 16},
+	}
+
+	roots, nodes := buildVideoCategoryTree(categories)
+	if len(roots) != 1 || roots[0].TotalVideoCount != 7 || nodes[2].TotalVideoCount != 6 || nodes[6].TotalVideoCount != 16 {
+		t.Fatalf("unexpected tree %+v", roots)
+	}
+	if ids := descendantVideoCategoryIDs(nodes[1]); !slices.Equal(ids, []int{2, 3}) {
+		t.Errorf("descendants of 1 = %v, want [2 3]", ids)
+	}
+	// 成环的分类不会导致死循环
+	if ids := descendantVideoCategoryIDs(nodes[4]); !slices.Equal(ids, []int{5}) {
+		t.Errorf("descendants of 4 = %v, want [5]", ids)
+	}
+	if ids := descendantVideoCategoryIDs(nodes[404]); ids != nil {
+		t.Errorf("descendants of missing category = %v, want nil", ids)
+	}
+}
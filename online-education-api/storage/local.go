@@ -0,0 +1,125 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Local 保存在本地目录的对象存储
+type Local struct {
+	dir       string
+	publicURL string
+}
+
+// NewLocal 创建本地存储，目录在第一次写入时创建；publicURL为客户端访问文件的地址前缀，如"/media"
+func NewLocal(dir, publicURL string) *Local {
+	return &Local{dir: dir, publicURL: publicURL}
+}
+
+// path 返回对象在本地目录中的路径
+func (l *Local) path(key string) (string, error) {
+	if err := checkKey(key); err != nil {
+		return "", err
+	}
+	return filepath.Join(l.dir, filepath.FromSlash(key)), nil
+}
+
+// Put 先写入同目录下的临时文件再重命名，读取方不会看到写了一半的文件
+func (l *Local) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	path, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, contextReader{ctx, r}); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func (l *Local) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	path, err := l.path(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return f, err
+}
+
+func (l *Local) Delete(ctx context.Context, key string) error {
+	path, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (l *Local) URL(key string) string {
+	return joinURL(l.publicURL, key)
+}
+
+// PublicPath 返回需要由API服务器提供文件下载的路径前缀，publicURL是完整地址(由其他服务器提供)时返回空字符串
+func (l *Local) PublicPath() string {
+	if !strings.HasPrefix(l.publicURL, "/") {
+		return ""
+	}
+	return strings.TrimSuffix(l.publicURL, "/") + "/"
+}
+
+// Handler 提供存储目录中文件的下载，路径为去掉 PublicPath 后的对象键，不列出目录内容
+func (l *Local) Handler() http.Handler {
+	files := http.FileServer(http.Dir(l.dir))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := strings.TrimPrefix(r.URL.Path, "/")
+		if checkKey(key) != nil || strings.HasPrefix(filepath.Base(key), ".") {
+			http.NotFound(w, r)
+			return
+		}
+		if info, err := os.Stat(filepath.Join(l.dir, filepath.FromSlash(key))); err != nil || info.IsDir() {
+			http.NotFound(w, r)
+			return
+		}
+		files.ServeHTTP(w, r)
+	})
+}
+
+// contextReader 上下文结束后读取返回上下文的错误，用于中断长时间的复制
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (c contextReader) Read(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return c.r.Read(p)
+}
@@ -0,0 +1,67 @@
+// Package storage 上传文件的对象存储
+//
+// Storage 按键(如"videos/2024/05/abc.mp4")读写对象。Local 保存在本地目录，
+// 由API服务器直接提供下载；S3 使用S3兼容的对象存储(AWS S3、MinIO等)；
+// Memory 只保存在进程内，用于测试。
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"online-education-api/config"
+)
+
+// ErrNotFound 对象不存在
+var ErrNotFound = errors.New("对象不存在")
+
+// Storage 对象存储接口
+type Storage interface {
+	// Put 写入对象，已存在时覆盖；size为对象的字节数，未知时为-1
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Open 读取对象，不存在时返回 ErrNotFound
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete 删除对象，对象不存在时不返回错误
+	Delete(ctx context.Context, key string) error
+	// URL 返回客户端访问对象的地址
+	URL(key string) string
+}
+
+// New 根据配置创建对象存储，Driver为local或s3
+func New(cfg *config.StorageConfig) (Storage, error) {
+	switch cfg.Driver {
+	case "local":
+		return NewLocal(cfg.LocalDir, cfg.PublicURL), nil
+	case "s3":
+		return NewS3(S3Config{
+			Endpoint:  cfg.S3Endpoint,
+			Region:    cfg.S3Region,
+			Bucket:    cfg.S3Bucket,
+			AccessKey: cfg.S3AccessKey,
+			SecretKey: cfg.S3SecretKey,
+			PublicURL: cfg.PublicURL,
+		})
+	}
+	return nil, fmt.Errorf("不支持的存储类型: %s", cfg.Driver)
+}
+
+// checkKey 检查对象键，键由"/"分隔的非空路径段组成，不能包含"."、".."和反斜杠
+func checkKey(key string) error {
+	if key == "" || strings.Contains(key, `\`) {
+		return fmt.Errorf("无效的对象键: %q", key)
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part == "." || part == ".." {
+			return fmt.Errorf("无效的对象键: %q", key)
+		}
+	}
+	return nil
+}
+
+// joinURL 拼接访问地址和对象键
+func joinURL(base, key string) string {
+	return strings.TrimSuffix(base, "/") + "/" + key
+}
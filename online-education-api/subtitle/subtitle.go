@@ -0,0 +1,281 @@
+// Package subtitle WebVTT和SRT字幕的解析、校验和输出
+//
+// 解析时去除样式标签和位置设置，只保留每条字幕的时间和纯文本，两种格式之间可以互相转换而不丢失文字。
+// 字幕条按开始时间排序；结束时间必须晚于开始时间，文本不能为空，文件必须是UTF-8编码。
+package subtitle
+
+import (
+	"bufio"
+	"cmp"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Format 字幕文件格式
+type Format string
+
+// 支持的字幕格式
+const (
+	VTT Format = "vtt"
+	SRT Format = "srt"
+)
+
+// ErrFormat 字幕无法解析或不符合格式要求，具体原因和行号包含在返回的错误中
+var ErrFormat = errors.New("无效的字幕")
+
+// Cue 一条字幕
+type Cue struct {
+	Start time.Duration
+	End   time.Duration
+	Text  string // 纯文本，多行以\n分隔
+}
+
+var (
+	// tagPattern 样式标签(如<b>、<v 讲师>、<c.yellow>)和WebVTT的时间标签(如<00:01.000>)
+	tagPattern = regexp.MustCompile(`</?[a-zA-Z0-9][^<>\n]*>`)
+	// assPattern SRT中常见的ASS位置和样式代码(如{\an8})
+	assPattern = regexp.MustCompile(`\{\\[^{}]*\}`)
+	// vttTimePattern WebVTT时间，小时可以省略
+	vttTimePattern = regexp.MustCompile(`^(?:(\d{2,}):)?(\d{2}):(\d{2})\.(\d{3})$`)
+	// srtTimePattern SRT时间，兼容以.分隔毫秒的文件
+	srtTimePattern = regexp.MustCompile(`^(\d{1,}):(\d{2}):(\d{2})[,.](\d{3})$`)
+)
+
+// ContentType 返回格式对应的HTTP内容类型
+func (f Format) ContentType() string {
+	if f == SRT {
+		return "application/x-subrip; charset=utf-8"
+	}
+	return "text/vtt; charset=utf-8"
+}
+
+// Parse 解析字幕，以WEBVTT开头时按WebVTT解析，否则按SRT解析
+func Parse(data []byte) ([]Cue, Format, error) {
+	if strings.HasPrefix(strings.TrimPrefix(string(data), "\ufeff"), "WEBVTT") {
+		cues, err := ParseVTT(data)
+		return cues, VTT, err
+	}
+	cues, err := ParseSRT(data)
+	return cues, SRT, err
+}
+
+// block 以空行分隔的一段，line为第一行的行号
+type block struct {
+	line  int
+	lines []string
+}
+
+// splitBlocks 检查编码，统一换行符后按空行分段
+func splitBlocks(data []byte) ([]block, error) {
+	if !utf8.Valid(data) {
+		return nil, fmt.Errorf("%w: 文件不是UTF-8编码", ErrFormat)
+	}
+	s := strings.TrimPrefix(string(data), "\ufeff")
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	s = strings.ReplaceAll(s, "\r", "\n")
+
+	var blocks []block
+	var current *block
+	for i, line := range strings.Split(s, "\n") {
+		if strings.TrimSpace(line) == "" {
+			current = nil
+			continue
+		}
+		if current == nil {
+			blocks = append(blocks, block{line: i + 1})
+			current = &blocks[len(blocks)-1]
+		}
+		current.lines = append(current.lines, line)
+	}
+	return blocks, nil
+}
+
+// hasKeyword 判断行是否以关键字开头，关键字后面必须是空白或行尾
+func hasKeyword(line, keyword string) bool {
+	rest, ok := strings.CutPrefix(line, keyword)
+	return ok && (rest == "" || rest[0] == ' ' || rest[0] == '\t')
+}
+
+// ParseVTT 解析WebVTT字幕，忽略NOTE、STYLE和REGION块以及字幕条的标识和显示设置
+func ParseVTT(data []byte) ([]Cue, error) {
+	blocks, err := splitBlocks(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(blocks) == 0 || !hasKeyword(blocks[0].lines[0], "WEBVTT") {
+		return nil, fmt.Errorf("%w: 缺少WEBVTT文件头", ErrFormat)
+	}
+
+	var cues []Cue
+	for _, b := range blocks[1:] {
+		if hasKeyword(b.lines[0], "NOTE") || hasKeyword(b.lines[0], "STYLE") || hasKeyword(b.lines[0], "REGION") {
+			continue
+		}
+		// 时间行之前可以有一行字幕条标识
+		lines, line := b.lines, b.line
+		if !strings.Contains(lines[0], "-->") {
+			lines, line = lines[1:], line+1
+		}
+		cue, err := parseCue(lines, line, parseVTTTime)
+		if err != nil {
+			return nil, err
+		}
+		cue.Text = cleanText(lines[1:], true)
+		if cue.Text == "" {
+			return nil, fmt.Errorf("%w: 第%d行: 字幕文本为空", ErrFormat, line)
+		}
+		cues = append(cues, cue)
+	}
+	return sortCues(cues)
+}
+
+// ParseSRT 解析SRT字幕，序号可以省略，忽略样式标签和ASS位置代码
+func ParseSRT(data []byte) ([]Cue, error) {
+	blocks, err := splitBlocks(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var cues []Cue
+	for _, b := range blocks {
+		lines, line := b.lines, b.line
+		if !strings.Contains(lines[0], "-->") {
+			if _, err := strconv.Atoi(strings.TrimSpace(lines[0])); err != nil {
+				return nil, fmt.Errorf("%w: 第%d行: 应为序号或时间", ErrFormat, line)
+			}
+			lines, line = lines[1:], line+1
+		}
+		cue, err := parseCue(lines, line, parseSRTTime)
+		if err != nil {
+			return nil, err
+		}
+		cue.Text = cleanText(lines[1:], false)
+		if cue.Text == "" {
+			return nil, fmt.Errorf("%w: 第%d行: 字幕文本为空", ErrFormat, line)
+		}
+		cues = append(cues, cue)
+	}
+	return sortCues(cues)
+}
+
+// parseCue 解析时间行，line为时间行的行号
+func parseCue(lines []string, line int, parseTime func(string) (time.Duration, bool)) (Cue, error) {
+	if len(lines) == 0 {
+		return Cue{}, fmt.Errorf("%w: 第%d行: 缺少时间", ErrFormat, line)
+	}
+	start, rest, ok := strings.Cut(lines[0], "-->")
+	fields := strings.Fields(rest)
+	if !ok || len(fields) == 0 {
+		return Cue{}, fmt.Errorf("%w: 第%d行: 无效的时间行", ErrFormat, line)
+	}
+	// 结束时间之后的显示设置(WebVTT)或坐标(SRT)被忽略
+	startTime, ok1 := parseTime(strings.TrimSpace(start))
+	endTime, ok2 := parseTime(fields[0])
+	if !ok1 || !ok2 {
+		return Cue{}, fmt.Errorf("%w: 第%d行: 无效的时间", ErrFormat, line)
+	}
+	if endTime <= startTime {
+		return Cue{}, fmt.Errorf("%w: 第%d行: 结束时间必须晚于开始时间", ErrFormat, line)
+	}
+	return Cue{Start: startTime, End: endTime}, nil
+}
+
+// parseVTTTime 解析[hh:]mm:ss.ttt
+func parseVTTTime(s string) (time.Duration, bool) {
+	return parseTime(vttTimePattern.FindStringSubmatch(s))
+}
+
+// parseSRTTime 解析hh:mm:ss,ttt
+func parseSRTTime(s string) (time.Duration, bool) {
+	return parseTime(srtTimePattern.FindStringSubmatch(s))
+}
+
+// parseTime 由时、分、秒、毫秒的匹配结果计算时间，分和秒不能超过59
+func parseTime(m []string) (time.Duration, bool) {
+	if m == nil {
+		return 0, false
+	}
+	var parts [4]int
+	for i, s := range m[1:] {
+		if s == "" {
+			continue
+		}
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return 0, false
+		}
+		parts[i] = n
+	}
+	if parts[1] > 59 || parts[2] > 59 {
+		return 0, false
+	}
+	return time.Duration(parts[0])*time.Hour + time.Duration(parts[1])*time.Minute +
+		time.Duration(parts[2])*time.Second + time.Duration(parts[3])*time.Millisecond, true
+}
+
+// cleanText 去除标签后合并文本行，WebVTT的字符引用(如&amp;)还原为字符
+func cleanText(lines []string, vtt bool) string {
+	var text []string
+	for _, line := range lines {
+		line = tagPattern.ReplaceAllString(line, "")
+		if vtt {
+			line = html.UnescapeString(line)
+		} else {
+			line = assPattern.ReplaceAllString(line, "")
+		}
+		if line = strings.TrimSpace(line); line != "" {
+			text = append(text, line)
+		}
+	}
+	return strings.Join(text, "\n")
+}
+
+// sortCues 按开始时间排序，没有字幕条时返回错误
+func sortCues(cues []Cue) ([]Cue, error) {
+	if len(cues) == 0 {
+		return nil, fmt.Errorf("%w: 没有字幕", ErrFormat)
+	}
+	slices.SortStableFunc(cues, func(a, b Cue) int {
+		return cmp.Compare(a.Start, b.Start)
+	})
+	return cues, nil
+}
+
+// formatTime 输出hh:mm:ss加毫秒，sep为毫秒前的分隔符
+func formatTime(d time.Duration, sep byte) string {
+	ms := d.Milliseconds()
+	return fmt.Sprintf("%02d:%02d:%02d%c%03d", ms/3600000, ms/60000%60, ms/1000%60, sep, ms%1000)
+}
+
+// vttEscaper 转义WebVTT文本中有特殊含义的字符
+var vttEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// Write 以指定格式输出字幕
+func Write(w io.Writer, format Format, cues []Cue) error {
+	bw := bufio.NewWriter(w)
+	switch format {
+	case VTT:
+		bw.WriteString("WEBVTT\n")
+		for _, cue := range cues {
+			fmt.Fprintf(bw, "\n%s --> %s\n%s\n", formatTime(cue.Start, '.'), formatTime(cue.End, '.'), vttEscaper.Replace(cue.Text))
+		}
+	case SRT:
+		for i, cue := range cues {
+			if i > 0 {
+				bw.WriteString("\n")
+			}
+			fmt.Fprintf(bw, "%d\n%s --> %s\n%s\n", i+1, formatTime(cue.Start, ','), formatTime(cue.End, ','), cue.Text)
+		}
+	default:
+		return fmt.Errorf("不支持的字幕格式: %s", format)
+	}
+	return bw.Flush()
+}
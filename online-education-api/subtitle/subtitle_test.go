@@ -0,0 +1,117 @@
+package subtitle
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+)
+
+const sampleVTT = `WEBVTT - 第一讲
+Kind: captions
+
+NOTE 这是注释
+不会出现在字幕中
+
+STYLE
+::cue { color: yellow }
+
+intro
+00:00:01.000 --> 00:00:04.500 align:start line:0
+<v 讲师>大家好，欢迎来到<b>Go</b>课程
+
+00:03.000 --> 00:06.000
+goroutine &amp; channel
+&lt;-ch
+`
+
+func TestParseVTT(t *testing.T) {
+	cues, format, err := Parse([]byte(sampleVTT))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Cue{
+		{Start: time.Second, End: 4500 * time.Millisecond, Text: "大家好，欢迎来到Go课程"},
+		{Start: 3 * time.Second, End: 6 * time.Second, Text: "goroutine & channel\n<-ch"},
+	}
+	if format != VTT || len(cues) != len(want) {
+		t.Fatalf("Parse = %d cues, %q; want %d, vtt", len(cues), format, len(want))
+	}
+	for i := range want {
+		if cues[i] != want[i] {
+			t.Errorf("cue %d = %+v, want %+v", i, cues[i], want[i])
+		}
+	}
+}
+
+func TestParseSRT(t *testing.T) {
+	data := "\ufeff1\r\n00:00:05,000 --> 00:00:07,250\r\n{\\an8}<i>第二条</i>\r\n\r\n2\r\n00:00:01,000 --> 00:00:02,000 X1:10 X2:20\r\n第一条\r\n第一条第二行\r\n"
+	cues, format, err := Parse([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 按开始时间排序
+	if format != SRT || len(cues) != 2 || cues[0].Text != "第一条\n第一条第二行" || cues[1].Text != "第二条" || cues[1].End != 7250*time.Millisecond {
+		t.Fatalf("Parse = %+v, %q", cues, format)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"empty", ""},
+		{"not utf-8", "1\n00:00:01,000 --> 00:00:02,000\n\xff\xfe\n"},
+		{"vtt without cues", "WEBVTT\n\nNOTE nothing\n"},
+		{"vtt bad timestamp", "WEBVTT\n\n00:01.0 --> 00:02.000\ntext\n"},
+		{"vtt minutes out of range", "WEBVTT\n\n00:61.000 --> 01:02.000\ntext\n"},
+		{"end before start", "1\n00:00:05,000 --> 00:00:04,000\ntext\n"},
+		{"missing text", "1\n00:00:01,000 --> 00:00:02,000\n"},
+		{"missing timing", "1\ntext\n"},
+		{"not a subtitle", "hello world\n"},
+	}
+	for _, tt := range tests {
+		if _, _, err := Parse([]byte(tt.data)); !errors.Is(err, ErrFormat) {
+			t.Errorf("%s: err = %v, want ErrFormat", tt.name, err)
+		}
+	}
+}
+
+func TestWriteRoundTrip(t *testing.T) {
+	cues := []Cue{
+		{Start: 1500 * time.Millisecond, End: 3 * time.Second, Text: "a < b & c"},
+		{Start: time.Hour + 2*time.Minute + 3*time.Second + 4*time.Millisecond, End: time.Hour + 3*time.Minute, Text: "第一行\n第二行"},
+	}
+
+	var vtt bytes.Buffer
+	if err := Write(&vtt, VTT, cues); err != nil {
+		t.Fatal(err)
+	}
+	wantVTT := "WEBVTT\n\n00:00:01.500 --> 00:00:03.000\na &lt; b &amp; c\n\n01:02:03.004 --> 01:03:00.000\n第一行\n第二行\n"
+	if vtt.String() != wantVTT {
+		t.Errorf("WebVTT output = %q, want %q", vtt.String(), wantVTT)
+	}
+
+	var srt bytes.Buffer
+	if err := Write(&srt, SRT, cues); err != nil {
+		t.Fatal(err)
+	}
+	wantSRT := "1\n00:00:01,500 --> 00:00:03,000\na < b & c\n\n2\n01:02:03,004 --> 01:03:00,000\n第一行\n第二行\n"
+	if srt.String() != wantSRT {
+		t.Errorf("SRT output = %q, want %q", srt.String(), wantSRT)
+	}
+
+	// 两种格式互相转换后文字不变
+	for _, data := range []*bytes.Buffer{&vtt, &srt} {
+		parsed, _, err := Parse(data.Bytes())
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := range cues {
+			if parsed[i] != cues[i] {
+				t.Errorf("round trip cue %d = %+v, want %+v", i, parsed[i], cues[i])
+			}
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package transcode
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Fake 不做实际处理的 Executor，返回固定的媒体信息并生成占位文件，用于测试
+type Fake struct {
+	Info *MediaInfo // 为nil时返回1280x720、时长2分钟
+	Err  error      // 不为nil时Probe返回该错误
+
+	mu     sync.Mutex
+	inputs []string
+}
+
+// Probe 记录输入文件并返回 Info
+func (f *Fake) Probe(ctx context.Context, input string) (*MediaInfo, error) {
+	f.mu.Lock()
+	f.inputs = append(f.inputs, input)
+	f.mu.Unlock()
+
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	if f.Info != nil {
+		info := *f.Info
+		return &info, nil
+	}
+	return &MediaInfo{Duration: 2 * time.Minute, Width: 1280, Height: 720}, nil
+}
+
+// HLS 生成只有一个分片的播放列表
+func (f *Fake) HLS(ctx context.Context, input, dir string, r Rendition, width int) error {
+	playlist := fmt.Sprintf("#EXTM3U\n#EXT-X-TARGETDURATION:%d\n#EXTINF:%d.0,\nsegment_0000.ts\n#EXT-X-ENDLIST\n", hlsSegmentSeconds, hlsSegmentSeconds)
+	if err := os.WriteFile(filepath.Join(dir, "index.m3u8"), []byte(playlist), 0o644); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, "segment_0000.ts"), []byte(r.Name), 0o644)
+}
+
+// Frame 生成占位图片
+func (f *Fake) Frame(ctx context.Context, input, output string, at time.Duration) error {
+	return os.WriteFile(output, []byte("cover"), 0o644)
+}
+
+// Sprite 生成占位图片
+func (f *Fake) Sprite(ctx context.Context, input, output string, interval time.Duration, columns, rows int) error {
+	return os.WriteFile(output, []byte("sprite"), 0o644)
+}
+
+// Inputs 返回已处理的输入文件
+func (f *Fake) Inputs() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.inputs...)
+}
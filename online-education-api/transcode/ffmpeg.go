@@ -0,0 +1,146 @@
+package transcode
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// hlsSegmentSeconds HLS分片时长
+const hlsSegmentSeconds = 6
+
+// FFmpeg 调用本地的ffmpeg和ffprobe处理视频
+type FFmpeg struct {
+	FFmpegPath  string // 为空时从PATH中查找ffmpeg
+	FFprobePath string // 为空时从PATH中查找ffprobe
+}
+
+// Check 检查ffmpeg和ffprobe是否可以执行
+func (f *FFmpeg) Check() error {
+	for _, name := range []string{f.ffmpeg(), f.ffprobe()} {
+		if _, err := exec.LookPath(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Probe 读取源文件的时长和第一个视频流的分辨率
+func (f *FFmpeg) Probe(ctx context.Context, input string) (*MediaInfo, error) {
+	out, err := f.run(ctx, f.ffprobe(),
+		"-v", "error", "-print_format", "json",
+		"-show_entries", "format=duration:stream=codec_type,width,height",
+		input)
+	if err != nil {
+		return nil, err
+	}
+
+	var probe struct {
+		Format struct {
+			Duration string `json:"duration"`
+		} `json:"format"`
+		Streams []struct {
+			CodecType string `json:"codec_type"`
+			Width     int    `json:"width"`
+			Height    int    `json:"height"`
+		} `json:"streams"`
+	}
+	if err := json.Unmarshal(out, &probe); err != nil {
+		return nil, fmt.Errorf("解析ffprobe输出失败: %w", err)
+	}
+
+	info := &MediaInfo{}
+	if seconds, err := strconv.ParseFloat(probe.Format.Duration, 64); err == nil {
+		info.Duration = time.Duration(seconds * float64(time.Second))
+	}
+	for _, s := range probe.Streams {
+		if s.CodecType == "video" {
+			info.Width, info.Height = s.Width, s.Height
+			break
+		}
+	}
+	return info, nil
+}
+
+// HLS 转码为H.264/AAC，关键帧间隔与分片时长对齐，保证各清晰度可以无缝切换
+func (f *FFmpeg) HLS(ctx context.Context, input, dir string, r Rendition, width int) error {
+	_, err := f.run(ctx, f.ffmpeg(),
+		"-y", "-v", "error", "-i", input,
+		"-map", "0:v:0", "-map", "0:a:0?",
+		"-vf", fmt.Sprintf("scale=%d:%d", width, r.Height),
+		"-c:v", "libx264", "-preset", "veryfast", "-profile:v", "main",
+		"-b:v", strconv.Itoa(r.VideoBitrate), "-maxrate", strconv.Itoa(r.VideoBitrate*107/100),
+		"-bufsize", strconv.Itoa(r.VideoBitrate*3/2),
+		"-force_key_frames", fmt.Sprintf("expr:gte(t,n_forced*%d)", hlsSegmentSeconds), "-sc_threshold", "0",
+		"-c:a", "aac", "-b:a", strconv.Itoa(r.AudioBitrate), "-ac", "2",
+		"-f", "hls", "-hls_time", strconv.Itoa(hlsSegmentSeconds), "-hls_playlist_type", "vod",
+		"-hls_segment_filename", filepath.Join(dir, "segment_%04d.ts"),
+		filepath.Join(dir, "index.m3u8"))
+	return err
+}
+
+// Frame 截取at之后的第一个关键帧
+func (f *FFmpeg) Frame(ctx context.Context, input, output string, at time.Duration) error {
+	_, err := f.run(ctx, f.ffmpeg(),
+		"-y", "-v", "error", "-ss", seconds(at), "-i", input,
+		"-frames:v", "1", "-q:v", "3", output)
+	return err
+}
+
+// Sprite 按间隔截帧并拼接为雪碧图
+func (f *FFmpeg) Sprite(ctx context.Context, input, output string, interval time.Duration, columns, rows int) error {
+	_, err := f.run(ctx, f.ffmpeg(),
+		"-y", "-v", "error", "-i", input,
+		"-vf", fmt.Sprintf("fps=1/%s,scale=%d:%d:force_original_aspect_ratio=decrease,pad=%d:%d:(ow-iw)/2:(oh-ih)/2,tile=%dx%d",
+			seconds(interval), TileWidth, TileHeight, TileWidth, TileHeight, columns, rows),
+		"-frames:v", "1", "-q:v", "5", output)
+	return err
+}
+
+func (f *FFmpeg) ffmpeg() string {
+	if f.FFmpegPath != "" {
+		return f.FFmpegPath
+	}
+	return "ffmpeg"
+}
+
+func (f *FFmpeg) ffprobe() string {
+	if f.FFprobePath != "" {
+		return f.FFprobePath
+	}
+	return "ffprobe"
+}
+
+// run 执行命令，失败时附上stderr的最后几行
+func (f *FFmpeg) run(ctx context.Context, name string, args ...string) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := lastLines(stderr.String(), 3); msg != "" {
+			return nil, fmt.Errorf("%s: %w: %s", filepath.Base(name), err, msg)
+		}
+		return nil, fmt.Errorf("%s: %w", filepath.Base(name), err)
+	}
+	return stdout.Bytes(), nil
+}
+
+// seconds 格式化为ffmpeg接受的秒数
+func seconds(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'f', 3, 64)
+}
+
+func lastLines(s string, n int) string {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "; ")
+}
@@ -0,0 +1,210 @@
+// Package transcode 将上传的视频转码为多清晰度的HLS，并生成封面和缩略图
+//
+// 实际的音视频处理由 Executor 完成：FFmpeg 调用本地的ffmpeg和ffprobe，Fake 只生成占位文件，用于测试。
+// Process 负责选择清晰度、生成主播放列表和缩略图的WebVTT索引，输出目录中的文件按相对路径上传到对象存储。
+package transcode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Rendition 一种HLS清晰度
+type Rendition struct {
+	Name         string // 如"720p"，同时作为输出子目录名
+	Height       int
+	VideoBitrate int // 视频码率，bit/s
+	AudioBitrate int // 音频码率，bit/s
+}
+
+// DefaultLadder 默认的清晰度阶梯
+var DefaultLadder = []Rendition{
+	{Name: "360p", Height: 360, VideoBitrate: 800_000, AudioBitrate: 96_000},
+	{Name: "720p", Height: 720, VideoBitrate: 2_800_000, AudioBitrate: 128_000},
+	{Name: "1080p", Height: 1080, VideoBitrate: 5_000_000, AudioBitrate: 192_000},
+}
+
+// ParseLadder 从逗号分隔的名称(如"360p,720p")中选出 DefaultLadder 中的清晰度
+func ParseLadder(names string) ([]Rendition, error) {
+	var ladder []Rendition
+	for _, name := range strings.Split(names, ",") {
+		name = strings.TrimSpace(name)
+		found := false
+		for _, r := range DefaultLadder {
+			if r.Name == name {
+				ladder = append(ladder, r)
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("未知的清晰度: %q", name)
+		}
+	}
+	return ladder, nil
+}
+
+// MediaInfo 源文件的媒体信息
+type MediaInfo struct {
+	Duration time.Duration
+	Width    int
+	Height   int
+}
+
+// Executor 执行实际的音视频处理
+type Executor interface {
+	// Probe 读取源文件的时长和分辨率
+	Probe(ctx context.Context, input string) (*MediaInfo, error)
+	// HLS 将源文件转码为一种清晰度，在dir中生成index.m3u8和分片
+	HLS(ctx context.Context, input, dir string, r Rendition, width int) error
+	// Frame 截取at附近的关键帧，保存为JPEG
+	Frame(ctx context.Context, input, output string, at time.Duration) error
+	// Sprite 每隔interval截取一帧，缩放为 TileWidth x TileHeight 后按columns列拼接为一张JPEG
+	Sprite(ctx context.Context, input, output string, interval time.Duration, columns, rows int) error
+}
+
+// 缩略图雪碧图中每张缩略图的尺寸和每行的数量
+const (
+	TileWidth     = 160
+	TileHeight    = 90
+	spriteColumns = 10
+	maxTiles      = 100
+)
+
+// Output 文件名，相对于输出目录
+const (
+	MasterPlaylist = "master.m3u8"
+	CoverImage     = "cover.jpg"
+	SpriteImage    = "sprite.jpg"
+	ThumbnailsVTT  = "thumbnails.vtt"
+)
+
+// RenditionResult 转码生成的一种清晰度
+type RenditionResult struct {
+	Rendition
+	Width    int
+	Playlist string // 相对于输出目录的播放列表路径
+}
+
+// Result 转码结果，路径均相对于输出目录
+type Result struct {
+	Info       *MediaInfo
+	Renditions []RenditionResult
+}
+
+// ErrNoVideo 源文件中没有视频流
+var ErrNoVideo = errors.New("文件中没有可用的视频流")
+
+// Process 转码input，结果写入outDir：各清晰度的子目录、master.m3u8、封面、缩略图雪碧图和WebVTT索引
+func Process(ctx context.Context, exec Executor, input, outDir string, ladder []Rendition) (*Result, error) {
+	info, err := exec.Probe(ctx, input)
+	if err != nil {
+		return nil, fmt.Errorf("读取媒体信息失败: %w", err)
+	}
+	if info.Width <= 0 || info.Height <= 0 || info.Duration <= 0 {
+		return nil, ErrNoVideo
+	}
+
+	result := &Result{Info: info}
+	for _, r := range selectLadder(ladder, info.Height) {
+		width := scaledWidth(info, r.Height)
+		if err := os.MkdirAll(filepath.Join(outDir, r.Name), 0o755); err != nil {
+			return nil, err
+		}
+		if err := exec.HLS(ctx, input, filepath.Join(outDir, r.Name), r, width); err != nil {
+			return nil, fmt.Errorf("转码%s失败: %w", r.Name, err)
+		}
+		result.Renditions = append(result.Renditions, RenditionResult{
+			Rendition: r,
+			Width:     width,
+			Playlist:  r.Name + "/index.m3u8",
+		})
+	}
+	if err := os.WriteFile(filepath.Join(outDir, MasterPlaylist), []byte(masterPlaylist(result.Renditions)), 0o644); err != nil {
+		return nil, err
+	}
+
+	// 封面取十分之一处的关键帧，避开片头的黑屏
+	if err := exec.Frame(ctx, input, filepath.Join(outDir, CoverImage), info.Duration/10); err != nil {
+		return nil, fmt.Errorf("截取封面失败: %w", err)
+	}
+
+	interval, count := spriteLayout(info.Duration)
+	rows := (count + spriteColumns - 1) / spriteColumns
+	if err := exec.Sprite(ctx, input, filepath.Join(outDir, SpriteImage), interval, spriteColumns, rows); err != nil {
+		return nil, fmt.Errorf("生成缩略图失败: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(outDir, ThumbnailsVTT), []byte(thumbnailsVTT(info.Duration, interval, count)), 0o644); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// selectLadder 去掉高于源文件分辨率的清晰度，至少保留最低的一种
+func selectLadder(ladder []Rendition, sourceHeight int) []Rendition {
+	var selected []Rendition
+	for _, r := range ladder {
+		if r.Height <= sourceHeight {
+			selected = append(selected, r)
+		}
+	}
+	if len(selected) == 0 && len(ladder) > 0 {
+		lowest := ladder[0]
+		for _, r := range ladder {
+			if r.Height < lowest.Height {
+				lowest = r
+			}
+		}
+		selected = append(selected, lowest)
+	}
+	return selected
+}
+
+// scaledWidth 按源文件的宽高比计算目标宽度，取偶数以满足H.264编码的要求
+func scaledWidth(info *MediaInfo, height int) int {
+	w := float64(info.Width) * float64(height) / float64(info.Height)
+	return int(math.Round(w/2)) * 2
+}
+
+// masterPlaylist 生成引用各清晰度播放列表的主播放列表
+func masterPlaylist(renditions []RenditionResult) string {
+	var b strings.Builder
+	b.WriteString("#EXTM3U\n#EXT-X-VERSION:3\n")
+	for _, r := range renditions {
+		fmt.Fprintf(&b, "#EXT-X-STREAM-INF:BANDWIDTH=%d,RESOLUTION=%dx%d,NAME=\"%s\"\n%s\n",
+			r.VideoBitrate+r.AudioBitrate, r.Width, r.Height, r.Name, r.Playlist)
+	}
+	return b.String()
+}
+
+// spriteLayout 计算截取缩略图的间隔和数量，间隔至少10秒，最多 maxTiles 张
+func spriteLayout(duration time.Duration) (time.Duration, int) {
+	interval := max(10*time.Second, (duration/maxTiles).Round(time.Second)+time.Second)
+	count := int((duration + interval - 1) / interval)
+	return interval, max(count, 1)
+}
+
+// thumbnailsVTT 生成缩略图索引，播放器根据时间显示雪碧图中对应位置的缩略图
+func thumbnailsVTT(duration, interval time.Duration, count int) string {
+	var b strings.Builder
+	b.WriteString("WEBVTT\n")
+	for i := range count {
+		start := time.Duration(i) * interval
+		end := min(start+interval, duration)
+		x := (i % spriteColumns) * TileWidth
+		y := (i / spriteColumns) * TileHeight
+		fmt.Fprintf(&b, "\n%s --> %s\n%s#xywh=%d,%d,%d,%d\n", vttTime(start), vttTime(end), SpriteImage, x, y, TileWidth, TileHeight)
+	}
+	return b.String()
+}
+
+// vttTime 格式化为WebVTT的时间戳 hh:mm:ss.mmm
+func vttTime(d time.Duration) string {
+	ms := d.Milliseconds()
+	return fmt.Sprintf("%02d:%02d:%02d.%03d", ms/3_600_000, ms/60_000%60, ms/1000%60, ms%1000)
+}
@@ -0,0 +1,111 @@
+package transcode
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProcessSkipsRenditionsAboveSource(t *testing.T) {
+	dir := t.TempDir()
+	exec := &Fake{Info: &MediaInfo{Duration: 95 * time.Second, Width: 1440, Height: 1080}}
+
+	result, err := Process(context.Background(), exec, "in.mp4", dir, DefaultLadder)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Renditions) != 3 || result.Renditions[1].Width != 960 {
+		t.Fatalf("unexpected renditions %+v", result.Renditions)
+	}
+
+	master, err := os.ReadFile(filepath.Join(dir, MasterPlaylist))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(master), "BANDWIDTH=2928000,RESOLUTION=960x720,NAME=\"720p\"\n720p/index.m3u8\n") {
+		t.Errorf("unexpected master playlist:\n%s", master)
+	}
+	for _, name := range []string{"1080p/index.m3u8", CoverImage, SpriteImage} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("missing %s: %v", name, err)
+		}
+	}
+
+	vtt, err := os.ReadFile(filepath.Join(dir, ThumbnailsVTT))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(string(vtt), "00:01:30.000 --> 00:01:35.000\nsprite.jpg#xywh=1440,0,160,90\n") {
+		t.Errorf("unexpected thumbnails:\n%s", vtt)
+	}
+
+	// 低于所有清晰度的源文件只转码最低的一种
+	exec.Info = &MediaInfo{Duration: time.Second, Width: 320, Height: 240}
+	result, err = Process(context.Background(), exec, "in.mp4", t.TempDir(), DefaultLadder)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Renditions) != 1 || result.Renditions[0].Name != "360p" || result.Renditions[0].Width != 480 {
+		t.Fatalf("unexpected renditions %+v", result.Renditions)
+	}
+}
+
+func TestSpriteLayout(t *testing.T) {
+	tests := []struct {
+		duration time.Duration
+		interval time.Duration
+		count    int
+	}{
+		{time.Second, 10 * time.Second, 1},
+		{95 * time.Second, 10 * time.Second, 10},
+		{time.Hour, 37 * time.Second, 98},
+	}
+	for _, tt := range tests {
+		interval, count := spriteLayout(tt.duration)
+		if interval != tt.interval || count != tt.count || count > maxTiles {
+			t.Errorf("spriteLayout(%v) = %v, %d, want %v, %d", tt.duration, interval, count, tt.interval, tt.count)
+		}
+	}
+}
+
+func TestParseLadder(t *testing.T) {
+	ladder, err := ParseLadder("360p, 1080p")
+	if err != nil || len(ladder) != 2 || ladder[1].Height != 1080 {
+		t.Fatalf("ParseLadder = %+v, %v", ladder, err)
+	}
+	if _, err := ParseLadder("360p,4k"); err == nil {
+		t.Error("expected error for unknown rendition")
+	}
+}
+
+func TestFFmpeg(t *testing.T) {
+	ffmpeg := &FFmpeg{}
+	if err := ffmpeg.Check(); err != nil {
+		t.Skip("ffmpeg not installed:", err)
+	}
+	dir := t.TempDir()
+	input := filepath.Join(dir, "in.mp4")
+	_, err := ffmpeg.run(context.Background(), ffmpeg.ffmpeg(), "-v", "error",
+		"-f", "lavfi", "-i", "testsrc=duration=12:size=640x360:rate=25",
+		"-f", "lavfi", "-i", "sine=duration=12", "-shortest", input)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := filepath.Join(dir, "out")
+	result, err := Process(context.Background(), ffmpeg, input, out, DefaultLadder)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Info.Width != 640 || result.Info.Duration.Round(time.Second) != 12*time.Second || len(result.Renditions) != 1 {
+		t.Fatalf("unexpected result %+v", result)
+	}
+	for _, name := range []string{"360p/index.m3u8", "360p/segment_0000.ts", CoverImage, SpriteImage} {
+		if _, err := os.Stat(filepath.Join(out, name)); err != nil {
+			t.Errorf("missing %s: %v", name, err)
+		}
+	}
+}
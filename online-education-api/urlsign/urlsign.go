@@ -0,0 +1,177 @@
+// Package urlsign 生成和校验带有效期的HMAC签名地址，用于保护付费视频等文件
+//
+// 签名放在路径中而不是查询参数中：{前缀}_s/{令牌}/{对象键}。HLS播放列表用相对路径引用分片，
+// 播放器按播放列表的地址解析分片地址时会带上同一个令牌，因此一次签名可以覆盖整个目录。
+// 令牌包含过期时间、用户ID和签名覆盖的范围，可以选择绑定客户端IP，泄露的地址可以追溯到用户。
+package urlsign
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// signedSegment 签名地址在访问地址前缀之后的第一段路径
+const signedSegment = "_s/"
+
+// macLen 令牌中保留的HMAC字节数
+const macLen = 16
+
+// 校验签名地址时返回的错误
+var (
+	ErrInvalid = errors.New("签名地址无效")
+	ErrExpired = errors.New("签名地址已过期")
+)
+
+// Signer 为一个访问地址前缀下的文件签名
+type Signer struct {
+	key    []byte
+	base   string // 访问地址前缀，以"/"结尾，如"/media/"
+	ttl    time.Duration
+	bindIP bool
+	now    func() time.Time
+}
+
+// New 创建签名器，base为文件的访问地址前缀，ttl为签名地址的有效期，bindIP为true时签名地址只能由签名时的客户端IP使用
+func New(key []byte, base string, ttl time.Duration, bindIP bool) *Signer {
+	return &Signer{
+		key:    key,
+		base:   strings.TrimSuffix(base, "/") + "/",
+		ttl:    ttl,
+		bindIP: bindIP,
+		now:    time.Now,
+	}
+}
+
+// Sign 为访问地址前缀下的文件地址签名，其他地址(如外部链接)原样返回。
+// dir为true时签名覆盖文件所在的目录及其子目录，用于HLS播放列表和引用雪碧图的WebVTT
+func (s *Signer) Sign(url string, dir bool, userID int64, ip string) string {
+	key, ok := strings.CutPrefix(url, s.base)
+	if !ok || key == "" || strings.HasPrefix(key, signedSegment) {
+		return url
+	}
+
+	depth := 0
+	if dir {
+		depth = strings.Count(key, "/")
+	}
+	// 过期时间向上取整到分钟，同一分钟内生成的地址相同，响应的ETag保持不变
+	expires := s.now().Add(s.ttl + time.Minute - 1).Truncate(time.Minute).Unix()
+	if !s.bindIP {
+		ip = ""
+	}
+	bound := "0"
+	if ip != "" {
+		bound = "1"
+	}
+	mac := s.mac(expires, userID, scope(key, depth), ip)
+	return fmt.Sprintf("%s%s%d.%d.%d.%s.%s/%s", s.base, signedSegment, expires, userID, depth, bound, mac, key)
+}
+
+// Verify 校验去掉访问地址前缀后的签名路径(如"_s/令牌/hls/1/master.m3u8")，返回对象键和签名时的用户ID
+func (s *Signer) Verify(path, ip string) (key string, userID int64, err error) {
+	rest, ok := strings.CutPrefix(path, signedSegment)
+	if !ok {
+		return "", 0, ErrInvalid
+	}
+	token, key, ok := strings.Cut(rest, "/")
+	if !ok || !validKey(key) {
+		return "", 0, ErrInvalid
+	}
+	fields := strings.Split(token, ".")
+	if len(fields) != 5 {
+		return "", 0, ErrInvalid
+	}
+	expires, err1 := strconv.ParseInt(fields[0], 10, 64)
+	userID, err2 := strconv.ParseInt(fields[1], 10, 64)
+	depth, err3 := strconv.Atoi(fields[2])
+	if err1 != nil || err2 != nil || err3 != nil || depth < 0 || depth > strings.Count(key, "/") {
+		return "", 0, ErrInvalid
+	}
+	if fields[3] != "1" {
+		ip = ""
+	}
+
+	want := s.mac(expires, userID, scope(key, depth), ip)
+	if !hmac.Equal([]byte(fields[4]), []byte(want)) {
+		return "", 0, ErrInvalid
+	}
+	if s.now().Unix() > expires {
+		return "", 0, ErrExpired
+	}
+	return key, userID, nil
+}
+
+// Handler 校验签名地址后将请求转发给next，转发的路径为对象键，请求路径需要已去掉访问地址前缀。
+// 以protected中任一前缀开头的对象键只能通过签名地址访问
+func (s *Signer) Handler(next http.Handler, protected ...string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := strings.TrimPrefix(r.URL.Path, "/")
+		if !strings.HasPrefix(path, signedSegment) {
+			for _, prefix := range protected {
+				if strings.HasPrefix(path, prefix) {
+					http.Error(w, "需要签名地址", http.StatusForbidden)
+					return
+				}
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		key, _, err := s.Verify(path, ClientIP(r))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		r2 := new(http.Request)
+		*r2 = *r
+		u := *r.URL
+		u.Path, u.RawPath = "/"+key, ""
+		r2.URL = &u
+		// 签名地址因用户而异，不能由共享缓存保存
+		w.Header().Set("Cache-Control", "private")
+		next.ServeHTTP(w, r2)
+	})
+}
+
+// mac 计算令牌的签名，覆盖过期时间、用户ID、签名范围和绑定的IP
+func (s *Signer) mac(expires, userID int64, scope, ip string) string {
+	h := hmac.New(sha256.New, s.key)
+	fmt.Fprintf(h, "v1\n%d\n%d\n%s\n%s", expires, userID, scope, ip)
+	return base64.RawURLEncoding.EncodeToString(h.Sum(nil)[:macLen])
+}
+
+// scope 返回签名覆盖的范围：depth为0时是对象键本身，否则是对象键的前depth段路径
+func scope(key string, depth int) string {
+	if depth == 0 {
+		return key
+	}
+	parts := strings.SplitN(key, "/", depth+1)
+	return strings.Join(parts[:depth], "/") + "/"
+}
+
+// validKey 检查对象键不为空且不包含空路径段和"."、".."，防止签名的目录范围被绕过
+func validKey(key string) bool {
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part == "." || part == ".." {
+			return false
+		}
+	}
+	return true
+}
+
+// ClientIP 返回请求的客户端IP。使用反向代理时为代理的地址，签名和校验使用同一来源，绑定IP只区分代理
+func ClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
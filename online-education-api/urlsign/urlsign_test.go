@@ -0,0 +1,95 @@
+package urlsign
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSignAndVerify(t *testing.T) {
+	now := time.Date(2026, 10, 1, 12, 0, 30, 0, time.UTC)
+	s := New([]byte("secret"), "/media", time.Hour, true)
+	s.now = func() time.Time { return now }
+
+	// 外部链接和其他前缀的地址不签名
+	for _, url := range []string{"https://cdn.example.com/a.mp4", "/static/a.mp4", "/media/"} {
+		if got := s.Sign(url, false, 1, ""); got != url {
+			t.Errorf("Sign(%q) = %q, want unchanged", url, got)
+		}
+	}
+
+	file := s.Sign("/media/videos/2026/10/a.mp4", false, 42, "10.0.0.1")
+	// 过期时间为一小时后，向上取整到分钟
+	expires := strconv.FormatInt(time.Date(2026, 10, 1, 13, 1, 0, 0, time.UTC).Unix(), 10)
+	if !strings.HasPrefix(file, "/media/_s/"+expires+".42.0.1.") || !strings.HasSuffix(file, "/videos/2026/10/a.mp4") {
+		t.Fatalf("unexpected signed url %q", file)
+	}
+	path := strings.TrimPrefix(file, "/media/")
+	key, userID, err := s.Verify(path, "10.0.0.1")
+	if err != nil || key != "videos/2026/10/a.mp4" || userID != 42 {
+		t.Fatalf("Verify = %q, %d, %v", key, userID, err)
+	}
+	if _, _, err := s.Verify(path, "10.0.0.2"); !errors.Is(err, ErrInvalid) {
+		t.Errorf("Verify from another IP err = %v", err)
+	}
+	if _, _, err := s.Verify(strings.Replace(path, ".42.", ".43.", 1), "10.0.0.1"); !errors.Is(err, ErrInvalid) {
+		t.Errorf("Verify with another user err = %v", err)
+	}
+	if _, _, err := s.Verify(strings.Replace(path, "a.mp4", "b.mp4", 1), "10.0.0.1"); !errors.Is(err, ErrInvalid) {
+		t.Errorf("Verify of another file err = %v", err)
+	}
+	if _, _, err := New([]byte("other"), "/media", time.Hour, true).Verify(path, "10.0.0.1"); !errors.Is(err, ErrInvalid) {
+		t.Errorf("Verify with another key err = %v", err)
+	}
+
+	// 目录签名覆盖同一目录及子目录中的分片，不覆盖上级目录
+	master := strings.TrimPrefix(s.Sign("/media/hls/7/master.m3u8", true, 42, "10.0.0.1"), "/media/")
+	token := strings.TrimSuffix(master, "master.m3u8")
+	for _, rel := range []string{"720p/index.m3u8", "720p/segment_0001.ts", "sprite.jpg"} {
+		if key, _, err := s.Verify(token+rel, "10.0.0.1"); err != nil || key != "hls/7/"+rel {
+			t.Errorf("Verify(%s) = %q, %v", rel, key, err)
+		}
+	}
+	for _, rel := range []string{"../8/master.m3u8", "./master.m3u8", "720p//index.m3u8"} {
+		if _, _, err := s.Verify(token+rel, "10.0.0.1"); !errors.Is(err, ErrInvalid) {
+			t.Errorf("Verify(%s) err = %v, want ErrInvalid", rel, err)
+		}
+	}
+
+	now = now.Add(time.Hour + time.Minute)
+	if _, _, err := s.Verify(path, "10.0.0.1"); !errors.Is(err, ErrExpired) {
+		t.Errorf("Verify after expiry err = %v", err)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	s := New([]byte("secret"), "/media/", time.Hour, false)
+	h := s.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.URL.Path))
+	}), "videos/", "hls/")
+
+	serve := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+		return rec
+	}
+
+	if rec := serve("/covers/1.jpg"); rec.Code != http.StatusOK || rec.Body.String() != "/covers/1.jpg" {
+		t.Errorf("public file: %d %q", rec.Code, rec.Body)
+	}
+	if rec := serve("/videos/a.mp4"); rec.Code != http.StatusForbidden {
+		t.Errorf("unsigned protected file: %d", rec.Code)
+	}
+	signed := strings.TrimPrefix(s.Sign("/media/videos/a.mp4", false, 1, "192.0.2.1"), "/media")
+	rec := serve(signed)
+	if rec.Code != http.StatusOK || rec.Body.String() != "/videos/a.mp4" || rec.Header().Get("Cache-Control") != "private" {
+		t.Errorf("signed file: %d %q", rec.Code, rec.Body)
+	}
+	if rec := serve(strings.Replace(signed, "a.mp4", "b.mp4", 1)); rec.Code != http.StatusForbidden {
+		t.Errorf("tampered url: %d", rec.Code)
+	}
+}
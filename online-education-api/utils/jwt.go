@@ -2,12 +2,14 @@ package utils
 
 import (
 	"errors"
-	"log"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"online-education-api/logger"
 )
 
+var jwtLog = logger.New("utils")
+
 // JWTSecret JWT密钥，实际应用中应从环境变量或配置文件中读取
 var JWTSecret = []byte("1234567890abcdef1234567890abcdef") // 更新为更安全的密钥
 
@@ -42,11 +44,10 @@ func GenerateToken(userID int64, username string, role string) (string, error) {
 	// 签名令牌
 	tokenString, err := token.SignedString(JWTSecret)
 	if err != nil {
-		log.Printf("生成令牌失败: %v\n", err)
+		jwtLog.Error("生成令牌失败", "user_id", userID, "error", err)
 		return "", err
 	}
 
-	log.Printf("成功生成令牌 for user: %s\n", username)
 	return tokenString, nil
 }
 
@@ -57,23 +58,22 @@ func ParseToken(tokenString string) (*Claims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
 		// 验证签名算法
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			log.Printf("无效的签名算法: %v\n", token.Header["alg"])
+			jwtLog.Warn("无效的签名算法", "alg", token.Header["alg"])
 			return nil, errors.New("无效的签名算法")
 		}
 		return JWTSecret, nil
 	})
 
 	if err != nil {
-		log.Printf("解析令牌失败: %v\n", err)
+		jwtLog.Debug("解析令牌失败", "error", err)
 		return nil, err
 	}
 
 	// 验证令牌是否有效
 	if !token.Valid {
-		log.Println("无效的令牌")
+		jwtLog.Debug("无效的令牌")
 		return nil, errors.New("无效的令牌")
 	}
 
-	log.Printf("成功解析令牌 for user: %s\n", claims.Username)
 	return claims, nil
 }
\ No newline at end of file
@@ -0,0 +1,96 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// MaxRequestBodySize 请求体的最大字节数
+const MaxRequestBodySize = 1 << 20 // 1MB
+
+// RequestError 请求体解析错误
+type RequestError struct {
+	Status int
+	Msg    string
+}
+
+// Error 实现error接口
+func (e *RequestError) Error() string {
+	return e.Msg
+}
+
+// DecodeJSON 严格解析JSON请求体并按binding标签校验
+// 拒绝未知字段、超过MaxRequestBodySize的请求体以及包含多个JSON值的请求体
+func DecodeJSON(w http.ResponseWriter, r *http.Request, dst interface{}) error {
+	r.Body = http.MaxBytesReader(w, r.Body, MaxRequestBodySize)
+
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(dst); err != nil {
+		var syntaxErr *json.SyntaxError
+		var typeErr *json.UnmarshalTypeError
+		var maxBytesErr *http.MaxBytesError
+
+		switch {
+		case errors.As(err, &maxBytesErr):
+			return &RequestError{Status: http.StatusRequestEntityTooLarge, Msg: fmt.Sprintf("请求体不能超过%d字节", maxBytesErr.Limit)}
+		case errors.As(err, &syntaxErr):
+			return &RequestError{Status: http.StatusBadRequest, Msg: fmt.Sprintf("请求体JSON格式错误(位置%d)", syntaxErr.Offset)}
+		case errors.Is(err, io.ErrUnexpectedEOF):
+			return &RequestError{Status: http.StatusBadRequest, Msg: "请求体JSON格式错误"}
+		case errors.As(err, &typeErr):
+			return ValidationErrors{{Field: typeErr.Field, Rule: "type", Message: fmt.Sprintf("%s类型错误", typeErr.Field)}}
+		case errors.Is(err, io.EOF):
+			return &RequestError{Status: http.StatusBadRequest, Msg: "请求体不能为空"}
+		default:
+			if field, ok := unknownField(err); ok {
+				return ValidationErrors{{Field: field, Rule: "unknown", Message: fmt.Sprintf("未知字段%s", field)}}
+			}
+			return &RequestError{Status: http.StatusBadRequest, Msg: "请求参数错误"}
+		}
+	}
+
+	// More遇到多余的]或}时返回false，读取下一个token确认请求体已经结束
+	if _, err := decoder.Token(); err != io.EOF {
+		return &RequestError{Status: http.StatusBadRequest, Msg: "请求体只能包含一个JSON对象"}
+	}
+
+	return Validate(dst)
+}
+
+// unknownField 从json包的错误信息中提取未知字段名
+func unknownField(err error) (string, bool) {
+	var field string
+	if _, scanErr := fmt.Sscanf(err.Error(), "json: unknown field %q", &field); scanErr != nil {
+		return "", false
+	}
+	return field, true
+}
+
+// WriteRequestError 将请求解析或校验错误写入响应
+func WriteRequestError(w http.ResponseWriter, err error) {
+	status := http.StatusBadRequest
+	body := map[string]interface{}{
+		"code": status,
+		"msg":  "请求参数错误",
+	}
+
+	var validationErrs ValidationErrors
+	var requestErr *RequestError
+	switch {
+	case errors.As(err, &validationErrs):
+		body["errors"] = validationErrs
+	case errors.As(err, &requestErr):
+		status = requestErr.Status
+		body["code"] = status
+		body["msg"] = requestErr.Msg
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
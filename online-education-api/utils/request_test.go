@@ -0,0 +1,96 @@
+package utils_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"online-education-api/utils"
+)
+
+type loginRequest struct {
+	Username string `json:"username" binding:"required"`
+	Age      int    `json:"age"`
+}
+
+func TestDecodeJSON(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		status int    // RequestError的状态码，0表示不是RequestError
+		field  string // ValidationErrors中的字段
+		rule   string
+	}{
+		{"valid", `{"username":"alice","age":3}`, 0, "", ""},
+		{"trailing whitespace", "{\"username\":\"alice\"}\n", 0, "", ""},
+		{"empty body", ``, http.StatusBadRequest, "", ""},
+		{"syntax error", `{"username":}`, http.StatusBadRequest, "", ""},
+		{"truncated", `{"username":"alice"`, http.StatusBadRequest, "", ""},
+		{"unknown field", `{"username":"alice","admin":true}`, 0, "admin", "unknown"},
+		{"wrong type", `{"username":"alice","age":"3"}`, 0, "age", "type"},
+		{"second object", `{"username":"alice"}{"username":"bob"}`, http.StatusBadRequest, "", ""},
+		{"trailing garbage", `{"username":"alice"} x`, http.StatusBadRequest, "", ""},
+		{"trailing brace", `{"username":"alice"}}`, http.StatusBadRequest, "", ""},
+		{"trailing bracket", `{"username":"alice"}]`, http.StatusBadRequest, "", ""},
+		{"validation", `{"age":3}`, 0, "username", "required"},
+		{"oversize", `{"username":"` + strings.Repeat("a", utils.MaxRequestBodySize) + `"}`, http.StatusRequestEntityTooLarge, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/", strings.NewReader(tt.body))
+			var dst loginRequest
+			err := utils.DecodeJSON(rec, req, &dst)
+
+			var reqErr *utils.RequestError
+			var errs utils.ValidationErrors
+			switch {
+			case tt.status != 0:
+				if !errors.As(err, &reqErr) || reqErr.Status != tt.status {
+					t.Fatalf("DecodeJSON = %#v, want RequestError %d", err, tt.status)
+				}
+			case tt.field != "":
+				if !errors.As(err, &errs) || len(errs) != 1 || errs[0].Field != tt.field || errs[0].Rule != tt.rule {
+					t.Fatalf("DecodeJSON = %#v, want %s:%s", err, tt.field, tt.rule)
+				}
+			default:
+				if err != nil {
+					t.Fatalf("DecodeJSON = %v", err)
+				}
+				if dst.Username != "alice" {
+					t.Errorf("decoded %+v", dst)
+				}
+			}
+		})
+	}
+}
+
+func TestWriteRequestError(t *testing.T) {
+	tests := []struct {
+		err    error
+		status int
+		errors int
+	}{
+		{&utils.RequestError{Status: http.StatusRequestEntityTooLarge, Msg: "too large"}, http.StatusRequestEntityTooLarge, 0},
+		{utils.ValidationErrors{{Field: "a", Rule: "required", Message: "a不能为空"}}, http.StatusBadRequest, 1},
+		{errors.New("other"), http.StatusBadRequest, 0},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		utils.WriteRequestError(rec, tt.err)
+		var body struct {
+			Code   int                `json:"code"`
+			Msg    string             `json:"msg"`
+			Errors []utils.FieldError `json:"errors"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatal(err)
+		}
+		if rec.Code != tt.status || body.Code != tt.status || len(body.Errors) != tt.errors || body.Msg == "" {
+			t.Errorf("WriteRequestError(%v) = %d %+v", tt.err, rec.Code, body)
+		}
+	}
+}
@@ -0,0 +1,214 @@
+package utils
+
+import (
+	"fmt"
+	"net/mail"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// FieldError 单个字段的校验错误
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// ValidationErrors 字段校验错误列表
+type ValidationErrors []FieldError
+
+// Error 实现error接口
+func (e ValidationErrors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, fe := range e {
+		msgs = append(msgs, fe.Message)
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Validate 按照结构体字段上的binding标签校验参数
+// 支持的规则: required, omitempty, min, max, len, oneof, email
+func Validate(v interface{}) error {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var errs ValidationErrors
+	validateStruct(rv, &errs)
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// validateStruct 递归校验结构体字段
+func validateStruct(rv reflect.Value, errs *ValidationErrors) {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		value := rv.Field(i)
+
+		// 嵌入结构体直接展开校验，类型未导出时字段同样会被JSON解析
+		if field.Anonymous {
+			if value.Kind() == reflect.Ptr && !value.IsNil() {
+				value = value.Elem()
+			}
+			if value.Kind() == reflect.Struct {
+				validateStruct(value, errs)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+
+		tag := field.Tag.Get("binding")
+		if tag == "" || tag == "-" {
+			continue
+		}
+
+		name := jsonFieldName(field)
+		rules := strings.Split(tag, ",")
+
+		// 指针字段为nil时视为零值，不为nil时表示请求中提供了该字段，指向零值也按其余规则校验
+		isPtr := value.Kind() == reflect.Ptr
+		if isPtr {
+			if value.IsNil() {
+				if hasRule(rules, "required") {
+					*errs = append(*errs, FieldError{Field: name, Rule: "required", Message: fmt.Sprintf("%s不能为空", name)})
+				}
+				continue
+			}
+			value = value.Elem()
+		}
+
+		if !isPtr && value.IsZero() {
+			if hasRule(rules, "required") {
+				*errs = append(*errs, FieldError{Field: name, Rule: "required", Message: fmt.Sprintf("%s不能为空", name)})
+				continue
+			}
+			if hasRule(rules, "omitempty") {
+				continue
+			}
+		}
+
+		for _, rule := range rules {
+			if fe, ok := checkRule(name, rule, value); !ok {
+				*errs = append(*errs, fe)
+				break
+			}
+		}
+	}
+}
+
+// checkRule 校验单条规则
+func checkRule(name, rule string, value reflect.Value) (FieldError, bool) {
+	key, param, _ := strings.Cut(rule, "=")
+	switch key {
+	case "required", "omitempty", "":
+		return FieldError{}, true
+	case "min", "max", "len":
+		limit, err := strconv.ParseFloat(param, 64)
+		if err != nil {
+			return FieldError{}, true
+		}
+		size, isLength := measure(value)
+		ok := true
+		switch key {
+		case "min":
+			ok = size >= limit
+		case "max":
+			ok = size <= limit
+		case "len":
+			ok = size == limit
+		}
+		if ok {
+			return FieldError{}, true
+		}
+		return FieldError{Field: name, Rule: key, Message: limitMessage(name, key, param, isLength)}, false
+	case "oneof":
+		options := strings.Fields(param)
+		actual := fmt.Sprint(value) // 来自未导出的嵌入结构体时不能调用Interface
+		for _, opt := range options {
+			if opt == actual {
+				return FieldError{}, true
+			}
+		}
+		return FieldError{Field: name, Rule: key, Message: fmt.Sprintf("%s必须是以下值之一: %s", name, strings.Join(options, ", "))}, false
+	case "email":
+		if value.Kind() == reflect.String {
+			if addr, err := mail.ParseAddress(value.String()); err == nil && addr.Address == value.String() {
+				return FieldError{}, true
+			}
+		}
+		return FieldError{Field: name, Rule: key, Message: fmt.Sprintf("%s不是有效的邮箱地址", name)}, false
+	}
+	return FieldError{}, true
+}
+
+// measure 返回字段用于比较的大小，字符串和切片返回长度
+func measure(value reflect.Value) (float64, bool) {
+	switch value.Kind() {
+	case reflect.String:
+		return float64(utf8.RuneCountInString(value.String())), true
+	case reflect.Slice, reflect.Map, reflect.Array:
+		return float64(value.Len()), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(value.Int()), false
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(value.Uint()), false
+	case reflect.Float32, reflect.Float64:
+		return value.Float(), false
+	}
+	return 0, false
+}
+
+// limitMessage 生成min/max/len规则的错误信息
+func limitMessage(name, key, param string, isLength bool) string {
+	if isLength {
+		switch key {
+		case "min":
+			return fmt.Sprintf("%s长度不能少于%s", name, param)
+		case "max":
+			return fmt.Sprintf("%s长度不能超过%s", name, param)
+		default:
+			return fmt.Sprintf("%s长度必须为%s", name, param)
+		}
+	}
+	switch key {
+	case "min":
+		return fmt.Sprintf("%s不能小于%s", name, param)
+	case "max":
+		return fmt.Sprintf("%s不能大于%s", name, param)
+	default:
+		return fmt.Sprintf("%s必须等于%s", name, param)
+	}
+}
+
+// hasRule 判断规则列表中是否包含指定规则
+func hasRule(rules []string, rule string) bool {
+	for _, r := range rules {
+		if r == rule {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonFieldName 获取字段在JSON中的名称
+func jsonFieldName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "" || name == "-" {
+		return field.Name
+	}
+	return name
+}
@@ -0,0 +1,139 @@
+package utils_test
+
+import (
+	"errors"
+	"testing"
+
+	"online-education-api/utils"
+)
+
+type pageParams struct {
+	Page int    `json:"page" binding:"omitempty,min=1"`
+	Sort string `json:"sort" binding:"omitempty,oneof=asc desc"`
+}
+
+type Filter struct {
+	Keyword string `json:"keyword" binding:"max=3"`
+}
+
+type validated struct {
+	pageParams
+	*Filter
+	Name     string   `json:"name" binding:"required,min=2,max=4"`
+	Nickname string   `json:"nickname" binding:"omitempty,min=2"`
+	Age      int      `json:"age" binding:"min=0,max=150"`
+	Score    float64  `json:"score" binding:"omitempty,max=9.5"`
+	Tags     []string `json:"tags" binding:"max=2"`
+	Code     string   `json:"code" binding:"omitempty,len=3"`
+	Role     string   `json:"role" binding:"omitempty,oneof=student teacher"`
+	Level    int      `json:"level" binding:"omitempty,oneof=1 2"`
+	Email    string   `json:"email" binding:"omitempty,email"`
+	Parent   *int     `json:"parent" binding:"omitempty,min=1"`
+	Owner    *string  `json:"owner" binding:"required"`
+	Ignored  string   `json:"-" binding:"-"`
+	NoJSON   string   `binding:"omitempty,max=1"`
+	internal string
+}
+
+func intPtr(v int) *int          { return &v }
+func stringPtr(v string) *string { return &v }
+
+// valid 返回通过校验的参数，用例在此基础上修改一个字段
+func valid() validated {
+	return validated{Name: "张三", Age: 20, Owner: stringPtr("")}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name  string
+		edit  func(v *validated)
+		field string
+		rule  string
+	}{
+		{"valid", func(v *validated) {}, "", ""},
+		{"required zero string", func(v *validated) { v.Name = "" }, "name", "required"},
+		{"required nil pointer", func(v *validated) { v.Owner = nil }, "owner", "required"},
+		{"required pointer to zero value is set", func(v *validated) { v.Owner = stringPtr("") }, "", ""},
+		{"omitempty skips zero", func(v *validated) { v.Nickname = "" }, "", ""},
+		{"omitempty checks non-zero", func(v *validated) { v.Nickname = "a" }, "nickname", "min"},
+		{"omitempty nil pointer", func(v *validated) { v.Parent = nil }, "", ""},
+		{"pointer to valid element", func(v *validated) { v.Parent = intPtr(1) }, "", ""},
+		{"pointer to zero is checked", func(v *validated) { v.Parent = intPtr(0) }, "parent", "min"},
+		{"pointer element below min", func(v *validated) { v.Parent = intPtr(-1) }, "parent", "min"},
+		{"min counts runes", func(v *validated) { v.Name = "张" }, "name", "min"},
+		{"max counts runes", func(v *validated) { v.Name = "张三李四" }, "", ""},
+		{"max over rune length", func(v *validated) { v.Name = "张三李四王" }, "name", "max"},
+		{"max number", func(v *validated) { v.Age = 151 }, "age", "max"},
+		{"min number", func(v *validated) { v.Age = -1 }, "age", "min"},
+		{"max float", func(v *validated) { v.Score = 9.6 }, "score", "max"},
+		{"max slice length", func(v *validated) { v.Tags = []string{"a", "b", "c"} }, "tags", "max"},
+		{"len", func(v *validated) { v.Code = "ab" }, "code", "len"},
+		{"oneof string", func(v *validated) { v.Role = "teacher" }, "", ""},
+		{"oneof string mismatch", func(v *validated) { v.Role = "admin" }, "role", "oneof"},
+		{"oneof number", func(v *validated) { v.Level = 3 }, "level", "oneof"},
+		{"email", func(v *validated) { v.Email = "a@example.com" }, "", ""},
+		{"email invalid", func(v *validated) { v.Email = "a@" }, "email", "email"},
+		{"email with display name", func(v *validated) { v.Email = "A <a@example.com>" }, "email", "email"},
+		{"embedded struct", func(v *validated) { v.Page = -1 }, "page", "min"},
+		{"embedded struct oneof", func(v *validated) { v.Sort = "up" }, "sort", "oneof"},
+		{"embedded pointer", func(v *validated) { v.Filter = &Filter{Keyword: "long"} }, "keyword", "max"},
+		{"field without json tag", func(v *validated) { v.NoJSON = "ab" }, "NoJSON", "max"},
+		{"ignored fields", func(v *validated) { v.Ignored, v.internal = "anything", "anything" }, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := valid()
+			tt.edit(&v)
+			err := utils.Validate(&v)
+			if tt.field == "" {
+				if err != nil {
+					t.Fatalf("Validate = %v, want nil", err)
+				}
+				return
+			}
+			var errs utils.ValidationErrors
+			if !errors.As(err, &errs) || len(errs) != 1 {
+				t.Fatalf("Validate = %v, want one error", err)
+			}
+			if errs[0].Field != tt.field || errs[0].Rule != tt.rule || errs[0].Message == "" {
+				t.Errorf("error = %+v, want field %s rule %s", errs[0], tt.field, tt.rule)
+			}
+		})
+	}
+}
+
+func TestValidateReportsEveryField(t *testing.T) {
+	// 每个字段只报告第一条失败的规则
+	v := validated{Name: "", Age: 200, Email: "x"}
+	var errs utils.ValidationErrors
+	if err := utils.Validate(v); !errors.As(err, &errs) {
+		t.Fatalf("Validate = %v", err)
+	}
+	var fields []string
+	for _, fe := range errs {
+		fields = append(fields, fe.Field+":"+fe.Rule)
+	}
+	want := "name:required age:max email:email owner:required"
+	if got := join(fields); got != want {
+		t.Errorf("errors = %s, want %s", got, want)
+	}
+
+	var nilPtr *validated
+	if err := utils.Validate(nilPtr); err != nil {
+		t.Errorf("Validate(nil) = %v", err)
+	}
+	if err := utils.Validate(42); err != nil {
+		t.Errorf("Validate(non-struct) = %v", err)
+	}
+}
+
+func join(parts []string) string {
+	s := ""
+	for i, p := range parts {
+		if i > 0 {
+			s += " "
+		}
+		s += p
+	}
+	return s
+}